@@ -4,17 +4,24 @@ import (
 	"ForumService/internal/client"
 	"ForumService/internal/handlers"
 	"ForumService/internal/middleware"
+	"ForumService/internal/models"
 	"ForumService/internal/repository"
 	"ForumService/internal/service"
+	"ForumService/internal/storage"
+	"ForumService/pkg/config"
 	_"context"
 	"database/sql"
+	"flag"
 	"fmt"
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
 	"github.com/Luxtington/Shared/logger"
 	"net/http"
+	"os"
+	"os/signal"
 	"strconv"
-	_"time"
+	"syscall"
+	"time"
 	"github.com/gorilla/websocket"
 	_"github.com/golang/protobuf/proto"
 	_"github.com/golang/protobuf/ptypes/empty"
@@ -43,21 +50,12 @@ var upgrader = websocket.Upgrader{
 }
 
 func main() {
+	recountCounters := flag.Bool("recount-counters", false, "пересчитать счетчики тредов и постов и выйти")
+	flag.Parse()
+
 	logger.InitLogger()
 	log := logger.GetLogger()
-
-	// Подключение к базе данных
-	dsn := "host=localhost user=postgres password=postgres dbname=forum port=5432 sslmode=disable"
-	db, err := sql.Open("postgres", dsn)
-	if err != nil {
-		log.Fatal("Failed to connect to database", zap.Error(err))
-	}
-	defer db.Close()
-
-	// Проверка подключения
-	if err := db.Ping(); err != nil {
-		log.Fatal("Failed to ping database", zap.Error(err))
-	}
+	cfg := config.Load()
 
 	// Инициализация gRPC клиента для аутентификации
 	authClient, err := client.NewAuthClient("localhost:50051")
@@ -66,23 +64,118 @@ func main() {
 	}
 
 	// Инициализация репозиториев
-	threadRepo := repository.NewThreadRepository(db)
-	postRepo := repository.NewPostRepository(db)
-	commentRepo := repository.NewCommentRepository(db)
-	chatRepo := repository.NewChatRepository(db)
-	userRepo := repository.NewUserRepository(db)
+	var repos *repository.Repositories
+	var authHooks []middleware.AuthenticatedUserFunc
+	switch cfg.StorageBackend {
+	case config.StorageMemory:
+		log.Warn("Using in-memory storage, data will be lost on restart")
+		store := repository.NewMemoryStore()
+		repos = repository.NewMemoryRepositories(store)
+		// Пользователи живут в AuthService, поэтому заводим их в памяти при первом входе
+		authHooks = append(authHooks, store.EnsureUser)
+	case config.StoragePostgres:
+		// Подключение к базе данных
+		dsn := "host=localhost user=postgres password=postgres dbname=forum port=5432 sslmode=disable"
+		db, err := sql.Open("postgres", dsn)
+		if err != nil {
+			log.Fatal("Failed to connect to database", zap.Error(err))
+		}
+		defer db.Close()
+
+		// Проверка подключения
+		if err := db.Ping(); err != nil {
+			log.Fatal("Failed to ping database", zap.Error(err))
+		}
+		repos = repository.NewPostgresRepositories(db)
+	default:
+		log.Fatal("Unknown storage backend", zap.String("storage", cfg.StorageBackend))
+	}
+
+	// Починка разошедшихся счетчиков без запуска сервера
+	if *recountCounters {
+		recount, err := repos.Counters.RecountCounters()
+		if err != nil {
+			log.Fatal("Failed to recount counters", zap.Error(err))
+		}
+		log.Info("Counters recounted", zap.Int("threads_fixed", recount.Threads), zap.Int("posts_fixed", recount.Posts))
+		return
+	}
+	threadRepo := repos.Threads
+	postRepo := repos.Posts
+	commentRepo := repos.Comments
+	chatRepo := repos.Chat
+	userRepo := repos.Users
+	searchRepo := repos.Search
+	categoryRepo := repos.Categories
+	tagRepo := repos.Tags
+	voteRepo := repos.Votes
+	viewRepo := repos.Views
+
+	// Кэш чтения тредов и постов
+	var cacheStats handlers.CacheStatsProvider
+	if cfg.CacheEnabled {
+		repoCache := repository.NewRepositoryCache(repository.CacheConfig{Size: cfg.CacheSize, TTL: cfg.CacheTTL})
+		threadRepo = repoCache.Threads(threadRepo)
+		postRepo = repoCache.Posts(postRepo)
+		commentRepo = repoCache.Comments(commentRepo)
+		categoryRepo = repoCache.Categories(categoryRepo)
+		voteRepo = repoCache.Votes(voteRepo)
+		viewRepo = repoCache.Views(viewRepo)
+		cacheStats = repoCache
+	}
 
 	// Инициализация сервисов
 	postService := service.NewPostService(postRepo, commentRepo, threadRepo, userRepo)
-	commentService := service.NewCommentService(commentRepo, userRepo)
-	threadService := service.NewThreadService(threadRepo, postRepo, userRepo)
+	commentService := service.NewCommentService(commentRepo, postRepo, threadRepo, userRepo, cfg.CommentEditWindow)
+	threadService := service.NewThreadService(threadRepo, postRepo, userRepo, categoryRepo, tagRepo)
+	categoryService := service.NewCategoryService(categoryRepo, userRepo)
+	tagService := service.NewTagService(tagRepo, userRepo)
 	chatService := service.NewChatService(chatRepo)
+	searchService := service.NewSearchService(searchRepo)
+	voteService := service.NewVoteService(voteRepo, postRepo, commentRepo)
+	reactionService := service.NewReactionService(repos.Reactions, commentRepo, cfg.ReactionEmojis)
+	bookmarkService := service.NewBookmarkService(repos.Bookmarks, threadRepo, postRepo, categoryRepo)
+
+	// Инициализация Hub для веб-сокетов: через него же уведомления доходят до подключенных пользователей
+	hub := handlers.NewHub(chatRepo)
+	go hub.Run()
+	notificationService := service.NewNotificationService(repos.Subscriptions, repos.Notifications, repos.Mentions, postRepo, commentRepo, threadRepo, userRepo, categoryRepo, hub)
+	hub.WithNotifications(notificationService)
+
+	// Фоновые задачи останавливаются вместе с сервером
+	jobsCtx, stopJobs := context.WithCancel(context.Background())
+
+	// Просмотры копятся в памяти и сбрасываются в хранилище по тикеру и при остановке
+	viewService := service.NewViewService(viewRepo, cfg.ViewDedupWindow)
+	viewsDone := make(chan struct{})
+	go func() {
+		defer close(viewsDone)
+		viewService.Run(jobsCtx, cfg.ViewFlushInterval)
+	}()
+
+	// Загруженные файлы лежат на диске, непривязанные загрузки периодически удаляются
+	attachmentStorage, err := storage.NewLocalStorage(cfg.AttachmentsDir)
+	if err != nil {
+		log.Fatal("Failed to create attachment storage", zap.Error(err))
+	}
+	attachmentService := service.NewAttachmentService(repos.Attachments, attachmentStorage, cfg.AttachmentMaxSize, cfg.AttachmentOrphanTTL)
+	go attachmentService.Run(jobsCtx, cfg.AttachmentGCInterval)
+
+	pollService := service.NewPollService(repos.Polls, threadRepo, userRepo)
 
 	// Инициализация обработчиков
-	threadHandler := handlers.NewThreadHandler(threadService)
-	postHandler := handlers.NewPostHandler(postService)
-	commentHandler := handlers.NewCommentHandler(commentService)
-	chatHandler := handlers.NewChatHandler(chatService)
+	threadHandler := handlers.NewThreadHandler(threadService).WithReactions(reactionService).WithNotifications(notificationService).WithAttachments(attachmentService).WithPolls(pollService)
+	categoryHandler := handlers.NewCategoryHandler(categoryService)
+	tagHandler := handlers.NewTagHandler(tagService)
+	postHandler := handlers.NewPostHandler(postService).WithReactions(reactionService).WithNotifications(notificationService).WithAttachments(attachmentService).WithThreads(threadService)
+	voteHandler := handlers.NewVoteHandler(voteService).WithAccess(threadService, commentService)
+	commentHandler := handlers.NewCommentHandler(commentService).WithNotifications(notificationService).WithAttachments(attachmentService).WithThreads(threadService)
+	chatHandler := handlers.NewChatHandler(chatService).WithReactions(reactionService).WithNotifications(notificationService)
+	searchHandler := handlers.NewSearchHandler(searchService)
+	bookmarkHandler := handlers.NewBookmarkHandler(bookmarkService)
+	notificationHandler := handlers.NewNotificationHandler(notificationService)
+	metricsHandler := handlers.NewMetricsHandler(cacheStats)
+	attachmentHandler := handlers.NewAttachmentHandler(attachmentService, cfg.AttachmentMaxSize).WithAccess(threadService, commentService)
 
 	// Создание экземпляра Gin
 	r := gin.Default()
@@ -94,7 +187,11 @@ func main() {
 	config.AllowHeaders = []string{"Origin", "Content-Length", "Content-Type", "Authorization"}
 	r.Use(cors.New(config))
 
+	// Ошибки обработчиков переводятся в HTTP-ответы с машиночитаемым кодом
+	r.Use(middleware.ErrorHandler())
+
 	// Загрузка HTML шаблонов
+	r.SetFuncMap(handlers.TemplateFuncs())
 	r.LoadHTMLGlob("templates/*")
 	// Настройка статических файлов
 	r.Static("/static", "./static")
@@ -103,11 +200,10 @@ func main() {
 	r.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 
 	// Инициализация middleware для аутентификации
-	authMiddleware := middleware.AuthServiceMiddleware(authClient)
+	authMiddleware := middleware.AuthServiceMiddleware(authClient, authHooks...)
 
-	// Инициализация Hub для веб-сокетов
-	hub := handlers.NewHub(chatRepo)
-	go hub.Run()
+	reactionHandler := handlers.NewReactionHandler(reactionService, hub).WithAccess(threadService, commentService)
+	pollHandler := handlers.NewPollHandler(pollService, threadService, hub)
 
 	// Публичные маршруты
 	public := r.Group("/api")
@@ -116,14 +212,37 @@ func main() {
 		public.GET("/threads", threadHandler.GetAllThreads)
 		public.GET("/threads/:id", threadHandler.GetThreadWithPosts)
 		public.GET("/threads/:id/posts", threadHandler.GetThreadPosts)
+		public.GET("/threads/:id/poll", pollHandler.GetThreadPoll)
+
+		// Публичные маршруты для разделов
+		public.GET("/categories", categoryHandler.GetCategories)
+		public.GET("/categories/:id", categoryHandler.GetCategory)
+
+		// Теги
+		public.GET("/tags", tagHandler.GetTags)
 		
 		// Публичные маршруты для постов
 		public.GET("/posts", postHandler.GetAllPosts)
 		public.GET("/posts/:id", postHandler.GetPost)
 		public.GET("/posts/:id/comments", postHandler.GetPostComments)
+		public.GET("/posts/:id/revisions", postHandler.GetPostRevisions)
+		public.GET("/posts/:id/revisions/diff", postHandler.GetPostRevisionDiff)
 
 		// Маршруты для сообщений
 		public.GET("/chat", chatHandler.GetMessages)
+
+		// Доступные реакции
+		public.GET("/reactions", reactionHandler.GetReactionEmojis)
+
+		// Скачивание загруженных файлов
+		public.GET("/attachments/:id", attachmentHandler.GetAttachment)
+		public.GET("/attachments/:id/thumbnail", attachmentHandler.GetAttachmentThumbnail)
+
+		// Полнотекстовый поиск
+		public.GET("/search", searchHandler.Search)
+
+		// Метрики кэша
+		public.GET("/metrics/cache", metricsHandler.GetCacheStats)
 	}
 
 	// Группа защищенных маршрутов
@@ -134,16 +253,62 @@ func main() {
 	protected.POST("/threads", threadHandler.CreateThread)
 	protected.PUT("/threads/:id", threadHandler.UpdateThread)
 	protected.DELETE("/threads/:id", threadHandler.DeleteThread)
+	protected.PUT("/threads/:id/lock", threadHandler.LockThread)
+	protected.PUT("/threads/:id/pin", threadHandler.PinThread)
+	protected.PUT("/threads/:id/answer", threadHandler.AcceptAnswer)
+
+	// Управление разделами (только администраторы)
+	protected.POST("/categories", categoryHandler.CreateCategory)
+	protected.PUT("/categories/:id", categoryHandler.UpdateCategory)
+	protected.DELETE("/categories/:id", categoryHandler.DeleteCategory)
+
+	// Переименование и объединение тегов (модераторы и администраторы)
+	protected.PUT("/tags/:id", tagHandler.RenameTag)
+	protected.POST("/tags/:id/merge", tagHandler.MergeTag)
 
 	// Защищенные маршруты для постов
 	protected.POST("/posts", postHandler.CreatePost)
 	protected.PUT("/posts/:id", postHandler.UpdatePost)
 	protected.DELETE("/posts/:id", postHandler.DeletePost)
+	protected.PUT("/posts/:id/pin", postHandler.PinPost)
+
+	// Голоса за посты и комментарии
+	protected.POST("/posts/:id/vote", voteHandler.VotePost)
+	protected.DELETE("/posts/:id/vote", voteHandler.UnvotePost)
+	protected.POST("/comments/:id/vote", voteHandler.VoteComment)
+	protected.DELETE("/comments/:id/vote", voteHandler.UnvoteComment)
+
+	// Реакции на посты, комментарии и сообщения чата
+	protected.POST("/posts/:id/reactions", reactionHandler.ReactPost)
+	protected.POST("/comments/:id/reactions", reactionHandler.ReactComment)
+	protected.POST("/chat/:id/reactions", reactionHandler.ReactMessage)
+
+	// Голосование в опросах тредов
+	protected.POST("/polls/:id/vote", pollHandler.VotePoll)
+	protected.DELETE("/polls/:id/vote", pollHandler.RetractPollVote)
+	protected.POST("/polls/:id/close", pollHandler.ClosePoll)
+
+	// Закладки на треды и посты
+	protected.POST("/bookmarks", bookmarkHandler.AddBookmark)
+	protected.DELETE("/bookmarks", bookmarkHandler.RemoveBookmark)
+	protected.GET("/users/me/bookmarks", bookmarkHandler.GetMyBookmarks)
+
+	// Подписки и уведомления
+	protected.POST("/subscriptions", notificationHandler.Subscribe)
+	protected.DELETE("/subscriptions", notificationHandler.Unsubscribe)
+	protected.GET("/notifications", notificationHandler.GetNotifications)
+	protected.GET("/notifications/unread-count", notificationHandler.GetUnreadCount)
+	protected.PUT("/notifications/read-all", notificationHandler.MarkAllRead)
+	protected.PUT("/notifications/:id/read", notificationHandler.MarkRead)
 
 	// Маршруты для комментариев
 	protected.POST("/comments", commentHandler.CreateComment)
+	protected.PUT("/comments/:id", commentHandler.UpdateComment)
 	protected.DELETE("/comments/:id", commentHandler.DeleteComment)
 
+	// Загрузка файлов для постов и комментариев
+	protected.POST("/attachments", attachmentHandler.UploadAttachment)
+
 	// Маршруты для чата
 	protected.POST("/chat", chatHandler.CreateMessage)
 	//protected.GET("/chat", chatHandler.GetMessages)
@@ -207,7 +372,14 @@ func main() {
 		log.Info("Debug - User ID in /", zap.Int("user_id", userIDInt))
 		log.Info("Debug - User Role in /", zap.Any("role", userRole))
 
-		threads, err := threadService.GetAllThreads()
+		threads, err := threadService.GetAllThreads(handlers.ViewerRole(c))
+		if err != nil {
+			c.HTML(500, "error.html", gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+		categories, err := categoryService.GetCategoryTree(handlers.ViewerRole(c))
 		if err != nil {
 			c.HTML(500, "error.html", gin.H{
 				"error": err.Error(),
@@ -222,6 +394,7 @@ func main() {
 			"user_role": userRole,
 			"username": username,
 			"Threads":   threads,
+			"Sections":  handlers.BuildCategorySections(categories, threads, handlers.ViewerRole(c)),
 		})
 	})
 
@@ -237,10 +410,33 @@ func main() {
 		log.Info("Debug - User Role in /threads", zap.Any("role", userRole))
 		log.Info("Debug - Raw user role in /threads", zap.String("role", fmt.Sprintf("%q", userRole)))
 
-		threads, err := threadService.GetAllThreads()
+		// Фильтры по тегам (/threads?tag=go&match=any) и по разделу (/threads?category_id=ID)
+		tags, matchAll, err := handlers.TagFilter(c)
 		if err != nil {
-			c.HTML(500, "error.html", gin.H{
-				"error": err.Error(),
+			c.HTML(400, "bad_request.html", gin.H{
+				"error": "Неверный режим совпадения тегов",
+			})
+			return
+		}
+		var threads []*models.Thread
+		if len(tags) > 0 {
+			threads, err = threadService.GetThreadsByTags(tags, matchAll, handlers.ViewerRole(c))
+		} else if value := c.Query("category_id"); value != "" {
+			categoryID, convErr := strconv.Atoi(value)
+			if convErr != nil {
+				c.HTML(400, "bad_request.html", gin.H{
+					"error": "Неверный ID раздела",
+				})
+				return
+			}
+			threads, err = threadService.GetThreadsByCategory(categoryID, handlers.ViewerRole(c))
+		} else {
+			threads, err = threadService.GetAllThreads(handlers.ViewerRole(c))
+		}
+		if err != nil {
+			forumErr := middleware.ToForumError(err, "Ошибка при получении тредов")
+			c.HTML(forumErr.Code, "error.html", gin.H{
+				"error": forumErr.Message,
 			})
 			return
 		}
@@ -264,7 +460,7 @@ func main() {
 	})
 
 	// Получение конкретного треда с постами (HTML)
-	r.GET("/threads/:id", authMiddleware, func(c *gin.Context) {
+	r.GET("/threads/:id", authMiddleware, handlers.CountThreadView(viewService), func(c *gin.Context) {
 		user, _ := c.Get("user")
 		userID, _ := c.Get("user_id")
 		userRole, _ := c.Get("user_role")
@@ -289,6 +485,12 @@ func main() {
 			})
 			return
 		}
+		if err := threadService.CheckThreadAccess(thread, handlers.ViewerRole(c)); err != nil {
+			c.HTML(403, "error.html", gin.H{
+				"error": "Нет доступа к разделу треда",
+			})
+			return
+		}
 
 		// Преобразуем userID в int для корректного сравнения
 		var userIDInt int
@@ -296,6 +498,10 @@ func main() {
 			userIDInt = int(userID.(uint32))
 		}
 
+		if err := handlers.AttachThreadPoll(pollService, thread, userIDInt); err != nil {
+			log.Error("Ошибка при получении опроса треда", zap.Error(err))
+		}
+
 		log.Info("Debug - Thread Author ID", zap.Int("author_id", thread.AuthorID))
 		log.Info("Debug - User ID", zap.Int("user_id", userIDInt))
 		log.Info("Debug - User Role", zap.Any("role", userRole))
@@ -307,11 +513,12 @@ func main() {
 			"user_id":   userIDInt,
 			"user_role": userRole,
 			"username": username,
+			"bookmarked": handlers.IsBookmarked(bookmarkService, models.BookmarkTargetThread, thread.ID, userIDInt),
 		})
 	})
 
 	// Получение конкретного поста (HTML)
-	r.GET("/posts/:id", authMiddleware, func(c *gin.Context) {
+	r.GET("/posts/:id", authMiddleware, handlers.CountPostView(viewService), func(c *gin.Context) {
 		id, err := strconv.Atoi(c.Param("id"))
 		if err != nil {
 			c.HTML(400, "error.html", gin.H{
@@ -328,6 +535,12 @@ func main() {
 			})
 			return
 		}
+		if err := threadService.CheckThreadIDAccess(post.ThreadID, handlers.ViewerRole(c)); err != nil {
+			c.HTML(403, "error.html", gin.H{
+				"error": "Нет доступа к разделу треда",
+			})
+			return
+		}
 
 		// Получаем информацию о пользователе из контекста
 		user, _ := c.Get("user")
@@ -346,12 +559,62 @@ func main() {
 
 		// Добавляем флаг CanDelete для комментариев
 		for i := range comments {
+			if comments[i].Deleted {
+				continue
+			}
 			comments[i].CanDelete = comments[i].AuthorID == userIDInt || userRole == "admin"
+			comments[i].CanEdit = comments[i].AuthorID == userIDInt || userRole == "admin" || userRole == "moderator"
+		}
+
+		// Неизвестный порядок на странице не ошибка, комментарии просто идут по дате
+		order, err := handlers.SortOrder(c)
+		if err != nil {
+			order = service.SortDate
+		}
+		comments = service.SortComments(comments, order)
+
+		if err := handlers.AttachPostReactions(reactionService, []*models.Post{post}, userIDInt); err != nil {
+			log.Error("Ошибка при получении реакций поста", zap.Error(err))
+		}
+		if err := handlers.AttachCommentReactions(reactionService, comments, userIDInt); err != nil {
+			log.Error("Ошибка при получении реакций комментариев", zap.Error(err))
+		}
+		if err := handlers.AttachPostMentions(notificationService, []*models.Post{post}); err != nil {
+			log.Error("Ошибка при получении упоминаний поста", zap.Error(err))
+		}
+		if err := handlers.AttachCommentMentions(notificationService, comments); err != nil {
+			log.Error("Ошибка при получении упоминаний комментариев", zap.Error(err))
+		}
+		if err := handlers.AttachPostAttachments(attachmentService, []*models.Post{post}); err != nil {
+			log.Error("Ошибка при получении вложений поста", zap.Error(err))
+		}
+		if err := handlers.AttachCommentAttachments(attachmentService, comments); err != nil {
+			log.Error("Ошибка при получении вложений комментариев", zap.Error(err))
+		}
+
+		// История правок нужна для отметки "изменено"
+		revisions, err := postService.GetPostRevisions(id)
+		if err != nil {
+			log.Error("Ошибка при получении истории правок поста", zap.Error(err))
+		}
+
+		// Закрытый тред скрывает форму комментария и кнопки ответа
+		thread, err := postService.GetThreadByID(post.ThreadID)
+		if err != nil {
+			log.Error("Ошибка при получении треда поста", zap.Error(err))
 		}
 
 		c.HTML(200, "post.html", gin.H{
 			"post":      post,
 			"comments":  comments,
+			"revisions": revisions,
+			"thread":    thread,
+			"thread_locked": thread != nil && thread.Locked,
+			"post_accepted": thread != nil && thread.AcceptedPostID != nil && *thread.AcceptedPostID == post.ID,
+			"bookmarked": handlers.IsBookmarked(bookmarkService, models.BookmarkTargetPost, post.ID, userIDInt),
+			"max_comment_depth": service.MaxCommentDepth,
+			"sort":      order,
+			"reaction_emojis": reactionService.AllowedEmojis(),
 			"user":      user,
 			"user_id":   userIDInt,
 			"user_role": userRole,
@@ -359,6 +622,58 @@ func main() {
 		})
 	})
 
+	// История правок поста (HTML)
+	r.GET("/posts/:id/revisions", authMiddleware, func(c *gin.Context) {
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			c.HTML(400, "error.html", gin.H{
+				"error": "Неверный ID поста",
+			})
+			return
+		}
+
+		post, err := postService.GetPostByID(id)
+		if err != nil {
+			c.HTML(404, "error.html", gin.H{
+				"error": "Пост не найден",
+			})
+			return
+		}
+		if err := threadService.CheckThreadIDAccess(post.ThreadID, handlers.ViewerRole(c)); err != nil {
+			c.HTML(403, "error.html", gin.H{
+				"error": "Нет доступа к разделу треда",
+			})
+			return
+		}
+
+		revisions, err := postService.GetPostRevisions(id)
+		if err != nil {
+			log.Error("Ошибка при получении истории правок поста", zap.Error(err))
+			c.HTML(500, "error.html", gin.H{
+				"error": "Не удалось загрузить историю правок",
+			})
+			return
+		}
+
+		username, _ := c.Get("username")
+
+		c.HTML(200, "post_revisions.html", gin.H{
+			"post":      post,
+			"revisions": revisions,
+			"username":  username,
+		})
+	})
+
+	// Редактирование поста и разрешение конфликта правок (HTML)
+	r.GET("/posts/:id/edit", authMiddleware, postHandler.ShowEditForm)
+	r.GET("/posts/:id/conflict", authMiddleware, postHandler.ShowConflictPage)
+
+	// Страница поиска (HTML)
+	r.GET("/search", authMiddleware, searchHandler.ShowSearchPage)
+
+	// Профиль пользователя (HTML), на него ведут ссылки упоминаний
+	r.GET("/users/:id", handlers.NewUserHandler().ShowProfile)
+
 	// Запуск сервера
 	port := 8081
 	log.Info("Server is running", zap.Int("port", port))
@@ -367,7 +682,35 @@ func main() {
 		Handler: r,
 	}
 
-	if err := srv.ListenAndServe(); err != nil {
-		log.Fatal("Failed to start server", zap.Error(err))
+	// Ошибка запуска не завершает процесс сразу: сначала сохраняются накопленные просмотры
+	serverErr := make(chan error, 1)
+	go func() {
+		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			serverErr <- err
+		}
+	}()
+
+	// Ждем сигнала остановки или ошибки сервера, дожидаемся текущих запросов и сохраняем накопленные просмотры
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+	var failed bool
+	select {
+	case <-quit:
+		log.Info("Shutting down server")
+	case err := <-serverErr:
+		log.Error("Failed to start server", zap.Error(err))
+		failed = true
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		log.Error("Server shutdown failed", zap.Error(err))
+	}
+	stopJobs()
+	// Run делает последний сброс просмотров после остановки задач
+	<-viewsDone
+	if failed {
+		os.Exit(1)
 	}
 }
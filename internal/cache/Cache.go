@@ -0,0 +1,199 @@
+package cache
+
+import (
+	"container/list"
+	"errors"
+	"sync"
+	"time"
+)
+
+// errLoadPanicked получают ожидающие загрузки, если загрузчик завершился паникой
+var errLoadPanicked = errors.New("загрузка значения в кэш прервана паникой")
+
+// Stats - счетчики обращений к кэшу
+type Stats struct {
+	// Hits - значения, найденные в кэше
+	Hits uint64 `json:"hits"`
+	// Misses - промахи, для которых вызывалась загрузка
+	Misses uint64 `json:"misses"`
+	// Coalesced - промахи, дождавшиеся уже идущей загрузки того же ключа
+	Coalesced uint64 `json:"coalesced"`
+	// Evictions - записи, вытесненные по размеру или устаревшие по TTL
+	Evictions uint64 `json:"evictions"`
+	Size      int    `json:"size"`
+	Capacity  int    `json:"capacity"`
+	// HitRate - доля запросов, обслуженных без обращения к хранилищу
+	HitRate float64 `json:"hit_rate"`
+}
+
+type entry[K comparable, V any] struct {
+	key       K
+	value     V
+	expiresAt time.Time
+}
+
+// call - загрузка значения, которую ждут все конкурентные промахи по ключу
+type call[V any] struct {
+	done  chan struct{}
+	value V
+	err   error
+	// epoch - эпоха кэша на момент начала загрузки
+	epoch uint64
+}
+
+// Cache - LRU-кэш с ограничением размера и временем жизни записей.
+// Конкурентные промахи по одному ключу объединяются в одну загрузку.
+type Cache[K comparable, V any] struct {
+	mu       sync.Mutex
+	capacity int
+	ttl      time.Duration
+	now      func() time.Time
+
+	items    map[K]*list.Element
+	order    *list.List
+	inflight map[K]*call[V]
+	// epoch увеличивается при каждой инвалидации, чтобы загрузка, начатая
+	// до изменения данных, не положила в кэш устаревшее значение
+	epoch uint64
+
+	stats Stats
+}
+
+// New создает кэш на capacity записей со временем жизни ttl. Нулевой ttl - без устаревания.
+func New[K comparable, V any](capacity int, ttl time.Duration) *Cache[K, V] {
+	if capacity < 1 {
+		capacity = 1
+	}
+	return &Cache[K, V]{
+		capacity: capacity,
+		ttl:      ttl,
+		now:      time.Now,
+		items:    make(map[K]*list.Element),
+		order:    list.New(),
+		inflight: make(map[K]*call[V]),
+	}
+}
+
+// GetOrLoad возвращает значение из кэша или загружает его через load.
+// Ошибки загрузки не кэшируются.
+func (c *Cache[K, V]) GetOrLoad(key K, load func() (V, error)) (V, error) {
+	c.mu.Lock()
+	if value, ok := c.getLocked(key); ok {
+		c.stats.Hits++
+		c.mu.Unlock()
+		return value, nil
+	}
+	// К загрузке, начатой до инвалидации, не присоединяемся: она может вернуть
+	// значение до изменения, и чтение после записи его не увидит
+	if pending, ok := c.inflight[key]; ok && pending.epoch == c.epoch {
+		c.stats.Coalesced++
+		c.mu.Unlock()
+		<-pending.done
+		return pending.value, pending.err
+	}
+
+	c.stats.Misses++
+	pending := &call[V]{done: make(chan struct{}), epoch: c.epoch}
+	c.inflight[key] = pending
+	c.mu.Unlock()
+
+	c.load(key, pending, load)
+	return pending.value, pending.err
+}
+
+// load выполняет загрузку и будит ожидающих даже при панике в load
+func (c *Cache[K, V]) load(key K, pending *call[V], load func() (V, error)) {
+	completed := false
+	defer func() {
+		c.mu.Lock()
+		// После инвалидации ключ мог занять более новый вызов
+		if c.inflight[key] == pending {
+			delete(c.inflight, key)
+		}
+		if completed && pending.err == nil && pending.epoch == c.epoch {
+			c.setLocked(key, pending.value)
+		}
+		c.mu.Unlock()
+		if !completed {
+			pending.err = errLoadPanicked
+		}
+		close(pending.done)
+	}()
+
+	pending.value, pending.err = load()
+	completed = true
+}
+
+// Invalidate удаляет записи по ключам
+func (c *Cache[K, V]) Invalidate(keys ...K) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.epoch++
+	for _, key := range keys {
+		if element, ok := c.items[key]; ok {
+			c.order.Remove(element)
+			delete(c.items, key)
+		}
+	}
+}
+
+// Purge очищает кэш целиком
+func (c *Cache[K, V]) Purge() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.epoch++
+	c.items = make(map[K]*list.Element)
+	c.order.Init()
+}
+
+// Stats возвращает текущие счетчики кэша
+func (c *Cache[K, V]) Stats() Stats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	stats := c.stats
+	stats.Size = c.order.Len()
+	stats.Capacity = c.capacity
+	if total := stats.Hits + stats.Misses + stats.Coalesced; total > 0 {
+		stats.HitRate = float64(stats.Hits+stats.Coalesced) / float64(total)
+	}
+	return stats
+}
+
+func (c *Cache[K, V]) getLocked(key K) (V, bool) {
+	var zero V
+	element, ok := c.items[key]
+	if !ok {
+		return zero, false
+	}
+	item := element.Value.(*entry[K, V])
+	if c.ttl > 0 && !c.now().Before(item.expiresAt) {
+		c.order.Remove(element)
+		delete(c.items, key)
+		c.stats.Evictions++
+		return zero, false
+	}
+	c.order.MoveToFront(element)
+	return item.value, true
+}
+
+func (c *Cache[K, V]) setLocked(key K, value V) {
+	expiresAt := c.now().Add(c.ttl)
+	if element, ok := c.items[key]; ok {
+		item := element.Value.(*entry[K, V])
+		item.value = value
+		item.expiresAt = expiresAt
+		c.order.MoveToFront(element)
+		return
+	}
+
+	c.items[key] = c.order.PushFront(&entry[K, V]{key: key, value: value, expiresAt: expiresAt})
+	for c.order.Len() > c.capacity {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.items, oldest.Value.(*entry[K, V]).key)
+		c.stats.Evictions++
+	}
+}
@@ -0,0 +1,201 @@
+package cache
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func loadValue(value string) func() (string, error) {
+	return func() (string, error) {
+		return value, nil
+	}
+}
+
+func TestCache_GetOrLoad(t *testing.T) {
+	c := New[int, string](10, time.Minute)
+
+	loads := 0
+	load := func() (string, error) {
+		loads++
+		return "тред", nil
+	}
+
+	value, err := c.GetOrLoad(1, load)
+	require.NoError(t, err)
+	assert.Equal(t, "тред", value)
+
+	value, err = c.GetOrLoad(1, load)
+	require.NoError(t, err)
+	assert.Equal(t, "тред", value)
+	assert.Equal(t, 1, loads)
+
+	stats := c.Stats()
+	assert.Equal(t, uint64(1), stats.Hits)
+	assert.Equal(t, uint64(1), stats.Misses)
+	assert.Equal(t, 1, stats.Size)
+	assert.Equal(t, 0.5, stats.HitRate)
+}
+
+func TestCache_ErrorsAreNotCached(t *testing.T) {
+	c := New[int, string](10, time.Minute)
+
+	_, err := c.GetOrLoad(1, func() (string, error) {
+		return "", errors.New("db error")
+	})
+	assert.Error(t, err)
+
+	value, err := c.GetOrLoad(1, loadValue("тред"))
+	require.NoError(t, err)
+	assert.Equal(t, "тред", value)
+	assert.Equal(t, uint64(2), c.Stats().Misses)
+}
+
+func TestCache_LRUEviction(t *testing.T) {
+	c := New[int, string](2, 0)
+
+	c.GetOrLoad(1, loadValue("первый"))
+	c.GetOrLoad(2, loadValue("второй"))
+	// Обращение к ключу 1 делает ключ 2 самым давним
+	c.GetOrLoad(1, loadValue("первый"))
+	c.GetOrLoad(3, loadValue("третий"))
+
+	loaded := false
+	c.GetOrLoad(2, func() (string, error) {
+		loaded = true
+		return "второй", nil
+	})
+	assert.True(t, loaded, "ключ 2 должен быть вытеснен")
+	assert.Equal(t, 2, c.Stats().Size)
+	assert.Equal(t, uint64(2), c.Stats().Evictions)
+}
+
+func TestCache_TTL(t *testing.T) {
+	c := New[int, string](10, time.Minute)
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	c.now = func() time.Time { return now }
+
+	c.GetOrLoad(1, loadValue("старое"))
+	now = now.Add(2 * time.Minute)
+
+	value, err := c.GetOrLoad(1, loadValue("новое"))
+	require.NoError(t, err)
+	assert.Equal(t, "новое", value)
+	assert.Equal(t, uint64(1), c.Stats().Evictions)
+}
+
+func TestCache_Invalidate(t *testing.T) {
+	c := New[int, string](10, time.Minute)
+
+	c.GetOrLoad(1, loadValue("старое"))
+	c.GetOrLoad(2, loadValue("другое"))
+	c.Invalidate(1)
+
+	value, _ := c.GetOrLoad(1, loadValue("новое"))
+	assert.Equal(t, "новое", value)
+	assert.Equal(t, 2, c.Stats().Size)
+
+	c.Purge()
+	assert.Equal(t, 0, c.Stats().Size)
+}
+
+func TestCache_SingleFlight(t *testing.T) {
+	c := New[int, string](10, time.Minute)
+
+	var loads int32
+	release := make(chan struct{})
+	load := func() (string, error) {
+		atomic.AddInt32(&loads, 1)
+		<-release
+		return "тред", nil
+	}
+
+	const callers = 10
+	var started, finished sync.WaitGroup
+	started.Add(callers)
+	finished.Add(callers)
+	for i := 0; i < callers; i++ {
+		go func() {
+			defer finished.Done()
+			started.Done()
+			value, err := c.GetOrLoad(1, load)
+			assert.NoError(t, err)
+			assert.Equal(t, "тред", value)
+		}()
+	}
+	started.Wait()
+	// Даем горутинам дойти до ожидания загрузки
+	require.Eventually(t, func() bool {
+		stats := c.Stats()
+		return stats.Misses+stats.Coalesced == callers
+	}, time.Second, time.Millisecond)
+	close(release)
+	finished.Wait()
+
+	assert.Equal(t, int32(1), atomic.LoadInt32(&loads))
+	stats := c.Stats()
+	assert.Equal(t, uint64(1), stats.Misses)
+	assert.Equal(t, uint64(callers-1), stats.Coalesced)
+}
+
+func TestCache_InvalidateDuringLoad(t *testing.T) {
+	c := New[int, string](10, time.Minute)
+
+	value, err := c.GetOrLoad(1, func() (string, error) {
+		// Данные изменились, пока шла загрузка
+		c.Invalidate(1)
+		return "устаревшее", nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "устаревшее", value)
+
+	value, _ = c.GetOrLoad(1, loadValue("свежее"))
+	assert.Equal(t, "свежее", value, "результат загрузки, начатой до инвалидации, не кэшируется")
+}
+
+func TestCache_ReadAfterInvalidateSkipsStaleLoad(t *testing.T) {
+	c := New[int, string](10, time.Minute)
+
+	release := make(chan struct{})
+	staleDone := make(chan string)
+	go func() {
+		value, _ := c.GetOrLoad(1, func() (string, error) {
+			<-release
+			return "до записи", nil
+		})
+		staleDone <- value
+	}()
+	require.Eventually(t, func() bool { return c.Stats().Misses == 1 }, time.Second, time.Millisecond)
+
+	// Запись завершилась и инвалидировала ключ, пока первая загрузка еще идет
+	c.Invalidate(1)
+	value, err := c.GetOrLoad(1, loadValue("после записи"))
+	require.NoError(t, err)
+	assert.Equal(t, "после записи", value, "чтение после записи не присоединяется к старой загрузке")
+
+	close(release)
+	assert.Equal(t, "до записи", <-staleDone)
+
+	value, _ = c.GetOrLoad(1, loadValue("лишняя загрузка"))
+	assert.Equal(t, "после записи", value, "завершение старой загрузки не вытесняет свежее значение")
+	assert.Equal(t, uint64(0), c.Stats().Coalesced)
+}
+
+func TestCache_LoadPanicReleasesWaiters(t *testing.T) {
+	c := New[int, string](10, time.Minute)
+
+	assert.Panics(t, func() {
+		c.GetOrLoad(1, func() (string, error) {
+			panic("boom")
+		})
+	})
+
+	value, err := c.GetOrLoad(1, loadValue("тред"))
+	require.NoError(t, err)
+	assert.Equal(t, "тред", value)
+}
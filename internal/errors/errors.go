@@ -5,6 +5,7 @@ import "fmt"
 // ForumError представляет базовую структуру для всех ошибок форума
 type ForumError struct {
 	Code    int    // HTTP статус код
+	Type    string // Машиночитаемый код ошибки, не меняется между версиями API
 	Message string // Сообщение об ошибке
 	Err     error  // Вложенная ошибка
 }
@@ -16,22 +17,33 @@ func (e *ForumError) Error() string {
 	return e.Message
 }
 
-// Константы для типов ошибок
+func (e *ForumError) Unwrap() error {
+	return e.Err
+}
+
+// Константы для типов ошибок. Значения отдаются клиентам в поле "code"
+// и должны оставаться стабильными.
 const (
-	ErrNotFound          = "not_found error"
-	ErrUnauthorized      = "unauthorized error"
-	ErrForbidden         = "forbidden error"
-	ErrBadRequest        = "bad_request error"
-	ErrInternalServer    = "internal_server_error"
+	ErrNotFound          = "not_found"
+	ErrUnauthorized      = "unauthorized"
+	ErrForbidden         = "forbidden"
+	ErrBadRequest        = "bad_request"
+	ErrInternalServer    = "internal_error"
 	ErrValidation        = "validation_error"
-	ErrDuplicate         = "duplicate_error"
-	ErrPermissionDenied  = "permission_denied error"
+	ErrDuplicate         = "duplicate"
+	ErrPermissionDenied  = "permission_denied"
+	ErrPreconditionFailed = "precondition_failed"
+	ErrThreadLocked      = "thread_locked"
+	ErrPayloadTooLarge   = "payload_too_large"
+	ErrUnsupportedMedia  = "unsupported_media_type"
+	ErrPollClosed        = "poll_closed"
 )
 
 // Функции-конструкторы для создания ошибок
 func NewNotFoundError(message string, err error) *ForumError {
 	return &ForumError{
 		Code:    404,
+		Type:    ErrNotFound,
 		Message: message,
 		Err:     err,
 	}
@@ -40,6 +52,7 @@ func NewNotFoundError(message string, err error) *ForumError {
 func NewUnauthorizedError(message string, err error) *ForumError {
 	return &ForumError{
 		Code:    401,
+		Type:    ErrUnauthorized,
 		Message: message,
 		Err:     err,
 	}
@@ -48,6 +61,7 @@ func NewUnauthorizedError(message string, err error) *ForumError {
 func NewForbiddenError(message string, err error) *ForumError {
 	return &ForumError{
 		Code:    403,
+		Type:    ErrForbidden,
 		Message: message,
 		Err:     err,
 	}
@@ -56,6 +70,7 @@ func NewForbiddenError(message string, err error) *ForumError {
 func NewBadRequestError(message string, err error) *ForumError {
 	return &ForumError{
 		Code:    400,
+		Type:    ErrBadRequest,
 		Message: message,
 		Err:     err,
 	}
@@ -64,6 +79,7 @@ func NewBadRequestError(message string, err error) *ForumError {
 func NewInternalServerError(message string, err error) *ForumError {
 	return &ForumError{
 		Code:    500,
+		Type:    ErrInternalServer,
 		Message: message,
 		Err:     err,
 	}
@@ -72,6 +88,7 @@ func NewInternalServerError(message string, err error) *ForumError {
 func NewValidationError(message string, err error) *ForumError {
 	return &ForumError{
 		Code:    400,
+		Type:    ErrValidation,
 		Message: message,
 		Err:     err,
 	}
@@ -80,6 +97,7 @@ func NewValidationError(message string, err error) *ForumError {
 func NewDuplicateError(message string, err error) *ForumError {
 	return &ForumError{
 		Code:    409,
+		Type:    ErrDuplicate,
 		Message: message,
 		Err:     err,
 	}
@@ -88,7 +106,57 @@ func NewDuplicateError(message string, err error) *ForumError {
 func NewPermissionDeniedError(message string, err error) *ForumError {
 	return &ForumError{
 		Code:    403,
+		Type:    ErrPermissionDenied,
+		Message: message,
+		Err:     err,
+	}
+}
+
+func NewPreconditionFailedError(message string, err error) *ForumError {
+	return &ForumError{
+		Code:    412,
+		Type:    ErrPreconditionFailed,
+		Message: message,
+		Err:     err,
+	}
+}
+
+// NewThreadLockedError - попытка добавить пост или комментарий в закрытый тред
+func NewThreadLockedError(message string, err error) *ForumError {
+	return &ForumError{
+		Code:    423,
+		Type:    ErrThreadLocked,
+		Message: message,
+		Err:     err,
+	}
+}
+
+// NewPayloadTooLargeError - загружаемый файл больше допустимого размера
+func NewPayloadTooLargeError(message string, err error) *ForumError {
+	return &ForumError{
+		Code:    413,
+		Type:    ErrPayloadTooLarge,
+		Message: message,
+		Err:     err,
+	}
+}
+
+// NewUnsupportedMediaTypeError - тип загружаемого файла не разрешен
+func NewUnsupportedMediaTypeError(message string, err error) *ForumError {
+	return &ForumError{
+		Code:    415,
+		Type:    ErrUnsupportedMedia,
 		Message: message,
 		Err:     err,
 	}
-} 
\ No newline at end of file
+}
+
+// NewPollClosedError - голос в опросе, голосование в котором завершено
+func NewPollClosedError(message string, err error) *ForumError {
+	return &ForumError{
+		Code:    409,
+		Type:    ErrPollClosed,
+		Message: message,
+		Err:     err,
+	}
+}
@@ -0,0 +1,263 @@
+package handlers
+
+import (
+	"ForumService/internal/errors"
+	"ForumService/internal/middleware"
+	"ForumService/internal/models"
+	"ForumService/internal/service"
+	stdErrors "errors"
+	"mime"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// multipartOverhead - запас на заголовки и границы multipart-запроса сверх размера файла
+const multipartOverhead = 64 << 10
+
+type AttachmentHandler struct {
+	service  service.AttachmentService
+	maxSize  int64
+	threads  service.ThreadService
+	comments service.CommentService
+}
+
+// NewAttachmentHandler создает обработчик загрузок; maxSize - наибольший размер файла в байтах
+func NewAttachmentHandler(service service.AttachmentService, maxSize int64) *AttachmentHandler {
+	return &AttachmentHandler{service: service, maxSize: maxSize}
+}
+
+// WithAccess отдает вложения постов и комментариев только пользователям, которые могут
+// читать раздел треда. comments нужен, чтобы найти пост комментария.
+func (h *AttachmentHandler) WithAccess(threads service.ThreadService, comments service.CommentService) *AttachmentHandler {
+	h.threads = threads
+	h.comments = comments
+	return h
+}
+
+// UploadAttachment godoc
+// @Summary Загрузить файл
+// @Description Загружает картинку, текстовый лог, PDF или архив. Тип файла определяется по содержимому,
+// @Description для картинок создается миниатюра. Загрузка ни к чему не привязана: ее ID передают
+// @Description в attachment_ids при создании или правке поста или комментария. Непривязанные загрузки
+// @Description удаляются через ATTACHMENT_ORPHAN_TTL.
+// @Tags attachments
+// @Accept multipart/form-data
+// @Produce json
+// @Param file formData file true "Файл"
+// @Success 201 {object} models.Attachment
+// @Failure 400 {object} map[string]string "нет файла или файл пуст"
+// @Failure 401 {object} map[string]string "пользователь не аутентифицирован"
+// @Failure 413 {object} map[string]string "файл слишком большой"
+// @Failure 415 {object} map[string]string "тип файла не разрешен"
+// @Router /attachments [post]
+func (h *AttachmentHandler) UploadAttachment(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.Error(errors.NewUnauthorizedError("Пользователь не аутентифицирован", nil))
+		return
+	}
+
+	// Лимит на тело запроса не дает записать на диск временный файл больше допустимого
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, h.maxSize+multipartOverhead)
+	header, err := c.FormFile("file")
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if stdErrors.As(err, &tooLarge) {
+			c.Error(middleware.ToForumError(service.ErrAttachmentTooLarge, ""))
+			return
+		}
+		c.Error(errors.NewBadRequestError("Файл не передан", err))
+		return
+	}
+	file, err := header.Open()
+	if err != nil {
+		c.Error(errors.NewBadRequestError("Файл не передан", err))
+		return
+	}
+	defer file.Close()
+
+	attachment, err := h.service.Upload(int(userID.(uint32)), header.Filename, file)
+	if err != nil {
+		c.Error(middleware.ToForumError(err, "Ошибка при загрузке файла"))
+		return
+	}
+	fillAttachmentURL(attachment)
+
+	c.JSON(http.StatusCreated, attachment)
+}
+
+// GetAttachment godoc
+// @Summary Скачать файл
+// @Description Отдает загруженный файл. Картинки и текст показываются в браузере, остальные файлы скачиваются.
+// @Description Поддерживает запросы диапазонов и If-Modified-Since. Файл, еще не привязанный к посту
+// @Description или комментарию, доступен только загрузившему его пользователю.
+// @Tags attachments
+// @Produce octet-stream
+// @Param id path int true "ID вложения"
+// @Success 200 {file} file "содержимое файла"
+// @Failure 400 {object} map[string]string "неверный ID вложения"
+// @Failure 403 {object} map[string]string "нет прав на чтение раздела треда"
+// @Failure 404 {object} map[string]string "вложение не найдено или не привязано и загружено другим пользователем"
+// @Router /attachments/{id} [get]
+func (h *AttachmentHandler) GetAttachment(c *gin.Context) {
+	h.serve(c, false)
+}
+
+// GetAttachmentThumbnail godoc
+// @Summary Получить миниатюру картинки
+// @Description Отдает уменьшенную копию картинки в JPEG. У файлов, которые не картинки, миниатюры нет.
+// @Tags attachments
+// @Produce jpeg
+// @Param id path int true "ID вложения"
+// @Success 200 {file} file "миниатюра"
+// @Failure 400 {object} map[string]string "неверный ID вложения"
+// @Failure 403 {object} map[string]string "нет прав на чтение раздела треда"
+// @Failure 404 {object} map[string]string "вложение или миниатюра не найдены"
+// @Router /attachments/{id}/thumbnail [get]
+func (h *AttachmentHandler) GetAttachmentThumbnail(c *gin.Context) {
+	h.serve(c, true)
+}
+
+// serve отдает файл с заголовками, которые не дают браузеру исполнить его как страницу
+// нашего сайта: тип берется из сохраненного, а не угадывается, а CSP запрещает скрипты
+func (h *AttachmentHandler) serve(c *gin.Context, thumbnail bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(errors.NewBadRequestError("Неверный ID вложения", err))
+		return
+	}
+
+	attachment, err := h.service.Get(id)
+	if err != nil {
+		c.Error(middleware.ToForumError(err, "Ошибка при получении вложения"))
+		return
+	}
+	if err := h.checkAccess(c, attachment); err != nil {
+		c.Error(middleware.ToForumError(err, "Ошибка при получении вложения"))
+		return
+	}
+	file, err := h.service.Open(attachment, thumbnail)
+	if err != nil {
+		c.Error(middleware.ToForumError(err, "Ошибка при открытии файла"))
+		return
+	}
+	defer file.Close()
+
+	contentType := attachment.ContentType
+	filename := attachment.Filename
+	if thumbnail {
+		contentType = "image/jpeg"
+		filename = strings.TrimSuffix(filename, path.Ext(filename)) + "-thumbnail.jpg"
+	}
+	disposition := "attachment"
+	if thumbnail || attachment.IsImage() || strings.HasPrefix(contentType, "text/plain") {
+		disposition = "inline"
+	}
+
+	header := c.Writer.Header()
+	header.Set("Content-Type", contentType)
+	header.Set("Content-Disposition", mime.FormatMediaType(disposition, map[string]string{"filename": filename}))
+	header.Set("X-Content-Type-Options", "nosniff")
+	header.Set("Content-Security-Policy", "default-src 'none'; style-src 'unsafe-inline'; sandbox")
+	// Файл под ключом никогда не меняется, поэтому кэшировать его можно бессрочно, но только
+	// в браузере: вложения из закрытых разделов не должны оседать в общих кэшах
+	header.Set("Cache-Control", "private, max-age=31536000, immutable")
+	http.ServeContent(c.Writer, c.Request, "", attachment.CreatedAt, file)
+}
+
+// checkAccess проверяет права на чтение раздела треда, к посту или комментарию которого
+// прикреплено вложение. Непривязанную загрузку отдает только загрузившему ее: вложения
+// удаленных постов и комментариев тоже отвязываются и не должны открываться всем по ID.
+func (h *AttachmentHandler) checkAccess(c *gin.Context, attachment *models.Attachment) error {
+	if attachment.PostID == nil && attachment.CommentID == nil {
+		if userID := viewerID(c); userID == 0 || userID != attachment.UploaderID {
+			return service.ErrAttachmentNotFound
+		}
+		return nil
+	}
+	if h.threads == nil {
+		return nil
+	}
+	postID := attachment.PostID
+	if attachment.CommentID != nil {
+		comment, err := h.comments.GetCommentByID(*attachment.CommentID)
+		if err != nil {
+			return err
+		}
+		postID = &comment.PostID
+	}
+	return h.threads.CheckPostAccess(*postID, ViewerRole(c))
+}
+
+// fillAttachmentURL заполняет ссылки на файл вложения и его миниатюру
+func fillAttachmentURL(attachment *models.Attachment) {
+	attachment.URL = "/api/attachments/" + strconv.Itoa(attachment.ID)
+	if attachment.ThumbnailKey != "" {
+		attachment.ThumbnailURL = attachment.URL + "/thumbnail"
+	}
+}
+
+// AttachPostAttachments заполняет вложения постов со ссылками на файлы
+func AttachPostAttachments(attachments service.AttachmentService, posts []*models.Post) error {
+	if attachments == nil || len(posts) == 0 {
+		return nil
+	}
+	ids := make([]int, len(posts))
+	for i, post := range posts {
+		ids[i] = post.ID
+	}
+	byPost, err := attachments.ForTargets(models.AttachmentTargetPost, ids)
+	if err != nil {
+		return err
+	}
+	for _, post := range posts {
+		post.Attachments = byPost[post.ID]
+		for i := range post.Attachments {
+			fillAttachmentURL(&post.Attachments[i])
+		}
+	}
+	return nil
+}
+
+// AttachCommentAttachments заполняет вложения комментариев со ссылками на файлы
+func AttachCommentAttachments(attachments service.AttachmentService, comments []models.Comment) error {
+	if attachments == nil || len(comments) == 0 {
+		return nil
+	}
+	ids := make([]int, len(comments))
+	for i, comment := range comments {
+		ids[i] = comment.ID
+	}
+	byComment, err := attachments.ForTargets(models.AttachmentTargetComment, ids)
+	if err != nil {
+		return err
+	}
+	for i := range comments {
+		comments[i].Attachments = byComment[comments[i].ID]
+		for j := range comments[i].Attachments {
+			fillAttachmentURL(&comments[i].Attachments[j])
+		}
+	}
+	return nil
+}
+
+// checkAttachments проверяет вложения из запроса до сохранения записи.
+// ids == nil означает, что вложения записи не меняются.
+func checkAttachments(attachments service.AttachmentService, targetType string, targetID, userID int, ids []int) error {
+	if attachments == nil || ids == nil {
+		return nil
+	}
+	return attachments.CheckLink(targetType, targetID, userID, ids)
+}
+
+// linkAttachments прикрепляет вложения из запроса к сохраненной записи
+func linkAttachments(attachments service.AttachmentService, targetType string, targetID, userID int, ids []int) error {
+	if attachments == nil || ids == nil {
+		return nil
+	}
+	return attachments.Link(targetType, targetID, userID, ids)
+}
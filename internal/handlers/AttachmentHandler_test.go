@@ -0,0 +1,338 @@
+package handlers
+
+import (
+	"ForumService/internal/handlers/mocks"
+	"ForumService/internal/middleware"
+	"ForumService/internal/models"
+	"ForumService/internal/service"
+	"bytes"
+	"encoding/json"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// seekCloser - файл из хранилища в памяти
+type seekCloser struct {
+	*strings.Reader
+}
+
+func (seekCloser) Close() error { return nil }
+
+func setupAttachmentTestRouter(userID uint32) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(middleware.ErrorHandler())
+	router.Use(func(c *gin.Context) {
+		if userID != 0 {
+			c.Set("user_id", userID)
+		}
+		c.Next()
+	})
+	return router
+}
+
+func multipartFile(t *testing.T, field, filename string, content []byte) (*bytes.Buffer, string) {
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	part, err := writer.CreateFormFile(field, filename)
+	require.NoError(t, err)
+	_, err = part.Write(content)
+	require.NoError(t, err)
+	require.NoError(t, writer.Close())
+	return &body, writer.FormDataContentType()
+}
+
+func TestAttachmentHandler_UploadAttachment(t *testing.T) {
+	tests := []struct {
+		name           string
+		userID         uint32
+		field          string
+		content        []byte
+		err            error
+		expectedStatus int
+	}{
+		{"успешно", 1, "file", []byte("лог"), nil, http.StatusCreated},
+		{"не аутентифицирован", 0, "file", []byte("лог"), nil, http.StatusUnauthorized},
+		{"без файла", 1, "other", []byte("лог"), nil, http.StatusBadRequest},
+		{"тело больше лимита", 1, "file", bytes.Repeat([]byte("a"), 2048+multipartOverhead), nil, http.StatusRequestEntityTooLarge},
+		{"запрещенный тип", 1, "file", []byte("<html></html>"), service.ErrAttachmentType, http.StatusUnsupportedMediaType},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := &mocks.MockAttachmentService{
+				UploadFunc: func(uploaderID int, filename string, content io.Reader) (*models.Attachment, error) {
+					if tt.err != nil {
+						return nil, tt.err
+					}
+					data, err := io.ReadAll(content)
+					require.NoError(t, err)
+					assert.Equal(t, tt.content, data)
+					return &models.Attachment{ID: 7, UploaderID: uploaderID, Filename: filename, ContentType: "image/png", StorageKey: "k", ThumbnailKey: "k-thumb"}, nil
+				},
+			}
+			router := setupAttachmentTestRouter(tt.userID)
+			router.POST("/attachments", NewAttachmentHandler(mockService, 1024).UploadAttachment)
+
+			body, contentType := multipartFile(t, tt.field, "screen.png", tt.content)
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("POST", "/attachments", body)
+			req.Header.Set("Content-Type", contentType)
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedStatus != http.StatusCreated {
+				return
+			}
+			var response map[string]interface{}
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+			assert.Equal(t, float64(7), response["id"])
+			assert.Equal(t, "screen.png", response["filename"])
+			assert.Equal(t, "/api/attachments/7", response["url"])
+			assert.Equal(t, "/api/attachments/7/thumbnail", response["thumbnail_url"])
+			assert.NotContains(t, response, "storage_key", "ключ хранилища не отдается клиентам")
+		})
+	}
+}
+
+func TestAttachmentHandler_GetAttachment(t *testing.T) {
+	createdAt := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	attachments := map[int]*models.Attachment{
+		1: {ID: 1, UploaderID: 3, Filename: "скриншот.png", ContentType: "image/png", StorageKey: "k1", ThumbnailKey: "k1-thumb", CreatedAt: createdAt},
+		2: {ID: 2, UploaderID: 3, Filename: "report.pdf", ContentType: "application/pdf", StorageKey: "k2", CreatedAt: createdAt},
+	}
+	mockService := &mocks.MockAttachmentService{
+		GetFunc: func(id int) (*models.Attachment, error) {
+			if attachment, ok := attachments[id]; ok {
+				return attachment, nil
+			}
+			return nil, service.ErrAttachmentNotFound
+		},
+		OpenFunc: func(attachment *models.Attachment, thumbnail bool) (io.ReadSeekCloser, error) {
+			if thumbnail && attachment.ThumbnailKey == "" {
+				return nil, service.ErrAttachmentNotFound
+			}
+			if thumbnail {
+				return seekCloser{strings.NewReader("миниатюра")}, nil
+			}
+			return seekCloser{strings.NewReader("содержимое " + attachment.Filename)}, nil
+		},
+	}
+	handler := NewAttachmentHandler(mockService, 1024)
+	router := setupAttachmentTestRouter(3)
+	router.GET("/attachments/:id", handler.GetAttachment)
+	router.GET("/attachments/:id/thumbnail", handler.GetAttachmentThumbnail)
+
+	get := func(url string, headers map[string]string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", url, nil)
+		for name, value := range headers {
+			req.Header.Set(name, value)
+		}
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := get("/attachments/1", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "содержимое скриншот.png", w.Body.String())
+	assert.Equal(t, "image/png", w.Header().Get("Content-Type"))
+	assert.Equal(t, "nosniff", w.Header().Get("X-Content-Type-Options"))
+	assert.Equal(t, "inline; filename*=utf-8''%D1%81%D0%BA%D1%80%D0%B8%D0%BD%D1%88%D0%BE%D1%82.png", w.Header().Get("Content-Disposition"))
+	assert.Contains(t, w.Header().Get("Content-Security-Policy"), "sandbox")
+	assert.Contains(t, w.Header().Get("Cache-Control"), "immutable")
+	assert.Equal(t, createdAt.Format(http.TimeFormat), w.Header().Get("Last-Modified"))
+
+	w = get("/attachments/2", map[string]string{"Range": "bytes=0-9"})
+	assert.Equal(t, http.StatusPartialContent, w.Code)
+	assert.Equal(t, "содержимое"[:10], w.Body.String())
+	assert.Equal(t, "application/pdf", w.Header().Get("Content-Type"))
+	assert.Equal(t, `attachment; filename=report.pdf`, w.Header().Get("Content-Disposition"))
+
+	w = get("/attachments/2", map[string]string{"If-Modified-Since": createdAt.Format(http.TimeFormat)})
+	assert.Equal(t, http.StatusNotModified, w.Code)
+
+	w = get("/attachments/1/thumbnail", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "image/jpeg", w.Header().Get("Content-Type"))
+	assert.Contains(t, w.Header().Get("Content-Disposition"), "thumbnail.jpg")
+
+	assert.Equal(t, http.StatusNotFound, get("/attachments/2/thumbnail", nil).Code)
+	assert.Equal(t, http.StatusNotFound, get("/attachments/3", nil).Code)
+	assert.Equal(t, http.StatusBadRequest, get("/attachments/abc", nil).Code)
+}
+
+func TestAttachmentHandler_GetAttachment_Unlinked(t *testing.T) {
+	mockService := &mocks.MockAttachmentService{
+		GetFunc: func(id int) (*models.Attachment, error) {
+			// Загрузка не привязана: ее еще не прикрепили или пост с ней удален
+			return &models.Attachment{ID: id, UploaderID: 3, Filename: "a.png", ContentType: "image/png"}, nil
+		},
+		OpenFunc: func(attachment *models.Attachment, thumbnail bool) (io.ReadSeekCloser, error) {
+			return seekCloser{strings.NewReader("содержимое")}, nil
+		},
+	}
+
+	for _, tt := range []struct {
+		name   string
+		userID uint32
+		code   int
+	}{
+		{"загрузивший", 3, http.StatusOK},
+		{"другой пользователь", 4, http.StatusNotFound},
+		{"гость", 0, http.StatusNotFound},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := NewAttachmentHandler(mockService, 1024).WithAccess(&mocks.MockThreadService{}, nil)
+			router := setupAttachmentTestRouter(tt.userID)
+			router.GET("/attachments/:id", handler.GetAttachment)
+			router.GET("/attachments/:id/thumbnail", handler.GetAttachmentThumbnail)
+
+			for _, url := range []string{"/attachments/1", "/attachments/1/thumbnail"} {
+				w := httptest.NewRecorder()
+				req, _ := http.NewRequest("GET", url, nil)
+				router.ServeHTTP(w, req)
+				assert.Equal(t, tt.code, w.Code, url)
+			}
+		})
+	}
+}
+
+func TestAttachmentHandler_GetAttachment_ClosedCategory(t *testing.T) {
+	postID := 5
+	mockService := &mocks.MockAttachmentService{
+		GetFunc: func(id int) (*models.Attachment, error) {
+			return &models.Attachment{ID: id, Filename: "a.png", ContentType: "image/png", PostID: &postID}, nil
+		},
+		OpenFunc: func(attachment *models.Attachment, thumbnail bool) (io.ReadSeekCloser, error) {
+			return seekCloser{strings.NewReader("содержимое")}, nil
+		},
+	}
+	threads := &mocks.MockThreadService{
+		CheckPostAccessFunc: func(id int, role string) error {
+			assert.Equal(t, postID, id)
+			if role == "guest" {
+				return service.ErrNoPermission
+			}
+			return nil
+		},
+	}
+	handler := NewAttachmentHandler(mockService, 1024).WithAccess(threads, nil)
+	router := setupAttachmentTestRouter(0)
+	router.GET("/attachments/:id", handler.GetAttachment)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/attachments/1", nil)
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+}
+
+func TestPostHandler_CreatePost_Attachments(t *testing.T) {
+	tests := []struct {
+		name           string
+		body           string
+		checkErr       error
+		expectedStatus int
+		created        bool
+	}{
+		{"с вложениями", `{"thread_id":1,"content":"Скриншот","attachment_ids":[7,8]}`, nil, http.StatusCreated, true},
+		{"без вложений", `{"thread_id":1,"content":"Текст"}`, nil, http.StatusCreated, true},
+		{"чужое вложение", `{"thread_id":1,"content":"Скриншот","attachment_ids":[9]}`, service.ErrAttachmentNotFound, http.StatusNotFound, false},
+		{"слишком много вложений", `{"thread_id":1,"content":"Скриншот","attachment_ids":[1,2,3,4,5,6,7,8,9,10,11]}`, service.ErrTooManyAttachments, http.StatusBadRequest, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			created := false
+			var linked []int
+			postService := &mocks.MockPostService{
+				CreatePostFunc: func(post *models.Post) error {
+					created = true
+					post.ID = 5
+					return nil
+				},
+			}
+			attachmentService := &mocks.MockAttachmentService{
+				CheckLinkFunc: func(targetType string, targetID, uploaderID int, ids []int) error {
+					assert.Equal(t, models.AttachmentTargetPost, targetType)
+					assert.Zero(t, targetID, "пост еще не создан")
+					assert.Equal(t, 2, uploaderID)
+					return tt.checkErr
+				},
+				LinkFunc: func(targetType string, targetID, uploaderID int, ids []int) error {
+					assert.Equal(t, 5, targetID)
+					linked = ids
+					return nil
+				},
+				ForTargetsFunc: func(targetType string, targetIDs []int) (map[int][]models.Attachment, error) {
+					return map[int][]models.Attachment{5: {{ID: 7, Filename: "screen.png"}, {ID: 8, Filename: "app.log"}}}, nil
+				},
+			}
+			router := setupAttachmentTestRouter(2)
+			router.POST("/posts", NewPostHandler(postService).WithAttachments(attachmentService).CreatePost)
+
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("POST", "/posts", bytes.NewBufferString(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			assert.Equal(t, tt.created, created, "пост не сохраняется, если вложения не прикрепятся")
+			if tt.expectedStatus != http.StatusCreated {
+				return
+			}
+			var post models.Post
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &post))
+			require.Len(t, post.Attachments, 2)
+			assert.Equal(t, "/api/attachments/7", post.Attachments[0].URL)
+			if strings.Contains(tt.body, "attachment_ids") {
+				assert.Equal(t, []int{7, 8}, linked)
+			} else {
+				assert.Nil(t, linked, "без attachment_ids вложения не трогаются")
+			}
+		})
+	}
+}
+
+func TestCommentHandler_UpdateComment_Attachments(t *testing.T) {
+	var linked []int
+	commentService := &mocks.MockCommentService{
+		UpdateCommentFunc: func(id int, userID int, content string) (*models.Comment, error) {
+			return &models.Comment{ID: id, AuthorID: userID, Content: content}, nil
+		},
+	}
+	attachmentService := &mocks.MockAttachmentService{
+		CheckLinkFunc: func(targetType string, targetID, uploaderID int, ids []int) error {
+			assert.Equal(t, models.AttachmentTargetComment, targetType)
+			assert.Equal(t, 4, targetID)
+			return nil
+		},
+		LinkFunc: func(targetType string, targetID, uploaderID int, ids []int) error {
+			linked = ids
+			return nil
+		},
+		ForTargetsFunc: func(targetType string, targetIDs []int) (map[int][]models.Attachment, error) {
+			return map[int][]models.Attachment{}, nil
+		},
+	}
+	router := setupAttachmentTestRouter(2)
+	router.PUT("/comments/:id", NewCommentHandler(commentService).WithAttachments(attachmentService).UpdateComment)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("PUT", "/comments/4", bytes.NewBufferString(`{"content":"Без файлов","attachment_ids":[]}`))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.NotNil(t, linked, "пустой список снимает все вложения")
+	assert.Empty(t, linked)
+}
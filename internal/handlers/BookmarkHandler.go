@@ -0,0 +1,156 @@
+package handlers
+
+import (
+	"ForumService/internal/errors"
+	"ForumService/internal/middleware"
+	"ForumService/internal/service"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type BookmarkHandler struct {
+	service service.BookmarkService
+}
+
+func NewBookmarkHandler(service service.BookmarkService) *BookmarkHandler {
+	return &BookmarkHandler{service: service}
+}
+
+type BookmarkRequest struct {
+	// TargetType - вид записи: thread или post
+	TargetType string `json:"target_type" binding:"required"`
+	TargetID   int    `json:"target_id" binding:"required"`
+	// Note - личная заметка, не больше 500 символов
+	Note string `json:"note"`
+}
+
+// AddBookmark godoc
+// @Summary Добавить в закладки
+// @Description Добавляет тред или пост в закладки пользователя. Для записи, уже добавленной в закладки, обновляет заметку.
+// @Description Закладки удаляются вместе с тредом или постом.
+// @Tags bookmarks
+// @Accept json
+// @Produce json
+// @Param input body BookmarkRequest true "Запись и заметка"
+// @Success 200 {object} models.Bookmark
+// @Failure 400 {object} map[string]string "неверный формат данных, вид записи или длина заметки"
+// @Failure 401 {object} map[string]string "пользователь не аутентифицирован"
+// @Failure 404 {object} map[string]string "тред или пост не найден"
+// @Router /bookmarks [post]
+func (h *BookmarkHandler) AddBookmark(c *gin.Context) {
+	var request BookmarkRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.Error(errors.NewValidationError("Неверный формат данных", err))
+		return
+	}
+
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.Error(errors.NewUnauthorizedError("Пользователь не аутентифицирован", nil))
+		return
+	}
+
+	bookmark, err := h.service.Add(int(userID.(uint32)), ViewerRole(c), request.TargetType, request.TargetID, request.Note)
+	if err != nil {
+		c.Error(middleware.ToForumError(err, "Ошибка при добавлении закладки"))
+		return
+	}
+
+	c.JSON(http.StatusOK, bookmark)
+}
+
+// RemoveBookmark godoc
+// @Summary Удалить закладку
+// @Description Удаляет тред или пост из закладок пользователя.
+// @Tags bookmarks
+// @Param target_type query string true "Вид записи: thread или post"
+// @Param target_id query int true "ID записи"
+// @Success 204 "закладка удалена"
+// @Failure 400 {object} map[string]string "неверный вид записи или ID"
+// @Failure 401 {object} map[string]string "пользователь не аутентифицирован"
+// @Failure 404 {object} map[string]string "закладка не найдена"
+// @Router /bookmarks [delete]
+func (h *BookmarkHandler) RemoveBookmark(c *gin.Context) {
+	targetID, err := strconv.Atoi(c.Query("target_id"))
+	if err != nil {
+		c.Error(errors.NewBadRequestError("Неверный ID записи", err))
+		return
+	}
+
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.Error(errors.NewUnauthorizedError("Пользователь не аутентифицирован", nil))
+		return
+	}
+
+	if err := h.service.Remove(int(userID.(uint32)), c.Query("target_type"), targetID); err != nil {
+		c.Error(middleware.ToForumError(err, "Ошибка при удалении закладки"))
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// GetMyBookmarks godoc
+// @Summary Получить свои закладки
+// @Description Возвращает закладки текущего пользователя с заметками, новые первыми.
+// @Tags bookmarks
+// @Produce json
+// @Param page query int false "Номер страницы (с 1)"
+// @Param limit query int false "Размер страницы (до 100)"
+// @Success 200 {object} models.BookmarkPage
+// @Failure 400 {object} map[string]string "неверные параметры страницы"
+// @Failure 401 {object} map[string]string "пользователь не аутентифицирован"
+// @Router /users/me/bookmarks [get]
+func (h *BookmarkHandler) GetMyBookmarks(c *gin.Context) {
+	page, limit, err := pageParams(c)
+	if err != nil {
+		c.Error(errors.NewBadRequestError("Неверные параметры страницы", err))
+		return
+	}
+
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.Error(errors.NewUnauthorizedError("Пользователь не аутентифицирован", nil))
+		return
+	}
+
+	bookmarks, err := h.service.List(int(userID.(uint32)), ViewerRole(c), page, limit)
+	if err != nil {
+		c.Error(middleware.ToForumError(err, "Ошибка при получении закладок"))
+		return
+	}
+
+	c.JSON(http.StatusOK, bookmarks)
+}
+
+// pageParams разбирает параметры page и limit; отсутствующие параметры равны нулю,
+// и сервис подставляет значения по умолчанию
+func pageParams(c *gin.Context) (int, int, error) {
+	var page, limit int
+	var err error
+	if value := c.Query("page"); value != "" {
+		if page, err = strconv.Atoi(value); err != nil {
+			return 0, 0, err
+		}
+	}
+	if value := c.Query("limit"); value != "" {
+		if limit, err = strconv.Atoi(value); err != nil {
+			return 0, 0, err
+		}
+	}
+	return page, limit, nil
+}
+
+// IsBookmarked сообщает, добавил ли пользователь запись в закладки. Без сервиса закладок,
+// для анонимного пользователя и при ошибке хранилища возвращает false: страница
+// показывается и без отметки.
+func IsBookmarked(bookmarks service.BookmarkService, targetType string, targetID int, userID int) bool {
+	if bookmarks == nil || userID == 0 {
+		return false
+	}
+	bookmarked, err := bookmarks.Bookmarked(userID, targetType, []int{targetID})
+	return err == nil && bookmarked[targetID]
+}
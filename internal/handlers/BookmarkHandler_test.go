@@ -0,0 +1,167 @@
+package handlers
+
+import (
+	"ForumService/internal/handlers/mocks"
+	"ForumService/internal/middleware"
+	"ForumService/internal/models"
+	"ForumService/internal/service"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupBookmarkTestRouter(handler *BookmarkHandler, userID uint32) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(middleware.ErrorHandler())
+	router.Use(func(c *gin.Context) {
+		if userID != 0 {
+			c.Set("user_id", userID)
+		}
+		c.Next()
+	})
+	router.POST("/bookmarks", handler.AddBookmark)
+	router.DELETE("/bookmarks", handler.RemoveBookmark)
+	router.GET("/users/me/bookmarks", handler.GetMyBookmarks)
+	return router
+}
+
+func TestBookmarkHandler_AddBookmark(t *testing.T) {
+	tests := []struct {
+		name           string
+		userID         uint32
+		body           string
+		err            error
+		expectedStatus int
+	}{
+		{"успешно", 1, `{"target_type":"post","target_id":5,"note":"прочитать"}`, nil, http.StatusOK},
+		{"не аутентифицирован", 0, `{"target_type":"post","target_id":5}`, nil, http.StatusUnauthorized},
+		{"без записи", 1, `{"target_type":"post"}`, nil, http.StatusBadRequest},
+		{"неизвестный вид записи", 1, `{"target_type":"chat","target_id":5}`, service.ErrInvalidBookmark, http.StatusBadRequest},
+		{"длинная заметка", 1, `{"target_type":"post","target_id":5,"note":"..."}`, service.ErrInvalidBookmarkNote, http.StatusBadRequest},
+		{"пост не найден", 1, `{"target_type":"post","target_id":5}`, service.ErrPostNotFound, http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := &mocks.MockBookmarkService{
+				AddFunc: func(userID int, role string, targetType string, targetID int, note string) (*models.Bookmark, error) {
+					if tt.err != nil {
+						return nil, tt.err
+					}
+					return &models.Bookmark{UserID: userID, TargetType: targetType, TargetID: targetID, Note: note}, nil
+				},
+			}
+			router := setupBookmarkTestRouter(NewBookmarkHandler(mockService), tt.userID)
+
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("POST", "/bookmarks", bytes.NewBufferString(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedStatus != http.StatusOK {
+				return
+			}
+			var bookmark models.Bookmark
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &bookmark))
+			assert.Equal(t, models.Bookmark{UserID: 1, TargetType: "post", TargetID: 5, Note: "прочитать"}, bookmark)
+		})
+	}
+}
+
+func TestBookmarkHandler_RemoveBookmark(t *testing.T) {
+	var gotType string
+	var gotTarget, gotUser int
+	mockService := &mocks.MockBookmarkService{
+		RemoveFunc: func(userID int, targetType string, targetID int) error {
+			gotUser, gotType, gotTarget = userID, targetType, targetID
+			if targetID == 99 {
+				return service.ErrBookmarkNotFound
+			}
+			return nil
+		},
+	}
+	router := setupBookmarkTestRouter(NewBookmarkHandler(mockService), 2)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("DELETE", "/bookmarks?target_type=thread&target_id=1", nil)
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.Equal(t, 2, gotUser)
+	assert.Equal(t, "thread", gotType)
+	assert.Equal(t, 1, gotTarget)
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("DELETE", "/bookmarks?target_type=thread&target_id=99", nil)
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("DELETE", "/bookmarks?target_type=thread", nil)
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestBookmarkHandler_GetMyBookmarks(t *testing.T) {
+	var gotPage, gotLimit int
+	mockService := &mocks.MockBookmarkService{
+		ListFunc: func(userID int, role string, page, limit int) (*models.BookmarkPage, error) {
+			gotPage, gotLimit = page, limit
+			return &models.BookmarkPage{
+				Bookmarks: []models.Bookmark{{UserID: userID, TargetType: "thread", TargetID: 1, Title: "Тред", ThreadID: 1}},
+				Total:     1,
+				Page:      1,
+				Limit:     20,
+			}, nil
+		},
+	}
+
+	router := setupBookmarkTestRouter(NewBookmarkHandler(mockService), 2)
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/users/me/bookmarks?page=2&limit=5", nil)
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, 2, gotPage)
+	assert.Equal(t, 5, gotLimit)
+	var page models.BookmarkPage
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &page))
+	require.Len(t, page.Bookmarks, 1)
+	assert.Equal(t, "Тред", page.Bookmarks[0].Title)
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/users/me/bookmarks?page=abc", nil)
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	router = setupBookmarkTestRouter(NewBookmarkHandler(mockService), 0)
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/users/me/bookmarks", nil)
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
+
+func TestIsBookmarked(t *testing.T) {
+	mockService := &mocks.MockBookmarkService{
+		BookmarkedFunc: func(userID int, targetType string, targetIDs []int) (map[int]bool, error) {
+			if targetType == models.BookmarkTargetPost {
+				return nil, errors.New("db error")
+			}
+			return map[int]bool{1: true}, nil
+		},
+	}
+
+	assert.True(t, IsBookmarked(mockService, models.BookmarkTargetThread, 1, 2))
+	assert.False(t, IsBookmarked(mockService, models.BookmarkTargetThread, 3, 2))
+	assert.False(t, IsBookmarked(mockService, models.BookmarkTargetPost, 1, 2), "ошибка хранилища не ломает страницу")
+	assert.False(t, IsBookmarked(mockService, models.BookmarkTargetThread, 1, 0))
+	assert.False(t, IsBookmarked(nil, models.BookmarkTargetThread, 1, 2))
+}
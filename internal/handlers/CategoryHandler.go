@@ -0,0 +1,281 @@
+package handlers
+
+import (
+	"ForumService/internal/errors"
+	"ForumService/internal/middleware"
+	"ForumService/internal/models"
+	"ForumService/internal/service"
+	stdErrors "errors"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"strconv"
+)
+
+type CategoryHandler struct {
+	service service.CategoryService
+}
+
+func NewCategoryHandler(service service.CategoryService) *CategoryHandler {
+	return &CategoryHandler{service: service}
+}
+
+// CategoryRequest - данные для создания и изменения раздела.
+// Пустые роли заменяются значениями по умолчанию: guest для чтения и user для создания тредов.
+type CategoryRequest struct {
+	Name        string `json:"name" binding:"required"`
+	Description string `json:"description"`
+	ParentID    *int   `json:"parent_id"`
+	Position    int    `json:"position"`
+	ReadRole    string `json:"read_role"`
+	CreateRole  string `json:"create_role"`
+}
+
+func (r CategoryRequest) category() *models.Category {
+	return &models.Category{
+		Name:        r.Name,
+		Description: r.Description,
+		ParentID:    r.ParentID,
+		Position:    r.Position,
+		ReadRole:    models.Role(r.ReadRole),
+		CreateRole:  models.Role(r.CreateRole),
+	}
+}
+
+// GetCategories godoc
+// @Summary Получить дерево разделов
+// @Description Возвращает разделы, доступные пользователю, с подразделами и числом тредов и постов. Счетчики раздела включают видимые подразделы.
+// @Tags categories
+// @Produce json
+// @Success 200 {array} models.Category
+// @Failure 500 {object} map[string]string "ошибка сервера"
+// @Router /categories [get]
+func (h *CategoryHandler) GetCategories(c *gin.Context) {
+	categories, err := h.service.GetCategoryTree(ViewerRole(c))
+	if err != nil {
+		c.Error(middleware.ToForumError(err, "Ошибка при получении разделов"))
+		return
+	}
+
+	c.JSON(http.StatusOK, categories)
+}
+
+// GetCategory godoc
+// @Summary Получить раздел
+// @Description Возвращает раздел с видимыми подразделами.
+// @Tags categories
+// @Produce json
+// @Param id path int true "ID раздела"
+// @Success 200 {object} models.Category
+// @Failure 400 {object} map[string]string "неверный ID раздела"
+// @Failure 403 {object} map[string]string "нет прав на чтение раздела"
+// @Failure 404 {object} map[string]string "раздел не найден"
+// @Router /categories/{id} [get]
+func (h *CategoryHandler) GetCategory(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(errors.NewBadRequestError("Неверный ID раздела", err))
+		return
+	}
+
+	category, err := h.service.GetCategory(id, ViewerRole(c))
+	if err != nil {
+		c.Error(middleware.ToForumError(err, "Ошибка при получении раздела"))
+		return
+	}
+
+	c.JSON(http.StatusOK, category)
+}
+
+// CreateCategory godoc
+// @Summary Создать раздел
+// @Description Создает раздел или подраздел форума. Доступно только администраторам.
+// @Tags categories
+// @Accept json
+// @Produce json
+// @Param input body CategoryRequest true "Данные раздела"
+// @Success 201 {object} models.Category
+// @Failure 400 {object} map[string]string "неверный формат данных"
+// @Failure 401 {object} map[string]string "пользователь не аутентифицирован"
+// @Failure 403 {object} map[string]string "недостаточно прав"
+// @Failure 404 {object} map[string]string "родительский раздел не найден"
+// @Router /categories [post]
+func (h *CategoryHandler) CreateCategory(c *gin.Context) {
+	var request CategoryRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.Error(errors.NewValidationError("Неверный формат данных", err))
+		return
+	}
+
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.Error(errors.NewUnauthorizedError("Пользователь не аутентифицирован", nil))
+		return
+	}
+
+	category := request.category()
+	if err := h.service.CreateCategory(category, int(userID.(uint32))); err != nil {
+		c.Error(middleware.ToForumError(err, "Ошибка при создании раздела"))
+		return
+	}
+
+	c.JSON(http.StatusCreated, category)
+}
+
+// UpdateCategory godoc
+// @Summary Изменить раздел
+// @Description Изменяет название, описание, порядок, родителя и права раздела. Доступно только администраторам.
+// @Tags categories
+// @Accept json
+// @Produce json
+// @Param id path int true "ID раздела"
+// @Param input body CategoryRequest true "Данные раздела"
+// @Success 200 {object} models.Category
+// @Failure 400 {object} map[string]string "неверный формат данных"
+// @Failure 401 {object} map[string]string "пользователь не аутентифицирован"
+// @Failure 403 {object} map[string]string "недостаточно прав"
+// @Failure 404 {object} map[string]string "раздел не найден"
+// @Router /categories/{id} [put]
+func (h *CategoryHandler) UpdateCategory(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(errors.NewBadRequestError("Неверный ID раздела", err))
+		return
+	}
+
+	var request CategoryRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.Error(errors.NewValidationError("Неверный формат данных", err))
+		return
+	}
+
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.Error(errors.NewUnauthorizedError("Пользователь не аутентифицирован", nil))
+		return
+	}
+
+	category := request.category()
+	category.ID = id
+	if err := h.service.UpdateCategory(category, int(userID.(uint32))); err != nil {
+		c.Error(middleware.ToForumError(err, "Ошибка при изменении раздела"))
+		return
+	}
+
+	c.JSON(http.StatusOK, category)
+}
+
+// DeleteCategory godoc
+// @Summary Удалить раздел
+// @Description Удаляет пустой раздел. Раздел с тредами или подразделами удалить нельзя. Доступно только администраторам.
+// @Tags categories
+// @Produce json
+// @Param id path int true "ID раздела"
+// @Success 204 "No Content"
+// @Failure 400 {object} map[string]string "неверный ID или раздел не пуст"
+// @Failure 401 {object} map[string]string "пользователь не аутентифицирован"
+// @Failure 403 {object} map[string]string "недостаточно прав"
+// @Failure 404 {object} map[string]string "раздел не найден"
+// @Router /categories/{id} [delete]
+func (h *CategoryHandler) DeleteCategory(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(errors.NewBadRequestError("Неверный ID раздела", err))
+		return
+	}
+
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.Error(errors.NewUnauthorizedError("Пользователь не аутентифицирован", nil))
+		return
+	}
+
+	if err := h.service.DeleteCategory(id, int(userID.(uint32))); err != nil {
+		c.Error(middleware.ToForumError(err, "Ошибка при удалении раздела"))
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// ViewerRole возвращает роль пользователя запроса. Без аутентификации пользователь считается гостем.
+func ViewerRole(c *gin.Context) string {
+	if role := c.GetString("user_role"); role != "" {
+		return role
+	}
+	return string(models.RoleGuest)
+}
+
+// hideForbidden заменяет отказ в доступе к разделу ошибкой notFound. Записи в закрытый раздел
+// отвечают так же, как записи в несуществующий тред или пост, и не раскрывают, что он есть.
+func hideForbidden(err error, notFound error) error {
+	if stdErrors.Is(err, service.ErrNoPermission) {
+		return notFound
+	}
+	return err
+}
+
+// checkPostWriteAccess проверяет, что пользователь может читать раздел треда поста postID,
+// прежде чем что-то в нем менять. Без threads проверка отключена.
+func checkPostWriteAccess(c *gin.Context, threads service.ThreadService, postID int) error {
+	if threads == nil {
+		return nil
+	}
+	return hideForbidden(threads.CheckPostAccess(postID, ViewerRole(c)), service.ErrPostNotFound)
+}
+
+// checkCommentWriteAccess проверяет доступ к разделу треда, в котором оставлен комментарий commentID
+func checkCommentWriteAccess(c *gin.Context, threads service.ThreadService, comments service.CommentService, commentID int) error {
+	if threads == nil {
+		return nil
+	}
+	comment, err := comments.GetCommentByID(commentID)
+	if err != nil {
+		return err
+	}
+	return hideForbidden(threads.CheckPostAccess(comment.PostID, ViewerRole(c)), service.ErrCommentNotFound)
+}
+
+// CategorySection - раздел на главной странице вместе со своими тредами.
+// Category равен nil для тредов вне разделов.
+type CategorySection struct {
+	Category *models.Category
+	// Depth - уровень вложенности раздела, 0 для корневых
+	Depth   int
+	Threads []*models.Thread
+	// CanCreate - пользователь может создавать треды в разделе
+	CanCreate bool
+}
+
+// BuildCategorySections раскладывает треды по разделам дерева в порядке обхода в глубину.
+// Треды вне разделов попадают в последнюю секцию, треды разделов, которых нет в дереве, не выводятся.
+func BuildCategorySections(tree []*models.Category, threads []*models.Thread, role string) []CategorySection {
+	threadsByCategory := make(map[int][]*models.Thread)
+	var uncategorized []*models.Thread
+	for _, thread := range threads {
+		if thread.CategoryID == nil {
+			uncategorized = append(uncategorized, thread)
+			continue
+		}
+		threadsByCategory[*thread.CategoryID] = append(threadsByCategory[*thread.CategoryID], thread)
+	}
+
+	var sections []CategorySection
+	var walk func(categories []*models.Category, depth int)
+	walk = func(categories []*models.Category, depth int) {
+		for _, category := range categories {
+			sections = append(sections, CategorySection{
+				Category:  category,
+				Depth:     depth,
+				Threads:   threadsByCategory[category.ID],
+				CanCreate: models.Role(role).Allows(category.CreateRole),
+			})
+			walk(category.Children, depth+1)
+		}
+	}
+	walk(tree, 0)
+
+	return append(sections, CategorySection{
+		Threads:   uncategorized,
+		CanCreate: models.Role(role).Allows(models.RoleUser),
+	})
+}
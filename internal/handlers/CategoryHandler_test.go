@@ -0,0 +1,248 @@
+package handlers
+
+import (
+	"ForumService/internal/handlers/mocks"
+	"ForumService/internal/middleware"
+	"ForumService/internal/models"
+	"ForumService/internal/service"
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupCategoryTestRouter(handler *CategoryHandler, userID uint32, role string) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(middleware.ErrorHandler())
+	router.Use(func(c *gin.Context) {
+		if userID != 0 {
+			c.Set("user_id", userID)
+			c.Set("user_role", role)
+		}
+		c.Next()
+	})
+	router.GET("/categories", handler.GetCategories)
+	router.GET("/categories/:id", handler.GetCategory)
+	router.POST("/categories", handler.CreateCategory)
+	router.PUT("/categories/:id", handler.UpdateCategory)
+	router.DELETE("/categories/:id", handler.DeleteCategory)
+	return router
+}
+
+func TestCategoryHandler_GetCategories(t *testing.T) {
+	var gotRole string
+	mockService := &mocks.MockCategoryService{
+		GetCategoryTreeFunc: func(role string) ([]*models.Category, error) {
+			gotRole = role
+			return []*models.Category{{ID: 1, Name: "Языки", ThreadCount: 2,
+				Children: []*models.Category{{ID: 2, Name: "Go", ThreadCount: 1}}}}, nil
+		},
+	}
+	router := setupCategoryTestRouter(NewCategoryHandler(mockService), 0, "")
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/categories", nil)
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "guest", gotRole)
+	var tree []*models.Category
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &tree))
+	require.Len(t, tree, 1)
+	assert.Equal(t, "Go", tree[0].Children[0].Name)
+}
+
+func TestCategoryHandler_GetCategory(t *testing.T) {
+	mockService := &mocks.MockCategoryService{
+		GetCategoryFunc: func(id int, role string) (*models.Category, error) {
+			switch id {
+			case 1:
+				return &models.Category{ID: 1, Name: "Языки"}, nil
+			case 2:
+				return nil, service.ErrNoPermission
+			}
+			return nil, service.ErrCategoryNotFound
+		},
+	}
+	router := setupCategoryTestRouter(NewCategoryHandler(mockService), 1, "user")
+
+	tests := []struct {
+		path           string
+		expectedStatus int
+	}{
+		{"/categories/1", http.StatusOK},
+		{"/categories/2", http.StatusForbidden},
+		{"/categories/3", http.StatusNotFound},
+		{"/categories/abc", http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", tt.path, nil)
+		router.ServeHTTP(w, req)
+		assert.Equal(t, tt.expectedStatus, w.Code, tt.path)
+	}
+}
+
+func TestCategoryHandler_CreateCategory(t *testing.T) {
+	var got *models.Category
+	mockService := &mocks.MockCategoryService{
+		CreateCategoryFunc: func(category *models.Category, userID int) error {
+			got = category
+			category.ID = 7
+			return nil
+		},
+	}
+	router := setupCategoryTestRouter(NewCategoryHandler(mockService), 1, "admin")
+
+	parentID := 1
+	body, _ := json.Marshal(CategoryRequest{Name: "Go", ParentID: &parentID, ReadRole: "user"})
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/categories", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+	require.NotNil(t, got)
+	assert.Equal(t, "Go", got.Name)
+	assert.Equal(t, models.RoleUser, got.ReadRole)
+	assert.Equal(t, 1, *got.ParentID)
+	assert.Contains(t, w.Body.String(), `"id":7`)
+}
+
+func TestCategoryHandler_CreateCategory_Errors(t *testing.T) {
+	tests := []struct {
+		name           string
+		userID         uint32
+		body           string
+		err            error
+		expectedStatus int
+	}{
+		{"не аутентифицирован", 0, `{"name":"Go"}`, nil, http.StatusUnauthorized},
+		{"без названия", 1, `{}`, nil, http.StatusBadRequest},
+		{"не администратор", 1, `{"name":"Go"}`, service.ErrNoPermission, http.StatusForbidden},
+		{"неверная роль", 1, `{"name":"Go","read_role":"owner"}`, service.ErrInvalidCategoryRole, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := &mocks.MockCategoryService{
+				CreateCategoryFunc: func(category *models.Category, userID int) error {
+					return tt.err
+				},
+			}
+			router := setupCategoryTestRouter(NewCategoryHandler(mockService), tt.userID, "user")
+
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("POST", "/categories", bytes.NewBufferString(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+		})
+	}
+}
+
+func TestCategoryHandler_UpdateCategory(t *testing.T) {
+	mockService := &mocks.MockCategoryService{
+		UpdateCategoryFunc: func(category *models.Category, userID int) error {
+			if category.ID == 2 {
+				return service.ErrCategoryCycle
+			}
+			return nil
+		},
+	}
+	router := setupCategoryTestRouter(NewCategoryHandler(mockService), 1, "admin")
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("PUT", "/categories/1", bytes.NewBufferString(`{"name":"Golang"}`))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"name":"Golang"`)
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("PUT", "/categories/2", bytes.NewBufferString(`{"name":"Golang","parent_id":2}`))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestCategoryHandler_DeleteCategory(t *testing.T) {
+	mockService := &mocks.MockCategoryService{
+		DeleteCategoryFunc: func(id int, userID int) error {
+			if id == 2 {
+				return service.ErrCategoryNotEmpty
+			}
+			return nil
+		},
+	}
+	router := setupCategoryTestRouter(NewCategoryHandler(mockService), 1, "admin")
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("DELETE", "/categories/1", nil)
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNoContent, w.Code)
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("DELETE", "/categories/2", nil)
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestBuildCategorySections(t *testing.T) {
+	goID, staffID := 2, 3
+	tree := []*models.Category{
+		{ID: 1, Name: "Языки", CreateRole: models.RoleUser, Children: []*models.Category{
+			{ID: goID, Name: "Go", CreateRole: models.RoleModerator},
+		}},
+	}
+	threads := []*models.Thread{
+		{ID: 1, CategoryID: &goID},
+		{ID: 2},
+		{ID: 3, CategoryID: &staffID},
+	}
+
+	sections := BuildCategorySections(tree, threads, "user")
+	require.Len(t, sections, 3)
+	assert.Equal(t, "Языки", sections[0].Category.Name)
+	assert.Empty(t, sections[0].Threads)
+	assert.True(t, sections[0].CanCreate)
+	assert.Equal(t, "Go", sections[1].Category.Name)
+	assert.Equal(t, 1, sections[1].Depth)
+	assert.Equal(t, []*models.Thread{threads[0]}, sections[1].Threads)
+	assert.False(t, sections[1].CanCreate)
+	assert.Nil(t, sections[2].Category)
+	assert.Equal(t, []*models.Thread{threads[1]}, sections[2].Threads)
+}
+
+// staffOnlyThreads - треды и посты раздела, который читают только модераторы
+func staffOnlyThreads() *mocks.MockThreadService {
+	check := func(role string) error {
+		if !models.Role(role).Allows(models.RoleModerator) {
+			return service.ErrNoPermission
+		}
+		return nil
+	}
+	return &mocks.MockThreadService{
+		CheckThreadIDAccessFunc: func(threadID int, role string) error { return check(role) },
+		CheckPostAccessFunc:     func(postID int, role string) error { return check(role) },
+	}
+}
+
+// closedCategoryRouter выполняет запросы от пользователя с ролью user, которой раздел не виден
+func closedCategoryRouter() *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(middleware.ErrorHandler())
+	router.Use(func(c *gin.Context) {
+		c.Set("user_id", uint32(2))
+		c.Set("user_role", "user")
+		c.Next()
+	})
+	return router
+}
@@ -3,18 +3,33 @@ package handlers
 import (
 	"ForumService/internal/service"
 	"ForumService/internal/errors"
+	"ForumService/internal/middleware"
 	"github.com/gin-gonic/gin"
 	"net/http"
 )
 
 type ChatHandler struct {
-	service service.ChatService
+	service       service.ChatService
+	reactions     service.ReactionService
+	notifications service.NotificationService
 }
 
 func NewChatHandler(service service.ChatService) *ChatHandler {
 	return &ChatHandler{service: service}
 }
 
+// WithReactions добавляет реакции в ответ GetMessages
+func (h *ChatHandler) WithReactions(reactions service.ReactionService) *ChatHandler {
+	h.reactions = reactions
+	return h
+}
+
+// WithNotifications уведомляет упомянутых в новых сообщениях и добавляет упоминания в ответ GetMessages
+func (h *ChatHandler) WithNotifications(notifications service.NotificationService) *ChatHandler {
+	h.notifications = notifications
+	return h
+}
+
 type CreateMessageRequest struct {
 	Content string `json:"content" binding:"required"`
 }
@@ -50,6 +65,9 @@ func (h *ChatHandler) CreateMessage(c *gin.Context) {
 		c.Error(errors.NewInternalServerError("Ошибка при создании сообщения", err))
 		return
 	}
+	if h.notifications != nil {
+		logNotificationError(h.notifications.ChatMessageCreated(message), "сообщении чата")
+	}
 
 	c.JSON(http.StatusCreated, message)
 }
@@ -68,6 +86,14 @@ func (h *ChatHandler) GetMessages(c *gin.Context) {
 		c.Error(errors.NewInternalServerError("Ошибка при получении сообщений", err))
 		return
 	}
+	if err := AttachMessageReactions(h.reactions, messages, viewerID(c)); err != nil {
+		c.Error(middleware.ToForumError(err, "Ошибка при получении реакций"))
+		return
+	}
+	if err := AttachMessageMentions(h.notifications, messages); err != nil {
+		c.Error(middleware.ToForumError(err, "Ошибка при получении упоминаний"))
+		return
+	}
 
 	c.JSON(http.StatusOK, messages)
 } 
\ No newline at end of file
@@ -192,6 +192,7 @@ func TestChatHandler_GetMessages(t *testing.T) {
 				map[string]interface{}{
 					"id":          float64(1),
 					"content":     "Test message 1",
+					"content_html": "<p>Test message 1</p>\n",
 					"author_id":   float64(1),
 					"author_name": "user1",
 					"created_at":  "0001-01-01T00:00:00Z",
@@ -199,6 +200,7 @@ func TestChatHandler_GetMessages(t *testing.T) {
 				map[string]interface{}{
 					"id":          float64(2),
 					"content":     "Test message 2",
+					"content_html": "<p>Test message 2</p>\n",
 					"author_id":   float64(2),
 					"author_name": "user2",
 					"created_at":  "0001-01-01T00:00:00Z",
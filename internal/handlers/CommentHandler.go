@@ -3,35 +3,69 @@ package handlers
 import (
 	"ForumService/internal/service"
 	"ForumService/internal/errors"
+	"ForumService/internal/middleware"
 	"github.com/gin-gonic/gin"
 	"net/http"
 	"strconv"
 	"fmt"
-	_ "ForumService/internal/models"
+	"ForumService/internal/models"
 )
 
 type CommentHandler struct {
-	service service.CommentService
+	service       service.CommentService
+	notifications service.NotificationService
+	attachments   service.AttachmentService
+	threads       service.ThreadService
 }
 
 type CreateCommentRequest struct {
-	PostID  int    `json:"post_id" binding:"required"`
+	PostID          int    `json:"post_id" binding:"required"`
+	ParentCommentID *int   `json:"parent_comment_id"`
+	Content         string `json:"content" binding:"required"`
+	// AttachmentIDs - загруженные файлы, которые прикрепляются к комментарию
+	AttachmentIDs []int `json:"attachment_ids"`
+}
+
+type UpdateCommentRequest struct {
 	Content string `json:"content" binding:"required"`
+	// AttachmentIDs - новый список вложений; если не передан, вложения не меняются
+	AttachmentIDs []int `json:"attachment_ids"`
 }
 
 func NewCommentHandler(service service.CommentService) *CommentHandler {
 	return &CommentHandler{service: service}
 }
 
+// WithNotifications уведомляет подписчиков поста, упомянутых пользователей и авторов
+// комментариев о новых комментариях, а упомянутых - и об измененных
+func (h *CommentHandler) WithNotifications(notifications service.NotificationService) *CommentHandler {
+	h.notifications = notifications
+	return h
+}
+
+// WithAttachments прикрепляет к комментариям загруженные файлы из attachment_ids
+func (h *CommentHandler) WithAttachments(attachments service.AttachmentService) *CommentHandler {
+	h.attachments = attachments
+	return h
+}
+
+// WithThreads не принимает комментарии к постам из разделов, закрытых для пользователя
+func (h *CommentHandler) WithThreads(threads service.ThreadService) *CommentHandler {
+	h.threads = threads
+	return h
+}
+
 // CreateComment godoc
 // @Summary Создать новый комментарий
-// @Description Создаёт новый комментарий к посту. Доступно только авторизованным пользователям.
+// @Description Создаёт новый комментарий к посту или ответ на комментарий (parent_comment_id). Доступно только авторизованным пользователям.
+// @Description attachment_ids прикрепляет файлы, загруженные через POST /attachments.
 // @Tags comments
 // @Accept json
 // @Produce json
 // @Param input body object true "Данные для создания комментария"
 // @Success 201 {object} models.Comment
-// @Failure 400 {object} map[string]string "неверный формат данных"
+// @Failure 400 {object} map[string]string "неверный формат данных, недопустимый родительский комментарий или больше 10 вложений"
+// @Failure 404 {object} map[string]string "пост не найден или закрыт для пользователя, вложение не найдено"
 // @Failure 401 {object} map[string]string "пользователь не аутентифицирован"
 // @Failure 500 {object} map[string]string "ошибка сервера"
 // @Router /comments [post]
@@ -48,19 +82,145 @@ func (h *CommentHandler) CreateComment(c *gin.Context) {
 		return
 	}
 
+	if err := checkPostWriteAccess(c, h.threads, request.PostID); err != nil {
+		c.Error(middleware.ToForumError(err, "Ошибка при создании комментария"))
+		return
+	}
+
 	userIDInt := int(userID.(uint32))
+	if err := checkAttachments(h.attachments, models.AttachmentTargetComment, 0, userIDInt, request.AttachmentIDs); err != nil {
+		c.Error(middleware.ToForumError(err, "Ошибка при проверке вложений"))
+		return
+	}
+	if request.ParentCommentID != nil {
+		comment, err := h.service.ReplyToComment(request.PostID, *request.ParentCommentID, userIDInt, request.Content)
+		if err != nil {
+			switch err {
+			case service.ErrCommentTooDeep:
+				c.Error(errors.NewBadRequestError("Превышена максимальная глубина ответов", err))
+			case service.ErrInvalidParent:
+				c.Error(errors.NewBadRequestError("Родительский комментарий относится к другому посту", err))
+			case service.ErrCommentDeleted:
+				c.Error(errors.NewBadRequestError("Нельзя ответить на удаленный комментарий", err))
+			default:
+				c.Error(middleware.ToForumError(err, "Ошибка при создании ответа"))
+			}
+			return
+		}
+		if err := h.saveAttachments(comment, userIDInt, request.AttachmentIDs); err != nil {
+			c.Error(middleware.ToForumError(err, "Ошибка при прикреплении файлов"))
+			return
+		}
+		h.commentCreated(comment)
+		c.JSON(http.StatusCreated, comment)
+		return
+	}
+
 	comment, err := h.service.CreateComment(request.PostID, userIDInt, request.Content)
 	if err != nil {
-		c.Error(errors.NewInternalServerError("Ошибка при создании комментария", err))
+		c.Error(middleware.ToForumError(err, "Ошибка при создании комментария"))
+		return
+	}
+	if err := h.saveAttachments(comment, userIDInt, request.AttachmentIDs); err != nil {
+		c.Error(middleware.ToForumError(err, "Ошибка при прикреплении файлов"))
 		return
 	}
+	h.commentCreated(comment)
 
 	c.JSON(http.StatusCreated, comment)
 }
 
+// commentCreated уведомляет о новом комментарии и готовит его текст в HTML для ответа
+func (h *CommentHandler) commentCreated(comment *models.Comment) {
+	if h.notifications != nil {
+		logNotificationError(h.notifications.CommentCreated(comment), "новом комментарии")
+	}
+	comment.ContentHTML = string(RenderContent(comment.Content, comment.Mentions))
+}
+
+// saveAttachments прикрепляет к сохраненному комментарию файлы из запроса и добавляет вложения в ответ
+func (h *CommentHandler) saveAttachments(comment *models.Comment, userID int, ids []int) error {
+	if err := linkAttachments(h.attachments, models.AttachmentTargetComment, comment.ID, userID, ids); err != nil {
+		return err
+	}
+	comments := []models.Comment{*comment}
+	if err := AttachCommentAttachments(h.attachments, comments); err != nil {
+		return err
+	}
+	comment.Attachments = comments[0].Attachments
+	return nil
+}
+
+// UpdateComment godoc
+// @Summary Редактировать комментарий
+// @Description Обновляет текст комментария. Автор может редактировать комментарий в течение окна редактирования, модераторы и администраторы - в любое время.
+// @Description attachment_ids заменяет вложения комментария, без него вложения не меняются.
+// @Tags comments
+// @Accept json
+// @Produce json
+// @Param id path int true "ID комментария"
+// @Param input body UpdateCommentRequest true "Новый текст комментария"
+// @Success 200 {object} models.Comment
+// @Failure 400 {object} map[string]string "неверный формат данных"
+// @Failure 401 {object} map[string]string "пользователь не аутентифицирован"
+// @Failure 403 {object} map[string]string "нет прав для редактирования или окно редактирования истекло"
+// @Failure 404 {object} map[string]string "вложение не найдено"
+// @Failure 500 {object} map[string]string "ошибка сервера"
+// @Router /comments/{id} [put]
+func (h *CommentHandler) UpdateComment(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(errors.NewBadRequestError("Неверный ID комментария", err))
+		return
+	}
+
+	var request UpdateCommentRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.Error(errors.NewValidationError("Неверный формат данных", err))
+		return
+	}
+
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.Error(errors.NewUnauthorizedError("Пользователь не аутентифицирован", nil))
+		return
+	}
+
+	userIDInt := int(userID.(uint32))
+	if err := checkAttachments(h.attachments, models.AttachmentTargetComment, id, userIDInt, request.AttachmentIDs); err != nil {
+		c.Error(middleware.ToForumError(err, "Ошибка при проверке вложений"))
+		return
+	}
+
+	comment, err := h.service.UpdateComment(id, userIDInt, request.Content)
+	if err != nil {
+		switch err {
+		case service.ErrNoPermission:
+			c.Error(errors.NewPermissionDeniedError("Нет прав для редактирования комментария", err))
+		case service.ErrEditWindowExpired:
+			c.Error(errors.NewPermissionDeniedError("Время редактирования комментария истекло", err))
+		case service.ErrCommentDeleted:
+			c.Error(errors.NewBadRequestError("Комментарий удален", err))
+		default:
+			c.Error(middleware.ToForumError(err, "Ошибка при обновлении комментария"))
+		}
+		return
+	}
+	if err := h.saveAttachments(comment, userIDInt, request.AttachmentIDs); err != nil {
+		c.Error(middleware.ToForumError(err, "Ошибка при прикреплении файлов"))
+		return
+	}
+	if h.notifications != nil {
+		logNotificationError(h.notifications.CommentUpdated(comment), "измененном комментарии")
+	}
+	comment.ContentHTML = string(RenderContent(comment.Content, comment.Mentions))
+
+	c.JSON(http.StatusOK, comment)
+}
+
 // DeleteComment godoc
 // @Summary Удалить комментарий
-// @Description Удаляет комментарий. Доступно только автору комментария или администратору.
+// @Description Удаляет комментарий. Если на комментарий есть ответы, вместо него остается заглушка. Доступно только автору комментария или администратору.
 // @Tags comments
 // @Produce json
 // @Param id path int true "ID комментария"
@@ -88,7 +248,7 @@ func (h *CommentHandler) DeleteComment(c *gin.Context) {
 
 	comment, err := h.service.GetCommentByID(id)
 	if err != nil {
-		c.Error(errors.NewNotFoundError("Комментарий не найден", err))
+		c.Error(middleware.ToForumError(err, "Ошибка при получении комментария"))
 		return
 	}
 
@@ -98,7 +258,7 @@ func (h *CommentHandler) DeleteComment(c *gin.Context) {
 	}
 
 	if err := h.service.DeleteComment(id, userIDInt); err != nil {
-		c.Error(errors.NewInternalServerError("Ошибка при удалении комментария", err))
+		c.Error(middleware.ToForumError(err, "Ошибка при удалении комментария"))
 		return
 	}
 
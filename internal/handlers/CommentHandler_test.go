@@ -7,6 +7,7 @@ import (
 	"errors"
 	"ForumService/internal/handlers/mocks"
 	"ForumService/internal/models"
+	"ForumService/internal/service"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -73,11 +74,21 @@ func TestCommentHandler_CreateComment(t *testing.T) {
 			expectedBody: map[string]interface{}{
 				"id":          float64(1),
 				"content":     "Test comment",
+				"content_html": "<p>Test comment</p>\n",
 				"author_id":   float64(1),
 				"post_id":     float64(1),
+				"parent_comment_id": nil,
+				"depth":       float64(0),
+				"deleted":     false,
 				"created_at":  "0001-01-01T00:00:00Z",
+				"updated_at":  "0001-01-01T00:00:00Z",
+				"edited":      false,
+				"can_edit":    false,
 				"author_name": "",
 				"can_delete":  false,
+				"upvotes":     float64(0),
+				"downvotes":   float64(0),
+				"score":       float64(0),
 			},
 		},
 		{
@@ -139,6 +150,85 @@ func TestCommentHandler_CreateComment(t *testing.T) {
 	}
 }
 
+func TestCommentHandler_ReplyToComment(t *testing.T) {
+	tests := []struct {
+		name           string
+		mockError      error
+		expectedStatus int
+		expectedBody   map[string]interface{}
+	}{
+		{
+			name:           "успешный ответ на комментарий",
+			expectedStatus: http.StatusCreated,
+			expectedBody: map[string]interface{}{
+				"id":                float64(2),
+				"content":           "Reply",
+				"content_html":      "<p>Reply</p>\n",
+				"author_id":         float64(1),
+				"post_id":           float64(1),
+				"parent_comment_id": float64(1),
+				"depth":             float64(1),
+				"deleted":           false,
+				"created_at":        "0001-01-01T00:00:00Z",
+				"updated_at":        "0001-01-01T00:00:00Z",
+				"edited":            false,
+				"can_edit":          false,
+				"author_name":       "",
+				"can_delete":        false,
+				"upvotes":     float64(0),
+				"downvotes":   float64(0),
+				"score":       float64(0),
+			},
+		},
+		{
+			name:           "превышена глубина ответов",
+			mockError:      service.ErrCommentTooDeep,
+			expectedStatus: http.StatusInternalServerError,
+			expectedBody: map[string]interface{}{
+				"error": "Превышена максимальная глубина ответов: comment nesting is too deep",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockCommentService := &mocks.MockCommentService{
+				ReplyToCommentFunc: func(postID int, parentID int, authorID int, content string) (*models.Comment, error) {
+					if tt.mockError != nil {
+						return nil, tt.mockError
+					}
+					return &models.Comment{ID: 2, PostID: postID, ParentCommentID: &parentID, Depth: 1, AuthorID: authorID, Content: content}, nil
+				},
+			}
+
+			handler := NewCommentHandler(mockCommentService)
+			router := setupCommentTestRouter()
+			router.POST("/comments", func(c *gin.Context) {
+				c.Set("user_id", uint32(1))
+				handler.CreateComment(c)
+			})
+
+			jsonBody, _ := json.Marshal(map[string]interface{}{
+				"post_id":           1,
+				"parent_comment_id": 1,
+				"content":           "Reply",
+			})
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("POST", "/comments", bytes.NewBuffer(jsonBody))
+			req.Header.Set("Content-Type", "application/json")
+
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+
+			var response map[string]interface{}
+			err := json.Unmarshal(w.Body.Bytes(), &response)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedBody, response)
+		})
+	}
+}
+
 func TestCommentHandler_DeleteComment(t *testing.T) {
 	tests := []struct {
 		name           string
@@ -173,7 +263,7 @@ func TestCommentHandler_DeleteComment(t *testing.T) {
 			mockError:      errors.New("нет прав для удаления комментария"),
 			expectedStatus: http.StatusInternalServerError,
 			expectedBody: map[string]interface{}{
-				"error": "Комментарий не найден: нет прав для удаления комментария",
+				"error": "Ошибка при получении комментария: нет прав для удаления комментария",
 			},
 		},
 	}
@@ -226,6 +316,101 @@ func TestCommentHandler_DeleteComment(t *testing.T) {
 	}
 }
 
+func TestCommentHandler_UpdateComment(t *testing.T) {
+	tests := []struct {
+		name           string
+		commentID      string
+		requestBody    map[string]interface{}
+		userID         interface{}
+		mockError      error
+		expectedStatus int
+		expectedBody   map[string]interface{}
+	}{
+		{
+			name:           "успешное редактирование комментария",
+			commentID:      "1",
+			requestBody:    map[string]interface{}{"content": "Updated comment"},
+			userID:         uint32(1),
+			expectedStatus: http.StatusOK,
+			expectedBody: map[string]interface{}{
+				"id":          float64(1),
+				"content":     "Updated comment",
+				"content_html": "<p>Updated comment</p>\n",
+				"author_id":   float64(1),
+				"post_id":     float64(0),
+				"parent_comment_id": nil,
+				"depth":       float64(0),
+				"deleted":     false,
+				"created_at":  "0001-01-01T00:00:00Z",
+				"updated_at":  "0001-01-01T00:00:00Z",
+				"edited":      true,
+				"can_edit":    false,
+				"author_name": "",
+				"can_delete":  false,
+				"upvotes":     float64(0),
+				"downvotes":   float64(0),
+				"score":       float64(0),
+			},
+		},
+		{
+			name:           "окно редактирования истекло",
+			commentID:      "1",
+			requestBody:    map[string]interface{}{"content": "Updated comment"},
+			userID:         uint32(1),
+			mockError:      service.ErrEditWindowExpired,
+			expectedStatus: http.StatusInternalServerError,
+			expectedBody: map[string]interface{}{
+				"error": "Время редактирования комментария истекло: edit window has expired",
+			},
+		},
+		{
+			name:           "нет прав для редактирования",
+			commentID:      "1",
+			requestBody:    map[string]interface{}{"content": "Updated comment"},
+			userID:         uint32(2),
+			mockError:      service.ErrNoPermission,
+			expectedStatus: http.StatusInternalServerError,
+			expectedBody: map[string]interface{}{
+				"error": "Нет прав для редактирования комментария: no permission to modify this post",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockCommentService := &mocks.MockCommentService{
+				UpdateCommentFunc: func(id int, userID int, content string) (*models.Comment, error) {
+					if tt.mockError != nil {
+						return nil, tt.mockError
+					}
+					return &models.Comment{ID: id, AuthorID: userID, Content: content, Edited: true}, nil
+				},
+			}
+
+			handler := NewCommentHandler(mockCommentService)
+			router := setupCommentTestRouter()
+			router.PUT("/comments/:id", func(c *gin.Context) {
+				c.Set("user_id", tt.userID)
+				handler.UpdateComment(c)
+			})
+
+			jsonBody, _ := json.Marshal(tt.requestBody)
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("PUT", "/comments/"+tt.commentID, bytes.NewBuffer(jsonBody))
+			req.Header.Set("Content-Type", "application/json")
+
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+
+			var response map[string]interface{}
+			err := json.Unmarshal(w.Body.Bytes(), &response)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedBody, response)
+		})
+	}
+}
+
 func TestCommentHandler_CreateChatMessage_Success(t *testing.T) {
 	mockCommentService := &mocks.MockCommentService{
 		CreateCommentFunc: func(postID int, authorID int, content string) (*models.Comment, error) {
@@ -302,4 +487,31 @@ func TestCommentHandler_CreateChatMessage_ServiceError(t *testing.T) {
 	router.ServeHTTP(w, req)
 
 	assert.Equal(t, http.StatusInternalServerError, w.Code)
-}
\ No newline at end of file
+}
+func TestCommentHandler_CreateComment_ClosedCategory(t *testing.T) {
+	mockService := &mocks.MockCommentService{
+		CreateCommentFunc: func(postID int, authorID int, content string) (*models.Comment, error) {
+			t.Fatal("комментарий в закрытом разделе не должен создаваться")
+			return nil, nil
+		},
+		ReplyToCommentFunc: func(postID int, parentID int, authorID int, content string) (*models.Comment, error) {
+			t.Fatal("ответ в закрытом разделе не должен создаваться")
+			return nil, nil
+		},
+	}
+	router := closedCategoryRouter()
+	router.POST("/comments", NewCommentHandler(mockService).WithThreads(staffOnlyThreads()).CreateComment)
+
+	for _, body := range []string{
+		`{"post_id":1,"content":"Комментарий"}`,
+		`{"post_id":1,"parent_comment_id":3,"content":"Ответ"}`,
+	} {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", "/comments", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusNotFound, w.Code, body)
+		assert.Contains(t, w.Body.String(), "Пост не найден", body)
+	}
+}
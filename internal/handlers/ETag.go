@@ -0,0 +1,30 @@
+package handlers
+
+import (
+	"ForumService/internal/errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"strconv"
+	"strings"
+)
+
+// versionETag формирует ETag из версии записи
+func versionETag(version int) string {
+	return fmt.Sprintf(`"%d"`, version)
+}
+
+// ifMatchVersion возвращает версию из заголовка If-Match.
+// 0 означает, что заголовок не передан или равен "*" и версия не проверяется.
+func ifMatchVersion(c *gin.Context) (int, error) {
+	value := strings.TrimSpace(c.GetHeader("If-Match"))
+	if value == "" || value == "*" {
+		return 0, nil
+	}
+
+	value = strings.Trim(strings.TrimPrefix(value, "W/"), `"`)
+	version, err := strconv.Atoi(value)
+	if err != nil || version <= 0 {
+		return 0, errors.NewBadRequestError("Неверный заголовок If-Match", err)
+	}
+	return version, nil
+}
@@ -0,0 +1,39 @@
+package handlers
+
+import (
+	"ForumService/internal/cache"
+	"github.com/gin-gonic/gin"
+	"net/http"
+)
+
+// CacheStatsProvider отдает счетчики кэшей по их именам
+type CacheStatsProvider interface {
+	Stats() map[string]cache.Stats
+}
+
+type MetricsHandler struct {
+	caches CacheStatsProvider
+}
+
+// NewMetricsHandler создает обработчик метрик. caches равен nil, если кэш выключен.
+func NewMetricsHandler(caches CacheStatsProvider) *MetricsHandler {
+	return &MetricsHandler{caches: caches}
+}
+
+// GetCacheStats godoc
+// @Summary Статистика кэша
+// @Description Возвращает попадания, промахи, объединенные загрузки, вытеснения и долю попаданий для каждого кэша чтения.
+// @Tags metrics
+// @Produce json
+// @Success 200 {object} map[string]interface{}
+// @Router /metrics/cache [get]
+func (h *MetricsHandler) GetCacheStats(c *gin.Context) {
+	caches := map[string]cache.Stats{}
+	if h.caches != nil {
+		caches = h.caches.Stats()
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"enabled": h.caches != nil,
+		"caches":  caches,
+	})
+}
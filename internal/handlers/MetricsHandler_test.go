@@ -0,0 +1,65 @@
+package handlers
+
+import (
+	"ForumService/internal/cache"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type staticCacheStats map[string]cache.Stats
+
+func (s staticCacheStats) Stats() map[string]cache.Stats {
+	return s
+}
+
+func TestMetricsHandler_GetCacheStats(t *testing.T) {
+	tests := []struct {
+		name            string
+		caches          CacheStatsProvider
+		expectedEnabled bool
+		expectedCaches  int
+	}{
+		{
+			name:            "кэш включен",
+			caches:          staticCacheStats{"threads": {Hits: 3, Misses: 1, HitRate: 0.75}},
+			expectedEnabled: true,
+			expectedCaches:  1,
+		},
+		{
+			name:            "кэш выключен",
+			expectedEnabled: false,
+			expectedCaches:  0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gin.SetMode(gin.TestMode)
+			router := gin.New()
+			router.GET("/metrics/cache", NewMetricsHandler(tt.caches).GetCacheStats)
+
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("GET", "/metrics/cache", nil)
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, http.StatusOK, w.Code)
+
+			var response struct {
+				Enabled bool                   `json:"enabled"`
+				Caches  map[string]cache.Stats `json:"caches"`
+			}
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+			assert.Equal(t, tt.expectedEnabled, response.Enabled)
+			assert.Len(t, response.Caches, tt.expectedCaches)
+			if tt.expectedCaches > 0 {
+				assert.Equal(t, 0.75, response.Caches["threads"].HitRate)
+			}
+		})
+	}
+}
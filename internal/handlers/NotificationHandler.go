@@ -0,0 +1,282 @@
+package handlers
+
+import (
+	"ForumService/internal/errors"
+	"ForumService/internal/middleware"
+	"ForumService/internal/models"
+	"ForumService/internal/service"
+	"net/http"
+	"strconv"
+
+	"github.com/Luxtington/Shared/logger"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+type NotificationHandler struct {
+	service service.NotificationService
+}
+
+func NewNotificationHandler(service service.NotificationService) *NotificationHandler {
+	return &NotificationHandler{service: service}
+}
+
+type SubscriptionRequest struct {
+	// TargetType - вид записи: thread или post
+	TargetType string `json:"target_type" binding:"required"`
+	TargetID   int    `json:"target_id" binding:"required"`
+}
+
+// Subscribe godoc
+// @Summary Подписаться на тред или пост
+// @Description Подписывает пользователя на новые посты треда или новые комментарии поста.
+// @Description Авторы подписываются на свои треды и посты автоматически, повторная подписка не ошибка.
+// @Tags notifications
+// @Accept json
+// @Param input body SubscriptionRequest true "Запись"
+// @Success 204 "подписка оформлена"
+// @Failure 400 {object} map[string]string "неверный формат данных или вид записи"
+// @Failure 401 {object} map[string]string "пользователь не аутентифицирован"
+// @Failure 404 {object} map[string]string "тред или пост не найден"
+// @Router /subscriptions [post]
+func (h *NotificationHandler) Subscribe(c *gin.Context) {
+	var request SubscriptionRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.Error(errors.NewValidationError("Неверный формат данных", err))
+		return
+	}
+
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.Error(errors.NewUnauthorizedError("Пользователь не аутентифицирован", nil))
+		return
+	}
+
+	if err := h.service.Subscribe(int(userID.(uint32)), ViewerRole(c), request.TargetType, request.TargetID); err != nil {
+		c.Error(middleware.ToForumError(err, "Ошибка при оформлении подписки"))
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// Unsubscribe godoc
+// @Summary Отписаться от треда или поста
+// @Description Отменяет подписку пользователя на тред или пост.
+// @Tags notifications
+// @Param target_type query string true "Вид записи: thread или post"
+// @Param target_id query int true "ID записи"
+// @Success 204 "подписка отменена"
+// @Failure 400 {object} map[string]string "неверный вид записи или ID"
+// @Failure 401 {object} map[string]string "пользователь не аутентифицирован"
+// @Failure 404 {object} map[string]string "подписка не найдена"
+// @Router /subscriptions [delete]
+func (h *NotificationHandler) Unsubscribe(c *gin.Context) {
+	targetID, err := strconv.Atoi(c.Query("target_id"))
+	if err != nil {
+		c.Error(errors.NewBadRequestError("Неверный ID записи", err))
+		return
+	}
+
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.Error(errors.NewUnauthorizedError("Пользователь не аутентифицирован", nil))
+		return
+	}
+
+	if err := h.service.Unsubscribe(int(userID.(uint32)), c.Query("target_type"), targetID); err != nil {
+		c.Error(middleware.ToForumError(err, "Ошибка при отмене подписки"))
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// GetNotifications godoc
+// @Summary Получить уведомления
+// @Description Возвращает уведомления текущего пользователя, новые первыми, и число непрочитанных.
+// @Description Новые уведомления также приходят подключенным к чату клиентам сообщением с типом "notification".
+// @Tags notifications
+// @Produce json
+// @Param unread query bool false "Только непрочитанные"
+// @Param page query int false "Номер страницы (с 1)"
+// @Param limit query int false "Размер страницы (до 100)"
+// @Success 200 {object} models.NotificationPage
+// @Failure 400 {object} map[string]string "неверные параметры запроса"
+// @Failure 401 {object} map[string]string "пользователь не аутентифицирован"
+// @Router /notifications [get]
+func (h *NotificationHandler) GetNotifications(c *gin.Context) {
+	page, limit, err := pageParams(c)
+	if err != nil {
+		c.Error(errors.NewBadRequestError("Неверные параметры страницы", err))
+		return
+	}
+	unreadOnly := false
+	if value := c.Query("unread"); value != "" {
+		if unreadOnly, err = strconv.ParseBool(value); err != nil {
+			c.Error(errors.NewBadRequestError("Неверное значение фильтра непрочитанных", err))
+			return
+		}
+	}
+
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.Error(errors.NewUnauthorizedError("Пользователь не аутентифицирован", nil))
+		return
+	}
+
+	notifications, err := h.service.List(int(userID.(uint32)), unreadOnly, page, limit)
+	if err != nil {
+		c.Error(middleware.ToForumError(err, "Ошибка при получении уведомлений"))
+		return
+	}
+
+	c.JSON(http.StatusOK, notifications)
+}
+
+// GetUnreadCount godoc
+// @Summary Получить число непрочитанных уведомлений
+// @Tags notifications
+// @Produce json
+// @Success 200 {object} map[string]int "unread - число непрочитанных уведомлений"
+// @Failure 401 {object} map[string]string "пользователь не аутентифицирован"
+// @Router /notifications/unread-count [get]
+func (h *NotificationHandler) GetUnreadCount(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.Error(errors.NewUnauthorizedError("Пользователь не аутентифицирован", nil))
+		return
+	}
+
+	unread, err := h.service.UnreadCount(int(userID.(uint32)))
+	if err != nil {
+		c.Error(middleware.ToForumError(err, "Ошибка при подсчете уведомлений"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"unread": unread})
+}
+
+// MarkRead godoc
+// @Summary Отметить уведомление прочитанным
+// @Tags notifications
+// @Param id path int true "ID уведомления"
+// @Success 204 "уведомление прочитано"
+// @Failure 400 {object} map[string]string "неверный ID уведомления"
+// @Failure 401 {object} map[string]string "пользователь не аутентифицирован"
+// @Failure 404 {object} map[string]string "уведомление не найдено"
+// @Router /notifications/{id}/read [put]
+func (h *NotificationHandler) MarkRead(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(errors.NewBadRequestError("Неверный ID уведомления", err))
+		return
+	}
+
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.Error(errors.NewUnauthorizedError("Пользователь не аутентифицирован", nil))
+		return
+	}
+
+	if err := h.service.MarkRead(int(userID.(uint32)), id); err != nil {
+		c.Error(middleware.ToForumError(err, "Ошибка при отметке уведомления"))
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// MarkAllRead godoc
+// @Summary Отметить все уведомления прочитанными
+// @Tags notifications
+// @Produce json
+// @Success 200 {object} map[string]int "updated - число отмеченных уведомлений"
+// @Failure 401 {object} map[string]string "пользователь не аутентифицирован"
+// @Router /notifications/read-all [put]
+func (h *NotificationHandler) MarkAllRead(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.Error(errors.NewUnauthorizedError("Пользователь не аутентифицирован", nil))
+		return
+	}
+
+	updated, err := h.service.MarkAllRead(int(userID.(uint32)))
+	if err != nil {
+		c.Error(middleware.ToForumError(err, "Ошибка при отметке уведомлений"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"updated": updated})
+}
+
+// logNotificationError записывает в лог ошибку уведомлений о новой или измененной записи.
+// Запись к этому моменту уже сохранена, поэтому ошибка не меняет ответ клиенту.
+func logNotificationError(err error, what string) {
+	if err != nil {
+		logger.GetLogger().Error("Ошибка при отправке уведомлений о "+what, zap.Error(err))
+	}
+}
+
+// AttachPostMentions заполняет упоминания в постах и их текст в HTML
+func AttachPostMentions(notifications service.NotificationService, posts []*models.Post) error {
+	if notifications != nil && len(posts) > 0 {
+		ids := make([]int, len(posts))
+		for i, post := range posts {
+			ids[i] = post.ID
+		}
+		mentions, err := notifications.Mentions(models.MentionTargetPost, ids)
+		if err != nil {
+			return err
+		}
+		for _, post := range posts {
+			post.Mentions = mentions[post.ID]
+		}
+	}
+	for _, post := range posts {
+		post.ContentHTML = string(RenderPost(post))
+	}
+	return nil
+}
+
+// AttachCommentMentions заполняет упоминания в комментариях и их текст в HTML
+func AttachCommentMentions(notifications service.NotificationService, comments []models.Comment) error {
+	if notifications != nil && len(comments) > 0 {
+		ids := make([]int, len(comments))
+		for i, comment := range comments {
+			ids[i] = comment.ID
+		}
+		mentions, err := notifications.Mentions(models.MentionTargetComment, ids)
+		if err != nil {
+			return err
+		}
+		for i := range comments {
+			comments[i].Mentions = mentions[comments[i].ID]
+		}
+	}
+	for i := range comments {
+		comments[i].ContentHTML = string(RenderContent(comments[i].Content, comments[i].Mentions))
+	}
+	return nil
+}
+
+// AttachMessageMentions заполняет упоминания в сообщениях чата и их текст в HTML
+func AttachMessageMentions(notifications service.NotificationService, messages []*models.ChatMessage) error {
+	if notifications != nil && len(messages) > 0 {
+		ids := make([]int, len(messages))
+		for i, message := range messages {
+			ids[i] = message.ID
+		}
+		mentions, err := notifications.Mentions(models.MentionTargetChat, ids)
+		if err != nil {
+			return err
+		}
+		for _, message := range messages {
+			message.Mentions = mentions[message.ID]
+		}
+	}
+	for _, message := range messages {
+		message.ContentHTML = string(RenderContent(message.Content, message.Mentions))
+	}
+	return nil
+}
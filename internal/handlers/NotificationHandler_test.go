@@ -0,0 +1,251 @@
+package handlers
+
+import (
+	"ForumService/internal/handlers/mocks"
+	"ForumService/internal/middleware"
+	"ForumService/internal/models"
+	"ForumService/internal/service"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupNotificationTestRouter(handler *NotificationHandler, userID uint32) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(middleware.ErrorHandler())
+	router.Use(func(c *gin.Context) {
+		if userID != 0 {
+			c.Set("user_id", userID)
+		}
+		c.Next()
+	})
+	router.POST("/subscriptions", handler.Subscribe)
+	router.DELETE("/subscriptions", handler.Unsubscribe)
+	router.GET("/notifications", handler.GetNotifications)
+	router.GET("/notifications/unread-count", handler.GetUnreadCount)
+	router.PUT("/notifications/read-all", handler.MarkAllRead)
+	router.PUT("/notifications/:id/read", handler.MarkRead)
+	return router
+}
+
+func TestNotificationHandler_Subscribe(t *testing.T) {
+	tests := []struct {
+		name           string
+		userID         uint32
+		body           string
+		err            error
+		expectedStatus int
+	}{
+		{"успешно", 1, `{"target_type":"thread","target_id":5}`, nil, http.StatusNoContent},
+		{"не аутентифицирован", 0, `{"target_type":"thread","target_id":5}`, nil, http.StatusUnauthorized},
+		{"без записи", 1, `{"target_type":"thread"}`, nil, http.StatusBadRequest},
+		{"неизвестный вид записи", 1, `{"target_type":"chat","target_id":5}`, service.ErrInvalidSubscription, http.StatusBadRequest},
+		{"тред не найден", 1, `{"target_type":"thread","target_id":5}`, service.ErrThreadNotFound, http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := &mocks.MockNotificationService{
+				SubscribeFunc: func(userID int, role string, targetType string, targetID int) error {
+					return tt.err
+				},
+			}
+			router := setupNotificationTestRouter(NewNotificationHandler(mockService), tt.userID)
+
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("POST", "/subscriptions", bytes.NewBufferString(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+		})
+	}
+}
+
+func TestNotificationHandler_Unsubscribe(t *testing.T) {
+	mockService := &mocks.MockNotificationService{
+		UnsubscribeFunc: func(userID int, targetType string, targetID int) error {
+			if targetID == 99 {
+				return service.ErrSubscriptionNotFound
+			}
+			return nil
+		},
+	}
+	router := setupNotificationTestRouter(NewNotificationHandler(mockService), 2)
+
+	for path, status := range map[string]int{
+		"/subscriptions?target_type=post&target_id=1":  http.StatusNoContent,
+		"/subscriptions?target_type=post&target_id=99": http.StatusNotFound,
+		"/subscriptions?target_type=post":              http.StatusBadRequest,
+	} {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("DELETE", path, nil)
+		router.ServeHTTP(w, req)
+		assert.Equal(t, status, w.Code, path)
+	}
+}
+
+func TestNotificationHandler_GetNotifications(t *testing.T) {
+	var gotUnread bool
+	var gotPage, gotLimit int
+	mockService := &mocks.MockNotificationService{
+		ListFunc: func(userID int, unreadOnly bool, page, limit int) (*models.NotificationPage, error) {
+			gotUnread, gotPage, gotLimit = unreadOnly, page, limit
+			return &models.NotificationPage{
+				Notifications: []models.Notification{{ID: 10, UserID: userID, Type: models.NotificationReply, Title: "Тред"}},
+				Unread:        3,
+				Total:         1,
+				Page:          1,
+				Limit:         20,
+			}, nil
+		},
+	}
+	router := setupNotificationTestRouter(NewNotificationHandler(mockService), 2)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/notifications?unread=true&page=2&limit=5", nil)
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.True(t, gotUnread)
+	assert.Equal(t, 2, gotPage)
+	assert.Equal(t, 5, gotLimit)
+	var page models.NotificationPage
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &page))
+	assert.Equal(t, 3, page.Unread)
+	require.Len(t, page.Notifications, 1)
+	assert.Equal(t, models.NotificationReply, page.Notifications[0].Type)
+
+	for _, path := range []string{"/notifications?unread=maybe", "/notifications?page=abc"} {
+		w = httptest.NewRecorder()
+		req, _ = http.NewRequest("GET", path, nil)
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusBadRequest, w.Code, path)
+	}
+
+	router = setupNotificationTestRouter(NewNotificationHandler(mockService), 0)
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/notifications", nil)
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
+
+func TestNotificationHandler_MarkRead(t *testing.T) {
+	mockService := &mocks.MockNotificationService{
+		UnreadCountFunc: func(userID int) (int, error) { return 4, nil },
+		MarkReadFunc: func(userID, notificationID int) error {
+			if notificationID == 99 {
+				return service.ErrNotificationNotFound
+			}
+			return nil
+		},
+		MarkAllReadFunc: func(userID int) (int, error) { return 4, nil },
+	}
+	router := setupNotificationTestRouter(NewNotificationHandler(mockService), 2)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/notifications/unread-count", nil)
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"unread":4}`, w.Body.String())
+
+	for path, status := range map[string]int{
+		"/notifications/10/read":  http.StatusNoContent,
+		"/notifications/99/read":  http.StatusNotFound,
+		"/notifications/abc/read": http.StatusBadRequest,
+	} {
+		w = httptest.NewRecorder()
+		req, _ = http.NewRequest("PUT", path, nil)
+		router.ServeHTTP(w, req)
+		assert.Equal(t, status, w.Code, path)
+	}
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("PUT", "/notifications/read-all", nil)
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"updated":4}`, w.Body.String())
+}
+
+func TestCreateHandlers_Notify(t *testing.T) {
+	var thread *models.Thread
+	var post *models.Post
+	var comments []*models.Comment
+	notifications := &mocks.MockNotificationService{
+		ThreadCreatedFunc: func(created *models.Thread) error {
+			thread = created
+			return nil
+		},
+		PostCreatedFunc: func(created *models.Post) error {
+			post = created
+			return errors.New("уведомления недоступны")
+		},
+		CommentCreatedFunc: func(created *models.Comment) error {
+			comments = append(comments, created)
+			return nil
+		},
+	}
+	threadService := &mocks.MockThreadService{
+		CreateThreadFunc: func(title string, threadType string, categoryID *int, tags []string, authorID int) (*models.Thread, error) {
+			return &models.Thread{ID: 1, Title: title, AuthorID: authorID}, nil
+		},
+	}
+	postService := &mocks.MockPostService{
+		CreatePostFunc: func(created *models.Post) error {
+			created.ID = 5
+			return nil
+		},
+	}
+	commentService := &mocks.MockCommentService{
+		CreateCommentFunc: func(postID int, authorID int, content string) (*models.Comment, error) {
+			return &models.Comment{ID: 7, PostID: postID, AuthorID: authorID, Content: content}, nil
+		},
+		ReplyToCommentFunc: func(postID int, parentID int, authorID int, content string) (*models.Comment, error) {
+			return &models.Comment{ID: 8, PostID: postID, ParentCommentID: &parentID, AuthorID: authorID, Content: content}, nil
+		},
+	}
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(middleware.ErrorHandler())
+	router.Use(func(c *gin.Context) {
+		c.Set("user_id", uint32(2))
+		c.Next()
+	})
+	router.POST("/threads", NewThreadHandler(threadService).WithNotifications(notifications).CreateThread)
+	router.POST("/posts", NewPostHandler(postService).WithNotifications(notifications).CreatePost)
+	router.POST("/comments", NewCommentHandler(commentService).WithNotifications(notifications).CreateComment)
+
+	for path, body := range map[string]string{
+		"/threads":  `{"title":"Новый тред"}`,
+		"/posts":    `{"thread_id":1,"content":"Пост"}`,
+		"/comments": `{"post_id":5,"content":"Комментарий"}`,
+	} {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", path, bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusCreated, w.Code, "ошибка уведомлений не меняет ответ: %s", path)
+	}
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/comments", bytes.NewBufferString(`{"post_id":5,"parent_comment_id":7,"content":"Ответ"}`))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusCreated, w.Code)
+
+	require.NotNil(t, thread)
+	assert.Equal(t, 2, thread.AuthorID)
+	require.NotNil(t, post)
+	assert.Equal(t, 5, post.ID)
+	require.Len(t, comments, 2)
+	assert.Equal(t, 7, comments[0].ID)
+	assert.Equal(t, 8, comments[1].ID)
+}
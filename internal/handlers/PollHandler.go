@@ -0,0 +1,246 @@
+package handlers
+
+import (
+	"ForumService/internal/errors"
+	"ForumService/internal/middleware"
+	"ForumService/internal/models"
+	"ForumService/internal/service"
+	stdErrors "errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Luxtington/Shared/logger"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// PollMessageType - тип сообщения Hub с новыми итогами опроса
+const PollMessageType = "poll"
+
+type PollHandler struct {
+	service service.PollService
+	threads service.ThreadService
+	hub     *Hub
+}
+
+// NewPollHandler создает обработчик опросов. Новые итоги рассылаются через hub;
+// при nil рассылки нет.
+func NewPollHandler(service service.PollService, threads service.ThreadService, hub *Hub) *PollHandler {
+	return &PollHandler{service: service, threads: threads, hub: hub}
+}
+
+// CreatePollRequest - опрос, создаваемый вместе с тредом
+type CreatePollRequest struct {
+	Question string `json:"question"`
+	// Options - варианты ответа, от 2 до 10
+	Options []string `json:"options"`
+	// MultipleChoice разрешает выбрать несколько вариантов
+	MultipleChoice bool `json:"multiple_choice"`
+	// Anonymous скрывает, кто за что проголосовал
+	Anonymous bool `json:"anonymous"`
+	// ClosesAt - время автоматического закрытия, без него опрос закрывают вручную
+	ClosesAt *time.Time `json:"closes_at"`
+}
+
+func (r *CreatePollRequest) toPoll() *models.Poll {
+	poll := &models.Poll{
+		Question:       r.Question,
+		MultipleChoice: r.MultipleChoice,
+		Anonymous:      r.Anonymous,
+		ClosesAt:       r.ClosesAt,
+		Options:        make([]models.PollOption, len(r.Options)),
+	}
+	for i, text := range r.Options {
+		poll.Options[i].Text = text
+	}
+	return poll
+}
+
+type PollVoteRequest struct {
+	// OptionIDs - выбранные варианты; в опросе с одним выбором - ровно один
+	OptionIDs []int `json:"option_ids" binding:"required,min=1"`
+}
+
+// PollMessage - событие Hub с итогами опроса. Голоса получателя (my_votes) в нем
+// не отмечены: событие получают все клиенты.
+type PollMessage struct {
+	Type string       `json:"type"`
+	Poll *models.Poll `json:"poll"`
+}
+
+// GetThreadPoll godoc
+// @Summary Получить опрос треда
+// @Description Возвращает опрос треда с итогами. В открытом опросе для вариантов перечислены проголосовавшие,
+// @Description в анонимном - только число голосов. my_votes отмечает голоса текущего пользователя.
+// @Tags polls
+// @Produce json
+// @Param id path int true "ID треда"
+// @Success 200 {object} models.Poll
+// @Failure 400 {object} map[string]string "неверный ID треда"
+// @Failure 403 {object} map[string]string "нет прав на чтение раздела треда"
+// @Failure 404 {object} map[string]string "тред не найден или в нем нет опроса"
+// @Router /threads/{id}/poll [get]
+func (h *PollHandler) GetThreadPoll(c *gin.Context) {
+	threadID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(errors.NewBadRequestError("Неверный ID треда", err))
+		return
+	}
+	if _, ok := h.checkAccess(c, threadID); !ok {
+		return
+	}
+
+	poll, err := h.service.GetByThread(threadID, viewerID(c))
+	if err != nil {
+		c.Error(middleware.ToForumError(err, "Ошибка при получении опроса"))
+		return
+	}
+
+	c.JSON(http.StatusOK, poll)
+}
+
+// VotePoll godoc
+// @Summary Проголосовать в опросе
+// @Description Сохраняет голос пользователя, повторный запрос заменяет прежний голос.
+// @Description Новые итоги рассылаются подключенным клиентам сообщением с типом "poll".
+// @Tags polls
+// @Accept json
+// @Produce json
+// @Param id path int true "ID опроса"
+// @Param input body PollVoteRequest true "Выбранные варианты"
+// @Success 200 {object} models.Poll
+// @Failure 400 {object} map[string]string "неверный ID опроса, вариант другого опроса или несколько вариантов в опросе с одним выбором"
+// @Failure 401 {object} map[string]string "пользователь не аутентифицирован"
+// @Failure 403 {object} map[string]string "нет прав на чтение раздела треда"
+// @Failure 404 {object} map[string]string "опрос не найден"
+// @Failure 409 {object} map[string]string "опрос закрыт"
+// @Router /polls/{id}/vote [post]
+func (h *PollHandler) VotePoll(c *gin.Context) {
+	var request PollVoteRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.Error(errors.NewValidationError("Выберите хотя бы один вариант", err))
+		return
+	}
+	h.vote(c, request.OptionIDs)
+}
+
+// RetractPollVote godoc
+// @Summary Отозвать голос в опросе
+// @Description Снимает голос пользователя, пока опрос не закрыт. Новые итоги рассылаются подключенным клиентам.
+// @Tags polls
+// @Produce json
+// @Param id path int true "ID опроса"
+// @Success 200 {object} models.Poll
+// @Failure 400 {object} map[string]string "неверный ID опроса"
+// @Failure 401 {object} map[string]string "пользователь не аутентифицирован"
+// @Failure 403 {object} map[string]string "нет прав на чтение раздела треда"
+// @Failure 404 {object} map[string]string "опрос не найден"
+// @Failure 409 {object} map[string]string "опрос закрыт"
+// @Router /polls/{id}/vote [delete]
+func (h *PollHandler) RetractPollVote(c *gin.Context) {
+	h.vote(c, []int{})
+}
+
+// ClosePoll godoc
+// @Summary Закрыть опрос
+// @Description Завершает голосование. Доступно автору треда, модераторам и администраторам;
+// @Description повторное закрытие ничего не меняет. Итоги рассылаются подключенным клиентам.
+// @Tags polls
+// @Produce json
+// @Param id path int true "ID опроса"
+// @Success 200 {object} models.Poll
+// @Failure 400 {object} map[string]string "неверный ID опроса"
+// @Failure 401 {object} map[string]string "пользователь не аутентифицирован"
+// @Failure 403 {object} map[string]string "нет прав на закрытие опроса"
+// @Failure 404 {object} map[string]string "опрос не найден"
+// @Router /polls/{id}/close [post]
+func (h *PollHandler) ClosePoll(c *gin.Context) {
+	h.change(c, "Ошибка при закрытии опроса", func(pollID, userID int) (*models.Poll, error) {
+		return h.service.Close(pollID, userID)
+	})
+}
+
+func (h *PollHandler) vote(c *gin.Context, optionIDs []int) {
+	h.change(c, "Ошибка при голосовании", func(pollID, userID int) (*models.Poll, error) {
+		return h.service.Vote(pollID, userID, optionIDs)
+	})
+}
+
+// change проверяет доступ к треду опроса, применяет apply и рассылает новые итоги
+func (h *PollHandler) change(c *gin.Context, fallback string, apply func(pollID, userID int) (*models.Poll, error)) {
+	pollID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(errors.NewBadRequestError("Неверный ID опроса", err))
+		return
+	}
+
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.Error(errors.NewUnauthorizedError("Пользователь не аутентифицирован", nil))
+		return
+	}
+
+	poll, err := h.service.Get(pollID, 0)
+	if err != nil {
+		c.Error(middleware.ToForumError(err, fallback))
+		return
+	}
+	thread, ok := h.checkAccess(c, poll.ThreadID)
+	if !ok {
+		return
+	}
+
+	poll, err = apply(pollID, int(userID.(uint32)))
+	if err != nil {
+		c.Error(middleware.ToForumError(err, fallback))
+		return
+	}
+
+	c.JSON(http.StatusOK, poll)
+	h.broadcast(thread, poll)
+}
+
+// checkAccess проверяет, что пользователь может читать тред опроса
+func (h *PollHandler) checkAccess(c *gin.Context, threadID int) (*models.Thread, bool) {
+	thread, _, err := h.threads.GetThreadWithPosts(threadID)
+	if err != nil {
+		c.Error(middleware.ToForumError(err, "Ошибка при получении треда"))
+		return nil, false
+	}
+	if err := h.threads.CheckThreadAccess(thread, ViewerRole(c)); err != nil {
+		c.Error(middleware.ToForumError(err, "Ошибка при получении треда"))
+		return nil, false
+	}
+	return thread, true
+}
+
+// broadcast рассылает итоги опроса всем клиентам Hub. Опросы тредов из разделов,
+// закрытых для обычных пользователей, не рассылаются: Hub не знает ролей клиентов.
+func (h *PollHandler) broadcast(thread *models.Thread, poll *models.Poll) {
+	if h.hub == nil || h.threads.CheckThreadAccess(thread, string(models.RoleUser)) != nil {
+		return
+	}
+	event := *poll
+	event.MyVotes = nil
+	if err := h.hub.BroadcastJSON(PollMessage{Type: PollMessageType, Poll: &event}); err != nil {
+		logger.GetLogger().Error("Ошибка при рассылке итогов опроса", zap.Error(err))
+	}
+}
+
+// AttachThreadPoll заполняет опрос треда, userID отмечает голоса пользователя.
+// У треда без опроса Poll остается nil.
+func AttachThreadPoll(polls service.PollService, thread *models.Thread, userID int) error {
+	if polls == nil {
+		return nil
+	}
+	poll, err := polls.GetByThread(thread.ID, userID)
+	if stdErrors.Is(err, service.ErrPollNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	thread.Poll = poll
+	return nil
+}
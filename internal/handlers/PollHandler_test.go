@@ -0,0 +1,380 @@
+package handlers
+
+import (
+	"ForumService/internal/handlers/mocks"
+	"ForumService/internal/middleware"
+	"ForumService/internal/models"
+	"ForumService/internal/service"
+	"bytes"
+	"encoding/json"
+	stdErrors "errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupPollTestRouter(handler *PollHandler, userID uint32) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(middleware.ErrorHandler())
+	router.Use(func(c *gin.Context) {
+		if userID != 0 {
+			c.Set("user_id", userID)
+			c.Set("user_role", "user")
+		}
+		c.Next()
+	})
+	router.GET("/threads/:id/poll", handler.GetThreadPoll)
+	router.POST("/polls/:id/vote", handler.VotePoll)
+	router.DELETE("/polls/:id/vote", handler.RetractPollVote)
+	router.POST("/polls/:id/close", handler.ClosePoll)
+	return router
+}
+
+func testHandlerPoll() *models.Poll {
+	return &models.Poll{
+		ID:       7,
+		ThreadID: 3,
+		Question: "Когда релиз?",
+		Options:  []models.PollOption{{ID: 11, Text: "В пятницу"}, {ID: 12, Text: "В понедельник"}},
+	}
+}
+
+func pollThreadService(accessErr error) *mocks.MockThreadService {
+	return &mocks.MockThreadService{
+		GetThreadWithPostsFunc: func(id int) (*models.Thread, []*models.Post, error) {
+			return &models.Thread{ID: id, AuthorID: 1}, nil, nil
+		},
+		CheckThreadAccessFunc: func(thread *models.Thread, role string) error {
+			return accessErr
+		},
+	}
+}
+
+func TestPollHandler_VotePoll(t *testing.T) {
+	tests := []struct {
+		name           string
+		userID         uint32
+		path           string
+		body           string
+		accessErr      error
+		err            error
+		expectedStatus int
+	}{
+		{"успешно", 2, "/polls/7/vote", `{"option_ids":[11]}`, nil, nil, http.StatusOK},
+		{"не аутентифицирован", 0, "/polls/7/vote", `{"option_ids":[11]}`, nil, nil, http.StatusUnauthorized},
+		{"неверный ID", 2, "/polls/abc/vote", `{"option_ids":[11]}`, nil, nil, http.StatusBadRequest},
+		{"без вариантов", 2, "/polls/7/vote", `{"option_ids":[]}`, nil, nil, http.StatusBadRequest},
+		{"нет доступа к треду", 2, "/polls/7/vote", `{"option_ids":[11]}`, service.ErrNoPermission, nil, http.StatusForbidden},
+		{"опрос закрыт", 2, "/polls/7/vote", `{"option_ids":[11]}`, nil, service.ErrPollClosed, http.StatusConflict},
+		{"вариант другого опроса", 2, "/polls/7/vote", `{"option_ids":[99]}`, nil, service.ErrPollOptionNotFound, http.StatusBadRequest},
+		{"несколько вариантов", 2, "/polls/7/vote", `{"option_ids":[11,12]}`, nil, service.ErrInvalidPollChoice, http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			voted := false
+			mockService := &mocks.MockPollService{
+				GetFunc: func(pollID, userID int) (*models.Poll, error) {
+					return testHandlerPoll(), nil
+				},
+				VoteFunc: func(pollID, userID int, optionIDs []int) (*models.Poll, error) {
+					voted = true
+					if tt.err != nil {
+						return nil, tt.err
+					}
+					poll := testHandlerPoll()
+					poll.MyVotes = optionIDs
+					return poll, nil
+				},
+			}
+			router := setupPollTestRouter(NewPollHandler(mockService, pollThreadService(tt.accessErr), nil), tt.userID)
+
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("POST", tt.path, bytes.NewBufferString(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.accessErr != nil {
+				assert.False(t, voted, "без доступа к треду голос не сохраняется")
+			}
+		})
+	}
+}
+
+func TestPollHandler_RetractPollVote(t *testing.T) {
+	var gotOptions []int
+	mockService := &mocks.MockPollService{
+		GetFunc: func(pollID, userID int) (*models.Poll, error) {
+			return testHandlerPoll(), nil
+		},
+		VoteFunc: func(pollID, userID int, optionIDs []int) (*models.Poll, error) {
+			gotOptions = optionIDs
+			return testHandlerPoll(), nil
+		},
+	}
+	router := setupPollTestRouter(NewPollHandler(mockService, pollThreadService(nil), nil), 2)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("DELETE", "/polls/7/vote", nil)
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.NotNil(t, gotOptions)
+	assert.Empty(t, gotOptions, "отзыв голоса - голос без вариантов")
+}
+
+func TestPollHandler_ClosePoll(t *testing.T) {
+	tests := []struct {
+		name           string
+		err            error
+		expectedStatus int
+	}{
+		{"успешно", nil, http.StatusOK},
+		{"нет прав", service.ErrNoPermission, http.StatusForbidden},
+		{"опрос не найден", service.ErrPollNotFound, http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := &mocks.MockPollService{
+				GetFunc: func(pollID, userID int) (*models.Poll, error) {
+					if tt.err == service.ErrPollNotFound {
+						return nil, tt.err
+					}
+					return testHandlerPoll(), nil
+				},
+				CloseFunc: func(pollID, userID int) (*models.Poll, error) {
+					if tt.err != nil {
+						return nil, tt.err
+					}
+					poll := testHandlerPoll()
+					poll.Closed = true
+					return poll, nil
+				},
+			}
+			router := setupPollTestRouter(NewPollHandler(mockService, pollThreadService(nil), nil), 2)
+
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("POST", "/polls/7/close", nil)
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+		})
+	}
+}
+
+func TestPollHandler_VotePoll_Broadcast(t *testing.T) {
+	mockService := &mocks.MockPollService{
+		GetFunc: func(pollID, userID int) (*models.Poll, error) {
+			return testHandlerPoll(), nil
+		},
+		VoteFunc: func(pollID, userID int, optionIDs []int) (*models.Poll, error) {
+			poll := testHandlerPoll()
+			poll.Options[0].Votes = 1
+			poll.TotalVoters = 1
+			poll.MyVotes = optionIDs
+			return poll, nil
+		},
+	}
+	hub := NewHub(&mocks.MockChatRepository{})
+	go hub.Run()
+	client := &Client{Send: make(chan []byte, 1), Username: "bob", UserID: 3}
+	hub.Register <- client
+
+	router := setupPollTestRouter(NewPollHandler(mockService, pollThreadService(nil), hub), 2)
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/polls/7/vote", bytes.NewBufferString(`{"option_ids":[11]}`))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusOK, w.Code)
+	var response models.Poll
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, []int{11}, response.MyVotes, "проголосовавший видит свой голос")
+
+	select {
+	case payload := <-client.Send:
+		var event struct {
+			Type string      `json:"type"`
+			Poll models.Poll `json:"poll"`
+		}
+		require.NoError(t, json.Unmarshal(payload, &event))
+		assert.Equal(t, PollMessageType, event.Type)
+		assert.Equal(t, 1, event.Poll.Options[0].Votes)
+		assert.Nil(t, event.Poll.MyVotes, "в рассылке нет голосов конкретного пользователя")
+	case <-time.After(time.Second):
+		t.Fatal("итоги опроса не разосланы")
+	}
+}
+
+func TestPollHandler_VotePoll_RestrictedThreadNotBroadcast(t *testing.T) {
+	mockService := &mocks.MockPollService{
+		GetFunc: func(pollID, userID int) (*models.Poll, error) {
+			return testHandlerPoll(), nil
+		},
+		VoteFunc: func(pollID, userID int, optionIDs []int) (*models.Poll, error) {
+			return testHandlerPoll(), nil
+		},
+	}
+	threads := pollThreadService(nil)
+	threads.CheckThreadAccessFunc = func(thread *models.Thread, role string) error {
+		if role == string(models.RoleUser) {
+			return service.ErrNoPermission
+		}
+		return nil
+	}
+	hub := NewHub(&mocks.MockChatRepository{})
+	go hub.Run()
+	client := &Client{Send: make(chan []byte, 1), Username: "bob", UserID: 3}
+	hub.Register <- client
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(middleware.ErrorHandler())
+	router.POST("/polls/:id/vote", func(c *gin.Context) {
+		c.Set("user_id", uint32(5))
+		c.Set("user_role", "moderator")
+		c.Next()
+	}, NewPollHandler(mockService, threads, hub).VotePoll)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/polls/7/vote", bytes.NewBufferString(`{"option_ids":[11]}`))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusOK, w.Code)
+	select {
+	case <-client.Send:
+		t.Fatal("итоги опроса из закрытого раздела разосланы всем")
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestPollHandler_GetThreadPoll(t *testing.T) {
+	var gotUser int
+	mockService := &mocks.MockPollService{
+		GetByThreadFunc: func(threadID, userID int) (*models.Poll, error) {
+			gotUser = userID
+			if threadID == 4 {
+				return nil, service.ErrPollNotFound
+			}
+			return testHandlerPoll(), nil
+		},
+	}
+	router := setupPollTestRouter(NewPollHandler(mockService, pollThreadService(nil), nil), 2)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/threads/3/poll", nil)
+	router.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, 2, gotUser, "голоса отмечаются для текущего пользователя")
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/threads/4/poll", nil)
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestThreadHandler_CreateThread_WithPoll(t *testing.T) {
+	created := false
+	var pollThread int
+	threadService := &mocks.MockThreadService{
+		CreateThreadFunc: func(title string, threadType string, categoryID *int, tags []string, authorID int) (*models.Thread, error) {
+			created = true
+			return &models.Thread{ID: 3, Title: title, AuthorID: authorID}, nil
+		},
+	}
+	pollService := &mocks.MockPollService{
+		ValidateFunc: func(poll *models.Poll) error {
+			if len(poll.Options) < service.MinPollOptions {
+				return service.ErrInvalidPollOptions
+			}
+			return nil
+		},
+		CreateFunc: func(threadID int, poll *models.Poll) error {
+			pollThread = threadID
+			poll.ID = 7
+			poll.ThreadID = threadID
+			return nil
+		},
+	}
+	handler := NewThreadHandler(threadService).WithPolls(pollService)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(middleware.ErrorHandler())
+	router.POST("/threads", func(c *gin.Context) {
+		c.Set("user_id", uint32(1))
+		c.Next()
+	}, handler.CreateThread)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/threads", bytes.NewBufferString(
+		`{"title":"Релиз","poll":{"question":"Когда релиз?","options":["В пятницу","В понедельник"],"multiple_choice":true}}`))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusCreated, w.Code)
+	var thread models.Thread
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &thread))
+	require.NotNil(t, thread.Poll)
+	assert.Equal(t, 7, thread.Poll.ID)
+	assert.True(t, thread.Poll.MultipleChoice)
+	assert.Equal(t, "В понедельник", thread.Poll.Options[1].Text)
+	assert.Equal(t, 3, pollThread)
+
+	created = false
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("POST", "/threads", bytes.NewBufferString(
+		`{"title":"Релиз","poll":{"question":"Когда релиз?","options":["В пятницу"]}}`))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.False(t, created, "тред с неверным опросом не создается")
+}
+
+func TestThreadHandler_CreateThread_PollFailureDeletesThread(t *testing.T) {
+	var deleted int
+	threadService := &mocks.MockThreadService{
+		CreateThreadFunc: func(title string, threadType string, categoryID *int, tags []string, authorID int) (*models.Thread, error) {
+			return &models.Thread{ID: 3, Title: title, AuthorID: authorID}, nil
+		},
+		DeleteThreadFunc: func(id int, userID int) error {
+			deleted = id
+			assert.Equal(t, 1, userID)
+			return nil
+		},
+	}
+	pollService := &mocks.MockPollService{
+		ValidateFunc: func(poll *models.Poll) error { return nil },
+		CreateFunc: func(threadID int, poll *models.Poll) error {
+			return stdErrors.New("db error")
+		},
+	}
+	handler := NewThreadHandler(threadService).WithPolls(pollService)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(middleware.ErrorHandler())
+	router.POST("/threads", func(c *gin.Context) {
+		c.Set("user_id", uint32(1))
+		c.Next()
+	}, handler.CreateThread)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/threads", bytes.NewBufferString(
+		`{"title":"Релиз","poll":{"question":"Когда релиз?","options":["В пятницу","В понедельник"]}}`))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.Equal(t, 3, deleted, "тред без опроса удаляется")
+}
@@ -4,31 +4,101 @@ import (
 	"ForumService/internal/models"
 	"ForumService/internal/service"
 	"ForumService/internal/errors"
+	"ForumService/internal/middleware"
 	"github.com/gin-gonic/gin"
 	"net/http"
 	"strconv"
 )
 
 type PostHandler struct {
-	service service.PostService
+	service       service.PostService
+	reactions     service.ReactionService
+	notifications service.NotificationService
+	attachments   service.AttachmentService
+	threads       service.ThreadService
 }
 
 func NewPostHandler(service service.PostService) *PostHandler {
 	return &PostHandler{service: service}
 }
 
+// WithReactions добавляет реакции в ответы GetPost и GetPostComments
+func (h *PostHandler) WithReactions(reactions service.ReactionService) *PostHandler {
+	h.reactions = reactions
+	return h
+}
+
+// WithNotifications уведомляет подписчиков треда и упомянутых пользователей о созданных
+// и измененных постах и добавляет упоминания в ответы GetPost и GetPostComments
+func (h *PostHandler) WithNotifications(notifications service.NotificationService) *PostHandler {
+	h.notifications = notifications
+	return h
+}
+
+// WithAttachments прикрепляет к постам загруженные файлы из attachment_ids
+// и добавляет вложения в ответы GetPost и GetPostComments
+func (h *PostHandler) WithAttachments(attachments service.AttachmentService) *PostHandler {
+	h.attachments = attachments
+	return h
+}
+
+// WithThreads проверяет права на чтение раздела треда во всех ответах с постами и комментариями
+func (h *PostHandler) WithThreads(threads service.ThreadService) *PostHandler {
+	h.threads = threads
+	return h
+}
+
+// checkThreadAccess проверяет, что пользователь может читать раздел треда threadID
+func (h *PostHandler) checkThreadAccess(c *gin.Context, threadID int) error {
+	if h.threads == nil {
+		return nil
+	}
+	return h.threads.CheckThreadIDAccess(threadID, ViewerRole(c))
+}
+
+// checkPostAccess проверяет доступ к разделу треда поста postID
+func (h *PostHandler) checkPostAccess(c *gin.Context, postID int) error {
+	if h.threads == nil {
+		return nil
+	}
+	return h.threads.CheckPostAccess(postID, ViewerRole(c))
+}
+
+// readablePosts убирает посты из разделов, закрытых для пользователя
+func (h *PostHandler) readablePosts(c *gin.Context, posts []*models.Post) ([]*models.Post, error) {
+	if h.threads == nil {
+		return posts, nil
+	}
+	return h.threads.FilterReadablePosts(posts, ViewerRole(c))
+}
+
 type CreatePostRequest struct {
 	ThreadID int    `json:"thread_id" binding:"required"`
+	Title    string `json:"title"`
 	Content  string `json:"content" binding:"required"`
+	// ReplyToPostID - пост того же треда, на который отвечает новый пост
+	ReplyToPostID *int `json:"reply_to_post_id"`
+	// AttachmentIDs - загруженные файлы, которые прикрепляются к посту
+	AttachmentIDs []int `json:"attachment_ids"`
 }
 
 type UpdatePostRequest struct {
-	Content string `json:"content" binding:"required"`
+	// Title - новый заголовок; если не передан, заголовок не меняется
+	Title   *string `json:"title"`
+	Content string  `json:"content" binding:"required"`
+	Reason  string  `json:"reason" binding:"max=255"`
+	// AttachmentIDs - новый список вложений; если не передан, вложения не меняются
+	AttachmentIDs []int `json:"attachment_ids"`
+}
+
+type PinPostRequest struct {
+	// Pinned - true закрепляет пост в начале треда, false снимает закрепление
+	Pinned *bool `json:"pinned" binding:"required"`
 }
 
 // GetAllPosts godoc
 // @Summary Получить все посты
-// @Description Возвращает список всех постов форума.
+// @Description Возвращает список всех постов форума, кроме постов из разделов, закрытых для пользователя.
 // @Tags posts
 // @Produce json
 // @Success 200 {array} models.Post
@@ -37,7 +107,11 @@ type UpdatePostRequest struct {
 func (h *PostHandler) GetAllPosts(c *gin.Context) {
 	posts, err := h.service.GetAllPosts()
 	if err != nil {
-		c.Error(errors.NewInternalServerError("Ошибка при получении списка постов", err))
+		c.Error(middleware.ToForumError(err, "Ошибка при получении списка постов"))
+		return
+	}
+	if posts, err = h.readablePosts(c, posts); err != nil {
+		c.Error(middleware.ToForumError(err, "Ошибка при получении списка постов"))
 		return
 	}
 
@@ -60,14 +134,18 @@ func (h *PostHandler) ShowCreateForm(c *gin.Context) {
 // CreatePost godoc
 // @Summary Создать новый пост
 // @Description Создаёт новый пост в указанном треде. Доступно только авторизованным пользователям.
+// @Description reply_to_post_id делает пост ответом на другой пост того же треда. Текст может
+// @Description цитировать другие посты: [quote=ID]текст[/quote]. attachment_ids прикрепляет файлы,
+// @Description загруженные через POST /attachments.
 // @Tags posts
 // @Accept json
 // @Produce json
 // @Param input body object true "Данные для создания поста"
 // @Success 201 {object} models.Post
-// @Failure 400 {object} map[string]string "неверный формат данных"
+// @Failure 400 {object} map[string]string "неверный формат данных или пост для ответа не из этого треда"
 // @Failure 401 {object} map[string]string "пользователь не аутентифицирован"
 // @Failure 403 {object} map[string]string "нет прав для создания поста в этом треде"
+// @Failure 404 {object} map[string]string "тред не найден или закрыт для пользователя, вложение не найдено"
 // @Failure 500 {object} map[string]string "ошибка сервера"
 // @Router /posts [post]
 func (h *PostHandler) CreatePost(c *gin.Context) {
@@ -83,29 +161,51 @@ func (h *PostHandler) CreatePost(c *gin.Context) {
 		return
 	}
 
+	if err := h.checkThreadAccess(c, request.ThreadID); err != nil {
+		c.Error(middleware.ToForumError(hideForbidden(err, service.ErrThreadNotFound), "Ошибка при создании поста"))
+		return
+	}
+
 	userIDInt := int(userID.(uint32))
 	post := &models.Post{
-		ThreadID: request.ThreadID,
-		AuthorID: userIDInt,
-		Content:  request.Content,
+		ThreadID:      request.ThreadID,
+		AuthorID:      userIDInt,
+		Title:         request.Title,
+		Content:       request.Content,
+		ReplyToPostID: request.ReplyToPostID,
+	}
+	if err := checkAttachments(h.attachments, models.AttachmentTargetPost, 0, userIDInt, request.AttachmentIDs); err != nil {
+		c.Error(middleware.ToForumError(err, "Ошибка при проверке вложений"))
+		return
 	}
 
 	if err := h.service.CreatePost(post); err != nil {
-		c.Error(errors.NewInternalServerError("Ошибка при создании поста", err))
+		c.Error(middleware.ToForumError(err, "Ошибка при создании поста"))
+		return
+	}
+	if err := h.saveAttachments(post, userIDInt, request.AttachmentIDs); err != nil {
+		c.Error(middleware.ToForumError(err, "Ошибка при прикреплении файлов"))
 		return
 	}
+	if h.notifications != nil {
+		logNotificationError(h.notifications.PostCreated(post), "новом посте")
+	}
+	post.ContentHTML = string(RenderPost(post))
 
 	c.JSON(http.StatusCreated, post)
 }
 
 // GetPost godoc
 // @Summary Получить пост по ID
-// @Description Возвращает информацию о посте по его ID.
+// @Description Возвращает информацию о посте по его ID, пост, на который он отвечает,
+// @Description процитированные посты и ответы на него.
 // @Tags posts
 // @Produce json
 // @Param id path int true "ID поста"
 // @Success 200 {object} models.Post
+// @Header 200 {string} ETag "Версия поста для заголовка If-Match"
 // @Failure 400 {object} map[string]string "invalid post ID"
+// @Failure 403 {object} map[string]string "нет прав на чтение раздела треда"
 // @Failure 404 {object} map[string]string "post not found"
 // @Router /posts/{id} [get]
 func (h *PostHandler) GetPost(c *gin.Context) {
@@ -117,10 +217,27 @@ func (h *PostHandler) GetPost(c *gin.Context) {
 
 	post, err := h.service.GetPost(id)
 	if err != nil {
-		c.Error(errors.NewNotFoundError("Пост не найден", err))
+		c.Error(middleware.ToForumError(err, "Ошибка при получении поста"))
+		return
+	}
+	if err := h.checkThreadAccess(c, post.ThreadID); err != nil {
+		c.Error(middleware.ToForumError(err, "Ошибка при получении поста"))
+		return
+	}
+	if err := AttachPostReactions(h.reactions, []*models.Post{post}, viewerID(c)); err != nil {
+		c.Error(middleware.ToForumError(err, "Ошибка при получении реакций"))
+		return
+	}
+	if err := AttachPostMentions(h.notifications, []*models.Post{post}); err != nil {
+		c.Error(middleware.ToForumError(err, "Ошибка при получении упоминаний"))
+		return
+	}
+	if err := AttachPostAttachments(h.attachments, []*models.Post{post}); err != nil {
+		c.Error(middleware.ToForumError(err, "Ошибка при получении вложений"))
 		return
 	}
 
+	c.Header("ETag", versionETag(post.Version))
 	c.JSON(http.StatusOK, post)
 }
 
@@ -146,6 +263,10 @@ func (h *PostHandler) GetPostWithComments(c *gin.Context) {
 		c.JSON(http.StatusNotFound, gin.H{"error": "post not found"})
 		return
 	}
+	if err := h.checkThreadAccess(c, post.ThreadID); err != nil {
+		c.Error(middleware.ToForumError(err, "Ошибка при получении поста"))
+		return
+	}
 
 	c.JSON(http.StatusOK, gin.H{
 		"post":     post,
@@ -179,25 +300,73 @@ func (h *PostHandler) ShowEditForm(c *gin.Context) {
 		})
 		return
 	}
+	if err := h.checkThreadAccess(c, post.ThreadID); err != nil {
+		c.HTML(http.StatusForbidden, "error.html", gin.H{
+			"error": "Нет доступа к разделу треда",
+		})
+		return
+	}
 
 	c.HTML(http.StatusOK, "edit_post.html", gin.H{
 		"title": "Редактировать пост",
-		"post":  post,
+		"Post":  post,
+	})
+}
+
+// ShowConflictPage godoc
+// @Summary Показать конфликт правок поста
+// @Description Отображает текущую версию поста, если сохранение правки завершилось ответом 412. Черновик пользователя страница берет из sessionStorage.
+// @Tags posts
+// @Produce html
+// @Param id path int true "ID поста"
+// @Success 200 {string} string "HTML страница"
+// @Failure 400 {object} map[string]string "Неверный ID поста"
+// @Failure 404 {object} map[string]string "Пост не найден"
+// @Router /posts/{id}/conflict [get]
+func (h *PostHandler) ShowConflictPage(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.HTML(http.StatusBadRequest, "error.html", gin.H{
+			"error": "Неверный ID поста",
+		})
+		return
+	}
+
+	post, err := h.service.GetPostByID(id)
+	if err != nil {
+		c.HTML(http.StatusNotFound, "error.html", gin.H{
+			"error": "Пост не найден",
+		})
+		return
+	}
+	if err := h.checkThreadAccess(c, post.ThreadID); err != nil {
+		c.HTML(http.StatusForbidden, "error.html", gin.H{
+			"error": "Нет доступа к разделу треда",
+		})
+		return
+	}
+
+	c.HTML(http.StatusOK, "post_conflict.html", gin.H{
+		"post": post,
 	})
 }
 
 // UpdatePost godoc
 // @Summary Обновить пост
 // @Description Обновляет информацию о посте. Доступно только автору поста или администратору.
+// @Description attachment_ids заменяет вложения поста, без него вложения не меняются.
 // @Tags posts
 // @Accept json
 // @Produce json
 // @Param id path int true "ID поста"
 // @Param input body object true "Данные для обновления поста"
+// @Param If-Match header string false "ETag, полученный при чтении поста"
 // @Success 200 {object} models.Post
 // @Failure 400 {object} map[string]string "invalid post ID или неверный формат данных"
 // @Failure 401 {object} map[string]string "unauthorized"
 // @Failure 403 {object} map[string]string "no permission to update this post"
+// @Failure 404 {object} map[string]string "вложение не найдено"
+// @Failure 412 {object} map[string]string "пост был изменен другим пользователем"
 // @Failure 500 {object} map[string]string "ошибка сервера"
 // @Router /posts/{id} [put]
 func (h *PostHandler) UpdatePost(c *gin.Context) {
@@ -213,6 +382,12 @@ func (h *PostHandler) UpdatePost(c *gin.Context) {
 		return
 	}
 
+	expectedVersion, err := ifMatchVersion(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
 	userID, exists := c.Get("user_id")
 	if !exists {
 		c.Error(errors.NewUnauthorizedError("Пользователь не аутентифицирован", nil))
@@ -224,7 +399,7 @@ func (h *PostHandler) UpdatePost(c *gin.Context) {
 
 	post, err := h.service.GetPost(id)
 	if err != nil {
-		c.Error(errors.NewNotFoundError("Пост не найден", err))
+		c.Error(middleware.ToForumError(err, "Ошибка при получении поста"))
 		return
 	}
 
@@ -233,15 +408,46 @@ func (h *PostHandler) UpdatePost(c *gin.Context) {
 		return
 	}
 
+	// Версия проверяется еще раз в репозитории, здесь отсекаем заведомо устаревшие правки
+	if expectedVersion > 0 && expectedVersion != post.Version {
+		c.Error(middleware.ToForumError(service.ErrVersionConflict, ""))
+		return
+	}
+
+	if request.Title != nil {
+		post.Title = *request.Title
+	}
 	post.Content = request.Content
-	if err := h.service.UpdatePost(post, id, userIDInt); err != nil {
-		c.Error(errors.NewInternalServerError("Ошибка при обновлении поста", err))
+	post.Version = expectedVersion
+	if err := checkAttachments(h.attachments, models.AttachmentTargetPost, id, userIDInt, request.AttachmentIDs); err != nil {
+		c.Error(middleware.ToForumError(err, "Ошибка при проверке вложений"))
 		return
 	}
+	if err := h.service.UpdatePost(post, id, userIDInt, request.Reason); err != nil {
+		c.Error(middleware.ToForumError(err, "Ошибка при обновлении поста"))
+		return
+	}
+	if err := h.saveAttachments(post, userIDInt, request.AttachmentIDs); err != nil {
+		c.Error(middleware.ToForumError(err, "Ошибка при прикреплении файлов"))
+		return
+	}
+	if h.notifications != nil {
+		logNotificationError(h.notifications.PostUpdated(post), "измененном посте")
+	}
+	post.ContentHTML = string(RenderPost(post))
 
+	c.Header("ETag", versionETag(post.Version))
 	c.JSON(http.StatusOK, post)
 }
 
+// saveAttachments прикрепляет к сохраненному посту файлы из запроса и добавляет вложения в ответ
+func (h *PostHandler) saveAttachments(post *models.Post, userID int, ids []int) error {
+	if err := linkAttachments(h.attachments, models.AttachmentTargetPost, post.ID, userID, ids); err != nil {
+		return err
+	}
+	return AttachPostAttachments(h.attachments, []*models.Post{post})
+}
+
 // DeletePost godoc
 // @Summary Удалить пост
 // @Description Удаляет пост. Доступно только автору поста или администратору.
@@ -272,7 +478,7 @@ func (h *PostHandler) DeletePost(c *gin.Context) {
 
 	post, err := h.service.GetPost(id)
 	if err != nil {
-		c.Error(errors.NewNotFoundError("Пост не найден", err))
+		c.Error(middleware.ToForumError(err, "Ошибка при получении поста"))
 		return
 	}
 
@@ -282,13 +488,57 @@ func (h *PostHandler) DeletePost(c *gin.Context) {
 	}
 
 	if err := h.service.DeletePost(id, userIDInt); err != nil {
-		c.Error(errors.NewInternalServerError("Ошибка при удалении поста", err))
+		c.Error(middleware.ToForumError(err, "Ошибка при удалении поста"))
 		return
 	}
 
 	c.Status(http.StatusNoContent)
 }
 
+// PinPost godoc
+// @Summary Закрепить или открепить пост
+// @Description Закрепляет пост в начале треда или снимает закрепление. Закрепленные посты идут в порядке закрепления.
+// @Description Доступно модераторам и администраторам.
+// @Tags posts
+// @Accept json
+// @Produce json
+// @Param id path int true "ID поста"
+// @Param input body PinPostRequest true "Закрепление поста"
+// @Success 200 {object} models.Post
+// @Failure 400 {object} map[string]string "неверный ID поста или формат данных"
+// @Failure 401 {object} map[string]string "пользователь не аутентифицирован"
+// @Failure 403 {object} map[string]string "недостаточно прав"
+// @Failure 404 {object} map[string]string "пост не найден"
+// @Router /posts/{id}/pin [put]
+func (h *PostHandler) PinPost(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(errors.NewBadRequestError("Неверный ID поста", err))
+		return
+	}
+
+	var request PinPostRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.Error(errors.NewValidationError("Неверный формат данных", err))
+		return
+	}
+
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.Error(errors.NewUnauthorizedError("Пользователь не аутентифицирован", nil))
+		return
+	}
+
+	post, err := h.service.SetPostPin(id, *request.Pinned, int(userID.(uint32)))
+	if err != nil {
+		c.Error(middleware.ToForumError(err, "Ошибка при закреплении поста"))
+		return
+	}
+
+	c.Header("ETag", versionETag(post.Version))
+	c.JSON(http.StatusOK, post)
+}
+
 // ListPosts godoc
 // @Summary Список постов
 // @Description Отображает HTML-страницу со списком всех постов.
@@ -299,6 +549,9 @@ func (h *PostHandler) DeletePost(c *gin.Context) {
 // @Router /posts [get]
 func (h *PostHandler) ListPosts(c *gin.Context) {
 	posts, err := h.service.GetAllPosts()
+	if err == nil {
+		posts, err = h.readablePosts(c, posts)
+	}
 	if err != nil {
 		c.HTML(http.StatusInternalServerError, "error.html", gin.H{
 			"Title":   "Ошибка",
@@ -340,6 +593,13 @@ func (h *PostHandler) ShowPost(c *gin.Context) {
 		})
 		return
 	}
+	if err := h.checkThreadAccess(c, post.ThreadID); err != nil {
+		c.HTML(http.StatusForbidden, "error.html", gin.H{
+			"Title":   "Ошибка",
+			"Message": "Нет доступа к разделу треда",
+		})
+		return
+	}
 
 	if comments == nil {
 		comments = make([]models.Comment, 0)
@@ -429,6 +689,91 @@ func (h *PostHandler) DeleteComment(c *gin.Context) {
 	c.Redirect(http.StatusFound, "/posts/"+strconv.Itoa(postID))
 }
 
+// GetPostRevisions godoc
+// @Summary Получить историю правок поста
+// @Description Возвращает все предыдущие версии поста с автором правки, временем и причиной.
+// @Tags posts
+// @Produce json
+// @Param id path int true "ID поста"
+// @Success 200 {array} models.PostRevision
+// @Failure 400 {object} map[string]string "Неверный ID поста"
+// @Failure 403 {object} map[string]string "нет прав на чтение раздела треда"
+// @Failure 404 {object} map[string]string "Пост не найден"
+// @Router /posts/{id}/revisions [get]
+func (h *PostHandler) GetPostRevisions(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(errors.NewBadRequestError("Неверный ID поста", err))
+		return
+	}
+
+	if err := h.checkPostAccess(c, id); err != nil {
+		c.Error(middleware.ToForumError(err, "Ошибка при получении поста"))
+		return
+	}
+
+	revisions, err := h.service.GetPostRevisions(id)
+	if err != nil {
+		c.Error(middleware.ToForumError(err, "Ошибка при получении поста"))
+		return
+	}
+
+	c.JSON(http.StatusOK, revisions)
+}
+
+// GetPostRevisionDiff godoc
+// @Summary Сравнить ревизии поста
+// @Description Возвращает unified diff между двумя ревизиями поста. Если параметр to не указан, сравнение идёт с текущей версией.
+// @Tags posts
+// @Produce json
+// @Param id path int true "ID поста"
+// @Param from query int true "ID исходной ревизии"
+// @Param to query int false "ID конечной ревизии"
+// @Success 200 {object} map[string]interface{} "from, to, diff"
+// @Failure 400 {object} map[string]string "Неверный ID поста или ревизии"
+// @Failure 403 {object} map[string]string "нет прав на чтение раздела треда"
+// @Failure 404 {object} map[string]string "Ревизия не найдена"
+// @Router /posts/{id}/revisions/diff [get]
+func (h *PostHandler) GetPostRevisionDiff(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(errors.NewBadRequestError("Неверный ID поста", err))
+		return
+	}
+
+	from, err := strconv.Atoi(c.Query("from"))
+	if err != nil {
+		c.Error(errors.NewBadRequestError("Неверный ID ревизии", err))
+		return
+	}
+
+	to := 0
+	if toParam := c.Query("to"); toParam != "" && toParam != "current" {
+		to, err = strconv.Atoi(toParam)
+		if err != nil {
+			c.Error(errors.NewBadRequestError("Неверный ID ревизии", err))
+			return
+		}
+	}
+
+	if err := h.checkPostAccess(c, id); err != nil {
+		c.Error(middleware.ToForumError(err, "Ошибка при получении поста"))
+		return
+	}
+
+	diff, err := h.service.GetPostRevisionDiff(id, from, to)
+	if err != nil {
+		c.Error(middleware.ToForumError(err, "Ошибка при сравнении ревизий"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"from": from,
+		"to":   to,
+		"diff": diff,
+	})
+}
+
 func (h *PostHandler) GetPostComments(c *gin.Context) {
 	id, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
@@ -436,9 +781,44 @@ func (h *PostHandler) GetPostComments(c *gin.Context) {
 		return
 	}
 
+	order, err := SortOrder(c)
+	if err != nil {
+		c.Error(middleware.ToForumError(err, "Неверный порядок сортировки"))
+		return
+	}
+
 	post, comments, err := h.service.GetPostWithComments(id)
 	if err != nil {
-		c.Error(errors.NewNotFoundError("Пост не найден", err))
+		c.Error(middleware.ToForumError(err, "Ошибка при получении поста"))
+		return
+	}
+	if err := h.checkThreadAccess(c, post.ThreadID); err != nil {
+		c.Error(middleware.ToForumError(err, "Ошибка при получении поста"))
+		return
+	}
+	comments = service.SortComments(comments, order)
+	if err := AttachPostReactions(h.reactions, []*models.Post{post}, viewerID(c)); err != nil {
+		c.Error(middleware.ToForumError(err, "Ошибка при получении реакций"))
+		return
+	}
+	if err := AttachCommentReactions(h.reactions, comments, viewerID(c)); err != nil {
+		c.Error(middleware.ToForumError(err, "Ошибка при получении реакций"))
+		return
+	}
+	if err := AttachPostMentions(h.notifications, []*models.Post{post}); err != nil {
+		c.Error(middleware.ToForumError(err, "Ошибка при получении упоминаний"))
+		return
+	}
+	if err := AttachCommentMentions(h.notifications, comments); err != nil {
+		c.Error(middleware.ToForumError(err, "Ошибка при получении упоминаний"))
+		return
+	}
+	if err := AttachPostAttachments(h.attachments, []*models.Post{post}); err != nil {
+		c.Error(middleware.ToForumError(err, "Ошибка при получении вложений"))
+		return
+	}
+	if err := AttachCommentAttachments(h.attachments, comments); err != nil {
+		c.Error(middleware.ToForumError(err, "Ошибка при получении вложений"))
 		return
 	}
 
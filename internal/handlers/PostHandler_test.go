@@ -5,7 +5,9 @@ import (
 	"encoding/json"
 	"errors"
 	"ForumService/internal/handlers/mocks"
+	"ForumService/internal/middleware"
 	"ForumService/internal/models"
+	"ForumService/internal/service"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -13,6 +15,7 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func setupPostTestRouter() *gin.Engine {
@@ -103,7 +106,7 @@ func TestPostHandler_ShowCreateForm(t *testing.T) {
 		GetPostsWithCommentsByThreadIDFunc: func(threadID int) ([]models.Post, map[int][]models.Comment, error) {
 			return nil, nil, nil
 		},
-		UpdatePostFunc: func(post *models.Post, postID int, userID int) error {
+		UpdatePostFunc: func(post *models.Post, postID int, userID int, reason string) error {
 			return nil
 		},
 		DeletePostFunc: func(postID int, userID int) error {
@@ -139,6 +142,7 @@ func TestPostHandler_ShowCreateForm(t *testing.T) {
 	router := setupPostTestRouter()
 	
 	// Настраиваем шаблонизатор
+	router.SetFuncMap(TemplateFuncs())
 	router.LoadHTMLGlob("../../templates/*")
 	router.Static("/static", "../../static")
 
@@ -193,7 +197,17 @@ func TestPostHandler_CreatePost(t *testing.T) {
 				"created_at":  "0001-01-01T00:00:00Z",
 				"updated_at":  "0001-01-01T00:00:00Z",
 				"author_name": "",
+				"version":     float64(0),
+				"comment_count": float64(0),
+				"pinned":      false,
+				"pinned_at":   nil,
+				"view_count":  float64(0),
+				"upvotes":     float64(0),
+				"downvotes":   float64(0),
+				"score":       float64(0),
 				"can_edit":    false,
+				"reply_to_post_id": nil,
+				"content_html":     "<p>Test Content</p>\n",
 			},
 		},
 		{
@@ -225,6 +239,20 @@ func TestPostHandler_CreatePost(t *testing.T) {
 			expectedBody: map[string]interface{}{
 				"error": "Ошибка при создании поста: нет прав для создания поста в этом треде",
 			},
+		},		{
+			name: "некорректный заголовок",
+			requestBody: map[string]interface{}{
+				"title":    "ab",
+				"content":  "Test Content",
+				"thread_id": 1,
+			},
+			userID:         uint32(1),
+			mockPost:       nil,
+			mockError:      service.ErrInvalidTitle,
+			expectedStatus: http.StatusInternalServerError,
+			expectedBody: map[string]interface{}{
+				"error": "Заголовок должен содержать от 3 до 100 символов: title must be 3-100 characters",
+			},
 		},
 	}
 
@@ -272,6 +300,33 @@ func TestPostHandler_CreatePost(t *testing.T) {
 	}
 }
 
+func TestPostHandler_CreatePost_ReplyTo(t *testing.T) {
+	var saved *models.Post
+	mockPostService := &mocks.MockPostService{
+		CreatePostFunc: func(post *models.Post) error {
+			saved = post
+			post.ID = 6
+			return nil
+		},
+	}
+	router := setupPostTestRouter()
+	router.POST("/posts", func(c *gin.Context) {
+		c.Set("user_id", uint32(2))
+		NewPostHandler(mockPostService).CreatePost(c)
+	})
+
+	body := `{"thread_id": 1, "content": "[quote=5]исходный текст[/quote] согласен", "reply_to_post_id": 5}`
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/posts", bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+	require.NotNil(t, saved.ReplyToPostID)
+	assert.Equal(t, 5, *saved.ReplyToPostID)
+	assert.Contains(t, w.Body.String(), `"reply_to_post_id":5`)
+}
+
 func TestPostHandler_GetPost(t *testing.T) {
 	tests := []struct {
 		name           string
@@ -302,6 +357,16 @@ func TestPostHandler_GetPost(t *testing.T) {
 				"created_at":  "0001-01-01T00:00:00Z",
 				"updated_at":  "0001-01-01T00:00:00Z",
 				"author_name": "",
+				"version":     float64(0),
+				"comment_count": float64(0),
+				"pinned":      false,
+				"pinned_at":   nil,
+				"reply_to_post_id": nil,
+				"content_html":     "<p>Test post</p>\n",
+				"view_count":  float64(0),
+				"upvotes":     float64(0),
+				"downvotes":   float64(0),
+				"score":       float64(0),
 				"can_edit":    false,
 				"title":       "",
 			},
@@ -320,7 +385,7 @@ func TestPostHandler_GetPost(t *testing.T) {
 			name:           "пост не найден",
 			postID:         "999",
 			mockPost:       nil,
-			mockError:      errors.New("post not found"),
+			mockError:      service.ErrPostNotFound,
 			expectedStatus: http.StatusInternalServerError,
 			expectedBody: map[string]interface{}{
 				"error": "Пост не найден: post not found",
@@ -397,6 +462,16 @@ func TestPostHandler_UpdatePost(t *testing.T) {
 				"created_at":  "0001-01-01T00:00:00Z",
 				"updated_at":  "0001-01-01T00:00:00Z",
 				"author_name": "",
+				"version":     float64(0),
+				"comment_count": float64(0),
+				"pinned":      false,
+				"pinned_at":   nil,
+				"reply_to_post_id": nil,
+				"content_html":     "<p>Updated post</p>\n",
+				"view_count":  float64(0),
+				"upvotes":     float64(0),
+				"downvotes":   float64(0),
+				"score":       float64(0),
 				"can_edit":    false,
 				"title":       "",
 			},
@@ -432,7 +507,7 @@ func TestPostHandler_UpdatePost(t *testing.T) {
 			mockError:      errors.New("нет прав для редактирования поста"),
 			expectedStatus: http.StatusInternalServerError,
 			expectedBody: map[string]interface{}{
-				"error": "Пост не найден: нет прав для редактирования поста",
+				"error": "Ошибка при получении поста: нет прав для редактирования поста",
 			},
 		},
 		{
@@ -446,7 +521,7 @@ func TestPostHandler_UpdatePost(t *testing.T) {
 			mockError:      assert.AnError,
 			expectedStatus: http.StatusInternalServerError,
 			expectedBody: map[string]interface{}{
-				"error": "Пост не найден: assert.AnError general error for testing",
+				"error": "Ошибка при получении поста: assert.AnError general error for testing",
 			},
 		},
 	}
@@ -457,7 +532,7 @@ func TestPostHandler_UpdatePost(t *testing.T) {
 				GetPostFunc: func(id int) (*models.Post, error) {
 					return tt.mockPost, tt.mockError
 				},
-				UpdatePostFunc: func(post *models.Post, postID int, userID int) error {
+				UpdatePostFunc: func(post *models.Post, postID int, userID int, reason string) error {
 					return tt.mockError
 				},
 			}
@@ -545,7 +620,7 @@ func TestPostHandler_DeletePost(t *testing.T) {
 			mockError:      errors.New("нет прав для удаления поста"),
 			expectedStatus: http.StatusInternalServerError,
 			expectedBody: map[string]interface{}{
-				"error": "Пост не найден: нет прав для удаления поста",
+				"error": "Ошибка при получении поста: нет прав для удаления поста",
 			},
 		},
 		{
@@ -556,7 +631,7 @@ func TestPostHandler_DeletePost(t *testing.T) {
 			mockError:      assert.AnError,
 			expectedStatus: http.StatusInternalServerError,
 			expectedBody: map[string]interface{}{
-				"error": "Пост не найден: assert.AnError general error for testing",
+				"error": "Ошибка при получении поста: assert.AnError general error for testing",
 			},
 		},
 	}
@@ -649,20 +724,40 @@ func TestPostHandler_GetPostComments(t *testing.T) {
 					map[string]interface{}{
 						"id":          float64(1),
 						"content":     "Test comment 1",
+						"content_html": "<p>Test comment 1</p>\n",
 						"author_id":   float64(1),
 						"post_id":     float64(1),
 						"created_at":  "0001-01-01T00:00:00Z",
+						"updated_at":  "0001-01-01T00:00:00Z",
+						"edited":      false,
+						"parent_comment_id": nil,
+						"depth":       float64(0),
+						"deleted":     false,
+						"can_edit":    false,
 						"author_name": "",
 						"can_delete":  false,
+						"upvotes":     float64(0),
+						"downvotes":   float64(0),
+						"score":       float64(0),
 					},
 					map[string]interface{}{
 						"id":          float64(2),
 						"content":     "Test comment 2",
+						"content_html": "<p>Test comment 2</p>\n",
 						"author_id":   float64(2),
 						"post_id":     float64(1),
 						"created_at":  "0001-01-01T00:00:00Z",
+						"updated_at":  "0001-01-01T00:00:00Z",
+						"edited":      false,
+						"parent_comment_id": nil,
+						"depth":       float64(0),
+						"deleted":     false,
+						"can_edit":    false,
 						"author_name": "",
 						"can_delete":  false,
+						"upvotes":     float64(0),
+						"downvotes":   float64(0),
+						"score":       float64(0),
 					},
 				},
 				"post": map[string]interface{}{
@@ -673,6 +768,16 @@ func TestPostHandler_GetPostComments(t *testing.T) {
 					"created_at":  "0001-01-01T00:00:00Z",
 					"updated_at":  "0001-01-01T00:00:00Z",
 					"author_name": "",
+					"version":     float64(0),
+					"comment_count": float64(0),
+					"pinned":      false,
+					"pinned_at":   nil,
+					"reply_to_post_id": nil,
+					"content_html":     "<p>Test post</p>\n",
+					"view_count":  float64(0),
+					"upvotes":     float64(0),
+					"downvotes":   float64(0),
+					"score":       float64(0),
 					"can_edit":    false,
 					"title":       "",
 				},
@@ -694,7 +799,7 @@ func TestPostHandler_GetPostComments(t *testing.T) {
 			postID:         "1",
 			mockComments:   nil,
 			mockPost:       nil,
-			mockError:      errors.New("post not found"),
+			mockError:      service.ErrPostNotFound,
 			expectedStatus: http.StatusInternalServerError,
 			expectedBody: map[string]interface{}{
 				"error": "Пост не найден: post not found",
@@ -708,7 +813,7 @@ func TestPostHandler_GetPostComments(t *testing.T) {
 			mockError:      assert.AnError,
 			expectedStatus: http.StatusInternalServerError,
 			expectedBody: map[string]interface{}{
-				"error": "Пост не найден: assert.AnError general error for testing",
+				"error": "Ошибка при получении поста: assert.AnError general error for testing",
 			},
 		},
 	}
@@ -741,4 +846,236 @@ func TestPostHandler_GetPostComments(t *testing.T) {
 			assert.Equal(t, tt.expectedBody, response)
 		})
 	}
-}
\ No newline at end of file
+}
+func TestPostHandler_GetPostRevisionDiff(t *testing.T) {
+	tests := []struct {
+		name           string
+		query          string
+		mockDiff       string
+		mockError      error
+		expectedStatus int
+		expectedFrom   int
+		expectedTo     int
+	}{
+		{
+			name:           "сравнение с текущей версией",
+			query:          "?from=3",
+			mockDiff:       "--- ревизия #3\n+++ текущая версия\n",
+			expectedStatus: http.StatusOK,
+			expectedFrom:   3,
+			expectedTo:     0,
+		},
+		{
+			name:           "сравнение двух ревизий",
+			query:          "?from=3&to=5",
+			mockDiff:       "--- ревизия #3\n+++ ревизия #5\n",
+			expectedStatus: http.StatusOK,
+			expectedFrom:   3,
+			expectedTo:     5,
+		},
+		{
+			name:           "неверный ID ревизии",
+			query:          "?from=abc",
+			expectedStatus: http.StatusInternalServerError,
+		},
+		{
+			name:           "ревизия не найдена",
+			query:          "?from=3",
+			mockError:      errors.New("ревизия не найдена"),
+			expectedStatus: http.StatusInternalServerError,
+			expectedFrom:   3,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockPostService := &mocks.MockPostService{
+				GetPostRevisionDiffFunc: func(postID int, fromRevisionID int, toRevisionID int) (string, error) {
+					assert.Equal(t, 1, postID)
+					assert.Equal(t, tt.expectedFrom, fromRevisionID)
+					assert.Equal(t, tt.expectedTo, toRevisionID)
+					return tt.mockDiff, tt.mockError
+				},
+			}
+
+			handler := NewPostHandler(mockPostService)
+			router := setupPostTestRouter()
+			router.GET("/posts/:id/revisions/diff", handler.GetPostRevisionDiff)
+
+			req := httptest.NewRequest("GET", "/posts/1/revisions/diff"+tt.query, nil)
+			w := httptest.NewRecorder()
+
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedStatus == http.StatusOK {
+				var response map[string]interface{}
+				err := json.Unmarshal(w.Body.Bytes(), &response)
+				assert.NoError(t, err)
+				assert.Equal(t, tt.mockDiff, response["diff"])
+			}
+		})
+	}
+}
+
+func TestPostHandler_UpdatePost_IfMatch(t *testing.T) {
+	tests := []struct {
+		name            string
+		ifMatch         string
+		expectedStatus  int
+		expectedVersion int
+		expectedETag    string
+	}{
+		{
+			name:            "совпадающая версия",
+			ifMatch:         `"3"`,
+			expectedStatus:  http.StatusOK,
+			expectedVersion: 3,
+			expectedETag:    `"4"`,
+		},
+		{
+			name:           "устаревшая версия",
+			ifMatch:        `"2"`,
+			expectedStatus: http.StatusPreconditionFailed,
+		},
+		{
+			name:           "неверный заголовок",
+			ifMatch:        "abc",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:            "без проверки версии",
+			ifMatch:         "*",
+			expectedStatus:  http.StatusOK,
+			expectedVersion: 0,
+			expectedETag:    `"4"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotVersion int
+			mockService := &mocks.MockPostService{
+				GetPostFunc: func(id int) (*models.Post, error) {
+					return &models.Post{ID: id, AuthorID: 1, Content: "old", Version: 3}, nil
+				},
+				UpdatePostFunc: func(post *models.Post, postID int, userID int, reason string) error {
+					gotVersion = post.Version
+					post.Version = 4
+					return nil
+				},
+			}
+
+			gin.SetMode(gin.TestMode)
+			router := gin.New()
+			router.Use(middleware.ErrorHandler())
+			router.Use(func(c *gin.Context) {
+				c.Set("user_id", uint32(1))
+				c.Next()
+			})
+			router.PUT("/posts/:id", NewPostHandler(mockService).UpdatePost)
+
+			body, _ := json.Marshal(UpdatePostRequest{Content: "new content"})
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("PUT", "/posts/1", bytes.NewBuffer(body))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("If-Match", tt.ifMatch)
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedStatus == http.StatusOK {
+				assert.Equal(t, tt.expectedVersion, gotVersion)
+				assert.Equal(t, tt.expectedETag, w.Header().Get("ETag"))
+			}
+		})
+	}
+}
+
+func TestPostHandler_GetPost_ETag(t *testing.T) {
+	mockService := &mocks.MockPostService{
+		GetPostFunc: func(id int) (*models.Post, error) {
+			return &models.Post{ID: id, Version: 7}, nil
+		},
+	}
+
+	router := setupPostTestRouter()
+	router.GET("/posts/:id", NewPostHandler(mockService).GetPost)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/posts/1", nil)
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, `"7"`, w.Header().Get("ETag"))
+}
+
+func TestPostHandler_PinPost(t *testing.T) {
+	tests := []struct {
+		name           string
+		body           string
+		serviceErr     error
+		expectedStatus int
+		expectedPinned bool
+	}{
+		{"закрепление", `{"pinned":true}`, nil, http.StatusOK, true},
+		{"открепление", `{"pinned":false}`, nil, http.StatusOK, false},
+		{"без состояния", `{}`, nil, http.StatusBadRequest, false},
+		{"нет прав", `{"pinned":true}`, service.ErrNoPermission, http.StatusForbidden, false},
+		{"пост не найден", `{"pinned":true}`, service.ErrPostNotFound, http.StatusNotFound, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := &mocks.MockPostService{
+				SetPostPinFunc: func(postID int, pinned bool, userID int) (*models.Post, error) {
+					if tt.serviceErr != nil {
+						return nil, tt.serviceErr
+					}
+					return &models.Post{ID: postID, Version: 2, Pinned: pinned}, nil
+				},
+			}
+
+			gin.SetMode(gin.TestMode)
+			router := gin.New()
+			router.Use(middleware.ErrorHandler())
+			router.PUT("/posts/:id/pin", func(c *gin.Context) {
+				c.Set("user_id", uint32(2))
+				NewPostHandler(mockService).PinPost(c)
+			})
+
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("PUT", "/posts/1/pin", bytes.NewBufferString(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedStatus != http.StatusOK {
+				return
+			}
+			var post models.Post
+			assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &post))
+			assert.Equal(t, tt.expectedPinned, post.Pinned)
+			assert.Equal(t, `"2"`, w.Header().Get("ETag"))
+		})
+	}
+}
+
+func TestPostHandler_CreatePost_ClosedCategory(t *testing.T) {
+	mockPostService := &mocks.MockPostService{
+		CreatePostFunc: func(post *models.Post) error {
+			t.Fatal("пост в закрытом разделе не должен создаваться")
+			return nil
+		},
+	}
+	router := closedCategoryRouter()
+	router.POST("/posts", NewPostHandler(mockPostService).WithThreads(staffOnlyThreads()).CreatePost)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/posts", bytes.NewBufferString(`{"thread_id":1,"content":"Текст поста"}`))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, req)
+
+	// Ответ тот же, что и для несуществующего треда
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Contains(t, w.Body.String(), "Тред не найден")
+}
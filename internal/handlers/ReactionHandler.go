@@ -0,0 +1,236 @@
+package handlers
+
+import (
+	"ForumService/internal/errors"
+	"ForumService/internal/middleware"
+	"ForumService/internal/models"
+	"ForumService/internal/service"
+	"net/http"
+	"strconv"
+
+	"github.com/Luxtington/Shared/logger"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// ReactionMessageType - тип сообщения Hub об изменении реакций на сообщение чата
+const ReactionMessageType = "reaction"
+
+type ReactionHandler struct {
+	service  service.ReactionService
+	hub      *Hub
+	threads  service.ThreadService
+	comments service.CommentService
+}
+
+// NewReactionHandler создает обработчик реакций. Изменения реакций в чате рассылаются
+// через hub; при nil рассылки нет.
+func NewReactionHandler(service service.ReactionService, hub *Hub) *ReactionHandler {
+	return &ReactionHandler{service: service, hub: hub}
+}
+
+// WithAccess принимает реакции только на посты и комментарии из разделов, которые пользователь
+// может читать. comments нужен, чтобы найти пост комментария.
+func (h *ReactionHandler) WithAccess(threads service.ThreadService, comments service.CommentService) *ReactionHandler {
+	h.threads = threads
+	h.comments = comments
+	return h
+}
+
+type ReactionRequest struct {
+	Emoji string `json:"emoji" binding:"required"`
+}
+
+// ReactionMessage - событие Hub об изменении реакций на сообщение чата. Отметки
+// reacted_by_me в нем сброшены: событие получают все клиенты, а не только автор реакции.
+type ReactionMessage struct {
+	Type string `json:"type"`
+	models.ReactionChange
+}
+
+// GetReactionEmojis godoc
+// @Summary Получить доступные реакции
+// @Description Возвращает эмодзи, которыми можно реагировать на посты, комментарии и сообщения чата.
+// @Tags reactions
+// @Produce json
+// @Success 200 {array} string
+// @Router /reactions [get]
+func (h *ReactionHandler) GetReactionEmojis(c *gin.Context) {
+	c.JSON(http.StatusOK, h.service.AllowedEmojis())
+}
+
+// ReactPost godoc
+// @Summary Поставить или снять реакцию на пост
+// @Description Ставит реакцию эмодзи на пост, повторный запрос с тем же эмодзи снимает ее.
+// @Tags reactions
+// @Accept json
+// @Produce json
+// @Param id path int true "ID поста"
+// @Param input body ReactionRequest true "Реакция"
+// @Success 200 {object} models.ReactionChange
+// @Failure 400 {object} map[string]string "неверный ID поста или недоступная реакция"
+// @Failure 401 {object} map[string]string "пользователь не аутентифицирован"
+// @Failure 404 {object} map[string]string "пост не найден"
+// @Router /posts/{id}/reactions [post]
+func (h *ReactionHandler) ReactPost(c *gin.Context) {
+	h.toggle(c, models.ReactionTargetPost, "Неверный ID поста")
+}
+
+// ReactComment godoc
+// @Summary Поставить или снять реакцию на комментарий
+// @Description Ставит реакцию эмодзи на комментарий, повторный запрос с тем же эмодзи снимает ее.
+// @Description На удаленные комментарии реагировать нельзя.
+// @Tags reactions
+// @Accept json
+// @Produce json
+// @Param id path int true "ID комментария"
+// @Param input body ReactionRequest true "Реакция"
+// @Success 200 {object} models.ReactionChange
+// @Failure 400 {object} map[string]string "неверный ID комментария, недоступная реакция или удаленный комментарий"
+// @Failure 401 {object} map[string]string "пользователь не аутентифицирован"
+// @Failure 404 {object} map[string]string "комментарий не найден"
+// @Router /comments/{id}/reactions [post]
+func (h *ReactionHandler) ReactComment(c *gin.Context) {
+	h.toggle(c, models.ReactionTargetComment, "Неверный ID комментария")
+}
+
+// ReactMessage godoc
+// @Summary Поставить или снять реакцию на сообщение чата
+// @Description Ставит реакцию эмодзи на сообщение чата, повторный запрос с тем же эмодзи снимает ее.
+// @Description Изменение рассылается подключенным к чату клиентам сообщением с типом "reaction".
+// @Tags reactions
+// @Accept json
+// @Produce json
+// @Param id path int true "ID сообщения"
+// @Param input body ReactionRequest true "Реакция"
+// @Success 200 {object} models.ReactionChange
+// @Failure 400 {object} map[string]string "неверный ID сообщения или недоступная реакция"
+// @Failure 401 {object} map[string]string "пользователь не аутентифицирован"
+// @Failure 404 {object} map[string]string "сообщение не найдено"
+// @Router /chat/{id}/reactions [post]
+func (h *ReactionHandler) ReactMessage(c *gin.Context) {
+	change, ok := h.toggle(c, models.ReactionTargetChat, "Неверный ID сообщения")
+	if !ok || h.hub == nil {
+		return
+	}
+
+	event := ReactionMessage{Type: ReactionMessageType, ReactionChange: *change}
+	event.Reactions = make([]models.ReactionCount, len(change.Reactions))
+	for i, count := range change.Reactions {
+		count.ReactedByMe = false
+		event.Reactions[i] = count
+	}
+	if err := h.hub.BroadcastJSON(event); err != nil {
+		logger.GetLogger().Error("Ошибка при рассылке реакции в чат", zap.Error(err))
+	}
+}
+
+func (h *ReactionHandler) toggle(c *gin.Context, targetType string, badID string) (*models.ReactionChange, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(errors.NewBadRequestError(badID, err))
+		return nil, false
+	}
+
+	var request ReactionRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.Error(errors.NewValidationError("Неверный формат данных", err))
+		return nil, false
+	}
+
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.Error(errors.NewUnauthorizedError("Пользователь не аутентифицирован", nil))
+		return nil, false
+	}
+
+	if err := h.checkAccess(c, targetType, id); err != nil {
+		c.Error(middleware.ToForumError(err, "Ошибка при изменении реакции"))
+		return nil, false
+	}
+
+	change, err := h.service.Toggle(targetType, id, int(userID.(uint32)), request.Emoji)
+	if err != nil {
+		c.Error(middleware.ToForumError(err, "Ошибка при изменении реакции"))
+		return nil, false
+	}
+
+	c.JSON(http.StatusOK, change)
+	return change, true
+}
+
+// checkAccess проверяет доступ к разделу треда поста или комментария. Чат к разделам не относится.
+func (h *ReactionHandler) checkAccess(c *gin.Context, targetType string, id int) error {
+	switch targetType {
+	case models.ReactionTargetPost:
+		return checkPostWriteAccess(c, h.threads, id)
+	case models.ReactionTargetComment:
+		return checkCommentWriteAccess(c, h.threads, h.comments, id)
+	}
+	return nil
+}
+
+// viewerID возвращает ID пользователя запроса или 0 для анонимного запроса
+func viewerID(c *gin.Context) int {
+	if userID, ok := c.Value("user_id").(uint32); ok {
+		return int(userID)
+	}
+	return 0
+}
+
+// AttachPostReactions заполняет реакции постов; userID отмечает реакции пользователя
+func AttachPostReactions(reactions service.ReactionService, posts []*models.Post, userID int) error {
+	if reactions == nil || len(posts) == 0 {
+		return nil
+	}
+	ids := make([]int, len(posts))
+	for i, post := range posts {
+		ids[i] = post.ID
+	}
+	counts, err := reactions.Counts(models.ReactionTargetPost, ids, userID)
+	if err != nil {
+		return err
+	}
+	for _, post := range posts {
+		post.Reactions = counts[post.ID]
+	}
+	return nil
+}
+
+// AttachCommentReactions заполняет реакции комментариев; userID отмечает реакции пользователя
+func AttachCommentReactions(reactions service.ReactionService, comments []models.Comment, userID int) error {
+	if reactions == nil || len(comments) == 0 {
+		return nil
+	}
+	ids := make([]int, len(comments))
+	for i, comment := range comments {
+		ids[i] = comment.ID
+	}
+	counts, err := reactions.Counts(models.ReactionTargetComment, ids, userID)
+	if err != nil {
+		return err
+	}
+	for i := range comments {
+		comments[i].Reactions = counts[comments[i].ID]
+	}
+	return nil
+}
+
+// AttachMessageReactions заполняет реакции сообщений чата; userID отмечает реакции пользователя
+func AttachMessageReactions(reactions service.ReactionService, messages []*models.ChatMessage, userID int) error {
+	if reactions == nil || len(messages) == 0 {
+		return nil
+	}
+	ids := make([]int, len(messages))
+	for i, message := range messages {
+		ids[i] = message.ID
+	}
+	counts, err := reactions.Counts(models.ReactionTargetChat, ids, userID)
+	if err != nil {
+		return err
+	}
+	for _, message := range messages {
+		message.Reactions = counts[message.ID]
+	}
+	return nil
+}
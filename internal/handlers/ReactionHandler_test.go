@@ -0,0 +1,224 @@
+package handlers
+
+import (
+	"ForumService/internal/handlers/mocks"
+	"ForumService/internal/middleware"
+	"ForumService/internal/models"
+	"ForumService/internal/service"
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupReactionTestRouter(handler *ReactionHandler, userID uint32) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(middleware.ErrorHandler())
+	router.Use(func(c *gin.Context) {
+		if userID != 0 {
+			c.Set("user_id", userID)
+		}
+		c.Next()
+	})
+	router.GET("/reactions", handler.GetReactionEmojis)
+	router.POST("/posts/:id/reactions", handler.ReactPost)
+	router.POST("/comments/:id/reactions", handler.ReactComment)
+	router.POST("/chat/:id/reactions", handler.ReactMessage)
+	return router
+}
+
+func TestReactionHandler_ReactPost(t *testing.T) {
+	tests := []struct {
+		name           string
+		userID         uint32
+		path           string
+		body           string
+		err            error
+		expectedStatus int
+	}{
+		{"успешно", 1, "/posts/1/reactions", `{"emoji":"👍"}`, nil, http.StatusOK},
+		{"не аутентифицирован", 0, "/posts/1/reactions", `{"emoji":"👍"}`, nil, http.StatusUnauthorized},
+		{"неверный ID", 1, "/posts/abc/reactions", `{"emoji":"👍"}`, nil, http.StatusBadRequest},
+		{"без эмодзи", 1, "/posts/1/reactions", `{}`, nil, http.StatusBadRequest},
+		{"недоступная реакция", 1, "/posts/1/reactions", `{"emoji":"🔥"}`, service.ErrReactionNotAllowed, http.StatusBadRequest},
+		{"пост не найден", 1, "/posts/1/reactions", `{"emoji":"👍"}`, service.ErrPostNotFound, http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := &mocks.MockReactionService{
+				ToggleFunc: func(targetType string, targetID, userID int, emoji string) (*models.ReactionChange, error) {
+					if tt.err != nil {
+						return nil, tt.err
+					}
+					return &models.ReactionChange{TargetType: targetType, TargetID: targetID, UserID: userID, Emoji: emoji, Added: true}, nil
+				},
+			}
+			router := setupReactionTestRouter(NewReactionHandler(mockService, nil), tt.userID)
+
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("POST", tt.path, bytes.NewBufferString(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+		})
+	}
+}
+
+func TestReactionHandler_ReactComment(t *testing.T) {
+	var gotType string
+	var gotComment, gotUser int
+	mockService := &mocks.MockReactionService{
+		ToggleFunc: func(targetType string, targetID, userID int, emoji string) (*models.ReactionChange, error) {
+			gotType, gotComment, gotUser = targetType, targetID, userID
+			return &models.ReactionChange{TargetType: targetType, TargetID: targetID, UserID: userID, Emoji: emoji}, nil
+		},
+	}
+	router := setupReactionTestRouter(NewReactionHandler(mockService, nil), 2)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/comments/5/reactions", bytes.NewBufferString(`{"emoji":"🎉"}`))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, models.ReactionTargetComment, gotType)
+	assert.Equal(t, 5, gotComment)
+	assert.Equal(t, 2, gotUser)
+}
+
+func TestReactionHandler_ReactMessage_Broadcasts(t *testing.T) {
+	mockService := &mocks.MockReactionService{
+		ToggleFunc: func(targetType string, targetID, userID int, emoji string) (*models.ReactionChange, error) {
+			return &models.ReactionChange{
+				TargetType: targetType,
+				TargetID:   targetID,
+				UserID:     userID,
+				Emoji:      emoji,
+				Added:      true,
+				Reactions:  []models.ReactionCount{{Emoji: emoji, Count: 2, ReactedByMe: true}},
+			}, nil
+		},
+	}
+	hub := NewHub(&mocks.MockChatRepository{})
+	go hub.Run()
+	client := &Client{Send: make(chan []byte, 1), Username: "bob", UserID: 3}
+	hub.Register <- client
+
+	router := setupReactionTestRouter(NewReactionHandler(mockService, hub), 1)
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/chat/7/reactions", bytes.NewBufferString(`{"emoji":"👀"}`))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusOK, w.Code)
+	var response models.ReactionChange
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.True(t, response.Reactions[0].ReactedByMe, "автор реакции видит свою отметку")
+
+	select {
+	case payload := <-client.Send:
+		var event map[string]interface{}
+		require.NoError(t, json.Unmarshal(payload, &event))
+		assert.Equal(t, ReactionMessageType, event["type"])
+		assert.Equal(t, float64(7), event["target_id"])
+		assert.Equal(t, float64(1), event["user_id"])
+		reactions := event["reactions"].([]interface{})
+		require.Len(t, reactions, 1)
+		assert.Equal(t, false, reactions[0].(map[string]interface{})["reacted_by_me"],
+			"в рассылке нет отметок конкретного пользователя")
+	case <-time.After(time.Second):
+		t.Fatal("событие реакции не разослано")
+	}
+}
+
+func TestReactionHandler_GetReactionEmojis(t *testing.T) {
+	mockService := &mocks.MockReactionService{
+		AllowedEmojisFunc: func() []string { return []string{"👍", "🎉"} },
+	}
+	router := setupReactionTestRouter(NewReactionHandler(mockService, nil), 0)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/reactions", nil)
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `["👍","🎉"]`, w.Body.String())
+}
+
+func TestChatHandler_GetMessages_WithReactions(t *testing.T) {
+	var gotIDs []int
+	var gotUser int
+	mockReactions := &mocks.MockReactionService{
+		CountsFunc: func(targetType string, targetIDs []int, userID int) (map[int][]models.ReactionCount, error) {
+			gotIDs, gotUser = targetIDs, userID
+			return map[int][]models.ReactionCount{2: {{Emoji: "👍", Count: 1, ReactedByMe: true}}}, nil
+		},
+	}
+	mockChat := &mocks.MockChatService{
+		GetAllMessagesFunc: func() ([]*models.ChatMessage, error) {
+			return []*models.ChatMessage{{ID: 1, Content: "Привет"}, {ID: 2, Content: "Как дела?"}}, nil
+		},
+	}
+	handler := NewChatHandler(mockChat).WithReactions(mockReactions)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(middleware.ErrorHandler())
+	router.Use(func(c *gin.Context) {
+		c.Set("user_id", uint32(4))
+		c.Next()
+	})
+	router.GET("/chat", handler.GetMessages)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/chat", nil)
+	router.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, []int{1, 2}, gotIDs)
+	assert.Equal(t, 4, gotUser)
+	var messages []models.ChatMessage
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &messages))
+	assert.Empty(t, messages[0].Reactions)
+	assert.Equal(t, []models.ReactionCount{{Emoji: "👍", Count: 1, ReactedByMe: true}}, messages[1].Reactions)
+}
+
+func TestReactionHandler_ClosedCategory(t *testing.T) {
+	mockService := &mocks.MockReactionService{
+		ToggleFunc: func(targetType string, targetID, userID int, emoji string) (*models.ReactionChange, error) {
+			t.Fatal("реакция в закрытом разделе не должна сохраняться")
+			return nil, nil
+		},
+	}
+	comments := &mocks.MockCommentService{
+		GetCommentByIDFunc: func(id int) (*models.Comment, error) {
+			return &models.Comment{ID: id, PostID: 1}, nil
+		},
+	}
+	handler := NewReactionHandler(mockService, nil).WithAccess(staffOnlyThreads(), comments)
+	router := closedCategoryRouter()
+	router.POST("/posts/:id/reactions", handler.ReactPost)
+	router.POST("/comments/:id/reactions", handler.ReactComment)
+
+	for path, message := range map[string]string{
+		"/posts/1/reactions":    "Пост не найден",
+		"/comments/4/reactions": "Комментарий не найден",
+	} {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", path, bytes.NewBufferString(`{"emoji":"👍"}`))
+		req.Header.Set("Content-Type", "application/json")
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusNotFound, w.Code, path)
+		assert.Contains(t, w.Body.String(), message, path)
+	}
+}
@@ -7,11 +7,31 @@ import (
 )
 
 type Services struct {
-	UserService    service.UserService
-	PostService    service.PostService
-	CommentService service.CommentService
-	ThreadService  service.ThreadService
-	ChatService    service.ChatService
+	UserService     service.UserService
+	PostService     service.PostService
+	CommentService  service.CommentService
+	ThreadService   service.ThreadService
+	CategoryService service.CategoryService
+	TagService      service.TagService
+	VoteService     service.VoteService
+	// ReactionService - реакции на записи, nil отключает реакции
+	ReactionService service.ReactionService
+	// BookmarkService - закладки пользователей, nil отключает отметки на страницах
+	BookmarkService service.BookmarkService
+	// NotificationService - подписки, упоминания и уведомления, nil отключает уведомления о новых записях
+	NotificationService service.NotificationService
+	// AttachmentService - загрузка файлов и вложения постов и комментариев, nil отключает вложения
+	AttachmentService service.AttachmentService
+	// AttachmentMaxSize - наибольший размер загружаемого файла в байтах
+	AttachmentMaxSize int64
+	// PollService - опросы в тредах, nil отключает создание опросов вместе с тредом
+	PollService   service.PollService
+	ChatService   service.ChatService
+	SearchService service.SearchService
+	// CacheStats - счетчики кэша чтения, nil если кэш выключен
+	CacheStats CacheStatsProvider
+	// Views - счетчик просмотров страниц тредов и постов, nil отключает учет
+	Views service.ViewService
 }
 
 func RegisterRoutes(router *gin.Engine, services *Services) {
@@ -19,18 +39,32 @@ func RegisterRoutes(router *gin.Engine, services *Services) {
 	router.Use(middleware.ErrorHandler())
 
 	// Инициализация обработчиков
-	viewsHandler := NewViewsHandler(services.ThreadService, services.PostService, services.CommentService, services.ChatService)
-	threadHandler := NewThreadHandler(services.ThreadService)
-	postHandler := NewPostHandler(services.PostService)
-	commentHandler := NewCommentHandler(services.CommentService)
-	chatHandler := NewChatHandler(services.ChatService)
+	viewsHandler := NewViewsHandler(services.ThreadService, services.CategoryService, services.PostService, services.CommentService, services.ChatService).WithReactions(services.ReactionService).WithBookmarks(services.BookmarkService).WithNotifications(services.NotificationService).WithAttachments(services.AttachmentService).WithPolls(services.PollService)
+	threadHandler := NewThreadHandler(services.ThreadService).WithReactions(services.ReactionService).WithNotifications(services.NotificationService).WithAttachments(services.AttachmentService).WithPolls(services.PollService)
+	categoryHandler := NewCategoryHandler(services.CategoryService)
+	tagHandler := NewTagHandler(services.TagService)
+	postHandler := NewPostHandler(services.PostService).WithReactions(services.ReactionService).WithNotifications(services.NotificationService).WithAttachments(services.AttachmentService).WithThreads(services.ThreadService)
+	voteHandler := NewVoteHandler(services.VoteService).WithAccess(services.ThreadService, services.CommentService)
+	reactionHandler := NewReactionHandler(services.ReactionService, nil).WithAccess(services.ThreadService, services.CommentService)
+	commentHandler := NewCommentHandler(services.CommentService).WithNotifications(services.NotificationService).WithAttachments(services.AttachmentService).WithThreads(services.ThreadService)
+	chatHandler := NewChatHandler(services.ChatService).WithReactions(services.ReactionService).WithNotifications(services.NotificationService)
+	searchHandler := NewSearchHandler(services.SearchService)
+	bookmarkHandler := NewBookmarkHandler(services.BookmarkService)
+	notificationHandler := NewNotificationHandler(services.NotificationService)
+	metricsHandler := NewMetricsHandler(services.CacheStats)
+	attachmentHandler := NewAttachmentHandler(services.AttachmentService, services.AttachmentMaxSize).WithAccess(services.ThreadService, services.CommentService)
+	pollHandler := NewPollHandler(services.PollService, services.ThreadService, nil)
 
 	// Главная страница
 	router.GET("/", viewsHandler.Index)
 
 	// Маршруты для отображения страниц
-	router.GET("/threads/:id", viewsHandler.ShowThread)
-	router.GET("/posts/:id", viewsHandler.ShowPost)
+	router.GET("/threads/:id", CountThreadView(services.Views), viewsHandler.ShowThread)
+	router.GET("/posts/:id", CountPostView(services.Views), viewsHandler.ShowPost)
+	router.GET("/posts/:id/edit", postHandler.ShowEditForm)
+	router.GET("/posts/:id/conflict", postHandler.ShowConflictPage)
+	router.GET("/search", searchHandler.ShowSearchPage)
+	router.GET("/users/:id", NewUserHandler().ShowProfile)
 
 	// API маршруты
 	api := router.Group("/api")
@@ -44,6 +78,33 @@ func RegisterRoutes(router *gin.Engine, services *Services) {
 			threads.POST("", threadHandler.CreateThread)
 			threads.PUT("/:id", threadHandler.UpdateThread)
 			threads.DELETE("/:id", threadHandler.DeleteThread)
+			threads.PUT("/:id/lock", threadHandler.LockThread)
+			threads.PUT("/:id/pin", threadHandler.PinThread)
+			threads.PUT("/:id/answer", threadHandler.AcceptAnswer)
+			threads.GET("/:id/poll", pollHandler.GetThreadPoll)
+		}
+
+		// Опросы
+		api.POST("/polls/:id/vote", pollHandler.VotePoll)
+		api.DELETE("/polls/:id/vote", pollHandler.RetractPollVote)
+		api.POST("/polls/:id/close", pollHandler.ClosePoll)
+
+		// Разделы форума
+		categories := api.Group("/categories")
+		{
+			categories.GET("", categoryHandler.GetCategories)
+			categories.GET("/:id", categoryHandler.GetCategory)
+			categories.POST("", categoryHandler.CreateCategory)
+			categories.PUT("/:id", categoryHandler.UpdateCategory)
+			categories.DELETE("/:id", categoryHandler.DeleteCategory)
+		}
+
+		// Теги тредов
+		tags := api.Group("/tags")
+		{
+			tags.GET("", tagHandler.GetTags)
+			tags.PUT("/:id", tagHandler.RenameTag)
+			tags.POST("/:id/merge", tagHandler.MergeTag)
 		}
 
 		// Маршруты для постов
@@ -52,21 +113,59 @@ func RegisterRoutes(router *gin.Engine, services *Services) {
 			posts.GET("", postHandler.GetAllPosts)
 			posts.GET("/:id", postHandler.GetPost)
 			posts.GET("/:id/comments", postHandler.GetPostComments)
+			posts.GET("/:id/revisions", postHandler.GetPostRevisions)
+			posts.GET("/:id/revisions/diff", postHandler.GetPostRevisionDiff)
 			posts.POST("", postHandler.CreatePost)
 			posts.PUT("/:id", postHandler.UpdatePost)
 			posts.DELETE("/:id", postHandler.DeletePost)
+			posts.PUT("/:id/pin", postHandler.PinPost)
+			posts.POST("/:id/vote", voteHandler.VotePost)
+			posts.DELETE("/:id/vote", voteHandler.UnvotePost)
+			posts.POST("/:id/reactions", reactionHandler.ReactPost)
 		}
 
 		// Маршруты для комментариев
 		comments := api.Group("/comments")
 		{
 			comments.POST("", commentHandler.CreateComment)
+			comments.PUT("/:id", commentHandler.UpdateComment)
 			comments.DELETE("/:id", commentHandler.DeleteComment)
+			comments.POST("/:id/vote", voteHandler.VoteComment)
+			comments.DELETE("/:id/vote", voteHandler.UnvoteComment)
+			comments.POST("/:id/reactions", reactionHandler.ReactComment)
 		}
 
 		// Чат
 		api.GET("/chat", chatHandler.GetMessages)
 		api.POST("/chat", chatHandler.CreateMessage)
+		api.POST("/chat/:id/reactions", reactionHandler.ReactMessage)
+
+		// Реакции
+		api.GET("/reactions", reactionHandler.GetReactionEmojis)
+
+		// Закладки
+		api.POST("/bookmarks", bookmarkHandler.AddBookmark)
+		api.DELETE("/bookmarks", bookmarkHandler.RemoveBookmark)
+		api.GET("/users/me/bookmarks", bookmarkHandler.GetMyBookmarks)
+
+		// Подписки и уведомления
+		api.POST("/subscriptions", notificationHandler.Subscribe)
+		api.DELETE("/subscriptions", notificationHandler.Unsubscribe)
+		api.GET("/notifications", notificationHandler.GetNotifications)
+		api.GET("/notifications/unread-count", notificationHandler.GetUnreadCount)
+		api.PUT("/notifications/read-all", notificationHandler.MarkAllRead)
+		api.PUT("/notifications/:id/read", notificationHandler.MarkRead)
+
+		// Загрузка и скачивание файлов
+		api.POST("/attachments", attachmentHandler.UploadAttachment)
+		api.GET("/attachments/:id", attachmentHandler.GetAttachment)
+		api.GET("/attachments/:id/thumbnail", attachmentHandler.GetAttachmentThumbnail)
+
+		// Поиск
+		api.GET("/search", searchHandler.Search)
+
+		// Метрики
+		api.GET("/metrics/cache", metricsHandler.GetCacheStats)
 	}
 
 	// Обработчики ошибок
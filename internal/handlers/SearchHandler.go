@@ -0,0 +1,149 @@
+package handlers
+
+import (
+	"ForumService/internal/errors"
+	"ForumService/internal/middleware"
+	"ForumService/internal/models"
+	"ForumService/internal/service"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// searchDateLayout - формат дат в фильтрах from/to
+const searchDateLayout = "2006-01-02"
+
+type SearchHandler struct {
+	service service.SearchService
+}
+
+func NewSearchHandler(service service.SearchService) *SearchHandler {
+	return &SearchHandler{service: service}
+}
+
+// Search godoc
+// @Summary Полнотекстовый поиск
+// @Description Ищет по заголовкам тредов, постам и комментариям. Результаты отсортированы по релевантности, совпадения в сниппетах выделены тегом <mark>.
+// @Description Записи из разделов, закрытых для пользователя, в результаты не попадают.
+// @Tags search
+// @Produce json
+// @Param q query string true "Поисковый запрос"
+// @Param type query string false "Типы результатов через запятую: thread, post, comment"
+// @Param author_id query int false "ID автора"
+// @Param author query string false "Имя автора"
+// @Param from query string false "Начальная дата (YYYY-MM-DD)"
+// @Param to query string false "Конечная дата включительно (YYYY-MM-DD)"
+// @Param page query int false "Номер страницы (с 1)"
+// @Param limit query int false "Размер страницы (до 100)"
+// @Success 200 {object} models.SearchPage
+// @Failure 400 {object} map[string]string "неверные параметры поиска"
+// @Failure 500 {object} map[string]string "ошибка сервера"
+// @Router /search [get]
+func (h *SearchHandler) Search(c *gin.Context) {
+	params, page, err := parseSearchParams(c)
+	if err != nil {
+		c.Error(errors.NewBadRequestError("Неверные параметры поиска", err))
+		return
+	}
+
+	result, err := h.service.Search(params, page)
+	if err != nil {
+		c.Error(middleware.ToForumError(err, "Ошибка при выполнении поиска"))
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// ShowSearchPage отображает страницу поиска с результатами
+func (h *SearchHandler) ShowSearchPage(c *gin.Context) {
+	data := gin.H{
+		"query":  c.Query("q"),
+		"type":   c.Query("type"),
+		"author": c.Query("author"),
+		"from":   c.Query("from"),
+		"to":     c.Query("to"),
+	}
+	username, _ := c.Get("username")
+	data["username"] = username
+
+	if strings.TrimSpace(c.Query("q")) == "" {
+		c.HTML(http.StatusOK, "search.html", data)
+		return
+	}
+
+	params, page, err := parseSearchParams(c)
+	if err != nil {
+		data["error"] = "Неверные параметры поиска"
+		c.HTML(http.StatusBadRequest, "search.html", data)
+		return
+	}
+
+	result, err := h.service.Search(params, page)
+	if err != nil {
+		data["error"] = "Не удалось выполнить поиск"
+		c.HTML(http.StatusBadRequest, "search.html", data)
+		return
+	}
+
+	data["result"] = result
+	if page > 1 {
+		data["prev_page"] = page - 1
+	}
+	if result.Page*result.Limit < result.Total {
+		data["next_page"] = page + 1
+	}
+	c.HTML(http.StatusOK, "search.html", data)
+}
+
+// parseSearchParams разбирает параметры поиска из строки запроса
+func parseSearchParams(c *gin.Context) (models.SearchParams, int, error) {
+	params := models.SearchParams{
+		Query:      c.Query("q"),
+		AuthorName: strings.TrimSpace(c.Query("author")),
+		Role:       models.Role(ViewerRole(c)),
+	}
+
+	for _, value := range c.QueryArray("type") {
+		for _, searchType := range strings.Split(value, ",") {
+			if searchType = strings.TrimSpace(searchType); searchType != "" {
+				params.Types = append(params.Types, searchType)
+			}
+		}
+	}
+
+	var err error
+	if value := c.Query("author_id"); value != "" {
+		if params.AuthorID, err = strconv.Atoi(value); err != nil {
+			return params, 0, err
+		}
+	}
+	if value := c.Query("from"); value != "" {
+		if params.From, err = time.Parse(searchDateLayout, value); err != nil {
+			return params, 0, err
+		}
+	}
+	if value := c.Query("to"); value != "" {
+		if params.To, err = time.Parse(searchDateLayout, value); err != nil {
+			return params, 0, err
+		}
+		// Конечная дата включается в диапазон целиком
+		params.To = params.To.AddDate(0, 0, 1)
+	}
+	if value := c.Query("limit"); value != "" {
+		if params.Limit, err = strconv.Atoi(value); err != nil {
+			return params, 0, err
+		}
+	}
+
+	page := 1
+	if value := c.Query("page"); value != "" {
+		if page, err = strconv.Atoi(value); err != nil {
+			return params, 0, err
+		}
+	}
+
+	return params, page, nil
+}
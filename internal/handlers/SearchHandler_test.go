@@ -0,0 +1,116 @@
+package handlers
+
+import (
+	"ForumService/internal/handlers/mocks"
+	"ForumService/internal/middleware"
+	"ForumService/internal/models"
+	"ForumService/internal/service"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func setupSearchTestRouter(handler *SearchHandler) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(middleware.ErrorHandler())
+	router.GET("/search", handler.Search)
+	return router
+}
+
+func TestSearchHandler_Search(t *testing.T) {
+	var gotParams models.SearchParams
+	var gotPage int
+	mockSearchService := &mocks.MockSearchService{
+		SearchFunc: func(params models.SearchParams, page int) (*models.SearchPage, error) {
+			gotParams = params
+			gotPage = page
+			return &models.SearchPage{
+				Query:   params.Query,
+				Results: []models.SearchResult{{Type: models.SearchTypePost, ID: 3, ThreadID: 1, PostID: 3, Snippet: "<mark>go</mark>"}},
+				Total:   1,
+				Page:    page,
+				Limit:   20,
+			}, nil
+		},
+	}
+
+	router := setupSearchTestRouter(NewSearchHandler(mockSearchService))
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/search?q=go&type=post,comment&author=user&from=2024-01-01&to=2024-01-31&page=2", nil)
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "go", gotParams.Query)
+	assert.Equal(t, []string{"post", "comment"}, gotParams.Types)
+	assert.Equal(t, "user", gotParams.AuthorName)
+	assert.Equal(t, time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), gotParams.From)
+	assert.Equal(t, time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC), gotParams.To)
+	assert.Equal(t, 2, gotPage)
+
+	var response models.SearchPage
+	err := json.Unmarshal(w.Body.Bytes(), &response)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, response.Total)
+	assert.Equal(t, "<mark>go</mark>", response.Results[0].Snippet)
+}
+
+func TestSearchHandler_Search_Errors(t *testing.T) {
+	tests := []struct {
+		name           string
+		url            string
+		serviceError   error
+		expectedStatus int
+		expectedBody   map[string]interface{}
+	}{
+		{
+			name:           "неверная дата",
+			url:            "/search?q=go&from=01.01.2024",
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   map[string]interface{}{"error": "Неверные параметры поиска", "code": "bad_request"},
+		},
+		{
+			name:           "пустой запрос",
+			url:            "/search?q=",
+			serviceError:   service.ErrEmptySearchQuery,
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   map[string]interface{}{"error": "Поисковый запрос не может быть пустым", "code": "bad_request"},
+		},
+		{
+			name:           "неизвестный тип",
+			url:            "/search?q=go&type=user",
+			serviceError:   service.ErrInvalidSearchType,
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   map[string]interface{}{"error": "Неизвестный тип результатов поиска", "code": "bad_request"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockSearchService := &mocks.MockSearchService{
+				SearchFunc: func(params models.SearchParams, page int) (*models.SearchPage, error) {
+					return nil, tt.serviceError
+				},
+			}
+
+			router := setupSearchTestRouter(NewSearchHandler(mockSearchService))
+
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("GET", tt.url, nil)
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+
+			var response map[string]interface{}
+			err := json.Unmarshal(w.Body.Bytes(), &response)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedBody, response)
+		})
+	}
+}
@@ -0,0 +1,131 @@
+package handlers
+
+import (
+	"ForumService/internal/errors"
+	"ForumService/internal/middleware"
+	"ForumService/internal/service"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type TagHandler struct {
+	service service.TagService
+}
+
+func NewTagHandler(service service.TagService) *TagHandler {
+	return &TagHandler{service: service}
+}
+
+type RenameTagRequest struct {
+	Name string `json:"name" binding:"required"`
+}
+
+type MergeTagRequest struct {
+	// TargetID - тег, на который переносятся треды объединяемого тега
+	TargetID int `json:"target_id" binding:"required"`
+}
+
+// GetTags godoc
+// @Summary Получить теги
+// @Description Возвращает используемые теги с числом тредов, популярные первыми.
+// @Tags tags
+// @Produce json
+// @Success 200 {array} models.Tag
+// @Failure 500 {object} map[string]string "ошибка сервера"
+// @Router /tags [get]
+func (h *TagHandler) GetTags(c *gin.Context) {
+	tags, err := h.service.GetTags()
+	if err != nil {
+		c.Error(middleware.ToForumError(err, "Ошибка при получении тегов"))
+		return
+	}
+
+	c.JSON(http.StatusOK, tags)
+}
+
+// RenameTag godoc
+// @Summary Переименовать тег
+// @Description Переименовывает тег у всех тредов. Доступно модераторам и администраторам.
+// @Tags tags
+// @Accept json
+// @Produce json
+// @Param id path int true "ID тега"
+// @Param input body RenameTagRequest true "Новое название"
+// @Success 200 {object} models.Tag
+// @Failure 400 {object} map[string]string "неверный ID или формат данных"
+// @Failure 401 {object} map[string]string "пользователь не аутентифицирован"
+// @Failure 403 {object} map[string]string "недостаточно прав"
+// @Failure 404 {object} map[string]string "тег не найден"
+// @Failure 409 {object} map[string]string "тег с таким названием уже существует"
+// @Router /tags/{id} [put]
+func (h *TagHandler) RenameTag(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(errors.NewBadRequestError("Неверный ID тега", err))
+		return
+	}
+
+	var request RenameTagRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.Error(errors.NewValidationError("Неверный формат данных", err))
+		return
+	}
+
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.Error(errors.NewUnauthorizedError("Пользователь не аутентифицирован", nil))
+		return
+	}
+
+	tag, err := h.service.RenameTag(id, request.Name, int(userID.(uint32)))
+	if err != nil {
+		c.Error(middleware.ToForumError(err, "Ошибка при переименовании тега"))
+		return
+	}
+
+	c.JSON(http.StatusOK, tag)
+}
+
+// MergeTag godoc
+// @Summary Объединить теги
+// @Description Переносит треды тега на целевой тег и удаляет исходный тег. Доступно модераторам и администраторам.
+// @Tags tags
+// @Accept json
+// @Produce json
+// @Param id path int true "ID объединяемого тега"
+// @Param input body MergeTagRequest true "Целевой тег"
+// @Success 200 {object} models.Tag "целевой тег после объединения"
+// @Failure 400 {object} map[string]string "неверный ID или формат данных"
+// @Failure 401 {object} map[string]string "пользователь не аутентифицирован"
+// @Failure 403 {object} map[string]string "недостаточно прав"
+// @Failure 404 {object} map[string]string "тег не найден"
+// @Router /tags/{id}/merge [post]
+func (h *TagHandler) MergeTag(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(errors.NewBadRequestError("Неверный ID тега", err))
+		return
+	}
+
+	var request MergeTagRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.Error(errors.NewValidationError("Неверный формат данных", err))
+		return
+	}
+
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.Error(errors.NewUnauthorizedError("Пользователь не аутентифицирован", nil))
+		return
+	}
+
+	tag, err := h.service.MergeTags(id, request.TargetID, int(userID.(uint32)))
+	if err != nil {
+		c.Error(middleware.ToForumError(err, "Ошибка при объединении тегов"))
+		return
+	}
+
+	c.JSON(http.StatusOK, tag)
+}
@@ -0,0 +1,122 @@
+package handlers
+
+import (
+	"ForumService/internal/handlers/mocks"
+	"ForumService/internal/middleware"
+	"ForumService/internal/models"
+	"ForumService/internal/service"
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupTagTestRouter(handler *TagHandler, userID uint32) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(middleware.ErrorHandler())
+	router.Use(func(c *gin.Context) {
+		if userID != 0 {
+			c.Set("user_id", userID)
+		}
+		c.Next()
+	})
+	router.GET("/tags", handler.GetTags)
+	router.PUT("/tags/:id", handler.RenameTag)
+	router.POST("/tags/:id/merge", handler.MergeTag)
+	return router
+}
+
+func TestTagHandler_GetTags(t *testing.T) {
+	mockService := &mocks.MockTagService{
+		GetTagsFunc: func() ([]*models.Tag, error) {
+			return []*models.Tag{{ID: 1, Name: "go", ThreadCount: 3}, {ID: 2, Name: "sql", ThreadCount: 1}}, nil
+		},
+	}
+	router := setupTagTestRouter(NewTagHandler(mockService), 0)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/tags", nil)
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var tags []*models.Tag
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &tags))
+	require.Len(t, tags, 2)
+	assert.Equal(t, 3, tags[0].ThreadCount)
+}
+
+func TestTagHandler_RenameTag(t *testing.T) {
+	tests := []struct {
+		name           string
+		userID         uint32
+		path           string
+		body           string
+		err            error
+		expectedStatus int
+	}{
+		{"успешно", 1, "/tags/1", `{"name":"golang"}`, nil, http.StatusOK},
+		{"не аутентифицирован", 0, "/tags/1", `{"name":"golang"}`, nil, http.StatusUnauthorized},
+		{"неверный ID", 1, "/tags/abc", `{"name":"golang"}`, nil, http.StatusBadRequest},
+		{"без названия", 1, "/tags/1", `{}`, nil, http.StatusBadRequest},
+		{"не модератор", 1, "/tags/1", `{"name":"golang"}`, service.ErrNoPermission, http.StatusForbidden},
+		{"название занято", 1, "/tags/1", `{"name":"sql"}`, service.ErrTagExists, http.StatusConflict},
+		{"тег не найден", 1, "/tags/1", `{"name":"golang"}`, service.ErrTagNotFound, http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := &mocks.MockTagService{
+				RenameTagFunc: func(id int, name string, userID int) (*models.Tag, error) {
+					if tt.err != nil {
+						return nil, tt.err
+					}
+					return &models.Tag{ID: id, Name: name}, nil
+				},
+			}
+			router := setupTagTestRouter(NewTagHandler(mockService), tt.userID)
+
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("PUT", tt.path, bytes.NewBufferString(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+		})
+	}
+}
+
+func TestTagHandler_MergeTag(t *testing.T) {
+	var gotSource, gotTarget int
+	mockService := &mocks.MockTagService{
+		MergeTagsFunc: func(sourceID, targetID int, userID int) (*models.Tag, error) {
+			gotSource, gotTarget = sourceID, targetID
+			if sourceID == targetID {
+				return nil, service.ErrInvalidTagMerge
+			}
+			return &models.Tag{ID: targetID, Name: "go", ThreadCount: 4}, nil
+		},
+	}
+	router := setupTagTestRouter(NewTagHandler(mockService), 1)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/tags/2/merge", bytes.NewBufferString(`{"target_id":1}`))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, 2, gotSource)
+	assert.Equal(t, 1, gotTarget)
+	assert.Contains(t, w.Body.String(), `"thread_count":4`)
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("POST", "/tags/1/merge", bytes.NewBufferString(`{"target_id":1}`))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
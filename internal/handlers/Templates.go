@@ -0,0 +1,86 @@
+package handlers
+
+import (
+	"ForumService/internal/markdown"
+	"ForumService/internal/models"
+	"ForumService/internal/service"
+	"fmt"
+	"html/template"
+	"strconv"
+	"strings"
+)
+
+// contentRenderer переводит текст записей из Markdown в HTML. Кэш общий для страниц
+// и API: одна и та же версия текста отрисовывается один раз.
+var contentRenderer = markdown.NewRenderer(markdown.DefaultCacheSize)
+
+// TemplateFuncs - функции HTML-шаблонов, подключаются до загрузки шаблонов
+func TemplateFuncs() template.FuncMap {
+	return template.FuncMap{
+		"content":     RenderContent,
+		"postContent": RenderPost,
+	}
+}
+
+// PostURL возвращает адрес страницы поста
+func PostURL(postID int) string {
+	return fmt.Sprintf("/posts/%d", postID)
+}
+
+// ProfileURL возвращает адрес страницы пользователя
+func ProfileURL(userID int) string {
+	return fmt.Sprintf("/users/%d", userID)
+}
+
+// RenderContent переводит текст записи из Markdown в очищенный HTML. Упоминания из mentions
+// становятся ссылками на страницы пользователей, упоминания неизвестных пользователей
+// остаются текстом.
+func RenderContent(content string, mentions []models.Mention) template.HTML {
+	var links map[string]string
+	if len(mentions) > 0 {
+		links = make(map[string]string, len(mentions))
+		for _, mention := range mentions {
+			links[mention.Username] = ProfileURL(mention.UserID)
+		}
+	}
+	return template.HTML(contentRenderer.Render(content, links))
+}
+
+// RenderPost переводит текст поста в HTML: цитаты [quote=ID] становятся блоками со ссылкой
+// на процитированный пост, текст вне цитат и внутри них отрисовывается как в RenderContent.
+// Цитата удаленного поста остается блоком с текстом, но без ссылки.
+func RenderPost(post *models.Post) template.HTML {
+	quotes := make(map[int]models.PostRef, len(post.Quotes))
+	for _, quote := range post.Quotes {
+		quotes[quote.ID] = quote
+	}
+
+	var html strings.Builder
+	last := 0
+	for _, match := range service.QuotePattern.FindAllStringSubmatchIndex(post.Content, -1) {
+		html.WriteString(string(RenderContent(post.Content[last:match[0]], post.Mentions)))
+		id, _ := strconv.Atoi(post.Content[match[2]:match[3]])
+		quote, ok := quotes[id]
+		if !ok {
+			quote = models.PostRef{ID: id}
+		}
+		html.WriteString(quoteHeader(quote))
+		html.WriteString(string(RenderContent(strings.TrimSpace(post.Content[match[4]:match[5]]), post.Mentions)))
+		html.WriteString("</blockquote>")
+		last = match[1]
+	}
+	html.WriteString(string(RenderContent(post.Content[last:], post.Mentions)))
+	return template.HTML(html.String())
+}
+
+// quoteHeader открывает блок цитаты и подписывает, из какого поста она взята
+func quoteHeader(quote models.PostRef) string {
+	if quote.Deleted {
+		return fmt.Sprintf(`<blockquote class="post-quote post-quote-deleted"><div class="post-quote-source">Цитата из удаленного поста #%d</div>`, quote.ID)
+	}
+	source := fmt.Sprintf("Пост #%d", quote.ID)
+	if quote.AuthorName != "" {
+		source = fmt.Sprintf("%s в посте #%d", template.HTMLEscapeString(quote.AuthorName), quote.ID)
+	}
+	return fmt.Sprintf(`<blockquote class="post-quote"><div class="post-quote-source"><a href="%s">%s</a></div>`, PostURL(quote.ID), source)
+}
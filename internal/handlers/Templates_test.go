@@ -0,0 +1,37 @@
+package handlers
+
+import (
+	"html/template"
+	"testing"
+
+	"ForumService/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRenderContent(t *testing.T) {
+	mentions := []models.Mention{{UserID: 3, Username: "bob"}, {UserID: 4, Username: "Иван"}}
+
+	assert.Equal(t,
+		template.HTML(`<p><a href="/users/3" class="mention">@bob</a>, смотри <b>тут</b> и спроси <a href="/users/4" class="mention">@Иван</a>.</p>`+"\n"),
+		RenderContent("@bob, смотри <b>тут</b> и спроси @Иван.", mentions))
+	assert.Equal(t,
+		template.HTML(`<p>@ghost остается текстом, <code>@bob</code> в коде тоже</p>`+"\n"),
+		RenderContent("@ghost остается текстом, `@bob` в коде тоже", mentions))
+	assert.Equal(t, template.HTML(""), RenderContent("<script>alert(1)</script>", nil))
+}
+
+func TestRenderPost(t *testing.T) {
+	post := &models.Post{
+		Content:  "[quote=3]\n@bob **прав**\n[/quote]\nСогласен, @bob. [quote=4]старое[/quote]",
+		Mentions: []models.Mention{{UserID: 5, Username: "bob"}},
+		Quotes:   []models.PostRef{{ID: 3, AuthorName: "<alice>"}, {ID: 4, Deleted: true}},
+	}
+
+	assert.Equal(t, template.HTML(
+		`<blockquote class="post-quote"><div class="post-quote-source"><a href="/posts/3">&lt;alice&gt; в посте #3</a></div>`+
+			`<p><a href="/users/5" class="mention">@bob</a> <strong>прав</strong></p>`+"\n</blockquote>"+
+			`<p>Согласен, <a href="/users/5" class="mention">@bob</a>.</p>`+"\n"+
+			`<blockquote class="post-quote post-quote-deleted"><div class="post-quote-source">Цитата из удаленного поста #4</div><p>старое</p>`+"\n</blockquote>"),
+		RenderPost(post))
+	assert.Equal(t, template.HTML("<p>[quote=3]без конца</p>\n"), RenderPost(&models.Post{Content: "[quote=3]без конца"}))
+}
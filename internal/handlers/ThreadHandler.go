@@ -2,8 +2,10 @@ package handlers
 
 import (
 	"ForumService/internal/service"
+	"ForumService/internal/markdown"
 	"ForumService/internal/models"
 	"ForumService/internal/errors"
+	"ForumService/internal/middleware"
 	"github.com/gin-gonic/gin"
 	"net/http"
 	"strconv"
@@ -12,22 +14,89 @@ import (
 	"fmt"
 	"unicode"
 	"regexp"
+
+	"github.com/Luxtington/Shared/logger"
+	"go.uber.org/zap"
 )
 
 type ThreadHandler struct {
-	service service.ThreadService
+	service       service.ThreadService
+	reactions     service.ReactionService
+	notifications service.NotificationService
+	attachments   service.AttachmentService
+	polls         service.PollService
 }
 
 func NewThreadHandler(service service.ThreadService) *ThreadHandler {
 	return &ThreadHandler{service: service}
 }
 
+// WithReactions добавляет реакции в ответ GetThreadPosts
+func (h *ThreadHandler) WithReactions(reactions service.ReactionService) *ThreadHandler {
+	h.reactions = reactions
+	return h
+}
+
+// WithNotifications подписывает авторов на созданные треды и добавляет упоминания
+// в ответ GetThreadPosts
+func (h *ThreadHandler) WithNotifications(notifications service.NotificationService) *ThreadHandler {
+	h.notifications = notifications
+	return h
+}
+
+// WithAttachments добавляет вложения постов в ответ GetThreadPosts
+func (h *ThreadHandler) WithAttachments(attachments service.AttachmentService) *ThreadHandler {
+	h.attachments = attachments
+	return h
+}
+
+// WithPolls создает опрос вместе с тредом и добавляет его в ответ GetThreadWithPosts
+func (h *ThreadHandler) WithPolls(polls service.PollService) *ThreadHandler {
+	h.polls = polls
+	return h
+}
+
 type CreateThreadRequest struct {
 	Title string `json:"title" binding:"required"`
+	// Type - тип треда: discussion (по умолчанию) или question
+	Type string `json:"type"`
+	// CategoryID - раздел треда, без него тред создается вне разделов
+	CategoryID *int `json:"category_id"`
+	// Tags - теги треда, не больше 5
+	Tags []string `json:"tags"`
+	// Poll - опрос, создаваемый вместе с тредом
+	Poll *CreatePollRequest `json:"poll"`
 }
 
 type UpdateThreadRequest struct {
 	Title string `json:"title" binding:"required"`
+	// Type меняет тип треда на discussion или question, без него тип не меняется
+	Type string `json:"type"`
+	// CategoryID переносит тред в другой раздел, без него раздел не меняется
+	CategoryID *int `json:"category_id"`
+	// Tags заменяет теги треда, без него теги не меняются, пустой список удаляет все теги
+	Tags []string `json:"tags"`
+}
+
+type LockThreadRequest struct {
+	// Locked - true закрывает тред, false открывает
+	Locked *bool `json:"locked" binding:"required"`
+	// Reason - причина закрытия, показывается в треде
+	Reason string `json:"reason"`
+}
+
+type PinThreadRequest struct {
+	// Pinned - true закрепляет тред, false снимает закрепление
+	Pinned *bool `json:"pinned" binding:"required"`
+	// Order - место среди закрепленных тредов, меньшие значения выше
+	Order int `json:"order"`
+	// Until - срок закрепления, без него тред закреплен бессрочно
+	Until *time.Time `json:"until"`
+}
+
+type AcceptAnswerRequest struct {
+	// PostID - пост, принимаемый как ответ; null снимает отметку
+	PostID *int `json:"post_id"`
 }
 
 // func (h *ThreadHandler) RegisterRoutes(r *gin.RouterGroup) {
@@ -41,14 +110,17 @@ type UpdateThreadRequest struct {
 
 // CreateThread godoc
 // @Summary Создать новый тред
-// @Description Создаёт новый тред (тему) форума. Доступно только авторизованным пользователям.
+// @Description Создаёт новый тред (тему) форума. Доступно только авторизованным пользователям, в разделе - пользователям с правом создания тредов в нем.
+// @Description Вместе с тредом можно создать опрос (поле poll): вопрос и от 2 до 10 вариантов.
 // @Tags threads
 // @Accept json
 // @Produce json
 // @Param input body object true "Данные для создания треда"
 // @Success 201 {object} models.Thread
-// @Failure 400 {object} map[string]string "неверный формат данных"
+// @Failure 400 {object} map[string]string "неверный формат данных, теги или опрос"
 // @Failure 401 {object} map[string]string "пользователь не аутентифицирован"
+// @Failure 403 {object} map[string]string "нет прав на создание тредов в разделе"
+// @Failure 404 {object} map[string]string "раздел не найден"
 // @Failure 500 {object} map[string]string "ошибка сервера"
 // @Router /threads [post]
 func (h *ThreadHandler) CreateThread(c *gin.Context) {
@@ -64,24 +136,50 @@ func (h *ThreadHandler) CreateThread(c *gin.Context) {
 		return
 	}
 
+	var poll *models.Poll
+	if request.Poll != nil && h.polls != nil {
+		poll = request.Poll.toPoll()
+		if err := h.polls.Validate(poll); err != nil {
+			c.Error(middleware.ToForumError(err, "Ошибка при проверке опроса"))
+			return
+		}
+	}
+
 	userIDInt := int(userID.(uint32))
-	thread, err := h.service.CreateThread(request.Title, userIDInt)
+	thread, err := h.service.CreateThread(request.Title, request.Type, request.CategoryID, request.Tags, userIDInt)
 	if err != nil {
-		c.Error(errors.NewInternalServerError("Ошибка при создании треда", err))
+		c.Error(middleware.ToForumError(err, "Ошибка при создании треда"))
 		return
 	}
+	if poll != nil {
+		if err := h.polls.Create(thread.ID, poll); err != nil {
+			// Тред без запрошенного опроса не оставляем: повтор запроса создал бы дубль
+			if delErr := h.service.DeleteThread(thread.ID, userIDInt); delErr != nil {
+				logger.GetLogger().Error("Ошибка при удалении треда без опроса",
+					zap.Int("thread_id", thread.ID), zap.Error(delErr))
+			}
+			c.Error(middleware.ToForumError(err, "Ошибка при создании опроса"))
+			return
+		}
+		thread.Poll = poll
+	}
+	if h.notifications != nil {
+		logNotificationError(h.notifications.ThreadCreated(thread), "новом треде")
+	}
 
 	c.JSON(http.StatusCreated, thread)
 }
 
 // GetThreadWithPosts godoc
 // @Summary Получить тред с постами
-// @Description Возвращает информацию о треде и все посты в нём.
+// @Description Возвращает информацию о треде и все посты в нём. Опрос треда с итогами - в поле thread.poll.
 // @Tags threads
 // @Produce json
 // @Param id path int true "ID треда"
 // @Success 200 {object} map[string]interface{} "thread: информация о треде, posts: список постов"
+// @Header 200 {string} ETag "Версия треда для заголовка If-Match"
 // @Failure 400 {object} map[string]string "invalid thread ID"
+// @Failure 403 {object} map[string]string "нет прав на чтение раздела треда"
 // @Failure 404 {object} map[string]string "thread not found"
 // @Router /threads/{id} [get]
 func (h *ThreadHandler) GetThreadWithPosts(c *gin.Context) {
@@ -93,10 +191,20 @@ func (h *ThreadHandler) GetThreadWithPosts(c *gin.Context) {
 
 	thread, posts, err := h.service.GetThreadWithPosts(id)
 	if err != nil {
-		c.Error(errors.NewNotFoundError("Тред не найден", err))
+		c.Error(middleware.ToForumError(err, "Ошибка при получении треда"))
 		return
 	}
 
+	if err := h.service.CheckThreadAccess(thread, ViewerRole(c)); err != nil {
+		c.Error(middleware.ToForumError(err, "Ошибка при получении треда"))
+		return
+	}
+	if err := AttachThreadPoll(h.polls, thread, viewerID(c)); err != nil {
+		c.Error(middleware.ToForumError(err, "Ошибка при получении опроса"))
+		return
+	}
+
+	c.Header("ETag", versionETag(thread.Version))
 	c.JSON(http.StatusOK, gin.H{
 		"thread": thread,
 		"posts":  posts,
@@ -133,7 +241,7 @@ func (h *ThreadHandler) DeleteThread(c *gin.Context) {
 
 	thread, _, err := h.service.GetThreadWithPosts(id)
 	if err != nil {
-		c.Error(errors.NewNotFoundError("Тред не найден", err))
+		c.Error(middleware.ToForumError(err, "Ошибка при получении треда"))
 		return
 	}
 
@@ -143,7 +251,7 @@ func (h *ThreadHandler) DeleteThread(c *gin.Context) {
 	}
 
 	if err := h.service.DeleteThread(id, userIDInt); err != nil {
-		c.Error(errors.NewInternalServerError("Ошибка при удалении треда", err))
+		c.Error(middleware.ToForumError(err, "Ошибка при удалении треда"))
 		return
 	}
 
@@ -152,16 +260,19 @@ func (h *ThreadHandler) DeleteThread(c *gin.Context) {
 
 // UpdateThread godoc
 // @Summary Обновить тред
-// @Description Обновляет информацию о треде. Доступно только автору треда или администратору.
+// @Description Обновляет информацию о треде. Доступно только автору треда или администратору. Перенести тред можно только в раздел, где пользователь может создавать треды.
+// @Description Поле type меняет тип треда на discussion или question.
 // @Tags threads
 // @Accept json
 // @Produce json
 // @Param id path int true "ID треда"
 // @Param input body object true "Данные для обновления треда"
+// @Param If-Match header string false "ETag, полученный при чтении треда"
 // @Success 200 {object} models.Thread
-// @Failure 400 {object} map[string]string "invalid thread ID или неверный формат данных"
+// @Failure 400 {object} map[string]string "invalid thread ID, неверный формат данных или тип треда"
 // @Failure 401 {object} map[string]string "unauthorized"
 // @Failure 403 {object} map[string]string "no permission to update this thread"
+// @Failure 412 {object} map[string]string "тред был изменен другим пользователем"
 // @Failure 500 {object} map[string]string "ошибка сервера"
 // @Router /threads/{id} [put]
 func (h *ThreadHandler) UpdateThread(c *gin.Context) {
@@ -177,6 +288,12 @@ func (h *ThreadHandler) UpdateThread(c *gin.Context) {
 		return
 	}
 
+	expectedVersion, err := ifMatchVersion(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
 	userID, exists := c.Get("user_id")
 	if !exists {
 		c.Error(errors.NewUnauthorizedError("Пользователь не аутентифицирован", nil))
@@ -188,7 +305,7 @@ func (h *ThreadHandler) UpdateThread(c *gin.Context) {
 
 	thread, _, err := h.service.GetThreadWithPosts(id)
 	if err != nil {
-		c.Error(errors.NewNotFoundError("Тред не найден", err))
+		c.Error(middleware.ToForumError(err, "Ошибка при получении треда"))
 		return
 	}
 
@@ -197,29 +314,227 @@ func (h *ThreadHandler) UpdateThread(c *gin.Context) {
 		return
 	}
 
+	// Версия проверяется еще раз в репозитории, здесь отсекаем заведомо устаревшие правки
+	if expectedVersion > 0 && expectedVersion != thread.Version {
+		c.Error(middleware.ToForumError(service.ErrVersionConflict, ""))
+		return
+	}
+
 	thread.Title = request.Title
+	if request.Type != "" {
+		thread.Type = request.Type
+	}
+	if request.CategoryID != nil {
+		thread.CategoryID = request.CategoryID
+	}
+	currentTags := thread.Tags
+	thread.Tags = request.Tags
+	thread.Version = expectedVersion
 	if err := h.service.UpdateThread(thread, userIDInt); err != nil {
-		c.Error(errors.NewInternalServerError("Ошибка при обновлении треда", err))
+		c.Error(middleware.ToForumError(err, "Ошибка при обновлении треда"))
 		return
 	}
+	if thread.Tags == nil {
+		thread.Tags = currentTags
+	}
 
+	c.Header("ETag", versionETag(thread.Version))
+	c.JSON(http.StatusOK, thread)
+}
+
+// LockThread godoc
+// @Summary Закрыть или открыть тред
+// @Description Закрывает тред для новых постов и комментариев или открывает его снова. Доступно модераторам и администраторам.
+// @Tags threads
+// @Accept json
+// @Produce json
+// @Param id path int true "ID треда"
+// @Param input body LockThreadRequest true "Состояние треда и причина закрытия"
+// @Success 200 {object} models.Thread
+// @Failure 400 {object} map[string]string "неверный ID треда, формат данных или слишком длинная причина"
+// @Failure 401 {object} map[string]string "пользователь не аутентифицирован"
+// @Failure 403 {object} map[string]string "недостаточно прав"
+// @Failure 404 {object} map[string]string "тред не найден"
+// @Router /threads/{id}/lock [put]
+func (h *ThreadHandler) LockThread(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(errors.NewBadRequestError("Неверный ID треда", err))
+		return
+	}
+
+	var request LockThreadRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.Error(errors.NewValidationError("Неверный формат данных", err))
+		return
+	}
+
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.Error(errors.NewUnauthorizedError("Пользователь не аутентифицирован", nil))
+		return
+	}
+
+	thread, err := h.service.SetThreadLock(id, *request.Locked, request.Reason, int(userID.(uint32)))
+	if err != nil {
+		c.Error(middleware.ToForumError(err, "Ошибка при изменении состояния треда"))
+		return
+	}
+
+	c.Header("ETag", versionETag(thread.Version))
+	c.JSON(http.StatusOK, thread)
+}
+
+// PinThread godoc
+// @Summary Закрепить или открепить тред
+// @Description Закрепляет тред в начале списка тредов или снимает закрепление. Закрепленные треды упорядочены по order,
+// @Description после until закрепление истекает само. Доступно модераторам и администраторам.
+// @Tags threads
+// @Accept json
+// @Produce json
+// @Param id path int true "ID треда"
+// @Param input body PinThreadRequest true "Закрепление, порядок и срок"
+// @Success 200 {object} models.Thread
+// @Failure 400 {object} map[string]string "неверный ID треда, формат данных, порядок или срок закрепления"
+// @Failure 401 {object} map[string]string "пользователь не аутентифицирован"
+// @Failure 403 {object} map[string]string "недостаточно прав"
+// @Failure 404 {object} map[string]string "тред не найден"
+// @Router /threads/{id}/pin [put]
+func (h *ThreadHandler) PinThread(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(errors.NewBadRequestError("Неверный ID треда", err))
+		return
+	}
+
+	var request PinThreadRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.Error(errors.NewValidationError("Неверный формат данных", err))
+		return
+	}
+
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.Error(errors.NewUnauthorizedError("Пользователь не аутентифицирован", nil))
+		return
+	}
+
+	thread, err := h.service.SetThreadPin(id, *request.Pinned, request.Order, request.Until, int(userID.(uint32)))
+	if err != nil {
+		c.Error(middleware.ToForumError(err, "Ошибка при закреплении треда"))
+		return
+	}
+
+	c.Header("ETag", versionETag(thread.Version))
+	c.JSON(http.StatusOK, thread)
+}
+
+// AcceptAnswer godoc
+// @Summary Принять ответ на вопрос
+// @Description Отмечает пост треда-вопроса как принятый ответ или снимает отметку при post_id = null.
+// @Description Принятый ответ идет сразу после первого поста, тред считается решенным. Доступно автору треда,
+// @Description модераторам и администраторам.
+// @Tags threads
+// @Accept json
+// @Produce json
+// @Param id path int true "ID треда"
+// @Param input body AcceptAnswerRequest true "Принятый ответ"
+// @Success 200 {object} models.Thread
+// @Failure 400 {object} map[string]string "неверный ID треда, тред не вопрос или пост не может быть ответом"
+// @Failure 401 {object} map[string]string "пользователь не аутентифицирован"
+// @Failure 403 {object} map[string]string "недостаточно прав"
+// @Failure 404 {object} map[string]string "тред или пост не найден"
+// @Router /threads/{id}/answer [put]
+func (h *ThreadHandler) AcceptAnswer(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(errors.NewBadRequestError("Неверный ID треда", err))
+		return
+	}
+
+	var request AcceptAnswerRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.Error(errors.NewValidationError("Неверный формат данных", err))
+		return
+	}
+
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.Error(errors.NewUnauthorizedError("Пользователь не аутентифицирован", nil))
+		return
+	}
+
+	thread, err := h.service.SetAcceptedAnswer(id, request.PostID, int(userID.(uint32)))
+	if err != nil {
+		c.Error(middleware.ToForumError(err, "Ошибка при принятии ответа"))
+		return
+	}
+
+	c.Header("ETag", versionETag(thread.Version))
 	c.JSON(http.StatusOK, thread)
 }
 
 // GetAllThreads godoc
 // @Summary Получить все треды
-// @Description Возвращает список всех тредов форума.
+// @Description Возвращает список тредов форума из разделов, доступных пользователю. С category_id - только треды раздела без подразделов.
+// @Description С tag - только треды с тегами: со всеми перечисленными при match=all (по умолчанию) или хотя бы с одним при match=any.
+// @Description С unanswered=true - только вопросы без принятого ответа.
 // @Tags threads
 // @Produce json
+// @Param category_id query int false "ID раздела"
+// @Param tag query []string false "Теги, можно повторять параметр или перечислить через запятую" collectionFormat(multi)
+// @Param match query string false "Совпадение тегов: all или any" Enums(all, any)
+// @Param unanswered query bool false "Только вопросы без принятого ответа"
 // @Success 200 {array} models.Thread
+// @Failure 400 {object} map[string]string "неверный ID раздела, тег, режим совпадения тегов или значение unanswered"
+// @Failure 403 {object} map[string]string "нет прав на чтение раздела"
+// @Failure 404 {object} map[string]string "раздел не найден"
 // @Failure 500 {object} map[string]string "ошибка сервера"
 // @Router /threads [get]
 func (h *ThreadHandler) GetAllThreads(c *gin.Context) {
-	threads, err := h.service.GetAllThreads()
+	tags, matchAll, err := TagFilter(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	var threads []*models.Thread
+	var categoryID *int
+	if value := c.Query("category_id"); value != "" {
+		id, convErr := strconv.Atoi(value)
+		if convErr != nil {
+			c.Error(errors.NewBadRequestError("Неверный ID раздела", convErr))
+			return
+		}
+		categoryID = &id
+	}
+	unanswered := false
+	if value := c.Query("unanswered"); value != "" {
+		parsed, convErr := strconv.ParseBool(value)
+		if convErr != nil {
+			c.Error(errors.NewBadRequestError("Неверное значение unanswered", convErr))
+			return
+		}
+		unanswered = parsed
+	}
+	switch {
+	case len(tags) > 0:
+		threads, err = h.service.GetThreadsByTags(tags, matchAll, ViewerRole(c))
+		if err == nil && categoryID != nil {
+			threads = filterThreadsByCategory(threads, *categoryID)
+		}
+	case categoryID != nil:
+		threads, err = h.service.GetThreadsByCategory(*categoryID, ViewerRole(c))
+	default:
+		threads, err = h.service.GetAllThreads(ViewerRole(c))
+	}
 	if err != nil {
-		c.Error(errors.NewInternalServerError("Ошибка при получении списка тредов", err))
+		c.Error(middleware.ToForumError(err, "Ошибка при получении списка тредов"))
 		return
 	}
+	if unanswered {
+		threads = filterUnansweredThreads(threads)
+	}
 
 	// Добавляем информацию об авторе для каждого треда
 	for _, thread := range threads {
@@ -232,14 +547,60 @@ func (h *ThreadHandler) GetAllThreads(c *gin.Context) {
 	c.JSON(http.StatusOK, threads)
 }
 
+// TagFilter разбирает фильтр по тегам из параметров tag и match запроса.
+// Теги можно передать несколькими параметрами tag или через запятую.
+func TagFilter(c *gin.Context) ([]string, bool, error) {
+	var tags []string
+	for _, value := range c.QueryArray("tag") {
+		for _, tag := range strings.Split(value, ",") {
+			if tag = strings.TrimSpace(tag); tag != "" {
+				tags = append(tags, tag)
+			}
+		}
+	}
+
+	switch c.DefaultQuery("match", "all") {
+	case "all":
+		return tags, true, nil
+	case "any":
+		return tags, false, nil
+	}
+	return nil, false, errors.NewBadRequestError("Режим совпадения тегов должен быть all или any", nil)
+}
+
+// filterThreadsByCategory оставляет треды раздела categoryID
+func filterThreadsByCategory(threads []*models.Thread, categoryID int) []*models.Thread {
+	filtered := make([]*models.Thread, 0, len(threads))
+	for _, thread := range threads {
+		if thread.CategoryID != nil && *thread.CategoryID == categoryID {
+			filtered = append(filtered, thread)
+		}
+	}
+	return filtered
+}
+
+// filterUnansweredThreads оставляет треды-вопросы без принятого ответа
+func filterUnansweredThreads(threads []*models.Thread) []*models.Thread {
+	filtered := make([]*models.Thread, 0, len(threads))
+	for _, thread := range threads {
+		if thread.Type == models.ThreadTypeQuestion && !thread.Solved {
+			filtered = append(filtered, thread)
+		}
+	}
+	return filtered
+}
+
 // GetThreadPosts godoc
 // @Summary Получить посты треда
-// @Description Возвращает список всех постов в указанном треде.
+// @Description Возвращает список всех постов в указанном треде. sort=top сортирует посты по рейтингу,
+// @Description закрепленные посты при этом остаются первыми. Принятый ответ треда-вопроса всегда идет сразу после первого поста.
 // @Tags threads
 // @Produce json
 // @Param id path int true "ID треда"
+// @Param sort query string false "Порядок: date (по умолчанию) или top"
 // @Success 200 {array} models.Post
 // @Failure 400 {object} map[string]string "invalid thread ID"
+// @Failure 403 {object} map[string]string "нет прав на чтение раздела треда"
 // @Failure 404 {object} map[string]string "thread not found"
 // @Router /threads/{id}/posts [get]
 func (h *ThreadHandler) GetThreadPosts(c *gin.Context) {
@@ -249,11 +610,35 @@ func (h *ThreadHandler) GetThreadPosts(c *gin.Context) {
 		return
 	}
 
+	order, err := SortOrder(c)
+	if err != nil {
+		c.Error(middleware.ToForumError(err, "Неверный порядок сортировки"))
+		return
+	}
+
+	if err := h.service.CheckThreadIDAccess(id, ViewerRole(c)); err != nil {
+		c.Error(middleware.ToForumError(err, "Ошибка при получении треда"))
+		return
+	}
+
 	posts, err := h.service.GetPostsByThreadID(id)
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "thread not found"})
 		return
 	}
+	service.SortPosts(posts, order)
+	if err := AttachPostReactions(h.reactions, posts, viewerID(c)); err != nil {
+		c.Error(middleware.ToForumError(err, "Ошибка при получении реакций"))
+		return
+	}
+	if err := AttachPostMentions(h.notifications, posts); err != nil {
+		c.Error(middleware.ToForumError(err, "Ошибка при получении упоминаний"))
+		return
+	}
+	if err := AttachPostAttachments(h.attachments, posts); err != nil {
+		c.Error(middleware.ToForumError(err, "Ошибка при получении вложений"))
+		return
+	}
 
 	c.JSON(http.StatusOK, posts)
 }
@@ -549,18 +934,9 @@ func formatPostSummary(content string) string {
 	return summary
 }
 
-// sanitizePostContent очищает содержимое поста
+// sanitizePostContent оставляет в HTML поста только разметку из белого списка
 func sanitizePostContent(content string) string {
-	if strings.Contains(content, "<script>") {
-		return "&lt;script&gt;alert('xss')&lt;/script&gt;Текст"
-	}
-	if strings.Contains(content, "Много    пробелов") {
-		return "Много пробелов здесь"
-	}
-	if strings.Contains(content, "Строка 1") {
-		return "Строка 1\n\nСтрока 2"
-	}
-	return content
+	return markdown.Sanitize(content)
 }
 
 // PostStats содержит статистику по постам
@@ -682,15 +1058,9 @@ func generatePostPreview(content string, maxLength int) string {
 	return content
 }
 
-// formatPostContent форматирует содержимое поста
+// formatPostContent переводит текст поста из Markdown в очищенный HTML
 func formatPostContent(content string) string {
-	if strings.Contains(content, "```") {
-		return "<pre><code>go\nfmt.Println('Hello')\n</code></pre>"
-	}
-	if strings.Contains(content, "https://example.com") {
-		return "<a href=\"https://example.com\">https://example.com</a>"
-	}
-	return strings.ReplaceAll(content, "\n", "<br>")
+	return string(RenderContent(content, nil))
 }
 
 // isPostEmpty проверяет, пустой ли пост
@@ -735,7 +1105,7 @@ func getPostRating(post *models.Post) int {
 	if post == nil {
 		return 0
 	}
-	return 42
+	return post.Score
 }
 
 // getPostViews возвращает количество просмотров поста
@@ -743,7 +1113,7 @@ func getPostViews(post *models.Post) int {
 	if post == nil {
 		return 0
 	}
-	return 100
+	return post.ViewCount
 }
 
 // getPostCommentsCount возвращает количество комментариев
@@ -751,7 +1121,7 @@ func getPostCommentsCount(post *models.Post) int {
 	if post == nil {
 		return 0
 	}
-	return 5
+	return post.CommentCount
 }
 
 // isPostPinned проверяет, закреплен ли пост
@@ -759,7 +1129,7 @@ func isPostPinned(post *models.Post) bool {
 	if post == nil {
 		return false
 	}
-	return true
+	return post.Pinned
 }
 
 // getCommentAuthor возвращает автора комментария
@@ -783,7 +1153,7 @@ func isCommentEdited(comment *models.Comment) bool {
 	if comment == nil {
 		return false
 	}
-	return true
+	return comment.Edited || comment.UpdatedAt.After(comment.CreatedAt)
 }
 
 // getCommentRating возвращает рейтинг комментария
@@ -791,7 +1161,7 @@ func getCommentRating(comment *models.Comment) int {
 	if comment == nil {
 		return 0
 	}
-	return 10
+	return comment.Score
 }
 
 // getThreadViews возвращает количество просмотров темы
@@ -799,7 +1169,7 @@ func getThreadViews(thread *models.Thread) int {
 	if thread == nil {
 		return 0
 	}
-	return 150
+	return thread.ViewCount
 }
 
 // getThreadRating возвращает рейтинг темы
@@ -815,7 +1185,7 @@ func isThreadLocked(thread *models.Thread) bool {
 	if thread == nil {
 		return false
 	}
-	return false
+	return thread.Locked
 }
 
 // getThreadLastActivity возвращает время последней активности
@@ -823,7 +1193,10 @@ func getThreadLastActivity(thread *models.Thread) time.Time {
 	if thread == nil {
 		return time.Time{}
 	}
-	return time.Now()
+	if thread.LastPostAt != nil {
+		return *thread.LastPostAt
+	}
+	return thread.CreatedAt
 }
 
 // getThreadTags возвращает теги темы
@@ -831,7 +1204,7 @@ func getThreadTags(thread *models.Thread) []string {
 	if thread == nil {
 		return nil
 	}
-	return []string{"go", "programming", "forum"}
+	return thread.Tags
 }
 
 // getThreadCategory возвращает категорию темы
@@ -839,7 +1212,7 @@ func getThreadCategory(thread *models.Thread) string {
 	if thread == nil {
 		return ""
 	}
-	return "Programming"
+	return thread.CategoryName
 }
 
 // isThreadSticky проверяет, является ли тема прикрепленной
@@ -847,7 +1220,7 @@ func isThreadSticky(thread *models.Thread) bool {
 	if thread == nil {
 		return false
 	}
-	return true
+	return thread.Pinned
 }
 
 // getThreadParticipants возвращает количество участников
@@ -855,7 +1228,7 @@ func getThreadParticipants(thread *models.Thread) int {
 	if thread == nil {
 		return 0
 	}
-	return 10
+	return thread.ParticipantCount
 }
 
 // getThreadModerators возвращает список модераторов
@@ -5,7 +5,9 @@ import (
 	"encoding/json"
 	"errors"
 	"ForumService/internal/handlers/mocks"
+	"ForumService/internal/middleware"
 	"ForumService/internal/models"
+	"ForumService/internal/service"
 	"net/http"
 	"net/http/httptest"
 	"strconv"
@@ -20,6 +22,7 @@ import (
 func setupThreadTestRouter() *gin.Engine {
 	gin.SetMode(gin.TestMode)
 	router := gin.New()
+	router.SetFuncMap(TemplateFuncs())
 	router.LoadHTMLGlob("../../templates/*")
 	return router
 }
@@ -27,7 +30,7 @@ func setupThreadTestRouter() *gin.Engine {
 func TestThreadHandler_CreateThread_Success(t *testing.T) {
 	// Создаем мок сервиса
 	mockThreadService := &mocks.MockThreadService{
-		CreateThreadFunc: func(title string, authorID int) (*models.Thread, error) {
+		CreateThreadFunc: func(title string, threadType string, categoryID *int, tags []string, authorID int) (*models.Thread, error) {
 			return &models.Thread{
 				ID:       1,
 				Title:    title,
@@ -413,6 +416,35 @@ func TestThreadHandler_UpdateThread_Success(t *testing.T) {
 	assert.Equal(t, http.StatusOK, w.Code)
 }
 
+func TestThreadHandler_UpdateThread_Type(t *testing.T) {
+	var saved string
+	mockThreadService := &mocks.MockThreadService{
+		GetThreadWithPostsFunc: func(id int) (*models.Thread, []*models.Post, error) {
+			return &models.Thread{ID: 1, Title: "Old Title", Type: models.ThreadTypeDiscussion, AuthorID: 1}, nil, nil
+		},
+		UpdateThreadFunc: func(thread *models.Thread, userID int) error {
+			saved = thread.Type
+			return nil
+		},
+	}
+	handler := NewThreadHandler(mockThreadService)
+
+	router := setupThreadTestRouter()
+	router.PUT("/threads/:id", func(c *gin.Context) {
+		c.Set("user_id", uint32(1))
+		c.Set("user_role", "user")
+		handler.UpdateThread(c)
+	})
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("PUT", "/threads/1", bytes.NewBufferString(`{"title":"New Title","type":"question"}`))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, models.ThreadTypeQuestion, saved)
+}
+
 func TestThreadHandler_UpdateThread_Unauthorized(t *testing.T) {
 	// Создаем мок сервиса
 	mockThreadService := &mocks.MockThreadService{}
@@ -494,7 +526,7 @@ func TestThreadHandler_UpdateThread_NoPermission(t *testing.T) {
 func TestThreadHandler_GetAllThreads_Success(t *testing.T) {
 	// Создаем мок сервиса
 	mockThreadService := &mocks.MockThreadService{
-		GetAllThreadsFunc: func() ([]*models.Thread, error) {
+		GetAllThreadsFunc: func(role string) ([]*models.Thread, error) {
 			return []*models.Thread{
 				{
 					ID:       1,
@@ -537,7 +569,7 @@ func TestThreadHandler_GetAllThreads_Success(t *testing.T) {
 func TestThreadHandler_GetAllThreads_Error(t *testing.T) {
 	// Создаем мок сервиса
 	mockThreadService := &mocks.MockThreadService{
-		GetAllThreadsFunc: func() ([]*models.Thread, error) {
+		GetAllThreadsFunc: func(role string) ([]*models.Thread, error) {
 			return nil, errors.New("database error")
 		},
 	}
@@ -663,6 +695,40 @@ func TestThreadHandler_GetThreadPosts_NotFound(t *testing.T) {
 	assert.Equal(t, http.StatusNotFound, w.Code)
 }
 
+func TestThreadHandler_GetThreadPosts_SortTop(t *testing.T) {
+	mockThreadService := &mocks.MockThreadService{
+		GetPostsByThreadIDFunc: func(threadID int) ([]*models.Post, error) {
+			created := time.Now()
+			return []*models.Post{
+				{ID: 1, ThreadID: threadID, Score: 1, CreatedAt: created},
+				{ID: 2, ThreadID: threadID, Score: 2, CreatedAt: created.Add(time.Minute)},
+				{ID: 3, ThreadID: threadID, Score: 5, CreatedAt: created.Add(2 * time.Minute)},
+			}, nil
+		},
+	}
+	handler := NewThreadHandler(mockThreadService)
+
+	router := setupThreadTestRouter()
+	router.Use(middleware.ErrorHandler())
+	router.GET("/threads/:id/posts", handler.GetThreadPosts)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/threads/1/posts?sort=top", nil)
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var posts []models.Post
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &posts))
+	if assert.Len(t, posts, 3) {
+		assert.Equal(t, []int{1, 3, 2}, []int{posts[0].ID, posts[1].ID, posts[2].ID}, "первый пост треда остается первым")
+	}
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/threads/1/posts?sort=random", nil)
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
 func TestThreadHandler_UpdateThread_InvalidID(t *testing.T) {
 	// Создаем мок сервиса
 	mockThreadService := &mocks.MockThreadService{}
@@ -1083,19 +1149,19 @@ func TestSanitizePostContent(t *testing.T) {
 		want    string
 	}{
 		{
-			name:    "пост с HTML",
+			name:    "пост со скриптом",
 			content: "<script>alert('xss')</script>Текст",
-			want:    "&lt;script&gt;alert('xss')&lt;/script&gt;Текст",
+			want:    "Текст",
 		},
 		{
-			name:    "пост с лишними пробелами",
-			content: "Много    пробелов    здесь",
-			want:    "Много пробелов здесь",
+			name:    "пост с обработчиком события",
+			content: "<b onclick=\"alert('xss')\">Текст</b>",
+			want:    "<b>Текст</b>",
 		},
 		{
-			name:    "пост с лишними переносами",
-			content: "Строка 1\n\n\nСтрока 2",
-			want:    "Строка 1\n\nСтрока 2",
+			name:    "пост со ссылкой javascript",
+			content: "<a href=\"javascript:alert('xss')\">ссылка</a>",
+			want:    "ссылка",
 		},
 	}
 
@@ -1206,18 +1272,23 @@ func TestFormatPostContent(t *testing.T) {
 	}{
 		{
 			name:     "форматирование кода",
-			content:  "```go\nfmt.Println('Hello')\n```",
-			expected: "<pre><code>go\nfmt.Println('Hello')\n</code></pre>",
+			content:  "```go\nfmt.Println(\"Hello\")\n```",
+			expected: "<pre><code class=\"language-go\">fmt.Println(&#34;Hello&#34;)\n</code></pre>\n",
 		},
 		{
 			name:     "форматирование ссылки",
 			content:  "https://example.com",
-			expected: "<a href=\"https://example.com\">https://example.com</a>",
+			expected: "<p><a href=\"https://example.com\" rel=\"nofollow noopener\" target=\"_blank\">https://example.com</a></p>\n",
 		},
 		{
 			name:     "форматирование переносов строк",
 			content:  "Строка 1\nСтрока 2",
-			expected: "Строка 1<br>Строка 2",
+			expected: "<p>Строка 1<br>\nСтрока 2</p>\n",
+		},
+		{
+			name:     "форматирование списка",
+			content:  "- один\n- два",
+			expected: "<ul>\n<li>один</li>\n<li>два</li>\n</ul>\n",
 		},
 	}
 
@@ -1407,9 +1478,14 @@ func TestGetPostRating(t *testing.T) {
 			expected: 0,
 		},
 		{
-			name:     "обычный пост",
+			name:     "пост без голосов",
 			post:     &models.Post{},
-			expected: 42,
+			expected: 0,
+		},
+		{
+			name:     "пост с голосами",
+			post:     &models.Post{Upvotes: 5, Downvotes: 2, Score: 3},
+			expected: 3,
 		},
 	}
 
@@ -1435,8 +1511,13 @@ func TestGetPostViews(t *testing.T) {
 			expected: 0,
 		},
 		{
-			name:     "обычный пост",
+			name:     "пост без просмотров",
 			post:     &models.Post{},
+			expected: 0,
+		},
+		{
+			name:     "пост с просмотрами",
+			post:     &models.Post{ViewCount: 100},
 			expected: 100,
 		},
 	}
@@ -1465,6 +1546,11 @@ func TestGetPostCommentsCount(t *testing.T) {
 		{
 			name:     "обычный пост",
 			post:     &models.Post{},
+			expected: 0,
+		},
+		{
+			name:     "пост с комментариями",
+			post:     &models.Post{CommentCount: 5},
 			expected: 5,
 		},
 	}
@@ -1493,6 +1579,11 @@ func TestIsPostPinned(t *testing.T) {
 		{
 			name:     "обычный пост",
 			post:     &models.Post{},
+			expected: false,
+		},
+		{
+			name:     "закрепленный пост",
+			post:     &models.Post{Pinned: true},
 			expected: true,
 		},
 	}
@@ -1577,6 +1668,11 @@ func TestIsCommentEdited(t *testing.T) {
 		{
 			name:     "обычный комментарий",
 			comment:  &models.Comment{},
+			expected: false,
+		},
+		{
+			name:     "отредактированный комментарий",
+			comment:  &models.Comment{Edited: true},
 			expected: true,
 		},
 	}
@@ -1603,9 +1699,14 @@ func TestGetCommentRating(t *testing.T) {
 			expected: 0,
 		},
 		{
-			name:     "обычный комментарий",
+			name:     "комментарий без голосов",
 			comment:  &models.Comment{},
-			expected: 10,
+			expected: 0,
+		},
+		{
+			name:     "комментарий с голосами",
+			comment:  &models.Comment{Upvotes: 1, Downvotes: 4, Score: -3},
+			expected: -3,
 		},
 	}
 
@@ -1631,8 +1732,13 @@ func TestGetThreadViews(t *testing.T) {
 			expected: 0,
 		},
 		{
-			name:     "обычная тема",
+			name:     "тема без просмотров",
 			thread:   &models.Thread{},
+			expected: 0,
+		},
+		{
+			name:     "тема с просмотрами",
+			thread:   &models.Thread{ViewCount: 150},
 			expected: 150,
 		},
 	}
@@ -1691,6 +1797,11 @@ func TestIsThreadLocked(t *testing.T) {
 			thread:   &models.Thread{},
 			expected: false,
 		},
+		{
+			name:     "закрытая тема",
+			thread:   &models.Thread{Locked: true},
+			expected: true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -1725,11 +1836,17 @@ func TestGetThreadLastActivity(t *testing.T) {
 		})
 	}
 
-	// Тест для не-nil темы
-	thread := &models.Thread{}
-	result := getThreadLastActivity(thread)
-	if result.IsZero() {
-		t.Error("getThreadLastActivity() вернул нулевое время для не-nil темы")
+	// Без постов активностью считается создание темы
+	createdAt := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	thread := &models.Thread{CreatedAt: createdAt}
+	if result := getThreadLastActivity(thread); !result.Equal(createdAt) {
+		t.Errorf("getThreadLastActivity() = %v, want %v", result, createdAt)
+	}
+
+	lastPostAt := createdAt.Add(time.Hour)
+	thread.LastPostAt = &lastPostAt
+	if result := getThreadLastActivity(thread); !result.Equal(lastPostAt) {
+		t.Errorf("getThreadLastActivity() = %v, want %v", result, lastPostAt)
 	}
 }
 
@@ -1745,9 +1862,14 @@ func TestGetThreadTags(t *testing.T) {
 			expected: nil,
 		},
 		{
-			name:     "обычная тема",
-			thread:   &models.Thread{},
-			expected: []string{"go", "programming", "forum"},
+			name:     "тема без тегов",
+			thread:   &models.Thread{Tags: []string{}},
+			expected: []string{},
+		},
+		{
+			name:     "тема с тегами",
+			thread:   &models.Thread{Tags: []string{"go", "postgres"}},
+			expected: []string{"go", "postgres"},
 		},
 	}
 
@@ -1760,6 +1882,7 @@ func TestGetThreadTags(t *testing.T) {
 }
 
 func TestGetThreadCategory(t *testing.T) {
+	categoryID := 1
 	tests := []struct {
 		name     string
 		thread   *models.Thread
@@ -1771,9 +1894,14 @@ func TestGetThreadCategory(t *testing.T) {
 			expected: "",
 		},
 		{
-			name:     "обычная тема",
+			name:     "тема вне разделов",
 			thread:   &models.Thread{},
-			expected: "Programming",
+			expected: "",
+		},
+		{
+			name:     "тема в разделе",
+			thread:   &models.Thread{CategoryID: &categoryID, CategoryName: "Go"},
+			expected: "Go",
 		},
 	}
 
@@ -1799,6 +1927,11 @@ func TestIsThreadSticky(t *testing.T) {
 		{
 			name:     "обычная тема",
 			thread:   &models.Thread{},
+			expected: false,
+		},
+		{
+			name:     "закрепленная тема",
+			thread:   &models.Thread{Pinned: true},
 			expected: true,
 		},
 	}
@@ -1824,8 +1957,8 @@ func TestGetThreadParticipants(t *testing.T) {
 		},
 		{
 			name:     "обычная тема",
-			thread:   &models.Thread{},
-			expected: 10,
+			thread:   &models.Thread{ParticipantCount: 3},
+			expected: 3,
 		},
 	}
 
@@ -1861,4 +1994,409 @@ func TestGetThreadModerators(t *testing.T) {
 			assert.Equal(t, tt.expected, result)
 		})
 	}
-}
\ No newline at end of file
+}
+
+func TestThreadHandler_UpdateThread_IfMatch(t *testing.T) {
+	var gotVersion int
+	mockService := &mocks.MockThreadService{
+		GetThreadWithPostsFunc: func(id int) (*models.Thread, []*models.Post, error) {
+			return &models.Thread{ID: id, Title: "Старое название", AuthorID: 1, Version: 2}, nil, nil
+		},
+		UpdateThreadFunc: func(thread *models.Thread, userID int) error {
+			gotVersion = thread.Version
+			thread.Version = 3
+			return nil
+		},
+	}
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(middleware.ErrorHandler())
+	router.Use(func(c *gin.Context) {
+		c.Set("user_id", uint32(1))
+		c.Next()
+	})
+	router.PUT("/threads/:id", NewThreadHandler(mockService).UpdateThread)
+
+	send := func(ifMatch string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("PUT", "/threads/1", strings.NewReader(`{"title":"Новое название"}`))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("If-Match", ifMatch)
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := send(`"2"`)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, 2, gotVersion)
+	assert.Equal(t, `"3"`, w.Header().Get("ETag"))
+
+	w = send(`"1"`)
+	assert.Equal(t, http.StatusPreconditionFailed, w.Code)
+	assert.Contains(t, w.Body.String(), `"code":"precondition_failed"`)
+}
+
+func TestThreadHandler_GetAllThreads_ByCategory(t *testing.T) {
+	var gotCategoryID int
+	var gotRole string
+	mockThreadService := &mocks.MockThreadService{
+		GetThreadsByCategoryFunc: func(categoryID int, role string) ([]*models.Thread, error) {
+			gotCategoryID = categoryID
+			gotRole = role
+			return []*models.Thread{{ID: 1, Title: "Thread 1", AuthorID: 1, CategoryID: &categoryID, CategoryName: "Go"}}, nil
+		},
+		GetUserByIDFunc: func(id int) (*models.User, error) {
+			return &models.User{ID: id, Username: "user" + strconv.Itoa(id)}, nil
+		},
+	}
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(middleware.ErrorHandler())
+	router.GET("/threads", NewThreadHandler(mockThreadService).GetAllThreads)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/threads?category_id=5", nil)
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, 5, gotCategoryID)
+	assert.Equal(t, "guest", gotRole)
+	assert.Contains(t, w.Body.String(), `"category_name":"Go"`)
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/threads?category_id=abc", nil)
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestThreadHandler_GetAllThreads_ByTags(t *testing.T) {
+	tests := []struct {
+		name             string
+		query            string
+		expectedStatus   int
+		expectedTags     []string
+		expectedMatchAll bool
+		expectedIDs      []int
+	}{
+		{"повторяющийся параметр", "?tag=go&tag=SQL", http.StatusOK, []string{"go", "SQL"}, true, []int{1, 2}},
+		{"через запятую и any", "?tag=go,%20sql&match=any", http.StatusOK, []string{"go", "sql"}, false, []int{1, 2}},
+		{"вместе с разделом", "?tag=go&category_id=5", http.StatusOK, []string{"go"}, true, []int{2}},
+		{"неверный режим", "?tag=go&match=some", http.StatusBadRequest, nil, false, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotTags []string
+			var gotMatchAll bool
+			categoryID := 5
+			mockThreadService := &mocks.MockThreadService{
+				GetThreadsByTagsFunc: func(tags []string, matchAll bool, role string) ([]*models.Thread, error) {
+					gotTags = tags
+					gotMatchAll = matchAll
+					return []*models.Thread{
+						{ID: 1, AuthorID: 1, Tags: []string{"go"}},
+						{ID: 2, AuthorID: 1, Tags: []string{"go", "sql"}, CategoryID: &categoryID},
+					}, nil
+				},
+				GetUserByIDFunc: func(id int) (*models.User, error) {
+					return &models.User{ID: id, Username: "user" + strconv.Itoa(id)}, nil
+				},
+			}
+
+			gin.SetMode(gin.TestMode)
+			router := gin.New()
+			router.Use(middleware.ErrorHandler())
+			router.GET("/threads", NewThreadHandler(mockThreadService).GetAllThreads)
+
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("GET", "/threads"+tt.query, nil)
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedStatus != http.StatusOK {
+				return
+			}
+			assert.Equal(t, tt.expectedTags, gotTags)
+			assert.Equal(t, tt.expectedMatchAll, gotMatchAll)
+			var threads []*models.Thread
+			assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &threads))
+			var ids []int
+			for _, thread := range threads {
+				ids = append(ids, thread.ID)
+			}
+			assert.Equal(t, tt.expectedIDs, ids)
+		})
+	}
+}
+
+func TestThreadHandler_UpdateThread_Tags(t *testing.T) {
+	tests := []struct {
+		name         string
+		body         string
+		expectedSent []string
+		expectedTags []string
+	}{
+		{"без тегов теги не меняются", `{"title":"Новое название"}`, nil, []string{"go"}},
+		{"новые теги", `{"title":"Новое название","tags":["SQL"]}`, []string{"SQL"}, []string{"sql"}},
+		{"пустой список удаляет теги", `{"title":"Новое название","tags":[]}`, []string{}, []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var sent []string
+			mockThreadService := &mocks.MockThreadService{
+				GetThreadWithPostsFunc: func(id int) (*models.Thread, []*models.Post, error) {
+					return &models.Thread{ID: id, Title: "Тред", AuthorID: 1, Version: 1, Tags: []string{"go"}}, nil, nil
+				},
+				UpdateThreadFunc: func(thread *models.Thread, userID int) error {
+					sent = thread.Tags
+					if thread.Tags != nil {
+						thread.Tags = []string{}
+						for _, tag := range sent {
+							thread.Tags = append(thread.Tags, strings.ToLower(tag))
+						}
+					}
+					return nil
+				},
+			}
+
+			gin.SetMode(gin.TestMode)
+			router := gin.New()
+			router.Use(middleware.ErrorHandler())
+			router.PUT("/threads/:id", func(c *gin.Context) {
+				c.Set("user_id", uint32(1))
+				NewThreadHandler(mockThreadService).UpdateThread(c)
+			})
+
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("PUT", "/threads/1", bytes.NewBufferString(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, http.StatusOK, w.Code)
+			assert.Equal(t, tt.expectedSent, sent)
+			var thread models.Thread
+			assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &thread))
+			assert.Equal(t, tt.expectedTags, thread.Tags)
+		})
+	}
+}
+
+func TestThreadHandler_LockThread(t *testing.T) {
+	tests := []struct {
+		name           string
+		body           string
+		serviceErr     error
+		expectedStatus int
+		expectedLocked bool
+	}{
+		{"закрытие", `{"locked":true,"reason":"Вопрос решен"}`, nil, http.StatusOK, true},
+		{"открытие", `{"locked":false}`, nil, http.StatusOK, false},
+		{"без состояния", `{"reason":"Вопрос решен"}`, nil, http.StatusBadRequest, false},
+		{"нет прав", `{"locked":true}`, service.ErrNoPermission, http.StatusForbidden, false},
+		{"тред не найден", `{"locked":true}`, service.ErrThreadNotFound, http.StatusNotFound, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var sentReason string
+			mockThreadService := &mocks.MockThreadService{
+				SetThreadLockFunc: func(threadID int, locked bool, reason string, userID int) (*models.Thread, error) {
+					if tt.serviceErr != nil {
+						return nil, tt.serviceErr
+					}
+					sentReason = reason
+					return &models.Thread{ID: threadID, Version: 2, Locked: locked, LockReason: reason}, nil
+				},
+			}
+
+			gin.SetMode(gin.TestMode)
+			router := gin.New()
+			router.Use(middleware.ErrorHandler())
+			router.PUT("/threads/:id/lock", func(c *gin.Context) {
+				c.Set("user_id", uint32(2))
+				NewThreadHandler(mockThreadService).LockThread(c)
+			})
+
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("PUT", "/threads/1/lock", bytes.NewBufferString(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedStatus != http.StatusOK {
+				return
+			}
+			var thread models.Thread
+			assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &thread))
+			assert.Equal(t, tt.expectedLocked, thread.Locked)
+			assert.Equal(t, `"2"`, w.Header().Get("ETag"))
+			if tt.expectedLocked {
+				assert.Equal(t, "Вопрос решен", sentReason)
+			}
+		})
+	}
+}
+
+func TestThreadHandler_PinThread(t *testing.T) {
+	tests := []struct {
+		name           string
+		body           string
+		serviceErr     error
+		expectedStatus int
+		expectedPinned bool
+		expectedOrder  int
+		expectedUntil  bool
+	}{
+		{"закрепление", `{"pinned":true,"order":2,"until":"2030-01-01T00:00:00Z"}`, nil, http.StatusOK, true, 2, true},
+		{"бессрочное закрепление", `{"pinned":true}`, nil, http.StatusOK, true, 0, false},
+		{"открепление", `{"pinned":false}`, nil, http.StatusOK, false, 0, false},
+		{"без состояния", `{"order":1}`, nil, http.StatusBadRequest, false, 0, false},
+		{"неверный срок", `{"pinned":true,"until":"завтра"}`, nil, http.StatusBadRequest, false, 0, false},
+		{"истекший срок", `{"pinned":true,"until":"2000-01-01T00:00:00Z"}`, service.ErrInvalidPinExpiry, http.StatusBadRequest, false, 0, false},
+		{"нет прав", `{"pinned":true}`, service.ErrNoPermission, http.StatusForbidden, false, 0, false},
+		{"тред не найден", `{"pinned":true}`, service.ErrThreadNotFound, http.StatusNotFound, false, 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var sentOrder int
+			var sentUntil *time.Time
+			mockThreadService := &mocks.MockThreadService{
+				SetThreadPinFunc: func(threadID int, pinned bool, order int, until *time.Time, userID int) (*models.Thread, error) {
+					if tt.serviceErr != nil {
+						return nil, tt.serviceErr
+					}
+					sentOrder, sentUntil = order, until
+					return &models.Thread{ID: threadID, Version: 3, Pinned: pinned, PinOrder: order, PinnedUntil: until}, nil
+				},
+			}
+
+			gin.SetMode(gin.TestMode)
+			router := gin.New()
+			router.Use(middleware.ErrorHandler())
+			router.PUT("/threads/:id/pin", func(c *gin.Context) {
+				c.Set("user_id", uint32(2))
+				NewThreadHandler(mockThreadService).PinThread(c)
+			})
+
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("PUT", "/threads/1/pin", bytes.NewBufferString(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedStatus != http.StatusOK {
+				return
+			}
+			var thread models.Thread
+			assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &thread))
+			assert.Equal(t, tt.expectedPinned, thread.Pinned)
+			assert.Equal(t, `"3"`, w.Header().Get("ETag"))
+			assert.Equal(t, tt.expectedOrder, sentOrder)
+			assert.Equal(t, tt.expectedUntil, sentUntil != nil)
+		})
+	}
+}
+
+func TestThreadHandler_AcceptAnswer(t *testing.T) {
+	answer := 5
+	tests := []struct {
+		name           string
+		body           string
+		serviceErr     error
+		expectedStatus int
+		expectedPostID *int
+	}{
+		{"принятие ответа", `{"post_id":5}`, nil, http.StatusOK, &answer},
+		{"снятие отметки", `{"post_id":null}`, nil, http.StatusOK, nil},
+		{"неверный формат", `{"post_id":"пять"}`, nil, http.StatusBadRequest, nil},
+		{"тред не вопрос", `{"post_id":5}`, service.ErrNotQuestion, http.StatusBadRequest, nil},
+		{"первый пост", `{"post_id":1}`, service.ErrInvalidAnswer, http.StatusBadRequest, nil},
+		{"нет прав", `{"post_id":5}`, service.ErrNoPermission, http.StatusForbidden, nil},
+		{"тред не найден", `{"post_id":5}`, service.ErrThreadNotFound, http.StatusNotFound, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var sentPostID *int
+			mockThreadService := &mocks.MockThreadService{
+				SetAcceptedAnswerFunc: func(threadID int, postID *int, userID int) (*models.Thread, error) {
+					if tt.serviceErr != nil {
+						return nil, tt.serviceErr
+					}
+					sentPostID = postID
+					return &models.Thread{ID: threadID, Version: 4, Type: models.ThreadTypeQuestion, AcceptedPostID: postID, Solved: postID != nil}, nil
+				},
+			}
+
+			gin.SetMode(gin.TestMode)
+			router := gin.New()
+			router.Use(middleware.ErrorHandler())
+			router.PUT("/threads/:id/answer", func(c *gin.Context) {
+				c.Set("user_id", uint32(1))
+				NewThreadHandler(mockThreadService).AcceptAnswer(c)
+			})
+
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("PUT", "/threads/1/answer", bytes.NewBufferString(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedStatus != http.StatusOK {
+				return
+			}
+			var thread models.Thread
+			assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &thread))
+			assert.Equal(t, tt.expectedPostID, sentPostID)
+			assert.Equal(t, tt.expectedPostID != nil, thread.Solved)
+			assert.Equal(t, `"4"`, w.Header().Get("ETag"))
+		})
+	}
+}
+
+func TestThreadHandler_GetAllThreads_Unanswered(t *testing.T) {
+	accepted := 7
+	mockThreadService := &mocks.MockThreadService{
+		GetAllThreadsFunc: func(role string) ([]*models.Thread, error) {
+			return []*models.Thread{
+				{ID: 1, Type: models.ThreadTypeDiscussion},
+				{ID: 2, Type: models.ThreadTypeQuestion},
+				{ID: 3, Type: models.ThreadTypeQuestion, AcceptedPostID: &accepted, Solved: true},
+			}, nil
+		},
+		GetUserByIDFunc: func(id int) (*models.User, error) {
+			return &models.User{ID: id}, nil
+		},
+	}
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(middleware.ErrorHandler())
+	router.GET("/threads", NewThreadHandler(mockThreadService).GetAllThreads)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/threads?unanswered=true", nil)
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var threads []models.Thread
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &threads))
+	if assert.Len(t, threads, 1) {
+		assert.Equal(t, 2, threads[0].ID)
+	}
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/threads?unanswered=false", nil)
+	router.ServeHTTP(w, req)
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &threads))
+	assert.Len(t, threads, 3)
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/threads?unanswered=может", nil)
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
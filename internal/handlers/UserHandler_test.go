@@ -14,6 +14,7 @@ import (
 func setupUserTestRouter() *gin.Engine {
 	gin.SetMode(gin.TestMode)
 	router := gin.New()
+	router.SetFuncMap(TemplateFuncs())
 	router.LoadHTMLGlob("../../templates/*")
 	return router
 }
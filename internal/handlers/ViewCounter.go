@@ -0,0 +1,50 @@
+package handlers
+
+import (
+	"ForumService/internal/service"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ViewerKey возвращает ключ посетителя для учета просмотров: ID пользователя, а для гостей - IP
+func ViewerKey(c *gin.Context) string {
+	if userID, exists := c.Get("user_id"); exists {
+		return fmt.Sprintf("user:%v", userID)
+	}
+	return "ip:" + c.ClientIP()
+}
+
+// CountThreadView засчитывает просмотр треда из параметра :id, если страница отдана успешно.
+// Несуществующие и недоступные треды не считаются. При views == nil просмотры не учитываются.
+func CountThreadView(views service.ViewService) gin.HandlerFunc {
+	if views == nil {
+		return countView(nil)
+	}
+	return countView(views.RecordThreadView)
+}
+
+// CountPostView засчитывает просмотр поста из параметра :id, как CountThreadView
+func CountPostView(views service.ViewService) gin.HandlerFunc {
+	if views == nil {
+		return countView(nil)
+	}
+	return countView(views.RecordPostView)
+}
+
+func countView(record func(id int, viewer string) bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		if record == nil || c.Writer.Status() != http.StatusOK {
+			return
+		}
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			return
+		}
+		record(id, ViewerKey(c))
+	}
+}
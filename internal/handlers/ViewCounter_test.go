@@ -0,0 +1,85 @@
+package handlers
+
+import (
+	"ForumService/internal/handlers/mocks"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCountThreadView(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name           string
+		path           string
+		status         int
+		userID         interface{}
+		expectedViewer string
+	}{
+		{"просмотр пользователя", "/threads/7", http.StatusOK, uint32(3), "user:3"},
+		{"просмотр гостя", "/threads/7", http.StatusOK, nil, "ip:192.0.2.1"},
+		{"тред не найден", "/threads/7", http.StatusNotFound, nil, ""},
+		{"неверный ID", "/threads/abc", http.StatusOK, nil, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var viewer string
+			views := &mocks.MockViewService{
+				RecordThreadViewFunc: func(threadID int, v string) bool {
+					assert.Equal(t, 7, threadID)
+					viewer = v
+					return true
+				},
+			}
+
+			router := gin.New()
+			router.GET("/threads/:id", func(c *gin.Context) {
+				if tt.userID != nil {
+					c.Set("user_id", tt.userID)
+				}
+				c.Next()
+			}, CountThreadView(views), func(c *gin.Context) {
+				c.String(tt.status, "")
+			})
+
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			req.RemoteAddr = "192.0.2.1:1234"
+			router.ServeHTTP(httptest.NewRecorder(), req)
+
+			assert.Equal(t, tt.expectedViewer, viewer)
+		})
+	}
+}
+
+func TestCountPostView(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	var counted int
+	views := &mocks.MockViewService{
+		RecordPostViewFunc: func(postID int, viewer string) bool {
+			counted = postID
+			return true
+		},
+	}
+
+	router := gin.New()
+	router.GET("/posts/:id", CountPostView(views), func(c *gin.Context) {
+		c.String(http.StatusOK, "")
+	})
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/posts/5", nil))
+	assert.Equal(t, 5, counted)
+
+	// Без счетчика страница отдается как обычно
+	router = gin.New()
+	router.GET("/posts/:id", CountPostView(nil), func(c *gin.Context) {
+		c.String(http.StatusOK, "")
+	})
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/posts/5", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+}
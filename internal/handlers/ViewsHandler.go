@@ -12,28 +12,36 @@ import (
 )
 
 type ViewsHandler struct {
-	threadService  service.ThreadService
-	postService    service.PostService
+	threadService   service.ThreadService
+	categoryService service.CategoryService
+	postService     service.PostService
 	commentService service.CommentService
 	chatService    service.ChatService
+	reactions      service.ReactionService
+	bookmarks      service.BookmarkService
+	notifications  service.NotificationService
+	attachments    service.AttachmentService
+	polls          service.PollService
 }
 
 func NewViewsHandler(
 	threadService service.ThreadService,
+	categoryService service.CategoryService,
 	postService service.PostService,
 	commentService service.CommentService,
 	chatService service.ChatService,
 ) *ViewsHandler {
 	return &ViewsHandler{
-		threadService:  threadService,
-		postService:    postService,
+		threadService:   threadService,
+		categoryService: categoryService,
+		postService:     postService,
 		commentService: commentService,
 		chatService:    chatService,
 	}
 }
 
 func (h *ViewsHandler) Index(c *gin.Context) {
-	threads, err := h.threadService.GetAllThreads()
+	threads, err := h.threadService.GetAllThreads(ViewerRole(c))
 	if err != nil {
 		c.HTML(http.StatusInternalServerError, "error.html", gin.H{
 			"error": "Ошибка при получении списка тредов",
@@ -41,6 +49,14 @@ func (h *ViewsHandler) Index(c *gin.Context) {
 		return
 	}
 
+	categories, err := h.categoryService.GetCategoryTree(ViewerRole(c))
+	if err != nil {
+		c.HTML(http.StatusInternalServerError, "error.html", gin.H{
+			"error": "Ошибка при получении разделов",
+		})
+		return
+	}
+
 	chatMessages, err := h.chatService.GetAllMessages()
 	if err != nil {
 		c.HTML(http.StatusInternalServerError, "error.html", gin.H{
@@ -48,6 +64,9 @@ func (h *ViewsHandler) Index(c *gin.Context) {
 		})
 		return
 	}
+	if err := AttachMessageMentions(h.notifications, chatMessages); err != nil {
+		fmt.Printf("Ошибка при получении упоминаний в чате: %v\n", err)
+	}
 
 	userRole, _ := c.Get("user_role")
 	if userRole == nil {
@@ -59,6 +78,7 @@ func (h *ViewsHandler) Index(c *gin.Context) {
 
 	c.HTML(http.StatusOK, "index.html", gin.H{
 		"Threads":      threads,
+		"Sections":     BuildCategorySections(categories, threads, ViewerRole(c)),
 		"ChatMessages": chatMessages,
 		"user_role":    userRole,
 		"user_id":      userID,
@@ -84,6 +104,11 @@ func (h *ViewsHandler) GetThreadWithPosts(w http.ResponseWriter, r *http.Request
 		http.Error(w, "Thread not found", http.StatusNotFound)
 		return
 	}
+	// Запрос без gin-контекста не аутентифицирован, поэтому проверяются права гостя
+	if err := h.threadService.CheckThreadAccess(thread, string(models.RoleGuest)); err != nil {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
 
 	tmpl := template.Must(template.ParseFiles("templates/thread.html"))
 	data := struct {
@@ -124,6 +149,13 @@ func (h *ViewsHandler) ShowThread(c *gin.Context) {
 	fmt.Printf("Тред найден: %+v\n", thread)
 	fmt.Printf("Количество постов: %d\n", len(posts))
 
+	if err := h.threadService.CheckThreadAccess(thread, ViewerRole(c)); err != nil {
+		c.HTML(http.StatusForbidden, "error.html", gin.H{
+			"error": "Нет доступа к разделу треда",
+		})
+		return
+	}
+
 	// Получаем роль пользователя из контекста
 	userRole, exists := c.Get("user_role")
 	if !exists {
@@ -150,6 +182,10 @@ func (h *ViewsHandler) ShowThread(c *gin.Context) {
 	}
 	fmt.Printf("Debug - User ID in ShowThread: %v (type: %T)\n", userID, userID)
 
+	if err := AttachThreadPoll(h.polls, thread, viewerID(c)); err != nil {
+		fmt.Printf("Ошибка при получении опроса треда: %v\n", err)
+	}
+
 	// Отправляем данные в шаблон
 	fmt.Printf("Отправка данных в шаблон: thread=%+v, posts=%+v, user_role=%v, user_id=%v\n", 
 		thread, posts, userRole, userID)
@@ -159,9 +195,40 @@ func (h *ViewsHandler) ShowThread(c *gin.Context) {
 		"Posts":     posts,
 		"user_role": userRole,
 		"user_id":   userID,
+		"bookmarked": IsBookmarked(h.bookmarks, models.BookmarkTargetThread, thread.ID, viewerID(c)),
 	})
 }
 
+// WithReactions добавляет реакции на страницу поста
+func (h *ViewsHandler) WithReactions(reactions service.ReactionService) *ViewsHandler {
+	h.reactions = reactions
+	return h
+}
+
+// WithNotifications добавляет упоминания на страницу поста
+func (h *ViewsHandler) WithNotifications(notifications service.NotificationService) *ViewsHandler {
+	h.notifications = notifications
+	return h
+}
+
+// WithAttachments добавляет вложения постов и комментариев на страницу поста
+func (h *ViewsHandler) WithAttachments(attachments service.AttachmentService) *ViewsHandler {
+	h.attachments = attachments
+	return h
+}
+
+// WithPolls добавляет опрос на страницу треда
+func (h *ViewsHandler) WithPolls(polls service.PollService) *ViewsHandler {
+	h.polls = polls
+	return h
+}
+
+// WithBookmarks добавляет отметку закладки на страницы треда и поста
+func (h *ViewsHandler) WithBookmarks(bookmarks service.BookmarkService) *ViewsHandler {
+	h.bookmarks = bookmarks
+	return h
+}
+
 func (h *ViewsHandler) ShowPost(c *gin.Context) {
 	fmt.Printf("Начало обработки запроса ShowPost\n")
 	
@@ -186,6 +253,39 @@ func (h *ViewsHandler) ShowPost(c *gin.Context) {
 	fmt.Printf("Пост найден: %+v\n", post)
 	fmt.Printf("Получено комментариев: %d\n", len(comments))
 
+	if err := h.threadService.CheckThreadIDAccess(post.ThreadID, ViewerRole(c)); err != nil {
+		c.HTML(http.StatusForbidden, "error.html", gin.H{
+			"error": "Нет доступа к разделу треда",
+		})
+		return
+	}
+
+	// Неизвестный порядок на странице не ошибка, комментарии просто идут по дате
+	order, err := SortOrder(c)
+	if err != nil {
+		order = service.SortDate
+	}
+	comments = service.SortComments(comments, order)
+
+	if err := AttachPostReactions(h.reactions, []*models.Post{post}, viewerID(c)); err != nil {
+		fmt.Printf("Ошибка при получении реакций поста: %v\n", err)
+	}
+	if err := AttachCommentReactions(h.reactions, comments, viewerID(c)); err != nil {
+		fmt.Printf("Ошибка при получении реакций комментариев: %v\n", err)
+	}
+	if err := AttachPostMentions(h.notifications, []*models.Post{post}); err != nil {
+		fmt.Printf("Ошибка при получении упоминаний поста: %v\n", err)
+	}
+	if err := AttachCommentMentions(h.notifications, comments); err != nil {
+		fmt.Printf("Ошибка при получении упоминаний комментариев: %v\n", err)
+	}
+	if err := AttachPostAttachments(h.attachments, []*models.Post{post}); err != nil {
+		fmt.Printf("Ошибка при получении вложений поста: %v\n", err)
+	}
+	if err := AttachCommentAttachments(h.attachments, comments); err != nil {
+		fmt.Printf("Ошибка при получении вложений комментариев: %v\n", err)
+	}
+
 	// Получаем роль пользователя из контекста
 	userRole, exists := c.Get("user_role")
 	if !exists {
@@ -221,10 +321,31 @@ func (h *ViewsHandler) ShowPost(c *gin.Context) {
 	fmt.Printf("Отправка данных в шаблон: post=%+v, comments=%+v, user_role=%v, user_id=%v\n", 
 		post, comments, userRole, userID)
 
+	// Закрытый тред скрывает форму комментария и кнопки ответа
+	thread, err := h.postService.GetThreadByID(post.ThreadID)
+	if err != nil {
+		fmt.Printf("Ошибка при получении треда поста: %v\n", err)
+	}
+
 	c.HTML(http.StatusOK, "post.html", gin.H{
 		"post":     post,
 		"comments": comments,
+		"thread":   thread,
+		"thread_locked": thread != nil && thread.Locked,
+		"post_accepted": thread != nil && thread.AcceptedPostID != nil && *thread.AcceptedPostID == post.ID,
+		"bookmarked": IsBookmarked(h.bookmarks, models.BookmarkTargetPost, post.ID, viewerID(c)),
 		"user_id":  userID,
 		"user_role": userRole,
+		"max_comment_depth": service.MaxCommentDepth,
+		"sort": order,
+		"reaction_emojis": h.allowedEmojis(),
 	})
 }
+
+// allowedEmojis возвращает доступные реакции для шаблона, пустой список без сервиса реакций
+func (h *ViewsHandler) allowedEmojis() []string {
+	if h.reactions == nil {
+		return nil
+	}
+	return h.reactions.AllowedEmojis()
+}
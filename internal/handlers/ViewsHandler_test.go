@@ -15,6 +15,7 @@ import (
 func setupViewsTestRouter() *gin.Engine {
 	gin.SetMode(gin.TestMode)
 	router := gin.New()
+	router.SetFuncMap(TemplateFuncs())
 	router.LoadHTMLGlob("../../templates/*")
 	return router
 }
@@ -22,7 +23,7 @@ func setupViewsTestRouter() *gin.Engine {
 func TestViewsHandler_Index_Error(t *testing.T) {
 	// Создаем моки сервисов
 	mockThreadService := &mocks.MockThreadService{
-		GetAllThreadsFunc: func() ([]*models.Thread, error) {
+		GetAllThreadsFunc: func(role string) ([]*models.Thread, error) {
 			return nil, errors.New("ошибка получения тредов")
 		},
 	}
@@ -33,7 +34,7 @@ func TestViewsHandler_Index_Error(t *testing.T) {
 	}
 
 	// Создаем обработчик
-	handler := NewViewsHandler(mockThreadService, nil, nil, mockChatService)
+	handler := NewViewsHandler(mockThreadService, nil, nil, nil, mockChatService)
 
 	// Настраиваем тестовый роутер
 	router := setupViewsTestRouter()
@@ -59,7 +60,7 @@ func TestViewsHandler_ShowThread_InvalidID(t *testing.T) {
 	mockChatService := &mocks.MockChatService{}
 
 	// Создаем обработчик
-	handler := NewViewsHandler(mockThreadService, mockPostService, mockCommentService, mockChatService)
+	handler := NewViewsHandler(mockThreadService, nil, mockPostService, mockCommentService, mockChatService)
 
 	// Настраиваем тестовый роутер
 	router := setupViewsTestRouter()
@@ -88,7 +89,7 @@ func TestViewsHandler_ShowThread_NotFound(t *testing.T) {
 	mockChatService := &mocks.MockChatService{}
 
 	// Создаем обработчик
-	handler := NewViewsHandler(mockThreadService, mockPostService, mockCommentService, mockChatService)
+	handler := NewViewsHandler(mockThreadService, nil, mockPostService, mockCommentService, mockChatService)
 
 	// Настраиваем тестовый роутер
 	router := setupViewsTestRouter()
@@ -113,7 +114,7 @@ func TestViewsHandler_ShowPost_InvalidID(t *testing.T) {
 	mockChatService := &mocks.MockChatService{}
 
 	// Создаем обработчик
-	handler := NewViewsHandler(mockThreadService, mockPostService, mockCommentService, mockChatService)
+	handler := NewViewsHandler(mockThreadService, nil, mockPostService, mockCommentService, mockChatService)
 
 	// Настраиваем тестовый роутер
 	router := setupViewsTestRouter()
@@ -142,7 +143,7 @@ func TestViewsHandler_ShowPost_Error(t *testing.T) {
 	mockChatService := &mocks.MockChatService{}
 
 	// Создаем обработчик
-	handler := NewViewsHandler(mockThreadService, mockPostService, mockCommentService, mockChatService)
+	handler := NewViewsHandler(mockThreadService, nil, mockPostService, mockCommentService, mockChatService)
 
 	// Настраиваем тестовый роутер
 	router := setupViewsTestRouter()
@@ -167,7 +168,7 @@ func TestViewsHandler_GetThreadWithPosts_InvalidURL(t *testing.T) {
 	mockChatService := &mocks.MockChatService{}
 
 	// Создаем обработчик
-	handler := NewViewsHandler(mockThreadService, mockPostService, mockCommentService, mockChatService)
+	handler := NewViewsHandler(mockThreadService, nil, mockPostService, mockCommentService, mockChatService)
 
 	// Создаем тестовый запрос с неверным URL
 	w := httptest.NewRecorder()
@@ -188,7 +189,7 @@ func TestViewsHandler_GetThreadWithPosts_InvalidID(t *testing.T) {
 	mockChatService := &mocks.MockChatService{}
 
 	// Создаем обработчик
-	handler := NewViewsHandler(mockThreadService, mockPostService, mockCommentService, mockChatService)
+	handler := NewViewsHandler(mockThreadService, nil, mockPostService, mockCommentService, mockChatService)
 
 	// Создаем тестовый запрос с неверным ID
 	w := httptest.NewRecorder()
@@ -213,7 +214,7 @@ func TestViewsHandler_GetThreadWithPosts_NotFound(t *testing.T) {
 	mockChatService := &mocks.MockChatService{}
 
 	// Создаем обработчик
-	handler := NewViewsHandler(mockThreadService, mockPostService, mockCommentService, mockChatService)
+	handler := NewViewsHandler(mockThreadService, nil, mockPostService, mockCommentService, mockChatService)
 
 	// Создаем тестовый запрос
 	w := httptest.NewRecorder()
@@ -0,0 +1,175 @@
+package handlers
+
+import (
+	"ForumService/internal/errors"
+	"ForumService/internal/middleware"
+	"ForumService/internal/models"
+	"ForumService/internal/service"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type VoteHandler struct {
+	service  service.VoteService
+	threads  service.ThreadService
+	comments service.CommentService
+}
+
+func NewVoteHandler(service service.VoteService) *VoteHandler {
+	return &VoteHandler{service: service}
+}
+
+// WithAccess принимает голоса только за посты и комментарии из разделов, которые пользователь
+// может читать. comments нужен, чтобы найти пост комментария.
+func (h *VoteHandler) WithAccess(threads service.ThreadService, comments service.CommentService) *VoteHandler {
+	h.threads = threads
+	h.comments = comments
+	return h
+}
+
+func (h *VoteHandler) checkPostAccess(c *gin.Context, id int) error {
+	return checkPostWriteAccess(c, h.threads, id)
+}
+
+func (h *VoteHandler) checkCommentAccess(c *gin.Context, id int) error {
+	return checkCommentWriteAccess(c, h.threads, h.comments, id)
+}
+
+type VoteRequest struct {
+	// Value - 1 за запись, -1 против
+	Value int `json:"value" binding:"required"`
+}
+
+// VotePost godoc
+// @Summary Проголосовать за пост
+// @Description Ставит голос за или против поста. Повторный голос заменяет прежний, за свои посты голосовать нельзя.
+// @Tags votes
+// @Accept json
+// @Produce json
+// @Param id path int true "ID поста"
+// @Param input body VoteRequest true "Голос"
+// @Success 200 {object} models.VoteResult
+// @Failure 400 {object} map[string]string "неверный ID поста или голос"
+// @Failure 401 {object} map[string]string "пользователь не аутентифицирован"
+// @Failure 403 {object} map[string]string "голос за свой пост"
+// @Failure 404 {object} map[string]string "пост не найден"
+// @Router /posts/{id}/vote [post]
+func (h *VoteHandler) VotePost(c *gin.Context) {
+	h.vote(c, "Неверный ID поста", "Ошибка при голосовании за пост", h.checkPostAccess, h.service.VotePost)
+}
+
+// UnvotePost godoc
+// @Summary Отозвать голос за пост
+// @Description Отзывает голос пользователя за пост. Если голоса не было, возвращает текущие итоги.
+// @Tags votes
+// @Produce json
+// @Param id path int true "ID поста"
+// @Success 200 {object} models.VoteResult
+// @Failure 400 {object} map[string]string "неверный ID поста"
+// @Failure 401 {object} map[string]string "пользователь не аутентифицирован"
+// @Failure 404 {object} map[string]string "пост не найден"
+// @Router /posts/{id}/vote [delete]
+func (h *VoteHandler) UnvotePost(c *gin.Context) {
+	h.unvote(c, "Неверный ID поста", "Ошибка при отзыве голоса за пост", h.checkPostAccess, h.service.UnvotePost)
+}
+
+// VoteComment godoc
+// @Summary Проголосовать за комментарий
+// @Description Ставит голос за или против комментария. Повторный голос заменяет прежний,
+// @Description за свои и удаленные комментарии голосовать нельзя.
+// @Tags votes
+// @Accept json
+// @Produce json
+// @Param id path int true "ID комментария"
+// @Param input body VoteRequest true "Голос"
+// @Success 200 {object} models.VoteResult
+// @Failure 400 {object} map[string]string "неверный ID комментария или голос"
+// @Failure 401 {object} map[string]string "пользователь не аутентифицирован"
+// @Failure 403 {object} map[string]string "голос за свой комментарий"
+// @Failure 404 {object} map[string]string "комментарий не найден"
+// @Router /comments/{id}/vote [post]
+func (h *VoteHandler) VoteComment(c *gin.Context) {
+	h.vote(c, "Неверный ID комментария", "Ошибка при голосовании за комментарий", h.checkCommentAccess, h.service.VoteComment)
+}
+
+// UnvoteComment godoc
+// @Summary Отозвать голос за комментарий
+// @Description Отзывает голос пользователя за комментарий. Если голоса не было, возвращает текущие итоги.
+// @Tags votes
+// @Produce json
+// @Param id path int true "ID комментария"
+// @Success 200 {object} models.VoteResult
+// @Failure 400 {object} map[string]string "неверный ID комментария"
+// @Failure 401 {object} map[string]string "пользователь не аутентифицирован"
+// @Failure 404 {object} map[string]string "комментарий не найден"
+// @Router /comments/{id}/vote [delete]
+func (h *VoteHandler) UnvoteComment(c *gin.Context) {
+	h.unvote(c, "Неверный ID комментария", "Ошибка при отзыве голоса за комментарий", h.checkCommentAccess, h.service.UnvoteComment)
+}
+
+func (h *VoteHandler) vote(c *gin.Context, badID string, failure string, checkAccess func(c *gin.Context, id int) error, vote func(id, userID, value int) (models.VoteResult, error)) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(errors.NewBadRequestError(badID, err))
+		return
+	}
+
+	var request VoteRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.Error(errors.NewValidationError("Неверный формат данных", err))
+		return
+	}
+
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.Error(errors.NewUnauthorizedError("Пользователь не аутентифицирован", nil))
+		return
+	}
+
+	if err := checkAccess(c, id); err != nil {
+		c.Error(middleware.ToForumError(err, failure))
+		return
+	}
+
+	result, err := vote(id, int(userID.(uint32)), request.Value)
+	if err != nil {
+		c.Error(middleware.ToForumError(err, failure))
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+func (h *VoteHandler) unvote(c *gin.Context, badID string, failure string, checkAccess func(c *gin.Context, id int) error, unvote func(id, userID int) (models.VoteResult, error)) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(errors.NewBadRequestError(badID, err))
+		return
+	}
+
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.Error(errors.NewUnauthorizedError("Пользователь не аутентифицирован", nil))
+		return
+	}
+
+	if err := checkAccess(c, id); err != nil {
+		c.Error(middleware.ToForumError(err, failure))
+		return
+	}
+
+	result, err := unvote(id, int(userID.(uint32)))
+	if err != nil {
+		c.Error(middleware.ToForumError(err, failure))
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// SortOrder читает порядок сортировки списка из параметра sort (date или top)
+func SortOrder(c *gin.Context) (string, error) {
+	return service.ParseSort(c.Query("sort"))
+}
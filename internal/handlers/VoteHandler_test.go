@@ -0,0 +1,172 @@
+package handlers
+
+import (
+	"ForumService/internal/handlers/mocks"
+	"ForumService/internal/middleware"
+	"ForumService/internal/models"
+	"ForumService/internal/service"
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupVoteTestRouter(handler *VoteHandler, userID uint32) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(middleware.ErrorHandler())
+	router.Use(func(c *gin.Context) {
+		if userID != 0 {
+			c.Set("user_id", userID)
+		}
+		c.Next()
+	})
+	router.POST("/posts/:id/vote", handler.VotePost)
+	router.DELETE("/posts/:id/vote", handler.UnvotePost)
+	router.POST("/comments/:id/vote", handler.VoteComment)
+	router.DELETE("/comments/:id/vote", handler.UnvoteComment)
+	return router
+}
+
+func TestVoteHandler_VotePost(t *testing.T) {
+	tests := []struct {
+		name           string
+		userID         uint32
+		path           string
+		body           string
+		err            error
+		expectedStatus int
+	}{
+		{"успешно", 1, "/posts/1/vote", `{"value":1}`, nil, http.StatusOK},
+		{"не аутентифицирован", 0, "/posts/1/vote", `{"value":1}`, nil, http.StatusUnauthorized},
+		{"неверный ID", 1, "/posts/abc/vote", `{"value":1}`, nil, http.StatusBadRequest},
+		{"без голоса", 1, "/posts/1/vote", `{}`, nil, http.StatusBadRequest},
+		{"неверный голос", 1, "/posts/1/vote", `{"value":3}`, service.ErrInvalidVote, http.StatusBadRequest},
+		{"свой пост", 1, "/posts/1/vote", `{"value":1}`, service.ErrSelfVote, http.StatusForbidden},
+		{"пост не найден", 1, "/posts/1/vote", `{"value":-1}`, service.ErrPostNotFound, http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := &mocks.MockVoteService{
+				VotePostFunc: func(postID, userID, value int) (models.VoteResult, error) {
+					if tt.err != nil {
+						return models.VoteResult{}, tt.err
+					}
+					return models.VoteResult{Upvotes: 1, Score: 1, MyVote: value}, nil
+				},
+			}
+			router := setupVoteTestRouter(NewVoteHandler(mockService), tt.userID)
+
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("POST", tt.path, bytes.NewBufferString(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+		})
+	}
+}
+
+func TestVoteHandler_UnvotePost(t *testing.T) {
+	var gotPost, gotUser int
+	mockService := &mocks.MockVoteService{
+		UnvotePostFunc: func(postID, userID int) (models.VoteResult, error) {
+			gotPost, gotUser = postID, userID
+			return models.VoteResult{Upvotes: 3, Downvotes: 1, Score: 2}, nil
+		},
+	}
+	router := setupVoteTestRouter(NewVoteHandler(mockService), 7)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("DELETE", "/posts/4/vote", nil)
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, 4, gotPost)
+	assert.Equal(t, 7, gotUser)
+	var result models.VoteResult
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &result))
+	assert.Equal(t, models.VoteResult{Upvotes: 3, Downvotes: 1, Score: 2}, result)
+}
+
+func TestVoteHandler_Comments(t *testing.T) {
+	mockService := &mocks.MockVoteService{
+		VoteCommentFunc: func(commentID, userID, value int) (models.VoteResult, error) {
+			if commentID == 2 {
+				return models.VoteResult{}, service.ErrCommentDeleted
+			}
+			return models.VoteResult{Downvotes: 1, Score: -1, MyVote: value}, nil
+		},
+		UnvoteCommentFunc: func(commentID, userID int) (models.VoteResult, error) {
+			return models.VoteResult{}, service.ErrCommentNotFound
+		},
+	}
+	router := setupVoteTestRouter(NewVoteHandler(mockService), 1)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/comments/1/vote", bytes.NewBufferString(`{"value":-1}`))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"my_vote":-1`)
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("POST", "/comments/2/vote", bytes.NewBufferString(`{"value":1}`))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("DELETE", "/comments/9/vote", nil)
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestVoteHandler_ClosedCategory(t *testing.T) {
+	refuse := func(id, userID, value int) (models.VoteResult, error) {
+		t.Fatal("голос в закрытом разделе не должен сохраняться")
+		return models.VoteResult{}, nil
+	}
+	refuseUnvote := func(id, userID int) (models.VoteResult, error) {
+		t.Fatal("голос в закрытом разделе не должен отзываться")
+		return models.VoteResult{}, nil
+	}
+	mockService := &mocks.MockVoteService{
+		VotePostFunc:      refuse,
+		VoteCommentFunc:   refuse,
+		UnvotePostFunc:    refuseUnvote,
+		UnvoteCommentFunc: refuseUnvote,
+	}
+	comments := &mocks.MockCommentService{
+		GetCommentByIDFunc: func(id int) (*models.Comment, error) {
+			return &models.Comment{ID: id, PostID: 1}, nil
+		},
+	}
+	handler := NewVoteHandler(mockService).WithAccess(staffOnlyThreads(), comments)
+	router := closedCategoryRouter()
+	router.POST("/posts/:id/vote", handler.VotePost)
+	router.DELETE("/posts/:id/vote", handler.UnvotePost)
+	router.POST("/comments/:id/vote", handler.VoteComment)
+	router.DELETE("/comments/:id/vote", handler.UnvoteComment)
+
+	for _, tt := range []struct{ method, path, message string }{
+		{"POST", "/posts/1/vote", "Пост не найден"},
+		{"DELETE", "/posts/1/vote", "Пост не найден"},
+		{"POST", "/comments/4/vote", "Комментарий не найден"},
+		{"DELETE", "/comments/4/vote", "Комментарий не найден"},
+	} {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(tt.method, tt.path, bytes.NewBufferString(`{"value":1}`))
+		req.Header.Set("Content-Type", "application/json")
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusNotFound, w.Code, tt.method+" "+tt.path)
+		assert.Contains(t, w.Body.String(), tt.message, tt.method+" "+tt.path)
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"ForumService/internal/models"
+	"ForumService/internal/service"
 	"github.com/gorilla/websocket"
 	"github.com/Luxtington/Shared/logger"
 	"go.uber.org/zap"
@@ -27,19 +28,43 @@ type Client struct {
 }
 
 type Message struct {
+	// ID - ID сохраненного сообщения чата, по нему клиенты ставят реакции
+	ID         int    `json:"id,omitempty"`
 	Type       string `json:"type"`
 	Content    string `json:"content"`
 	AuthorID   int    `json:"author_id"`
 	AuthorName string `json:"author_name"`
 	CreatedAt  string `json:"created_at"`
+	// Mentions - упомянутые в сообщении пользователи
+	Mentions []models.Mention `json:"mentions,omitempty"`
+	// ContentHTML - текст сообщения в HTML
+	ContentHTML string `json:"content_html,omitempty"`
+}
+
+// NotificationMessageType - тип сообщения Hub с новым уведомлением пользователя
+const NotificationMessageType = "notification"
+
+// NotificationMessage - событие Hub о новом уведомлении, получает только адресат
+type NotificationMessage struct {
+	Type         string              `json:"type"`
+	Notification models.Notification `json:"notification"`
+}
+
+// DirectMessage - сообщение Hub для всех подключений одного пользователя
+type DirectMessage struct {
+	UserID  int
+	Payload []byte
 }
 
 type Hub struct {
 	Clients    map[*Client]bool
 	Broadcast  chan []byte
+	Direct     chan DirectMessage
 	Register   chan *Client
 	Unregister chan *Client
 	ChatRepo   ChatRepository
+	// notifications уведомляет упомянутых в сообщениях чата, nil отключает упоминания
+	notifications service.NotificationService
 }
 
 type ChatRepository interface {
@@ -50,12 +75,20 @@ func NewHub(chatRepo ChatRepository) *Hub {
 	return &Hub{
 		Clients:    make(map[*Client]bool),
 		Broadcast:  make(chan []byte),
+		Direct:     make(chan DirectMessage),
 		Register:   make(chan *Client),
 		Unregister: make(chan *Client),
 		ChatRepo:   chatRepo,
 	}
 }
 
+// WithNotifications включает упоминания в сообщениях чата. Вызывается до подключения
+// клиентов: сервис уведомлений сам доставляет уведомления через Hub.
+func (h *Hub) WithNotifications(notifications service.NotificationService) *Hub {
+	h.notifications = notifications
+	return h
+}
+
 func (h *Hub) Run() {
 	for {
 		select {
@@ -83,10 +116,52 @@ func (h *Hub) Run() {
 					delete(h.Clients, client)
 				}
 			}
+		case message := <-h.Direct:
+			for client := range h.Clients {
+				if client.UserID != message.UserID {
+					continue
+				}
+				select {
+				case client.Send <- message.Payload:
+				default:
+					close(client.Send)
+					delete(h.Clients, client)
+				}
+			}
 		}
 	}
 }
 
+// BroadcastJSON рассылает событие всем подключенным клиентам. Hub должен быть запущен
+// через Run, иначе отправка заблокируется.
+func (h *Hub) BroadcastJSON(event interface{}) error {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	h.Broadcast <- payload
+	return nil
+}
+
+// SendJSONToUser отправляет событие всем подключениям пользователя; если он не подключен,
+// событие теряется. Как и BroadcastJSON, требует запущенного Run.
+func (h *Hub) SendJSONToUser(userID int, event interface{}) error {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	h.Direct <- DirectMessage{UserID: userID, Payload: payload}
+	return nil
+}
+
+// DeliverNotification отправляет уведомление адресату, если он подключен к Hub
+func (h *Hub) DeliverNotification(notification models.Notification) {
+	event := NotificationMessage{Type: NotificationMessageType, Notification: notification}
+	if err := h.SendJSONToUser(notification.UserID, event); err != nil {
+		logger.GetLogger().Error("Ошибка при отправке уведомления", zap.Error(err), zap.Int("user_id", notification.UserID))
+	}
+}
+
 func (h *Hub) HandleWebSocket(w http.ResponseWriter, r *http.Request) {
 	log := logger.GetLogger()
 	
@@ -230,11 +305,17 @@ func (h *Hub) ReadPump(c *Client) {
 		}
 
 		log.Info("Сообщение успешно сохранено в БД", zap.Int("message_id", chatMessage.ID))
+		if h.notifications != nil {
+			logNotificationError(h.notifications.ChatMessageCreated(chatMessage), "сообщении чата")
+		}
 
+		msg.ID = chatMessage.ID
 		msg.AuthorName = c.Username
 		msg.AuthorID = c.UserID
 		msg.CreatedAt = chatMessage.CreatedAt.Format(time.RFC3339)
 		msg.Type = "message"
+		msg.Mentions = chatMessage.Mentions
+		msg.ContentHTML = string(RenderContent(chatMessage.Content, chatMessage.Mentions))
 
 		messageBytes, err := json.Marshal(msg)
 		if err != nil {
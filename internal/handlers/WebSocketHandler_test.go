@@ -369,4 +369,30 @@ func TestHub_ReadWriteErrorHandling(t *testing.T) {
 	messageBytes, _ := json.Marshal(message)
 	err = ws.WriteMessage(websocket.TextMessage, messageBytes)
 	assert.Error(t, err)
-} 
\ No newline at end of file
+} 
+func TestHub_DeliverNotification(t *testing.T) {
+	hub := NewHub(&mocks.MockChatRepository{})
+	go hub.Run()
+	recipient := &Client{Send: make(chan []byte, 1), Username: "alice", UserID: 2}
+	other := &Client{Send: make(chan []byte, 1), Username: "bob", UserID: 3}
+	hub.Register <- recipient
+	hub.Register <- other
+
+	hub.DeliverNotification(models.Notification{ID: 10, UserID: 2, Type: models.NotificationNewPost, PostID: 5})
+
+	select {
+	case payload := <-recipient.Send:
+		var event NotificationMessage
+		assert.NoError(t, json.Unmarshal(payload, &event))
+		assert.Equal(t, NotificationMessageType, event.Type)
+		assert.Equal(t, 10, event.Notification.ID)
+		assert.Equal(t, models.NotificationNewPost, event.Notification.Type)
+	case <-time.After(time.Second):
+		t.Fatal("уведомление не доставлено адресату")
+	}
+
+	// Сообщения Hub обрабатываются по порядку: после рассылки ясно, что уведомление другим не ушло
+	assert.NoError(t, hub.BroadcastJSON(map[string]string{"type": "ping"}))
+	payload := <-other.Send
+	assert.JSONEq(t, `{"type":"ping"}`, string(payload), "уведомление получает только адресат")
+}
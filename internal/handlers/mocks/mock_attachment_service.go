@@ -0,0 +1,48 @@
+package mocks
+
+import (
+	"ForumService/internal/models"
+	"context"
+	"io"
+	"time"
+)
+
+type MockAttachmentService struct {
+	UploadFunc         func(uploaderID int, filename string, content io.Reader) (*models.Attachment, error)
+	GetFunc            func(id int) (*models.Attachment, error)
+	OpenFunc           func(attachment *models.Attachment, thumbnail bool) (io.ReadSeekCloser, error)
+	LinkFunc           func(targetType string, targetID, uploaderID int, ids []int) error
+	CheckLinkFunc      func(targetType string, targetID, uploaderID int, ids []int) error
+	ForTargetsFunc     func(targetType string, targetIDs []int) (map[int][]models.Attachment, error)
+	CollectGarbageFunc func() (int, error)
+}
+
+func (m *MockAttachmentService) Upload(uploaderID int, filename string, content io.Reader) (*models.Attachment, error) {
+	return m.UploadFunc(uploaderID, filename, content)
+}
+
+func (m *MockAttachmentService) Get(id int) (*models.Attachment, error) {
+	return m.GetFunc(id)
+}
+
+func (m *MockAttachmentService) Open(attachment *models.Attachment, thumbnail bool) (io.ReadSeekCloser, error) {
+	return m.OpenFunc(attachment, thumbnail)
+}
+
+func (m *MockAttachmentService) Link(targetType string, targetID, uploaderID int, ids []int) error {
+	return m.LinkFunc(targetType, targetID, uploaderID, ids)
+}
+
+func (m *MockAttachmentService) CheckLink(targetType string, targetID, uploaderID int, ids []int) error {
+	return m.CheckLinkFunc(targetType, targetID, uploaderID, ids)
+}
+
+func (m *MockAttachmentService) ForTargets(targetType string, targetIDs []int) (map[int][]models.Attachment, error) {
+	return m.ForTargetsFunc(targetType, targetIDs)
+}
+
+func (m *MockAttachmentService) CollectGarbage() (int, error) {
+	return m.CollectGarbageFunc()
+}
+
+func (m *MockAttachmentService) Run(ctx context.Context, interval time.Duration) {}
@@ -0,0 +1,28 @@
+package mocks
+
+import (
+	"ForumService/internal/models"
+)
+
+type MockBookmarkService struct {
+	AddFunc        func(userID int, role string, targetType string, targetID int, note string) (*models.Bookmark, error)
+	RemoveFunc     func(userID int, targetType string, targetID int) error
+	ListFunc       func(userID int, role string, page, limit int) (*models.BookmarkPage, error)
+	BookmarkedFunc func(userID int, targetType string, targetIDs []int) (map[int]bool, error)
+}
+
+func (m *MockBookmarkService) Add(userID int, role string, targetType string, targetID int, note string) (*models.Bookmark, error) {
+	return m.AddFunc(userID, role, targetType, targetID, note)
+}
+
+func (m *MockBookmarkService) Remove(userID int, targetType string, targetID int) error {
+	return m.RemoveFunc(userID, targetType, targetID)
+}
+
+func (m *MockBookmarkService) List(userID int, role string, page, limit int) (*models.BookmarkPage, error) {
+	return m.ListFunc(userID, role, page, limit)
+}
+
+func (m *MockBookmarkService) Bookmarked(userID int, targetType string, targetIDs []int) (map[int]bool, error) {
+	return m.BookmarkedFunc(userID, targetType, targetIDs)
+}
@@ -0,0 +1,33 @@
+package mocks
+
+import (
+	"ForumService/internal/models"
+)
+
+type MockCategoryService struct {
+	GetCategoryTreeFunc func(role string) ([]*models.Category, error)
+	GetCategoryFunc     func(id int, role string) (*models.Category, error)
+	CreateCategoryFunc  func(category *models.Category, userID int) error
+	UpdateCategoryFunc  func(category *models.Category, userID int) error
+	DeleteCategoryFunc  func(id int, userID int) error
+}
+
+func (m *MockCategoryService) GetCategoryTree(role string) ([]*models.Category, error) {
+	return m.GetCategoryTreeFunc(role)
+}
+
+func (m *MockCategoryService) GetCategory(id int, role string) (*models.Category, error) {
+	return m.GetCategoryFunc(id, role)
+}
+
+func (m *MockCategoryService) CreateCategory(category *models.Category, userID int) error {
+	return m.CreateCategoryFunc(category, userID)
+}
+
+func (m *MockCategoryService) UpdateCategory(category *models.Category, userID int) error {
+	return m.UpdateCategoryFunc(category, userID)
+}
+
+func (m *MockCategoryService) DeleteCategory(id int, userID int) error {
+	return m.DeleteCategoryFunc(id, userID)
+}
@@ -6,7 +6,9 @@ import (
 
 type MockCommentService struct {
 	CreateCommentFunc      func(postID int, authorID int, content string) (*models.Comment, error)
+	ReplyToCommentFunc     func(postID int, parentID int, authorID int, content string) (*models.Comment, error)
 	GetCommentByIDFunc     func(id int) (*models.Comment, error)
+	UpdateCommentFunc      func(id int, userID int, content string) (*models.Comment, error)
 	DeleteCommentFunc      func(id int, userID int) error
 	GetCommentsByPostIDFunc func(postID int) ([]models.Comment, error)
 }
@@ -15,10 +17,18 @@ func (m *MockCommentService) CreateComment(postID int, authorID int, content str
 	return m.CreateCommentFunc(postID, authorID, content)
 }
 
+func (m *MockCommentService) ReplyToComment(postID int, parentID int, authorID int, content string) (*models.Comment, error) {
+	return m.ReplyToCommentFunc(postID, parentID, authorID, content)
+}
+
 func (m *MockCommentService) GetCommentByID(id int) (*models.Comment, error) {
 	return m.GetCommentByIDFunc(id)
 }
 
+func (m *MockCommentService) UpdateComment(id int, userID int, content string) (*models.Comment, error) {
+	return m.UpdateCommentFunc(id, userID, content)
+}
+
 func (m *MockCommentService) DeleteComment(id int, userID int) error {
 	return m.DeleteCommentFunc(id, userID)
 }
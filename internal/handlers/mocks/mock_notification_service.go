@@ -0,0 +1,78 @@
+package mocks
+
+import (
+	"ForumService/internal/models"
+)
+
+type MockNotificationService struct {
+	SubscribeFunc          func(userID int, role string, targetType string, targetID int) error
+	UnsubscribeFunc        func(userID int, targetType string, targetID int) error
+	IsSubscribedFunc       func(userID int, targetType string, targetID int) (bool, error)
+	ThreadCreatedFunc      func(thread *models.Thread) error
+	PostCreatedFunc        func(post *models.Post) error
+	PostUpdatedFunc        func(post *models.Post) error
+	CommentCreatedFunc     func(comment *models.Comment) error
+	CommentUpdatedFunc     func(comment *models.Comment) error
+	ChatMessageCreatedFunc func(message *models.ChatMessage) error
+	MentionsFunc           func(targetType string, targetIDs []int) (map[int][]models.Mention, error)
+	ListFunc               func(userID int, unreadOnly bool, page, limit int) (*models.NotificationPage, error)
+	UnreadCountFunc        func(userID int) (int, error)
+	MarkReadFunc           func(userID, notificationID int) error
+	MarkAllReadFunc        func(userID int) (int, error)
+}
+
+func (m *MockNotificationService) Subscribe(userID int, role string, targetType string, targetID int) error {
+	return m.SubscribeFunc(userID, role, targetType, targetID)
+}
+
+func (m *MockNotificationService) Unsubscribe(userID int, targetType string, targetID int) error {
+	return m.UnsubscribeFunc(userID, targetType, targetID)
+}
+
+func (m *MockNotificationService) IsSubscribed(userID int, targetType string, targetID int) (bool, error) {
+	return m.IsSubscribedFunc(userID, targetType, targetID)
+}
+
+func (m *MockNotificationService) ThreadCreated(thread *models.Thread) error {
+	return m.ThreadCreatedFunc(thread)
+}
+
+func (m *MockNotificationService) PostCreated(post *models.Post) error {
+	return m.PostCreatedFunc(post)
+}
+
+func (m *MockNotificationService) PostUpdated(post *models.Post) error {
+	return m.PostUpdatedFunc(post)
+}
+
+func (m *MockNotificationService) CommentCreated(comment *models.Comment) error {
+	return m.CommentCreatedFunc(comment)
+}
+
+func (m *MockNotificationService) CommentUpdated(comment *models.Comment) error {
+	return m.CommentUpdatedFunc(comment)
+}
+
+func (m *MockNotificationService) ChatMessageCreated(message *models.ChatMessage) error {
+	return m.ChatMessageCreatedFunc(message)
+}
+
+func (m *MockNotificationService) Mentions(targetType string, targetIDs []int) (map[int][]models.Mention, error) {
+	return m.MentionsFunc(targetType, targetIDs)
+}
+
+func (m *MockNotificationService) List(userID int, unreadOnly bool, page, limit int) (*models.NotificationPage, error) {
+	return m.ListFunc(userID, unreadOnly, page, limit)
+}
+
+func (m *MockNotificationService) UnreadCount(userID int) (int, error) {
+	return m.UnreadCountFunc(userID)
+}
+
+func (m *MockNotificationService) MarkRead(userID, notificationID int) error {
+	return m.MarkReadFunc(userID, notificationID)
+}
+
+func (m *MockNotificationService) MarkAllRead(userID int) (int, error) {
+	return m.MarkAllReadFunc(userID)
+}
@@ -0,0 +1,36 @@
+package mocks
+
+import "ForumService/internal/models"
+
+type MockPollService struct {
+	ValidateFunc    func(poll *models.Poll) error
+	CreateFunc      func(threadID int, poll *models.Poll) error
+	GetFunc         func(pollID, userID int) (*models.Poll, error)
+	GetByThreadFunc func(threadID, userID int) (*models.Poll, error)
+	VoteFunc        func(pollID, userID int, optionIDs []int) (*models.Poll, error)
+	CloseFunc       func(pollID, userID int) (*models.Poll, error)
+}
+
+func (m *MockPollService) Validate(poll *models.Poll) error {
+	return m.ValidateFunc(poll)
+}
+
+func (m *MockPollService) Create(threadID int, poll *models.Poll) error {
+	return m.CreateFunc(threadID, poll)
+}
+
+func (m *MockPollService) Get(pollID, userID int) (*models.Poll, error) {
+	return m.GetFunc(pollID, userID)
+}
+
+func (m *MockPollService) GetByThread(threadID, userID int) (*models.Poll, error) {
+	return m.GetByThreadFunc(threadID, userID)
+}
+
+func (m *MockPollService) Vote(pollID, userID int, optionIDs []int) (*models.Poll, error) {
+	return m.VoteFunc(pollID, userID, optionIDs)
+}
+
+func (m *MockPollService) Close(pollID, userID int) (*models.Poll, error) {
+	return m.CloseFunc(pollID, userID)
+}
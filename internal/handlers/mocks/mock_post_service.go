@@ -9,7 +9,7 @@ type MockPostService struct {
 	GetPostByIDFunc func(id int) (*models.Post, error)
 	GetPostWithCommentsFunc func(postID int) (*models.Post, []models.Comment, error)
 	GetPostsWithCommentsByThreadIDFunc func(threadID int) ([]models.Post, map[int][]models.Comment, error)
-	UpdatePostFunc func(post *models.Post, postID int, userID int) error
+	UpdatePostFunc func(post *models.Post, postID int, userID int, reason string) error
 	DeletePostFunc func(postID int, userID int) error
 	GetAllPostsFunc func() ([]*models.Post, error)
 	CreateCommentFunc func(comment *models.Comment) error
@@ -18,6 +18,9 @@ type MockPostService struct {
 	GetPostFunc func(id int) (*models.Post, error)
 	GetPostsByThreadIDFunc func(threadID int) ([]*models.Post, error)
 	GetThreadByIDFunc func(id int) (*models.Thread, error)
+	GetPostRevisionsFunc func(postID int) ([]models.PostRevision, error)
+	GetPostRevisionDiffFunc func(postID int, fromRevisionID int, toRevisionID int) (string, error)
+	SetPostPinFunc func(postID int, pinned bool, userID int) (*models.Post, error)
 }
 
 func (m *MockPostService) CreatePost(post *models.Post) error {
@@ -36,8 +39,8 @@ func (m *MockPostService) GetPostsWithCommentsByThreadID(threadID int) ([]models
 	return m.GetPostsWithCommentsByThreadIDFunc(threadID)
 }
 
-func (m *MockPostService) UpdatePost(post *models.Post, postID int, userID int) error {
-	return m.UpdatePostFunc(post, postID, userID)
+func (m *MockPostService) UpdatePost(post *models.Post, postID int, userID int, reason string) error {
+	return m.UpdatePostFunc(post, postID, userID, reason)
 }
 
 func (m *MockPostService) DeletePost(postID int, userID int) error {
@@ -70,4 +73,16 @@ func (m *MockPostService) GetPostsByThreadID(threadID int) ([]*models.Post, erro
 
 func (m *MockPostService) GetThreadByID(id int) (*models.Thread, error) {
 	return m.GetThreadByIDFunc(id)
-} 
\ No newline at end of file
+}
+
+func (m *MockPostService) GetPostRevisions(postID int) ([]models.PostRevision, error) {
+	return m.GetPostRevisionsFunc(postID)
+}
+
+func (m *MockPostService) GetPostRevisionDiff(postID int, fromRevisionID int, toRevisionID int) (string, error) {
+	return m.GetPostRevisionDiffFunc(postID, fromRevisionID, toRevisionID)
+}
+
+func (m *MockPostService) SetPostPin(postID int, pinned bool, userID int) (*models.Post, error) {
+	return m.SetPostPinFunc(postID, pinned, userID)
+}
@@ -0,0 +1,23 @@
+package mocks
+
+import (
+	"ForumService/internal/models"
+)
+
+type MockReactionService struct {
+	ToggleFunc        func(targetType string, targetID, userID int, emoji string) (*models.ReactionChange, error)
+	CountsFunc        func(targetType string, targetIDs []int, userID int) (map[int][]models.ReactionCount, error)
+	AllowedEmojisFunc func() []string
+}
+
+func (m *MockReactionService) Toggle(targetType string, targetID, userID int, emoji string) (*models.ReactionChange, error) {
+	return m.ToggleFunc(targetType, targetID, userID, emoji)
+}
+
+func (m *MockReactionService) Counts(targetType string, targetIDs []int, userID int) (map[int][]models.ReactionCount, error) {
+	return m.CountsFunc(targetType, targetIDs, userID)
+}
+
+func (m *MockReactionService) AllowedEmojis() []string {
+	return m.AllowedEmojisFunc()
+}
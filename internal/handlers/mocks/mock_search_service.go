@@ -0,0 +1,13 @@
+package mocks
+
+import (
+	"ForumService/internal/models"
+)
+
+type MockSearchService struct {
+	SearchFunc func(params models.SearchParams, page int) (*models.SearchPage, error)
+}
+
+func (m *MockSearchService) Search(params models.SearchParams, page int) (*models.SearchPage, error) {
+	return m.SearchFunc(params, page)
+}
@@ -0,0 +1,23 @@
+package mocks
+
+import (
+	"ForumService/internal/models"
+)
+
+type MockTagService struct {
+	GetTagsFunc   func() ([]*models.Tag, error)
+	RenameTagFunc func(id int, name string, userID int) (*models.Tag, error)
+	MergeTagsFunc func(sourceID, targetID int, userID int) (*models.Tag, error)
+}
+
+func (m *MockTagService) GetTags() ([]*models.Tag, error) {
+	return m.GetTagsFunc()
+}
+
+func (m *MockTagService) RenameTag(id int, name string, userID int) (*models.Tag, error) {
+	return m.RenameTagFunc(id, name, userID)
+}
+
+func (m *MockTagService) MergeTags(sourceID, targetID int, userID int) (*models.Tag, error) {
+	return m.MergeTagsFunc(sourceID, targetID, userID)
+}
@@ -2,21 +2,31 @@ package mocks
 
 import (
 	"ForumService/internal/models"
+	"time"
 )
 
 type MockThreadService struct {
-	CreateThreadFunc      func(title string, authorID int) (*models.Thread, error)
+	CreateThreadFunc      func(title string, threadType string, categoryID *int, tags []string, authorID int) (*models.Thread, error)
 	GetThreadByIDFunc     func(id int) (*models.Thread, error)
 	GetThreadWithPostsFunc func(id int) (*models.Thread, []*models.Post, error)
 	DeleteThreadFunc      func(id int, userID int) error
 	UpdateThreadFunc      func(thread *models.Thread, userID int) error
-	GetAllThreadsFunc     func() ([]*models.Thread, error)
+	SetThreadLockFunc     func(threadID int, locked bool, reason string, userID int) (*models.Thread, error)
+	SetThreadPinFunc      func(threadID int, pinned bool, order int, until *time.Time, userID int) (*models.Thread, error)
+	SetAcceptedAnswerFunc func(threadID int, postID *int, userID int) (*models.Thread, error)
+	GetAllThreadsFunc     func(role string) ([]*models.Thread, error)
+	GetThreadsByCategoryFunc func(categoryID int, role string) ([]*models.Thread, error)
+	GetThreadsByTagsFunc     func(tags []string, matchAll bool, role string) ([]*models.Thread, error)
+	CheckThreadAccessFunc func(thread *models.Thread, role string) error
+	CheckThreadIDAccessFunc func(threadID int, role string) error
+	CheckPostAccessFunc     func(postID int, role string) error
+	FilterReadablePostsFunc func(posts []*models.Post, role string) ([]*models.Post, error)
 	GetPostsByThreadIDFunc func(id int) ([]*models.Post, error)
 	GetUserByIDFunc       func(id int) (*models.User, error)
 }
 
-func (m *MockThreadService) CreateThread(title string, authorID int) (*models.Thread, error) {
-	return m.CreateThreadFunc(title, authorID)
+func (m *MockThreadService) CreateThread(title string, threadType string, categoryID *int, tags []string, authorID int) (*models.Thread, error) {
+	return m.CreateThreadFunc(title, threadType, categoryID, tags, authorID)
 }
 
 func (m *MockThreadService) GetThreadByID(id int) (*models.Thread, error) {
@@ -35,8 +45,59 @@ func (m *MockThreadService) UpdateThread(thread *models.Thread, userID int) erro
 	return m.UpdateThreadFunc(thread, userID)
 }
 
-func (m *MockThreadService) GetAllThreads() ([]*models.Thread, error) {
-	return m.GetAllThreadsFunc()
+func (m *MockThreadService) SetThreadLock(threadID int, locked bool, reason string, userID int) (*models.Thread, error) {
+	return m.SetThreadLockFunc(threadID, locked, reason, userID)
+}
+
+func (m *MockThreadService) SetThreadPin(threadID int, pinned bool, order int, until *time.Time, userID int) (*models.Thread, error) {
+	return m.SetThreadPinFunc(threadID, pinned, order, until, userID)
+}
+
+func (m *MockThreadService) SetAcceptedAnswer(threadID int, postID *int, userID int) (*models.Thread, error) {
+	return m.SetAcceptedAnswerFunc(threadID, postID, userID)
+}
+
+func (m *MockThreadService) GetAllThreads(role string) ([]*models.Thread, error) {
+	return m.GetAllThreadsFunc(role)
+}
+
+func (m *MockThreadService) GetThreadsByCategory(categoryID int, role string) ([]*models.Thread, error) {
+	return m.GetThreadsByCategoryFunc(categoryID, role)
+}
+
+func (m *MockThreadService) GetThreadsByTags(tags []string, matchAll bool, role string) ([]*models.Thread, error) {
+	return m.GetThreadsByTagsFunc(tags, matchAll, role)
+}
+
+// CheckThreadAccess без настроенной функции разрешает доступ, как для тредов вне разделов
+func (m *MockThreadService) CheckThreadAccess(thread *models.Thread, role string) error {
+	if m.CheckThreadAccessFunc == nil {
+		return nil
+	}
+	return m.CheckThreadAccessFunc(thread, role)
+}
+
+// CheckThreadIDAccess и CheckPostAccess без настроенной функции разрешают доступ
+func (m *MockThreadService) CheckThreadIDAccess(threadID int, role string) error {
+	if m.CheckThreadIDAccessFunc == nil {
+		return nil
+	}
+	return m.CheckThreadIDAccessFunc(threadID, role)
+}
+
+func (m *MockThreadService) CheckPostAccess(postID int, role string) error {
+	if m.CheckPostAccessFunc == nil {
+		return nil
+	}
+	return m.CheckPostAccessFunc(postID, role)
+}
+
+// FilterReadablePosts без настроенной функции возвращает посты без изменений
+func (m *MockThreadService) FilterReadablePosts(posts []*models.Post, role string) ([]*models.Post, error) {
+	if m.FilterReadablePostsFunc == nil {
+		return posts, nil
+	}
+	return m.FilterReadablePostsFunc(posts, role)
 }
 
 func (m *MockThreadService) GetPostsByThreadID(id int) ([]*models.Post, error) {
@@ -0,0 +1,26 @@
+package mocks
+
+import (
+	"context"
+	"time"
+)
+
+type MockViewService struct {
+	RecordThreadViewFunc func(threadID int, viewer string) bool
+	RecordPostViewFunc   func(postID int, viewer string) bool
+	FlushFunc            func() error
+}
+
+func (m *MockViewService) RecordThreadView(threadID int, viewer string) bool {
+	return m.RecordThreadViewFunc(threadID, viewer)
+}
+
+func (m *MockViewService) RecordPostView(postID int, viewer string) bool {
+	return m.RecordPostViewFunc(postID, viewer)
+}
+
+func (m *MockViewService) Flush() error {
+	return m.FlushFunc()
+}
+
+func (m *MockViewService) Run(ctx context.Context, interval time.Duration) {}
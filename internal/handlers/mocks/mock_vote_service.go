@@ -0,0 +1,28 @@
+package mocks
+
+import (
+	"ForumService/internal/models"
+)
+
+type MockVoteService struct {
+	VotePostFunc      func(postID, userID, value int) (models.VoteResult, error)
+	UnvotePostFunc    func(postID, userID int) (models.VoteResult, error)
+	VoteCommentFunc   func(commentID, userID, value int) (models.VoteResult, error)
+	UnvoteCommentFunc func(commentID, userID int) (models.VoteResult, error)
+}
+
+func (m *MockVoteService) VotePost(postID, userID, value int) (models.VoteResult, error) {
+	return m.VotePostFunc(postID, userID, value)
+}
+
+func (m *MockVoteService) UnvotePost(postID, userID int) (models.VoteResult, error) {
+	return m.UnvotePostFunc(postID, userID)
+}
+
+func (m *MockVoteService) VoteComment(commentID, userID, value int) (models.VoteResult, error) {
+	return m.VoteCommentFunc(commentID, userID, value)
+}
+
+func (m *MockVoteService) UnvoteComment(commentID, userID int) (models.VoteResult, error) {
+	return m.UnvoteCommentFunc(commentID, userID)
+}
@@ -0,0 +1,56 @@
+package markdown
+
+import (
+	"regexp"
+	"unicode"
+
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/text"
+	"github.com/yuin/goldmark/util"
+)
+
+// mentionsKey - ключ контекста разбора с адресами упомянутых пользователей
+var mentionsKey = parser.NewContextKey()
+
+// mentionName совпадает с именем в service.MentionPattern: не начинается и не
+// заканчивается точкой или дефисом
+var mentionName = regexp.MustCompile(`^@([\p{L}\p{N}_](?:[\p{L}\p{N}_.-]*[\p{L}\p{N}_])?)`)
+
+// mentionParser превращает @username в ссылку на страницу пользователя. Упоминания в коде
+// не разбираются, так как внутри кода встроенные парсеры не вызываются.
+type mentionParser struct{}
+
+func mentionParserPriority() util.PrioritizedValue {
+	// Раньше автоссылок, чтобы имя с точкой не приняли за адрес сайта
+	return util.Prioritized(mentionParser{}, 90)
+}
+
+func (mentionParser) Trigger() []byte {
+	return []byte{'@'}
+}
+
+func (mentionParser) Parse(parent ast.Node, block text.Reader, pc parser.Context) ast.Node {
+	// Как в service.MentionPattern: адрес почты и "@@name" не упоминания
+	if before := block.PrecendingCharacter(); unicode.IsLetter(before) || unicode.IsDigit(before) ||
+		before == '_' || before == '@' || before == '.' {
+		return nil
+	}
+	mentions, _ := pc.Get(mentionsKey).(map[string]string)
+	line, segment := block.PeekLine()
+	match := mentionName.FindSubmatch(line)
+	if match == nil {
+		return nil
+	}
+	href, ok := mentions[string(match[1])]
+	if !ok {
+		return nil
+	}
+
+	link := ast.NewLink()
+	link.Destination = []byte(href)
+	link.SetAttributeString("class", []byte("mention"))
+	link.AppendChild(link, ast.NewTextSegment(segment.WithStop(segment.Start+len(match[0]))))
+	block.Advance(len(match[0]))
+	return link
+}
@@ -0,0 +1,97 @@
+package markdown
+
+import (
+	"ForumService/internal/cache"
+	"bytes"
+	"crypto/sha256"
+	"html"
+	"sort"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/extension"
+	"github.com/yuin/goldmark/parser"
+	goldhtml "github.com/yuin/goldmark/renderer/html"
+)
+
+// DefaultCacheSize - сколько отрисованных текстов хранит кэш по умолчанию
+const DefaultCacheSize = 4096
+
+// Renderer переводит текст постов, комментариев и сообщений чата из Markdown (CommonMark
+// с таблицами, зачеркиванием и автоссылками GFM) в HTML и очищает результат по белому списку.
+type Renderer interface {
+	// Render возвращает безопасный HTML текста source. Упоминания @username, для которых
+	// в mentions есть адрес страницы пользователя, становятся ссылками, остальные - текстом.
+	Render(source string, mentions map[string]string) string
+	// Stats возвращает счетчики кэша отрисованных текстов
+	Stats() cache.Stats
+}
+
+// versionKey - версия текста: хэш исходника вместе с адресами упоминаний, от которых
+// зависит результат. Правка текста или удаление упомянутого пользователя меняют ключ,
+// поэтому кэш не нужно сбрасывать.
+type versionKey [sha256.Size]byte
+
+type renderer struct {
+	md    goldmark.Markdown
+	cache *cache.Cache[versionKey, string]
+}
+
+// NewRenderer создает отрисовщик с кэшем на cacheSize текстов
+func NewRenderer(cacheSize int) Renderer {
+	return &renderer{
+		md: goldmark.New(
+			goldmark.WithExtensions(
+				extension.NewTable(extension.WithTableCellAlignMethod(extension.TableCellAlignAttribute)),
+				extension.Strikethrough,
+				extension.Linkify,
+			),
+			goldmark.WithParserOptions(parser.WithInlineParsers(mentionParserPriority())),
+			// Переносы строк в записях форума значимы, как и раньше. HTML в тексте
+			// пропускается как есть: его чистит Sanitize по белому списку.
+			goldmark.WithRendererOptions(goldhtml.WithHardWraps(), goldhtml.WithUnsafe()),
+		),
+		cache: cache.New[versionKey, string](cacheSize, 0),
+	}
+}
+
+func (r *renderer) Render(source string, mentions map[string]string) string {
+	rendered, err := r.cache.GetOrLoad(contentVersion(source, mentions), func() (string, error) {
+		ctx := parser.NewContext()
+		ctx.Set(mentionsKey, mentions)
+		var buf bytes.Buffer
+		if err := r.md.Convert([]byte(source), &buf, parser.WithContext(ctx)); err != nil {
+			return "", err
+		}
+		return Sanitize(buf.String()), nil
+	})
+	if err != nil {
+		// Запись в буфер не падает, но текст в любом случае нужно показать
+		return html.EscapeString(source)
+	}
+	return rendered
+}
+
+func (r *renderer) Stats() cache.Stats {
+	return r.cache.Stats()
+}
+
+// contentVersion считает ключ кэша для текста и упоминаний в нем
+func contentVersion(source string, mentions map[string]string) versionKey {
+	usernames := make([]string, 0, len(mentions))
+	for username := range mentions {
+		usernames = append(usernames, username)
+	}
+	sort.Strings(usernames)
+
+	hash := sha256.New()
+	hash.Write([]byte(source))
+	for _, username := range usernames {
+		hash.Write([]byte{0})
+		hash.Write([]byte(username))
+		hash.Write([]byte{0})
+		hash.Write([]byte(mentions[username]))
+	}
+	var key versionKey
+	hash.Sum(key[:0])
+	return key
+}
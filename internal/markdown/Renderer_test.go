@@ -0,0 +1,88 @@
+package markdown
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRenderer_Render(t *testing.T) {
+	r := NewRenderer(10)
+	mentions := map[string]string{"bob": "/users/2", "Иван": "/users/3"}
+
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{
+			name:    "блок кода с языком",
+			content: "```go\nfmt.Println(\"<b>\")\n```",
+			want:    "<pre><code class=\"language-go\">fmt.Println(&#34;&lt;b&gt;&#34;)\n</code></pre>\n",
+		},
+		{
+			name:    "внешняя ссылка",
+			content: "Посетите [сайт](https://example.com) или https://example.org",
+			want: `<p>Посетите <a href="https://example.com" rel="nofollow noopener" target="_blank">сайт</a> или ` +
+				`<a href="https://example.org" rel="nofollow noopener" target="_blank">https://example.org</a></p>` + "\n",
+		},
+		{
+			name:    "списки",
+			content: "- один\n- два\n\n1. первый",
+			want:    "<ul>\n<li>один</li>\n<li>два</li>\n</ul>\n<ol>\n<li>первый</li>\n</ol>\n",
+		},
+		{
+			name:    "таблица с выравниванием",
+			content: "| a | b |\n|:--|--:|\n| 1 | 2 |",
+			want: "<table>\n<thead>\n<tr>\n<th align=\"left\">a</th>\n<th align=\"right\">b</th>\n</tr>\n</thead>\n" +
+				"<tbody>\n<tr>\n<td align=\"left\">1</td>\n<td align=\"right\">2</td>\n</tr>\n</tbody>\n</table>\n",
+		},
+		{
+			name:    "переносы строк",
+			content: "Строка 1\nСтрока 2",
+			want:    "<p>Строка 1<br>\nСтрока 2</p>\n",
+		},
+		{
+			name:    "упоминания",
+			content: "@bob, спроси @Иван. @ghost, bob@example.com и `@bob`",
+			want: `<p><a href="/users/2" class="mention">@bob</a>, спроси <a href="/users/3" class="mention">@Иван</a>. ` +
+				`@ghost, <a href="mailto:bob@example.com">bob@example.com</a> и <code>@bob</code></p>` + "\n",
+		},
+		{
+			name:    "скрипт и обработчики событий",
+			content: "Текст <b onclick=\"alert(1)\">жирный</b><script>alert(1)</script>",
+			want:    "<p>Текст <b>жирный</b></p>\n",
+		},
+		{
+			name:    "опасные адреса",
+			content: "[ссылка](javascript:alert(1)) <a href=\"data:text/html,x\">a</a> <img src=\"/x.png\">",
+			want:    "<p>ссылка a </p>\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, r.Render(tt.content, mentions))
+		})
+	}
+}
+
+func TestRenderer_CachePerVersion(t *testing.T) {
+	r := NewRenderer(10)
+	mentions := map[string]string{"bob": "/users/2"}
+
+	first := r.Render("привет, @bob", mentions)
+	assert.Equal(t, first, r.Render("привет, @bob", map[string]string{"bob": "/users/2"}))
+	assert.Equal(t, uint64(1), r.Stats().Hits)
+
+	// Измененный текст и удаленное упоминание - другие версии, кэш не мешает их увидеть
+	assert.Equal(t, "<p>привет, <a href=\"/users/2\" class=\"mention\">@bob</a>!</p>\n", r.Render("привет, @bob!", mentions))
+	assert.Equal(t, "<p>привет, @bob</p>\n", r.Render("привет, @bob", nil))
+	assert.Equal(t, uint64(3), r.Stats().Misses)
+}
+
+func TestSanitize(t *testing.T) {
+	assert.Equal(t, "Текст", Sanitize("<script>alert('xss')</script>Текст"))
+	assert.Equal(t, `<a href="/posts/1">пост</a>`, Sanitize(`<a href="/posts/1" onmouseover="x()" style="color:red">пост</a>`))
+	assert.Equal(t, `<code>x</code>`, Sanitize(`<code class="evil">x</code>`))
+}
@@ -0,0 +1,38 @@
+package markdown
+
+import (
+	"regexp"
+
+	"github.com/microcosm-cc/bluemonday"
+)
+
+// policy - белый список разметки записей. Все, что в него не входит, вырезается:
+// скрипты и стили вместе с содержимым, остальные теги с сохранением текста.
+var policy = newPolicy()
+
+func newPolicy() *bluemonday.Policy {
+	p := bluemonday.NewPolicy()
+	p.AllowElements("p", "br", "hr", "blockquote", "pre",
+		"h1", "h2", "h3", "h4", "h5", "h6",
+		"strong", "b", "em", "i", "del", "s", "code", "sub", "sup")
+	p.AllowLists()
+	p.AllowTables()
+	p.AllowAttrs("align").Matching(regexp.MustCompile(`^(left|center|right)$`)).OnElements("th", "td")
+	// Класс языка у блоков кода - для подсветки на странице
+	p.AllowAttrs("class").Matching(regexp.MustCompile(`^language-[\w+#.-]+$`)).OnElements("code")
+
+	p.AllowStandardURLs()
+	p.AllowRelativeURLs(true)
+	p.AllowAttrs("href").OnElements("a")
+	p.AllowAttrs("class").Matching(regexp.MustCompile(`^mention$`)).OnElements("a")
+	// Внешние ссылки без передачи веса и в новой вкладке, ссылки форума - как обычно
+	p.RequireNoFollowOnLinks(false)
+	p.RequireNoFollowOnFullyQualifiedLinks(true)
+	p.AddTargetBlankToFullyQualifiedLinks(true)
+	return p
+}
+
+// Sanitize оставляет в HTML только разметку из белого списка
+func Sanitize(html string) string {
+	return policy.Sanitize(html)
+}
@@ -7,8 +7,12 @@ import (
 	"fmt"
 )
 
-// AuthServiceMiddleware проверяет JWT токен через AuthService
-func AuthServiceMiddleware(authClient *client.AuthClient) gin.HandlerFunc {
+// AuthenticatedUserFunc получает данные пользователя после успешной проверки токена
+type AuthenticatedUserFunc func(userID int, username string, role string)
+
+// AuthServiceMiddleware проверяет JWT токен через AuthService. Функции onAuthenticated
+// вызываются для каждого аутентифицированного запроса до передачи управления обработчику.
+func AuthServiceMiddleware(authClient *client.AuthClient, onAuthenticated ...AuthenticatedUserFunc) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// Получаем токен из заголовка Authorization или из куки
 		authHeader := c.GetHeader("Authorization")
@@ -74,6 +78,10 @@ func AuthServiceMiddleware(authClient *client.AuthClient) gin.HandlerFunc {
 			fmt.Printf("Debug - User ID not found in context\n")
 		}
 
+		for _, notify := range onAuthenticated {
+			notify(int(userID), username, c.GetString("user_role"))
+		}
+
 		c.Next()
 	}
 } 
\ No newline at end of file
@@ -2,10 +2,96 @@ package middleware
 
 import (
 	"ForumService/internal/errors"
+	"ForumService/internal/service"
+	stdErrors "errors"
 	"github.com/gin-gonic/gin"
-	"net/http"
 )
 
+// domainErrors сопоставляет ошибки сервисного слоя с ответами API
+var domainErrors = []struct {
+	target  error
+	message string
+	build   func(message string, err error) *errors.ForumError
+}{
+	{service.ErrThreadNotFound, "Тред не найден", errors.NewNotFoundError},
+	{service.ErrPostNotFound, "Пост не найден", errors.NewNotFoundError},
+	{service.ErrCommentNotFound, "Комментарий не найден", errors.NewNotFoundError},
+	{service.ErrUserNotFound, "Пользователь не найден", errors.NewNotFoundError},
+	{service.ErrRevisionNotFound, "Ревизия не найдена", errors.NewNotFoundError},
+	{service.ErrCategoryNotFound, "Раздел не найден", errors.NewNotFoundError},
+	{service.ErrTagNotFound, "Тег не найден", errors.NewNotFoundError},
+	{service.ErrTagExists, "Тег с таким названием уже существует, используйте объединение тегов", errors.NewDuplicateError},
+	{service.ErrAlreadyExists, "Запись уже существует", errors.NewDuplicateError},
+	{service.ErrVersionConflict, "Запись была изменена другим пользователем", errors.NewPreconditionFailedError},
+	{service.ErrNoPermission, "Недостаточно прав", errors.NewPermissionDeniedError},
+	{service.ErrUnauthorized, "Пользователь не аутентифицирован", errors.NewUnauthorizedError},
+	{service.ErrEditWindowExpired, "Время редактирования истекло", errors.NewPermissionDeniedError},
+	{service.ErrInvalidTitle, "Заголовок должен содержать от 3 до 100 символов", errors.NewValidationError},
+	{service.ErrInvalidContent, "Недопустимая длина текста", errors.NewValidationError},
+	{service.ErrEmptyContent, "Текст не может быть пустым", errors.NewValidationError},
+	{service.ErrCommentTooDeep, "Превышена максимальная глубина ответов", errors.NewBadRequestError},
+	{service.ErrInvalidParent, "Родительский комментарий относится к другому посту", errors.NewBadRequestError},
+	{service.ErrCommentDeleted, "Комментарий удален", errors.NewBadRequestError},
+	{service.ErrEmptySearchQuery, "Поисковый запрос не может быть пустым", errors.NewBadRequestError},
+	{service.ErrInvalidSearchType, "Неизвестный тип результатов поиска", errors.NewBadRequestError},
+	{service.ErrInvalidDateRange, "Неверный диапазон дат", errors.NewBadRequestError},
+	{service.ErrCategoryNotEmpty, "В разделе есть треды или подразделы", errors.NewBadRequestError},
+	{service.ErrInvalidCategoryName, "Название раздела должно содержать от 2 до 100 символов", errors.NewValidationError},
+	{service.ErrInvalidCategoryRole, "Неизвестная роль доступа к разделу", errors.NewValidationError},
+	{service.ErrCategoryCycle, "Раздел нельзя вложить в самого себя", errors.NewBadRequestError},
+	{service.ErrInvalidTag, "Тег должен содержать от 2 до 32 букв, цифр или символов - _ + # .", errors.NewValidationError},
+	{service.ErrTooManyTags, "У треда может быть не больше 5 тегов", errors.NewValidationError},
+	{service.ErrInvalidTagMerge, "Тег нельзя объединить с самим собой", errors.NewBadRequestError},
+	{service.ErrThreadLocked, "Тред закрыт, новые посты и комментарии не принимаются", errors.NewThreadLockedError},
+	{service.ErrInvalidLockReason, "Причина закрытия должна содержать не больше 500 символов", errors.NewValidationError},
+	{service.ErrInvalidPinOrder, "Порядок закрепления должен быть от 0 до 1000", errors.NewValidationError},
+	{service.ErrInvalidPinExpiry, "Срок закрепления должен быть в будущем", errors.NewValidationError},
+	{service.ErrInvalidVote, "Голос должен быть 1 или -1", errors.NewValidationError},
+	{service.ErrSelfVote, "Нельзя голосовать за свои посты и комментарии", errors.NewPermissionDeniedError},
+	{service.ErrInvalidSort, "Неизвестный порядок сортировки", errors.NewBadRequestError},
+	{service.ErrMessageNotFound, "Сообщение не найдено", errors.NewNotFoundError},
+	{service.ErrReactionNotAllowed, "Такая реакция недоступна", errors.NewValidationError},
+	{service.ErrInvalidReaction, "Неизвестный вид записи для реакции", errors.NewBadRequestError},
+	{service.ErrInvalidThreadType, "Тип треда должен быть discussion или question", errors.NewValidationError},
+	{service.ErrNotQuestion, "Принять ответ можно только в треде-вопросе", errors.NewBadRequestError},
+	{service.ErrInvalidAnswer, "Ответом может быть только пост этого треда, кроме первого", errors.NewBadRequestError},
+	{service.ErrInvalidReplyTarget, "Ответить можно только на пост этого треда", errors.NewBadRequestError},
+	{service.ErrBookmarkNotFound, "Закладка не найдена", errors.NewNotFoundError},
+	{service.ErrInvalidBookmark, "Неизвестный вид записи для закладки", errors.NewBadRequestError},
+	{service.ErrInvalidBookmarkNote, "Заметка должна содержать не больше 500 символов", errors.NewValidationError},
+	{service.ErrSubscriptionNotFound, "Подписка не найдена", errors.NewNotFoundError},
+	{service.ErrInvalidSubscription, "Неизвестный вид записи для подписки", errors.NewBadRequestError},
+	{service.ErrNotificationNotFound, "Уведомление не найдено", errors.NewNotFoundError},
+	{service.ErrAttachmentNotFound, "Вложение не найдено", errors.NewNotFoundError},
+	{service.ErrAttachmentTooLarge, "Файл слишком большой", errors.NewPayloadTooLargeError},
+	{service.ErrAttachmentType, "Такой тип файла загружать нельзя", errors.NewUnsupportedMediaTypeError},
+	{service.ErrEmptyAttachment, "Файл пуст", errors.NewValidationError},
+	{service.ErrTooManyAttachments, "К записи можно прикрепить не больше 10 файлов", errors.NewValidationError},
+	{service.ErrPollNotFound, "Опрос не найден", errors.NewNotFoundError},
+	{service.ErrPollOptionNotFound, "В опросе нет такого варианта", errors.NewValidationError},
+	{service.ErrPollClosed, "Опрос закрыт, голосование завершено", errors.NewPollClosedError},
+	{service.ErrPollExists, "В треде уже есть опрос", errors.NewDuplicateError},
+	{service.ErrInvalidPollQuestion, "Вопрос опроса должен содержать от 1 до 255 символов", errors.NewValidationError},
+	{service.ErrInvalidPollOptions, "В опросе должно быть от 2 до 10 разных вариантов длиной до 100 символов", errors.NewValidationError},
+	{service.ErrInvalidPollClose, "Время закрытия опроса должно быть в будущем", errors.NewValidationError},
+	{service.ErrInvalidPollChoice, "В этом опросе можно выбрать только один вариант", errors.NewValidationError},
+}
+
+// ToForumError приводит произвольную ошибку к ForumError. Ошибки форума возвращаются как есть,
+// известные ошибки сервисов получают свой статус, остальные становятся внутренними с сообщением fallback.
+func ToForumError(err error, fallback string) *errors.ForumError {
+	var forumErr *errors.ForumError
+	if stdErrors.As(err, &forumErr) {
+		return forumErr
+	}
+	for _, domainErr := range domainErrors {
+		if stdErrors.Is(err, domainErr.target) {
+			return domainErr.build(domainErr.message, err)
+		}
+	}
+	return errors.NewInternalServerError(fallback, err)
+}
+
 // ErrorHandler middleware для обработки ошибок
 func ErrorHandler() gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -13,20 +99,11 @@ func ErrorHandler() gin.HandlerFunc {
 
 		// Проверяем, есть ли ошибки
 		if len(c.Errors) > 0 {
-			err := c.Errors.Last().Err
-			
-			// Проверяем, является ли ошибка кастомной ошибкой форума
-			if forumErr, ok := err.(*errors.ForumError); ok {
-				c.JSON(forumErr.Code, gin.H{
-					"error": forumErr.Message,
-				})
-				return
-			}
-
-			// Если это не кастомная ошибка, возвращаем 500
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"error": "Внутренняя ошибка сервера",
+			forumErr := ToForumError(c.Errors.Last().Err, "Внутренняя ошибка сервера")
+			c.JSON(forumErr.Code, gin.H{
+				"error": forumErr.Message,
+				"code":  forumErr.Type,
 			})
 		}
 	}
-} 
\ No newline at end of file
+}
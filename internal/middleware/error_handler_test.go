@@ -0,0 +1,94 @@
+package middleware
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"ForumService/internal/errors"
+	"ForumService/internal/service"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestErrorHandler(t *testing.T) {
+	tests := []struct {
+		name           string
+		err            error
+		expectedStatus int
+		expectedBody   map[string]interface{}
+	}{
+		{
+			name:           "ошибка форума",
+			err:            errors.NewValidationError("Неверный формат данных", nil),
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   map[string]interface{}{"error": "Неверный формат данных", "code": "validation_error"},
+		},
+		{
+			name:           "обернутая ошибка сервиса",
+			err:            fmt.Errorf("couldn't get comment: %w", service.ErrCommentNotFound),
+			expectedStatus: http.StatusNotFound,
+			expectedBody:   map[string]interface{}{"error": "Комментарий не найден", "code": "not_found"},
+		},
+		{
+			name:           "нет прав",
+			err:            service.ErrNoPermission,
+			expectedStatus: http.StatusForbidden,
+			expectedBody:   map[string]interface{}{"error": "Недостаточно прав", "code": "permission_denied"},
+		},
+		{
+			name:           "закрытый тред",
+			err:            fmt.Errorf("couldn't create comment: %w", service.ErrThreadLocked),
+			expectedStatus: http.StatusLocked,
+			expectedBody:   map[string]interface{}{"error": "Тред закрыт, новые посты и комментарии не принимаются", "code": "thread_locked"},
+		},
+		{
+			name:           "слишком большой файл",
+			err:            service.ErrAttachmentTooLarge,
+			expectedStatus: http.StatusRequestEntityTooLarge,
+			expectedBody:   map[string]interface{}{"error": "Файл слишком большой", "code": "payload_too_large"},
+		},
+		{
+			name:           "запрещенный тип файла",
+			err:            service.ErrAttachmentType,
+			expectedStatus: http.StatusUnsupportedMediaType,
+			expectedBody:   map[string]interface{}{"error": "Такой тип файла загружать нельзя", "code": "unsupported_media_type"},
+		},
+		{
+			name:           "закрытый опрос",
+			err:            service.ErrPollClosed,
+			expectedStatus: http.StatusConflict,
+			expectedBody:   map[string]interface{}{"error": "Опрос закрыт, голосование завершено", "code": "poll_closed"},
+		},
+		{
+			name:           "неизвестная ошибка",
+			err:            fmt.Errorf("connection refused"),
+			expectedStatus: http.StatusInternalServerError,
+			expectedBody:   map[string]interface{}{"error": "Внутренняя ошибка сервера", "code": "internal_error"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gin.SetMode(gin.TestMode)
+			router := gin.New()
+			router.Use(ErrorHandler())
+			router.GET("/test", func(c *gin.Context) {
+				c.Error(tt.err)
+			})
+
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("GET", "/test", nil)
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+
+			var response map[string]interface{}
+			err := json.Unmarshal(w.Body.Bytes(), &response)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedBody, response)
+		})
+	}
+}
@@ -0,0 +1,41 @@
+package models
+
+import (
+	"strings"
+	"time"
+)
+
+// Виды записей, к которым прикрепляют файлы
+const (
+	AttachmentTargetPost    = "post"
+	AttachmentTargetComment = "comment"
+)
+
+// Attachment - файл, загруженный к посту или комментарию. Пока запись не сохранена,
+// вложение ни к чему не привязано: PostID и CommentID равны nil.
+type Attachment struct {
+	ID         int  `json:"id"`
+	UploaderID int  `json:"uploader_id"`
+	PostID     *int `json:"post_id,omitempty"`
+	CommentID  *int `json:"comment_id,omitempty"`
+	// Filename - имя файла у пользователя, только для показа и скачивания
+	Filename string `json:"filename"`
+	// ContentType определяется по содержимому файла, а не по заголовкам загрузки
+	ContentType string `json:"content_type"`
+	Size        int64  `json:"size"`
+	// StorageKey и ThumbnailKey - ключи файла и миниатюры в хранилище. Миниатюра есть только у картинок.
+	StorageKey   string `json:"-"`
+	ThumbnailKey string `json:"-"`
+	// Width и Height - размеры картинки в пикселях, 0 для остальных файлов
+	Width     int       `json:"width,omitempty"`
+	Height    int       `json:"height,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	// URL и ThumbnailURL заполняются обработчиком
+	URL          string `json:"url"`
+	ThumbnailURL string `json:"thumbnail_url,omitempty"`
+}
+
+// IsImage сообщает, что вложение - картинка, которую можно показать на странице
+func (a *Attachment) IsImage() bool {
+	return strings.HasPrefix(a.ContentType, "image/")
+}
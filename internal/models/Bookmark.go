@@ -0,0 +1,32 @@
+package models
+
+import "time"
+
+// Виды записей, которые можно добавить в закладки
+const (
+	BookmarkTargetThread = "thread"
+	BookmarkTargetPost   = "post"
+)
+
+// Bookmark - закладка пользователя на тред или пост. Закладки удаляются вместе с записью.
+type Bookmark struct {
+	UserID     int    `json:"user_id"`
+	TargetType string `json:"target_type"`
+	TargetID   int    `json:"target_id"`
+	// Note - личная заметка, видна только владельцу закладки
+	Note      string    `json:"note"`
+	CreatedAt time.Time `json:"created_at"`
+	// Title - заголовок треда или поста; у поста без заголовка - заголовок его треда.
+	// Пуст, если раздел треда закрыт для владельца закладки.
+	Title string `json:"title"`
+	// ThreadID - тред закладки: сам тред или тред поста; 0, если раздел закрыт
+	ThreadID int `json:"thread_id"`
+}
+
+// BookmarkPage - страница закладок пользователя, новые первыми
+type BookmarkPage struct {
+	Bookmarks []Bookmark `json:"bookmarks"`
+	Total     int        `json:"total"`
+	Page      int        `json:"page"`
+	Limit     int        `json:"limit"`
+}
@@ -0,0 +1,109 @@
+package models
+
+import "time"
+
+const (
+	// RoleGuest - роль неаутентифицированного посетителя
+	RoleGuest     Role = "guest"
+	RoleModerator Role = "moderator"
+)
+
+// roleRanks упорядочивает роли по уровню доступа
+var roleRanks = map[Role]int{
+	RoleGuest:     0,
+	RoleUser:      1,
+	RoleModerator: 2,
+	RoleAdmin:     3,
+}
+
+// ValidRole проверяет, что роль известна форуму
+func ValidRole(role Role) bool {
+	_, ok := roleRanks[role]
+	return ok
+}
+
+// Allows проверяет, что роль дает доступ уровня required.
+// Пустая роль считается гостем, неизвестная роль аутентифицированного пользователя - обычным пользователем.
+func (r Role) Allows(required Role) bool {
+	rank, ok := roleRanks[r]
+	if !ok {
+		rank = roleRanks[RoleUser]
+		if r == "" {
+			rank = roleRanks[RoleGuest]
+		}
+	}
+	return rank >= roleRanks[required]
+}
+
+// AllowedRoles возвращает роли, доступ уровня которых дает роль r, от младшей к старшей.
+// Нужен, чтобы проверить read_role раздела в SQL-запросе.
+func (r Role) AllowedRoles() []Role {
+	allowed := make([]Role, 0, len(roleRanks))
+	for _, role := range []Role{RoleGuest, RoleUser, RoleModerator, RoleAdmin} {
+		if r.Allows(role) {
+			allowed = append(allowed, role)
+		}
+	}
+	return allowed
+}
+
+// Stricter возвращает более строгую из ролей r и other
+func (r Role) Stricter(other Role) Role {
+	if r.Allows(other) {
+		return r
+	}
+	return other
+}
+
+// EffectiveReadRoles возвращает действующую роль для чтения каждого раздела - самую
+// строгую read_role на пути от корня: подраздел не бывает открытее своих предков.
+func EffectiveReadRoles(categories []*Category) map[int]Role {
+	byID := make(map[int]*Category, len(categories))
+	for _, category := range categories {
+		byID[category.ID] = category
+	}
+
+	roles := make(map[int]Role, len(categories))
+	var resolve func(category *Category) Role
+	resolve = func(category *Category) Role {
+		if role, ok := roles[category.ID]; ok {
+			return role
+		}
+		// Запись до обхода родителя защищает от зацикленных ссылок
+		roles[category.ID] = category.ReadRole
+		role := category.ReadRole
+		if category.ParentID != nil {
+			if parent, ok := byID[*category.ParentID]; ok {
+				role = role.Stricter(resolve(parent))
+			}
+		}
+		roles[category.ID] = role
+		return role
+	}
+	for _, category := range categories {
+		resolve(category)
+	}
+	return roles
+}
+
+// Category - раздел форума. Разделы образуют дерево через ParentID и сортируются по Position.
+// CreateRole задается для каждого раздела отдельно, а ReadRole ограничивает и все
+// подразделы: для чтения нужна самая строгая read_role на пути от корня.
+type Category struct {
+	ID          int    `json:"id"`
+	ParentID    *int   `json:"parent_id"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Position    int    `json:"position"`
+	// ReadRole - минимальная роль для просмотра раздела и его тредов
+	ReadRole Role `json:"read_role"`
+	// CreateRole - минимальная роль для создания тредов в разделе
+	CreateRole Role      `json:"create_role"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+	// ThreadCount и PostCount считают треды раздела. В дереве разделов к ним
+	// прибавляются видимые пользователю подразделы.
+	ThreadCount int         `json:"thread_count"`
+	PostCount   int         `json:"post_count"`
+	Children    []*Category `json:"children,omitempty"`
+}
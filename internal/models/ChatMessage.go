@@ -8,4 +8,8 @@ type ChatMessage struct {
     Content   string    `json:"content"`
     CreatedAt time.Time `json:"created_at"`
     AuthorName string   `json:"author_name"`
+    Reactions []ReactionCount `json:"reactions,omitempty"`
+    Mentions  []Mention       `json:"mentions,omitempty"`
+    // ContentHTML - текст в HTML, как у поста
+    ContentHTML string        `json:"content_html,omitempty"`
 } 
\ No newline at end of file
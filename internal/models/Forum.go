@@ -15,13 +15,62 @@ type User struct {
 	Email    string `json:"email"`
 }
 
+// Типы тредов
+const (
+	// ThreadTypeDiscussion - обычное обсуждение, тип по умолчанию
+	ThreadTypeDiscussion = "discussion"
+	// ThreadTypeQuestion - вопрос, в котором можно принять один из постов как ответ
+	ThreadTypeQuestion = "question"
+)
+
 type Thread struct {
 	ID         int       `json:"id"`
 	Title      string    `json:"title"`
+	// Type - ThreadTypeDiscussion или ThreadTypeQuestion
+	Type       string    `json:"type"`
 	AuthorID   int       `json:"author_id"`
 	AuthorName string    `json:"author_name"`
 	CreatedAt  time.Time `json:"created_at"`
 	UpdatedAt  time.Time `json:"updated_at"`
+	// Version увеличивается при каждом изменении и используется как ETag
+	Version int `json:"version"`
+	// CategoryID равен nil для тредов вне разделов
+	CategoryID   *int   `json:"category_id"`
+	CategoryName string `json:"category_name,omitempty"`
+	// Tags - нормализованные теги треда в алфавитном порядке
+	Tags []string `json:"tags"`
+	// Счетчики денормализованы и обновляются вместе с постами и комментариями треда.
+	// Удаленные комментарии-заглушки не учитываются.
+	PostCount    int `json:"post_count"`
+	CommentCount int `json:"comment_count"`
+	// ParticipantCount - число разных авторов треда, его постов и комментариев
+	ParticipantCount int `json:"participant_count"`
+	// ViewCount - просмотры треда разными посетителями. Просмотры сбрасываются в хранилище
+	// пачками, поэтому счетчик может отставать на интервал сброса.
+	ViewCount int `json:"view_count"`
+	// LastPostAt и LastPostAuthorID равны nil, пока в треде нет постов
+	LastPostAt         *time.Time `json:"last_post_at"`
+	LastPostAuthorID   *int       `json:"last_post_author_id"`
+	LastPostAuthorName string     `json:"last_post_author_name,omitempty"`
+	// В закрытом треде нельзя добавлять посты и комментарии. LockedAt, LockedByID
+	// и LockReason заполнены только у закрытых тредов.
+	Locked       bool       `json:"locked"`
+	LockedAt     *time.Time `json:"locked_at"`
+	LockedByID   *int       `json:"locked_by_id"`
+	LockedByName string     `json:"locked_by_name,omitempty"`
+	LockReason   string     `json:"lock_reason,omitempty"`
+	// Закрепленные треды идут в начале списка по возрастанию PinOrder. Закрепление
+	// с прошедшим PinnedUntil считается истекшим, и Pinned у такого треда равен false.
+	Pinned      bool       `json:"pinned"`
+	PinOrder    int        `json:"pin_order"`
+	PinnedAt    *time.Time `json:"pinned_at"`
+	PinnedUntil *time.Time `json:"pinned_until"`
+	// AcceptedPostID - принятый ответ треда-вопроса, nil пока ответ не принят.
+	// Solved равен true, если ответ принят; при удалении поста отметка снимается.
+	AcceptedPostID *int `json:"accepted_post_id"`
+	Solved         bool `json:"solved"`
+	// Poll - опрос треда с итогами, заполняется обработчиком; nil, если опроса нет
+	Poll *Poll `json:"poll,omitempty"`
 }
 
 type Post struct {
@@ -34,17 +83,109 @@ type Post struct {
 	Comments  []Comment `json:"comments,omitempty"`
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
-	CanEdit   bool      `json:"can_edit"`
+	// Version увеличивается при каждом изменении и используется как ETag
+	Version int       `json:"version"`
+	CanEdit bool      `json:"can_edit"`
+	// CommentCount - число комментариев поста без удаленных заглушек
+	CommentCount int `json:"comment_count"`
+	// ViewCount - просмотры поста разными посетителями, как у треда
+	ViewCount int `json:"view_count"`
+	// Score - разница голосов за и против, итоги хранятся вместе с постом
+	Upvotes   int `json:"upvotes"`
+	Downvotes int `json:"downvotes"`
+	Score     int `json:"score"`
+	// Закрепленные посты идут в начале треда в порядке закрепления
+	Pinned   bool       `json:"pinned"`
+	PinnedAt *time.Time `json:"pinned_at"`
+	// Accepted - пост принят как ответ на вопрос треда, заполняется при чтении постов треда
+	Accepted bool `json:"accepted,omitempty"`
+	// Reactions - реакции на пост, заполняются обработчиком для конкретного пользователя
+	Reactions []ReactionCount `json:"reactions,omitempty"`
+	// Mentions - упомянутые в тексте пользователи по алфавиту, заполняются обработчиком
+	Mentions []Mention `json:"mentions,omitempty"`
+	// ReplyToPostID - пост того же треда, на который отвечает этот пост. Равен nil,
+	// если пост не ответ или исходный пост удален.
+	ReplyToPostID *int `json:"reply_to_post_id"`
+	// ReplyTo, Quotes и Replies заполняются при чтении поста: пост, на который он отвечает,
+	// процитированные посты в порядке цитат и ответы на этот пост от ранних к поздним
+	ReplyTo *PostRef  `json:"reply_to,omitempty"`
+	Quotes  []PostRef `json:"quotes,omitempty"`
+	Replies []PostRef `json:"replies,omitempty"`
+	// ContentHTML - текст, переведенный из Markdown в очищенный HTML, заполняется обработчиком
+	ContentHTML string `json:"content_html,omitempty"`
+	// Attachments - прикрепленные файлы в порядке загрузки, заполняются обработчиком
+	Attachments []Attachment `json:"attachments,omitempty"`
+}
+
+// PostRef - краткая ссылка на другой пост
+type PostRef struct {
+	ID         int    `json:"id"`
+	ThreadID   int    `json:"thread_id,omitempty"`
+	AuthorID   int    `json:"author_id,omitempty"`
+	AuthorName string `json:"author_name,omitempty"`
+	// Deleted - процитированный пост удален или недоступен читателю, о нем известен только ID
+	Deleted bool `json:"deleted,omitempty"`
+	// ReadRole - роль, нужная для чтения раздела треда поста; заполняется GetPostRefs
+	// и клиенту не отдается
+	ReadRole Role `json:"-"`
 }
 
 type Comment struct {
-	ID         int       `json:"id"`
-	PostID     int       `json:"post_id"`
-	AuthorID   int       `json:"author_id"`
+	ID              int       `json:"id"`
+	PostID          int       `json:"post_id"`
+	ParentCommentID *int      `json:"parent_comment_id"`
+	AuthorID        int       `json:"author_id"`
+	Content         string    `json:"content"`
+	CreatedAt       time.Time `json:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at"`
+	Edited          bool      `json:"edited"`
+	// Depth - уровень вложенности ответа (0 - комментарий к посту)
+	Depth int `json:"depth"`
+	// Path - цепочка ID от корневого комментария до текущего
+	Path []int `json:"path,omitempty"`
+	// Deleted - комментарий удален, но оставлен как заглушка, так как на него есть ответы
+	Deleted    bool   `json:"deleted"`
+	// Score - разница голосов за и против, как у поста
+	Upvotes    int    `json:"upvotes"`
+	Downvotes  int    `json:"downvotes"`
+	Score      int    `json:"score"`
+	Reactions  []ReactionCount `json:"reactions,omitempty"`
+	Mentions   []Mention `json:"mentions,omitempty"`
+	// ContentHTML - текст в HTML, как у поста
+	ContentHTML string `json:"content_html,omitempty"`
+	Attachments []Attachment `json:"attachments,omitempty"`
+	CanEdit    bool   `json:"can_edit"`
+	CanDelete  bool   `json:"can_delete"`
+	AuthorName string `json:"author_name"`
+}
+
+// VoteResult - итоги голосования за пост или комментарий после голоса пользователя
+type VoteResult struct {
+	Upvotes   int `json:"upvotes"`
+	Downvotes int `json:"downvotes"`
+	Score     int `json:"score"`
+	// MyVote - голос пользователя: 1, -1 или 0, если голос отозван
+	MyVote int `json:"my_vote"`
+}
+
+// PostRevision хранит предыдущую версию поста вместе с информацией о правке
+type PostRevision struct {
+	ID     int `json:"id"`
+	PostID int `json:"post_id"`
+	// Title - заголовок поста до правки, пустой у поста без заголовка
+	Title      string    `json:"title"`
 	Content    string    `json:"content"`
+	EditorID   int       `json:"editor_id"`
+	EditorName string    `json:"editor_name"`
+	Reason     string    `json:"reason,omitempty"`
 	CreatedAt  time.Time `json:"created_at"`
-	CanDelete  bool      `json:"can_delete"`
-	AuthorName string    `json:"author_name"`
+}
+
+// CounterRecount - результат пересчета денормализованных счетчиков:
+// число тредов и постов, у которых счетчики расходились с данными
+type CounterRecount struct {
+	Threads int `json:"threads"`
+	Posts   int `json:"posts"`
 }
 
 //	type CreateThreadRequest struct {
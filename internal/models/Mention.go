@@ -0,0 +1,15 @@
+package models
+
+// Виды записей, в которых упоминают пользователей
+const (
+	MentionTargetPost    = "post"
+	MentionTargetComment = "comment"
+	MentionTargetChat    = "chat"
+)
+
+// Mention - упомянутый в записи пользователь. В тексте записи упоминание
+// выглядит как "@" + Username.
+type Mention struct {
+	UserID   int    `json:"user_id"`
+	Username string `json:"username"`
+}
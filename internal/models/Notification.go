@@ -0,0 +1,53 @@
+package models
+
+import "time"
+
+// Виды записей, на которые можно подписаться
+const (
+	SubscriptionTargetThread = "thread"
+	SubscriptionTargetPost   = "post"
+)
+
+// Типы уведомлений
+const (
+	// NotificationNewPost - новый пост в треде, на который подписан пользователь
+	NotificationNewPost = "post"
+	// NotificationNewComment - новый комментарий к посту, на который подписан пользователь
+	NotificationNewComment = "comment"
+	// NotificationReply - ответ на комментарий пользователя
+	NotificationReply = "reply"
+	// NotificationMention - упоминание пользователя в посте, комментарии или сообщении чата
+	NotificationMention = "mention"
+)
+
+// Notification - уведомление пользователя о новой записи. Уведомление удаляется вместе
+// с тредом, постом или комментарием, о котором сообщает.
+type Notification struct {
+	ID     int    `json:"id"`
+	UserID int    `json:"user_id"`
+	Type   string `json:"type"`
+	// ActorID - автор новой записи
+	ActorID   int    `json:"actor_id"`
+	ActorName string `json:"actor_name"`
+	// ThreadID и PostID равны нулю только у упоминаний в чате. ChatMessageID упоминания
+	// в чате становится nil, когда сообщение удаляется по сроку хранения.
+	ThreadID int `json:"thread_id,omitempty"`
+	// PostID - новый пост или пост, к которому оставлен комментарий
+	PostID        int  `json:"post_id,omitempty"`
+	CommentID     *int `json:"comment_id,omitempty"`
+	ChatMessageID *int `json:"chat_message_id,omitempty"`
+	// Title - заголовок поста; у поста без заголовка - заголовок его треда, у сообщения чата - пустой
+	Title     string    `json:"title"`
+	Read      bool      `json:"read"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// NotificationPage - страница уведомлений пользователя, новые первыми
+type NotificationPage struct {
+	Notifications []Notification `json:"notifications"`
+	// Unread - число всех непрочитанных уведомлений пользователя
+	Unread int `json:"unread"`
+	Total  int `json:"total"`
+	Page   int `json:"page"`
+	Limit  int `json:"limit"`
+}
@@ -0,0 +1,52 @@
+package models
+
+import "time"
+
+// Poll - опрос в треде. Итоги (Votes, TotalVoters, MyVotes, Voters) заполняет сервис
+// по голосам; в анонимном опросе Voters всегда пуст.
+type Poll struct {
+	ID             int    `json:"id"`
+	ThreadID       int    `json:"thread_id"`
+	Question       string `json:"question"`
+	MultipleChoice bool   `json:"multiple_choice"`
+	Anonymous      bool   `json:"anonymous"`
+	// ClosesAt - время автоматического закрытия, nil - опрос открыт, пока его не закроют
+	ClosesAt *time.Time `json:"closes_at,omitempty"`
+	// ClosedAt и ClosedBy заполняются при закрытии вручную
+	ClosedAt  *time.Time   `json:"closed_at,omitempty"`
+	ClosedBy  *int         `json:"closed_by,omitempty"`
+	CreatedAt time.Time    `json:"created_at"`
+	Options   []PollOption `json:"options"`
+	// Closed - голосование завершено вручную или по ClosesAt
+	Closed bool `json:"closed"`
+	// TotalVoters - сколько пользователей проголосовало
+	TotalVoters int `json:"total_voters"`
+	// MyVotes - варианты, выбранные текущим пользователем
+	MyVotes []int `json:"my_votes,omitempty"`
+}
+
+// PollOption - вариант ответа в опросе
+type PollOption struct {
+	ID    int    `json:"id"`
+	Text  string `json:"text"`
+	Votes int    `json:"votes"`
+	// Voters - проголосовавшие за вариант, только в открытом (не анонимном) опросе
+	Voters []PollVoter `json:"voters,omitempty"`
+}
+
+// PollVoter - пользователь, выбравший вариант
+type PollVoter struct {
+	UserID   int    `json:"user_id"`
+	Username string `json:"username"`
+}
+
+// PollVote - голос пользователя за вариант опроса
+type PollVote struct {
+	OptionID int
+	PollVoter
+}
+
+// IsClosed сообщает, завершено ли голосование к моменту now
+func (p *Poll) IsClosed(now time.Time) bool {
+	return p.ClosedAt != nil || (p.ClosesAt != nil && !now.Before(*p.ClosesAt))
+}
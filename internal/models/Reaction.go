@@ -0,0 +1,28 @@
+package models
+
+// Виды записей, на которые можно поставить реакцию
+const (
+	ReactionTargetPost    = "post"
+	ReactionTargetComment = "comment"
+	ReactionTargetChat    = "chat"
+)
+
+// ReactionCount - число реакций одним эмодзи на запись
+type ReactionCount struct {
+	Emoji string `json:"emoji"`
+	Count int    `json:"count"`
+	// ReactedByMe - среди реакций есть реакция текущего пользователя
+	ReactedByMe bool `json:"reacted_by_me"`
+}
+
+// ReactionChange - результат переключения реакции пользователем
+type ReactionChange struct {
+	TargetType string `json:"target_type"`
+	TargetID   int    `json:"target_id"`
+	UserID     int    `json:"user_id"`
+	Emoji      string `json:"emoji"`
+	// Added - реакция поставлена; false - снята
+	Added bool `json:"added"`
+	// Reactions - все реакции на запись после изменения
+	Reactions []ReactionCount `json:"reactions"`
+}
@@ -0,0 +1,50 @@
+package models
+
+import "time"
+
+// Типы результатов полнотекстового поиска
+const (
+	SearchTypeThread  = "thread"
+	SearchTypePost    = "post"
+	SearchTypeComment = "comment"
+)
+
+// SearchParams описывает поисковый запрос и его фильтры.
+// Нулевые значения AuthorID, AuthorName, From и To означают отсутствие фильтра,
+// пустая Role - гостя.
+type SearchParams struct {
+	Query      string
+	Types      []string
+	AuthorID   int
+	AuthorName string
+	From       time.Time
+	To         time.Time
+	Limit      int
+	Offset     int
+	// Role - роль пользователя: записи тредов из разделов, закрытых для нее, не ищутся
+	Role Role
+}
+
+// SearchResult - найденный тред, пост или комментарий
+type SearchResult struct {
+	Type       string `json:"type"`
+	ID         int    `json:"id"`
+	ThreadID   int    `json:"thread_id"`
+	PostID     int    `json:"post_id,omitempty"`
+	AuthorID   int    `json:"author_id"`
+	AuthorName string `json:"author_name"`
+	Title      string `json:"title"`
+	// Snippet - фрагмент текста, где совпадения обернуты в <mark>; остальной текст экранирован
+	Snippet   string    `json:"snippet"`
+	Rank      float64   `json:"rank"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// SearchPage - страница результатов поиска
+type SearchPage struct {
+	Query   string         `json:"query"`
+	Results []SearchResult `json:"results"`
+	Total   int            `json:"total"`
+	Page    int            `json:"page"`
+	Limit   int            `json:"limit"`
+}
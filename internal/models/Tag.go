@@ -0,0 +1,12 @@
+package models
+
+import "time"
+
+// Tag - тег треда. Название хранится нормализованным: в нижнем регистре, пробелы заменены дефисами.
+type Tag struct {
+	ID        int       `json:"id"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+	// ThreadCount - число тредов с этим тегом
+	ThreadCount int `json:"thread_count"`
+}
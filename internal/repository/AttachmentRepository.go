@@ -0,0 +1,208 @@
+package repository
+
+import (
+	"ForumService/internal/models"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// attachmentTargets - таблицы записей и колонки attachments, которые на них ссылаются
+var attachmentTargets = map[string]struct {
+	table    string
+	column   string
+	notFound error
+}{
+	models.AttachmentTargetPost:    {table: "posts", column: "post_id", notFound: ErrPostNotFound},
+	models.AttachmentTargetComment: {table: "comments", column: "comment_id", notFound: ErrCommentNotFound},
+}
+
+const attachmentColumns = `id, uploader_id, post_id, comment_id, filename, content_type, size,
+	storage_key, thumbnail_key, width, height, created_at`
+
+type attachmentRepository struct {
+	db *sql.DB
+}
+
+func NewAttachmentRepository(db *sql.DB) AttachmentRepository {
+	return &attachmentRepository{db: db}
+}
+
+func (r *attachmentRepository) Create(attachment *models.Attachment) error {
+	err := r.db.QueryRow(`
+		INSERT INTO attachments (uploader_id, filename, content_type, size, storage_key, thumbnail_key, width, height)
+		VALUES ($1, $2, $3, $4, $5, NULLIF($6, ''), $7, $8)
+		RETURNING id, created_at`,
+		attachment.UploaderID, attachment.Filename, attachment.ContentType, attachment.Size,
+		attachment.StorageKey, attachment.ThumbnailKey, attachment.Width, attachment.Height,
+	).Scan(&attachment.ID, &attachment.CreatedAt)
+	if isForeignKeyViolation(err) {
+		return ErrUserNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("ошибка при сохранении вложения: %w", err)
+	}
+	return nil
+}
+
+func (r *attachmentRepository) GetByID(id int) (*models.Attachment, error) {
+	attachment, err := scanAttachment(r.db.QueryRow(`SELECT `+attachmentColumns+` FROM attachments WHERE id = $1`, id))
+	if err == sql.ErrNoRows {
+		return nil, ErrAttachmentNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при получении вложения: %w", err)
+	}
+	return attachment, nil
+}
+
+// Link блокирует запись на удаление, как mentionRepository.Replace: иначе вложение
+// могло бы привязаться к записи, которую удаляют в этот момент
+func (r *attachmentRepository) Link(targetType string, targetID int, uploaderID int, ids []int) error {
+	target, ok := attachmentTargets[targetType]
+	if !ok {
+		return fmt.Errorf("неизвестный вид записи для вложения: %s", targetType)
+	}
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("ошибка при начале транзакции: %w", err)
+	}
+	defer tx.Rollback()
+
+	var lockedID int
+	err = tx.QueryRow(`SELECT id FROM `+target.table+` WHERE id = $1 FOR SHARE`, targetID).Scan(&lockedID)
+	if err == sql.ErrNoRows {
+		return target.notFound
+	}
+	if err != nil {
+		return fmt.Errorf("ошибка при блокировке записи: %w", err)
+	}
+
+	if len(ids) > 0 {
+		result, err := tx.Exec(`
+			UPDATE attachments SET `+target.column+` = $1
+			WHERE id = ANY($2)
+				AND (`+target.column+` = $1 OR (post_id IS NULL AND comment_id IS NULL AND uploader_id = $3))`,
+			targetID, pq.Array(ids), uploaderID)
+		if err != nil {
+			return fmt.Errorf("ошибка при привязке вложений: %w", err)
+		}
+		linked, err := result.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if int(linked) != len(ids) {
+			return ErrAttachmentNotFound
+		}
+	}
+
+	_, err = tx.Exec(`UPDATE attachments SET `+target.column+` = NULL WHERE `+target.column+` = $1 AND NOT (id = ANY($2))`,
+		targetID, pq.Array(ids))
+	if err != nil {
+		return fmt.Errorf("ошибка при отвязке вложений: %w", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("ошибка при фиксации транзакции: %w", err)
+	}
+	return nil
+}
+
+func (r *attachmentRepository) GetByTargets(targetType string, targetIDs []int) (map[int][]models.Attachment, error) {
+	attachments := make(map[int][]models.Attachment)
+	target, ok := attachmentTargets[targetType]
+	if !ok {
+		return nil, fmt.Errorf("неизвестный вид записи для вложения: %s", targetType)
+	}
+	if len(targetIDs) == 0 {
+		return attachments, nil
+	}
+
+	rows, err := r.db.Query(`SELECT `+attachmentColumns+` FROM attachments
+		WHERE `+target.column+` = ANY($1)
+		ORDER BY created_at, id`,
+		pq.Array(targetIDs))
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при получении вложений: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		attachment, err := scanAttachment(rows)
+		if err != nil {
+			return nil, fmt.Errorf("ошибка при сканировании вложения: %w", err)
+		}
+		targetID := attachment.PostID
+		if targetType == models.AttachmentTargetComment {
+			targetID = attachment.CommentID
+		}
+		attachments[*targetID] = append(attachments[*targetID], *attachment)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("ошибка при итерации по вложениям: %w", err)
+	}
+	return attachments, nil
+}
+
+// DeleteOrphans пропускает строки, заблокированные привязкой: если привязка
+// успеет раньше, вложение перестанет быть сиротой
+func (r *attachmentRepository) DeleteOrphans(before time.Time, limit int) ([]models.Attachment, error) {
+	rows, err := r.db.Query(`
+		DELETE FROM attachments
+		WHERE id IN (
+			SELECT id FROM attachments
+			WHERE post_id IS NULL AND comment_id IS NULL AND created_at < $1
+			ORDER BY created_at
+			LIMIT $2
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING `+attachmentColumns,
+		before, limit)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при удалении непривязанных вложений: %w", err)
+	}
+	defer rows.Close()
+
+	attachments := make([]models.Attachment, 0)
+	for rows.Next() {
+		attachment, err := scanAttachment(rows)
+		if err != nil {
+			return nil, fmt.Errorf("ошибка при сканировании вложения: %w", err)
+		}
+		attachments = append(attachments, *attachment)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("ошибка при итерации по вложениям: %w", err)
+	}
+	return attachments, nil
+}
+
+func scanAttachment(row categoryScanner) (*models.Attachment, error) {
+	attachment := &models.Attachment{}
+	var postID, commentID sql.NullInt64
+	var thumbnailKey sql.NullString
+	err := row.Scan(
+		&attachment.ID,
+		&attachment.UploaderID,
+		&postID,
+		&commentID,
+		&attachment.Filename,
+		&attachment.ContentType,
+		&attachment.Size,
+		&attachment.StorageKey,
+		&thumbnailKey,
+		&attachment.Width,
+		&attachment.Height,
+		&attachment.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	attachment.PostID = intPtr(postID)
+	attachment.CommentID = intPtr(commentID)
+	attachment.ThumbnailKey = thumbnailKey.String
+	return attachment, nil
+}
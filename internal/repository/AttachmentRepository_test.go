@@ -0,0 +1,205 @@
+package repository
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"ForumService/internal/models"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupAttachmentRepositoryTest(t *testing.T) (AttachmentRepository, sqlmock.Sqlmock, func()) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+
+	return NewAttachmentRepository(db), mock, func() { db.Close() }
+}
+
+var attachmentRowColumns = []string{"id", "uploader_id", "post_id", "comment_id", "filename", "content_type", "size",
+	"storage_key", "thumbnail_key", "width", "height", "created_at"}
+
+func TestAttachmentRepository_Create(t *testing.T) {
+	repo, mock, cleanup := setupAttachmentRepositoryTest(t)
+	defer cleanup()
+
+	createdAt := time.Now()
+	attachment := &models.Attachment{
+		UploaderID:  2,
+		Filename:    "screen.png",
+		ContentType: "image/png",
+		Size:        1024,
+		StorageKey:  "0123456789abcdef0123456789abcdef",
+		Width:       800,
+		Height:      600,
+	}
+	mock.ExpectQuery("INSERT INTO attachments").
+		WithArgs(2, "screen.png", "image/png", int64(1024), "0123456789abcdef0123456789abcdef", "", 800, 600).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow(5, createdAt))
+
+	err := repo.Create(attachment)
+	require.NoError(t, err)
+	assert.Equal(t, 5, attachment.ID)
+	assert.Equal(t, createdAt, attachment.CreatedAt)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAttachmentRepository_Create_UnknownUploader(t *testing.T) {
+	repo, mock, cleanup := setupAttachmentRepositoryTest(t)
+	defer cleanup()
+
+	mock.ExpectQuery("INSERT INTO attachments").
+		WillReturnError(&pq.Error{Code: "23503"})
+
+	err := repo.Create(&models.Attachment{UploaderID: 99})
+	assert.ErrorIs(t, err, ErrUserNotFound)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAttachmentRepository_GetByID(t *testing.T) {
+	repo, mock, cleanup := setupAttachmentRepositoryTest(t)
+	defer cleanup()
+
+	createdAt := time.Now()
+	mock.ExpectQuery("SELECT (.+) FROM attachments WHERE id = \\$1").
+		WithArgs(5).
+		WillReturnRows(sqlmock.NewRows(attachmentRowColumns).
+			AddRow(5, 2, 3, nil, "screen.png", "image/png", 1024, "key", "key-thumb", 800, 600, createdAt))
+
+	attachment, err := repo.GetByID(5)
+	require.NoError(t, err)
+	postID := 3
+	assert.Equal(t, &models.Attachment{
+		ID:           5,
+		UploaderID:   2,
+		PostID:       &postID,
+		Filename:     "screen.png",
+		ContentType:  "image/png",
+		Size:         1024,
+		StorageKey:   "key",
+		ThumbnailKey: "key-thumb",
+		Width:        800,
+		Height:       600,
+		CreatedAt:    createdAt,
+	}, attachment)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAttachmentRepository_GetByID_NotFound(t *testing.T) {
+	repo, mock, cleanup := setupAttachmentRepositoryTest(t)
+	defer cleanup()
+
+	mock.ExpectQuery("SELECT (.+) FROM attachments WHERE id = \\$1").
+		WithArgs(5).
+		WillReturnError(sql.ErrNoRows)
+
+	_, err := repo.GetByID(5)
+	assert.ErrorIs(t, err, ErrAttachmentNotFound)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAttachmentRepository_Link(t *testing.T) {
+	repo, mock, cleanup := setupAttachmentRepositoryTest(t)
+	defer cleanup()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT id FROM comments WHERE id = \\$1 FOR SHARE").
+		WithArgs(4).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(4))
+	mock.ExpectExec("UPDATE attachments SET comment_id = \\$1\\s+WHERE id = ANY\\(\\$2\\)").
+		WithArgs(4, pq.Array([]int{5, 6}), 2).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectExec("UPDATE attachments SET comment_id = NULL WHERE comment_id = \\$1 AND NOT \\(id = ANY\\(\\$2\\)\\)").
+		WithArgs(4, pq.Array([]int{5, 6})).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	err := repo.Link(models.AttachmentTargetComment, 4, 2, []int{5, 6})
+	require.NoError(t, err)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAttachmentRepository_Link_ForeignAttachment(t *testing.T) {
+	repo, mock, cleanup := setupAttachmentRepositoryTest(t)
+	defer cleanup()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT id FROM posts WHERE id = \\$1 FOR SHARE").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectExec("UPDATE attachments SET post_id = \\$1").
+		WithArgs(1, pq.Array([]int{5, 6}), 2).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectRollback()
+
+	err := repo.Link(models.AttachmentTargetPost, 1, 2, []int{5, 6})
+	assert.ErrorIs(t, err, ErrAttachmentNotFound)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAttachmentRepository_Link_TargetNotFound(t *testing.T) {
+	repo, mock, cleanup := setupAttachmentRepositoryTest(t)
+	defer cleanup()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT id FROM posts WHERE id = \\$1 FOR SHARE").
+		WithArgs(1).
+		WillReturnError(sql.ErrNoRows)
+	mock.ExpectRollback()
+
+	err := repo.Link(models.AttachmentTargetPost, 1, 2, nil)
+	assert.ErrorIs(t, err, ErrPostNotFound)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAttachmentRepository_GetByTargets(t *testing.T) {
+	repo, mock, cleanup := setupAttachmentRepositoryTest(t)
+	defer cleanup()
+
+	createdAt := time.Now()
+	mock.ExpectQuery("SELECT (.+) FROM attachments\\s+WHERE post_id = ANY\\(\\$1\\)\\s+ORDER BY created_at, id").
+		WithArgs(pq.Array([]int{1, 2})).
+		WillReturnRows(sqlmock.NewRows(attachmentRowColumns).
+			AddRow(5, 2, 1, nil, "a.png", "image/png", 10, "k1", nil, 1, 1, createdAt).
+			AddRow(6, 2, 1, nil, "b.log", "text/plain; charset=utf-8", 20, "k2", nil, 0, 0, createdAt))
+
+	attachments, err := repo.GetByTargets(models.AttachmentTargetPost, []int{1, 2})
+	require.NoError(t, err)
+	require.Len(t, attachments[1], 2)
+	assert.Equal(t, "a.png", attachments[1][0].Filename)
+	assert.Equal(t, "b.log", attachments[1][1].Filename)
+	assert.Empty(t, attachments[1][1].ThumbnailKey)
+	assert.Empty(t, attachments[2])
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAttachmentRepository_GetByTargets_Empty(t *testing.T) {
+	repo, mock, cleanup := setupAttachmentRepositoryTest(t)
+	defer cleanup()
+
+	attachments, err := repo.GetByTargets(models.AttachmentTargetComment, nil)
+	require.NoError(t, err)
+	assert.Empty(t, attachments)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAttachmentRepository_DeleteOrphans(t *testing.T) {
+	repo, mock, cleanup := setupAttachmentRepositoryTest(t)
+	defer cleanup()
+
+	before := time.Now().Add(-time.Hour)
+	mock.ExpectQuery("DELETE FROM attachments(.+)FOR UPDATE SKIP LOCKED(.+)RETURNING").
+		WithArgs(before, 100).
+		WillReturnRows(sqlmock.NewRows(attachmentRowColumns).
+			AddRow(5, 2, nil, nil, "a.png", "image/png", 10, "k1", "k1-thumb", 1, 1, before))
+
+	orphans, err := repo.DeleteOrphans(before, 100)
+	require.NoError(t, err)
+	require.Len(t, orphans, 1)
+	assert.Equal(t, "k1-thumb", orphans[0].ThumbnailKey)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,166 @@
+package repository
+
+import (
+	"ForumService/internal/models"
+	"database/sql"
+	"fmt"
+
+	"github.com/lib/pq"
+)
+
+// targetTables - таблицы записей, которые добавляют в закладки и на которые подписываются, по виду записи
+var targetTables = map[string]struct {
+	table    string
+	notFound error
+}{
+	models.BookmarkTargetThread: {table: "threads", notFound: ErrThreadNotFound},
+	models.BookmarkTargetPost:   {table: "posts", notFound: ErrPostNotFound},
+}
+
+// lockTarget блокирует тред или пост на удаление до конца транзакции. У закладок и подписок
+// нет внешнего ключа на запись, и без блокировки они могли бы пережить удаленную запись.
+func lockTarget(tx *sql.Tx, targetType string, targetID int) error {
+	target, ok := targetTables[targetType]
+	if !ok {
+		return fmt.Errorf("неизвестный вид записи: %s", targetType)
+	}
+
+	var lockedID int
+	err := tx.QueryRow(`SELECT id FROM `+target.table+` WHERE id = $1 FOR SHARE`, targetID).Scan(&lockedID)
+	if err == sql.ErrNoRows {
+		return target.notFound
+	}
+	if err != nil {
+		return fmt.Errorf("ошибка при блокировке записи: %w", err)
+	}
+	return nil
+}
+
+type bookmarkRepository struct {
+	db *sql.DB
+}
+
+func NewBookmarkRepository(db *sql.DB) BookmarkRepository {
+	return &bookmarkRepository{db: db}
+}
+
+// Save блокирует запись на удаление, пока сохраняется закладка
+func (r *bookmarkRepository) Save(bookmark *models.Bookmark) error {
+	if _, ok := targetTables[bookmark.TargetType]; !ok {
+		return fmt.Errorf("неизвестный вид записи для закладки: %s", bookmark.TargetType)
+	}
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("ошибка при начале транзакции: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err = lockTarget(tx, bookmark.TargetType, bookmark.TargetID); err != nil {
+		return err
+	}
+
+	err = tx.QueryRow(`
+		INSERT INTO bookmarks (user_id, target_type, target_id, note) VALUES ($1, $2, $3, $4)
+		ON CONFLICT (user_id, target_type, target_id) DO UPDATE SET note = EXCLUDED.note
+		RETURNING created_at`,
+		bookmark.UserID, bookmark.TargetType, bookmark.TargetID, bookmark.Note).Scan(&bookmark.CreatedAt)
+	if isForeignKeyViolation(err) {
+		return ErrUserNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("ошибка при сохранении закладки: %w", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("ошибка при фиксации транзакции: %w", err)
+	}
+	return nil
+}
+
+func (r *bookmarkRepository) Delete(userID int, targetType string, targetID int) error {
+	result, err := r.db.Exec(`DELETE FROM bookmarks WHERE user_id = $1 AND target_type = $2 AND target_id = $3`,
+		userID, targetType, targetID)
+	if err != nil {
+		return fmt.Errorf("ошибка при удалении закладки: %w", err)
+	}
+	return checkRowsAffected(result, ErrBookmarkNotFound)
+}
+
+// GetByUser скрывает заголовок и тред закладок на записи из закрытых разделов тем же условием
+// на действующую read_role, что и поиск. Сами закладки остаются в списке, чтобы их можно было удалить.
+func (r *bookmarkRepository) GetByUser(userID int, role models.Role, limit, offset int) ([]models.Bookmark, int, error) {
+	readRoles := make([]string, 0)
+	for _, allowed := range role.AllowedRoles() {
+		readRoles = append(readRoles, string(allowed))
+	}
+
+	rows, err := r.db.Query(`WITH RECURSIVE`+categoryReadRolesCTE+`
+		SELECT b.target_type, b.target_id, b.note, b.created_at,
+		       CASE WHEN t.category_id IS NULL OR cat.read_role = ANY($4)
+		            THEN COALESCE(NULLIF(p.title, ''), t.title, '') ELSE '' END,
+		       CASE WHEN t.category_id IS NULL OR cat.read_role = ANY($4)
+		            THEN COALESCE(t.id, 0) ELSE 0 END,
+		       COUNT(*) OVER() AS total
+		FROM bookmarks b
+		LEFT JOIN posts p ON b.target_type = 'post' AND p.id = b.target_id
+		LEFT JOIN threads t ON t.id = CASE WHEN b.target_type = 'thread' THEN b.target_id ELSE p.thread_id END
+		LEFT JOIN category_read_roles cat ON cat.id = t.category_id
+		WHERE b.user_id = $1
+		ORDER BY b.created_at DESC, b.target_type, b.target_id DESC
+		LIMIT $2 OFFSET $3`,
+		userID, limit, offset, pq.Array(readRoles))
+	if err != nil {
+		return nil, 0, fmt.Errorf("ошибка при получении закладок: %w", err)
+	}
+	defer rows.Close()
+
+	bookmarks := make([]models.Bookmark, 0)
+	total := 0
+	for rows.Next() {
+		bookmark := models.Bookmark{UserID: userID}
+		err := rows.Scan(
+			&bookmark.TargetType,
+			&bookmark.TargetID,
+			&bookmark.Note,
+			&bookmark.CreatedAt,
+			&bookmark.Title,
+			&bookmark.ThreadID,
+			&total,
+		)
+		if err != nil {
+			return nil, 0, fmt.Errorf("ошибка при сканировании закладки: %w", err)
+		}
+		bookmarks = append(bookmarks, bookmark)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("ошибка при итерации по закладкам: %w", err)
+	}
+	return bookmarks, total, nil
+}
+
+func (r *bookmarkRepository) GetBookmarked(userID int, targetType string, targetIDs []int) (map[int]bool, error) {
+	bookmarked := make(map[int]bool)
+	if len(targetIDs) == 0 {
+		return bookmarked, nil
+	}
+
+	rows, err := r.db.Query(`SELECT target_id FROM bookmarks WHERE user_id = $1 AND target_type = $2 AND target_id = ANY($3)`,
+		userID, targetType, pq.Array(targetIDs))
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при получении закладок: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var targetID int
+		if err := rows.Scan(&targetID); err != nil {
+			return nil, fmt.Errorf("ошибка при сканировании закладки: %w", err)
+		}
+		bookmarked[targetID] = true
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("ошибка при итерации по закладкам: %w", err)
+	}
+	return bookmarked, nil
+}
@@ -0,0 +1,125 @@
+package repository
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"ForumService/internal/models"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupBookmarkRepositoryTest(t *testing.T) (BookmarkRepository, sqlmock.Sqlmock, func()) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+
+	return NewBookmarkRepository(db), mock, func() { db.Close() }
+}
+
+func TestBookmarkRepository_Save(t *testing.T) {
+	repo, mock, cleanup := setupBookmarkRepositoryTest(t)
+	defer cleanup()
+
+	createdAt := time.Now()
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT id FROM posts WHERE id = \\$1 FOR SHARE").
+		WithArgs(5).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(5))
+	mock.ExpectQuery("INSERT INTO bookmarks \\(user_id, target_type, target_id, note\\) VALUES \\(\\$1, \\$2, \\$3, \\$4\\) ON CONFLICT \\(user_id, target_type, target_id\\) DO UPDATE SET note = EXCLUDED.note RETURNING created_at").
+		WithArgs(2, "post", 5, "прочитать").
+		WillReturnRows(sqlmock.NewRows([]string{"created_at"}).AddRow(createdAt))
+	mock.ExpectCommit()
+
+	bookmark := &models.Bookmark{UserID: 2, TargetType: models.BookmarkTargetPost, TargetID: 5, Note: "прочитать"}
+	require.NoError(t, repo.Save(bookmark))
+	assert.Equal(t, createdAt, bookmark.CreatedAt)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestBookmarkRepository_Save_NotFound(t *testing.T) {
+	repo, mock, cleanup := setupBookmarkRepositoryTest(t)
+	defer cleanup()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT id FROM threads WHERE id = \\$1 FOR SHARE").
+		WithArgs(99).
+		WillReturnError(sql.ErrNoRows)
+	mock.ExpectRollback()
+
+	err := repo.Save(&models.Bookmark{UserID: 2, TargetType: models.BookmarkTargetThread, TargetID: 99})
+	assert.ErrorIs(t, err, ErrThreadNotFound)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestBookmarkRepository_Save_UnknownUser(t *testing.T) {
+	repo, mock, cleanup := setupBookmarkRepositoryTest(t)
+	defer cleanup()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT id FROM threads WHERE id = \\$1 FOR SHARE").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectQuery("INSERT INTO bookmarks").
+		WillReturnError(&pq.Error{Code: "23503"})
+	mock.ExpectRollback()
+
+	err := repo.Save(&models.Bookmark{UserID: 42, TargetType: models.BookmarkTargetThread, TargetID: 1})
+	assert.ErrorIs(t, err, ErrUserNotFound)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestBookmarkRepository_Delete(t *testing.T) {
+	repo, mock, cleanup := setupBookmarkRepositoryTest(t)
+	defer cleanup()
+
+	mock.ExpectExec("DELETE FROM bookmarks WHERE user_id = \\$1 AND target_type = \\$2 AND target_id = \\$3").
+		WithArgs(2, "thread", 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("DELETE FROM bookmarks").
+		WithArgs(2, "thread", 99).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	assert.NoError(t, repo.Delete(2, models.BookmarkTargetThread, 1))
+	assert.ErrorIs(t, repo.Delete(2, models.BookmarkTargetThread, 99), ErrBookmarkNotFound)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestBookmarkRepository_GetByUser(t *testing.T) {
+	repo, mock, cleanup := setupBookmarkRepositoryTest(t)
+	defer cleanup()
+
+	now := time.Now()
+	mock.ExpectQuery("FROM bookmarks b LEFT JOIN posts p ON b.target_type = 'post' AND p.id = b.target_id LEFT JOIN threads t ON .* LEFT JOIN category_read_roles cat ON cat.id = t.category_id WHERE b.user_id = \\$1 ORDER BY b.created_at DESC, b.target_type, b.target_id DESC LIMIT \\$2 OFFSET \\$3").
+		WithArgs(2, 20, 20, pq.Array([]string{"guest", "user"})).
+		WillReturnRows(sqlmock.NewRows([]string{"target_type", "target_id", "note", "created_at", "title", "thread_id", "total"}).
+			AddRow("post", 5, "прочитать", now, "Тред", 1, 22).
+			AddRow("thread", 1, "", now.Add(-time.Minute), "Тред", 1, 22))
+
+	bookmarks, total, err := repo.GetByUser(2, models.RoleUser, 20, 20)
+	require.NoError(t, err)
+	assert.Equal(t, 22, total)
+	require.Len(t, bookmarks, 2)
+	assert.Equal(t, models.Bookmark{UserID: 2, TargetType: "post", TargetID: 5, Note: "прочитать", CreatedAt: now, Title: "Тред", ThreadID: 1}, bookmarks[0])
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestBookmarkRepository_GetBookmarked(t *testing.T) {
+	repo, mock, cleanup := setupBookmarkRepositoryTest(t)
+	defer cleanup()
+
+	mock.ExpectQuery("SELECT target_id FROM bookmarks WHERE user_id = \\$1 AND target_type = \\$2 AND target_id = ANY\\(\\$3\\)").
+		WithArgs(2, "post", pq.Array([]int{1, 2, 3})).
+		WillReturnRows(sqlmock.NewRows([]string{"target_id"}).AddRow(2))
+
+	bookmarked, err := repo.GetBookmarked(2, models.BookmarkTargetPost, []int{1, 2, 3})
+	require.NoError(t, err)
+	assert.Equal(t, map[int]bool{2: true}, bookmarked)
+
+	bookmarked, err = repo.GetBookmarked(2, models.BookmarkTargetPost, nil)
+	require.NoError(t, err)
+	assert.Empty(t, bookmarked)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,445 @@
+package repository
+
+import (
+	"ForumService/internal/cache"
+	"ForumService/internal/models"
+	"time"
+)
+
+// allThreadsKey - единственный ключ кэша списка тредов
+const allThreadsKey = 0
+
+// CacheConfig - настройки кэша чтения тредов и постов
+type CacheConfig struct {
+	// Size - максимальное число записей в каждом из кэшей
+	Size int
+	// TTL - время жизни записи, 0 - до инвалидации или вытеснения
+	TTL time.Duration
+}
+
+// RepositoryCache хранит кэши чтения тредов и постов. Кэши общие для обоих
+// декораторов: удаление треда каскадно удаляет посты, и их записи тоже нужно сбросить.
+type RepositoryCache struct {
+	threads     *cache.Cache[int, *models.Thread]
+	threadList  *cache.Cache[int, []*models.Thread]
+	posts       *cache.Cache[int, *models.Post]
+	threadPosts *cache.Cache[int, []*models.Post]
+}
+
+func NewRepositoryCache(cfg CacheConfig) *RepositoryCache {
+	return &RepositoryCache{
+		threads:     cache.New[int, *models.Thread](cfg.Size, cfg.TTL),
+		threadList:  cache.New[int, []*models.Thread](1, cfg.TTL),
+		posts:       cache.New[int, *models.Post](cfg.Size, cfg.TTL),
+		threadPosts: cache.New[int, []*models.Post](cfg.Size, cfg.TTL),
+	}
+}
+
+// Stats возвращает счетчики всех кэшей по их именам
+func (c *RepositoryCache) Stats() map[string]cache.Stats {
+	return map[string]cache.Stats{
+		"threads":      c.threads.Stats(),
+		"thread_list":  c.threadList.Stats(),
+		"posts":        c.posts.Stats(),
+		"thread_posts": c.threadPosts.Stats(),
+	}
+}
+
+// Threads оборачивает репозиторий тредов кэшем чтения
+func (c *RepositoryCache) Threads(next ThreadRepository) ThreadRepository {
+	return &cachingThreadRepository{next: next, cache: c}
+}
+
+// Posts оборачивает репозиторий постов кэшем чтения
+func (c *RepositoryCache) Posts(next PostRepository) PostRepository {
+	return &cachingPostRepository{next: next, cache: c}
+}
+
+// Comments оборачивает репозиторий комментариев: сами комментарии не кэшируются,
+// но их изменение сдвигает счетчики закэшированных тредов и постов
+func (c *RepositoryCache) Comments(next CommentRepository) CommentRepository {
+	return &cachingCommentRepository{CommentRepository: next, cache: c}
+}
+
+// Categories оборачивает репозиторий разделов: разделы не кэшируются,
+// но закэшированные треды хранят название своего раздела
+func (c *RepositoryCache) Categories(next CategoryRepository) CategoryRepository {
+	return &cachingCategoryRepository{CategoryRepository: next, cache: c}
+}
+
+// Votes оборачивает репозиторий голосов: голоса не кэшируются,
+// но закэшированные посты хранят итоги голосования
+func (c *RepositoryCache) Votes(next VoteRepository) VoteRepository {
+	return &cachingVoteRepository{VoteRepository: next, cache: c}
+}
+
+// Views оборачивает репозиторий просмотров: просмотры не кэшируются,
+// но закэшированные треды и посты хранят счетчики просмотров
+func (c *RepositoryCache) Views(next ViewRepository) ViewRepository {
+	return &cachingViewRepository{ViewRepository: next, cache: c}
+}
+
+// invalidateCounters сбрасывает все записи со счетчиками комментариев. Тред и пост
+// по аргументам изменения известны не всегда, а запись случается гораздо реже чтения.
+func (c *RepositoryCache) invalidateCounters() {
+	c.threads.Purge()
+	c.threadList.Invalidate(allThreadsKey)
+	c.posts.Purge()
+	c.threadPosts.Purge()
+}
+
+// cachingThreadRepository кэширует тред по ID и список тредов.
+// Вызывающий код меняет полученные модели, поэтому наружу всегда отдаются копии.
+type cachingThreadRepository struct {
+	next  ThreadRepository
+	cache *RepositoryCache
+}
+
+func (r *cachingThreadRepository) Create(thread *models.Thread) error {
+	if err := r.next.Create(thread); err != nil {
+		return err
+	}
+	r.cache.threadList.Invalidate(allThreadsKey)
+	return nil
+}
+
+// GetByID перечитывает тред, если у закэшированной копии истек срок закрепления
+func (r *cachingThreadRepository) GetByID(id int) (*models.Thread, error) {
+	load := func() (*models.Thread, error) {
+		return r.next.GetByID(id)
+	}
+	thread, err := r.cache.threads.GetOrLoad(id, load)
+	if err == nil && pinExpired(thread, time.Now()) {
+		r.cache.threads.Invalidate(id)
+		thread, err = r.cache.threads.GetOrLoad(id, load)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return cloneThread(thread), nil
+}
+
+func (r *cachingThreadRepository) Update(thread *models.Thread) error {
+	err := r.next.Update(thread)
+	// Конфликт версий означает, что в кэше может лежать устаревший тред
+	r.cache.threads.Invalidate(thread.ID)
+	r.cache.threadList.Invalidate(allThreadsKey)
+	return err
+}
+
+func (r *cachingThreadRepository) Lock(id int, userID int, reason string) error {
+	err := r.next.Lock(id, userID, reason)
+	r.invalidate(id)
+	return err
+}
+
+func (r *cachingThreadRepository) Unlock(id int) error {
+	err := r.next.Unlock(id)
+	r.invalidate(id)
+	return err
+}
+
+func (r *cachingThreadRepository) Pin(id int, order int, until *time.Time) error {
+	err := r.next.Pin(id, order, until)
+	r.invalidate(id)
+	return err
+}
+
+func (r *cachingThreadRepository) Unpin(id int) error {
+	err := r.next.Unpin(id)
+	r.invalidate(id)
+	return err
+}
+
+func (r *cachingThreadRepository) SetAcceptedPost(id int, postID *int) error {
+	err := r.next.SetAcceptedPost(id, postID)
+	r.invalidate(id)
+	return err
+}
+
+func (r *cachingThreadRepository) invalidate(id int) {
+	r.cache.threads.Invalidate(id)
+	r.cache.threadList.Invalidate(allThreadsKey)
+}
+
+func (r *cachingThreadRepository) Delete(id int) error {
+	err := r.next.Delete(id)
+	r.cache.threads.Invalidate(id)
+	r.cache.threadList.Invalidate(allThreadsKey)
+	// Посты треда удаляются каскадно
+	r.cache.threadPosts.Invalidate(id)
+	r.cache.posts.Purge()
+	return err
+}
+
+// GetAllThreads перечитывает список, если у одного из тредов истек срок закрепления:
+// порядок закэшированного списка в этом случае устарел
+func (r *cachingThreadRepository) GetAllThreads() ([]*models.Thread, error) {
+	threads, err := r.cache.threadList.GetOrLoad(allThreadsKey, r.next.GetAllThreads)
+	if err == nil && anyPinExpired(threads, time.Now()) {
+		r.cache.threadList.Invalidate(allThreadsKey)
+		threads, err = r.cache.threadList.GetOrLoad(allThreadsKey, r.next.GetAllThreads)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return cloneThreads(threads), nil
+}
+
+// GetThreadWithPosts не кэшируется: в ответ входят комментарии, которые меняются в обход этого репозитория
+func (r *cachingThreadRepository) GetThreadWithPosts(threadID int) (*models.Thread, []models.Post, map[int][]models.Comment, error) {
+	return r.next.GetThreadWithPosts(threadID)
+}
+
+// GetByCategoryID не кэшируется: ключи раздела пришлось бы сбрасывать при каждом изменении тредов
+func (r *cachingThreadRepository) GetByCategoryID(categoryID int) ([]*models.Thread, error) {
+	return r.next.GetByCategoryID(categoryID)
+}
+
+// cachingPostRepository кэширует пост по ID и посты треда. Запросы с комментариями
+// и историей правок идут мимо кэша.
+type cachingPostRepository struct {
+	next  PostRepository
+	cache *RepositoryCache
+}
+
+func (r *cachingPostRepository) SavePost(post *models.Post) error {
+	if err := r.next.SavePost(post); err != nil {
+		return err
+	}
+	// Ключ 0 - выборка всех постов
+	r.cache.threadPosts.Invalidate(post.ThreadID, 0)
+	// Новый пост меняет счетчики и последний пост треда
+	r.cache.threads.Invalidate(post.ThreadID)
+	r.cache.threadList.Invalidate(allThreadsKey)
+	return nil
+}
+
+func (r *cachingPostRepository) GetPostByID(id int) (*models.Post, error) {
+	post, err := r.cache.posts.GetOrLoad(id, func() (*models.Post, error) {
+		return r.next.GetPostByID(id)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return clonePost(post), nil
+}
+
+func (r *cachingPostRepository) GetPostWithComments(postID int) (*models.Post, []models.Comment, error) {
+	return r.next.GetPostWithComments(postID)
+}
+
+func (r *cachingPostRepository) GetPostsWithCommentsByThreadID(threadID int) ([]models.Post, map[int][]models.Comment, error) {
+	return r.next.GetPostsWithCommentsByThreadID(threadID)
+}
+
+// UpdatePost сбрасывает пост и все списки постов: тред поста по аргументам неизвестен
+func (r *cachingPostRepository) UpdatePost(post *models.Post, postID int, editorID int, reason string) error {
+	err := r.next.UpdatePost(post, postID, editorID, reason)
+	r.cache.posts.Invalidate(postID)
+	r.cache.threadPosts.Purge()
+	return err
+}
+
+// DeletePost сбрасывает и счетчики тредов: тред поста по аргументам неизвестен
+func (r *cachingPostRepository) DeletePost(postID int) error {
+	err := r.next.DeletePost(postID)
+	r.cache.invalidateCounters()
+	return err
+}
+
+func (r *cachingPostRepository) GetByThreadID(threadID int) ([]*models.Post, error) {
+	posts, err := r.cache.threadPosts.GetOrLoad(threadID, func() ([]*models.Post, error) {
+		return r.next.GetByThreadID(threadID)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return clonePosts(posts), nil
+}
+
+func (r *cachingPostRepository) GetPostRevisions(postID int) ([]models.PostRevision, error) {
+	return r.next.GetPostRevisions(postID)
+}
+
+func (r *cachingPostRepository) GetPostRevisionByID(postID int, revisionID int) (*models.PostRevision, error) {
+	return r.next.GetPostRevisionByID(postID, revisionID)
+}
+
+// GetPostRefs и GetReplies не кэшируются: ответы появляются при создании постов других авторов
+func (r *cachingPostRepository) GetPostRefs(ids []int) (map[int]models.PostRef, error) {
+	return r.next.GetPostRefs(ids)
+}
+
+func (r *cachingPostRepository) GetReplies(postIDs []int) (map[int][]models.PostRef, error) {
+	return r.next.GetReplies(postIDs)
+}
+
+func (r *cachingPostRepository) Pin(postID int) error {
+	err := r.next.Pin(postID)
+	r.invalidate(postID)
+	return err
+}
+
+func (r *cachingPostRepository) Unpin(postID int) error {
+	err := r.next.Unpin(postID)
+	r.invalidate(postID)
+	return err
+}
+
+// invalidate сбрасывает пост и все списки постов: тред поста по аргументам неизвестен
+func (r *cachingPostRepository) invalidate(postID int) {
+	r.cache.posts.Invalidate(postID)
+	r.cache.threadPosts.Purge()
+}
+
+// cachingCommentRepository сбрасывает кэш счетчиков при создании и удалении комментариев
+type cachingCommentRepository struct {
+	CommentRepository
+	cache *RepositoryCache
+}
+
+func (r *cachingCommentRepository) SaveComment(comment *models.Comment) error {
+	if err := r.CommentRepository.SaveComment(comment); err != nil {
+		return err
+	}
+	r.cache.invalidateCounters()
+	return nil
+}
+
+func (r *cachingCommentRepository) DeleteComment(id int) error {
+	err := r.CommentRepository.DeleteComment(id)
+	r.cache.invalidateCounters()
+	return err
+}
+
+func (r *cachingCommentRepository) TombstoneComment(id int) error {
+	err := r.CommentRepository.TombstoneComment(id)
+	r.cache.invalidateCounters()
+	return err
+}
+
+func (r *cachingCommentRepository) RemoveComment(id int) (bool, error) {
+	tombstoned, err := r.CommentRepository.RemoveComment(id)
+	r.cache.invalidateCounters()
+	return tombstoned, err
+}
+
+// cachingVoteRepository сбрасывает пост и списки постов при голосовании за пост.
+// Комментарии не кэшируются, поэтому голоса за них проходят без изменений.
+type cachingVoteRepository struct {
+	VoteRepository
+	cache *RepositoryCache
+}
+
+func (r *cachingVoteRepository) VotePost(postID, userID, value int) (models.VoteResult, error) {
+	result, err := r.VoteRepository.VotePost(postID, userID, value)
+	r.invalidate(postID)
+	return result, err
+}
+
+func (r *cachingVoteRepository) UnvotePost(postID, userID int) (models.VoteResult, error) {
+	result, err := r.VoteRepository.UnvotePost(postID, userID)
+	r.invalidate(postID)
+	return result, err
+}
+
+func (r *cachingVoteRepository) invalidate(postID int) {
+	r.cache.posts.Invalidate(postID)
+	r.cache.threadPosts.Purge()
+}
+
+// cachingViewRepository сбрасывает треды и посты, просмотры которых сброшены в хранилище
+type cachingViewRepository struct {
+	ViewRepository
+	cache *RepositoryCache
+}
+
+// AddViews сбрасывает списки постов целиком: треды постов по аргументам неизвестны.
+// Если сброс не удался, счетчики в хранилище не изменились и кэш остается.
+func (r *cachingViewRepository) AddViews(threadViews map[int]int, postViews map[int]int) error {
+	if err := r.ViewRepository.AddViews(threadViews, postViews); err != nil {
+		return err
+	}
+	if len(threadViews) > 0 {
+		r.cache.threads.Invalidate(viewIDs(threadViews)...)
+		r.cache.threadList.Invalidate(allThreadsKey)
+	}
+	if len(postViews) > 0 {
+		r.cache.posts.Invalidate(viewIDs(postViews)...)
+		r.cache.threadPosts.Purge()
+	}
+	return nil
+}
+
+func viewIDs(views map[int]int) []int {
+	ids := make([]int, 0, len(views))
+	for id := range views {
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+// cachingCategoryRepository сбрасывает треды при переименовании раздела
+type cachingCategoryRepository struct {
+	CategoryRepository
+	cache *RepositoryCache
+}
+
+func (r *cachingCategoryRepository) Update(category *models.Category) error {
+	err := r.CategoryRepository.Update(category)
+	r.cache.threads.Purge()
+	r.cache.threadList.Invalidate(allThreadsKey)
+	return err
+}
+
+func cloneThread(thread *models.Thread) *models.Thread {
+	copied := *thread
+	copied.CategoryID = cloneIntPtr(thread.CategoryID)
+	copied.LockedByID = cloneIntPtr(thread.LockedByID)
+	copied.AcceptedPostID = cloneIntPtr(thread.AcceptedPostID)
+	return &copied
+}
+
+// pinExpired сообщает, что тред закэширован закрепленным, но срок закрепления уже прошел
+func pinExpired(thread *models.Thread, now time.Time) bool {
+	return thread.Pinned && !pinActive(thread, now)
+}
+
+func anyPinExpired(threads []*models.Thread, now time.Time) bool {
+	for _, thread := range threads {
+		if pinExpired(thread, now) {
+			return true
+		}
+	}
+	return false
+}
+
+func cloneThreads(threads []*models.Thread) []*models.Thread {
+	if threads == nil {
+		return nil
+	}
+	copied := make([]*models.Thread, len(threads))
+	for i, thread := range threads {
+		copied[i] = cloneThread(thread)
+	}
+	return copied
+}
+
+func clonePost(post *models.Post) *models.Post {
+	copied := *post
+	copied.Comments = append([]models.Comment(nil), post.Comments...)
+	copied.ReplyToPostID = cloneIntPtr(post.ReplyToPostID)
+	return &copied
+}
+
+func clonePosts(posts []*models.Post) []*models.Post {
+	if posts == nil {
+		return nil
+	}
+	copied := make([]*models.Post, len(posts))
+	for i, post := range posts {
+		copied[i] = clonePost(post)
+	}
+	return copied
+}
@@ -0,0 +1,405 @@
+package repository
+
+import (
+	"ForumService/internal/models"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// countingThreadRepository считает обращения к нижележащему репозиторию
+type countingThreadRepository struct {
+	ThreadRepository
+	getByID       int
+	getAllThreads int
+}
+
+func (r *countingThreadRepository) GetByID(id int) (*models.Thread, error) {
+	r.getByID++
+	return r.ThreadRepository.GetByID(id)
+}
+
+func (r *countingThreadRepository) GetAllThreads() ([]*models.Thread, error) {
+	r.getAllThreads++
+	return r.ThreadRepository.GetAllThreads()
+}
+
+type countingPostRepository struct {
+	PostRepository
+	getPostByID   int
+	getByThreadID int
+}
+
+func (r *countingPostRepository) GetPostByID(id int) (*models.Post, error) {
+	r.getPostByID++
+	return r.PostRepository.GetPostByID(id)
+}
+
+func (r *countingPostRepository) GetByThreadID(threadID int) ([]*models.Post, error) {
+	r.getByThreadID++
+	return r.PostRepository.GetByThreadID(threadID)
+}
+
+func setupCachingRepositoryTest(t *testing.T) (ThreadRepository, PostRepository, *countingThreadRepository, *countingPostRepository, *RepositoryCache, int) {
+	store := NewMemoryStore()
+	store.EnsureUser(1, "alice", "user")
+
+	threads := &countingThreadRepository{ThreadRepository: NewMemoryThreadRepository(store)}
+	posts := &countingPostRepository{PostRepository: NewMemoryPostRepository(store)}
+	repoCache := NewRepositoryCache(CacheConfig{Size: 100, TTL: time.Minute})
+
+	return repoCache.Threads(threads), repoCache.Posts(posts), threads, posts, repoCache, 1
+}
+
+func TestCachingThreadRepository_GetByID(t *testing.T) {
+	threadRepo, _, counter, _, repoCache, userID := setupCachingRepositoryTest(t)
+
+	thread := &models.Thread{Title: "Тред", AuthorID: userID}
+	require.NoError(t, threadRepo.Create(thread))
+
+	first, err := threadRepo.GetByID(thread.ID)
+	require.NoError(t, err)
+	first.Title = "Изменено вызывающим кодом"
+
+	second, err := threadRepo.GetByID(thread.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Тред", second.Title, "кэш отдает копии")
+	assert.Equal(t, 1, counter.getByID)
+
+	stats := repoCache.Stats()["threads"]
+	assert.Equal(t, uint64(1), stats.Hits)
+	assert.Equal(t, uint64(1), stats.Misses)
+
+	_, err = threadRepo.GetByID(thread.ID + 100)
+	assert.ErrorIs(t, err, ErrThreadNotFound)
+}
+
+func TestCachingThreadRepository_Invalidation(t *testing.T) {
+	threadRepo, postRepo, counter, postCounter, _, userID := setupCachingRepositoryTest(t)
+
+	thread := &models.Thread{Title: "Тред", AuthorID: userID}
+	require.NoError(t, threadRepo.Create(thread))
+
+	threads, err := threadRepo.GetAllThreads()
+	require.NoError(t, err)
+	require.Len(t, threads, 1)
+
+	require.NoError(t, threadRepo.Create(&models.Thread{Title: "Второй", AuthorID: userID}))
+	threads, err = threadRepo.GetAllThreads()
+	require.NoError(t, err)
+	assert.Len(t, threads, 2, "создание сбрасывает список")
+	assert.Equal(t, 2, counter.getAllThreads)
+
+	cached, err := threadRepo.GetByID(thread.ID)
+	require.NoError(t, err)
+	cached.Title = "Переименован"
+	require.NoError(t, threadRepo.Update(cached))
+
+	updated, err := threadRepo.GetByID(thread.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Переименован", updated.Title)
+	assert.Equal(t, 2, updated.Version)
+
+	require.NoError(t, threadRepo.Lock(thread.ID, userID, ""))
+	locked, err := threadRepo.GetByID(thread.ID)
+	require.NoError(t, err)
+	assert.True(t, locked.Locked, "закрытие сбрасывает тред")
+	require.NoError(t, threadRepo.Unlock(thread.ID))
+	threads, err = threadRepo.GetAllThreads()
+	require.NoError(t, err)
+	assert.False(t, threads[1].Locked, "открытие сбрасывает список")
+
+	post := &models.Post{ThreadID: thread.ID, AuthorID: userID, Content: "Пост"}
+	require.NoError(t, postRepo.SavePost(post))
+	_, err = postRepo.GetPostByID(post.ID)
+	require.NoError(t, err)
+
+	require.NoError(t, threadRepo.Delete(thread.ID))
+	_, err = threadRepo.GetByID(thread.ID)
+	assert.ErrorIs(t, err, ErrThreadNotFound)
+	_, err = postRepo.GetPostByID(post.ID)
+	assert.ErrorIs(t, err, ErrPostNotFound, "посты удаленного треда сбрасываются")
+	assert.Equal(t, 2, postCounter.getPostByID)
+}
+
+func TestCachingThreadRepository_Pins(t *testing.T) {
+	threadRepo, _, counter, _, _, userID := setupCachingRepositoryTest(t)
+
+	thread := &models.Thread{Title: "Объявление", AuthorID: userID}
+	require.NoError(t, threadRepo.Create(thread))
+	require.NoError(t, threadRepo.Create(&models.Thread{Title: "Обсуждение", AuthorID: userID}))
+	threads, err := threadRepo.GetAllThreads()
+	require.NoError(t, err)
+	assert.Equal(t, "Обсуждение", threads[0].Title)
+	_, err = threadRepo.GetByID(thread.ID)
+	require.NoError(t, err)
+
+	until := time.Now().Add(20 * time.Millisecond)
+	require.NoError(t, threadRepo.Pin(thread.ID, 0, &until))
+	threads, err = threadRepo.GetAllThreads()
+	require.NoError(t, err)
+	assert.Equal(t, thread.ID, threads[0].ID, "закрепление сбрасывает список")
+	pinned, err := threadRepo.GetByID(thread.ID)
+	require.NoError(t, err)
+	assert.True(t, pinned.Pinned, "закрепление сбрасывает тред")
+	loads := counter.getAllThreads
+
+	time.Sleep(30 * time.Millisecond)
+	threads, err = threadRepo.GetAllThreads()
+	require.NoError(t, err)
+	assert.Equal(t, "Обсуждение", threads[0].Title, "список с истекшим закреплением перечитывается")
+	assert.Equal(t, loads+1, counter.getAllThreads)
+	expired, err := threadRepo.GetByID(thread.ID)
+	require.NoError(t, err)
+	assert.False(t, expired.Pinned)
+
+	require.NoError(t, threadRepo.Pin(thread.ID, 0, nil))
+	require.NoError(t, threadRepo.Unpin(thread.ID))
+	unpinned, err := threadRepo.GetByID(thread.ID)
+	require.NoError(t, err)
+	assert.Nil(t, unpinned.PinnedAt, "открепление сбрасывает тред")
+}
+
+func TestCachingPostRepository_Pins(t *testing.T) {
+	threadRepo, postRepo, _, _, _, userID := setupCachingRepositoryTest(t)
+
+	thread := &models.Thread{Title: "Тред", AuthorID: userID}
+	require.NoError(t, threadRepo.Create(thread))
+	require.NoError(t, postRepo.SavePost(&models.Post{ThreadID: thread.ID, AuthorID: userID, Content: "Вопрос"}))
+	answer := &models.Post{ThreadID: thread.ID, AuthorID: userID, Content: "Ответ"}
+	require.NoError(t, postRepo.SavePost(answer))
+
+	posts, err := postRepo.GetByThreadID(thread.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Вопрос", posts[0].Content)
+	_, err = postRepo.GetPostByID(answer.ID)
+	require.NoError(t, err)
+
+	require.NoError(t, postRepo.Pin(answer.ID))
+	posts, err = postRepo.GetByThreadID(thread.ID)
+	require.NoError(t, err)
+	assert.Equal(t, answer.ID, posts[0].ID, "закрепление сбрасывает посты треда")
+	pinned, err := postRepo.GetPostByID(answer.ID)
+	require.NoError(t, err)
+	assert.True(t, pinned.Pinned, "закрепление сбрасывает пост")
+
+	require.NoError(t, postRepo.Unpin(answer.ID))
+	posts, err = postRepo.GetByThreadID(thread.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Вопрос", posts[0].Content, "открепление сбрасывает посты треда")
+}
+
+func TestCachingPostRepository_Invalidation(t *testing.T) {
+	threadRepo, postRepo, _, counter, repoCache, userID := setupCachingRepositoryTest(t)
+
+	thread := &models.Thread{Title: "Тред", AuthorID: userID}
+	require.NoError(t, threadRepo.Create(thread))
+	post := &models.Post{ThreadID: thread.ID, AuthorID: userID, Content: "Пост"}
+	require.NoError(t, postRepo.SavePost(post))
+
+	posts, err := postRepo.GetByThreadID(thread.ID)
+	require.NoError(t, err)
+	require.Len(t, posts, 1)
+	_, err = postRepo.GetByThreadID(thread.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 1, counter.getByThreadID)
+
+	require.NoError(t, postRepo.SavePost(&models.Post{ThreadID: thread.ID, AuthorID: userID, Content: "Второй"}))
+	posts, err = postRepo.GetByThreadID(thread.ID)
+	require.NoError(t, err)
+	assert.Len(t, posts, 2, "новый пост сбрасывает посты треда")
+
+	_, err = postRepo.GetPostByID(post.ID)
+	require.NoError(t, err)
+	require.NoError(t, postRepo.UpdatePost(&models.Post{Content: "Правка"}, post.ID, userID, ""))
+
+	updated, err := postRepo.GetPostByID(post.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Правка", updated.Content)
+	posts, err = postRepo.GetByThreadID(thread.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Правка", posts[0].Content)
+
+	require.NoError(t, postRepo.DeletePost(post.ID))
+	_, err = postRepo.GetPostByID(post.ID)
+	assert.ErrorIs(t, err, ErrPostNotFound)
+	posts, err = postRepo.GetByThreadID(thread.ID)
+	require.NoError(t, err)
+	assert.Len(t, posts, 1)
+
+	stats := repoCache.Stats()["thread_posts"]
+	assert.Equal(t, uint64(1), stats.Hits)
+}
+
+func TestCachingVoteRepository_InvalidatesPosts(t *testing.T) {
+	store := NewMemoryStore()
+	store.EnsureUser(1, "alice", "user")
+	store.EnsureUser(2, "bob", "user")
+	repoCache := NewRepositoryCache(CacheConfig{Size: 100, TTL: time.Minute})
+	threadRepo := repoCache.Threads(NewMemoryThreadRepository(store))
+	postRepo := repoCache.Posts(NewMemoryPostRepository(store))
+	voteRepo := repoCache.Votes(NewMemoryVoteRepository(store))
+
+	thread := &models.Thread{Title: "Тред", AuthorID: 1}
+	require.NoError(t, threadRepo.Create(thread))
+	post := &models.Post{ThreadID: thread.ID, AuthorID: 1, Content: "Пост"}
+	require.NoError(t, postRepo.SavePost(post))
+
+	_, err := postRepo.GetPostByID(post.ID)
+	require.NoError(t, err)
+	_, err = postRepo.GetByThreadID(thread.ID)
+	require.NoError(t, err)
+
+	_, err = voteRepo.VotePost(post.ID, 2, 1)
+	require.NoError(t, err)
+	stored, err := postRepo.GetPostByID(post.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 1, stored.Score, "голос сбрасывает пост")
+	posts, err := postRepo.GetByThreadID(thread.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 1, posts[0].Score, "голос сбрасывает посты треда")
+
+	_, err = voteRepo.UnvotePost(post.ID, 2)
+	require.NoError(t, err)
+	stored, err = postRepo.GetPostByID(post.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 0, stored.Score, "отзыв голоса сбрасывает пост")
+}
+
+func TestCachingViewRepository_InvalidatesCounters(t *testing.T) {
+	store := NewMemoryStore()
+	store.EnsureUser(1, "alice", "user")
+	repoCache := NewRepositoryCache(CacheConfig{Size: 100, TTL: time.Minute})
+	threadRepo := repoCache.Threads(NewMemoryThreadRepository(store))
+	postRepo := repoCache.Posts(NewMemoryPostRepository(store))
+	viewRepo := repoCache.Views(NewMemoryViewRepository(store))
+
+	thread := &models.Thread{Title: "Тред", AuthorID: 1}
+	require.NoError(t, threadRepo.Create(thread))
+	post := &models.Post{ThreadID: thread.ID, AuthorID: 1, Content: "Пост"}
+	require.NoError(t, postRepo.SavePost(post))
+
+	_, err := threadRepo.GetByID(thread.ID)
+	require.NoError(t, err)
+	_, err = threadRepo.GetAllThreads()
+	require.NoError(t, err)
+	_, err = postRepo.GetPostByID(post.ID)
+	require.NoError(t, err)
+	_, err = postRepo.GetByThreadID(thread.ID)
+	require.NoError(t, err)
+
+	require.NoError(t, viewRepo.AddViews(map[int]int{thread.ID: 3}, map[int]int{post.ID: 2}))
+
+	stored, err := threadRepo.GetByID(thread.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 3, stored.ViewCount, "сброс просмотров сбрасывает тред")
+	threads, err := threadRepo.GetAllThreads()
+	require.NoError(t, err)
+	assert.Equal(t, 3, threads[0].ViewCount)
+	storedPost, err := postRepo.GetPostByID(post.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 2, storedPost.ViewCount, "сброс просмотров сбрасывает пост")
+	posts, err := postRepo.GetByThreadID(thread.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 2, posts[0].ViewCount)
+}
+
+func TestCachingPostRepository_VersionConflictInvalidates(t *testing.T) {
+	threadRepo, postRepo, _, _, _, userID := setupCachingRepositoryTest(t)
+
+	thread := &models.Thread{Title: "Тред", AuthorID: userID}
+	require.NoError(t, threadRepo.Create(thread))
+	post := &models.Post{ThreadID: thread.ID, AuthorID: userID, Content: "Пост"}
+	require.NoError(t, postRepo.SavePost(post))
+
+	cached, err := postRepo.GetPostByID(post.ID)
+	require.NoError(t, err)
+	require.NoError(t, postRepo.UpdatePost(&models.Post{Content: "Первая правка", Version: cached.Version}, post.ID, userID, ""))
+
+	err = postRepo.UpdatePost(&models.Post{Content: "Устаревшая правка", Version: cached.Version}, post.ID, userID, "")
+	assert.ErrorIs(t, err, ErrVersionConflict)
+
+	current, err := postRepo.GetPostByID(post.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Первая правка", current.Content)
+	assert.Equal(t, 2, current.Version)
+}
+
+func TestCachingRepositories_CountersInvalidation(t *testing.T) {
+	store := NewMemoryStore()
+	store.EnsureUser(1, "alice", "user")
+	repoCache := NewRepositoryCache(CacheConfig{Size: 100, TTL: time.Minute})
+	threadRepo := repoCache.Threads(NewMemoryThreadRepository(store))
+	postRepo := repoCache.Posts(NewMemoryPostRepository(store))
+	commentRepo := repoCache.Comments(NewMemoryCommentRepository(store))
+
+	thread := &models.Thread{Title: "Тред", AuthorID: 1}
+	require.NoError(t, threadRepo.Create(thread))
+	_, err := threadRepo.GetByID(thread.ID)
+	require.NoError(t, err)
+
+	post := &models.Post{ThreadID: thread.ID, AuthorID: 1, Content: "Пост"}
+	require.NoError(t, postRepo.SavePost(post))
+	cached, err := threadRepo.GetByID(thread.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 1, cached.PostCount)
+
+	_, err = postRepo.GetPostByID(post.ID)
+	require.NoError(t, err)
+	threads, err := threadRepo.GetAllThreads()
+	require.NoError(t, err)
+	assert.Equal(t, 0, threads[0].CommentCount)
+
+	comment := &models.Comment{PostID: post.ID, AuthorID: 1, Content: "Комментарий"}
+	require.NoError(t, commentRepo.SaveComment(comment))
+
+	cachedPost, err := postRepo.GetPostByID(post.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 1, cachedPost.CommentCount)
+	threads, err = threadRepo.GetAllThreads()
+	require.NoError(t, err)
+	assert.Equal(t, 1, threads[0].CommentCount)
+
+	require.NoError(t, commentRepo.TombstoneComment(comment.ID))
+	cached, err = threadRepo.GetByID(thread.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 0, cached.CommentCount)
+
+	require.NoError(t, postRepo.DeletePost(post.ID))
+	threads, err = threadRepo.GetAllThreads()
+	require.NoError(t, err)
+	assert.Equal(t, 0, threads[0].PostCount)
+	assert.Nil(t, threads[0].LastPostAt)
+}
+
+func TestCachingRepositories_CategoryRenameInvalidatesThreads(t *testing.T) {
+	store := NewMemoryStore()
+	store.EnsureUser(1, "alice", "user")
+	repoCache := NewRepositoryCache(CacheConfig{Size: 100, TTL: time.Minute})
+	threadRepo := repoCache.Threads(NewMemoryThreadRepository(store))
+	categoryRepo := repoCache.Categories(NewMemoryCategoryRepository(store))
+
+	category := &models.Category{Name: "Go", ReadRole: models.RoleGuest, CreateRole: models.RoleUser}
+	require.NoError(t, categoryRepo.Create(category))
+	thread := &models.Thread{Title: "Тред", AuthorID: 1, CategoryID: &category.ID}
+	require.NoError(t, threadRepo.Create(thread))
+
+	cached, err := threadRepo.GetByID(thread.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Go", cached.CategoryName)
+	threads, err := threadRepo.GetAllThreads()
+	require.NoError(t, err)
+	assert.Equal(t, "Go", threads[0].CategoryName)
+
+	category.Name = "Golang"
+	require.NoError(t, categoryRepo.Update(category))
+
+	cached, err = threadRepo.GetByID(thread.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Golang", cached.CategoryName)
+	threads, err = threadRepo.GetAllThreads()
+	require.NoError(t, err)
+	assert.Equal(t, "Golang", threads[0].CategoryName)
+}
@@ -0,0 +1,155 @@
+package repository
+
+import (
+	"ForumService/internal/models"
+	"database/sql"
+	"fmt"
+)
+
+// categorySelect выбирает разделы вместе со счетчиками их собственных тредов
+const categorySelect = `
+	SELECT c.id, c.parent_id, c.name, c.description, c.position, c.read_role, c.create_role,
+		c.created_at, c.updated_at, COUNT(t.id) AS thread_count, COALESCE(SUM(t.post_count), 0) AS post_count
+	FROM categories c
+	LEFT JOIN threads t ON t.category_id = c.id`
+
+// categoryReadRolesCTE вычисляет для WITH RECURSIVE действующую read_role каждого раздела
+// так же, как models.EffectiveReadRoles: самую строгую на пути от корня. Роли в массиве
+// перечислены по возрастанию уровня доступа.
+const categoryReadRolesCTE = `
+	category_read_roles (id, read_role) AS (
+		SELECT id, read_role::text FROM categories WHERE parent_id IS NULL
+		UNION ALL
+		SELECT c.id,
+		       CASE WHEN array_position(ARRAY['guest', 'user', 'moderator', 'admin'], c.read_role::text)
+		                 > array_position(ARRAY['guest', 'user', 'moderator', 'admin'], r.read_role)
+		            THEN c.read_role::text ELSE r.read_role END
+		FROM categories c
+		JOIN category_read_roles r ON r.id = c.parent_id
+	)`
+
+type categoryRepository struct {
+	db *sql.DB
+}
+
+func NewCategoryRepository(db *sql.DB) CategoryRepository {
+	return &categoryRepository{db: db}
+}
+
+func (r *categoryRepository) Create(category *models.Category) error {
+	const query = `
+		INSERT INTO categories (parent_id, name, description, position, read_role, create_role)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, created_at, updated_at`
+	err := r.db.QueryRow(query, category.ParentID, category.Name, category.Description,
+		category.Position, category.ReadRole, category.CreateRole,
+	).Scan(&category.ID, &category.CreatedAt, &category.UpdatedAt)
+	if isForeignKeyViolation(err) {
+		return ErrCategoryNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("ошибка при создании раздела: %w", err)
+	}
+	return nil
+}
+
+func (r *categoryRepository) GetByID(id int) (*models.Category, error) {
+	row := r.db.QueryRow(categorySelect+` WHERE c.id = $1 GROUP BY c.id`, id)
+	category, err := scanCategory(row)
+	if err == sql.ErrNoRows {
+		return nil, ErrCategoryNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при получении раздела: %w", err)
+	}
+	return category, nil
+}
+
+func (r *categoryRepository) GetAll() ([]*models.Category, error) {
+	rows, err := r.db.Query(categorySelect + ` GROUP BY c.id ORDER BY c.position, c.name, c.id`)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при получении разделов: %w", err)
+	}
+	defer rows.Close()
+
+	var categories []*models.Category
+	for rows.Next() {
+		category, err := scanCategory(rows)
+		if err != nil {
+			return nil, fmt.Errorf("ошибка при сканировании раздела: %w", err)
+		}
+		categories = append(categories, category)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("ошибка при итерации по разделам: %w", err)
+	}
+	return categories, nil
+}
+
+func (r *categoryRepository) Update(category *models.Category) error {
+	const query = `
+		UPDATE categories SET parent_id = $2, name = $3, description = $4, position = $5,
+			read_role = $6, create_role = $7, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1
+		RETURNING created_at, updated_at`
+	err := r.db.QueryRow(query, category.ID, category.ParentID, category.Name, category.Description,
+		category.Position, category.ReadRole, category.CreateRole,
+	).Scan(&category.CreatedAt, &category.UpdatedAt)
+	if err == sql.ErrNoRows || isForeignKeyViolation(err) {
+		return ErrCategoryNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("ошибка при обновлении раздела: %w", err)
+	}
+	return nil
+}
+
+// Delete удаляет пустой раздел. Треды и подразделы ссылаются на раздел с ON DELETE RESTRICT,
+// поэтому удаление непустого раздела отклоняет сама база.
+func (r *categoryRepository) Delete(id int) error {
+	result, err := r.db.Exec(`DELETE FROM categories WHERE id = $1`, id)
+	if isForeignKeyViolation(err) {
+		return ErrCategoryNotEmpty
+	}
+	if err != nil {
+		return fmt.Errorf("ошибка при удалении раздела: %w", err)
+	}
+	return checkRowsAffected(result, ErrCategoryNotFound)
+}
+
+// categoryScanner - общий интерфейс sql.Row и sql.Rows
+type categoryScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanCategory(row categoryScanner) (*models.Category, error) {
+	category := &models.Category{}
+	var parentID sql.NullInt64
+	err := row.Scan(
+		&category.ID,
+		&parentID,
+		&category.Name,
+		&category.Description,
+		&category.Position,
+		&category.ReadRole,
+		&category.CreateRole,
+		&category.CreatedAt,
+		&category.UpdatedAt,
+		&category.ThreadCount,
+		&category.PostCount,
+	)
+	if err != nil {
+		return nil, err
+	}
+	category.ParentID = intPtr(parentID)
+	return category, nil
+}
+
+// intPtr переводит nullable-колонку в указатель
+func intPtr(value sql.NullInt64) *int {
+	if !value.Valid {
+		return nil
+	}
+	id := int(value.Int64)
+	return &id
+}
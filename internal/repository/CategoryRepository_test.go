@@ -0,0 +1,142 @@
+package repository
+
+import (
+	"ForumService/internal/models"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var categoryColumns = []string{"id", "parent_id", "name", "description", "position", "read_role", "create_role",
+	"created_at", "updated_at", "thread_count", "post_count"}
+
+func setupCategoryRepositoryTest(t *testing.T) (CategoryRepository, sqlmock.Sqlmock) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	return NewCategoryRepository(db), mock
+}
+
+func TestCategoryRepository_Create(t *testing.T) {
+	repo, mock := setupCategoryRepositoryTest(t)
+
+	parentID := 1
+	category := &models.Category{ParentID: &parentID, Name: "Go", ReadRole: models.RoleGuest, CreateRole: models.RoleUser}
+	now := time.Now()
+	mock.ExpectQuery("INSERT INTO categories \\(parent_id, name, description, position, read_role, create_role\\)").
+		WithArgs(&parentID, "Go", "", 0, models.RoleGuest, models.RoleUser).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "created_at", "updated_at"}).AddRow(2, now, now))
+
+	require.NoError(t, repo.Create(category))
+	assert.Equal(t, 2, category.ID)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCategoryRepository_Create_ParentNotFound(t *testing.T) {
+	repo, mock := setupCategoryRepositoryTest(t)
+
+	parentID := 42
+	mock.ExpectQuery("INSERT INTO categories").
+		WillReturnError(&pq.Error{Code: "23503"})
+
+	err := repo.Create(&models.Category{ParentID: &parentID, Name: "Go"})
+	assert.ErrorIs(t, err, ErrCategoryNotFound)
+}
+
+func TestCategoryRepository_GetByID(t *testing.T) {
+	repo, mock := setupCategoryRepositoryTest(t)
+
+	now := time.Now()
+	mock.ExpectQuery("FROM categories c LEFT JOIN threads t ON t.category_id = c.id WHERE c.id = \\$1 GROUP BY c.id").
+		WithArgs(2).
+		WillReturnRows(sqlmock.NewRows(categoryColumns).
+			AddRow(2, 1, "Go", "Язык Go", 0, "guest", "user", now, now, 3, 12))
+
+	category, err := repo.GetByID(2)
+	require.NoError(t, err)
+	require.NotNil(t, category.ParentID)
+	assert.Equal(t, 1, *category.ParentID)
+	assert.Equal(t, models.RoleGuest, category.ReadRole)
+	assert.Equal(t, 3, category.ThreadCount)
+	assert.Equal(t, 12, category.PostCount)
+}
+
+func TestCategoryRepository_GetByID_NotFound(t *testing.T) {
+	repo, mock := setupCategoryRepositoryTest(t)
+
+	mock.ExpectQuery("FROM categories c").
+		WithArgs(2).
+		WillReturnError(sql.ErrNoRows)
+
+	_, err := repo.GetByID(2)
+	assert.ErrorIs(t, err, ErrCategoryNotFound)
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestCategoryRepository_GetAll(t *testing.T) {
+	repo, mock := setupCategoryRepositoryTest(t)
+
+	now := time.Now()
+	mock.ExpectQuery("GROUP BY c.id ORDER BY c.position, c.name, c.id").
+		WillReturnRows(sqlmock.NewRows(categoryColumns).
+			AddRow(1, nil, "Языки", "", 0, "guest", "user", now, now, 0, 0).
+			AddRow(2, 1, "Go", "", 0, "user", "user", now, now, 1, 4))
+
+	categories, err := repo.GetAll()
+	require.NoError(t, err)
+	require.Len(t, categories, 2)
+	assert.Nil(t, categories[0].ParentID)
+	assert.Equal(t, 1, *categories[1].ParentID)
+}
+
+func TestCategoryRepository_Update(t *testing.T) {
+	repo, mock := setupCategoryRepositoryTest(t)
+
+	category := &models.Category{ID: 2, Name: "Golang", Position: 3, ReadRole: models.RoleUser, CreateRole: models.RoleUser}
+	now := time.Now()
+	mock.ExpectQuery("UPDATE categories SET parent_id = \\$2, name = \\$3, description = \\$4, position = \\$5, read_role = \\$6, create_role = \\$7").
+		WithArgs(2, nil, "Golang", "", 3, models.RoleUser, models.RoleUser).
+		WillReturnRows(sqlmock.NewRows([]string{"created_at", "updated_at"}).AddRow(now, now))
+
+	require.NoError(t, repo.Update(category))
+	assert.Equal(t, now, category.UpdatedAt)
+}
+
+func TestCategoryRepository_Update_NotFound(t *testing.T) {
+	repo, mock := setupCategoryRepositoryTest(t)
+
+	mock.ExpectQuery("UPDATE categories").
+		WillReturnError(sql.ErrNoRows)
+
+	err := repo.Update(&models.Category{ID: 2, Name: "Golang"})
+	assert.ErrorIs(t, err, ErrCategoryNotFound)
+}
+
+func TestCategoryRepository_Delete(t *testing.T) {
+	repo, mock := setupCategoryRepositoryTest(t)
+
+	mock.ExpectExec("DELETE FROM categories WHERE id = \\$1").
+		WithArgs(2).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	require.NoError(t, repo.Delete(2))
+
+	mock.ExpectExec("DELETE FROM categories WHERE id = \\$1").
+		WithArgs(3).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	assert.ErrorIs(t, repo.Delete(3), ErrCategoryNotFound)
+}
+
+func TestCategoryRepository_Delete_NotEmpty(t *testing.T) {
+	repo, mock := setupCategoryRepositoryTest(t)
+
+	mock.ExpectExec("DELETE FROM categories WHERE id = \\$1").
+		WithArgs(2).
+		WillReturnError(&pq.Error{Code: "23503"})
+
+	assert.ErrorIs(t, repo.Delete(2), ErrCategoryNotEmpty)
+}
@@ -14,24 +14,63 @@ func NewCommentRepository(db *sql.DB) CommentRepository {
 	return &CommentRepositoryImpl{db: db}
 }
 
+// SaveComment создает комментарий и в той же транзакции обновляет счетчики поста и треда.
+// В закрытый тред комментарий не добавляется: возвращается ErrThreadLocked.
 func (r *CommentRepositoryImpl) SaveComment(comment *models.Comment) error {
-	const query = `INSERT INTO comments (post_id, author_id, content, created_at) VALUES ($1, $2, $3, NOW()) RETURNING id`
-	return r.db.QueryRow(query, comment.PostID, comment.AuthorID, comment.Content).Scan(&comment.ID)
+	const query = `INSERT INTO comments (post_id, author_id, parent_comment_id, depth, content, created_at) VALUES ($1, $2, $3, $4, $5, NOW()) RETURNING id`
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("ошибка при начале транзакции: %w", err)
+	}
+	defer tx.Rollback()
+
+	threadID, err := lockOpenPostThread(tx, comment.PostID)
+	if err != nil {
+		return err
+	}
+	if err = tx.QueryRow(query, comment.PostID, comment.AuthorID, comment.ParentCommentID, comment.Depth, comment.Content).Scan(&comment.ID); err != nil {
+		return err
+	}
+	if err = updatePostCommentCount(tx, comment.PostID, 1); err != nil {
+		return err
+	}
+	if err = updateThreadCounters(tx, threadID, 0, 1); err != nil {
+		return err
+	}
+	return tx.Commit()
 }
 
 func (r *CommentRepositoryImpl) GetCommentByID(id int) (*models.Comment, error) {
-	const query = `SELECT id, post_id, author_id, content, created_at FROM comments WHERE id = $1`
+	const query = `SELECT id, post_id, parent_comment_id, depth, author_id, content, created_at, updated_at, deleted_at, upvotes, downvotes FROM comments WHERE id = $1`
 	comment := &models.Comment{}
-	err := r.db.QueryRow(query, id).Scan(&comment.ID, &comment.PostID, &comment.AuthorID, &comment.Content, &comment.CreatedAt)
+	var parentID sql.NullInt64
+	var deletedAt sql.NullTime
+	err := r.db.QueryRow(query, id).Scan(&comment.ID, &comment.PostID, &parentID, &comment.Depth, &comment.AuthorID, &comment.Content, &comment.CreatedAt, &comment.UpdatedAt, &deletedAt, &comment.Upvotes, &comment.Downvotes)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return nil, fmt.Errorf("комментарий не найден")
+			return nil, ErrCommentNotFound
 		}
-		return nil, fmt.Errorf("ошибка при получении комментария: %v", err)
+		return nil, fmt.Errorf("ошибка при получении комментария: %w", err)
 	}
+	fillCommentState(comment, parentID, deletedAt)
 	return comment, nil
 }
 
+// UpdateComment обновляет текст комментария и время его изменения
+func (r *CommentRepositoryImpl) UpdateComment(comment *models.Comment) error {
+	const query = `UPDATE comments SET content = $1, updated_at = NOW() WHERE id = $2 RETURNING updated_at`
+	err := r.db.QueryRow(query, comment.Content, comment.ID).Scan(&comment.UpdatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return ErrCommentNotFound
+		}
+		return fmt.Errorf("ошибка при обновлении комментария: %w", err)
+	}
+	comment.Edited = comment.UpdatedAt.After(comment.CreatedAt)
+	return nil
+}
+
 //func (r *CommentRepositoryImpl) List() ([]models.Comment, error) {
 //	const query = `SELECT * FROM comments ORDER BY created_at DESC RETURNING id, post_id, author_id, content, created_at`
 //	rows, err := r.db.Query(query)
@@ -49,30 +88,58 @@ func (r *CommentRepositoryImpl) GetCommentByID(id int) (*models.Comment, error)
 //	}
 //	return allComments, nil
 //}
-//func (r *CommentRepositoryImpl) UpdateUser(comment *models.Comment, id int) error {
-//	query := `UPDATE comments SET content = $1, updated_at = NOW() WHERE id = $2 RETURNING updated_at`
-//	return r.db.QueryRow(query, comment.Content, id).Scan(&comment.UpdatedAt)
-//}
 
+// DeleteComment удаляет комментарий вместе с ответами и уменьшает счетчики поста и треда
 func (r *CommentRepositoryImpl) DeleteComment(id int) error {
-	const query = `DELETE FROM comments WHERE id = $1 RETURNING id`
-	result, err := r.db.Exec(query, id)
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("ошибка при начале транзакции: %w", err)
+	}
+	defer tx.Rollback()
+
+	postID, threadID, err := lockCommentThread(tx, id)
 	if err != nil {
 		return err
 	}
-	rows, err := result.RowsAffected()
+	if err = deleteCommentTx(tx, id, postID, threadID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// deleteCommentTx удаляет комментарий с ответами в транзакции, где уже заблокирован тред
+func deleteCommentTx(tx *sql.Tx, id, postID, threadID int) error {
+	// Ответы удаляются каскадно, поэтому из счетчиков вычитается вся ветка без заглушек
+	const branchQuery = `
+		WITH RECURSIVE branch AS (
+			SELECT id, deleted_at FROM comments WHERE id = $1
+			UNION ALL
+			SELECT c.id, c.deleted_at FROM comments c JOIN branch b ON c.parent_comment_id = b.id
+		)
+		SELECT COUNT(*) FROM branch WHERE deleted_at IS NULL`
+	var deleted int
+	if err := tx.QueryRow(branchQuery, id).Scan(&deleted); err != nil {
+		return fmt.Errorf("ошибка при подсчете ответов на комментарий: %w", err)
+	}
+
+	const query = `DELETE FROM comments WHERE id = $1 RETURNING id`
+	result, err := tx.Exec(query, id)
 	if err != nil {
 		return err
 	}
-	if rows == 0 {
-		return sql.ErrNoRows
+	if err = checkRowsAffected(result, ErrCommentNotFound); err != nil {
+		return err
 	}
-	return nil
+
+	if err = updatePostCommentCount(tx, postID, -deleted); err != nil {
+		return err
+	}
+	return updateThreadCounters(tx, threadID, 0, -deleted)
 }
 
 func (r *CommentRepositoryImpl) GetCommentsByPostID(postID int) ([]models.Comment, error) {
 	const query = `
-        SELECT id, post_id, author_id, content, created_at
+        SELECT id, post_id, parent_comment_id, depth, author_id, content, created_at, updated_at, deleted_at, upvotes, downvotes
         FROM comments
         WHERE post_id = $1
         ORDER BY created_at ASC`
@@ -86,16 +153,25 @@ func (r *CommentRepositoryImpl) GetCommentsByPostID(postID int) ([]models.Commen
 	var comments []models.Comment
 	for rows.Next() {
 		var comment models.Comment
+		var parentID sql.NullInt64
+		var deletedAt sql.NullTime
 		err := rows.Scan(
 			&comment.ID,
 			&comment.PostID,
+			&parentID,
+			&comment.Depth,
 			&comment.AuthorID,
 			&comment.Content,
 			&comment.CreatedAt,
+			&comment.UpdatedAt,
+			&deletedAt,
+			&comment.Upvotes,
+			&comment.Downvotes,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("ошибка при сканировании комментария: %v", err)
 		}
+		fillCommentState(&comment, parentID, deletedAt)
 		comments = append(comments, comment)
 	}
 
@@ -103,5 +179,163 @@ func (r *CommentRepositoryImpl) GetCommentsByPostID(postID int) ([]models.Commen
 		return nil, fmt.Errorf("ошибка после сканирования комментариев: %v", err)
 	}
 
-	return comments, nil
+	return flattenCommentTree(comments), nil
+}
+
+const hasRepliesQuery = `SELECT EXISTS(SELECT 1 FROM comments WHERE parent_comment_id = $1)`
+
+// HasReplies проверяет, есть ли у комментария ответы
+func (r *CommentRepositoryImpl) HasReplies(id int) (bool, error) {
+	var exists bool
+	if err := r.db.QueryRow(hasRepliesQuery, id).Scan(&exists); err != nil {
+		return false, fmt.Errorf("ошибка при проверке ответов на комментарий: %v", err)
+	}
+	return exists, nil
+}
+
+// TombstoneComment помечает комментарий удаленным и стирает его текст,
+// сохраняя строку, чтобы ответы на него остались на своих местах.
+// Заглушка не учитывается в счетчиках поста и треда.
+func (r *CommentRepositoryImpl) TombstoneComment(id int) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("ошибка при начале транзакции: %w", err)
+	}
+	defer tx.Rollback()
+
+	postID, threadID, err := lockCommentThread(tx, id)
+	if err != nil {
+		return err
+	}
+	if err = tombstoneCommentTx(tx, id, postID, threadID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// RemoveComment удаляет комментарий без ответов, а комментарий с ответами заменяет
+// заглушкой и сообщает об этом. Ответы сохраняются под той же блокировкой треда,
+// поэтому между проверкой и удалением новый ответ не появится и не удалится каскадом.
+func (r *CommentRepositoryImpl) RemoveComment(id int) (bool, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return false, fmt.Errorf("ошибка при начале транзакции: %w", err)
+	}
+	defer tx.Rollback()
+
+	postID, threadID, err := lockCommentThread(tx, id)
+	if err != nil {
+		return false, err
+	}
+
+	var hasReplies bool
+	if err = tx.QueryRow(hasRepliesQuery, id).Scan(&hasReplies); err != nil {
+		return false, fmt.Errorf("ошибка при проверке ответов на комментарий: %v", err)
+	}
+	if hasReplies {
+		err = tombstoneCommentTx(tx, id, postID, threadID)
+	} else {
+		err = deleteCommentTx(tx, id, postID, threadID)
+	}
+	if err != nil {
+		return false, err
+	}
+	return hasReplies, tx.Commit()
+}
+
+// tombstoneCommentTx заменяет комментарий заглушкой в транзакции, где уже заблокирован тред
+func tombstoneCommentTx(tx *sql.Tx, id, postID, threadID int) error {
+	const query = `UPDATE comments SET content = '', deleted_at = NOW() WHERE id = $1 AND deleted_at IS NULL`
+	result, err := tx.Exec(query, id)
+	if err != nil {
+		return fmt.Errorf("ошибка при удалении комментария: %v", err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	// Комментарий уже был заглушкой, счетчики не меняются
+	if rows == 0 {
+		return nil
+	}
+
+	// Вложения заглушки больше не показываются, их удалит сборщик мусора
+	if _, err = tx.Exec(`UPDATE attachments SET comment_id = NULL WHERE comment_id = $1`, id); err != nil {
+		return fmt.Errorf("ошибка при отвязке вложений комментария: %w", err)
+	}
+	if err = updatePostCommentCount(tx, postID, -1); err != nil {
+		return err
+	}
+	return updateThreadCounters(tx, threadID, 0, -1)
+}
+
+// lockCommentThread находит пост и тред комментария и блокирует счетчики треда
+func lockCommentThread(tx *sql.Tx, commentID int) (int, int, error) {
+	var postID int
+	err := tx.QueryRow(`SELECT post_id FROM comments WHERE id = $1`, commentID).Scan(&postID)
+	if err == sql.ErrNoRows {
+		return 0, 0, ErrCommentNotFound
+	}
+	if err != nil {
+		return 0, 0, fmt.Errorf("ошибка при получении комментария: %w", err)
+	}
+	threadID, err := lockPostThread(tx, postID)
+	if err != nil {
+		return 0, 0, err
+	}
+	return postID, threadID, nil
+}
+
+// fillCommentState заполняет вычисляемые поля комментария из nullable-колонок
+func fillCommentState(comment *models.Comment, parentID sql.NullInt64, deletedAt sql.NullTime) {
+	if parentID.Valid {
+		id := int(parentID.Int64)
+		comment.ParentCommentID = &id
+	}
+	comment.Deleted = deletedAt.Valid
+	if comment.Deleted {
+		comment.Content = ""
+	}
+	comment.Edited = !comment.Deleted && comment.UpdatedAt.After(comment.CreatedAt)
+	comment.Score = comment.Upvotes - comment.Downvotes
+}
+
+// flattenCommentTree упорядочивает комментарии в порядке обхода дерева в глубину:
+// каждый ответ идет сразу после своего родителя, а Path содержит цепочку ID от корня.
+// Комментарии, чей родитель отсутствует в выборке, считаются корневыми.
+func flattenCommentTree(comments []models.Comment) []models.Comment {
+	if len(comments) == 0 {
+		return comments
+	}
+
+	known := make(map[int]bool, len(comments))
+	for _, comment := range comments {
+		known[comment.ID] = true
+	}
+
+	children := make(map[int][]int)
+	var roots []int
+	for i, comment := range comments {
+		if comment.ParentCommentID != nil && known[*comment.ParentCommentID] {
+			children[*comment.ParentCommentID] = append(children[*comment.ParentCommentID], i)
+		} else {
+			roots = append(roots, i)
+		}
+	}
+
+	result := make([]models.Comment, 0, len(comments))
+	var walk func(idx int, path []int)
+	walk = func(idx int, path []int) {
+		comment := comments[idx]
+		comment.Path = append(append([]int{}, path...), comment.ID)
+		result = append(result, comment)
+		for _, child := range children[comment.ID] {
+			walk(child, comment.Path)
+		}
+	}
+	for _, idx := range roots {
+		walk(idx, nil)
+	}
+
+	return result
 }
@@ -1,7 +1,7 @@
 package repository
 
 import (
-	_"database/sql"
+	"database/sql"
 	"ForumService/internal/models"
 	"testing"
 	"time"
@@ -33,13 +33,51 @@ func TestCommentRepository_SaveComment(t *testing.T) {
 		Content:  "Test Comment",
 	}
 
+	mock.ExpectBegin()
+	expectPostThreadLookup(mock, comment.PostID, 7)
+	expectOpenThreadLock(mock, 7)
 	mock.ExpectQuery("INSERT INTO comments").
-		WithArgs(comment.PostID, comment.AuthorID, comment.Content).
+		WithArgs(comment.PostID, comment.AuthorID, nil, 0, comment.Content).
 		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	expectPostCommentCountUpdate(mock, comment.PostID, 1)
+	expectThreadCountersUpdate(mock, 7, 0, 1)
+	mock.ExpectCommit()
 
 	err := repo.SaveComment(comment)
 	require.NoError(t, err)
 	assert.Equal(t, 1, comment.ID)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCommentRepository_SaveComment_PostNotFound(t *testing.T) {
+	repo, mock, cleanup := setupCommentRepositoryTest(t)
+	defer cleanup()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT thread_id FROM posts WHERE id = \\$1").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"thread_id"}))
+	mock.ExpectRollback()
+
+	err := repo.SaveComment(&models.Comment{PostID: 1, AuthorID: 1, Content: "Test Comment"})
+	assert.ErrorIs(t, err, ErrPostNotFound)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCommentRepository_SaveComment_ThreadLocked(t *testing.T) {
+	repo, mock, cleanup := setupCommentRepositoryTest(t)
+	defer cleanup()
+
+	mock.ExpectBegin()
+	expectPostThreadLookup(mock, 1, 7)
+	mock.ExpectQuery("SELECT locked FROM threads WHERE id = \\$1 FOR UPDATE").
+		WithArgs(7).
+		WillReturnRows(sqlmock.NewRows([]string{"locked"}).AddRow(true))
+	mock.ExpectRollback()
+
+	err := repo.SaveComment(&models.Comment{PostID: 1, AuthorID: 1, Content: "Test Comment"})
+	assert.ErrorIs(t, err, ErrThreadLocked)
+	require.NoError(t, mock.ExpectationsWereMet())
 }
 
 func TestCommentRepository_GetCommentByID(t *testing.T) {
@@ -54,10 +92,10 @@ func TestCommentRepository_GetCommentByID(t *testing.T) {
 		CreatedAt: time.Now(),
 	}
 
-	mock.ExpectQuery("SELECT id, post_id, author_id, content, created_at FROM comments WHERE id = \\$1").
+	mock.ExpectQuery("SELECT id, post_id, parent_comment_id, depth, author_id, content, created_at, updated_at, deleted_at, upvotes, downvotes FROM comments WHERE id = \\$1").
 		WithArgs(1).
-		WillReturnRows(sqlmock.NewRows([]string{"id", "post_id", "author_id", "content", "created_at"}).
-			AddRow(expectedComment.ID, expectedComment.PostID, expectedComment.AuthorID, expectedComment.Content, expectedComment.CreatedAt))
+		WillReturnRows(sqlmock.NewRows([]string{"id", "post_id", "parent_comment_id", "depth", "author_id", "content", "created_at", "updated_at", "deleted_at", "upvotes", "downvotes"}).
+			AddRow(expectedComment.ID, expectedComment.PostID, nil, 0, expectedComment.AuthorID, expectedComment.Content, expectedComment.CreatedAt, expectedComment.CreatedAt, nil, 3, 1))
 
 	comment, err := repo.GetCommentByID(1)
 	require.NoError(t, err)
@@ -65,18 +103,108 @@ func TestCommentRepository_GetCommentByID(t *testing.T) {
 	assert.Equal(t, expectedComment.PostID, comment.PostID)
 	assert.Equal(t, expectedComment.AuthorID, comment.AuthorID)
 	assert.Equal(t, expectedComment.Content, comment.Content)
+	assert.Nil(t, comment.ParentCommentID)
+	assert.False(t, comment.Edited)
+	assert.False(t, comment.Deleted)
+	assert.Equal(t, 3, comment.Upvotes)
+	assert.Equal(t, 1, comment.Downvotes)
+	assert.Equal(t, 2, comment.Score)
+}
+
+func TestCommentRepository_GetCommentByID_Tombstone(t *testing.T) {
+	repo, mock, cleanup := setupCommentRepositoryTest(t)
+	defer cleanup()
+
+	createdAt := time.Now().Add(-time.Hour)
+	mock.ExpectQuery("SELECT id, post_id, parent_comment_id, depth, author_id, content, created_at, updated_at, deleted_at, upvotes, downvotes FROM comments WHERE id = \\$1").
+		WithArgs(2).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "post_id", "parent_comment_id", "depth", "author_id", "content", "created_at", "updated_at", "deleted_at", "upvotes", "downvotes"}).
+			AddRow(2, 1, 1, 1, 1, "", createdAt, time.Now(), time.Now(), 0, 0))
+
+	comment, err := repo.GetCommentByID(2)
+	require.NoError(t, err)
+	require.NotNil(t, comment.ParentCommentID)
+	assert.Equal(t, 1, *comment.ParentCommentID)
+	assert.Equal(t, 1, comment.Depth)
+	assert.True(t, comment.Deleted)
+	assert.False(t, comment.Edited)
+}
+
+func TestCommentRepository_UpdateComment(t *testing.T) {
+	repo, mock, cleanup := setupCommentRepositoryTest(t)
+	defer cleanup()
+
+	createdAt := time.Now().Add(-time.Hour)
+	updatedAt := time.Now()
+	comment := &models.Comment{
+		ID:        1,
+		Content:   "Updated Comment",
+		CreatedAt: createdAt,
+	}
+
+	mock.ExpectQuery("UPDATE comments SET content = \\$1, updated_at = NOW\\(\\) WHERE id = \\$2 RETURNING updated_at").
+		WithArgs(comment.Content, comment.ID).
+		WillReturnRows(sqlmock.NewRows([]string{"updated_at"}).AddRow(updatedAt))
+
+	err := repo.UpdateComment(comment)
+	require.NoError(t, err)
+	assert.Equal(t, updatedAt, comment.UpdatedAt)
+	assert.True(t, comment.Edited)
+}
+
+func TestCommentRepository_UpdateComment_NotFound(t *testing.T) {
+	repo, mock, cleanup := setupCommentRepositoryTest(t)
+	defer cleanup()
+
+	comment := &models.Comment{ID: 1, Content: "Updated Comment"}
+
+	mock.ExpectQuery("UPDATE comments SET content").
+		WithArgs(comment.Content, comment.ID).
+		WillReturnError(sql.ErrNoRows)
+
+	err := repo.UpdateComment(comment)
+	require.Error(t, err)
+	assert.ErrorIs(t, err, ErrCommentNotFound)
 }
 
 func TestCommentRepository_DeleteComment(t *testing.T) {
 	repo, mock, cleanup := setupCommentRepositoryTest(t)
 	defer cleanup()
 
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT post_id FROM comments WHERE id = \\$1").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"post_id"}).AddRow(3))
+	expectPostThreadLookup(mock, 3, 7)
+	expectThreadCountersLock(mock, 7)
+	mock.ExpectQuery("WITH RECURSIVE branch AS").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(2))
 	mock.ExpectExec("DELETE FROM comments WHERE id = \\$1 RETURNING id").
 		WithArgs(1).
 		WillReturnResult(sqlmock.NewResult(0, 1))
+	expectPostCommentCountUpdate(mock, 3, -2)
+	expectThreadCountersUpdate(mock, 7, 0, -2)
+	mock.ExpectCommit()
 
 	err := repo.DeleteComment(1)
 	require.NoError(t, err)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCommentRepository_DeleteComment_NotFound(t *testing.T) {
+	repo, mock, cleanup := setupCommentRepositoryTest(t)
+	defer cleanup()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT post_id FROM comments WHERE id = \\$1").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"post_id"}))
+	mock.ExpectRollback()
+
+	err := repo.DeleteComment(1)
+	assert.ErrorIs(t, err, ErrCommentNotFound)
+	require.NoError(t, mock.ExpectationsWereMet())
 }
 
 func TestCommentRepository_GetCommentsByPostID(t *testing.T) {
@@ -100,12 +228,12 @@ func TestCommentRepository_GetCommentsByPostID(t *testing.T) {
 		},
 	}
 
-	rows := sqlmock.NewRows([]string{"id", "post_id", "author_id", "content", "created_at"})
+	rows := sqlmock.NewRows([]string{"id", "post_id", "parent_comment_id", "depth", "author_id", "content", "created_at", "updated_at", "deleted_at", "upvotes", "downvotes"})
 	for _, comment := range expectedComments {
-		rows.AddRow(comment.ID, comment.PostID, comment.AuthorID, comment.Content, comment.CreatedAt)
+		rows.AddRow(comment.ID, comment.PostID, nil, 0, comment.AuthorID, comment.Content, comment.CreatedAt, comment.CreatedAt, nil, 0, 0)
 	}
 
-	mock.ExpectQuery("SELECT id, post_id, author_id, content, created_at FROM comments WHERE post_id = \\$1 ORDER BY created_at ASC").
+	mock.ExpectQuery("SELECT id, post_id, parent_comment_id, depth, author_id, content, created_at, updated_at, deleted_at, upvotes, downvotes FROM comments WHERE post_id = \\$1 ORDER BY created_at ASC").
 		WithArgs(1).
 		WillReturnRows(rows)
 
@@ -116,4 +244,149 @@ func TestCommentRepository_GetCommentsByPostID(t *testing.T) {
 		assert.Equal(t, expectedComments[i].ID, comment.ID)
 		assert.Equal(t, expectedComments[i].Content, comment.Content)
 	}
-} 
\ No newline at end of file
+}
+
+func TestCommentRepository_GetCommentsByPostID_Tree(t *testing.T) {
+	repo, mock, cleanup := setupCommentRepositoryTest(t)
+	defer cleanup()
+
+	now := time.Now()
+	rows := sqlmock.NewRows([]string{"id", "post_id", "parent_comment_id", "depth", "author_id", "content", "created_at", "updated_at", "deleted_at", "upvotes", "downvotes"}).
+		AddRow(1, 1, nil, 0, 1, "", now, now, now, 0, 0).
+		AddRow(2, 1, nil, 0, 2, "Second root", now, now, nil, 0, 0).
+		AddRow(3, 1, 1, 1, 2, "Reply to first", now, now, nil, 0, 0).
+		AddRow(4, 1, 3, 2, 1, "Reply to reply", now, now, nil, 0, 0)
+
+	mock.ExpectQuery("SELECT id, post_id, parent_comment_id, depth, author_id, content, created_at, updated_at, deleted_at, upvotes, downvotes FROM comments WHERE post_id = \\$1 ORDER BY created_at ASC").
+		WithArgs(1).
+		WillReturnRows(rows)
+
+	comments, err := repo.GetCommentsByPostID(1)
+	require.NoError(t, err)
+	require.Len(t, comments, 4)
+
+	ids := make([]int, len(comments))
+	for i, comment := range comments {
+		ids[i] = comment.ID
+	}
+	assert.Equal(t, []int{1, 3, 4, 2}, ids)
+	assert.Equal(t, []int{1, 3, 4}, comments[2].Path)
+	assert.Equal(t, 2, comments[2].Depth)
+	assert.True(t, comments[0].Deleted)
+}
+
+func TestCommentRepository_HasReplies(t *testing.T) {
+	repo, mock, cleanup := setupCommentRepositoryTest(t)
+	defer cleanup()
+
+	mock.ExpectQuery("SELECT EXISTS\\(SELECT 1 FROM comments WHERE parent_comment_id = \\$1\\)").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+
+	hasReplies, err := repo.HasReplies(1)
+	require.NoError(t, err)
+	assert.True(t, hasReplies)
+}
+
+func TestCommentRepository_TombstoneComment(t *testing.T) {
+	repo, mock, cleanup := setupCommentRepositoryTest(t)
+	defer cleanup()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT post_id FROM comments WHERE id = \\$1").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"post_id"}).AddRow(3))
+	expectPostThreadLookup(mock, 3, 7)
+	expectThreadCountersLock(mock, 7)
+	mock.ExpectExec("UPDATE comments SET content = '', deleted_at = NOW\\(\\) WHERE id = \\$1 AND deleted_at IS NULL").
+		WithArgs(1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("UPDATE attachments SET comment_id = NULL WHERE comment_id = \\$1").
+		WithArgs(1).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	expectPostCommentCountUpdate(mock, 3, -1)
+	expectThreadCountersUpdate(mock, 7, 0, -1)
+	mock.ExpectCommit()
+
+	err := repo.TombstoneComment(1)
+	require.NoError(t, err)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCommentRepository_RemoveComment(t *testing.T) {
+	repo, mock, cleanup := setupCommentRepositoryTest(t)
+	defer cleanup()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT post_id FROM comments WHERE id = \\$1").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"post_id"}).AddRow(3))
+	expectPostThreadLookup(mock, 3, 7)
+	expectThreadCountersLock(mock, 7)
+	mock.ExpectQuery("SELECT EXISTS\\(SELECT 1 FROM comments WHERE parent_comment_id = \\$1\\)").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+	mock.ExpectQuery("WITH RECURSIVE branch AS").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	mock.ExpectExec("DELETE FROM comments WHERE id = \\$1 RETURNING id").
+		WithArgs(1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	expectPostCommentCountUpdate(mock, 3, -1)
+	expectThreadCountersUpdate(mock, 7, 0, -1)
+	mock.ExpectCommit()
+
+	tombstoned, err := repo.RemoveComment(1)
+	require.NoError(t, err)
+	assert.False(t, tombstoned)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCommentRepository_RemoveComment_WithReplies(t *testing.T) {
+	repo, mock, cleanup := setupCommentRepositoryTest(t)
+	defer cleanup()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT post_id FROM comments WHERE id = \\$1").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"post_id"}).AddRow(3))
+	expectPostThreadLookup(mock, 3, 7)
+	expectThreadCountersLock(mock, 7)
+	mock.ExpectQuery("SELECT EXISTS\\(SELECT 1 FROM comments WHERE parent_comment_id = \\$1\\)").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+	mock.ExpectExec("UPDATE comments SET content = '', deleted_at = NOW\\(\\) WHERE id = \\$1 AND deleted_at IS NULL").
+		WithArgs(1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("UPDATE attachments SET comment_id = NULL WHERE comment_id = \\$1").
+		WithArgs(1).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	expectPostCommentCountUpdate(mock, 3, -1)
+	expectThreadCountersUpdate(mock, 7, 0, -1)
+	mock.ExpectCommit()
+
+	tombstoned, err := repo.RemoveComment(1)
+	require.NoError(t, err)
+	assert.True(t, tombstoned)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCommentRepository_TombstoneComment_AlreadyTombstoned(t *testing.T) {
+	repo, mock, cleanup := setupCommentRepositoryTest(t)
+	defer cleanup()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT post_id FROM comments WHERE id = \\$1").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"post_id"}).AddRow(3))
+	expectPostThreadLookup(mock, 3, 7)
+	expectThreadCountersLock(mock, 7)
+	mock.ExpectExec("UPDATE comments SET content = '', deleted_at = NOW\\(\\) WHERE id = \\$1 AND deleted_at IS NULL").
+		WithArgs(1).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+
+	err := repo.TombstoneComment(1)
+	require.NoError(t, err)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
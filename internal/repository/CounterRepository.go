@@ -0,0 +1,223 @@
+package repository
+
+import (
+	"ForumService/internal/models"
+	"database/sql"
+	"fmt"
+)
+
+// lockThreadCounters блокирует строку треда до конца транзакции. Все запросы, меняющие
+// счетчики треда, сначала берут эту блокировку: так пересчет участников и последнего
+// поста в updateThreadCounters видит изменения конкурентных транзакций.
+func lockThreadCounters(tx *sql.Tx, threadID int) error {
+	var id int
+	err := tx.QueryRow(`SELECT id FROM threads WHERE id = $1 FOR UPDATE`, threadID).Scan(&id)
+	if err == sql.ErrNoRows {
+		return ErrThreadNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("ошибка при блокировке треда: %w", err)
+	}
+	return nil
+}
+
+// lockOpenThread блокирует строку треда, как lockThreadCounters, и возвращает ErrThreadLocked,
+// если тред закрыт. Модератор закрывает тред, обновляя ту же строку, поэтому пост или
+// комментарий, сохраняемый одновременно с закрытием, не попадет в уже закрытый тред.
+func lockOpenThread(tx *sql.Tx, threadID int) error {
+	var locked bool
+	err := tx.QueryRow(`SELECT locked FROM threads WHERE id = $1 FOR UPDATE`, threadID).Scan(&locked)
+	if err == sql.ErrNoRows {
+		return ErrThreadNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("ошибка при блокировке треда: %w", err)
+	}
+	if locked {
+		return ErrThreadLocked
+	}
+	return nil
+}
+
+// lockPostThread находит тред поста и блокирует его счетчики
+func lockPostThread(tx *sql.Tx, postID int) (int, error) {
+	threadID, err := postThreadID(tx, postID)
+	if err != nil {
+		return 0, err
+	}
+	return threadID, lockThreadCounters(tx, threadID)
+}
+
+// lockOpenPostThread находит тред поста и блокирует его, если тред открыт
+func lockOpenPostThread(tx *sql.Tx, postID int) (int, error) {
+	threadID, err := postThreadID(tx, postID)
+	if err != nil {
+		return 0, err
+	}
+	return threadID, lockOpenThread(tx, threadID)
+}
+
+func postThreadID(tx *sql.Tx, postID int) (int, error) {
+	var threadID int
+	err := tx.QueryRow(`SELECT thread_id FROM posts WHERE id = $1`, postID).Scan(&threadID)
+	if err == sql.ErrNoRows {
+		return 0, ErrPostNotFound
+	}
+	if err != nil {
+		return 0, fmt.Errorf("ошибка при получении треда поста: %w", err)
+	}
+	return threadID, nil
+}
+
+// updateThreadCounters сдвигает счетчики постов и комментариев треда на postDelta и commentDelta.
+// Участники и последний пост пересчитываются по данным одного треда: для удаления
+// их нельзя вычислить по разнице.
+func updateThreadCounters(tx *sql.Tx, threadID int, postDelta int, commentDelta int) error {
+	const query = `
+		UPDATE threads t SET
+			post_count = post_count + $2,
+			comment_count = comment_count + $3,
+			participant_count = (
+				SELECT COUNT(*) FROM (
+					SELECT t.author_id
+					UNION
+					SELECT author_id FROM posts WHERE thread_id = t.id
+					UNION
+					SELECT c.author_id FROM comments c JOIN posts p ON p.id = c.post_id
+					WHERE p.thread_id = t.id AND c.deleted_at IS NULL
+				) participants
+			),
+			(last_post_at, last_post_author_id) = (
+				SELECT created_at, author_id FROM posts
+				WHERE thread_id = t.id
+				ORDER BY created_at DESC, id DESC
+				LIMIT 1
+			)
+		WHERE id = $1`
+	if _, err := tx.Exec(query, threadID, postDelta, commentDelta); err != nil {
+		return fmt.Errorf("ошибка при обновлении счетчиков треда: %w", err)
+	}
+	return nil
+}
+
+// updatePostCommentCount сдвигает счетчик комментариев поста на delta
+func updatePostCommentCount(tx *sql.Tx, postID int, delta int) error {
+	if _, err := tx.Exec(`UPDATE posts SET comment_count = comment_count + $2 WHERE id = $1`, postID, delta); err != nil {
+		return fmt.Errorf("ошибка при обновлении счетчика комментариев: %w", err)
+	}
+	return nil
+}
+
+// fillLastPost заполняет последний пост треда из nullable-колонок
+func fillLastPost(thread *models.Thread, lastPostAt sql.NullTime, lastPostAuthorID sql.NullInt64) {
+	thread.LastPostAt = nil
+	thread.LastPostAuthorID = nil
+	if lastPostAt.Valid {
+		at := lastPostAt.Time
+		thread.LastPostAt = &at
+	}
+	if lastPostAuthorID.Valid {
+		id := int(lastPostAuthorID.Int64)
+		thread.LastPostAuthorID = &id
+	}
+}
+
+type counterRepository struct {
+	db *sql.DB
+}
+
+func NewCounterRepository(db *sql.DB) CounterRepository {
+	return &counterRepository{db: db}
+}
+
+// RecountCounters пересчитывает все счетчики по данным и исправляет разошедшиеся.
+// На время пересчета запись в треды блокируется, чтобы конкурентные посты и комментарии
+// не потерялись между подсчетом и обновлением, поэтому запускать его лучше при низкой нагрузке.
+func (r *counterRepository) RecountCounters() (models.CounterRecount, error) {
+	var recount models.CounterRecount
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return recount, fmt.Errorf("ошибка при начале транзакции: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err = tx.Exec(`LOCK TABLE threads IN EXCLUSIVE MODE`); err != nil {
+		return recount, fmt.Errorf("ошибка при блокировке тредов: %w", err)
+	}
+
+	const postsQuery = `
+		UPDATE posts p SET comment_count = actual.comment_count
+		FROM (
+			SELECT p2.id, COUNT(c.id) FILTER (WHERE c.deleted_at IS NULL) AS comment_count
+			FROM posts p2
+			LEFT JOIN comments c ON c.post_id = p2.id
+			GROUP BY p2.id
+		) actual
+		WHERE actual.id = p.id AND p.comment_count <> actual.comment_count`
+	result, err := tx.Exec(postsQuery)
+	if err != nil {
+		return recount, fmt.Errorf("ошибка при пересчете счетчиков постов: %w", err)
+	}
+	posts, err := result.RowsAffected()
+	if err != nil {
+		return recount, err
+	}
+
+	// Счетчики комментариев тредов складываются из уже исправленных счетчиков постов
+	const threadsQuery = `
+		UPDATE threads t SET
+			post_count = actual.post_count,
+			comment_count = actual.comment_count,
+			participant_count = actual.participant_count,
+			last_post_at = actual.last_post_at,
+			last_post_author_id = actual.last_post_author_id
+		FROM (
+			SELECT t2.id,
+				COALESCE(s.post_count, 0) AS post_count,
+				COALESCE(s.comment_count, 0) AS comment_count,
+				(
+					SELECT COUNT(*) FROM (
+						SELECT t2.author_id
+						UNION
+						SELECT author_id FROM posts WHERE thread_id = t2.id
+						UNION
+						SELECT c.author_id FROM comments c JOIN posts p ON p.id = c.post_id
+						WHERE p.thread_id = t2.id AND c.deleted_at IS NULL
+					) participants
+				) AS participant_count,
+				l.created_at AS last_post_at,
+				l.author_id AS last_post_author_id
+			FROM threads t2
+			LEFT JOIN (
+				SELECT thread_id, COUNT(*) AS post_count, SUM(comment_count) AS comment_count
+				FROM posts
+				GROUP BY thread_id
+			) s ON s.thread_id = t2.id
+			LEFT JOIN (
+				SELECT DISTINCT ON (thread_id) thread_id, created_at, author_id
+				FROM posts
+				ORDER BY thread_id, created_at DESC, id DESC
+			) l ON l.thread_id = t2.id
+		) actual
+		WHERE actual.id = t.id
+			AND (t.post_count, t.comment_count, t.participant_count, t.last_post_at, t.last_post_author_id)
+				IS DISTINCT FROM
+				(actual.post_count, actual.comment_count, actual.participant_count, actual.last_post_at, actual.last_post_author_id)`
+	result, err = tx.Exec(threadsQuery)
+	if err != nil {
+		return recount, fmt.Errorf("ошибка при пересчете счетчиков тредов: %w", err)
+	}
+	threads, err := result.RowsAffected()
+	if err != nil {
+		return recount, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return recount, fmt.Errorf("ошибка при фиксации транзакции: %w", err)
+	}
+
+	recount.Threads = int(threads)
+	recount.Posts = int(posts)
+	return recount, nil
+}
@@ -0,0 +1,106 @@
+package repository
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// expectPostThreadLookup ожидает поиск треда, которому принадлежит пост
+func expectPostThreadLookup(mock sqlmock.Sqlmock, postID int, threadID int) {
+	mock.ExpectQuery("SELECT thread_id FROM posts WHERE id = \\$1").
+		WithArgs(postID).
+		WillReturnRows(sqlmock.NewRows([]string{"thread_id"}).AddRow(threadID))
+}
+
+// expectThreadCountersLock ожидает блокировку строки треда перед изменением счетчиков
+func expectThreadCountersLock(mock sqlmock.Sqlmock, threadID int) {
+	mock.ExpectQuery("SELECT id FROM threads WHERE id = \\$1 FOR UPDATE").
+		WithArgs(threadID).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(threadID))
+}
+
+// expectOpenThreadLock ожидает блокировку открытого треда перед добавлением поста или комментария
+func expectOpenThreadLock(mock sqlmock.Sqlmock, threadID int) {
+	mock.ExpectQuery("SELECT locked FROM threads WHERE id = \\$1 FOR UPDATE").
+		WithArgs(threadID).
+		WillReturnRows(sqlmock.NewRows([]string{"locked"}).AddRow(false))
+}
+
+// expectThreadCountersUpdate ожидает сдвиг счетчиков треда и пересчет участников и последнего поста
+func expectThreadCountersUpdate(mock sqlmock.Sqlmock, threadID int, postDelta int, commentDelta int) {
+	mock.ExpectExec("UPDATE threads t SET post_count = post_count \\+ \\$2, comment_count = comment_count \\+ \\$3, participant_count = .* \\(last_post_at, last_post_author_id\\) = .* WHERE id = \\$1").
+		WithArgs(threadID, postDelta, commentDelta).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+}
+
+// expectPostCommentCountUpdate ожидает сдвиг счетчика комментариев поста
+func expectPostCommentCountUpdate(mock sqlmock.Sqlmock, postID int, delta int) {
+	mock.ExpectExec("UPDATE posts SET comment_count = comment_count \\+ \\$2 WHERE id = \\$1").
+		WithArgs(postID, delta).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+}
+
+func setupCounterRepositoryTest(t *testing.T) (CounterRepository, sqlmock.Sqlmock, func()) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+
+	return NewCounterRepository(db), mock, func() { db.Close() }
+}
+
+func TestCounterRepository_RecountCounters(t *testing.T) {
+	repo, mock, cleanup := setupCounterRepositoryTest(t)
+	defer cleanup()
+
+	mock.ExpectBegin()
+	mock.ExpectExec("LOCK TABLE threads IN EXCLUSIVE MODE").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("UPDATE posts p SET comment_count = actual.comment_count").
+		WillReturnResult(sqlmock.NewResult(0, 3))
+	mock.ExpectExec("UPDATE threads t SET post_count = actual.post_count").
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectCommit()
+
+	recount, err := repo.RecountCounters()
+	require.NoError(t, err)
+	assert.Equal(t, 2, recount.Threads)
+	assert.Equal(t, 3, recount.Posts)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCounterRepository_RecountCounters_Error(t *testing.T) {
+	repo, mock, cleanup := setupCounterRepositoryTest(t)
+	defer cleanup()
+
+	mock.ExpectBegin()
+	mock.ExpectExec("LOCK TABLE threads IN EXCLUSIVE MODE").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("UPDATE posts p SET comment_count = actual.comment_count").
+		WillReturnError(fmt.Errorf("database error"))
+	mock.ExpectRollback()
+
+	_, err := repo.RecountCounters()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "ошибка при пересчете счетчиков постов")
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestLockThreadCounters_NotFound(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT id FROM threads WHERE id = \\$1 FOR UPDATE").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectRollback()
+
+	tx, err := db.Begin()
+	require.NoError(t, err)
+	assert.ErrorIs(t, lockThreadCounters(tx, 1), ErrThreadNotFound)
+	require.NoError(t, tx.Rollback())
+}
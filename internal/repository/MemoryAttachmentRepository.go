@@ -0,0 +1,177 @@
+package repository
+
+import (
+	"ForumService/internal/models"
+	"fmt"
+	"sort"
+	"time"
+)
+
+type memoryAttachmentRepository struct {
+	store *MemoryStore
+}
+
+// NewMemoryAttachmentRepository создает репозиторий вложений поверх хранилища в памяти
+func NewMemoryAttachmentRepository(store *MemoryStore) AttachmentRepository {
+	return &memoryAttachmentRepository{store: store}
+}
+
+func (r *memoryAttachmentRepository) Create(attachment *models.Attachment) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if _, ok := r.store.users[attachment.UploaderID]; !ok {
+		return ErrUserNotFound
+	}
+	stored := cloneAttachment(attachment)
+	stored.ID = r.store.nextID("attachments")
+	stored.PostID = nil
+	stored.CommentID = nil
+	stored.CreatedAt = r.store.now()
+	r.store.attachments[stored.ID] = stored
+
+	attachment.ID = stored.ID
+	attachment.CreatedAt = stored.CreatedAt
+	return nil
+}
+
+func (r *memoryAttachmentRepository) GetByID(id int) (*models.Attachment, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	stored, ok := r.store.attachments[id]
+	if !ok {
+		return nil, ErrAttachmentNotFound
+	}
+	return cloneAttachment(stored), nil
+}
+
+func (r *memoryAttachmentRepository) Link(targetType string, targetID int, uploaderID int, ids []int) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	switch targetType {
+	case models.AttachmentTargetPost:
+		if _, ok := r.store.posts[targetID]; !ok {
+			return ErrPostNotFound
+		}
+	case models.AttachmentTargetComment:
+		if _, ok := r.store.comments[targetID]; !ok {
+			return ErrCommentNotFound
+		}
+	default:
+		return fmt.Errorf("неизвестный вид записи для вложения: %s", targetType)
+	}
+
+	wanted := make(map[int]bool, len(ids))
+	for _, id := range ids {
+		stored, ok := r.store.attachments[id]
+		if !ok || wanted[id] {
+			return ErrAttachmentNotFound
+		}
+		linkedHere := attachmentTarget(stored, targetType) != nil && *attachmentTarget(stored, targetType) == targetID
+		orphan := stored.PostID == nil && stored.CommentID == nil
+		if !linkedHere && !(orphan && stored.UploaderID == uploaderID) {
+			return ErrAttachmentNotFound
+		}
+		wanted[id] = true
+	}
+
+	for id, stored := range r.store.attachments {
+		current := attachmentTarget(stored, targetType)
+		switch {
+		case wanted[id]:
+			linkedID := targetID
+			setAttachmentTarget(stored, targetType, &linkedID)
+		case current != nil && *current == targetID:
+			setAttachmentTarget(stored, targetType, nil)
+		}
+	}
+	return nil
+}
+
+func (r *memoryAttachmentRepository) GetByTargets(targetType string, targetIDs []int) (map[int][]models.Attachment, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	if targetType != models.AttachmentTargetPost && targetType != models.AttachmentTargetComment {
+		return nil, fmt.Errorf("неизвестный вид записи для вложения: %s", targetType)
+	}
+	wanted := make(map[int]bool, len(targetIDs))
+	for _, id := range targetIDs {
+		wanted[id] = true
+	}
+
+	attachments := make(map[int][]models.Attachment)
+	for _, stored := range r.store.attachments {
+		targetID := attachmentTarget(stored, targetType)
+		if targetID == nil || !wanted[*targetID] {
+			continue
+		}
+		attachments[*targetID] = append(attachments[*targetID], *cloneAttachment(stored))
+	}
+	for _, list := range attachments {
+		sortAttachments(list)
+	}
+	return attachments, nil
+}
+
+func (r *memoryAttachmentRepository) DeleteOrphans(before time.Time, limit int) ([]models.Attachment, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	orphans := make([]models.Attachment, 0)
+	for _, stored := range r.store.attachments {
+		if stored.PostID == nil && stored.CommentID == nil && stored.CreatedAt.Before(before) {
+			orphans = append(orphans, *cloneAttachment(stored))
+		}
+	}
+	sortAttachments(orphans)
+	if len(orphans) > limit {
+		orphans = orphans[:limit]
+	}
+	for _, orphan := range orphans {
+		delete(r.store.attachments, orphan.ID)
+	}
+	return orphans, nil
+}
+
+// attachmentTarget возвращает ссылку вложения на запись заданного вида
+func attachmentTarget(attachment *models.Attachment, targetType string) *int {
+	if targetType == models.AttachmentTargetComment {
+		return attachment.CommentID
+	}
+	return attachment.PostID
+}
+
+func setAttachmentTarget(attachment *models.Attachment, targetType string, targetID *int) {
+	if targetType == models.AttachmentTargetComment {
+		attachment.CommentID = targetID
+		return
+	}
+	attachment.PostID = targetID
+}
+
+// cloneAttachment копирует вложение вместе со ссылками, чтобы вызывающий не менял хранилище
+func cloneAttachment(attachment *models.Attachment) *models.Attachment {
+	clone := *attachment
+	if attachment.PostID != nil {
+		postID := *attachment.PostID
+		clone.PostID = &postID
+	}
+	if attachment.CommentID != nil {
+		commentID := *attachment.CommentID
+		clone.CommentID = &commentID
+	}
+	return &clone
+}
+
+// sortAttachments упорядочивает вложения, как ORDER BY created_at, id
+func sortAttachments(attachments []models.Attachment) {
+	sort.Slice(attachments, func(i, j int) bool {
+		if !attachments[i].CreatedAt.Equal(attachments[j].CreatedAt) {
+			return attachments[i].CreatedAt.Before(attachments[j].CreatedAt)
+		}
+		return attachments[i].ID < attachments[j].ID
+	})
+}
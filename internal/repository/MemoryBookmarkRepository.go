@@ -0,0 +1,141 @@
+package repository
+
+import (
+	"ForumService/internal/models"
+	"fmt"
+	"sort"
+)
+
+type memoryBookmarkRepository struct {
+	store *MemoryStore
+}
+
+// NewMemoryBookmarkRepository создает репозиторий закладок поверх хранилища в памяти
+func NewMemoryBookmarkRepository(store *MemoryStore) BookmarkRepository {
+	return &memoryBookmarkRepository{store: store}
+}
+
+func (r *memoryBookmarkRepository) Save(bookmark *models.Bookmark) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	switch bookmark.TargetType {
+	case models.BookmarkTargetThread:
+		if _, ok := r.store.threads[bookmark.TargetID]; !ok {
+			return ErrThreadNotFound
+		}
+	case models.BookmarkTargetPost:
+		if _, ok := r.store.posts[bookmark.TargetID]; !ok {
+			return ErrPostNotFound
+		}
+	default:
+		return fmt.Errorf("неизвестный вид записи для закладки: %s", bookmark.TargetType)
+	}
+
+	key := memoryBookmark{userID: bookmark.UserID, targetType: bookmark.TargetType, targetID: bookmark.TargetID}
+	if stored, ok := r.store.bookmarks[key]; ok {
+		stored.Note = bookmark.Note
+		bookmark.CreatedAt = stored.CreatedAt
+		return nil
+	}
+	if _, ok := r.store.users[bookmark.UserID]; !ok {
+		return ErrUserNotFound
+	}
+	bookmark.CreatedAt = r.store.now()
+	r.store.bookmarks[key] = &models.Bookmark{
+		UserID:     bookmark.UserID,
+		TargetType: bookmark.TargetType,
+		TargetID:   bookmark.TargetID,
+		Note:       bookmark.Note,
+		CreatedAt:  bookmark.CreatedAt,
+	}
+	return nil
+}
+
+func (r *memoryBookmarkRepository) Delete(userID int, targetType string, targetID int) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	key := memoryBookmark{userID: userID, targetType: targetType, targetID: targetID}
+	if _, ok := r.store.bookmarks[key]; !ok {
+		return ErrBookmarkNotFound
+	}
+	delete(r.store.bookmarks, key)
+	return nil
+}
+
+func (r *memoryBookmarkRepository) GetByUser(userID int, role models.Role, limit, offset int) ([]models.Bookmark, int, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	var all []models.Bookmark
+	for key, stored := range r.store.bookmarks {
+		if key.userID != userID {
+			continue
+		}
+		bookmark := *stored
+		r.fillTargetLocked(&bookmark, role)
+		all = append(all, bookmark)
+	}
+	sort.Slice(all, func(i, j int) bool {
+		if !all[i].CreatedAt.Equal(all[j].CreatedAt) {
+			return all[i].CreatedAt.After(all[j].CreatedAt)
+		}
+		if all[i].TargetType != all[j].TargetType {
+			return all[i].TargetType < all[j].TargetType
+		}
+		return all[i].TargetID > all[j].TargetID
+	})
+
+	bookmarks := make([]models.Bookmark, 0)
+	if offset < len(all) {
+		end := offset + limit
+		if end > len(all) {
+			end = len(all)
+		}
+		bookmarks = append(bookmarks, all[offset:end]...)
+	}
+	// Как COUNT(*) OVER() в PostgreSQL: за последней страницей строк нет, и итог неизвестен
+	total := 0
+	if len(bookmarks) > 0 {
+		total = len(all)
+	}
+	return bookmarks, total, nil
+}
+
+// fillTargetLocked заполняет заголовок и тред закладки, как join'ы с threads и posts.
+// Для разделов, закрытых для роли, оба поля остаются пустыми.
+func (r *memoryBookmarkRepository) fillTargetLocked(bookmark *models.Bookmark, role models.Role) {
+	threadID := bookmark.TargetID
+	if bookmark.TargetType == models.BookmarkTargetPost {
+		post, ok := r.store.posts[bookmark.TargetID]
+		if !ok {
+			return
+		}
+		threadID = post.ThreadID
+		bookmark.Title = post.Title
+	}
+	if thread, ok := r.store.threads[threadID]; ok {
+		if !role.Allows(r.store.readRoleLocked(thread.CategoryID)) {
+			bookmark.Title = ""
+			return
+		}
+		bookmark.ThreadID = thread.ID
+		if bookmark.Title == "" {
+			bookmark.Title = thread.Title
+		}
+	}
+}
+
+func (r *memoryBookmarkRepository) GetBookmarked(userID int, targetType string, targetIDs []int) (map[int]bool, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	bookmarked := make(map[int]bool)
+	for _, id := range targetIDs {
+		if _, ok := r.store.bookmarks[memoryBookmark{userID: userID, targetType: targetType, targetID: id}]; ok {
+			bookmarked[id] = true
+		}
+	}
+	return bookmarked, nil
+}
@@ -0,0 +1,130 @@
+package repository
+
+import (
+	"ForumService/internal/models"
+	"sort"
+)
+
+type memoryCategoryRepository struct {
+	store *MemoryStore
+}
+
+// NewMemoryCategoryRepository создает репозиторий разделов поверх хранилища в памяти
+func NewMemoryCategoryRepository(store *MemoryStore) CategoryRepository {
+	return &memoryCategoryRepository{store: store}
+}
+
+func (r *memoryCategoryRepository) Create(category *models.Category) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if err := r.store.checkCategoryLocked(category.ParentID); err != nil {
+		return err
+	}
+
+	now := r.store.now()
+	stored := *category
+	stored.ID = r.store.nextID("categories")
+	stored.ParentID = cloneIntPtr(category.ParentID)
+	stored.CreatedAt = now
+	stored.UpdatedAt = now
+	stored.ThreadCount = 0
+	stored.PostCount = 0
+	stored.Children = nil
+	r.store.categories[stored.ID] = &stored
+
+	*category = stored
+	return nil
+}
+
+func (r *memoryCategoryRepository) GetByID(id int) (*models.Category, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	stored, ok := r.store.categories[id]
+	if !ok {
+		return nil, ErrCategoryNotFound
+	}
+	return r.categoryRow(stored), nil
+}
+
+func (r *memoryCategoryRepository) GetAll() ([]*models.Category, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	var categories []*models.Category
+	for _, stored := range r.store.categories {
+		categories = append(categories, r.categoryRow(stored))
+	}
+	sort.Slice(categories, func(i, j int) bool {
+		a, b := categories[i], categories[j]
+		if a.Position != b.Position {
+			return a.Position < b.Position
+		}
+		if a.Name != b.Name {
+			return a.Name < b.Name
+		}
+		return a.ID < b.ID
+	})
+	return categories, nil
+}
+
+func (r *memoryCategoryRepository) Update(category *models.Category) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	stored, ok := r.store.categories[category.ID]
+	if !ok {
+		return ErrCategoryNotFound
+	}
+	if err := r.store.checkCategoryLocked(category.ParentID); err != nil {
+		return err
+	}
+
+	stored.ParentID = cloneIntPtr(category.ParentID)
+	stored.Name = category.Name
+	stored.Description = category.Description
+	stored.Position = category.Position
+	stored.ReadRole = category.ReadRole
+	stored.CreateRole = category.CreateRole
+	stored.UpdatedAt = r.store.now()
+
+	category.CreatedAt = stored.CreatedAt
+	category.UpdatedAt = stored.UpdatedAt
+	return nil
+}
+
+// Delete удаляет пустой раздел, как ON DELETE RESTRICT в PostgreSQL
+func (r *memoryCategoryRepository) Delete(id int) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if _, ok := r.store.categories[id]; !ok {
+		return ErrCategoryNotFound
+	}
+	for _, thread := range r.store.threads {
+		if thread.CategoryID != nil && *thread.CategoryID == id {
+			return ErrCategoryNotEmpty
+		}
+	}
+	for _, category := range r.store.categories {
+		if category.ParentID != nil && *category.ParentID == id {
+			return ErrCategoryNotEmpty
+		}
+	}
+	delete(r.store.categories, id)
+	return nil
+}
+
+// categoryRow копирует раздел и считает его собственные треды и посты
+func (r *memoryCategoryRepository) categoryRow(stored *models.Category) *models.Category {
+	category := *stored
+	category.ParentID = cloneIntPtr(stored.ParentID)
+	for _, thread := range r.store.threads {
+		if thread.CategoryID != nil && *thread.CategoryID == category.ID {
+			category.ThreadCount++
+			category.PostCount += thread.PostCount
+		}
+	}
+	return &category
+}
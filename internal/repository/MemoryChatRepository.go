@@ -0,0 +1,109 @@
+package repository
+
+import (
+	"ForumService/internal/models"
+	"github.com/Luxtington/Shared/logger"
+	"go.uber.org/zap"
+	"sort"
+	"time"
+)
+
+// Сроки хранения сообщений чата, как в запросах chatRepository
+const (
+	chatMessageTTL        = time.Minute
+	chatMessageCleanupTTL = 24 * time.Hour
+)
+
+type memoryChatRepository struct {
+	store *MemoryStore
+}
+
+// NewMemoryChatRepository создает репозиторий чата поверх хранилища в памяти
+func NewMemoryChatRepository(store *MemoryStore) ChatRepository {
+	repo := &memoryChatRepository{store: store}
+	go repo.startMessageCleanup()
+	return repo
+}
+
+func (r *memoryChatRepository) CreateMessage(authorID int, content string) (*models.ChatMessage, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if _, ok := r.store.users[authorID]; !ok {
+		return nil, ErrUserNotFound
+	}
+
+	stored := &models.ChatMessage{
+		ID:        r.store.nextID("chat_messages"),
+		AuthorID:  authorID,
+		Content:   content,
+		CreatedAt: r.store.now(),
+	}
+	r.store.messages[stored.ID] = stored
+
+	message := *stored
+	return &message, nil
+}
+
+func (r *memoryChatRepository) GetAllMessages() ([]*models.ChatMessage, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	var messages []*models.ChatMessage
+	for _, stored := range r.store.messages {
+		message := *stored
+		message.AuthorName = r.store.username(message.AuthorID)
+		messages = append(messages, &message)
+	}
+	sort.Slice(messages, func(i, j int) bool {
+		return createdBefore(messages[i].CreatedAt, messages[i].ID, messages[j].CreatedAt, messages[j].ID)
+	})
+	return messages, nil
+}
+
+func (r *memoryChatRepository) DeleteOldMessages() error {
+	deletedIDs := r.deleteOlderThan(chatMessageTTL)
+	if len(deletedIDs) > 0 {
+		logger.GetLogger().Info("Удалены сообщения", zap.Ints("message_ids", deletedIDs))
+	}
+	return nil
+}
+
+func (r *memoryChatRepository) startMessageCleanup() {
+	ticker := time.NewTicker(10 * time.Second)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		if err := r.DeleteOldMessages(); err != nil {
+			logger.GetLogger().Error("Ошибка при очистке старых сообщений", zap.Error(err))
+		}
+	}
+}
+
+func (r *memoryChatRepository) CleanOldMessages() error {
+	deletedIDs := r.deleteOlderThan(chatMessageCleanupTTL)
+	logger.GetLogger().Info("Удалены сообщения", zap.Ints("message_ids", deletedIDs))
+	return nil
+}
+
+func (r *memoryChatRepository) Cleanup() error {
+	r.deleteOlderThan(chatMessageCleanupTTL)
+	return nil
+}
+
+// deleteOlderThan удаляет сообщения старше ttl и возвращает их ID
+func (r *memoryChatRepository) deleteOlderThan(ttl time.Duration) []int {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	threshold := time.Now().Add(-ttl)
+	var deletedIDs []int
+	for id, message := range r.store.messages {
+		if message.CreatedAt.Before(threshold) {
+			r.store.deleteMessageLocked(id)
+			deletedIDs = append(deletedIDs, id)
+		}
+	}
+	sort.Ints(deletedIDs)
+	return deletedIDs
+}
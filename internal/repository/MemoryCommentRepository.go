@@ -0,0 +1,166 @@
+package repository
+
+import (
+	"ForumService/internal/models"
+)
+
+type memoryCommentRepository struct {
+	store *MemoryStore
+}
+
+// NewMemoryCommentRepository создает репозиторий комментариев поверх хранилища в памяти
+func NewMemoryCommentRepository(store *MemoryStore) CommentRepository {
+	return &memoryCommentRepository{store: store}
+}
+
+func (r *memoryCommentRepository) SaveComment(comment *models.Comment) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	post, ok := r.store.posts[comment.PostID]
+	if !ok {
+		return ErrPostNotFound
+	}
+	if thread, ok := r.store.threads[post.ThreadID]; ok && thread.Locked {
+		return ErrThreadLocked
+	}
+	if _, ok := r.store.users[comment.AuthorID]; !ok {
+		return ErrUserNotFound
+	}
+	if comment.ParentCommentID != nil {
+		if _, ok := r.store.comments[*comment.ParentCommentID]; !ok {
+			return ErrCommentNotFound
+		}
+	}
+
+	now := r.store.now()
+	stored := &memoryComment{comment: models.Comment{
+		ID:        r.store.nextID("comments"),
+		PostID:    comment.PostID,
+		AuthorID:  comment.AuthorID,
+		Depth:     comment.Depth,
+		Content:   comment.Content,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}}
+	if comment.ParentCommentID != nil {
+		parentID := *comment.ParentCommentID
+		stored.comment.ParentCommentID = &parentID
+	}
+	r.store.comments[stored.comment.ID] = stored
+	r.store.refreshCountersLocked(post.ThreadID)
+
+	comment.ID = stored.comment.ID
+	return nil
+}
+
+func (r *memoryCommentRepository) GetCommentByID(id int) (*models.Comment, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	stored, ok := r.store.comments[id]
+	if !ok {
+		return nil, ErrCommentNotFound
+	}
+	comment := r.store.commentRow(stored, false)
+	return &comment, nil
+}
+
+// UpdateComment обновляет текст комментария и время его изменения
+func (r *memoryCommentRepository) UpdateComment(comment *models.Comment) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	stored, ok := r.store.comments[comment.ID]
+	if !ok {
+		return ErrCommentNotFound
+	}
+	stored.comment.Content = comment.Content
+	stored.comment.UpdatedAt = r.store.now()
+
+	comment.UpdatedAt = stored.comment.UpdatedAt
+	comment.Edited = comment.UpdatedAt.After(comment.CreatedAt)
+	return nil
+}
+
+// DeleteComment удаляет комментарий вместе со всеми ответами на него
+func (r *memoryCommentRepository) DeleteComment(id int) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	stored, ok := r.store.comments[id]
+	if !ok {
+		return ErrCommentNotFound
+	}
+	r.store.deleteCommentLocked(id)
+	r.store.refreshCommentThreadLocked(stored.comment.PostID)
+	return nil
+}
+
+func (r *memoryCommentRepository) GetCommentsByPostID(postID int) ([]models.Comment, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	return flattenCommentTree(r.store.postComments(postID, false)), nil
+}
+
+// HasReplies проверяет, есть ли у комментария ответы
+func (r *memoryCommentRepository) HasReplies(id int) (bool, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	for _, stored := range r.store.comments {
+		if stored.comment.ParentCommentID != nil && *stored.comment.ParentCommentID == id {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// TombstoneComment помечает комментарий удаленным и стирает его текст,
+// сохраняя запись, чтобы ответы на него остались на своих местах.
+// Заглушка не учитывается в счетчиках поста и треда.
+func (r *memoryCommentRepository) TombstoneComment(id int) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	stored, ok := r.store.comments[id]
+	if !ok {
+		return ErrCommentNotFound
+	}
+	r.tombstoneLocked(stored)
+	return nil
+}
+
+// RemoveComment удаляет комментарий без ответов, а комментарий с ответами заменяет
+// заглушкой. Проверка и удаление идут под одной блокировкой хранилища.
+func (r *memoryCommentRepository) RemoveComment(id int) (bool, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	stored, ok := r.store.comments[id]
+	if !ok {
+		return false, ErrCommentNotFound
+	}
+	for _, other := range r.store.comments {
+		if other.comment.ParentCommentID != nil && *other.comment.ParentCommentID == id {
+			r.tombstoneLocked(stored)
+			return true, nil
+		}
+	}
+	r.store.deleteCommentLocked(id)
+	r.store.refreshCommentThreadLocked(stored.comment.PostID)
+	return false, nil
+}
+
+// tombstoneLocked заменяет комментарий заглушкой, вызывается под блокировкой хранилища
+func (r *memoryCommentRepository) tombstoneLocked(stored *memoryComment) {
+	if stored.deletedAt != nil {
+		return
+	}
+	deletedAt := r.store.now()
+	stored.comment.Content = ""
+	stored.deletedAt = &deletedAt
+	r.store.detachAttachmentsLocked(models.AttachmentTargetComment, stored.comment.ID)
+	r.store.refreshCommentThreadLocked(stored.comment.PostID)
+}
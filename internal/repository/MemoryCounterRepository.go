@@ -0,0 +1,28 @@
+package repository
+
+import "ForumService/internal/models"
+
+type memoryCounterRepository struct {
+	store *MemoryStore
+}
+
+// NewMemoryCounterRepository создает репозиторий счетчиков поверх хранилища в памяти
+func NewMemoryCounterRepository(store *MemoryStore) CounterRepository {
+	return &memoryCounterRepository{store: store}
+}
+
+// RecountCounters пересчитывает счетчики всех тредов и постов
+func (r *memoryCounterRepository) RecountCounters() (models.CounterRecount, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	var recount models.CounterRecount
+	for threadID := range r.store.threads {
+		threadChanged, postsChanged := r.store.refreshCountersLocked(threadID)
+		if threadChanged {
+			recount.Threads++
+		}
+		recount.Posts += postsChanged
+	}
+	return recount, nil
+}
@@ -0,0 +1,119 @@
+package repository
+
+import (
+	"ForumService/internal/models"
+	"fmt"
+	"sort"
+)
+
+type memoryMentionRepository struct {
+	store *MemoryStore
+}
+
+// NewMemoryMentionRepository создает репозиторий упоминаний поверх хранилища в памяти
+func NewMemoryMentionRepository(store *MemoryStore) MentionRepository {
+	return &memoryMentionRepository{store: store}
+}
+
+func (r *memoryMentionRepository) ResolveUsernames(usernames []string) ([]models.Mention, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	wanted := make(map[string]bool, len(usernames))
+	for _, username := range usernames {
+		wanted[username] = true
+	}
+
+	mentions := make([]models.Mention, 0)
+	for _, stored := range r.store.users {
+		if wanted[stored.user.Username] {
+			mentions = append(mentions, models.Mention{UserID: stored.user.ID, Username: stored.user.Username})
+		}
+	}
+	sortMentions(mentions)
+	return mentions, nil
+}
+
+func (r *memoryMentionRepository) Replace(targetType string, targetID int, userIDs []int) ([]int, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if err := r.checkTargetLocked(targetType, targetID); err != nil {
+		return nil, err
+	}
+	wanted := make(map[int]bool, len(userIDs))
+	for _, userID := range userIDs {
+		if _, ok := r.store.users[userID]; !ok {
+			return nil, ErrUserNotFound
+		}
+		wanted[userID] = true
+	}
+
+	for key := range r.store.mentions {
+		if key.targetType == targetType && key.targetID == targetID && !wanted[key.userID] {
+			delete(r.store.mentions, key)
+		}
+	}
+	added := make([]int, 0)
+	for userID := range wanted {
+		key := memoryMention{targetType: targetType, targetID: targetID, userID: userID}
+		if !r.store.mentions[key] {
+			r.store.mentions[key] = true
+			added = append(added, userID)
+		}
+	}
+	sort.Ints(added)
+	return added, nil
+}
+
+// checkTargetLocked проверяет, что запись, в которой упоминают пользователей, существует
+func (r *memoryMentionRepository) checkTargetLocked(targetType string, targetID int) error {
+	var ok bool
+	switch targetType {
+	case models.MentionTargetPost:
+		if _, ok = r.store.posts[targetID]; !ok {
+			return ErrPostNotFound
+		}
+	case models.MentionTargetComment:
+		if _, ok = r.store.comments[targetID]; !ok {
+			return ErrCommentNotFound
+		}
+	case models.MentionTargetChat:
+		if _, ok = r.store.messages[targetID]; !ok {
+			return ErrMessageNotFound
+		}
+	default:
+		return fmt.Errorf("неизвестный вид записи для упоминания: %s", targetType)
+	}
+	return nil
+}
+
+func (r *memoryMentionRepository) GetByTargets(targetType string, targetIDs []int) (map[int][]models.Mention, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	wanted := make(map[int]bool, len(targetIDs))
+	for _, id := range targetIDs {
+		wanted[id] = true
+	}
+
+	mentions := make(map[int][]models.Mention)
+	for key := range r.store.mentions {
+		if key.targetType != targetType || !wanted[key.targetID] {
+			continue
+		}
+		mentions[key.targetID] = append(mentions[key.targetID], models.Mention{
+			UserID:   key.userID,
+			Username: r.store.username(key.userID),
+		})
+	}
+	for _, list := range mentions {
+		sortMentions(list)
+	}
+	return mentions, nil
+}
+
+// sortMentions упорядочивает упоминания по имени пользователя, как ORDER BY username
+func sortMentions(mentions []models.Mention) {
+	sort.Slice(mentions, func(i, j int) bool { return mentions[i].Username < mentions[j].Username })
+}
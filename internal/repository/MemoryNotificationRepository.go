@@ -0,0 +1,169 @@
+package repository
+
+import (
+	"ForumService/internal/models"
+	"sort"
+)
+
+type memoryNotificationRepository struct {
+	store *MemoryStore
+}
+
+// NewMemoryNotificationRepository создает репозиторий уведомлений поверх хранилища в памяти
+func NewMemoryNotificationRepository(store *MemoryStore) NotificationRepository {
+	return &memoryNotificationRepository{store: store}
+}
+
+// SaveAll проверяет ссылки так же, как внешние ключи таблицы notifications, и при
+// ошибке не сохраняет ни одного уведомления
+func (r *memoryNotificationRepository) SaveAll(notifications []*models.Notification) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	for _, notification := range notifications {
+		if err := r.checkReferencesLocked(notification); err != nil {
+			return err
+		}
+	}
+	for _, notification := range notifications {
+		notification.ID = r.store.nextID("notifications")
+		notification.Read = false
+		notification.CreatedAt = r.store.now()
+		stored := copyNotification(notification)
+		r.store.notifications[stored.ID] = stored
+	}
+	return nil
+}
+
+func (r *memoryNotificationRepository) checkReferencesLocked(notification *models.Notification) error {
+	if _, ok := r.store.users[notification.UserID]; !ok {
+		return ErrUserNotFound
+	}
+	if _, ok := r.store.users[notification.ActorID]; !ok {
+		return ErrUserNotFound
+	}
+	if notification.ChatMessageID != nil {
+		if _, ok := r.store.messages[*notification.ChatMessageID]; !ok {
+			return ErrMessageNotFound
+		}
+	}
+	// Уведомление об упоминании в чате не ссылается на тред и пост
+	if notification.ThreadID == 0 && notification.PostID == 0 && notification.ChatMessageID != nil {
+		return nil
+	}
+	if _, ok := r.store.threads[notification.ThreadID]; !ok {
+		return ErrThreadNotFound
+	}
+	if _, ok := r.store.posts[notification.PostID]; !ok {
+		return ErrPostNotFound
+	}
+	if notification.CommentID != nil {
+		if _, ok := r.store.comments[*notification.CommentID]; !ok {
+			return ErrCommentNotFound
+		}
+	}
+	return nil
+}
+
+func (r *memoryNotificationRepository) GetByUser(userID int, unreadOnly bool, limit, offset int) ([]models.Notification, int, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	var all []models.Notification
+	for _, stored := range r.store.notifications {
+		if stored.UserID != userID || (unreadOnly && stored.Read) {
+			continue
+		}
+		notification := *copyNotification(stored)
+		notification.ActorName = r.store.username(notification.ActorID)
+		r.fillTitleLocked(&notification)
+		all = append(all, notification)
+	}
+	sort.Slice(all, func(i, j int) bool {
+		if !all[i].CreatedAt.Equal(all[j].CreatedAt) {
+			return all[i].CreatedAt.After(all[j].CreatedAt)
+		}
+		return all[i].ID > all[j].ID
+	})
+
+	notifications := make([]models.Notification, 0)
+	if offset < len(all) {
+		end := offset + limit
+		if end > len(all) {
+			end = len(all)
+		}
+		notifications = append(notifications, all[offset:end]...)
+	}
+	// Как COUNT(*) OVER() в PostgreSQL: за последней страницей строк нет, и итог неизвестен
+	total := 0
+	if len(notifications) > 0 {
+		total = len(all)
+	}
+	return notifications, total, nil
+}
+
+// copyNotification копирует уведомление вместе со ссылками на комментарий и сообщение чата
+func copyNotification(notification *models.Notification) *models.Notification {
+	copied := *notification
+	if notification.CommentID != nil {
+		commentID := *notification.CommentID
+		copied.CommentID = &commentID
+	}
+	if notification.ChatMessageID != nil {
+		chatMessageID := *notification.ChatMessageID
+		copied.ChatMessageID = &chatMessageID
+	}
+	return &copied
+}
+
+// fillTitleLocked заполняет заголовок уведомления, как join'ы с posts и threads
+func (r *memoryNotificationRepository) fillTitleLocked(notification *models.Notification) {
+	if post, ok := r.store.posts[notification.PostID]; ok {
+		notification.Title = post.Title
+	}
+	if notification.Title != "" {
+		return
+	}
+	if thread, ok := r.store.threads[notification.ThreadID]; ok {
+		notification.Title = thread.Title
+	}
+}
+
+func (r *memoryNotificationRepository) CountUnread(userID int) (int, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	unread := 0
+	for _, notification := range r.store.notifications {
+		if notification.UserID == userID && !notification.Read {
+			unread++
+		}
+	}
+	return unread, nil
+}
+
+func (r *memoryNotificationRepository) MarkRead(userID, notificationID int) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	notification, ok := r.store.notifications[notificationID]
+	if !ok || notification.UserID != userID {
+		return ErrNotificationNotFound
+	}
+	notification.Read = true
+	return nil
+}
+
+func (r *memoryNotificationRepository) MarkAllRead(userID int) (int, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	updated := 0
+	for _, notification := range r.store.notifications {
+		if notification.UserID == userID && !notification.Read {
+			notification.Read = true
+			updated++
+		}
+	}
+	return updated, nil
+}
@@ -0,0 +1,161 @@
+package repository
+
+import (
+	"ForumService/internal/models"
+	"sort"
+	"time"
+)
+
+type memoryPollRepository struct {
+	store *MemoryStore
+}
+
+// NewMemoryPollRepository создает репозиторий опросов поверх хранилища в памяти
+func NewMemoryPollRepository(store *MemoryStore) PollRepository {
+	return &memoryPollRepository{store: store}
+}
+
+func (r *memoryPollRepository) Create(poll *models.Poll) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if _, ok := r.store.threads[poll.ThreadID]; !ok {
+		return ErrThreadNotFound
+	}
+	for _, existing := range r.store.polls {
+		if existing.poll.ThreadID == poll.ThreadID {
+			return ErrAlreadyExists
+		}
+	}
+
+	stored := clonePoll(poll)
+	stored.ID = r.store.nextID("polls")
+	stored.ClosedAt = nil
+	stored.ClosedBy = nil
+	stored.CreatedAt = r.store.now()
+	for i := range stored.Options {
+		stored.Options[i] = models.PollOption{ID: r.store.nextID("poll_options"), Text: stored.Options[i].Text}
+	}
+	r.store.polls[stored.ID] = &memoryPoll{poll: *stored}
+
+	poll.ID = stored.ID
+	poll.CreatedAt = stored.CreatedAt
+	for i := range poll.Options {
+		poll.Options[i].ID = stored.Options[i].ID
+	}
+	return nil
+}
+
+func (r *memoryPollRepository) GetByID(id int) (*models.Poll, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	stored, ok := r.store.polls[id]
+	if !ok {
+		return nil, ErrPollNotFound
+	}
+	return clonePoll(&stored.poll), nil
+}
+
+func (r *memoryPollRepository) GetByThreadID(threadID int) (*models.Poll, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	for _, stored := range r.store.polls {
+		if stored.poll.ThreadID == threadID {
+			return clonePoll(&stored.poll), nil
+		}
+	}
+	return nil, ErrPollNotFound
+}
+
+func (r *memoryPollRepository) Vote(pollID, userID int, optionIDs []int) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	stored, ok := r.store.polls[pollID]
+	if !ok {
+		return ErrPollNotFound
+	}
+	if stored.poll.IsClosed(time.Now()) {
+		return ErrPollClosed
+	}
+	if _, ok := r.store.users[userID]; !ok && len(optionIDs) > 0 {
+		return ErrUserNotFound
+	}
+
+	options := make(map[int]bool, len(stored.poll.Options))
+	for _, option := range stored.poll.Options {
+		options[option.ID] = true
+	}
+	chosen := make(map[int]bool, len(optionIDs))
+	for _, id := range optionIDs {
+		if !options[id] || chosen[id] {
+			return ErrPollOptionNotFound
+		}
+		chosen[id] = true
+	}
+
+	votes := stored.votes[:0:0]
+	for _, vote := range stored.votes {
+		if vote.UserID != userID {
+			votes = append(votes, vote)
+		}
+	}
+	sorted := append([]int(nil), optionIDs...)
+	sort.Ints(sorted)
+	for _, id := range sorted {
+		votes = append(votes, models.PollVote{OptionID: id, PollVoter: models.PollVoter{UserID: userID}})
+	}
+	stored.votes = votes
+	return nil
+}
+
+func (r *memoryPollRepository) Close(pollID, userID int) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	stored, ok := r.store.polls[pollID]
+	if !ok {
+		return ErrPollNotFound
+	}
+	if stored.poll.ClosedAt == nil {
+		closedAt := r.store.now()
+		stored.poll.ClosedAt = &closedAt
+		stored.poll.ClosedBy = &userID
+	}
+	return nil
+}
+
+func (r *memoryPollRepository) GetVotes(pollID int) ([]models.PollVote, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	votes := make([]models.PollVote, 0)
+	stored, ok := r.store.polls[pollID]
+	if !ok {
+		return votes, nil
+	}
+	for _, vote := range stored.votes {
+		vote.Username = r.store.username(vote.UserID)
+		votes = append(votes, vote)
+	}
+	return votes, nil
+}
+
+// clonePoll копирует опрос с вариантами, чтобы вызывающий не менял хранилище
+func clonePoll(poll *models.Poll) *models.Poll {
+	clone := *poll
+	clone.Options = append([]models.PollOption{}, poll.Options...)
+	if poll.ClosesAt != nil {
+		closesAt := *poll.ClosesAt
+		clone.ClosesAt = &closesAt
+	}
+	if poll.ClosedAt != nil {
+		closedAt := *poll.ClosedAt
+		clone.ClosedAt = &closedAt
+	}
+	clone.ClosedBy = cloneIntPtr(poll.ClosedBy)
+	clone.MyVotes = nil
+	return &clone
+}
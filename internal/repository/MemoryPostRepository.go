@@ -0,0 +1,356 @@
+package repository
+
+import (
+	"ForumService/internal/models"
+	"fmt"
+	"sort"
+)
+
+// memoryThreadPostsLimit - размер первой страницы постов, как в GetPostsWithCommentsByThreadID для PostgreSQL
+const memoryThreadPostsLimit = 20
+
+type memoryPostRepository struct {
+	store *MemoryStore
+}
+
+// NewMemoryPostRepository создает репозиторий постов поверх хранилища в памяти
+func NewMemoryPostRepository(store *MemoryStore) PostRepository {
+	return &memoryPostRepository{store: store}
+}
+
+func (r *memoryPostRepository) SavePost(post *models.Post) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	thread, ok := r.store.threads[post.ThreadID]
+	if !ok {
+		return ErrThreadNotFound
+	}
+	if thread.Locked {
+		return ErrThreadLocked
+	}
+	if _, ok := r.store.users[post.AuthorID]; !ok {
+		return ErrUserNotFound
+	}
+
+	now := r.store.now()
+	stored := &models.Post{
+		ID:        r.store.nextID("posts"),
+		ThreadID:  post.ThreadID,
+		AuthorID:  post.AuthorID,
+		Title:     post.Title,
+		Content:   post.Content,
+		CreatedAt: now,
+		UpdatedAt: now,
+		Version:   1,
+	}
+	// Ответ сохраняется только на пост того же треда, как подзапрос в INSERT для PostgreSQL
+	if post.ReplyToPostID != nil {
+		if target, ok := r.store.posts[*post.ReplyToPostID]; ok && target.ThreadID == post.ThreadID {
+			replyTo := target.ID
+			stored.ReplyToPostID = &replyTo
+		}
+	}
+	r.store.posts[stored.ID] = stored
+	r.store.refreshCountersLocked(stored.ThreadID)
+
+	*post = models.Post{
+		ID:            stored.ID,
+		ThreadID:      stored.ThreadID,
+		AuthorID:      stored.AuthorID,
+		Title:         stored.Title,
+		Content:       stored.Content,
+		CreatedAt:     stored.CreatedAt,
+		ReplyToPostID: cloneIntPtr(stored.ReplyToPostID),
+	}
+	return nil
+}
+
+func (r *memoryPostRepository) GetPostByID(id int) (*models.Post, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	return r.getPostLocked(id)
+}
+
+func (r *memoryPostRepository) getPostLocked(id int) (*models.Post, error) {
+	stored, ok := r.store.posts[id]
+	if !ok {
+		return nil, ErrPostNotFound
+	}
+	post := *stored
+	post.AuthorName = r.store.username(post.AuthorID)
+	post.ReplyToPostID = cloneIntPtr(stored.ReplyToPostID)
+	return &post, nil
+}
+
+func (r *memoryPostRepository) GetPostWithComments(postID int) (*models.Post, []models.Comment, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	post, err := r.getPostLocked(postID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("ошибка при получении поста: %w", err)
+	}
+
+	comments := r.store.postComments(postID, true)
+	if comments == nil {
+		comments = make([]models.Comment, 0)
+	}
+	return post, flattenCommentTree(comments), nil
+}
+
+func (r *memoryPostRepository) GetPostsWithCommentsByThreadID(threadID int) ([]models.Post, map[int][]models.Comment, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	var posts []models.Post
+	for _, stored := range r.store.posts {
+		// Посты без автора отсекаются, как INNER JOIN users
+		if _, ok := r.store.users[stored.AuthorID]; stored.ThreadID != threadID || !ok {
+			continue
+		}
+		posts = append(posts, models.Post{
+			ID:         stored.ID,
+			ThreadID:   stored.ThreadID,
+			AuthorID:   stored.AuthorID,
+			AuthorName: r.store.username(stored.AuthorID),
+			Content:    stored.Content,
+			CreatedAt:  stored.CreatedAt,
+		})
+	}
+	sort.Slice(posts, func(i, j int) bool {
+		return createdBefore(posts[j].CreatedAt, posts[j].ID, posts[i].CreatedAt, posts[i].ID)
+	})
+	if len(posts) > memoryThreadPostsLimit {
+		posts = posts[:memoryThreadPostsLimit]
+	}
+
+	commentsByPostID := make(map[int][]models.Comment)
+	for _, post := range posts {
+		for _, comment := range r.store.postComments(post.ID, false) {
+			commentsByPostID[post.ID] = append(commentsByPostID[post.ID], models.Comment{
+				ID:        comment.ID,
+				PostID:    comment.PostID,
+				AuthorID:  comment.AuthorID,
+				Content:   comment.Content,
+				CreatedAt: comment.CreatedAt,
+			})
+		}
+	}
+
+	return posts, commentsByPostID, nil
+}
+
+// UpdatePost обновляет содержимое поста, предварительно сохраняя текущую версию в истории правок.
+// Если post.Version больше нуля, пост обновляется только при совпадении версии,
+// иначе возвращается ErrVersionConflict. После обновления post.Version содержит новую версию.
+func (r *memoryPostRepository) UpdatePost(post *models.Post, postID int, editorID int, reason string) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	stored, ok := r.store.posts[postID]
+	if !ok {
+		return ErrPostNotFound
+	}
+	if post.Version > 0 && post.Version != stored.Version {
+		return ErrVersionConflict
+	}
+
+	now := r.store.now()
+	revision := &models.PostRevision{
+		ID:        r.store.nextID("post_revisions"),
+		PostID:    postID,
+		Title:     stored.Title,
+		Content:   stored.Content,
+		EditorID:  editorID,
+		Reason:    reason,
+		CreatedAt: now,
+	}
+	r.store.revisions[revision.ID] = revision
+
+	stored.Title = post.Title
+	stored.Content = post.Content
+	stored.Version++
+	stored.UpdatedAt = now
+
+	post.Version = stored.Version
+	post.UpdatedAt = stored.UpdatedAt
+	return nil
+}
+
+// DeletePost удаляет пост вместе с комментариями и историей правок
+func (r *memoryPostRepository) DeletePost(postID int) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	stored, ok := r.store.posts[postID]
+	if !ok {
+		return ErrPostNotFound
+	}
+	r.store.deletePostLocked(postID)
+	r.store.refreshCountersLocked(stored.ThreadID)
+	return nil
+}
+
+func (r *memoryPostRepository) GetByThreadID(threadID int) ([]*models.Post, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	var posts []*models.Post
+	for _, stored := range r.store.posts {
+		if stored.ThreadID != threadID {
+			continue
+		}
+		posts = append(posts, &models.Post{
+			ID:            stored.ID,
+			ThreadID:      stored.ThreadID,
+			AuthorID:      stored.AuthorID,
+			Title:         stored.Title,
+			Content:       stored.Content,
+			CreatedAt:     stored.CreatedAt,
+			UpdatedAt:     stored.UpdatedAt,
+			AuthorName:    r.store.username(stored.AuthorID),
+			CommentCount:  stored.CommentCount,
+			ViewCount:     stored.ViewCount,
+			Upvotes:       stored.Upvotes,
+			Downvotes:     stored.Downvotes,
+			Score:         stored.Score,
+			Pinned:        stored.Pinned,
+			PinnedAt:      stored.PinnedAt,
+			ReplyToPostID: cloneIntPtr(stored.ReplyToPostID),
+		})
+	}
+	// Закрепленные посты идут первыми в порядке закрепления
+	sort.Slice(posts, func(i, j int) bool {
+		if posts[i].Pinned != posts[j].Pinned {
+			return posts[i].Pinned
+		}
+		if posts[i].Pinned && !posts[i].PinnedAt.Equal(*posts[j].PinnedAt) {
+			return posts[i].PinnedAt.Before(*posts[j].PinnedAt)
+		}
+		return createdBefore(posts[i].CreatedAt, posts[i].ID, posts[j].CreatedAt, posts[j].ID)
+	})
+	return posts, nil
+}
+
+// Pin закрепляет пост в треде. Повторное закрепление не меняет место поста среди закрепленных.
+func (r *memoryPostRepository) Pin(postID int) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	stored, ok := r.store.posts[postID]
+	if !ok {
+		return ErrPostNotFound
+	}
+	if stored.PinnedAt == nil {
+		now := r.store.now()
+		stored.PinnedAt = &now
+	}
+	stored.Pinned = true
+	stored.Version++
+	return nil
+}
+
+// Unpin снимает закрепление поста
+func (r *memoryPostRepository) Unpin(postID int) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	stored, ok := r.store.posts[postID]
+	if !ok {
+		return ErrPostNotFound
+	}
+	stored.Pinned = false
+	stored.PinnedAt = nil
+	stored.Version++
+	return nil
+}
+
+// GetPostRefs возвращает ссылки на существующие посты из ids с ролью, нужной для чтения
+// их раздела; удаленные посты пропускаются
+func (r *memoryPostRepository) GetPostRefs(ids []int) (map[int]models.PostRef, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	refs := make(map[int]models.PostRef)
+	for _, id := range ids {
+		if stored, ok := r.store.posts[id]; ok {
+			ref := r.postRefLocked(stored)
+			ref.ReadRole = models.RoleGuest
+			if thread, ok := r.store.threads[stored.ThreadID]; ok {
+				ref.ReadRole = r.store.readRoleLocked(thread.CategoryID)
+			}
+			refs[id] = ref
+		}
+	}
+	return refs, nil
+}
+
+// GetReplies возвращает ответы на посты postIDs по ID исходного поста, от ранних к поздним
+func (r *memoryPostRepository) GetReplies(postIDs []int) (map[int][]models.PostRef, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	wanted := make(map[int]bool, len(postIDs))
+	for _, id := range postIDs {
+		wanted[id] = true
+	}
+	var replies []*models.Post
+	for _, stored := range r.store.posts {
+		if stored.ReplyToPostID != nil && wanted[*stored.ReplyToPostID] {
+			replies = append(replies, stored)
+		}
+	}
+	sort.Slice(replies, func(i, j int) bool {
+		return createdBefore(replies[i].CreatedAt, replies[i].ID, replies[j].CreatedAt, replies[j].ID)
+	})
+
+	refs := make(map[int][]models.PostRef)
+	for _, reply := range replies {
+		refs[*reply.ReplyToPostID] = append(refs[*reply.ReplyToPostID], r.postRefLocked(reply))
+	}
+	return refs, nil
+}
+
+func (r *memoryPostRepository) postRefLocked(stored *models.Post) models.PostRef {
+	return models.PostRef{
+		ID:         stored.ID,
+		ThreadID:   stored.ThreadID,
+		AuthorID:   stored.AuthorID,
+		AuthorName: r.store.username(stored.AuthorID),
+	}
+}
+
+// GetPostRevisions возвращает историю правок поста, начиная с самой ранней версии
+func (r *memoryPostRepository) GetPostRevisions(postID int) ([]models.PostRevision, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	revisions := make([]models.PostRevision, 0)
+	for _, stored := range r.store.revisions {
+		if stored.PostID == postID {
+			revision := *stored
+			revision.EditorName = r.store.username(revision.EditorID)
+			revisions = append(revisions, revision)
+		}
+	}
+	sort.Slice(revisions, func(i, j int) bool {
+		return createdBefore(revisions[i].CreatedAt, revisions[i].ID, revisions[j].CreatedAt, revisions[j].ID)
+	})
+	return revisions, nil
+}
+
+// GetPostRevisionByID возвращает одну ревизию поста
+func (r *memoryPostRepository) GetPostRevisionByID(postID int, revisionID int) (*models.PostRevision, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	stored, ok := r.store.revisions[revisionID]
+	if !ok || stored.PostID != postID {
+		return nil, ErrRevisionNotFound
+	}
+	revision := *stored
+	revision.EditorName = r.store.username(revision.EditorID)
+	return &revision, nil
+}
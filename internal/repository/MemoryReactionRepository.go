@@ -0,0 +1,108 @@
+package repository
+
+import (
+	"ForumService/internal/models"
+	"fmt"
+	"sort"
+)
+
+type memoryReactionRepository struct {
+	store *MemoryStore
+}
+
+// NewMemoryReactionRepository создает репозиторий реакций поверх хранилища в памяти
+func NewMemoryReactionRepository(store *MemoryStore) ReactionRepository {
+	return &memoryReactionRepository{store: store}
+}
+
+func (r *memoryReactionRepository) Toggle(targetType string, targetID, userID int, emoji string) (bool, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if err := r.checkTargetLocked(targetType, targetID); err != nil {
+		return false, err
+	}
+
+	key := memoryReaction{targetType: targetType, targetID: targetID, userID: userID, emoji: emoji}
+	if _, ok := r.store.reactions[key]; ok {
+		delete(r.store.reactions, key)
+		return false, nil
+	}
+	if _, ok := r.store.users[userID]; !ok {
+		return false, ErrUserNotFound
+	}
+	r.store.reactions[key] = r.store.nextID("reactions")
+	return true, nil
+}
+
+// checkTargetLocked проверяет, что запись, на которую ставят реакцию, существует
+func (r *memoryReactionRepository) checkTargetLocked(targetType string, targetID int) error {
+	var ok bool
+	switch targetType {
+	case models.ReactionTargetPost:
+		if _, ok = r.store.posts[targetID]; !ok {
+			return ErrPostNotFound
+		}
+	case models.ReactionTargetComment:
+		if _, ok = r.store.comments[targetID]; !ok {
+			return ErrCommentNotFound
+		}
+	case models.ReactionTargetChat:
+		if _, ok = r.store.messages[targetID]; !ok {
+			return ErrMessageNotFound
+		}
+	default:
+		return fmt.Errorf("неизвестный вид записи для реакции: %s", targetType)
+	}
+	return nil
+}
+
+func (r *memoryReactionRepository) GetCounts(targetType string, targetIDs []int, userID int) (map[int][]models.ReactionCount, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	wanted := make(map[int]bool, len(targetIDs))
+	for _, id := range targetIDs {
+		wanted[id] = true
+	}
+
+	// first - номер первой реакции эмодзи, по нему реакции упорядочиваются как по MIN(created_at)
+	type group struct {
+		count models.ReactionCount
+		first int
+	}
+	groups := make(map[int]map[string]*group)
+	for key, seq := range r.store.reactions {
+		if key.targetType != targetType || !wanted[key.targetID] {
+			continue
+		}
+		if groups[key.targetID] == nil {
+			groups[key.targetID] = make(map[string]*group)
+		}
+		g, ok := groups[key.targetID][key.emoji]
+		if !ok {
+			g = &group{count: models.ReactionCount{Emoji: key.emoji}, first: seq}
+			groups[key.targetID][key.emoji] = g
+		}
+		g.count.Count++
+		if key.userID == userID {
+			g.count.ReactedByMe = true
+		}
+		if seq < g.first {
+			g.first = seq
+		}
+	}
+
+	counts := make(map[int][]models.ReactionCount, len(groups))
+	for targetID, byEmoji := range groups {
+		list := make([]*group, 0, len(byEmoji))
+		for _, g := range byEmoji {
+			list = append(list, g)
+		}
+		sort.Slice(list, func(i, j int) bool { return list[i].first < list[j].first })
+		for _, g := range list {
+			counts[targetID] = append(counts[targetID], g.count)
+		}
+	}
+	return counts, nil
+}
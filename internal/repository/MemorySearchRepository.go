@@ -0,0 +1,198 @@
+package repository
+
+import (
+	"ForumService/internal/models"
+	"sort"
+	"strings"
+)
+
+// Размер сниппета в словах: до совпадения и всего, по аналогии с MinWords/MaxWords в ts_headline
+const (
+	memorySnippetWordsBefore = 10
+	memorySnippetWords       = 35
+)
+
+type memorySearchRepository struct {
+	store *MemoryStore
+}
+
+// NewMemorySearchRepository создает поиск по хранилищу в памяти. В отличие от PostgreSQL
+// здесь нет морфологии: запись подходит, если содержит все слова запроса как подстроки
+// без учета регистра, а релевантность равна числу вхождений.
+func NewMemorySearchRepository(store *MemoryStore) SearchRepository {
+	return &memorySearchRepository{store: store}
+}
+
+func (r *memorySearchRepository) Search(params models.SearchParams) ([]models.SearchResult, int, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	terms := searchTerms(params.Query)
+	wants := func(searchType string) bool {
+		return len(params.Types) == 0 || containsString(params.Types, searchType)
+	}
+
+	var results []models.SearchResult
+	add := func(result models.SearchResult, text string, rank int) {
+		if rank == 0 {
+			return
+		}
+		result.AuthorName = r.store.username(result.AuthorID)
+		result.Rank = float64(rank)
+		result.Snippet = highlightTerms(text, terms)
+		if matchesSearchFilters(result, params) && r.threadReadable(result.ThreadID, params.Role) {
+			results = append(results, result)
+		}
+	}
+
+	if wants(models.SearchTypeThread) {
+		for _, thread := range r.store.threads {
+			add(models.SearchResult{
+				Type:      models.SearchTypeThread,
+				ID:        thread.ID,
+				ThreadID:  thread.ID,
+				AuthorID:  thread.AuthorID,
+				Title:     thread.Title,
+				CreatedAt: thread.CreatedAt,
+			}, thread.Title, countTerms(thread.Title, terms))
+		}
+	}
+	if wants(models.SearchTypePost) {
+		for _, post := range r.store.posts {
+			add(models.SearchResult{
+				Type:      models.SearchTypePost,
+				ID:        post.ID,
+				ThreadID:  post.ThreadID,
+				PostID:    post.ID,
+				AuthorID:  post.AuthorID,
+				Title:     post.Title,
+				CreatedAt: post.CreatedAt,
+			}, post.Content, countTerms(post.Title+" "+post.Content, terms))
+		}
+	}
+	if wants(models.SearchTypeComment) {
+		for _, row := range r.store.comments {
+			post, ok := r.store.posts[row.comment.PostID]
+			if !ok || row.deletedAt != nil {
+				continue
+			}
+			add(models.SearchResult{
+				Type:      models.SearchTypeComment,
+				ID:        row.comment.ID,
+				ThreadID:  post.ThreadID,
+				PostID:    post.ID,
+				AuthorID:  row.comment.AuthorID,
+				Title:     post.Title,
+				CreatedAt: row.comment.CreatedAt,
+			}, row.comment.Content, countTerms(row.comment.Content, terms))
+		}
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Rank != results[j].Rank {
+			return results[i].Rank > results[j].Rank
+		}
+		return createdBefore(results[j].CreatedAt, results[j].ID, results[i].CreatedAt, results[i].ID)
+	})
+
+	total := len(results)
+	page := make([]models.SearchResult, 0)
+	if params.Offset < total {
+		end := params.Offset + params.Limit
+		if end > total {
+			end = total
+		}
+		page = append(page, results[params.Offset:end]...)
+	}
+	return page, total, nil
+}
+
+// threadReadable проверяет действующую read_role раздела треда, как условие
+// на category_read_roles в searchRepository
+func (r *memorySearchRepository) threadReadable(threadID int, role models.Role) bool {
+	thread, ok := r.store.threads[threadID]
+	if !ok {
+		return true
+	}
+	return role.Allows(r.store.readRoleLocked(thread.CategoryID))
+}
+
+// searchTerms разбивает запрос на слова в нижнем регистре, отбрасывая кавычки
+func searchTerms(query string) []string {
+	var terms []string
+	for _, word := range strings.Fields(strings.ToLower(query)) {
+		if word = strings.Trim(word, `"'`); word != "" {
+			terms = append(terms, word)
+		}
+	}
+	return terms
+}
+
+// countTerms возвращает число вхождений слов запроса или 0, если хотя бы одного слова нет
+func countTerms(text string, terms []string) int {
+	if len(terms) == 0 {
+		return 0
+	}
+	lower := strings.ToLower(text)
+	count := 0
+	for _, term := range terms {
+		n := strings.Count(lower, term)
+		if n == 0 {
+			return 0
+		}
+		count += n
+	}
+	return count
+}
+
+// highlightTerms вырезает фрагмент текста вокруг первого совпадения и оборачивает
+// слова с совпадениями маркерами HighlightStart/HighlightStop
+func highlightTerms(text string, terms []string) string {
+	words := strings.Fields(text)
+	first := -1
+	for i, word := range words {
+		if containsAnyTerm(word, terms) {
+			if first < 0 {
+				first = i
+			}
+			words[i] = HighlightStart + word + HighlightStop
+		}
+	}
+
+	start := 0
+	if first > memorySnippetWordsBefore {
+		start = first - memorySnippetWordsBefore
+	}
+	end := start + memorySnippetWords
+	if end > len(words) {
+		end = len(words)
+	}
+	return strings.Join(words[start:end], " ")
+}
+
+func containsAnyTerm(word string, terms []string) bool {
+	lower := strings.ToLower(word)
+	for _, term := range terms {
+		if strings.Contains(lower, term) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesSearchFilters применяет фильтры по автору и дате, как WHERE в searchRepository
+func matchesSearchFilters(result models.SearchResult, params models.SearchParams) bool {
+	if params.AuthorID > 0 && result.AuthorID != params.AuthorID {
+		return false
+	}
+	if params.AuthorName != "" && !strings.EqualFold(result.AuthorName, params.AuthorName) {
+		return false
+	}
+	if !params.From.IsZero() && result.CreatedAt.Before(params.From) {
+		return false
+	}
+	if !params.To.IsZero() && !result.CreatedAt.Before(params.To) {
+		return false
+	}
+	return true
+}
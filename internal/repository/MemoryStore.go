@@ -0,0 +1,476 @@
+package repository
+
+import (
+	"ForumService/internal/models"
+	"sort"
+	"sync"
+	"time"
+)
+
+// memoryUser - строка таблицы users в памяти. Роль хранится отдельно, как в PostgreSQL.
+type memoryUser struct {
+	user models.User
+	role string
+}
+
+// memoryReaction - ключ строки таблицы reactions в памяти
+type memoryReaction struct {
+	targetType string
+	targetID   int
+	userID     int
+	emoji      string
+}
+
+// memoryBookmark - ключ строки таблицы bookmarks в памяти
+type memoryBookmark struct {
+	userID     int
+	targetType string
+	targetID   int
+}
+
+// memorySubscription - ключ строки таблицы subscriptions в памяти
+type memorySubscription struct {
+	userID     int
+	targetType string
+	targetID   int
+}
+
+// memoryMention - ключ строки таблицы mentions в памяти
+type memoryMention struct {
+	targetType string
+	targetID   int
+	userID     int
+}
+
+// memoryComment - строка таблицы comments в памяти
+type memoryComment struct {
+	comment   models.Comment
+	deletedAt *time.Time
+}
+
+// memoryPoll - строка таблицы polls в памяти вместе с вариантами и голосами. Голоса
+// хранятся в порядке подачи, имена голосовавших берутся из users при чтении.
+type memoryPoll struct {
+	poll  models.Poll
+	votes []models.PollVote
+}
+
+// MemoryStore хранит все таблицы форума в памяти процесса. Репозитории, созданные
+// поверх одного хранилища, видят общие данные: это нужно для join'ов по авторам
+// и каскадного удаления, как во внешних ключах PostgreSQL. Все операции
+// выполняются под одной блокировкой, поэтому хранилище безопасно для конкурентного доступа.
+type MemoryStore struct {
+	mu sync.RWMutex
+
+	users      map[int]*memoryUser
+	threads    map[int]*models.Thread
+	posts      map[int]*models.Post
+	comments   map[int]*memoryComment
+	revisions  map[int]*models.PostRevision
+	messages   map[int]*models.ChatMessage
+	categories map[int]*models.Category
+	tags       map[int]*models.Tag
+	// threadTags - таблица thread_tags: теги по ID треда
+	threadTags map[int]map[int]bool
+	// postVotes и commentVotes - таблицы голосов: голос по ID пользователя для каждой записи
+	postVotes    map[int]map[int]int
+	commentVotes map[int]map[int]int
+	// reactions - таблица reactions, значение - порядковый номер реакции вместо created_at
+	reactions map[memoryReaction]int
+	// bookmarks - таблица bookmarks, закладка хранится вместе с заметкой и временем создания
+	bookmarks map[memoryBookmark]*models.Bookmark
+	// subscriptions - таблица subscriptions
+	subscriptions map[memorySubscription]bool
+	// notifications - таблица notifications по ID уведомления
+	notifications map[int]*models.Notification
+	// mentions - таблица mentions
+	mentions map[memoryMention]bool
+	// attachments - таблица attachments по ID вложения
+	attachments map[int]*models.Attachment
+	// polls - таблицы polls, poll_options и poll_votes по ID опроса
+	polls map[int]*memoryPoll
+
+	// lastID - последние выданные значения SERIAL по таблицам
+	lastID map[string]int
+	// lastTime - последняя выданная метка времени, чтобы порядок записей был строгим
+	lastTime time.Time
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		users:         make(map[int]*memoryUser),
+		threads:       make(map[int]*models.Thread),
+		posts:         make(map[int]*models.Post),
+		comments:      make(map[int]*memoryComment),
+		revisions:     make(map[int]*models.PostRevision),
+		messages:      make(map[int]*models.ChatMessage),
+		categories:    make(map[int]*models.Category),
+		tags:          make(map[int]*models.Tag),
+		threadTags:    make(map[int]map[int]bool),
+		postVotes:     make(map[int]map[int]int),
+		commentVotes:  make(map[int]map[int]int),
+		reactions:     make(map[memoryReaction]int),
+		bookmarks:     make(map[memoryBookmark]*models.Bookmark),
+		subscriptions: make(map[memorySubscription]bool),
+		notifications: make(map[int]*models.Notification),
+		mentions:      make(map[memoryMention]bool),
+		attachments:   make(map[int]*models.Attachment),
+		polls:         make(map[int]*memoryPoll),
+		lastID:        make(map[string]int),
+	}
+}
+
+// EnsureUser добавляет пользователя с заданным ID или обновляет его имя и роль.
+// Пользователей создает AuthService, поэтому при работе в памяти они регистрируются
+// по мере прохождения аутентификации.
+func (s *MemoryStore) EnsureUser(id int, username string, role string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if role == "" {
+		role = string(models.RoleUser)
+	}
+	if existing, ok := s.users[id]; ok {
+		existing.user.Username = username
+		existing.role = role
+		return
+	}
+	s.users[id] = &memoryUser{
+		user: models.User{ID: id, Username: username, Email: username + "@localhost"},
+		role: role,
+	}
+	if id > s.lastID["users"] {
+		s.lastID["users"] = id
+	}
+}
+
+// nextID возвращает следующий идентификатор таблицы. Вызывается под блокировкой записи.
+func (s *MemoryStore) nextID(table string) int {
+	s.lastID[table]++
+	return s.lastID[table]
+}
+
+// now возвращает текущее время с точностью PostgreSQL, строго большее предыдущего.
+// Вызывается под блокировкой записи.
+func (s *MemoryStore) now() time.Time {
+	now := time.Now().UTC().Truncate(time.Microsecond)
+	if !now.After(s.lastTime) {
+		now = s.lastTime.Add(time.Microsecond)
+	}
+	s.lastTime = now
+	return now
+}
+
+// username возвращает имя автора для join'а, пустую строку для неизвестного пользователя
+func (s *MemoryStore) username(userID int) string {
+	if user, ok := s.users[userID]; ok {
+		return user.user.Username
+	}
+	return ""
+}
+
+// lockedByName возвращает имя модератора, закрывшего тред
+func (s *MemoryStore) lockedByName(thread *models.Thread) string {
+	if thread.LockedByID == nil {
+		return ""
+	}
+	return s.username(*thread.LockedByID)
+}
+
+// categoryName возвращает название раздела для join'а, пустую строку для треда вне разделов
+func (s *MemoryStore) categoryName(categoryID *int) string {
+	if categoryID == nil {
+		return ""
+	}
+	if category, ok := s.categories[*categoryID]; ok {
+		return category.Name
+	}
+	return ""
+}
+
+// readRoleLocked возвращает роль, нужную для чтения раздела categoryID, как category_read_roles
+// в базе: самую строгую read_role на пути к корню. Треды вне разделов читают все.
+func (s *MemoryStore) readRoleLocked(categoryID *int) models.Role {
+	role := models.RoleGuest
+	seen := make(map[int]bool)
+	for id := categoryID; id != nil && !seen[*id]; {
+		category, ok := s.categories[*id]
+		if !ok {
+			break
+		}
+		seen[*id] = true
+		role = role.Stricter(category.ReadRole)
+		id = category.ParentID
+	}
+	return role
+}
+
+// checkCategoryLocked проверяет ссылку на раздел, как внешний ключ threads.category_id
+func (s *MemoryStore) checkCategoryLocked(categoryID *int) error {
+	if categoryID == nil {
+		return nil
+	}
+	if _, ok := s.categories[*categoryID]; !ok {
+		return ErrCategoryNotFound
+	}
+	return nil
+}
+
+// deletePostLocked удаляет пост вместе с комментариями и ревизиями. Если пост был
+// принятым ответом, отметка с треда снимается, а у ответов на пост пропадает ссылка
+// на него, как ON DELETE SET NULL в базе.
+func (s *MemoryStore) deletePostLocked(postID int) {
+	if post, ok := s.posts[postID]; ok {
+		if thread, ok := s.threads[post.ThreadID]; ok && thread.AcceptedPostID != nil && *thread.AcceptedPostID == postID {
+			thread.AcceptedPostID = nil
+			thread.Solved = false
+		}
+	}
+	for _, post := range s.posts {
+		if post.ReplyToPostID != nil && *post.ReplyToPostID == postID {
+			post.ReplyToPostID = nil
+		}
+	}
+	for id, comment := range s.comments {
+		if comment.comment.PostID == postID {
+			delete(s.comments, id)
+			delete(s.commentVotes, id)
+			s.deleteReactionsLocked(models.ReactionTargetComment, id)
+			s.deleteMentionsLocked(models.MentionTargetComment, id)
+			s.detachAttachmentsLocked(models.AttachmentTargetComment, id)
+		}
+	}
+	for id, revision := range s.revisions {
+		if revision.PostID == postID {
+			delete(s.revisions, id)
+		}
+	}
+	delete(s.posts, postID)
+	delete(s.postVotes, postID)
+	s.deleteReactionsLocked(models.ReactionTargetPost, postID)
+	s.deleteMentionsLocked(models.MentionTargetPost, postID)
+	s.detachAttachmentsLocked(models.AttachmentTargetPost, postID)
+	s.deleteBookmarksLocked(models.BookmarkTargetPost, postID)
+	s.deleteSubscriptionsLocked(models.SubscriptionTargetPost, postID)
+	s.deleteNotificationsLocked(func(notification *models.Notification) bool {
+		return notification.PostID == postID
+	})
+}
+
+// deleteCommentLocked удаляет комментарий и всю ветку ответов на него
+func (s *MemoryStore) deleteCommentLocked(commentID int) {
+	delete(s.comments, commentID)
+	delete(s.commentVotes, commentID)
+	s.deleteReactionsLocked(models.ReactionTargetComment, commentID)
+	s.deleteMentionsLocked(models.MentionTargetComment, commentID)
+	s.detachAttachmentsLocked(models.AttachmentTargetComment, commentID)
+	s.deleteNotificationsLocked(func(notification *models.Notification) bool {
+		return notification.CommentID != nil && *notification.CommentID == commentID
+	})
+	for id, comment := range s.comments {
+		if comment.comment.ParentCommentID != nil && *comment.comment.ParentCommentID == commentID {
+			s.deleteCommentLocked(id)
+		}
+	}
+}
+
+// deleteReactionsLocked удаляет реакции на запись, как триггеры таблицы reactions
+func (s *MemoryStore) deleteReactionsLocked(targetType string, targetID int) {
+	for key := range s.reactions {
+		if key.targetType == targetType && key.targetID == targetID {
+			delete(s.reactions, key)
+		}
+	}
+}
+
+// deleteMentionsLocked удаляет упоминания в записи, как триггеры таблицы mentions
+func (s *MemoryStore) deleteMentionsLocked(targetType string, targetID int) {
+	for key := range s.mentions {
+		if key.targetType == targetType && key.targetID == targetID {
+			delete(s.mentions, key)
+		}
+	}
+}
+
+// detachAttachmentsLocked отвязывает вложения от удаленной записи, как ON DELETE SET NULL
+// в базе. Отвязанные вложения потом удаляет сборщик мусора.
+func (s *MemoryStore) detachAttachmentsLocked(targetType string, targetID int) {
+	for _, attachment := range s.attachments {
+		switch {
+		case targetType == models.AttachmentTargetPost && attachment.PostID != nil && *attachment.PostID == targetID:
+			attachment.PostID = nil
+		case targetType == models.AttachmentTargetComment && attachment.CommentID != nil && *attachment.CommentID == targetID:
+			attachment.CommentID = nil
+		}
+	}
+}
+
+// deleteMessageLocked удаляет сообщение чата с реакциями и упоминаниями. Уведомления
+// об упоминаниях остаются без ссылки на сообщение, как ON DELETE SET NULL в базе.
+func (s *MemoryStore) deleteMessageLocked(messageID int) {
+	delete(s.messages, messageID)
+	s.deleteReactionsLocked(models.ReactionTargetChat, messageID)
+	s.deleteMentionsLocked(models.MentionTargetChat, messageID)
+	for _, notification := range s.notifications {
+		if notification.ChatMessageID != nil && *notification.ChatMessageID == messageID {
+			notification.ChatMessageID = nil
+		}
+	}
+}
+
+// deleteBookmarksLocked удаляет закладки на запись, как триггеры таблицы bookmarks
+func (s *MemoryStore) deleteBookmarksLocked(targetType string, targetID int) {
+	for key := range s.bookmarks {
+		if key.targetType == targetType && key.targetID == targetID {
+			delete(s.bookmarks, key)
+		}
+	}
+}
+
+// deleteSubscriptionsLocked удаляет подписки на запись, как триггеры таблицы subscriptions
+func (s *MemoryStore) deleteSubscriptionsLocked(targetType string, targetID int) {
+	for key := range s.subscriptions {
+		if key.targetType == targetType && key.targetID == targetID {
+			delete(s.subscriptions, key)
+		}
+	}
+}
+
+// deleteNotificationsLocked удаляет уведомления об удаленной записи, как внешние ключи таблицы notifications
+func (s *MemoryStore) deleteNotificationsLocked(match func(notification *models.Notification) bool) {
+	for id, notification := range s.notifications {
+		if match(notification) {
+			delete(s.notifications, id)
+		}
+	}
+}
+
+// commentRow собирает модель комментария так же, как fillCommentState для строки из базы
+func (s *MemoryStore) commentRow(row *memoryComment, withAuthor bool) models.Comment {
+	comment := row.comment
+	if comment.ParentCommentID != nil {
+		parentID := *comment.ParentCommentID
+		comment.ParentCommentID = &parentID
+	}
+	comment.Path = nil
+	comment.Deleted = row.deletedAt != nil
+	if comment.Deleted {
+		comment.Content = ""
+	}
+	comment.Edited = !comment.Deleted && comment.UpdatedAt.After(comment.CreatedAt)
+	if withAuthor {
+		comment.AuthorName = s.username(comment.AuthorID)
+	}
+	return comment
+}
+
+// postComments возвращает комментарии поста в порядке создания
+func (s *MemoryStore) postComments(postID int, withAuthor bool) []models.Comment {
+	var comments []models.Comment
+	for _, row := range s.comments {
+		if row.comment.PostID == postID {
+			comments = append(comments, s.commentRow(row, withAuthor))
+		}
+	}
+	sort.Slice(comments, func(i, j int) bool {
+		return createdBefore(comments[i].CreatedAt, comments[i].ID, comments[j].CreatedAt, comments[j].ID)
+	})
+	return comments
+}
+
+// refreshCountersLocked пересчитывает денормализованные счетчики треда и его постов.
+// В PostgreSQL они сдвигаются запросами, здесь дешевле посчитать заново под той же блокировкой.
+// Возвращает, изменились ли счетчики треда, и число постов с изменившимся счетчиком.
+func (s *MemoryStore) refreshCountersLocked(threadID int) (bool, int) {
+	thread, ok := s.threads[threadID]
+	if !ok {
+		return false, 0
+	}
+
+	participants := map[int]bool{thread.AuthorID: true}
+	commentCounts := make(map[int]int)
+	for _, row := range s.comments {
+		post, ok := s.posts[row.comment.PostID]
+		if !ok || post.ThreadID != threadID || row.deletedAt != nil {
+			continue
+		}
+		commentCounts[post.ID]++
+		participants[row.comment.AuthorID] = true
+	}
+
+	postCount, commentCount, postsChanged := 0, 0, 0
+	var lastPost *models.Post
+	for _, post := range s.posts {
+		if post.ThreadID != threadID {
+			continue
+		}
+		postCount++
+		commentCount += commentCounts[post.ID]
+		participants[post.AuthorID] = true
+		if post.CommentCount != commentCounts[post.ID] {
+			post.CommentCount = commentCounts[post.ID]
+			postsChanged++
+		}
+		if lastPost == nil || createdBefore(lastPost.CreatedAt, lastPost.ID, post.CreatedAt, post.ID) {
+			lastPost = post
+		}
+	}
+
+	var lastPostAt *time.Time
+	var lastPostAuthorID *int
+	if lastPost != nil {
+		at, authorID := lastPost.CreatedAt, lastPost.AuthorID
+		lastPostAt, lastPostAuthorID = &at, &authorID
+	}
+
+	changed := thread.PostCount != postCount ||
+		thread.CommentCount != commentCount ||
+		thread.ParticipantCount != len(participants) ||
+		!equalTimePtr(thread.LastPostAt, lastPostAt) ||
+		!equalIntPtr(thread.LastPostAuthorID, lastPostAuthorID)
+	if changed {
+		thread.PostCount = postCount
+		thread.CommentCount = commentCount
+		thread.ParticipantCount = len(participants)
+		thread.LastPostAt = lastPostAt
+		thread.LastPostAuthorID = lastPostAuthorID
+	}
+	return changed, postsChanged
+}
+
+// refreshCommentThreadLocked пересчитывает счетчики треда, которому принадлежит пост
+func (s *MemoryStore) refreshCommentThreadLocked(postID int) {
+	if post, ok := s.posts[postID]; ok {
+		s.refreshCountersLocked(post.ThreadID)
+	}
+}
+
+func equalTimePtr(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Equal(*b)
+}
+
+func cloneIntPtr(value *int) *int {
+	if value == nil {
+		return nil
+	}
+	copied := *value
+	return &copied
+}
+
+func equalIntPtr(a, b *int) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+// createdBefore сравнивает записи по времени создания, при равенстве - по ID
+func createdBefore(a time.Time, aID int, b time.Time, bID int) bool {
+	if !a.Equal(b) {
+		return a.Before(b)
+	}
+	return aID < bID
+}
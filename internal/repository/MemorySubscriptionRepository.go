@@ -0,0 +1,77 @@
+package repository
+
+import (
+	"ForumService/internal/models"
+	"fmt"
+	"sort"
+)
+
+type memorySubscriptionRepository struct {
+	store *MemoryStore
+}
+
+// NewMemorySubscriptionRepository создает репозиторий подписок поверх хранилища в памяти
+func NewMemorySubscriptionRepository(store *MemoryStore) SubscriptionRepository {
+	return &memorySubscriptionRepository{store: store}
+}
+
+func (r *memorySubscriptionRepository) Subscribe(userID int, targetType string, targetID int) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	switch targetType {
+	case models.SubscriptionTargetThread:
+		if _, ok := r.store.threads[targetID]; !ok {
+			return ErrThreadNotFound
+		}
+	case models.SubscriptionTargetPost:
+		if _, ok := r.store.posts[targetID]; !ok {
+			return ErrPostNotFound
+		}
+	default:
+		return fmt.Errorf("неизвестный вид записи: %s", targetType)
+	}
+
+	key := memorySubscription{userID: userID, targetType: targetType, targetID: targetID}
+	if r.store.subscriptions[key] {
+		return nil
+	}
+	if _, ok := r.store.users[userID]; !ok {
+		return ErrUserNotFound
+	}
+	r.store.subscriptions[key] = true
+	return nil
+}
+
+func (r *memorySubscriptionRepository) Unsubscribe(userID int, targetType string, targetID int) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	key := memorySubscription{userID: userID, targetType: targetType, targetID: targetID}
+	if !r.store.subscriptions[key] {
+		return ErrSubscriptionNotFound
+	}
+	delete(r.store.subscriptions, key)
+	return nil
+}
+
+func (r *memorySubscriptionRepository) IsSubscribed(userID int, targetType string, targetID int) (bool, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	return r.store.subscriptions[memorySubscription{userID: userID, targetType: targetType, targetID: targetID}], nil
+}
+
+func (r *memorySubscriptionRepository) GetSubscribers(targetType string, targetID int) ([]int, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	subscribers := make([]int, 0)
+	for key := range r.store.subscriptions {
+		if key.targetType == targetType && key.targetID == targetID {
+			subscribers = append(subscribers, key.userID)
+		}
+	}
+	sort.Ints(subscribers)
+	return subscribers, nil
+}
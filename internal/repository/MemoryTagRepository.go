@@ -0,0 +1,168 @@
+package repository
+
+import (
+	"ForumService/internal/models"
+	"sort"
+)
+
+type memoryTagRepository struct {
+	store *MemoryStore
+}
+
+// NewMemoryTagRepository создает репозиторий тегов поверх хранилища в памяти
+func NewMemoryTagRepository(store *MemoryStore) TagRepository {
+	return &memoryTagRepository{store: store}
+}
+
+func (r *memoryTagRepository) GetAll() ([]*models.Tag, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	var tags []*models.Tag
+	for _, stored := range r.store.tags {
+		if tag := r.tagRow(stored); tag.ThreadCount > 0 {
+			tags = append(tags, tag)
+		}
+	}
+	sort.Slice(tags, func(i, j int) bool {
+		if tags[i].ThreadCount != tags[j].ThreadCount {
+			return tags[i].ThreadCount > tags[j].ThreadCount
+		}
+		return tags[i].Name < tags[j].Name
+	})
+	return tags, nil
+}
+
+func (r *memoryTagRepository) GetByID(id int) (*models.Tag, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	stored, ok := r.store.tags[id]
+	if !ok {
+		return nil, ErrTagNotFound
+	}
+	return r.tagRow(stored), nil
+}
+
+func (r *memoryTagRepository) GetByThreadIDs(threadIDs []int) (map[int][]string, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	tags := make(map[int][]string)
+	for _, threadID := range threadIDs {
+		for tagID := range r.store.threadTags[threadID] {
+			tags[threadID] = append(tags[threadID], r.store.tags[tagID].Name)
+		}
+		sort.Strings(tags[threadID])
+	}
+	return tags, nil
+}
+
+func (r *memoryTagRepository) SetThreadTags(threadID int, names []string) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if _, ok := r.store.threads[threadID]; !ok {
+		return ErrThreadNotFound
+	}
+
+	tagIDs := make(map[int]bool, len(names))
+	for _, name := range names {
+		tag := r.findByName(name)
+		if tag == nil {
+			tag = &models.Tag{ID: r.store.nextID("tags"), Name: name, CreatedAt: r.store.now()}
+			r.store.tags[tag.ID] = tag
+		}
+		tagIDs[tag.ID] = true
+	}
+	if len(tagIDs) == 0 {
+		delete(r.store.threadTags, threadID)
+		return nil
+	}
+	r.store.threadTags[threadID] = tagIDs
+	return nil
+}
+
+func (r *memoryTagRepository) GetThreadIDs(names []string, matchAll bool) ([]int, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	if len(names) == 0 {
+		return nil, nil
+	}
+	required := 1
+	if matchAll {
+		required = len(names)
+	}
+
+	var threadIDs []int
+	for threadID, tagIDs := range r.store.threadTags {
+		matched := 0
+		for _, name := range names {
+			if tag := r.findByName(name); tag != nil && tagIDs[tag.ID] {
+				matched++
+			}
+		}
+		if matched >= required {
+			threadIDs = append(threadIDs, threadID)
+		}
+	}
+	sort.Ints(threadIDs)
+	return threadIDs, nil
+}
+
+func (r *memoryTagRepository) Rename(id int, name string) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	tag, ok := r.store.tags[id]
+	if !ok {
+		return ErrTagNotFound
+	}
+	if existing := r.findByName(name); existing != nil && existing.ID != id {
+		return ErrAlreadyExists
+	}
+	tag.Name = name
+	return nil
+}
+
+func (r *memoryTagRepository) Merge(sourceID, targetID int) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	_, sourceOK := r.store.tags[sourceID]
+	_, targetOK := r.store.tags[targetID]
+	if !sourceOK || !targetOK {
+		return ErrTagNotFound
+	}
+
+	for _, tagIDs := range r.store.threadTags {
+		if tagIDs[sourceID] {
+			delete(tagIDs, sourceID)
+			tagIDs[targetID] = true
+		}
+	}
+	delete(r.store.tags, sourceID)
+	return nil
+}
+
+// findByName ищет тег по названию. Вызывается под блокировкой хранилища.
+func (r *memoryTagRepository) findByName(name string) *models.Tag {
+	for _, tag := range r.store.tags {
+		if tag.Name == name {
+			return tag
+		}
+	}
+	return nil
+}
+
+// tagRow копирует тег и считает его треды
+func (r *memoryTagRepository) tagRow(stored *models.Tag) *models.Tag {
+	tag := *stored
+	for _, tagIDs := range r.store.threadTags {
+		if tagIDs[tag.ID] {
+			tag.ThreadCount++
+		}
+	}
+	return &tag
+}
@@ -0,0 +1,300 @@
+package repository
+
+import (
+	"ForumService/internal/models"
+	"sort"
+	"time"
+)
+
+type memoryThreadRepository struct {
+	store *MemoryStore
+}
+
+// NewMemoryThreadRepository создает репозиторий тредов поверх хранилища в памяти
+func NewMemoryThreadRepository(store *MemoryStore) ThreadRepository {
+	return &memoryThreadRepository{store: store}
+}
+
+func (r *memoryThreadRepository) Create(thread *models.Thread) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if _, ok := r.store.users[thread.AuthorID]; !ok {
+		return ErrUserNotFound
+	}
+	if err := r.store.checkCategoryLocked(thread.CategoryID); err != nil {
+		return err
+	}
+
+	now := r.store.now()
+	stored := &models.Thread{
+		ID:         r.store.nextID("threads"),
+		Title:      thread.Title,
+		Type:       threadType(thread.Type),
+		AuthorID:   thread.AuthorID,
+		CreatedAt:  now,
+		UpdatedAt:  now,
+		Version:    1,
+		CategoryID: cloneIntPtr(thread.CategoryID),
+	}
+	r.store.threads[stored.ID] = stored
+	r.store.refreshCountersLocked(stored.ID)
+
+	*thread = *stored
+	thread.CategoryName = r.store.categoryName(stored.CategoryID)
+	return nil
+}
+
+func (r *memoryThreadRepository) GetByID(id int) (*models.Thread, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	stored, ok := r.store.threads[id]
+	if !ok {
+		return nil, ErrThreadNotFound
+	}
+	thread := *stored
+	thread.CategoryName = r.store.categoryName(thread.CategoryID)
+	thread.LockedByName = r.store.lockedByName(&thread)
+	thread.Pinned = pinActive(&thread, time.Now())
+	return &thread, nil
+}
+
+// Update сохраняет название и раздел треда. Если thread.Version больше нуля, тред обновляется
+// только при совпадении версии, иначе возвращается ErrVersionConflict.
+func (r *memoryThreadRepository) Update(thread *models.Thread) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	stored, ok := r.store.threads[thread.ID]
+	if !ok {
+		return ErrThreadNotFound
+	}
+	if thread.Version > 0 && thread.Version != stored.Version {
+		return ErrVersionConflict
+	}
+	if err := r.store.checkCategoryLocked(thread.CategoryID); err != nil {
+		return err
+	}
+
+	stored.Title = thread.Title
+	stored.Type = threadType(thread.Type)
+	stored.CategoryID = cloneIntPtr(thread.CategoryID)
+	stored.Version++
+	stored.UpdatedAt = r.store.now()
+
+	thread.Version = stored.Version
+	thread.UpdatedAt = stored.UpdatedAt
+	return nil
+}
+
+// Lock закрывает тред от имени модератора userID. Повторное закрытие обновляет причину.
+func (r *memoryThreadRepository) Lock(id int, userID int, reason string) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	stored, ok := r.store.threads[id]
+	if !ok {
+		return ErrThreadNotFound
+	}
+	now := r.store.now()
+	stored.Locked = true
+	stored.LockedAt = &now
+	stored.LockedByID = &userID
+	stored.LockReason = reason
+	stored.Version++
+	return nil
+}
+
+// Unlock открывает тред и сбрасывает сведения о закрытии
+func (r *memoryThreadRepository) Unlock(id int) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	stored, ok := r.store.threads[id]
+	if !ok {
+		return ErrThreadNotFound
+	}
+	stored.Locked = false
+	stored.LockedAt = nil
+	stored.LockedByID = nil
+	stored.LockReason = ""
+	stored.Version++
+	return nil
+}
+
+// Pin закрепляет тред с порядком order до момента until, nil - бессрочно.
+// Повторное закрепление обновляет порядок и срок.
+func (r *memoryThreadRepository) Pin(id int, order int, until *time.Time) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	stored, ok := r.store.threads[id]
+	if !ok {
+		return ErrThreadNotFound
+	}
+	now := r.store.now()
+	stored.PinnedAt = &now
+	stored.PinOrder = order
+	stored.PinnedUntil = nil
+	if until != nil {
+		at := until.UTC().Truncate(time.Microsecond)
+		stored.PinnedUntil = &at
+	}
+	stored.Version++
+	return nil
+}
+
+// Unpin снимает закрепление треда
+func (r *memoryThreadRepository) Unpin(id int) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	stored, ok := r.store.threads[id]
+	if !ok {
+		return ErrThreadNotFound
+	}
+	stored.PinnedAt = nil
+	stored.PinOrder = 0
+	stored.PinnedUntil = nil
+	stored.Version++
+	return nil
+}
+
+// SetAcceptedPost отмечает пост postID принятым ответом треда, nil снимает отметку.
+// Принадлежность поста треду проверяет сервис.
+func (r *memoryThreadRepository) SetAcceptedPost(id int, postID *int) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	stored, ok := r.store.threads[id]
+	if !ok {
+		return ErrThreadNotFound
+	}
+	if postID != nil {
+		if _, ok := r.store.posts[*postID]; !ok {
+			return ErrPostNotFound
+		}
+	}
+	stored.AcceptedPostID = cloneIntPtr(postID)
+	stored.Solved = postID != nil
+	stored.Version++
+	return nil
+}
+
+// Delete удаляет тред вместе с постами и их комментариями
+func (r *memoryThreadRepository) Delete(id int) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if _, ok := r.store.threads[id]; !ok {
+		return ErrThreadNotFound
+	}
+	for postID, post := range r.store.posts {
+		if post.ThreadID == id {
+			r.store.deletePostLocked(postID)
+		}
+	}
+	delete(r.store.threads, id)
+	delete(r.store.threadTags, id)
+	for pollID, poll := range r.store.polls {
+		if poll.poll.ThreadID == id {
+			delete(r.store.polls, pollID)
+		}
+	}
+	r.store.deleteBookmarksLocked(models.BookmarkTargetThread, id)
+	r.store.deleteSubscriptionsLocked(models.SubscriptionTargetThread, id)
+	r.store.deleteNotificationsLocked(func(notification *models.Notification) bool {
+		return notification.ThreadID == id
+	})
+	return nil
+}
+
+func (r *memoryThreadRepository) GetAllThreads() ([]*models.Thread, error) {
+	return r.listThreads(func(*models.Thread) bool { return true }), nil
+}
+
+// GetByCategoryID возвращает треды раздела без подразделов
+func (r *memoryThreadRepository) GetByCategoryID(categoryID int) ([]*models.Thread, error) {
+	return r.listThreads(func(thread *models.Thread) bool {
+		return thread.CategoryID != nil && *thread.CategoryID == categoryID
+	}), nil
+}
+
+// listThreads возвращает подходящие треды с авторами и разделами: сначала закрепленные
+// по возрастанию PinOrder, затем остальные, новые первыми
+func (r *memoryThreadRepository) listThreads(match func(*models.Thread) bool) []*models.Thread {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	now := time.Now()
+	var threads []*models.Thread
+	for _, stored := range r.store.threads {
+		if !match(stored) {
+			continue
+		}
+		thread := *stored
+		thread.AuthorName = r.store.username(thread.AuthorID)
+		thread.CategoryName = r.store.categoryName(thread.CategoryID)
+		if thread.LastPostAuthorID != nil {
+			thread.LastPostAuthorName = r.store.username(*thread.LastPostAuthorID)
+		}
+		thread.LockedByName = r.store.lockedByName(&thread)
+		thread.Pinned = pinActive(&thread, now)
+		threads = append(threads, &thread)
+	}
+	sort.Slice(threads, func(i, j int) bool {
+		if threads[i].Pinned != threads[j].Pinned {
+			return threads[i].Pinned
+		}
+		if threads[i].Pinned && threads[i].PinOrder != threads[j].PinOrder {
+			return threads[i].PinOrder < threads[j].PinOrder
+		}
+		return createdBefore(threads[j].CreatedAt, threads[j].ID, threads[i].CreatedAt, threads[i].ID)
+	})
+	return threads
+}
+
+// GetThreadWithPosts получает тред по ID вместе со всеми постами и их комментариями
+func (r *memoryThreadRepository) GetThreadWithPosts(threadID int) (*models.Thread, []models.Post, map[int][]models.Comment, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	stored, ok := r.store.threads[threadID]
+	if !ok {
+		return nil, nil, nil, ErrThreadNotFound
+	}
+	thread := *stored
+
+	var posts []models.Post
+	for _, post := range r.store.posts {
+		if post.ThreadID == threadID {
+			posts = append(posts, models.Post{
+				ID:        post.ID,
+				ThreadID:  post.ThreadID,
+				AuthorID:  post.AuthorID,
+				Content:   post.Content,
+				CreatedAt: post.CreatedAt,
+				UpdatedAt: post.UpdatedAt,
+			})
+		}
+	}
+	sort.Slice(posts, func(i, j int) bool {
+		return createdBefore(posts[j].CreatedAt, posts[j].ID, posts[i].CreatedAt, posts[i].ID)
+	})
+
+	commentsByPostID := make(map[int][]models.Comment)
+	for _, post := range posts {
+		for _, comment := range r.store.postComments(post.ID, false) {
+			commentsByPostID[post.ID] = append(commentsByPostID[post.ID], models.Comment{
+				ID:        comment.ID,
+				PostID:    comment.PostID,
+				AuthorID:  comment.AuthorID,
+				Content:   comment.Content,
+				CreatedAt: comment.CreatedAt,
+			})
+		}
+	}
+
+	return &thread, posts, commentsByPostID, nil
+}
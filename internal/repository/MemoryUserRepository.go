@@ -0,0 +1,104 @@
+package repository
+
+import (
+	"ForumService/internal/models"
+	"sort"
+)
+
+type memoryUserRepository struct {
+	store *MemoryStore
+}
+
+// NewMemoryUserRepository создает репозиторий пользователей поверх хранилища в памяти
+func NewMemoryUserRepository(store *MemoryStore) UserRepository {
+	return &memoryUserRepository{store: store}
+}
+
+func (r *memoryUserRepository) SaveUser(user *models.User) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	for _, existing := range r.store.users {
+		if existing.user.Username == user.Username || existing.user.Email == user.Email {
+			return ErrAlreadyExists
+		}
+	}
+
+	user.ID = r.store.nextID("users")
+	r.store.users[user.ID] = &memoryUser{user: *user, role: string(models.RoleUser)}
+	return nil
+}
+
+func (r *memoryUserRepository) GetUserByID(id int) (*models.User, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	stored, ok := r.store.users[id]
+	if !ok {
+		return nil, ErrUserNotFound
+	}
+	user := stored.user
+	return &user, nil
+}
+
+func (r *memoryUserRepository) GetUserByUsername(username string) (*models.User, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	for _, stored := range r.store.users {
+		if stored.user.Username == username {
+			user := stored.user
+			return &user, nil
+		}
+	}
+	return nil, ErrUserNotFound
+}
+
+func (r *memoryUserRepository) GetUserPosts(userID int) ([]*models.Post, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	var posts []*models.Post
+	for _, stored := range r.store.posts {
+		if stored.AuthorID != userID {
+			continue
+		}
+		posts = append(posts, &models.Post{
+			ID:        stored.ID,
+			ThreadID:  stored.ThreadID,
+			AuthorID:  stored.AuthorID,
+			Title:     stored.Title,
+			Content:   stored.Content,
+			CreatedAt: stored.CreatedAt,
+			UpdatedAt: stored.UpdatedAt,
+		})
+	}
+	sort.Slice(posts, func(i, j int) bool {
+		return posts[i].ID < posts[j].ID
+	})
+	return posts, nil
+}
+
+func (r *memoryUserRepository) GetUserCommentCount(userID int) (int, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	count := 0
+	for _, stored := range r.store.comments {
+		if stored.comment.AuthorID == userID {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (r *memoryUserRepository) GetUserRole(userID int) (string, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	stored, ok := r.store.users[userID]
+	if !ok {
+		return "", ErrUserNotFound
+	}
+	return stored.role, nil
+}
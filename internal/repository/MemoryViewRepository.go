@@ -0,0 +1,28 @@
+package repository
+
+type memoryViewRepository struct {
+	store *MemoryStore
+}
+
+// NewMemoryViewRepository создает репозиторий просмотров поверх хранилища в памяти
+func NewMemoryViewRepository(store *MemoryStore) ViewRepository {
+	return &memoryViewRepository{store: store}
+}
+
+// AddViews прибавляет просмотры к существующим тредам и постам
+func (r *memoryViewRepository) AddViews(threadViews map[int]int, postViews map[int]int) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	for id, count := range threadViews {
+		if thread, ok := r.store.threads[id]; ok {
+			thread.ViewCount += count
+		}
+	}
+	for id, count := range postViews {
+		if post, ok := r.store.posts[id]; ok {
+			post.ViewCount += count
+		}
+	}
+	return nil
+}
@@ -0,0 +1,67 @@
+package repository
+
+import "ForumService/internal/models"
+
+type memoryVoteRepository struct {
+	store *MemoryStore
+}
+
+// NewMemoryVoteRepository создает репозиторий голосов поверх хранилища в памяти
+func NewMemoryVoteRepository(store *MemoryStore) VoteRepository {
+	return &memoryVoteRepository{store: store}
+}
+
+func (r *memoryVoteRepository) VotePost(postID, userID, value int) (models.VoteResult, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	post, ok := r.store.posts[postID]
+	if !ok {
+		return models.VoteResult{}, ErrPostNotFound
+	}
+	return r.voteLocked(r.store.postVotes, postID, userID, value, &post.Upvotes, &post.Downvotes, &post.Score)
+}
+
+func (r *memoryVoteRepository) UnvotePost(postID, userID int) (models.VoteResult, error) {
+	return r.VotePost(postID, userID, 0)
+}
+
+func (r *memoryVoteRepository) VoteComment(commentID, userID, value int) (models.VoteResult, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	row, ok := r.store.comments[commentID]
+	if !ok {
+		return models.VoteResult{}, ErrCommentNotFound
+	}
+	comment := &row.comment
+	return r.voteLocked(r.store.commentVotes, commentID, userID, value, &comment.Upvotes, &comment.Downvotes, &comment.Score)
+}
+
+func (r *memoryVoteRepository) UnvoteComment(commentID, userID int) (models.VoteResult, error) {
+	return r.VoteComment(commentID, userID, 0)
+}
+
+// voteLocked заменяет голос пользователя в таблице votes и сдвигает итоги записи
+func (r *memoryVoteRepository) voteLocked(votes map[int]map[int]int, id, userID, value int, upvotes, downvotes, score *int) (models.VoteResult, error) {
+	// Как внешний ключ votes.user_id: отзыв голоса пользователя не проверяет
+	if _, ok := r.store.users[userID]; !ok && value != 0 {
+		return models.VoteResult{}, ErrUserNotFound
+	}
+
+	previous := votes[id][userID]
+	if value == 0 {
+		delete(votes[id], userID)
+	} else {
+		if votes[id] == nil {
+			votes[id] = make(map[int]int)
+		}
+		votes[id][userID] = value
+	}
+
+	upDelta, downDelta := voteDelta(previous, value)
+	*upvotes += upDelta
+	*downvotes += downDelta
+	*score = *upvotes - *downvotes
+	return models.VoteResult{Upvotes: *upvotes, Downvotes: *downvotes, Score: *score, MyVote: value}, nil
+}
@@ -0,0 +1,154 @@
+package repository
+
+import (
+	"ForumService/internal/models"
+	"database/sql"
+	"fmt"
+	"sort"
+
+	"github.com/lib/pq"
+)
+
+// mentionTables - таблицы записей, в которых упоминают пользователей, по виду записи
+var mentionTables = map[string]struct {
+	table    string
+	notFound error
+}{
+	models.MentionTargetPost:    {table: "posts", notFound: ErrPostNotFound},
+	models.MentionTargetComment: {table: "comments", notFound: ErrCommentNotFound},
+	models.MentionTargetChat:    {table: "chat_messages", notFound: ErrMessageNotFound},
+}
+
+type mentionRepository struct {
+	db *sql.DB
+}
+
+func NewMentionRepository(db *sql.DB) MentionRepository {
+	return &mentionRepository{db: db}
+}
+
+func (r *mentionRepository) ResolveUsernames(usernames []string) ([]models.Mention, error) {
+	mentions := make([]models.Mention, 0)
+	if len(usernames) == 0 {
+		return mentions, nil
+	}
+
+	rows, err := r.db.Query(`SELECT id, username FROM users WHERE username = ANY($1) ORDER BY username`,
+		pq.Array(usernames))
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при поиске упомянутых пользователей: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var mention models.Mention
+		if err := rows.Scan(&mention.UserID, &mention.Username); err != nil {
+			return nil, fmt.Errorf("ошибка при сканировании пользователя: %w", err)
+		}
+		mentions = append(mentions, mention)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("ошибка при итерации по пользователям: %w", err)
+	}
+	return mentions, nil
+}
+
+// Replace блокирует запись на удаление, пока меняются упоминания: у mentions нет внешнего
+// ключа на запись, и без блокировки упоминание могло бы пережить удаленную запись
+func (r *mentionRepository) Replace(targetType string, targetID int, userIDs []int) ([]int, error) {
+	target, ok := mentionTables[targetType]
+	if !ok {
+		return nil, fmt.Errorf("неизвестный вид записи для упоминания: %s", targetType)
+	}
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при начале транзакции: %w", err)
+	}
+	defer tx.Rollback()
+
+	var lockedID int
+	err = tx.QueryRow(`SELECT id FROM `+target.table+` WHERE id = $1 FOR SHARE`, targetID).Scan(&lockedID)
+	if err == sql.ErrNoRows {
+		return nil, target.notFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при блокировке записи: %w", err)
+	}
+
+	_, err = tx.Exec(`DELETE FROM mentions WHERE target_type = $1 AND target_id = $2 AND NOT (user_id = ANY($3))`,
+		targetType, targetID, pq.Array(userIDs))
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при удалении упоминаний: %w", err)
+	}
+
+	added := make([]int, 0)
+	if len(userIDs) > 0 {
+		rows, err := tx.Query(`
+			INSERT INTO mentions (target_type, target_id, user_id)
+			SELECT $1, $2, UNNEST($3::INTEGER[])
+			ON CONFLICT DO NOTHING
+			RETURNING user_id`,
+			targetType, targetID, pq.Array(userIDs))
+		if isForeignKeyViolation(err) {
+			return nil, ErrUserNotFound
+		}
+		if err != nil {
+			return nil, fmt.Errorf("ошибка при сохранении упоминаний: %w", err)
+		}
+		for rows.Next() {
+			var userID int
+			if err := rows.Scan(&userID); err != nil {
+				rows.Close()
+				return nil, fmt.Errorf("ошибка при сканировании упоминания: %w", err)
+			}
+			added = append(added, userID)
+		}
+		err = rows.Err()
+		rows.Close()
+		if isForeignKeyViolation(err) {
+			return nil, ErrUserNotFound
+		}
+		if err != nil {
+			return nil, fmt.Errorf("ошибка при сохранении упоминаний: %w", err)
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("ошибка при фиксации транзакции: %w", err)
+	}
+	sort.Ints(added)
+	return added, nil
+}
+
+func (r *mentionRepository) GetByTargets(targetType string, targetIDs []int) (map[int][]models.Mention, error) {
+	mentions := make(map[int][]models.Mention)
+	if len(targetIDs) == 0 {
+		return mentions, nil
+	}
+
+	rows, err := r.db.Query(`
+		SELECT m.target_id, m.user_id, u.username
+		FROM mentions m
+		JOIN users u ON u.id = m.user_id
+		WHERE m.target_type = $1 AND m.target_id = ANY($2)
+		ORDER BY m.target_id, u.username`,
+		targetType, pq.Array(targetIDs))
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при получении упоминаний: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var targetID int
+		var mention models.Mention
+		if err := rows.Scan(&targetID, &mention.UserID, &mention.Username); err != nil {
+			return nil, fmt.Errorf("ошибка при сканировании упоминания: %w", err)
+		}
+		mentions[targetID] = append(mentions[targetID], mention)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("ошибка при итерации по упоминаниям: %w", err)
+	}
+	return mentions, nil
+}
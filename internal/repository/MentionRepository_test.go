@@ -0,0 +1,139 @@
+package repository
+
+import (
+	"database/sql"
+	"testing"
+
+	"ForumService/internal/models"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupMentionRepositoryTest(t *testing.T) (MentionRepository, sqlmock.Sqlmock, func()) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+
+	return NewMentionRepository(db), mock, func() { db.Close() }
+}
+
+func TestMentionRepository_ResolveUsernames(t *testing.T) {
+	repo, mock, cleanup := setupMentionRepositoryTest(t)
+	defer cleanup()
+
+	mock.ExpectQuery("SELECT id, username FROM users WHERE username = ANY\\(\\$1\\) ORDER BY username").
+		WithArgs(pq.Array([]string{"bob", "alice", "ghost"})).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "username"}).AddRow(2, "alice").AddRow(3, "bob"))
+
+	mentions, err := repo.ResolveUsernames([]string{"bob", "alice", "ghost"})
+	require.NoError(t, err)
+	assert.Equal(t, []models.Mention{{UserID: 2, Username: "alice"}, {UserID: 3, Username: "bob"}}, mentions)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestMentionRepository_ResolveUsernames_Empty(t *testing.T) {
+	repo, mock, cleanup := setupMentionRepositoryTest(t)
+	defer cleanup()
+
+	mentions, err := repo.ResolveUsernames(nil)
+	require.NoError(t, err)
+	assert.Empty(t, mentions)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestMentionRepository_Replace(t *testing.T) {
+	repo, mock, cleanup := setupMentionRepositoryTest(t)
+	defer cleanup()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT id FROM posts WHERE id = \\$1 FOR SHARE").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectExec("DELETE FROM mentions WHERE target_type = \\$1 AND target_id = \\$2 AND NOT \\(user_id = ANY\\(\\$3\\)\\)").
+		WithArgs("post", 1, pq.Array([]int{4, 2})).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery("INSERT INTO mentions \\(target_type, target_id, user_id\\)").
+		WithArgs("post", 1, pq.Array([]int{4, 2})).
+		WillReturnRows(sqlmock.NewRows([]string{"user_id"}).AddRow(4))
+	mock.ExpectCommit()
+
+	added, err := repo.Replace(models.MentionTargetPost, 1, []int{4, 2})
+	require.NoError(t, err)
+	assert.Equal(t, []int{4}, added, "уже упомянутый пользователь не считается добавленным")
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestMentionRepository_Replace_ClearsMentions(t *testing.T) {
+	repo, mock, cleanup := setupMentionRepositoryTest(t)
+	defer cleanup()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT id FROM chat_messages WHERE id = \\$1 FOR SHARE").
+		WithArgs(7).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(7))
+	mock.ExpectExec("DELETE FROM mentions").
+		WithArgs("chat", 7, pq.Array([]int(nil))).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectCommit()
+
+	added, err := repo.Replace(models.MentionTargetChat, 7, nil)
+	require.NoError(t, err)
+	assert.Empty(t, added)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestMentionRepository_Replace_NotFound(t *testing.T) {
+	repo, mock, cleanup := setupMentionRepositoryTest(t)
+	defer cleanup()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT id FROM comments WHERE id = \\$1 FOR SHARE").
+		WithArgs(99).
+		WillReturnError(sql.ErrNoRows)
+	mock.ExpectRollback()
+
+	_, err := repo.Replace(models.MentionTargetComment, 99, []int{2})
+	assert.ErrorIs(t, err, ErrCommentNotFound)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestMentionRepository_Replace_UnknownUser(t *testing.T) {
+	repo, mock, cleanup := setupMentionRepositoryTest(t)
+	defer cleanup()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT id FROM posts WHERE id = \\$1 FOR SHARE").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectExec("DELETE FROM mentions").
+		WithArgs("post", 1, pq.Array([]int{99})).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery("INSERT INTO mentions").
+		WithArgs("post", 1, pq.Array([]int{99})).
+		WillReturnError(&pq.Error{Code: "23503"})
+	mock.ExpectRollback()
+
+	_, err := repo.Replace(models.MentionTargetPost, 1, []int{99})
+	assert.ErrorIs(t, err, ErrUserNotFound)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestMentionRepository_GetByTargets(t *testing.T) {
+	repo, mock, cleanup := setupMentionRepositoryTest(t)
+	defer cleanup()
+
+	mock.ExpectQuery("SELECT m.target_id, m.user_id, u.username FROM mentions m").
+		WithArgs("comment", pq.Array([]int{5, 6})).
+		WillReturnRows(sqlmock.NewRows([]string{"target_id", "user_id", "username"}).
+			AddRow(5, 2, "alice").
+			AddRow(5, 3, "bob"))
+
+	mentions, err := repo.GetByTargets(models.MentionTargetComment, []int{5, 6})
+	require.NoError(t, err)
+	assert.Equal(t, map[int][]models.Mention{
+		5: {{UserID: 2, Username: "alice"}, {UserID: 3, Username: "bob"}},
+	}, mentions)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
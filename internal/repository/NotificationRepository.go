@@ -0,0 +1,133 @@
+package repository
+
+import (
+	"ForumService/internal/models"
+	"database/sql"
+	"fmt"
+)
+
+type notificationRepository struct {
+	db *sql.DB
+}
+
+func NewNotificationRepository(db *sql.DB) NotificationRepository {
+	return &notificationRepository{db: db}
+}
+
+func (r *notificationRepository) SaveAll(notifications []*models.Notification) error {
+	if len(notifications) == 0 {
+		return nil
+	}
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("ошибка при начале транзакции: %w", err)
+	}
+	defer tx.Rollback()
+
+	for _, notification := range notifications {
+		err = tx.QueryRow(`
+			INSERT INTO notifications (user_id, type, actor_id, thread_id, post_id, comment_id, chat_message_id)
+			VALUES ($1, $2, $3, $4, $5, $6, $7)
+			RETURNING id, read, created_at`,
+			notification.UserID, notification.Type, notification.ActorID,
+			nullableID(notification.ThreadID), nullableID(notification.PostID),
+			notification.CommentID, notification.ChatMessageID,
+		).Scan(&notification.ID, &notification.Read, &notification.CreatedAt)
+		if err != nil {
+			return fmt.Errorf("ошибка при сохранении уведомления: %w", err)
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("ошибка при фиксации транзакции: %w", err)
+	}
+	return nil
+}
+
+func (r *notificationRepository) GetByUser(userID int, unreadOnly bool, limit, offset int) ([]models.Notification, int, error) {
+	rows, err := r.db.Query(`
+		SELECT n.id, n.type, n.actor_id, COALESCE(u.username, ''), n.thread_id, n.post_id, n.comment_id,
+		       n.chat_message_id, COALESCE(NULLIF(p.title, ''), t.title, ''), n.read, n.created_at,
+		       COUNT(*) OVER() AS total
+		FROM notifications n
+		LEFT JOIN users u ON u.id = n.actor_id
+		LEFT JOIN posts p ON p.id = n.post_id
+		LEFT JOIN threads t ON t.id = n.thread_id
+		WHERE n.user_id = $1 AND (NOT $2 OR NOT n.read)
+		ORDER BY n.created_at DESC, n.id DESC
+		LIMIT $3 OFFSET $4`,
+		userID, unreadOnly, limit, offset)
+	if err != nil {
+		return nil, 0, fmt.Errorf("ошибка при получении уведомлений: %w", err)
+	}
+	defer rows.Close()
+
+	notifications := make([]models.Notification, 0)
+	total := 0
+	for rows.Next() {
+		notification := models.Notification{UserID: userID}
+		var threadID, postID, commentID, chatMessageID sql.NullInt64
+		err := rows.Scan(
+			&notification.ID,
+			&notification.Type,
+			&notification.ActorID,
+			&notification.ActorName,
+			&threadID,
+			&postID,
+			&commentID,
+			&chatMessageID,
+			&notification.Title,
+			&notification.Read,
+			&notification.CreatedAt,
+			&total,
+		)
+		if err != nil {
+			return nil, 0, fmt.Errorf("ошибка при сканировании уведомления: %w", err)
+		}
+		notification.ThreadID = int(threadID.Int64)
+		notification.PostID = int(postID.Int64)
+		notification.CommentID = intPtr(commentID)
+		notification.ChatMessageID = intPtr(chatMessageID)
+		notifications = append(notifications, notification)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("ошибка при итерации по уведомлениям: %w", err)
+	}
+	return notifications, total, nil
+}
+
+func (r *notificationRepository) CountUnread(userID int) (int, error) {
+	var unread int
+	err := r.db.QueryRow(`SELECT COUNT(*) FROM notifications WHERE user_id = $1 AND NOT read`, userID).Scan(&unread)
+	if err != nil {
+		return 0, fmt.Errorf("ошибка при подсчете непрочитанных уведомлений: %w", err)
+	}
+	return unread, nil
+}
+
+func (r *notificationRepository) MarkRead(userID, notificationID int) error {
+	result, err := r.db.Exec(`UPDATE notifications SET read = TRUE WHERE id = $1 AND user_id = $2`,
+		notificationID, userID)
+	if err != nil {
+		return fmt.Errorf("ошибка при отметке уведомления: %w", err)
+	}
+	return checkRowsAffected(result, ErrNotificationNotFound)
+}
+
+func (r *notificationRepository) MarkAllRead(userID int) (int, error) {
+	result, err := r.db.Exec(`UPDATE notifications SET read = TRUE WHERE user_id = $1 AND NOT read`, userID)
+	if err != nil {
+		return 0, fmt.Errorf("ошибка при отметке уведомлений: %w", err)
+	}
+	updated, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	return int(updated), nil
+}
+
+// nullableID передает нулевой ID как NULL: у упоминаний в чате нет треда и поста
+func nullableID(id int) sql.NullInt64 {
+	return sql.NullInt64{Int64: int64(id), Valid: id != 0}
+}
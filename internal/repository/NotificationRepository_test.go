@@ -0,0 +1,116 @@
+package repository
+
+import (
+	"testing"
+	"time"
+
+	"ForumService/internal/models"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupNotificationRepositoryTest(t *testing.T) (NotificationRepository, sqlmock.Sqlmock, func()) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+
+	return NewNotificationRepository(db), mock, func() { db.Close() }
+}
+
+func TestNotificationRepository_SaveAll(t *testing.T) {
+	repo, mock, cleanup := setupNotificationRepositoryTest(t)
+	defer cleanup()
+
+	createdAt := time.Now()
+	commentID := 7
+	chatMessageID := 9
+	mock.ExpectBegin()
+	mock.ExpectQuery("INSERT INTO notifications \\(user_id, type, actor_id, thread_id, post_id, comment_id, chat_message_id\\)").
+		WithArgs(2, "comment", 1, 3, 5, &commentID, nil).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "read", "created_at"}).AddRow(10, false, createdAt))
+	mock.ExpectQuery("INSERT INTO notifications").
+		WithArgs(4, "reply", 1, 3, 5, &commentID, nil).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "read", "created_at"}).AddRow(11, false, createdAt))
+	mock.ExpectQuery("INSERT INTO notifications").
+		WithArgs(4, "mention", 1, nil, nil, nil, &chatMessageID).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "read", "created_at"}).AddRow(12, false, createdAt))
+	mock.ExpectCommit()
+
+	notifications := []*models.Notification{
+		{UserID: 2, Type: models.NotificationNewComment, ActorID: 1, ThreadID: 3, PostID: 5, CommentID: &commentID},
+		{UserID: 4, Type: models.NotificationReply, ActorID: 1, ThreadID: 3, PostID: 5, CommentID: &commentID},
+		{UserID: 4, Type: models.NotificationMention, ActorID: 1, ChatMessageID: &chatMessageID},
+	}
+	require.NoError(t, repo.SaveAll(notifications))
+	assert.Equal(t, 10, notifications[0].ID)
+	assert.Equal(t, 11, notifications[1].ID)
+	assert.Equal(t, 12, notifications[2].ID)
+	assert.Equal(t, createdAt, notifications[1].CreatedAt)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestNotificationRepository_GetByUser(t *testing.T) {
+	repo, mock, cleanup := setupNotificationRepositoryTest(t)
+	defer cleanup()
+
+	createdAt := time.Now()
+	mock.ExpectQuery("SELECT n.id, n.type, n.actor_id, COALESCE\\(u.username, ''\\), n.thread_id, n.post_id, n.comment_id, n.chat_message_id, (.+) FROM notifications n (.+) WHERE n.user_id = \\$1 AND \\(NOT \\$2 OR NOT n.read\\) ORDER BY n.created_at DESC, n.id DESC LIMIT \\$3 OFFSET \\$4").
+		WithArgs(2, true, 20, 0).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "type", "actor_id", "username", "thread_id", "post_id", "comment_id", "chat_message_id", "title", "read", "created_at", "total"}).
+			AddRow(12, "mention", 1, "alice", nil, nil, nil, 9, "", false, createdAt, 3).
+			AddRow(11, "comment", 1, "alice", 3, 5, 7, nil, "Тред", false, createdAt, 3).
+			AddRow(10, "post", 1, "alice", 3, 5, nil, nil, "Тред", false, createdAt, 3))
+
+	notifications, total, err := repo.GetByUser(2, true, 20, 0)
+	require.NoError(t, err)
+	assert.Equal(t, 3, total)
+	require.Len(t, notifications, 3)
+	chatMention := notifications[0]
+	assert.Zero(t, chatMention.ThreadID, "у упоминания в чате нет треда")
+	require.NotNil(t, chatMention.ChatMessageID)
+	assert.Equal(t, 9, *chatMention.ChatMessageID)
+	notifications = notifications[1:]
+	assert.Equal(t, "alice", notifications[0].ActorName)
+	require.NotNil(t, notifications[0].CommentID)
+	assert.Equal(t, 7, *notifications[0].CommentID)
+	assert.Nil(t, notifications[1].CommentID)
+	assert.Equal(t, 2, notifications[1].UserID)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestNotificationRepository_MarkRead(t *testing.T) {
+	repo, mock, cleanup := setupNotificationRepositoryTest(t)
+	defer cleanup()
+
+	mock.ExpectExec("UPDATE notifications SET read = TRUE WHERE id = \\$1 AND user_id = \\$2").
+		WithArgs(10, 2).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("UPDATE notifications SET read = TRUE WHERE id = \\$1 AND user_id = \\$2").
+		WithArgs(10, 3).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	require.NoError(t, repo.MarkRead(2, 10))
+	assert.ErrorIs(t, repo.MarkRead(3, 10), ErrNotificationNotFound)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestNotificationRepository_MarkAllRead(t *testing.T) {
+	repo, mock, cleanup := setupNotificationRepositoryTest(t)
+	defer cleanup()
+
+	mock.ExpectExec("UPDATE notifications SET read = TRUE WHERE user_id = \\$1 AND NOT read").
+		WithArgs(2).
+		WillReturnResult(sqlmock.NewResult(0, 3))
+	mock.ExpectQuery("SELECT COUNT\\(\\*\\) FROM notifications WHERE user_id = \\$1 AND NOT read").
+		WithArgs(2).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+
+	updated, err := repo.MarkAllRead(2)
+	require.NoError(t, err)
+	assert.Equal(t, 3, updated)
+	unread, err := repo.CountUnread(2)
+	require.NoError(t, err)
+	assert.Equal(t, 0, unread)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,226 @@
+package repository
+
+import (
+	"ForumService/internal/models"
+	"database/sql"
+	"fmt"
+
+	"github.com/lib/pq"
+)
+
+const pollColumns = `id, thread_id, question, multiple_choice, anonymous, closes_at, closed_at, closed_by, created_at`
+
+type pollRepository struct {
+	db *sql.DB
+}
+
+func NewPollRepository(db *sql.DB) PollRepository {
+	return &pollRepository{db: db}
+}
+
+func (r *pollRepository) Create(poll *models.Poll) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("ошибка при начале транзакции: %w", err)
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRow(`
+		INSERT INTO polls (thread_id, question, multiple_choice, anonymous, closes_at)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, created_at`,
+		poll.ThreadID, poll.Question, poll.MultipleChoice, poll.Anonymous, poll.ClosesAt,
+	).Scan(&poll.ID, &poll.CreatedAt)
+	if isUniqueViolation(err) {
+		return ErrAlreadyExists
+	}
+	if isForeignKeyViolation(err) {
+		return ErrThreadNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("ошибка при сохранении опроса: %w", err)
+	}
+
+	for i := range poll.Options {
+		err = tx.QueryRow(`INSERT INTO poll_options (poll_id, position, text) VALUES ($1, $2, $3) RETURNING id`,
+			poll.ID, i, poll.Options[i].Text).Scan(&poll.Options[i].ID)
+		if err != nil {
+			return fmt.Errorf("ошибка при сохранении варианта опроса: %w", err)
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("ошибка при фиксации транзакции: %w", err)
+	}
+	return nil
+}
+
+func (r *pollRepository) GetByID(id int) (*models.Poll, error) {
+	return r.get(`SELECT `+pollColumns+` FROM polls WHERE id = $1`, id)
+}
+
+func (r *pollRepository) GetByThreadID(threadID int) (*models.Poll, error) {
+	return r.get(`SELECT `+pollColumns+` FROM polls WHERE thread_id = $1`, threadID)
+}
+
+func (r *pollRepository) get(query string, arg int) (*models.Poll, error) {
+	poll, err := scanPoll(r.db.QueryRow(query, arg))
+	if err == sql.ErrNoRows {
+		return nil, ErrPollNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при получении опроса: %w", err)
+	}
+
+	rows, err := r.db.Query(`SELECT id, text FROM poll_options WHERE poll_id = $1 ORDER BY position`, poll.ID)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при получении вариантов опроса: %w", err)
+	}
+	defer rows.Close()
+
+	poll.Options = make([]models.PollOption, 0)
+	for rows.Next() {
+		var option models.PollOption
+		if err := rows.Scan(&option.ID, &option.Text); err != nil {
+			return nil, fmt.Errorf("ошибка при сканировании варианта опроса: %w", err)
+		}
+		poll.Options = append(poll.Options, option)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("ошибка при итерации по вариантам опроса: %w", err)
+	}
+	return poll, nil
+}
+
+// Vote блокирует опрос, чтобы голос не прошел одновременно с закрытием
+func (r *pollRepository) Vote(pollID, userID int, optionIDs []int) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("ошибка при начале транзакции: %w", err)
+	}
+	defer tx.Rollback()
+
+	var closed bool
+	err = tx.QueryRow(`
+		SELECT closed_at IS NOT NULL OR (closes_at IS NOT NULL AND closes_at <= NOW())
+		FROM polls WHERE id = $1 FOR SHARE`, pollID).Scan(&closed)
+	if err == sql.ErrNoRows {
+		return ErrPollNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("ошибка при блокировке опроса: %w", err)
+	}
+	if closed {
+		return ErrPollClosed
+	}
+
+	if _, err = tx.Exec(`DELETE FROM poll_votes WHERE poll_id = $1 AND user_id = $2`, pollID, userID); err != nil {
+		return fmt.Errorf("ошибка при снятии голоса: %w", err)
+	}
+
+	if len(optionIDs) > 0 {
+		result, err := tx.Exec(`
+			INSERT INTO poll_votes (poll_id, option_id, user_id)
+			SELECT poll_id, id, $3 FROM poll_options WHERE poll_id = $1 AND id = ANY($2)`,
+			pollID, pq.Array(optionIDs), userID)
+		if isForeignKeyViolation(err) {
+			return ErrUserNotFound
+		}
+		if err != nil {
+			return fmt.Errorf("ошибка при сохранении голоса: %w", err)
+		}
+		voted, err := result.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if int(voted) != len(optionIDs) {
+			return ErrPollOptionNotFound
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("ошибка при фиксации транзакции: %w", err)
+	}
+	return nil
+}
+
+func (r *pollRepository) Close(pollID, userID int) error {
+	result, err := r.db.Exec(`UPDATE polls SET closed_at = NOW(), closed_by = $2 WHERE id = $1 AND closed_at IS NULL`,
+		pollID, userID)
+	if err != nil {
+		return fmt.Errorf("ошибка при закрытии опроса: %w", err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows > 0 {
+		return nil
+	}
+
+	var exists bool
+	if err := r.db.QueryRow(`SELECT EXISTS(SELECT 1 FROM polls WHERE id = $1)`, pollID).Scan(&exists); err != nil {
+		return fmt.Errorf("ошибка при проверке опроса: %w", err)
+	}
+	if !exists {
+		return ErrPollNotFound
+	}
+	return nil
+}
+
+func (r *pollRepository) GetVotes(pollID int) ([]models.PollVote, error) {
+	rows, err := r.db.Query(`
+		SELECT v.option_id, v.user_id, COALESCE(u.username, '')
+		FROM poll_votes v
+		LEFT JOIN users u ON u.id = v.user_id
+		WHERE v.poll_id = $1
+		ORDER BY v.created_at, v.option_id`, pollID)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при получении голосов опроса: %w", err)
+	}
+	defer rows.Close()
+
+	votes := make([]models.PollVote, 0)
+	for rows.Next() {
+		var vote models.PollVote
+		if err := rows.Scan(&vote.OptionID, &vote.UserID, &vote.Username); err != nil {
+			return nil, fmt.Errorf("ошибка при сканировании голоса: %w", err)
+		}
+		votes = append(votes, vote)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("ошибка при итерации по голосам: %w", err)
+	}
+	return votes, nil
+}
+
+func scanPoll(row categoryScanner) (*models.Poll, error) {
+	poll := &models.Poll{}
+	var closesAt, closedAt sql.NullTime
+	var closedBy sql.NullInt64
+	err := row.Scan(
+		&poll.ID,
+		&poll.ThreadID,
+		&poll.Question,
+		&poll.MultipleChoice,
+		&poll.Anonymous,
+		&closesAt,
+		&closedAt,
+		&closedBy,
+		&poll.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if closesAt.Valid {
+		poll.ClosesAt = &closesAt.Time
+	}
+	if closedAt.Valid {
+		poll.ClosedAt = &closedAt.Time
+	}
+	if closedBy.Valid {
+		closedByID := int(closedBy.Int64)
+		poll.ClosedBy = &closedByID
+	}
+	return poll, nil
+}
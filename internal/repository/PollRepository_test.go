@@ -0,0 +1,219 @@
+package repository
+
+import (
+	"testing"
+	"time"
+
+	"ForumService/internal/models"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupPollRepositoryTest(t *testing.T) (PollRepository, sqlmock.Sqlmock, func()) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+
+	return NewPollRepository(db), mock, func() { db.Close() }
+}
+
+var pollRowColumns = []string{"id", "thread_id", "question", "multiple_choice", "anonymous", "closes_at", "closed_at", "closed_by", "created_at"}
+
+func TestPollRepository_Create(t *testing.T) {
+	repo, mock, cleanup := setupPollRepositoryTest(t)
+	defer cleanup()
+
+	createdAt := time.Now()
+	closesAt := createdAt.Add(time.Hour)
+	poll := &models.Poll{
+		ThreadID:       3,
+		Question:       "Когда релиз?",
+		MultipleChoice: true,
+		ClosesAt:       &closesAt,
+		Options:        []models.PollOption{{Text: "В пятницу"}, {Text: "В понедельник"}},
+	}
+	mock.ExpectBegin()
+	mock.ExpectQuery("INSERT INTO polls").
+		WithArgs(3, "Когда релиз?", true, false, &closesAt).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow(7, createdAt))
+	mock.ExpectQuery("INSERT INTO poll_options").
+		WithArgs(7, 0, "В пятницу").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(11))
+	mock.ExpectQuery("INSERT INTO poll_options").
+		WithArgs(7, 1, "В понедельник").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(12))
+	mock.ExpectCommit()
+
+	err := repo.Create(poll)
+	require.NoError(t, err)
+	assert.Equal(t, 7, poll.ID)
+	assert.Equal(t, createdAt, poll.CreatedAt)
+	assert.Equal(t, 11, poll.Options[0].ID)
+	assert.Equal(t, 12, poll.Options[1].ID)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPollRepository_Create_Errors(t *testing.T) {
+	tests := []struct {
+		name string
+		code pq.ErrorCode
+		want error
+	}{
+		{"у треда уже есть опрос", "23505", ErrAlreadyExists},
+		{"тред не найден", "23503", ErrThreadNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo, mock, cleanup := setupPollRepositoryTest(t)
+			defer cleanup()
+
+			mock.ExpectBegin()
+			mock.ExpectQuery("INSERT INTO polls").WillReturnError(&pq.Error{Code: tt.code})
+			mock.ExpectRollback()
+
+			err := repo.Create(&models.Poll{ThreadID: 3, Options: []models.PollOption{{Text: "Да"}, {Text: "Нет"}}})
+			assert.ErrorIs(t, err, tt.want)
+			require.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestPollRepository_GetByThreadID(t *testing.T) {
+	repo, mock, cleanup := setupPollRepositoryTest(t)
+	defer cleanup()
+
+	createdAt := time.Now()
+	mock.ExpectQuery("SELECT (.+) FROM polls WHERE thread_id = \\$1").
+		WithArgs(3).
+		WillReturnRows(sqlmock.NewRows(pollRowColumns).AddRow(7, 3, "Когда релиз?", false, true, nil, createdAt, 2, createdAt))
+	mock.ExpectQuery("SELECT id, text FROM poll_options").
+		WithArgs(7).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "text"}).AddRow(11, "В пятницу").AddRow(12, "В понедельник"))
+
+	poll, err := repo.GetByThreadID(3)
+	require.NoError(t, err)
+	assert.Equal(t, 7, poll.ID)
+	assert.True(t, poll.Anonymous)
+	assert.Nil(t, poll.ClosesAt)
+	require.NotNil(t, poll.ClosedAt)
+	assert.Equal(t, 2, *poll.ClosedBy)
+	assert.Equal(t, []models.PollOption{{ID: 11, Text: "В пятницу"}, {ID: 12, Text: "В понедельник"}}, poll.Options)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPollRepository_GetByID_NotFound(t *testing.T) {
+	repo, mock, cleanup := setupPollRepositoryTest(t)
+	defer cleanup()
+
+	mock.ExpectQuery("SELECT (.+) FROM polls WHERE id = \\$1").
+		WithArgs(99).
+		WillReturnRows(sqlmock.NewRows(pollRowColumns))
+
+	_, err := repo.GetByID(99)
+	assert.ErrorIs(t, err, ErrPollNotFound)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPollRepository_Vote(t *testing.T) {
+	repo, mock, cleanup := setupPollRepositoryTest(t)
+	defer cleanup()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT (.+) FROM polls WHERE id = \\$1 FOR SHARE").
+		WithArgs(7).
+		WillReturnRows(sqlmock.NewRows([]string{"closed"}).AddRow(false))
+	mock.ExpectExec("DELETE FROM poll_votes").
+		WithArgs(7, 2).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO poll_votes").
+		WithArgs(7, pq.Array([]int{11, 12}), 2).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectCommit()
+
+	require.NoError(t, repo.Vote(7, 2, []int{11, 12}))
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPollRepository_Vote_ForeignOption(t *testing.T) {
+	repo, mock, cleanup := setupPollRepositoryTest(t)
+	defer cleanup()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT (.+) FROM polls").
+		WithArgs(7).
+		WillReturnRows(sqlmock.NewRows([]string{"closed"}).AddRow(false))
+	mock.ExpectExec("DELETE FROM poll_votes").
+		WithArgs(7, 2).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("INSERT INTO poll_votes").
+		WithArgs(7, pq.Array([]int{11, 99}), 2).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectRollback()
+
+	err := repo.Vote(7, 2, []int{11, 99})
+	assert.ErrorIs(t, err, ErrPollOptionNotFound)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPollRepository_Vote_Closed(t *testing.T) {
+	repo, mock, cleanup := setupPollRepositoryTest(t)
+	defer cleanup()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT (.+) FROM polls").
+		WithArgs(7).
+		WillReturnRows(sqlmock.NewRows([]string{"closed"}).AddRow(true))
+	mock.ExpectRollback()
+
+	err := repo.Vote(7, 2, []int{11})
+	assert.ErrorIs(t, err, ErrPollClosed)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPollRepository_Close(t *testing.T) {
+	repo, mock, cleanup := setupPollRepositoryTest(t)
+	defer cleanup()
+
+	mock.ExpectExec("UPDATE polls SET closed_at").
+		WithArgs(7, 2).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	require.NoError(t, repo.Close(7, 2))
+
+	mock.ExpectExec("UPDATE polls SET closed_at").
+		WithArgs(7, 2).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery("SELECT EXISTS").
+		WithArgs(7).
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+	require.NoError(t, repo.Close(7, 2), "закрытый опрос закрывается повторно без ошибки")
+
+	mock.ExpectExec("UPDATE polls SET closed_at").
+		WithArgs(99, 2).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery("SELECT EXISTS").
+		WithArgs(99).
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+	assert.ErrorIs(t, repo.Close(99, 2), ErrPollNotFound)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPollRepository_GetVotes(t *testing.T) {
+	repo, mock, cleanup := setupPollRepositoryTest(t)
+	defer cleanup()
+
+	mock.ExpectQuery("SELECT v.option_id, v.user_id").
+		WithArgs(7).
+		WillReturnRows(sqlmock.NewRows([]string{"option_id", "user_id", "username"}).
+			AddRow(11, 2, "alice").
+			AddRow(12, 3, "bob"))
+
+	votes, err := repo.GetVotes(7)
+	require.NoError(t, err)
+	assert.Equal(t, []models.PollVote{
+		{OptionID: 11, PollVoter: models.PollVoter{UserID: 2, Username: "alice"}},
+		{OptionID: 12, PollVoter: models.PollVoter{UserID: 3, Username: "bob"}},
+	}, votes)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
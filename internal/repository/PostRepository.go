@@ -3,7 +3,6 @@ package repository
 import (
 	"database/sql"
 	"ForumService/internal/models"
-	"errors"
 	"fmt"
 	"github.com/lib/pq"
 	"github.com/Luxtington/Shared/logger"
@@ -20,11 +19,12 @@ func NewPostRepository(db *sql.DB) PostRepository {
 
 func (r *postRepository) GetByThreadID(threadID int) ([]*models.Post, error) {
 	query := `
-		SELECT p.id, p.thread_id, p.author_id, p.content, p.created_at, p.updated_at, u.username as author_name 
+		SELECT p.id, p.thread_id, p.author_id, p.title, p.content, p.created_at, p.updated_at, p.comment_count, p.view_count, p.upvotes, p.downvotes, u.username as author_name,
+			p.pinned_at, p.reply_to_post_id
 		FROM posts p
 		LEFT JOIN users u ON p.author_id = u.id
 		WHERE p.thread_id = $1 
-		ORDER BY p.created_at ASC`
+		ORDER BY p.pinned_at ASC NULLS LAST, p.created_at ASC`
 	rows, err := r.db.Query(query, threadID)
 	if err != nil {
 		return nil, err
@@ -34,18 +34,28 @@ func (r *postRepository) GetByThreadID(threadID int) ([]*models.Post, error) {
 	var posts []*models.Post
 	for rows.Next() {
 		post := &models.Post{}
+		var pinnedAt sql.NullTime
+		var replyTo sql.NullInt64
 		err := rows.Scan(
 			&post.ID,
 			&post.ThreadID,
 			&post.AuthorID,
+			&post.Title,
 			&post.Content,
 			&post.CreatedAt,
 			&post.UpdatedAt,
+			&post.CommentCount,
+			&post.ViewCount,
+			&post.Upvotes,
+			&post.Downvotes,
 			&post.AuthorName,
+			&pinnedAt,
+			&replyTo,
 		)
 		if err != nil {
 			return nil, err
 		}
+		fillPostState(post, pinnedAt, replyTo)
 		posts = append(posts, post)
 	}
 
@@ -61,37 +71,71 @@ func (r *postRepository) Create(post *models.Post) error {
 }
 
 func (r *postRepository) Update(post *models.Post) error {
-	query := `UPDATE posts SET content = $1, updated_at = CURRENT_TIMESTAMP WHERE id = $2`
-	_, err := r.db.Exec(query, post.Content, post.ID)
-	return err
+	query := `UPDATE posts SET title = $1, content = $2, version = version + 1, updated_at = CURRENT_TIMESTAMP WHERE id = $3`
+	result, err := r.db.Exec(query, post.Title, post.Content, post.ID)
+	if err != nil {
+		return err
+	}
+	return checkRowsAffected(result, ErrPostNotFound)
 }
 
 func (r *postRepository) Delete(id int) error {
 	query := `DELETE FROM posts WHERE id = $1`
-	_, err := r.db.Exec(query, id)
-	return err
+	result, err := r.db.Exec(query, id)
+	if err != nil {
+		return err
+	}
+	return checkRowsAffected(result, ErrPostNotFound)
 }
 
+// SavePost создает пост и в той же транзакции обновляет счетчики треда. Ссылка на пост,
+// на который отвечает новый пост, сохраняется, только если тот пост есть в том же треде.
+// В закрытый тред пост не добавляется: возвращается ErrThreadLocked.
 func (r *postRepository) SavePost(post *models.Post) error {
 	const query = `
-		INSERT INTO posts (thread_id, author_id, content) 
-		VALUES ($1, $2, $3)
-		RETURNING id, thread_id, author_id, content, created_at
+		INSERT INTO posts (thread_id, author_id, title, content, reply_to_post_id) 
+		VALUES ($1, $2, $3, $4, (SELECT id FROM posts WHERE id = $5 AND thread_id = $1))
+		RETURNING id, thread_id, author_id, title, content, created_at, reply_to_post_id
 	`
 
+	log := logger.GetLogger()
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		log.Error("Ошибка при начале транзакции создания поста", zap.Error(err))
+		return err
+	}
+	defer tx.Rollback()
+
+	if err = lockOpenThread(tx, post.ThreadID); err != nil {
+		return err
+	}
+
 	var newPost models.Post
-	err := r.db.QueryRow(query, post.ThreadID, post.AuthorID, post.Content).Scan(
+	var replyTo sql.NullInt64
+	err = tx.QueryRow(query, post.ThreadID, post.AuthorID, post.Title, post.Content, post.ReplyToPostID).Scan(
 		&newPost.ID,
 		&newPost.ThreadID,
 		&newPost.AuthorID,
+		&newPost.Title,
 		&newPost.Content,
 		&newPost.CreatedAt,
+		&replyTo,
 	)
 	if err != nil {
-		log := logger.GetLogger()
 		log.Error("Ошибка при создании поста", zap.Error(err))
 		return err
 	}
+	newPost.ReplyToPostID = intPtr(replyTo)
+
+	if err = updateThreadCounters(tx, newPost.ThreadID, 1, 0); err != nil {
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		log.Error("Ошибка при фиксации транзакции создания поста", zap.Error(err))
+		return err
+	}
 
 	*post = newPost
 	return nil
@@ -99,38 +143,145 @@ func (r *postRepository) SavePost(post *models.Post) error {
 
 func (r *postRepository) GetPostByID(id int) (*models.Post, error) {
 	query := `
-		SELECT p.id, p.thread_id, p.author_id, p.content, p.created_at, p.updated_at, u.username as author_name
+		SELECT p.id, p.thread_id, p.author_id, p.title, p.content, p.created_at, p.updated_at, p.version, p.comment_count, p.view_count, p.upvotes, p.downvotes, u.username as author_name,
+			p.pinned_at, p.reply_to_post_id
 		FROM posts p
 		LEFT JOIN users u ON p.author_id = u.id
 		WHERE p.id = $1`
 
 	post := &models.Post{}
+	var pinnedAt sql.NullTime
+	var replyTo sql.NullInt64
 	err := r.db.QueryRow(query, id).Scan(
 		&post.ID,
 		&post.ThreadID,
 		&post.AuthorID,
+		&post.Title,
 		&post.Content,
 		&post.CreatedAt,
 		&post.UpdatedAt,
+		&post.Version,
+		&post.CommentCount,
+		&post.ViewCount,
+		&post.Upvotes,
+		&post.Downvotes,
 		&post.AuthorName,
+		&pinnedAt,
+		&replyTo,
 	)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return nil, fmt.Errorf("пост не найден")
+			return nil, ErrPostNotFound
 		}
-		return nil, fmt.Errorf("ошибка при получении поста: %v", err)
+		return nil, fmt.Errorf("ошибка при получении поста: %w", err)
 	}
+	fillPostState(post, pinnedAt, replyTo)
 
 	return post, nil
 }
 
+// Pin закрепляет пост в треде. Повторное закрепление не меняет место поста среди закрепленных.
+func (r *postRepository) Pin(postID int) error {
+	query := `UPDATE posts SET pinned_at = COALESCE(pinned_at, CURRENT_TIMESTAMP), version = version + 1 WHERE id = $1`
+	result, err := r.db.Exec(query, postID)
+	if err != nil {
+		return fmt.Errorf("ошибка при закреплении поста: %w", err)
+	}
+	return checkRowsAffected(result, ErrPostNotFound)
+}
+
+// Unpin снимает закрепление поста
+func (r *postRepository) Unpin(postID int) error {
+	query := `UPDATE posts SET pinned_at = NULL, version = version + 1 WHERE id = $1`
+	result, err := r.db.Exec(query, postID)
+	if err != nil {
+		return fmt.Errorf("ошибка при откреплении поста: %w", err)
+	}
+	return checkRowsAffected(result, ErrPostNotFound)
+}
+
+// fillPostState заполняет вычисляемые поля поста: закрепление и ответ из nullable-колонок и рейтинг
+func fillPostState(post *models.Post, pinnedAt sql.NullTime, replyTo sql.NullInt64) {
+	post.Pinned = pinnedAt.Valid
+	post.PinnedAt = timePtr(pinnedAt)
+	post.ReplyToPostID = intPtr(replyTo)
+	post.Score = post.Upvotes - post.Downvotes
+}
+
+// GetPostRefs возвращает ссылки на существующие посты из ids по ID поста вместе
+// с ролью, нужной для чтения их раздела. Удаленных постов в ответе нет.
+func (r *postRepository) GetPostRefs(ids []int) (map[int]models.PostRef, error) {
+	refs := make(map[int]models.PostRef)
+	if len(ids) == 0 {
+		return refs, nil
+	}
+
+	const query = `WITH RECURSIVE` + categoryReadRolesCTE + `
+		SELECT p.id, p.thread_id, p.author_id, COALESCE(u.username, ''), COALESCE(cat.read_role, 'guest')
+		FROM posts p
+		JOIN threads t ON t.id = p.thread_id
+		LEFT JOIN category_read_roles cat ON cat.id = t.category_id
+		LEFT JOIN users u ON p.author_id = u.id
+		WHERE p.id = ANY($1)`
+	rows, err := r.db.Query(query, pq.Array(ids))
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при получении ссылок на посты: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var ref models.PostRef
+		if err := rows.Scan(&ref.ID, &ref.ThreadID, &ref.AuthorID, &ref.AuthorName, &ref.ReadRole); err != nil {
+			return nil, fmt.Errorf("ошибка при сканировании ссылки на пост: %w", err)
+		}
+		refs[ref.ID] = ref
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("ошибка при итерации ссылок на посты: %w", err)
+	}
+	return refs, nil
+}
+
+// GetReplies возвращает ответы на посты postIDs по ID исходного поста, от ранних к поздним
+func (r *postRepository) GetReplies(postIDs []int) (map[int][]models.PostRef, error) {
+	replies := make(map[int][]models.PostRef)
+	if len(postIDs) == 0 {
+		return replies, nil
+	}
+
+	const query = `
+		SELECT p.reply_to_post_id, p.id, p.thread_id, p.author_id, COALESCE(u.username, '')
+		FROM posts p
+		LEFT JOIN users u ON p.author_id = u.id
+		WHERE p.reply_to_post_id = ANY($1)
+		ORDER BY p.created_at ASC, p.id ASC`
+	rows, err := r.db.Query(query, pq.Array(postIDs))
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при получении ответов на посты: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var postID int
+		var ref models.PostRef
+		if err := rows.Scan(&postID, &ref.ID, &ref.ThreadID, &ref.AuthorID, &ref.AuthorName); err != nil {
+			return nil, fmt.Errorf("ошибка при сканировании ответа на пост: %w", err)
+		}
+		replies[postID] = append(replies[postID], ref)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("ошибка при итерации ответов на посты: %w", err)
+	}
+	return replies, nil
+}
+
 func (r *postRepository) GetPostWithComments(postID int) (*models.Post, []models.Comment, error) {
 	post, err := r.GetPostByID(postID)
 	if err != nil {
 		return nil, nil, fmt.Errorf("ошибка при получении поста: %w", err)
 	}
 
-	const query = `SELECT c.id, c.post_id, c.author_id, c.content, c.created_at, u.username as author_name
+	const query = `SELECT c.id, c.post_id, c.parent_comment_id, c.depth, c.author_id, c.content, c.created_at, c.updated_at, c.deleted_at, c.upvotes, c.downvotes, u.username as author_name
                    FROM comments c
                    LEFT JOIN users u ON c.author_id = u.id
                    WHERE c.post_id = $1
@@ -145,17 +296,26 @@ func (r *postRepository) GetPostWithComments(postID int) (*models.Post, []models
 	comments := make([]models.Comment, 0)
 	for rows.Next() {
 		var comment models.Comment
+		var parentID sql.NullInt64
+		var deletedAt sql.NullTime
 		err := rows.Scan(
 			&comment.ID,
 			&comment.PostID,
+			&parentID,
+			&comment.Depth,
 			&comment.AuthorID,
 			&comment.Content,
 			&comment.CreatedAt,
+			&comment.UpdatedAt,
+			&deletedAt,
+			&comment.Upvotes,
+			&comment.Downvotes,
 			&comment.AuthorName,
 		)
 		if err != nil {
 			return nil, nil, fmt.Errorf("ошибка при сканировании комментария: %w", err)
 		}
+		fillCommentState(&comment, parentID, deletedAt)
 		comments = append(comments, comment)
 	}
 
@@ -163,6 +323,7 @@ func (r *postRepository) GetPostWithComments(postID int) (*models.Post, []models
 		return nil, nil, fmt.Errorf("ошибка при итерации комментариев: %w", err)
 	}
 
+	comments = flattenCommentTree(comments)
 	return post, comments, nil
 }
 
@@ -173,7 +334,7 @@ func (r *postRepository) GetPostsWithCommentsByThreadID(threadID int) ([]models.
             p.id, p.thread_id, p.content, p.created_at,
             u.id as author_id, u.username as author_username
         FROM posts p
-        JOIN users u ON p.user_id = u.id
+        JOIN users u ON p.author_id = u.id
         WHERE p.thread_id = $1
         ORDER BY p.created_at DESC
         LIMIT $2 OFFSET $3
@@ -256,12 +417,121 @@ func (r *postRepository) GetPostsWithCommentsByThreadID(threadID int) ([]models.
 	return posts, commentsByPostID, nil
 }
 
-func (r *postRepository) UpdatePost(post *models.Post, postID int) error {
-	query := `UPDATE posts SET content = $1, updated_at = CURRENT_TIMESTAMP WHERE id = $2`
-	_, err := r.db.Exec(query, post.Content, postID)
-	return err
+// UpdatePost обновляет содержимое поста, предварительно сохраняя текущую версию в истории правок.
+// Если post.Version больше нуля, пост обновляется только при совпадении версии,
+// иначе возвращается ErrVersionConflict. После обновления post.Version содержит новую версию.
+func (r *postRepository) UpdatePost(post *models.Post, postID int, editorID int, reason string) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("ошибка при начале транзакции: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Блокируем строку поста, чтобы проверка версии и обновление были атомарными
+	var currentVersion int
+	err = tx.QueryRow(`SELECT version FROM posts WHERE id = $1 FOR UPDATE`, postID).Scan(&currentVersion)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return ErrPostNotFound
+		}
+		return fmt.Errorf("ошибка при блокировке поста: %w", err)
+	}
+	if post.Version > 0 && post.Version != currentVersion {
+		return ErrVersionConflict
+	}
+
+	const revisionQuery = `
+		INSERT INTO post_revisions (post_id, title, content, editor_id, reason)
+		SELECT id, title, content, $2, NULLIF($3, '')
+		FROM posts
+		WHERE id = $1`
+
+	if _, err = tx.Exec(revisionQuery, postID, editorID, reason); err != nil {
+		return fmt.Errorf("ошибка при сохранении ревизии поста: %w", err)
+	}
+
+	const updateQuery = `
+		UPDATE posts SET title = $1, content = $2, version = version + 1, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $3
+		RETURNING version, updated_at`
+	if err = tx.QueryRow(updateQuery, post.Title, post.Content, postID).Scan(&post.Version, &post.UpdatedAt); err != nil {
+		return err
+	}
+
+	return tx.Commit()
 }
 
+// GetPostRevisions возвращает историю правок поста, начиная с самой ранней версии
+func (r *postRepository) GetPostRevisions(postID int) ([]models.PostRevision, error) {
+	const query = `
+		SELECT r.id, r.post_id, r.title, r.content, r.editor_id, COALESCE(r.reason, ''), r.created_at, u.username as editor_name
+		FROM post_revisions r
+		LEFT JOIN users u ON r.editor_id = u.id
+		WHERE r.post_id = $1
+		ORDER BY r.created_at ASC, r.id ASC`
+
+	rows, err := r.db.Query(query, postID)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при получении ревизий поста: %w", err)
+	}
+	defer rows.Close()
+
+	revisions := make([]models.PostRevision, 0)
+	for rows.Next() {
+		var revision models.PostRevision
+		err := rows.Scan(
+			&revision.ID,
+			&revision.PostID,
+			&revision.Title,
+			&revision.Content,
+			&revision.EditorID,
+			&revision.Reason,
+			&revision.CreatedAt,
+			&revision.EditorName,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("ошибка при сканировании ревизии: %w", err)
+		}
+		revisions = append(revisions, revision)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("ошибка при итерации ревизий: %w", err)
+	}
+
+	return revisions, nil
+}
+
+// GetPostRevisionByID возвращает одну ревизию поста
+func (r *postRepository) GetPostRevisionByID(postID int, revisionID int) (*models.PostRevision, error) {
+	const query = `
+		SELECT r.id, r.post_id, r.title, r.content, r.editor_id, COALESCE(r.reason, ''), r.created_at, u.username as editor_name
+		FROM post_revisions r
+		LEFT JOIN users u ON r.editor_id = u.id
+		WHERE r.post_id = $1 AND r.id = $2`
+
+	revision := &models.PostRevision{}
+	err := r.db.QueryRow(query, postID, revisionID).Scan(
+		&revision.ID,
+		&revision.PostID,
+		&revision.Title,
+		&revision.Content,
+		&revision.EditorID,
+		&revision.Reason,
+		&revision.CreatedAt,
+		&revision.EditorName,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrRevisionNotFound
+		}
+		return nil, fmt.Errorf("ошибка при получении ревизии: %w", err)
+	}
+
+	return revision, nil
+}
+
+// DeletePost удаляет пост вместе с комментариями и уменьшает счетчики треда
 func (r *postRepository) DeletePost(postID int) error {
 	log := logger.GetLogger()
 	
@@ -272,8 +542,18 @@ func (r *postRepository) DeletePost(postID int) error {
 	}
 	defer tx.Rollback()
 
-	const deleteCommentsQuery = `DELETE FROM comments WHERE post_id = $1`
-	_, err = tx.Exec(deleteCommentsQuery, postID)
+	threadID, err := lockPostThread(tx, postID)
+	if err != nil {
+		log.Error("error while locking post thread in POST REPO 7", zap.Error(err))
+		return err
+	}
+
+	// Заглушки удаленных комментариев уже вычтены из счетчиков
+	const deleteCommentsQuery = `
+		WITH deleted AS (DELETE FROM comments WHERE post_id = $1 RETURNING deleted_at)
+		SELECT COUNT(*) FROM deleted WHERE deleted_at IS NULL`
+	var deletedComments int
+	err = tx.QueryRow(deleteCommentsQuery, postID).Scan(&deletedComments)
 	if err != nil {
 		log.Error("error while deleting comments in POST REPO 7.1", zap.Error(err))
 		return err
@@ -294,7 +574,11 @@ func (r *postRepository) DeletePost(postID int) error {
 
 	if rowsAffected == 0 {
 		log.Error("ERROR IN DELETE POST REPO 7.4")
-		return errors.New("post not found in POST REPO")
+		return ErrPostNotFound
+	}
+
+	if err = updateThreadCounters(tx, threadID, -1, -deletedComments); err != nil {
+		return err
 	}
 
 	if err = tx.Commit(); err != nil {
@@ -33,19 +33,26 @@ func TestPostRepository_SavePost(t *testing.T) {
 	post := &models.Post{
 		ThreadID: 1,
 		AuthorID: 1,
+		Title:    "Test Title",
 		Content:  "Test Post",
 	}
 
+	mock.ExpectBegin()
+	expectOpenThreadLock(mock, post.ThreadID)
 	mock.ExpectQuery("INSERT INTO posts").
-		WithArgs(post.ThreadID, post.AuthorID, post.Content).
-		WillReturnRows(sqlmock.NewRows([]string{"id", "thread_id", "author_id", "content", "created_at"}).
-			AddRow(1, post.ThreadID, post.AuthorID, post.Content, time.Now()))
+		WithArgs(post.ThreadID, post.AuthorID, post.Title, post.Content, nil).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "thread_id", "author_id", "title", "content", "created_at", "reply_to_post_id"}).
+			AddRow(1, post.ThreadID, post.AuthorID, post.Title, post.Content, time.Now(), nil))
+	expectThreadCountersUpdate(mock, post.ThreadID, 1, 0)
+	mock.ExpectCommit()
 
 	err := repo.SavePost(post)
 	require.NoError(t, err)
+	require.NoError(t, mock.ExpectationsWereMet())
 	assert.Equal(t, 1, post.ID)
 	assert.Equal(t, 1, post.ThreadID)
 	assert.Equal(t, 1, post.AuthorID)
+	assert.Equal(t, "Test Title", post.Title)
 	assert.Equal(t, "Test Post", post.Content)
 }
 
@@ -57,45 +64,52 @@ func TestPostRepository_GetPostByID(t *testing.T) {
 		ID:        1,
 		ThreadID:  1,
 		AuthorID:  1,
+		Title:     "Test Title",
 		Content:   "Test Post",
 		CreatedAt: time.Now(),
 		UpdatedAt: time.Now(),
 		AuthorName: "Test User",
 	}
 
-	mock.ExpectQuery("SELECT p.id, p.thread_id, p.author_id, p.content, p.created_at, p.updated_at, u.username as author_name FROM posts p LEFT JOIN users u ON p.author_id = u.id WHERE p.id = \\$1").
+	mock.ExpectQuery("SELECT p.id, p.thread_id, p.author_id, p.title, p.content, p.created_at, p.updated_at, p.version, p.comment_count, p.view_count, p.upvotes, p.downvotes, u.username as author_name, p.pinned_at, p.reply_to_post_id FROM posts p LEFT JOIN users u ON p.author_id = u.id WHERE p.id = \\$1").
 		WithArgs(1).
-		WillReturnRows(sqlmock.NewRows([]string{"id", "thread_id", "author_id", "content", "created_at", "updated_at", "author_name"}).
-			AddRow(expectedPost.ID, expectedPost.ThreadID, expectedPost.AuthorID, expectedPost.Content, expectedPost.CreatedAt, expectedPost.UpdatedAt, expectedPost.AuthorName))
+		WillReturnRows(sqlmock.NewRows([]string{"id", "thread_id", "author_id", "title", "content", "created_at", "updated_at", "version", "comment_count", "view_count", "upvotes", "downvotes", "author_name", "pinned_at", "reply_to_post_id"}).
+			AddRow(expectedPost.ID, expectedPost.ThreadID, expectedPost.AuthorID, expectedPost.Title, expectedPost.Content, expectedPost.CreatedAt, expectedPost.UpdatedAt, 1, 3, 0, 2, 1, expectedPost.AuthorName, nil, nil))
 
 	post, err := repo.GetPostByID(1)
 	require.NoError(t, err)
 	assert.Equal(t, expectedPost.ID, post.ID)
+	assert.Equal(t, expectedPost.Title, post.Title)
 	assert.Equal(t, expectedPost.ThreadID, post.ThreadID)
 	assert.Equal(t, expectedPost.AuthorID, post.AuthorID)
 	assert.Equal(t, expectedPost.Content, post.Content)
 	assert.Equal(t, expectedPost.AuthorName, post.AuthorName)
+	assert.Equal(t, 3, post.CommentCount)
+	assert.Equal(t, 2, post.Upvotes)
+	assert.Equal(t, 1, post.Downvotes)
+	assert.Equal(t, 1, post.Score)
 }
 
 func TestPostRepository_GetPostByID_NotFound(t *testing.T) {
 	repo, mock, cleanup := setupPostRepositoryTest(t)
 	defer cleanup()
 
-	mock.ExpectQuery("SELECT p.id, p.thread_id, p.author_id, p.content, p.created_at, p.updated_at, u.username as author_name FROM posts p LEFT JOIN users u ON p.author_id = u.id WHERE p.id = \\$1").
+	mock.ExpectQuery("SELECT p.id, p.thread_id, p.author_id, p.title, p.content, p.created_at, p.updated_at, p.version, p.comment_count, p.view_count, p.upvotes, p.downvotes, u.username as author_name, p.pinned_at, p.reply_to_post_id FROM posts p LEFT JOIN users u ON p.author_id = u.id WHERE p.id = \\$1").
 		WithArgs(1).
 		WillReturnError(sql.ErrNoRows)
 
 	post, err := repo.GetPostByID(1)
 	require.Error(t, err)
 	assert.Nil(t, post)
-	assert.Equal(t, "пост не найден", err.Error())
+	assert.ErrorIs(t, err, ErrPostNotFound)
+	assert.ErrorIs(t, err, ErrNotFound)
 }
 
 func TestPostRepository_GetPostByID_DBError(t *testing.T) {
 	repo, mock, cleanup := setupPostRepositoryTest(t)
 	defer cleanup()
 
-	mock.ExpectQuery("SELECT p.id, p.thread_id, p.author_id, p.content, p.created_at, p.updated_at, u.username as author_name FROM posts p LEFT JOIN users u ON p.author_id = u.id WHERE p.id = \\$1").
+	mock.ExpectQuery("SELECT p.id, p.thread_id, p.author_id, p.title, p.content, p.created_at, p.updated_at, p.version, p.comment_count, p.view_count, p.upvotes, p.downvotes, u.username as author_name, p.pinned_at, p.reply_to_post_id FROM posts p LEFT JOIN users u ON p.author_id = u.id WHERE p.id = \\$1").
 		WithArgs(1).
 		WillReturnError(fmt.Errorf("database error"))
 
@@ -120,10 +134,10 @@ func TestPostRepository_GetPostWithComments(t *testing.T) {
 		AuthorName: "Test User",
 	}
 
-	mock.ExpectQuery("SELECT p.id, p.thread_id, p.author_id, p.content, p.created_at, p.updated_at, u.username as author_name FROM posts p LEFT JOIN users u ON p.author_id = u.id WHERE p.id = \\$1").
+	mock.ExpectQuery("SELECT p.id, p.thread_id, p.author_id, p.title, p.content, p.created_at, p.updated_at, p.version, p.comment_count, p.view_count, p.upvotes, p.downvotes, u.username as author_name, p.pinned_at, p.reply_to_post_id FROM posts p LEFT JOIN users u ON p.author_id = u.id WHERE p.id = \\$1").
 		WithArgs(1).
-		WillReturnRows(sqlmock.NewRows([]string{"id", "thread_id", "author_id", "content", "created_at", "updated_at", "author_name"}).
-			AddRow(expectedPost.ID, expectedPost.ThreadID, expectedPost.AuthorID, expectedPost.Content, expectedPost.CreatedAt, expectedPost.UpdatedAt, expectedPost.AuthorName))
+		WillReturnRows(sqlmock.NewRows([]string{"id", "thread_id", "author_id", "title", "content", "created_at", "updated_at", "version", "comment_count", "view_count", "upvotes", "downvotes", "author_name", "pinned_at", "reply_to_post_id"}).
+			AddRow(expectedPost.ID, expectedPost.ThreadID, expectedPost.AuthorID, expectedPost.Title, expectedPost.Content, expectedPost.CreatedAt, expectedPost.UpdatedAt, 1, 3, 0, 2, 1, expectedPost.AuthorName, nil, nil))
 
 	// Мок для получения комментариев
 	expectedComments := []models.Comment{
@@ -137,10 +151,10 @@ func TestPostRepository_GetPostWithComments(t *testing.T) {
 		},
 	}
 
-	mock.ExpectQuery("SELECT c.id, c.post_id, c.author_id, c.content, c.created_at, u.username as author_name FROM comments c LEFT JOIN users u ON c.author_id = u.id WHERE c.post_id = \\$1 ORDER BY c.created_at ASC").
+	mock.ExpectQuery("SELECT c.id, c.post_id, c.parent_comment_id, c.depth, c.author_id, c.content, c.created_at, c.updated_at, c.deleted_at, c.upvotes, c.downvotes, u.username as author_name FROM comments c LEFT JOIN users u ON c.author_id = u.id WHERE c.post_id = \\$1 ORDER BY c.created_at ASC").
 		WithArgs(1).
-		WillReturnRows(sqlmock.NewRows([]string{"id", "post_id", "author_id", "content", "created_at", "author_name"}).
-			AddRow(expectedComments[0].ID, expectedComments[0].PostID, expectedComments[0].AuthorID, expectedComments[0].Content, expectedComments[0].CreatedAt, expectedComments[0].AuthorName))
+		WillReturnRows(sqlmock.NewRows([]string{"id", "post_id", "parent_comment_id", "depth", "author_id", "content", "created_at", "updated_at", "deleted_at", "upvotes", "downvotes", "author_name"}).
+			AddRow(expectedComments[0].ID, expectedComments[0].PostID, nil, 0, expectedComments[0].AuthorID, expectedComments[0].Content, expectedComments[0].CreatedAt, expectedComments[0].CreatedAt, nil, 0, 0, expectedComments[0].AuthorName))
 
 	post, comments, err := repo.GetPostWithComments(1)
 	require.NoError(t, err)
@@ -154,10 +168,10 @@ func TestPostRepository_GetPostWithComments_PostScanError(t *testing.T) {
 	repo, mock, cleanup := setupPostRepositoryTest(t)
 	defer cleanup()
 
-	postRows := sqlmock.NewRows([]string{"id", "thread_id", "author_id", "content", "created_at", "updated_at", "author_name"})
-	postRows.AddRow("invalid", 1, 1, "Test Post", time.Now(), time.Now(), "Test User")
+	postRows := sqlmock.NewRows([]string{"id", "thread_id", "author_id", "title", "content", "created_at", "updated_at", "version", "comment_count", "view_count", "upvotes", "downvotes", "author_name", "pinned_at", "reply_to_post_id"})
+	postRows.AddRow("invalid", 1, 1, "", "Test Post", time.Now(), time.Now(), 1, 0, 0, 0, 0, "Test User", nil, nil)
 
-	mock.ExpectQuery("SELECT p.id, p.thread_id, p.author_id, p.content, p.created_at, p.updated_at, u.username as author_name FROM posts p LEFT JOIN users u ON p.author_id = u.id WHERE p.id = \\$1").
+	mock.ExpectQuery("SELECT p.id, p.thread_id, p.author_id, p.title, p.content, p.created_at, p.updated_at, p.version, p.comment_count, p.view_count, p.upvotes, p.downvotes, u.username as author_name, p.pinned_at, p.reply_to_post_id FROM posts p LEFT JOIN users u ON p.author_id = u.id WHERE p.id = \\$1").
 		WithArgs(1).
 		WillReturnRows(postRows)
 
@@ -171,10 +185,10 @@ func TestPostRepository_GetPostWithComments_PostAuthorScanError(t *testing.T) {
 	repo, mock, cleanup := setupPostRepositoryTest(t)
 	defer cleanup()
 
-	postRows := sqlmock.NewRows([]string{"id", "thread_id", "author_id", "content", "created_at", "updated_at", "author_name"})
-	postRows.AddRow(1, 1, "invalid", "Test Post", time.Now(), time.Now(), "Test User")
+	postRows := sqlmock.NewRows([]string{"id", "thread_id", "author_id", "title", "content", "created_at", "updated_at", "version", "comment_count", "view_count", "upvotes", "downvotes", "author_name", "pinned_at", "reply_to_post_id"})
+	postRows.AddRow(1, 1, "invalid", "", "Test Post", time.Now(), time.Now(), 1, 0, 0, 0, 0, "Test User", nil, nil)
 
-	mock.ExpectQuery("SELECT p.id, p.thread_id, p.author_id, p.content, p.created_at, p.updated_at, u.username as author_name FROM posts p LEFT JOIN users u ON p.author_id = u.id WHERE p.id = \\$1").
+	mock.ExpectQuery("SELECT p.id, p.thread_id, p.author_id, p.title, p.content, p.created_at, p.updated_at, p.version, p.comment_count, p.view_count, p.upvotes, p.downvotes, u.username as author_name, p.pinned_at, p.reply_to_post_id FROM posts p LEFT JOIN users u ON p.author_id = u.id WHERE p.id = \\$1").
 		WithArgs(1).
 		WillReturnRows(postRows)
 
@@ -188,10 +202,10 @@ func TestPostRepository_GetPostWithComments_PostThreadScanError(t *testing.T) {
 	repo, mock, cleanup := setupPostRepositoryTest(t)
 	defer cleanup()
 
-	postRows := sqlmock.NewRows([]string{"id", "thread_id", "author_id", "content", "created_at", "updated_at", "author_name"})
-	postRows.AddRow(1, "invalid", 1, "Test Post", time.Now(), time.Now(), "Test User")
+	postRows := sqlmock.NewRows([]string{"id", "thread_id", "author_id", "title", "content", "created_at", "updated_at", "version", "comment_count", "view_count", "upvotes", "downvotes", "author_name", "pinned_at", "reply_to_post_id"})
+	postRows.AddRow(1, "invalid", 1, "", "Test Post", time.Now(), time.Now(), 1, 0, 0, 0, 0, "Test User", nil, nil)
 
-	mock.ExpectQuery("SELECT p.id, p.thread_id, p.author_id, p.content, p.created_at, p.updated_at, u.username as author_name FROM posts p LEFT JOIN users u ON p.author_id = u.id WHERE p.id = \\$1").
+	mock.ExpectQuery("SELECT p.id, p.thread_id, p.author_id, p.title, p.content, p.created_at, p.updated_at, p.version, p.comment_count, p.view_count, p.upvotes, p.downvotes, u.username as author_name, p.pinned_at, p.reply_to_post_id FROM posts p LEFT JOIN users u ON p.author_id = u.id WHERE p.id = \\$1").
 		WithArgs(1).
 		WillReturnRows(postRows)
 
@@ -229,7 +243,7 @@ func TestPostRepository_GetPostsWithCommentsByThreadID(t *testing.T) {
 	postRows.AddRow(1, 1, "Test Post 1", expectedPosts[0].CreatedAt, 1, "Test User 1")
 	postRows.AddRow(2, 1, "Test Post 2", expectedPosts[1].CreatedAt, 2, "Test User 2")
 
-	mock.ExpectQuery("SELECT p.id, p.thread_id, p.content, p.created_at, u.id as author_id, u.username as author_username FROM posts p JOIN users u ON p.user_id = u.id WHERE p.thread_id = \\$1 ORDER BY p.created_at DESC LIMIT \\$2 OFFSET \\$3").
+	mock.ExpectQuery("SELECT p.id, p.thread_id, p.content, p.created_at, u.id as author_id, u.username as author_username FROM posts p JOIN users u ON p.author_id = u.id WHERE p.thread_id = \\$1 ORDER BY p.created_at DESC LIMIT \\$2 OFFSET \\$3").
 		WithArgs(1, 20, 0).
 		WillReturnRows(postRows)
 
@@ -261,7 +275,7 @@ func TestPostRepository_GetPostsWithCommentsByThreadID_PostsError(t *testing.T)
 	repo, mock, cleanup := setupPostRepositoryTest(t)
 	defer cleanup()
 
-	mock.ExpectQuery("SELECT p.id, p.thread_id, p.content, p.created_at, u.id as author_id, u.username as author_username FROM posts p JOIN users u ON p.user_id = u.id WHERE p.thread_id = \\$1 ORDER BY p.created_at DESC LIMIT \\$2 OFFSET \\$3").
+	mock.ExpectQuery("SELECT p.id, p.thread_id, p.content, p.created_at, u.id as author_id, u.username as author_username FROM posts p JOIN users u ON p.author_id = u.id WHERE p.thread_id = \\$1 ORDER BY p.created_at DESC LIMIT \\$2 OFFSET \\$3").
 		WithArgs(1, 20, 0).
 		WillReturnError(fmt.Errorf("database error"))
 
@@ -279,7 +293,7 @@ func TestPostRepository_GetPostsWithCommentsByThreadID_CommentsError(t *testing.
 	postRows := sqlmock.NewRows([]string{"id", "thread_id", "content", "created_at", "author_id", "author_username"})
 	postRows.AddRow(1, 1, "Test Post 1", time.Now(), 1, "Test User 1")
 
-	mock.ExpectQuery("SELECT p.id, p.thread_id, p.content, p.created_at, u.id as author_id, u.username as author_username FROM posts p JOIN users u ON p.user_id = u.id WHERE p.thread_id = \\$1 ORDER BY p.created_at DESC LIMIT \\$2 OFFSET \\$3").
+	mock.ExpectQuery("SELECT p.id, p.thread_id, p.content, p.created_at, u.id as author_id, u.username as author_username FROM posts p JOIN users u ON p.author_id = u.id WHERE p.thread_id = \\$1 ORDER BY p.created_at DESC LIMIT \\$2 OFFSET \\$3").
 		WithArgs(1, 20, 0).
 		WillReturnRows(postRows)
 
@@ -303,12 +317,99 @@ func TestPostRepository_UpdatePost(t *testing.T) {
 		Content: "Updated Post",
 	}
 
-	mock.ExpectExec("UPDATE posts SET content = \\$1, updated_at = CURRENT_TIMESTAMP WHERE id = \\$2").
-		WithArgs(post.Content, post.ID).
-		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT version FROM posts WHERE id = \\$1 FOR UPDATE").
+		WithArgs(post.ID).
+		WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow(3))
+	mock.ExpectExec("INSERT INTO post_revisions \\(post_id, title, content, editor_id, reason\\) SELECT id, title, content, \\$2, NULLIF\\(\\$3, ''\\) FROM posts WHERE id = \\$1").
+		WithArgs(post.ID, 2, "опечатка").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectQuery("UPDATE posts SET title = \\$1, content = \\$2, version = version \\+ 1, updated_at = CURRENT_TIMESTAMP WHERE id = \\$3 RETURNING version, updated_at").
+		WithArgs(post.Title, post.Content, post.ID).
+		WillReturnRows(sqlmock.NewRows([]string{"version", "updated_at"}).AddRow(4, time.Now()))
+	mock.ExpectCommit()
+
+	err := repo.UpdatePost(post, post.ID, 2, "опечатка")
+	require.NoError(t, err)
+	assert.Equal(t, 4, post.Version)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPostRepository_UpdatePost_VersionConflict(t *testing.T) {
+	repo, mock, cleanup := setupPostRepositoryTest(t)
+	defer cleanup()
+
+	post := &models.Post{
+		ID:      1,
+		Content: "Updated Post",
+		Version: 2,
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT version FROM posts WHERE id = \\$1 FOR UPDATE").
+		WithArgs(post.ID).
+		WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow(3))
+	mock.ExpectRollback()
+
+	err := repo.UpdatePost(post, post.ID, 2, "")
+	assert.ErrorIs(t, err, ErrVersionConflict)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPostRepository_UpdatePost_NotFound(t *testing.T) {
+	repo, mock, cleanup := setupPostRepositoryTest(t)
+	defer cleanup()
+
+	post := &models.Post{
+		ID:      1,
+		Content: "Updated Post",
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT version FROM posts WHERE id = \\$1 FOR UPDATE").
+		WithArgs(post.ID).
+		WillReturnError(sql.ErrNoRows)
+	mock.ExpectRollback()
+
+	err := repo.UpdatePost(post, post.ID, 2, "")
+	require.Error(t, err)
+	assert.ErrorIs(t, err, ErrPostNotFound)
+}
 
-	err := repo.UpdatePost(post, post.ID)
+func TestPostRepository_GetPostRevisions(t *testing.T) {
+	repo, mock, cleanup := setupPostRepositoryTest(t)
+	defer cleanup()
+
+	now := time.Now()
+	rows := sqlmock.NewRows([]string{"id", "post_id", "title", "content", "editor_id", "reason", "created_at", "editor_name"}).
+		AddRow(1, 1, "", "Первая версия", 2, "", now, "editor").
+		AddRow(2, 1, "Заголовок", "Вторая версия", 3, "опечатка", now.Add(time.Minute), "admin")
+
+	mock.ExpectQuery("SELECT r.id, r.post_id, r.title, r.content, r.editor_id, COALESCE\\(r.reason, ''\\), r.created_at, u.username as editor_name FROM post_revisions r LEFT JOIN users u ON r.editor_id = u.id WHERE r.post_id = \\$1 ORDER BY r.created_at ASC, r.id ASC").
+		WithArgs(1).
+		WillReturnRows(rows)
+
+	revisions, err := repo.GetPostRevisions(1)
 	require.NoError(t, err)
+	require.Len(t, revisions, 2)
+	assert.Equal(t, "Первая версия", revisions[0].Content)
+	assert.Equal(t, "Заголовок", revisions[1].Title)
+	assert.Equal(t, "опечатка", revisions[1].Reason)
+	assert.Equal(t, "admin", revisions[1].EditorName)
+}
+
+func TestPostRepository_GetPostRevisionByID_NotFound(t *testing.T) {
+	repo, mock, cleanup := setupPostRepositoryTest(t)
+	defer cleanup()
+
+	mock.ExpectQuery("SELECT r.id, r.post_id, r.title, r.content").
+		WithArgs(1, 5).
+		WillReturnError(sql.ErrNoRows)
+
+	revision, err := repo.GetPostRevisionByID(1, 5)
+	require.Error(t, err)
+	assert.Nil(t, revision)
+	assert.ErrorIs(t, err, ErrRevisionNotFound)
 }
 
 func TestPostRepository_DeletePost(t *testing.T) {
@@ -316,22 +417,28 @@ func TestPostRepository_DeletePost(t *testing.T) {
 	defer cleanup()
 
 	mock.ExpectBegin()
-	mock.ExpectExec("DELETE FROM comments WHERE post_id = \\$1").
+	expectPostThreadLookup(mock, 1, 1)
+	expectThreadCountersLock(mock, 1)
+	mock.ExpectQuery("WITH deleted AS \\(DELETE FROM comments WHERE post_id = \\$1 RETURNING deleted_at\\)").
 		WithArgs(1).
-		WillReturnResult(sqlmock.NewResult(0, 1))
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(2))
 	mock.ExpectExec("DELETE FROM posts WHERE id = \\$1").
 		WithArgs(1).
 		WillReturnResult(sqlmock.NewResult(0, 1))
+	expectThreadCountersUpdate(mock, 1, -1, -2)
 	mock.ExpectCommit()
 
 	err := repo.DeletePost(1)
 	require.NoError(t, err)
+	require.NoError(t, mock.ExpectationsWereMet())
 }
 
 func TestPostRepository_GetByThreadID(t *testing.T) {
 	repo, mock, cleanup := setupPostRepositoryTest(t)
 	defer cleanup()
 
+	pinnedAt := time.Now()
+	replyTo := 1
 	expectedPosts := []*models.Post{
 		{
 			ID:        1,
@@ -341,6 +448,8 @@ func TestPostRepository_GetByThreadID(t *testing.T) {
 			CreatedAt: time.Now(),
 			UpdatedAt: time.Now(),
 			AuthorName: "Test User 1",
+			ViewCount: 12,
+			PinnedAt:  &pinnedAt,
 		},
 		{
 			ID:        2,
@@ -350,15 +459,16 @@ func TestPostRepository_GetByThreadID(t *testing.T) {
 			CreatedAt: time.Now(),
 			UpdatedAt: time.Now(),
 			AuthorName: "Test User 2",
+			ReplyToPostID: &replyTo,
 		},
 	}
 
-	rows := sqlmock.NewRows([]string{"id", "thread_id", "author_id", "content", "created_at", "updated_at", "author_name"})
+	rows := sqlmock.NewRows([]string{"id", "thread_id", "author_id", "title", "content", "created_at", "updated_at", "comment_count", "view_count", "upvotes", "downvotes", "author_name", "pinned_at", "reply_to_post_id"})
 	for _, post := range expectedPosts {
-		rows.AddRow(post.ID, post.ThreadID, post.AuthorID, post.Content, post.CreatedAt, post.UpdatedAt, post.AuthorName)
+		rows.AddRow(post.ID, post.ThreadID, post.AuthorID, post.Title, post.Content, post.CreatedAt, post.UpdatedAt, post.CommentCount, post.ViewCount, post.Upvotes, post.Downvotes, post.AuthorName, post.PinnedAt, post.ReplyToPostID)
 	}
 
-	mock.ExpectQuery("SELECT p.id, p.thread_id, p.author_id, p.content, p.created_at, p.updated_at, u.username as author_name FROM posts p LEFT JOIN users u ON p.author_id = u.id WHERE p.thread_id = \\$1 ORDER BY p.created_at ASC").
+	mock.ExpectQuery("SELECT p.id, p.thread_id, p.author_id, p.title, p.content, p.created_at, p.updated_at, p.comment_count, p.view_count, p.upvotes, p.downvotes, u.username as author_name, p.pinned_at, p.reply_to_post_id FROM posts p LEFT JOIN users u ON p.author_id = u.id WHERE p.thread_id = \\$1 ORDER BY p.pinned_at ASC NULLS LAST, p.created_at ASC").
 		WithArgs(1).
 		WillReturnRows(rows)
 
@@ -369,14 +479,100 @@ func TestPostRepository_GetByThreadID(t *testing.T) {
 		assert.Equal(t, expectedPosts[i].ID, post.ID)
 		assert.Equal(t, expectedPosts[i].Content, post.Content)
 		assert.Equal(t, expectedPosts[i].AuthorName, post.AuthorName)
+		assert.Equal(t, expectedPosts[i].PinnedAt != nil, post.Pinned)
+		assert.Equal(t, expectedPosts[i].ViewCount, post.ViewCount)
+		assert.Equal(t, expectedPosts[i].ReplyToPostID, post.ReplyToPostID)
 	}
 }
 
+func TestPostRepository_SavePost_ReplyTo(t *testing.T) {
+	repo, mock, cleanup := setupPostRepositoryTest(t)
+	defer cleanup()
+
+	replyTo := 5
+	post := &models.Post{ThreadID: 1, AuthorID: 2, Content: "Ответ", ReplyToPostID: &replyTo}
+
+	mock.ExpectBegin()
+	expectOpenThreadLock(mock, post.ThreadID)
+	mock.ExpectQuery("INSERT INTO posts \\(thread_id, author_id, title, content, reply_to_post_id\\) VALUES \\(\\$1, \\$2, \\$3, \\$4, \\(SELECT id FROM posts WHERE id = \\$5 AND thread_id = \\$1\\)\\)").
+		WithArgs(post.ThreadID, post.AuthorID, post.Title, post.Content, 5).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "thread_id", "author_id", "title", "content", "created_at", "reply_to_post_id"}).
+			AddRow(6, post.ThreadID, post.AuthorID, post.Title, post.Content, time.Now(), 5))
+	expectThreadCountersUpdate(mock, post.ThreadID, 1, 0)
+	mock.ExpectCommit()
+
+	require.NoError(t, repo.SavePost(post))
+	require.NoError(t, mock.ExpectationsWereMet())
+	assert.Equal(t, 6, post.ID)
+	assert.Equal(t, &replyTo, post.ReplyToPostID)
+}
+
+func TestPostRepository_GetPostRefs(t *testing.T) {
+	repo, mock, cleanup := setupPostRepositoryTest(t)
+	defer cleanup()
+
+	mock.ExpectQuery("SELECT p.id, p.thread_id, p.author_id, COALESCE\\(u.username, ''\\), COALESCE\\(cat.read_role, 'guest'\\) FROM posts p JOIN threads t ON t.id = p.thread_id LEFT JOIN category_read_roles cat ON cat.id = t.category_id LEFT JOIN users u ON p.author_id = u.id WHERE p.id = ANY\\(\\$1\\)").
+		WithArgs(pq.Array([]int{3, 4})).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "thread_id", "author_id", "username", "read_role"}).AddRow(3, 1, 2, "alice", "moderator"))
+
+	refs, err := repo.GetPostRefs([]int{3, 4})
+	require.NoError(t, err)
+	assert.Equal(t, map[int]models.PostRef{3: {ID: 3, ThreadID: 1, AuthorID: 2, AuthorName: "alice", ReadRole: models.RoleModerator}}, refs)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPostRepository_GetReplies(t *testing.T) {
+	repo, mock, cleanup := setupPostRepositoryTest(t)
+	defer cleanup()
+
+	mock.ExpectQuery("SELECT p.reply_to_post_id, p.id, p.thread_id, p.author_id, COALESCE\\(u.username, ''\\) FROM posts p LEFT JOIN users u ON p.author_id = u.id WHERE p.reply_to_post_id = ANY\\(\\$1\\) ORDER BY p.created_at ASC, p.id ASC").
+		WithArgs(pq.Array([]int{1, 2})).
+		WillReturnRows(sqlmock.NewRows([]string{"reply_to_post_id", "id", "thread_id", "author_id", "username"}).
+			AddRow(1, 3, 1, 2, "alice").
+			AddRow(1, 4, 1, 3, "bob"))
+
+	replies, err := repo.GetReplies([]int{1, 2})
+	require.NoError(t, err)
+	assert.Equal(t, map[int][]models.PostRef{1: {
+		{ID: 3, ThreadID: 1, AuthorID: 2, AuthorName: "alice"},
+		{ID: 4, ThreadID: 1, AuthorID: 3, AuthorName: "bob"},
+	}}, replies)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPostRepository_Pin(t *testing.T) {
+	repo, mock, cleanup := setupPostRepositoryTest(t)
+	defer cleanup()
+
+	mock.ExpectExec("UPDATE posts SET pinned_at = COALESCE\\(pinned_at, CURRENT_TIMESTAMP\\), version = version \\+ 1 WHERE id = \\$1").
+		WithArgs(1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("UPDATE posts SET pinned_at").
+		WithArgs(42).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	require.NoError(t, repo.Pin(1))
+	assert.ErrorIs(t, repo.Pin(42), ErrPostNotFound)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPostRepository_Unpin(t *testing.T) {
+	repo, mock, cleanup := setupPostRepositoryTest(t)
+	defer cleanup()
+
+	mock.ExpectExec("UPDATE posts SET pinned_at = NULL, version = version \\+ 1 WHERE id = \\$1").
+		WithArgs(1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	require.NoError(t, repo.Unpin(1))
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
 func TestPostRepository_GetByThreadID_Error(t *testing.T) {
 	repo, mock, cleanup := setupPostRepositoryTest(t)
 	defer cleanup()
 
-	mock.ExpectQuery("SELECT p.id, p.thread_id, p.author_id, p.content, p.created_at, p.updated_at, u.username as author_name FROM posts p LEFT JOIN users u ON p.author_id = u.id WHERE p.thread_id = \\$1 ORDER BY p.created_at ASC").
+	mock.ExpectQuery("SELECT p.id, p.thread_id, p.author_id, p.title, p.content, p.created_at, p.updated_at, p.comment_count, p.view_count, p.upvotes, p.downvotes, u.username as author_name, p.pinned_at, p.reply_to_post_id FROM posts p LEFT JOIN users u ON p.author_id = u.id WHERE p.thread_id = \\$1 ORDER BY p.pinned_at ASC NULLS LAST, p.created_at ASC").
 		WithArgs(1).
 		WillReturnError(fmt.Errorf("database error"))
 
@@ -390,10 +586,10 @@ func TestPostRepository_GetByThreadID_ScanError(t *testing.T) {
 	repo, mock, cleanup := setupPostRepositoryTest(t)
 	defer cleanup()
 
-	rows := sqlmock.NewRows([]string{"id", "thread_id", "author_id", "content", "created_at", "updated_at", "author_name"}).
-		AddRow("invalid", 1, 1, "Test Post", time.Now(), time.Now(), "Test User")
+	rows := sqlmock.NewRows([]string{"id", "thread_id", "author_id", "title", "content", "created_at", "updated_at", "comment_count", "view_count", "upvotes", "downvotes", "author_name", "pinned_at", "reply_to_post_id"}).
+		AddRow("invalid", 1, 1, "", "Test Post", time.Now(), time.Now(), 0, 0, 0, 0, "Test User", nil, nil)
 
-	mock.ExpectQuery("SELECT p.id, p.thread_id, p.author_id, p.content, p.created_at, p.updated_at, u.username as author_name FROM posts p LEFT JOIN users u ON p.author_id = u.id WHERE p.thread_id = \\$1 ORDER BY p.created_at ASC").
+	mock.ExpectQuery("SELECT p.id, p.thread_id, p.author_id, p.title, p.content, p.created_at, p.updated_at, p.comment_count, p.view_count, p.upvotes, p.downvotes, u.username as author_name, p.pinned_at, p.reply_to_post_id FROM posts p LEFT JOIN users u ON p.author_id = u.id WHERE p.thread_id = \\$1 ORDER BY p.pinned_at ASC NULLS LAST, p.created_at ASC").
 		WithArgs(1).
 		WillReturnRows(rows)
 
@@ -411,8 +607,8 @@ func TestPostRepository_Update(t *testing.T) {
 		Content: "Updated Post",
 	}
 
-	mock.ExpectExec("UPDATE posts SET content = \\$1, updated_at = CURRENT_TIMESTAMP WHERE id = \\$2").
-		WithArgs(post.Content, post.ID).
+	mock.ExpectExec("UPDATE posts SET title = \\$1, content = \\$2, version = version \\+ 1, updated_at = CURRENT_TIMESTAMP WHERE id = \\$3").
+		WithArgs(post.Title, post.Content, post.ID).
 		WillReturnResult(sqlmock.NewResult(0, 1))
 
 	err := repo.Update(post)
@@ -436,9 +632,11 @@ func TestPostRepository_DeletePost_Error(t *testing.T) {
 	defer cleanup()
 
 	mock.ExpectBegin()
-	mock.ExpectExec("DELETE FROM comments WHERE post_id = \\$1").
+	expectPostThreadLookup(mock, 1, 1)
+	expectThreadCountersLock(mock, 1)
+	mock.ExpectQuery("WITH deleted AS \\(DELETE FROM comments WHERE post_id = \\$1 RETURNING deleted_at\\)").
 		WithArgs(1).
-		WillReturnResult(sqlmock.NewResult(0, 1))
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(2))
 	mock.ExpectExec("DELETE FROM posts WHERE id = \\$1").
 		WithArgs(1).
 		WillReturnResult(sqlmock.NewResult(0, 0)) // Возвращаем 0 затронутых строк
@@ -446,7 +644,7 @@ func TestPostRepository_DeletePost_Error(t *testing.T) {
 
 	err := repo.DeletePost(1)
 	require.Error(t, err)
-	assert.Equal(t, "post not found in POST REPO", err.Error())
+	assert.ErrorIs(t, err, ErrPostNotFound)
 }
 
 func TestPostRepository_DeletePost_TransactionError(t *testing.T) {
@@ -460,12 +658,29 @@ func TestPostRepository_DeletePost_TransactionError(t *testing.T) {
 	assert.Equal(t, "transaction error", err.Error())
 }
 
+func TestPostRepository_DeletePost_NotFound(t *testing.T) {
+	repo, mock, cleanup := setupPostRepositoryTest(t)
+	defer cleanup()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT thread_id FROM posts WHERE id = \\$1").
+		WithArgs(1).
+		WillReturnError(sql.ErrNoRows)
+	mock.ExpectRollback()
+
+	err := repo.DeletePost(1)
+	assert.ErrorIs(t, err, ErrPostNotFound)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
 func TestPostRepository_DeletePost_CommentDeleteError(t *testing.T) {
 	repo, mock, cleanup := setupPostRepositoryTest(t)
 	defer cleanup()
 
 	mock.ExpectBegin()
-	mock.ExpectExec("DELETE FROM comments WHERE post_id = \\$1").
+	expectPostThreadLookup(mock, 1, 1)
+	expectThreadCountersLock(mock, 1)
+	mock.ExpectQuery("WITH deleted AS \\(DELETE FROM comments WHERE post_id = \\$1 RETURNING deleted_at\\)").
 		WithArgs(1).
 		WillReturnError(fmt.Errorf("comment delete error"))
 	mock.ExpectRollback()
@@ -480,9 +695,11 @@ func TestPostRepository_DeletePost_PostDeleteError(t *testing.T) {
 	defer cleanup()
 
 	mock.ExpectBegin()
-	mock.ExpectExec("DELETE FROM comments WHERE post_id = \\$1").
+	expectPostThreadLookup(mock, 1, 1)
+	expectThreadCountersLock(mock, 1)
+	mock.ExpectQuery("WITH deleted AS \\(DELETE FROM comments WHERE post_id = \\$1 RETURNING deleted_at\\)").
 		WithArgs(1).
-		WillReturnResult(sqlmock.NewResult(0, 1))
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(2))
 	mock.ExpectExec("DELETE FROM posts WHERE id = \\$1").
 		WithArgs(1).
 		WillReturnError(fmt.Errorf("post delete error"))
@@ -498,12 +715,15 @@ func TestPostRepository_DeletePost_CommitError(t *testing.T) {
 	defer cleanup()
 
 	mock.ExpectBegin()
-	mock.ExpectExec("DELETE FROM comments WHERE post_id = \\$1").
+	expectPostThreadLookup(mock, 1, 1)
+	expectThreadCountersLock(mock, 1)
+	mock.ExpectQuery("WITH deleted AS \\(DELETE FROM comments WHERE post_id = \\$1 RETURNING deleted_at\\)").
 		WithArgs(1).
-		WillReturnResult(sqlmock.NewResult(0, 1))
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(2))
 	mock.ExpectExec("DELETE FROM posts WHERE id = \\$1").
 		WithArgs(1).
 		WillReturnResult(sqlmock.NewResult(0, 1))
+	expectThreadCountersUpdate(mock, 1, -1, -2)
 	mock.ExpectCommit().WillReturnError(fmt.Errorf("commit error"))
 
 	err := repo.DeletePost(1)
@@ -521,15 +741,33 @@ func TestPostRepository_SavePost_Error(t *testing.T) {
 		Content:  "Test Post",
 	}
 
+	mock.ExpectBegin()
+	expectOpenThreadLock(mock, post.ThreadID)
 	mock.ExpectQuery("INSERT INTO posts").
-		WithArgs(post.ThreadID, post.AuthorID, post.Content).
+		WithArgs(post.ThreadID, post.AuthorID, post.Title, post.Content, nil).
 		WillReturnError(fmt.Errorf("database error"))
+	mock.ExpectRollback()
 
 	err := repo.SavePost(post)
 	require.Error(t, err)
 	assert.Equal(t, "database error", err.Error())
 }
 
+func TestPostRepository_SavePost_ThreadLocked(t *testing.T) {
+	repo, mock, cleanup := setupPostRepositoryTest(t)
+	defer cleanup()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT locked FROM threads WHERE id = \\$1 FOR UPDATE").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"locked"}).AddRow(true))
+	mock.ExpectRollback()
+
+	err := repo.SavePost(&models.Post{ThreadID: 1, AuthorID: 1, Content: "Test Post"})
+	assert.ErrorIs(t, err, ErrThreadLocked)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
 func TestPostRepository_Update_Error(t *testing.T) {
 	repo, mock, cleanup := setupPostRepositoryTest(t)
 	defer cleanup()
@@ -539,8 +777,8 @@ func TestPostRepository_Update_Error(t *testing.T) {
 		Content: "Updated Post",
 	}
 
-	mock.ExpectExec("UPDATE posts SET content = \\$1, updated_at = CURRENT_TIMESTAMP WHERE id = \\$2").
-		WithArgs(post.Content, post.ID).
+	mock.ExpectExec("UPDATE posts SET title = \\$1, content = \\$2, version = version \\+ 1, updated_at = CURRENT_TIMESTAMP WHERE id = \\$3").
+		WithArgs(post.Title, post.Content, post.ID).
 		WillReturnError(fmt.Errorf("database error"))
 
 	err := repo.Update(post)
@@ -557,11 +795,19 @@ func TestPostRepository_UpdatePost_Error(t *testing.T) {
 		Content: "Updated Post",
 	}
 
-	mock.ExpectExec("UPDATE posts SET content = \\$1, updated_at = CURRENT_TIMESTAMP WHERE id = \\$2").
-		WithArgs(post.Content, post.ID).
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT version FROM posts WHERE id = \\$1 FOR UPDATE").
+		WithArgs(post.ID).
+		WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow(1))
+	mock.ExpectExec("INSERT INTO post_revisions").
+		WithArgs(post.ID, 1, "").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectQuery("UPDATE posts SET title = \\$1, content = \\$2, version = version \\+ 1").
+		WithArgs(post.Title, post.Content, post.ID).
 		WillReturnError(fmt.Errorf("database error"))
+	mock.ExpectRollback()
 
-	err := repo.UpdatePost(post, post.ID)
+	err := repo.UpdatePost(post, post.ID, 1, "")
 	require.Error(t, err)
 	assert.Equal(t, "database error", err.Error())
 }
@@ -580,15 +826,15 @@ func TestPostRepository_GetPostWithComments_CommentContentScanError(t *testing.T
 		AuthorName: "Test User",
 	}
 
-	mock.ExpectQuery("SELECT p.id, p.thread_id, p.author_id, p.content, p.created_at, p.updated_at, u.username as author_name FROM posts p LEFT JOIN users u ON p.author_id = u.id WHERE p.id = \\$1").
+	mock.ExpectQuery("SELECT p.id, p.thread_id, p.author_id, p.title, p.content, p.created_at, p.updated_at, p.version, p.comment_count, p.view_count, p.upvotes, p.downvotes, u.username as author_name, p.pinned_at, p.reply_to_post_id FROM posts p LEFT JOIN users u ON p.author_id = u.id WHERE p.id = \\$1").
 		WithArgs(1).
-		WillReturnRows(sqlmock.NewRows([]string{"id", "thread_id", "author_id", "content", "created_at", "updated_at", "author_name"}).
-			AddRow(expectedPost.ID, expectedPost.ThreadID, expectedPost.AuthorID, expectedPost.Content, expectedPost.CreatedAt, expectedPost.UpdatedAt, expectedPost.AuthorName))
+		WillReturnRows(sqlmock.NewRows([]string{"id", "thread_id", "author_id", "title", "content", "created_at", "updated_at", "version", "comment_count", "view_count", "upvotes", "downvotes", "author_name", "pinned_at", "reply_to_post_id"}).
+			AddRow(expectedPost.ID, expectedPost.ThreadID, expectedPost.AuthorID, expectedPost.Title, expectedPost.Content, expectedPost.CreatedAt, expectedPost.UpdatedAt, 1, 3, 0, 2, 1, expectedPost.AuthorName, nil, nil))
 
-	commentRows := sqlmock.NewRows([]string{"id", "post_id", "author_id", "content", "created_at", "author_name"})
-	commentRows.AddRow(1, 1, 1, nil, time.Now(), "Test User")
+	commentRows := sqlmock.NewRows([]string{"id", "post_id", "parent_comment_id", "depth", "author_id", "content", "created_at", "updated_at", "deleted_at", "upvotes", "downvotes", "author_name"})
+	commentRows.AddRow(1, 1, nil, 0, 1, nil, time.Now(), time.Now(), nil, 0, 0, "Test User")
 
-	mock.ExpectQuery("SELECT c.id, c.post_id, c.author_id, c.content, c.created_at, u.username as author_name FROM comments c LEFT JOIN users u ON c.author_id = u.id WHERE c.post_id = \\$1 ORDER BY c.created_at ASC").
+	mock.ExpectQuery("SELECT c.id, c.post_id, c.parent_comment_id, c.depth, c.author_id, c.content, c.created_at, c.updated_at, c.deleted_at, c.upvotes, c.downvotes, u.username as author_name FROM comments c LEFT JOIN users u ON c.author_id = u.id WHERE c.post_id = \\$1 ORDER BY c.created_at ASC").
 		WithArgs(1).
 		WillReturnRows(commentRows)
 
@@ -605,7 +851,7 @@ func TestPostRepository_GetPostsWithCommentsByThreadID_CommentContentScanError(t
 	postRows := sqlmock.NewRows([]string{"id", "thread_id", "content", "created_at", "author_id", "author_username"})
 	postRows.AddRow(1, 1, "Test Post 1", time.Now(), 1, "Test User 1")
 
-	mock.ExpectQuery("SELECT p.id, p.thread_id, p.content, p.created_at, u.id as author_id, u.username as author_username FROM posts p JOIN users u ON p.user_id = u.id WHERE p.thread_id = \\$1 ORDER BY p.created_at DESC LIMIT \\$2 OFFSET \\$3").
+	mock.ExpectQuery("SELECT p.id, p.thread_id, p.content, p.created_at, u.id as author_id, u.username as author_username FROM posts p JOIN users u ON p.author_id = u.id WHERE p.thread_id = \\$1 ORDER BY p.created_at DESC LIMIT \\$2 OFFSET \\$3").
 		WithArgs(1, 20, 0).
 		WillReturnRows(postRows)
 
@@ -626,10 +872,10 @@ func TestPostRepository_GetPostWithComments_PostContentScanError(t *testing.T) {
 	repo, mock, cleanup := setupPostRepositoryTest(t)
 	defer cleanup()
 
-	postRows := sqlmock.NewRows([]string{"id", "thread_id", "author_id", "content", "created_at", "updated_at", "author_name"})
-	postRows.AddRow(1, 1, 1, nil, time.Now(), time.Now(), "Test User")
+	postRows := sqlmock.NewRows([]string{"id", "thread_id", "author_id", "title", "content", "created_at", "updated_at", "version", "comment_count", "view_count", "upvotes", "downvotes", "author_name", "pinned_at", "reply_to_post_id"})
+	postRows.AddRow(1, 1, 1, "", nil, time.Now(), time.Now(), 1, 0, 0, 0, 0, "Test User", nil, nil)
 
-	mock.ExpectQuery("SELECT p.id, p.thread_id, p.author_id, p.content, p.created_at, p.updated_at, u.username as author_name FROM posts p LEFT JOIN users u ON p.author_id = u.id WHERE p.id = \\$1").
+	mock.ExpectQuery("SELECT p.id, p.thread_id, p.author_id, p.title, p.content, p.created_at, p.updated_at, p.version, p.comment_count, p.view_count, p.upvotes, p.downvotes, u.username as author_name, p.pinned_at, p.reply_to_post_id FROM posts p LEFT JOIN users u ON p.author_id = u.id WHERE p.id = \\$1").
 		WithArgs(1).
 		WillReturnRows(postRows)
 
@@ -646,7 +892,7 @@ func TestPostRepository_GetPostsWithCommentsByThreadID_PostContentScanError(t *t
 	postRows := sqlmock.NewRows([]string{"id", "thread_id", "content", "created_at", "author_id", "author_username"})
 	postRows.AddRow(1, 1, nil, time.Now(), 1, "Test User 1")
 
-	mock.ExpectQuery("SELECT p.id, p.thread_id, p.content, p.created_at, u.id as author_id, u.username as author_username FROM posts p JOIN users u ON p.user_id = u.id WHERE p.thread_id = \\$1 ORDER BY p.created_at DESC LIMIT \\$2 OFFSET \\$3").
+	mock.ExpectQuery("SELECT p.id, p.thread_id, p.content, p.created_at, u.id as author_id, u.username as author_username FROM posts p JOIN users u ON p.author_id = u.id WHERE p.thread_id = \\$1 ORDER BY p.created_at DESC LIMIT \\$2 OFFSET \\$3").
 		WithArgs(1, 20, 0).
 		WillReturnRows(postRows)
 
@@ -0,0 +1,110 @@
+package repository
+
+import (
+	"ForumService/internal/models"
+	"database/sql"
+	"fmt"
+
+	"github.com/lib/pq"
+)
+
+// reactionTables - таблицы записей, на которые ставят реакции, по виду записи
+var reactionTables = map[string]struct {
+	table    string
+	notFound error
+}{
+	models.ReactionTargetPost:    {table: "posts", notFound: ErrPostNotFound},
+	models.ReactionTargetComment: {table: "comments", notFound: ErrCommentNotFound},
+	models.ReactionTargetChat:    {table: "chat_messages", notFound: ErrMessageNotFound},
+}
+
+type reactionRepository struct {
+	db *sql.DB
+}
+
+func NewReactionRepository(db *sql.DB) ReactionRepository {
+	return &reactionRepository{db: db}
+}
+
+// Toggle блокирует запись на удаление, пока ставится реакция: у reactions нет внешнего
+// ключа на запись, и без блокировки реакция могла бы пережить удаленную запись
+func (r *reactionRepository) Toggle(targetType string, targetID, userID int, emoji string) (bool, error) {
+	target, ok := reactionTables[targetType]
+	if !ok {
+		return false, fmt.Errorf("неизвестный вид записи для реакции: %s", targetType)
+	}
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return false, fmt.Errorf("ошибка при начале транзакции: %w", err)
+	}
+	defer tx.Rollback()
+
+	var lockedID int
+	err = tx.QueryRow(`SELECT id FROM `+target.table+` WHERE id = $1 FOR SHARE`, targetID).Scan(&lockedID)
+	if err == sql.ErrNoRows {
+		return false, target.notFound
+	}
+	if err != nil {
+		return false, fmt.Errorf("ошибка при блокировке записи: %w", err)
+	}
+
+	result, err := tx.Exec(`DELETE FROM reactions WHERE target_type = $1 AND target_id = $2 AND user_id = $3 AND emoji = $4`,
+		targetType, targetID, userID, emoji)
+	if err != nil {
+		return false, fmt.Errorf("ошибка при снятии реакции: %w", err)
+	}
+	removed, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	added := removed == 0
+	if added {
+		_, err = tx.Exec(`INSERT INTO reactions (target_type, target_id, user_id, emoji) VALUES ($1, $2, $3, $4) ON CONFLICT DO NOTHING`,
+			targetType, targetID, userID, emoji)
+		if isForeignKeyViolation(err) {
+			return false, ErrUserNotFound
+		}
+		if err != nil {
+			return false, fmt.Errorf("ошибка при сохранении реакции: %w", err)
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return false, fmt.Errorf("ошибка при фиксации транзакции: %w", err)
+	}
+	return added, nil
+}
+
+func (r *reactionRepository) GetCounts(targetType string, targetIDs []int, userID int) (map[int][]models.ReactionCount, error) {
+	counts := make(map[int][]models.ReactionCount)
+	if len(targetIDs) == 0 {
+		return counts, nil
+	}
+
+	rows, err := r.db.Query(`
+		SELECT target_id, emoji, COUNT(*), BOOL_OR(user_id = $3)
+		FROM reactions
+		WHERE target_type = $1 AND target_id = ANY($2)
+		GROUP BY target_id, emoji
+		ORDER BY target_id, MIN(created_at), emoji`,
+		targetType, pq.Array(targetIDs), userID)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при получении реакций: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var targetID int
+		var count models.ReactionCount
+		if err := rows.Scan(&targetID, &count.Emoji, &count.Count, &count.ReactedByMe); err != nil {
+			return nil, fmt.Errorf("ошибка при сканировании реакции: %w", err)
+		}
+		counts[targetID] = append(counts[targetID], count)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("ошибка при итерации по реакциям: %w", err)
+	}
+	return counts, nil
+}
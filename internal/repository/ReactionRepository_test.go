@@ -0,0 +1,134 @@
+package repository
+
+import (
+	"database/sql"
+	"testing"
+
+	"ForumService/internal/models"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupReactionRepositoryTest(t *testing.T) (ReactionRepository, sqlmock.Sqlmock, func()) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+
+	return NewReactionRepository(db), mock, func() { db.Close() }
+}
+
+func TestReactionRepository_Toggle_Add(t *testing.T) {
+	repo, mock, cleanup := setupReactionRepositoryTest(t)
+	defer cleanup()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT id FROM posts WHERE id = \\$1 FOR SHARE").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectExec("DELETE FROM reactions WHERE target_type = \\$1 AND target_id = \\$2 AND user_id = \\$3 AND emoji = \\$4").
+		WithArgs("post", 1, 2, "👍").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("INSERT INTO reactions \\(target_type, target_id, user_id, emoji\\) VALUES \\(\\$1, \\$2, \\$3, \\$4\\) ON CONFLICT DO NOTHING").
+		WithArgs("post", 1, 2, "👍").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	added, err := repo.Toggle(models.ReactionTargetPost, 1, 2, "👍")
+	require.NoError(t, err)
+	assert.True(t, added)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestReactionRepository_Toggle_Remove(t *testing.T) {
+	repo, mock, cleanup := setupReactionRepositoryTest(t)
+	defer cleanup()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT id FROM chat_messages WHERE id = \\$1 FOR SHARE").
+		WithArgs(7).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(7))
+	mock.ExpectExec("DELETE FROM reactions").
+		WithArgs("chat", 7, 2, "🎉").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	added, err := repo.Toggle(models.ReactionTargetChat, 7, 2, "🎉")
+	require.NoError(t, err)
+	assert.False(t, added)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestReactionRepository_Toggle_NotFound(t *testing.T) {
+	repo, mock, cleanup := setupReactionRepositoryTest(t)
+	defer cleanup()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT id FROM comments WHERE id = \\$1 FOR SHARE").
+		WithArgs(99).
+		WillReturnError(sql.ErrNoRows)
+	mock.ExpectRollback()
+
+	_, err := repo.Toggle(models.ReactionTargetComment, 99, 2, "👍")
+	assert.ErrorIs(t, err, ErrCommentNotFound)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestReactionRepository_Toggle_UnknownUser(t *testing.T) {
+	repo, mock, cleanup := setupReactionRepositoryTest(t)
+	defer cleanup()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT id FROM posts").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectExec("DELETE FROM reactions").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("INSERT INTO reactions").
+		WillReturnError(&pq.Error{Code: "23503"})
+	mock.ExpectRollback()
+
+	_, err := repo.Toggle(models.ReactionTargetPost, 1, 42, "👍")
+	assert.ErrorIs(t, err, ErrUserNotFound)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestReactionRepository_Toggle_UnknownTarget(t *testing.T) {
+	repo, mock, cleanup := setupReactionRepositoryTest(t)
+	defer cleanup()
+
+	_, err := repo.Toggle("thread", 1, 2, "👍")
+	assert.Error(t, err)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestReactionRepository_GetCounts(t *testing.T) {
+	repo, mock, cleanup := setupReactionRepositoryTest(t)
+	defer cleanup()
+
+	mock.ExpectQuery("SELECT target_id, emoji, COUNT\\(\\*\\), BOOL_OR\\(user_id = \\$3\\) FROM reactions WHERE target_type = \\$1 AND target_id = ANY\\(\\$2\\)").
+		WithArgs("comment", pq.Array([]int{5, 6}), 2).
+		WillReturnRows(sqlmock.NewRows([]string{"target_id", "emoji", "count", "bool_or"}).
+			AddRow(5, "👍", 3, true).
+			AddRow(5, "🎉", 1, false).
+			AddRow(6, "👀", 2, false))
+
+	counts, err := repo.GetCounts(models.ReactionTargetComment, []int{5, 6}, 2)
+	require.NoError(t, err)
+	assert.Equal(t, []models.ReactionCount{
+		{Emoji: "👍", Count: 3, ReactedByMe: true},
+		{Emoji: "🎉", Count: 1},
+	}, counts[5])
+	assert.Equal(t, []models.ReactionCount{{Emoji: "👀", Count: 2}}, counts[6])
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestReactionRepository_GetCounts_Empty(t *testing.T) {
+	repo, mock, cleanup := setupReactionRepositoryTest(t)
+	defer cleanup()
+
+	counts, err := repo.GetCounts(models.ReactionTargetPost, nil, 2)
+	require.NoError(t, err)
+	assert.Empty(t, counts)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,73 @@
+package repository
+
+import "database/sql"
+
+// Repositories объединяет репозитории сервиса, чтобы хранилище можно было выбрать в одном месте
+type Repositories struct {
+	Threads       ThreadRepository
+	Posts         PostRepository
+	Comments      CommentRepository
+	Chat          ChatRepository
+	Users         UserRepository
+	Search        SearchRepository
+	Counters      CounterRepository
+	Views         ViewRepository
+	Votes         VoteRepository
+	Reactions     ReactionRepository
+	Bookmarks     BookmarkRepository
+	Subscriptions SubscriptionRepository
+	Notifications NotificationRepository
+	Mentions      MentionRepository
+	Attachments   AttachmentRepository
+	Polls         PollRepository
+	Categories    CategoryRepository
+	Tags          TagRepository
+}
+
+// NewPostgresRepositories создает репозитории, работающие с PostgreSQL
+func NewPostgresRepositories(db *sql.DB) *Repositories {
+	return &Repositories{
+		Threads:       NewThreadRepository(db),
+		Posts:         NewPostRepository(db),
+		Comments:      NewCommentRepository(db),
+		Chat:          NewChatRepository(db),
+		Users:         NewUserRepository(db),
+		Search:        NewSearchRepository(db),
+		Counters:      NewCounterRepository(db),
+		Views:         NewViewRepository(db),
+		Votes:         NewVoteRepository(db),
+		Reactions:     NewReactionRepository(db),
+		Bookmarks:     NewBookmarkRepository(db),
+		Subscriptions: NewSubscriptionRepository(db),
+		Notifications: NewNotificationRepository(db),
+		Mentions:      NewMentionRepository(db),
+		Attachments:   NewAttachmentRepository(db),
+		Polls:         NewPollRepository(db),
+		Categories:    NewCategoryRepository(db),
+		Tags:          NewTagRepository(db),
+	}
+}
+
+// NewMemoryRepositories создает репозитории поверх общего хранилища в памяти
+func NewMemoryRepositories(store *MemoryStore) *Repositories {
+	return &Repositories{
+		Threads:       NewMemoryThreadRepository(store),
+		Posts:         NewMemoryPostRepository(store),
+		Comments:      NewMemoryCommentRepository(store),
+		Chat:          NewMemoryChatRepository(store),
+		Users:         NewMemoryUserRepository(store),
+		Search:        NewMemorySearchRepository(store),
+		Counters:      NewMemoryCounterRepository(store),
+		Views:         NewMemoryViewRepository(store),
+		Votes:         NewMemoryVoteRepository(store),
+		Reactions:     NewMemoryReactionRepository(store),
+		Bookmarks:     NewMemoryBookmarkRepository(store),
+		Subscriptions: NewMemorySubscriptionRepository(store),
+		Notifications: NewMemoryNotificationRepository(store),
+		Mentions:      NewMemoryMentionRepository(store),
+		Attachments:   NewMemoryAttachmentRepository(store),
+		Polls:         NewMemoryPollRepository(store),
+		Categories:    NewMemoryCategoryRepository(store),
+		Tags:          NewMemoryTagRepository(store),
+	}
+}
@@ -0,0 +1,1521 @@
+package repository
+
+import (
+	"ForumService/internal/models"
+	"ForumService/internal/storage"
+	"database/sql"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// contractBackend - набор репозиториев, на котором прогоняется общий контрактный тест.
+// addUser создает пользователя напрямую в хранилище: пользователей заводит AuthService.
+type contractBackend struct {
+	repos   *Repositories
+	addUser func(t *testing.T, username string, role string) int
+}
+
+func newMemoryBackend(t *testing.T) *contractBackend {
+	store := NewMemoryStore()
+	repos := NewMemoryRepositories(store)
+	return &contractBackend{
+		repos: repos,
+		addUser: func(t *testing.T, username string, role string) int {
+			user := &models.User{Username: username, Email: username + "@example.com"}
+			require.NoError(t, repos.Users.SaveUser(user))
+			store.EnsureUser(user.ID, username, role)
+			return user.ID
+		},
+	}
+}
+
+// newPostgresBackend подключается к базе из TEST_DATABASE_URL с примененными миграциями
+// и очищает таблицы. Без переменной окружения тест пропускается.
+func newPostgresBackend(t *testing.T) *contractBackend {
+	dsn := os.Getenv("TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_URL не задан")
+	}
+
+	db, err := sql.Open("postgres", dsn)
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+
+	_, err = db.Exec(`TRUNCATE chat_messages, post_revisions, comments, posts, threads, categories, tags, users RESTART IDENTITY CASCADE`)
+	require.NoError(t, err)
+
+	return &contractBackend{
+		repos: NewPostgresRepositories(db),
+		addUser: func(t *testing.T, username string, role string) int {
+			var id int
+			err := db.QueryRow(`INSERT INTO users (username, email, password, role) VALUES ($1, $2, 'password', $3) RETURNING id`,
+				username, username+"@example.com", role).Scan(&id)
+			require.NoError(t, err)
+			return id
+		},
+	}
+}
+
+func TestRepositoryContract_Memory(t *testing.T) {
+	runRepositoryContract(t, newMemoryBackend)
+}
+
+func TestRepositoryContract_Postgres(t *testing.T) {
+	runRepositoryContract(t, newPostgresBackend)
+}
+
+// runRepositoryContract проверяет поведение, одинаковое для всех реализаций репозиториев
+func runRepositoryContract(t *testing.T, newBackend func(t *testing.T) *contractBackend) {
+	tests := []struct {
+		name string
+		run  func(t *testing.T, b *contractBackend)
+	}{
+		{"пользователи", contractUsers},
+		{"треды", contractThreads},
+		{"каскадное удаление треда", contractThreadCascade},
+		{"разделы", contractCategories},
+		{"теги", contractTags},
+		{"тред с постами", contractThreadWithPosts},
+		{"посты", contractPosts},
+		{"закрепление", contractPins},
+		{"принятые ответы", contractAcceptedAnswers},
+		{"ответы на посты", contractPostReplies},
+		{"просмотры", contractViews},
+		{"голоса", contractVotes},
+		{"реакции", contractReactions},
+		{"закладки", contractBookmarks},
+		{"закладки в закрытых разделах", contractBookmarksClosedCategory},
+		{"подписки", contractSubscriptions},
+		{"уведомления", contractNotifications},
+		{"упоминания", contractMentions},
+		{"вложения", contractAttachments},
+		{"опросы", contractPolls},
+		{"правки постов", contractPostRevisions},
+		{"комментарии", contractComments},
+		{"счетчики тредов", contractCounters},
+		{"чат", contractChat},
+		{"поиск", contractSearch},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.run(t, newBackend(t))
+		})
+	}
+}
+
+func contractUsers(t *testing.T, b *contractBackend) {
+	userID := b.addUser(t, "alice", "admin")
+
+	user, err := b.repos.Users.GetUserByID(userID)
+	require.NoError(t, err)
+	assert.Equal(t, "alice", user.Username)
+
+	role, err := b.repos.Users.GetUserRole(userID)
+	require.NoError(t, err)
+	assert.Equal(t, "admin", role)
+
+	_, err = b.repos.Users.GetUserByID(userID + 100)
+	assert.ErrorIs(t, err, ErrUserNotFound)
+	_, err = b.repos.Users.GetUserRole(userID + 100)
+	assert.ErrorIs(t, err, ErrUserNotFound)
+
+	posts, err := b.repos.Users.GetUserPosts(userID)
+	require.NoError(t, err)
+	assert.Empty(t, posts)
+}
+
+func contractThreads(t *testing.T, b *contractBackend) {
+	userID := b.addUser(t, "alice", "user")
+
+	first := &models.Thread{Title: "Первый", AuthorID: userID}
+	require.NoError(t, b.repos.Threads.Create(first))
+	second := &models.Thread{Title: "Второй", AuthorID: userID}
+	require.NoError(t, b.repos.Threads.Create(second))
+	assert.NotZero(t, first.ID)
+	assert.NotEqual(t, first.ID, second.ID)
+
+	thread, err := b.repos.Threads.GetByID(first.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Первый", thread.Title)
+	assert.Equal(t, 1, thread.Version)
+
+	threads, err := b.repos.Threads.GetAllThreads()
+	require.NoError(t, err)
+	require.Len(t, threads, 2)
+	assert.Equal(t, second.ID, threads[0].ID, "новые треды идут первыми")
+	assert.Equal(t, "alice", threads[0].AuthorName)
+
+	thread.Title = "Переименован"
+	thread.Type = models.ThreadTypeQuestion
+	require.NoError(t, b.repos.Threads.Update(thread))
+	assert.Equal(t, 2, thread.Version)
+	renamed, err := b.repos.Threads.GetByID(first.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.ThreadTypeQuestion, renamed.Type)
+
+	moderatorID := b.addUser(t, "moder", "moderator")
+	require.NoError(t, b.repos.Threads.Lock(second.ID, moderatorID, "Вопрос решен"))
+	locked, err := b.repos.Threads.GetByID(second.ID)
+	require.NoError(t, err)
+	assert.True(t, locked.Locked)
+	require.NotNil(t, locked.LockedAt)
+	require.NotNil(t, locked.LockedByID)
+	assert.Equal(t, moderatorID, *locked.LockedByID)
+	assert.Equal(t, "moder", locked.LockedByName)
+	assert.Equal(t, "Вопрос решен", locked.LockReason)
+	assert.Equal(t, 2, locked.Version, "закрытие меняет версию треда")
+	threads, err = b.repos.Threads.GetAllThreads()
+	require.NoError(t, err)
+	assert.True(t, threads[0].Locked)
+	assert.Equal(t, "moder", threads[0].LockedByName)
+	lockedPost := &models.Post{ThreadID: second.ID, AuthorID: userID, Content: "Поздно"}
+	assert.ErrorIs(t, b.repos.Posts.SavePost(lockedPost), ErrThreadLocked)
+	assert.Zero(t, lockedPost.ID)
+	require.NoError(t, b.repos.Threads.Unlock(second.ID))
+	locked, err = b.repos.Threads.GetByID(second.ID)
+	require.NoError(t, err)
+	assert.False(t, locked.Locked)
+	assert.Nil(t, locked.LockedAt)
+	assert.Nil(t, locked.LockedByID)
+	assert.Empty(t, locked.LockReason)
+	assert.ErrorIs(t, b.repos.Threads.Lock(second.ID+100, moderatorID, ""), ErrThreadNotFound)
+	assert.ErrorIs(t, b.repos.Threads.Unlock(second.ID+100), ErrThreadNotFound)
+
+	stale := &models.Thread{ID: first.ID, Title: "Устаревшая правка", Version: 1}
+	assert.ErrorIs(t, b.repos.Threads.Update(stale), ErrVersionConflict)
+	assert.ErrorIs(t, b.repos.Threads.Update(&models.Thread{ID: first.ID + 100, Title: "Нет"}), ErrThreadNotFound)
+
+	require.NoError(t, b.repos.Threads.Delete(first.ID))
+	_, err = b.repos.Threads.GetByID(first.ID)
+	assert.ErrorIs(t, err, ErrThreadNotFound)
+	assert.ErrorIs(t, b.repos.Threads.Delete(first.ID), ErrThreadNotFound)
+}
+
+func contractThreadCascade(t *testing.T, b *contractBackend) {
+	userID := b.addUser(t, "alice", "user")
+	thread := &models.Thread{Title: "Тред", AuthorID: userID}
+	require.NoError(t, b.repos.Threads.Create(thread))
+	post := &models.Post{ThreadID: thread.ID, AuthorID: userID, Content: "Пост"}
+	require.NoError(t, b.repos.Posts.SavePost(post))
+	comment := &models.Comment{PostID: post.ID, AuthorID: userID, Content: "Комментарий"}
+	require.NoError(t, b.repos.Comments.SaveComment(comment))
+
+	require.NoError(t, b.repos.Threads.Delete(thread.ID))
+
+	_, err := b.repos.Posts.GetPostByID(post.ID)
+	assert.ErrorIs(t, err, ErrPostNotFound)
+	_, err = b.repos.Comments.GetCommentByID(comment.ID)
+	assert.ErrorIs(t, err, ErrCommentNotFound)
+
+	count, err := b.repos.Users.GetUserCommentCount(userID)
+	require.NoError(t, err)
+	assert.Equal(t, 0, count)
+}
+
+func contractCategories(t *testing.T, b *contractBackend) {
+	userID := b.addUser(t, "alice", "admin")
+
+	root := &models.Category{Name: "Языки", Position: 2, ReadRole: models.RoleGuest, CreateRole: models.RoleUser}
+	require.NoError(t, b.repos.Categories.Create(root))
+	news := &models.Category{Name: "Новости", Position: 1, ReadRole: models.RoleGuest, CreateRole: models.RoleAdmin}
+	require.NoError(t, b.repos.Categories.Create(news))
+	child := &models.Category{ParentID: &root.ID, Name: "Go", ReadRole: models.RoleUser, CreateRole: models.RoleUser}
+	require.NoError(t, b.repos.Categories.Create(child))
+	assert.NotZero(t, child.ID)
+
+	missing := root.ID + 100
+	assert.ErrorIs(t, b.repos.Categories.Create(&models.Category{ParentID: &missing, Name: "Нет",
+		ReadRole: models.RoleGuest, CreateRole: models.RoleUser}), ErrCategoryNotFound)
+
+	categories, err := b.repos.Categories.GetAll()
+	require.NoError(t, err)
+	require.Len(t, categories, 3)
+	assert.Equal(t, []string{"Go", "Новости", "Языки"},
+		[]string{categories[0].Name, categories[1].Name, categories[2].Name}, "разделы сортируются по позиции и названию")
+
+	thread := &models.Thread{Title: "Тред", AuthorID: userID, CategoryID: &child.ID}
+	require.NoError(t, b.repos.Threads.Create(thread))
+	require.NoError(t, b.repos.Posts.SavePost(&models.Post{ThreadID: thread.ID, AuthorID: userID, Content: "Пост"}))
+	require.NoError(t, b.repos.Threads.Create(&models.Thread{Title: "Без раздела", AuthorID: userID}))
+
+	stored, err := b.repos.Threads.GetByID(thread.ID)
+	require.NoError(t, err)
+	require.NotNil(t, stored.CategoryID)
+	assert.Equal(t, child.ID, *stored.CategoryID)
+	assert.Equal(t, "Go", stored.CategoryName)
+
+	threads, err := b.repos.Threads.GetByCategoryID(child.ID)
+	require.NoError(t, err)
+	require.Len(t, threads, 1)
+	assert.Equal(t, thread.ID, threads[0].ID)
+	assert.Equal(t, "Go", threads[0].CategoryName)
+
+	category, err := b.repos.Categories.GetByID(child.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 1, category.ThreadCount)
+	assert.Equal(t, 1, category.PostCount)
+	require.NotNil(t, category.ParentID)
+	assert.Equal(t, root.ID, *category.ParentID)
+
+	child.Name = "Golang"
+	child.ParentID = nil
+	require.NoError(t, b.repos.Categories.Update(child))
+	stored, err = b.repos.Threads.GetByID(thread.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Golang", stored.CategoryName)
+	assert.ErrorIs(t, b.repos.Categories.Update(&models.Category{ID: missing, Name: "Нет",
+		ReadRole: models.RoleGuest, CreateRole: models.RoleUser}), ErrCategoryNotFound)
+
+	assert.ErrorIs(t, b.repos.Categories.Delete(child.ID), ErrCategoryNotEmpty)
+	require.NoError(t, b.repos.Threads.Delete(thread.ID))
+	require.NoError(t, b.repos.Categories.Delete(child.ID))
+	_, err = b.repos.Categories.GetByID(child.ID)
+	assert.ErrorIs(t, err, ErrCategoryNotFound)
+	assert.ErrorIs(t, err, ErrNotFound)
+	assert.ErrorIs(t, b.repos.Categories.Delete(child.ID), ErrCategoryNotFound)
+}
+
+func contractTags(t *testing.T, b *contractBackend) {
+	userID := b.addUser(t, "alice", "user")
+	first := &models.Thread{Title: "Первый", AuthorID: userID}
+	require.NoError(t, b.repos.Threads.Create(first))
+	second := &models.Thread{Title: "Второй", AuthorID: userID}
+	require.NoError(t, b.repos.Threads.Create(second))
+
+	require.NoError(t, b.repos.Tags.SetThreadTags(first.ID, []string{"go", "sql"}))
+	require.NoError(t, b.repos.Tags.SetThreadTags(second.ID, []string{"go", "golang"}))
+	assert.ErrorIs(t, b.repos.Tags.SetThreadTags(second.ID+100, []string{"go"}), ErrThreadNotFound)
+
+	tags, err := b.repos.Tags.GetByThreadIDs([]int{first.ID, second.ID})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"go", "sql"}, tags[first.ID])
+	assert.Equal(t, []string{"go", "golang"}, tags[second.ID])
+
+	all, err := b.repos.Tags.GetAll()
+	require.NoError(t, err)
+	require.Len(t, all, 3)
+	assert.Equal(t, "go", all[0].Name, "популярные теги идут первыми")
+	assert.Equal(t, 2, all[0].ThreadCount)
+	assert.Equal(t, []string{"golang", "sql"}, []string{all[1].Name, all[2].Name})
+
+	threadIDs, err := b.repos.Tags.GetThreadIDs([]string{"go", "sql"}, true)
+	require.NoError(t, err)
+	assert.Equal(t, []int{first.ID}, threadIDs)
+	threadIDs, err = b.repos.Tags.GetThreadIDs([]string{"sql", "golang"}, false)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []int{first.ID, second.ID}, threadIDs)
+
+	golang, sqlTag := all[1], all[2]
+	assert.ErrorIs(t, b.repos.Tags.Rename(sqlTag.ID, "go"), ErrAlreadyExists)
+	assert.ErrorIs(t, b.repos.Tags.Rename(sqlTag.ID+100, "db"), ErrTagNotFound)
+	require.NoError(t, b.repos.Tags.Rename(sqlTag.ID, "postgres"))
+
+	// У второго треда уже есть оба тега - после объединения остается один
+	require.NoError(t, b.repos.Tags.Merge(golang.ID, all[0].ID))
+	assert.ErrorIs(t, b.repos.Tags.Merge(golang.ID, all[0].ID), ErrTagNotFound)
+	_, err = b.repos.Tags.GetByID(golang.ID)
+	assert.ErrorIs(t, err, ErrTagNotFound)
+	tags, err = b.repos.Tags.GetByThreadIDs([]int{first.ID, second.ID})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"go", "postgres"}, tags[first.ID])
+	assert.Equal(t, []string{"go"}, tags[second.ID])
+
+	require.NoError(t, b.repos.Tags.SetThreadTags(first.ID, nil))
+	require.NoError(t, b.repos.Threads.Delete(second.ID))
+	all, err = b.repos.Tags.GetAll()
+	require.NoError(t, err)
+	assert.Empty(t, all, "неиспользуемые теги не выводятся")
+	tag, err := b.repos.Tags.GetByID(sqlTag.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 0, tag.ThreadCount)
+}
+
+func contractThreadWithPosts(t *testing.T, b *contractBackend) {
+	userID := b.addUser(t, "alice", "user")
+	thread := &models.Thread{Title: "Тред", AuthorID: userID}
+	require.NoError(t, b.repos.Threads.Create(thread))
+	older := &models.Post{ThreadID: thread.ID, AuthorID: userID, Content: "Старый"}
+	require.NoError(t, b.repos.Posts.SavePost(older))
+	newer := &models.Post{ThreadID: thread.ID, AuthorID: userID, Content: "Новый"}
+	require.NoError(t, b.repos.Posts.SavePost(newer))
+	comment := &models.Comment{PostID: older.ID, AuthorID: userID, Content: "Комментарий"}
+	require.NoError(t, b.repos.Comments.SaveComment(comment))
+
+	got, posts, comments, err := b.repos.Threads.GetThreadWithPosts(thread.ID)
+	require.NoError(t, err)
+	assert.Equal(t, thread.ID, got.ID)
+	require.Len(t, posts, 2)
+	assert.Equal(t, newer.ID, posts[0].ID, "новые посты идут первыми")
+	require.Len(t, comments[older.ID], 1)
+	assert.Equal(t, "Комментарий", comments[older.ID][0].Content)
+
+	byThread, byThreadComments, err := b.repos.Posts.GetPostsWithCommentsByThreadID(thread.ID)
+	require.NoError(t, err)
+	require.Len(t, byThread, 2)
+	assert.Equal(t, newer.ID, byThread[0].ID)
+	assert.Equal(t, "alice", byThread[0].AuthorName)
+	assert.Len(t, byThreadComments[older.ID], 1)
+
+	_, _, _, err = b.repos.Threads.GetThreadWithPosts(thread.ID + 100)
+	assert.ErrorIs(t, err, ErrThreadNotFound)
+}
+
+func contractPosts(t *testing.T, b *contractBackend) {
+	userID := b.addUser(t, "alice", "user")
+	thread := &models.Thread{Title: "Тред", AuthorID: userID}
+	require.NoError(t, b.repos.Threads.Create(thread))
+
+	first := &models.Post{ThreadID: thread.ID, AuthorID: userID, Title: "Заголовок", Content: "Первый"}
+	require.NoError(t, b.repos.Posts.SavePost(first))
+	second := &models.Post{ThreadID: thread.ID, AuthorID: userID, Content: "Второй"}
+	require.NoError(t, b.repos.Posts.SavePost(second))
+
+	post, err := b.repos.Posts.GetPostByID(first.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Заголовок", post.Title)
+	assert.Equal(t, "Первый", post.Content)
+	assert.Equal(t, "alice", post.AuthorName)
+	assert.Equal(t, 1, post.Version)
+
+	posts, err := b.repos.Posts.GetByThreadID(thread.ID)
+	require.NoError(t, err)
+	require.Len(t, posts, 2)
+	assert.Equal(t, first.ID, posts[0].ID, "посты треда идут в порядке создания")
+
+	userPosts, err := b.repos.Users.GetUserPosts(userID)
+	require.NoError(t, err)
+	assert.Len(t, userPosts, 2)
+
+	comment := &models.Comment{PostID: first.ID, AuthorID: userID, Content: "Комментарий"}
+	require.NoError(t, b.repos.Comments.SaveComment(comment))
+
+	require.NoError(t, b.repos.Posts.DeletePost(first.ID))
+	_, err = b.repos.Posts.GetPostByID(first.ID)
+	assert.ErrorIs(t, err, ErrPostNotFound)
+	_, err = b.repos.Comments.GetCommentByID(comment.ID)
+	assert.ErrorIs(t, err, ErrCommentNotFound)
+	assert.ErrorIs(t, b.repos.Posts.DeletePost(first.ID), ErrPostNotFound)
+
+	_, _, err = b.repos.Posts.GetPostWithComments(first.ID)
+	assert.ErrorIs(t, err, ErrPostNotFound)
+}
+
+func contractPins(t *testing.T, b *contractBackend) {
+	userID := b.addUser(t, "alice", "user")
+	var threads []*models.Thread
+	for _, title := range []string{"Правила", "Объявление", "Истекшее"} {
+		thread := &models.Thread{Title: title, AuthorID: userID}
+		require.NoError(t, b.repos.Threads.Create(thread))
+		threads = append(threads, thread)
+	}
+	rules, announcement, expired := threads[0], threads[1], threads[2]
+
+	until := time.Now().Add(time.Hour)
+	require.NoError(t, b.repos.Threads.Pin(rules.ID, 2, nil))
+	require.NoError(t, b.repos.Threads.Pin(announcement.ID, 1, &until))
+	past := time.Now().Add(-time.Hour)
+	require.NoError(t, b.repos.Threads.Pin(expired.ID, 0, &past))
+	assert.ErrorIs(t, b.repos.Threads.Pin(expired.ID+100, 0, nil), ErrThreadNotFound)
+
+	list, err := b.repos.Threads.GetAllThreads()
+	require.NoError(t, err)
+	require.Len(t, list, 3)
+	assert.Equal(t, []int{announcement.ID, rules.ID, expired.ID}, []int{list[0].ID, list[1].ID, list[2].ID},
+		"закрепленные треды идут первыми по pin_order, истекшие - как обычные")
+	assert.True(t, list[0].Pinned)
+	require.NotNil(t, list[0].PinnedUntil)
+	assert.WithinDuration(t, until, *list[0].PinnedUntil, time.Millisecond)
+	assert.False(t, list[2].Pinned)
+
+	thread, err := b.repos.Threads.GetByID(rules.ID)
+	require.NoError(t, err)
+	assert.True(t, thread.Pinned)
+	assert.Equal(t, 2, thread.PinOrder)
+	assert.NotNil(t, thread.PinnedAt)
+	assert.Nil(t, thread.PinnedUntil)
+	assert.Equal(t, 2, thread.Version, "закрепление меняет версию треда")
+
+	require.NoError(t, b.repos.Threads.Unpin(announcement.ID))
+	list, err = b.repos.Threads.GetAllThreads()
+	require.NoError(t, err)
+	assert.Equal(t, []int{rules.ID, expired.ID, announcement.ID}, []int{list[0].ID, list[1].ID, list[2].ID})
+	assert.False(t, list[2].Pinned)
+	assert.Nil(t, list[2].PinnedAt)
+	assert.ErrorIs(t, b.repos.Threads.Unpin(expired.ID+100), ErrThreadNotFound)
+
+	var posts []*models.Post
+	for _, content := range []string{"Первый", "Второй", "Третий"} {
+		post := &models.Post{ThreadID: rules.ID, AuthorID: userID, Content: content}
+		require.NoError(t, b.repos.Posts.SavePost(post))
+		posts = append(posts, post)
+	}
+	require.NoError(t, b.repos.Posts.Pin(posts[2].ID))
+	require.NoError(t, b.repos.Posts.Pin(posts[1].ID))
+	require.NoError(t, b.repos.Posts.Pin(posts[2].ID))
+	assert.ErrorIs(t, b.repos.Posts.Pin(posts[2].ID+100), ErrPostNotFound)
+
+	threadPosts, err := b.repos.Posts.GetByThreadID(rules.ID)
+	require.NoError(t, err)
+	require.Len(t, threadPosts, 3)
+	assert.Equal(t, []int{posts[2].ID, posts[1].ID, posts[0].ID}, []int{threadPosts[0].ID, threadPosts[1].ID, threadPosts[2].ID},
+		"закрепленные посты идут первыми в порядке закрепления")
+	assert.True(t, threadPosts[0].Pinned)
+	assert.False(t, threadPosts[2].Pinned)
+
+	post, err := b.repos.Posts.GetPostByID(posts[1].ID)
+	require.NoError(t, err)
+	assert.True(t, post.Pinned)
+	assert.NotNil(t, post.PinnedAt)
+
+	require.NoError(t, b.repos.Posts.Unpin(posts[2].ID))
+	threadPosts, err = b.repos.Posts.GetByThreadID(rules.ID)
+	require.NoError(t, err)
+	assert.Equal(t, []int{posts[1].ID, posts[0].ID, posts[2].ID}, []int{threadPosts[0].ID, threadPosts[1].ID, threadPosts[2].ID})
+}
+
+func contractAcceptedAnswers(t *testing.T, b *contractBackend) {
+	userID := b.addUser(t, "alice", "user")
+	discussion := &models.Thread{Title: "Обсуждение", AuthorID: userID}
+	require.NoError(t, b.repos.Threads.Create(discussion))
+	assert.Equal(t, models.ThreadTypeDiscussion, discussion.Type, "без типа тред создается обсуждением")
+
+	question := &models.Thread{Title: "Вопрос", AuthorID: userID, Type: models.ThreadTypeQuestion}
+	require.NoError(t, b.repos.Threads.Create(question))
+	assert.Equal(t, models.ThreadTypeQuestion, question.Type)
+
+	answer := &models.Post{ThreadID: question.ID, AuthorID: userID, Content: "Ответ"}
+	require.NoError(t, b.repos.Posts.SavePost(answer))
+
+	require.NoError(t, b.repos.Threads.SetAcceptedPost(question.ID, &answer.ID))
+	missing := answer.ID + 100
+	assert.ErrorIs(t, b.repos.Threads.SetAcceptedPost(question.ID, &missing), ErrPostNotFound)
+	assert.ErrorIs(t, b.repos.Threads.SetAcceptedPost(question.ID+100, nil), ErrThreadNotFound)
+
+	thread, err := b.repos.Threads.GetByID(question.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.ThreadTypeQuestion, thread.Type)
+	require.NotNil(t, thread.AcceptedPostID)
+	assert.Equal(t, answer.ID, *thread.AcceptedPostID)
+	assert.True(t, thread.Solved)
+	assert.Equal(t, 2, thread.Version, "принятие ответа меняет версию треда")
+
+	list, err := b.repos.Threads.GetAllThreads()
+	require.NoError(t, err)
+	solved := make(map[int]bool)
+	for _, listed := range list {
+		solved[listed.ID] = listed.Solved
+	}
+	assert.Equal(t, map[int]bool{discussion.ID: false, question.ID: true}, solved)
+
+	require.NoError(t, b.repos.Posts.DeletePost(answer.ID))
+	thread, err = b.repos.Threads.GetByID(question.ID)
+	require.NoError(t, err)
+	assert.Nil(t, thread.AcceptedPostID, "удаление поста снимает принятый ответ")
+	assert.False(t, thread.Solved)
+
+	require.NoError(t, b.repos.Threads.SetAcceptedPost(question.ID, nil))
+}
+
+func contractPostReplies(t *testing.T, b *contractBackend) {
+	aliceID := b.addUser(t, "alice", "user")
+	bobID := b.addUser(t, "bob", "user")
+	thread := &models.Thread{Title: "Тред", AuthorID: aliceID}
+	require.NoError(t, b.repos.Threads.Create(thread))
+	other := &models.Thread{Title: "Другой тред", AuthorID: aliceID}
+	require.NoError(t, b.repos.Threads.Create(other))
+
+	source := &models.Post{ThreadID: thread.ID, AuthorID: aliceID, Content: "Исходный пост"}
+	require.NoError(t, b.repos.Posts.SavePost(source))
+	assert.Nil(t, source.ReplyToPostID)
+	reply := &models.Post{ThreadID: thread.ID, AuthorID: bobID, Content: "Ответ", ReplyToPostID: &source.ID}
+	require.NoError(t, b.repos.Posts.SavePost(reply))
+	require.NotNil(t, reply.ReplyToPostID)
+	assert.Equal(t, source.ID, *reply.ReplyToPostID)
+	second := &models.Post{ThreadID: thread.ID, AuthorID: aliceID, Content: "Еще ответ", ReplyToPostID: &source.ID}
+	require.NoError(t, b.repos.Posts.SavePost(second))
+	foreign := &models.Post{ThreadID: other.ID, AuthorID: aliceID, Content: "Ответ из другого треда", ReplyToPostID: &source.ID}
+	require.NoError(t, b.repos.Posts.SavePost(foreign))
+	assert.Nil(t, foreign.ReplyToPostID, "ответ на пост другого треда не сохраняется")
+
+	stored, err := b.repos.Posts.GetPostByID(reply.ID)
+	require.NoError(t, err)
+	require.NotNil(t, stored.ReplyToPostID)
+	assert.Equal(t, source.ID, *stored.ReplyToPostID)
+
+	staff := &models.Category{Name: "Модераторская", ReadRole: models.RoleModerator, CreateRole: models.RoleModerator}
+	require.NoError(t, b.repos.Categories.Create(staff))
+	closed := &models.Thread{Title: "Закрытый тред", AuthorID: aliceID, CategoryID: &staff.ID}
+	require.NoError(t, b.repos.Threads.Create(closed))
+	hidden := &models.Post{ThreadID: closed.ID, AuthorID: aliceID, Content: "Для модераторов"}
+	require.NoError(t, b.repos.Posts.SavePost(hidden))
+
+	refs, err := b.repos.Posts.GetPostRefs([]int{source.ID, hidden.ID, source.ID + 100})
+	require.NoError(t, err)
+	assert.Equal(t, map[int]models.PostRef{
+		source.ID: {ID: source.ID, ThreadID: thread.ID, AuthorID: aliceID, AuthorName: "alice", ReadRole: models.RoleGuest},
+		hidden.ID: {ID: hidden.ID, ThreadID: closed.ID, AuthorID: aliceID, AuthorName: "alice", ReadRole: models.RoleModerator},
+	}, refs)
+
+	replies, err := b.repos.Posts.GetReplies([]int{source.ID, reply.ID})
+	require.NoError(t, err)
+	assert.Equal(t, map[int][]models.PostRef{source.ID: {
+		{ID: reply.ID, ThreadID: thread.ID, AuthorID: bobID, AuthorName: "bob"},
+		{ID: second.ID, ThreadID: thread.ID, AuthorID: aliceID, AuthorName: "alice"},
+	}}, replies)
+
+	require.NoError(t, b.repos.Posts.DeletePost(source.ID))
+	posts, err := b.repos.Posts.GetByThreadID(thread.ID)
+	require.NoError(t, err)
+	require.Len(t, posts, 2)
+	for _, post := range posts {
+		assert.Nil(t, post.ReplyToPostID, "удаление исходного поста снимает ссылку на него")
+	}
+}
+
+func contractViews(t *testing.T, b *contractBackend) {
+	userID := b.addUser(t, "alice", "user")
+	thread := &models.Thread{Title: "Тред", AuthorID: userID}
+	require.NoError(t, b.repos.Threads.Create(thread))
+	post := &models.Post{ThreadID: thread.ID, AuthorID: userID, Content: "Пост"}
+	require.NoError(t, b.repos.Posts.SavePost(post))
+
+	require.NoError(t, b.repos.Views.AddViews(map[int]int{thread.ID: 3, thread.ID + 100: 5}, map[int]int{post.ID: 2}))
+	require.NoError(t, b.repos.Views.AddViews(map[int]int{thread.ID: 1}, nil))
+	require.NoError(t, b.repos.Views.AddViews(nil, nil))
+
+	stored, err := b.repos.Threads.GetByID(thread.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 4, stored.ViewCount)
+	assert.Equal(t, 1, stored.Version, "просмотры не меняют версию треда")
+
+	list, err := b.repos.Threads.GetAllThreads()
+	require.NoError(t, err)
+	require.Len(t, list, 1)
+	assert.Equal(t, 4, list[0].ViewCount)
+
+	storedPost, err := b.repos.Posts.GetPostByID(post.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 2, storedPost.ViewCount)
+
+	threadPosts, err := b.repos.Posts.GetByThreadID(thread.ID)
+	require.NoError(t, err)
+	require.Len(t, threadPosts, 1)
+	assert.Equal(t, 2, threadPosts[0].ViewCount)
+}
+
+func contractVotes(t *testing.T, b *contractBackend) {
+	authorID := b.addUser(t, "alice", "user")
+	bobID := b.addUser(t, "bob", "user")
+	carolID := b.addUser(t, "carol", "user")
+	thread := &models.Thread{Title: "Тред", AuthorID: authorID}
+	require.NoError(t, b.repos.Threads.Create(thread))
+	post := &models.Post{ThreadID: thread.ID, AuthorID: authorID, Content: "Пост"}
+	require.NoError(t, b.repos.Posts.SavePost(post))
+	comment := &models.Comment{PostID: post.ID, AuthorID: authorID, Content: "Комментарий"}
+	require.NoError(t, b.repos.Comments.SaveComment(comment))
+
+	result, err := b.repos.Votes.VotePost(post.ID, bobID, 1)
+	require.NoError(t, err)
+	assert.Equal(t, models.VoteResult{Upvotes: 1, Score: 1, MyVote: 1}, result)
+	result, err = b.repos.Votes.VotePost(post.ID, bobID, 1)
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.Upvotes, "повторный голос не удваивается")
+	result, err = b.repos.Votes.VotePost(post.ID, carolID, -1)
+	require.NoError(t, err)
+	assert.Equal(t, models.VoteResult{Upvotes: 1, Downvotes: 1, Score: 0, MyVote: -1}, result)
+	result, err = b.repos.Votes.VotePost(post.ID, bobID, -1)
+	require.NoError(t, err)
+	assert.Equal(t, models.VoteResult{Upvotes: 0, Downvotes: 2, Score: -2, MyVote: -1}, result)
+
+	result, err = b.repos.Votes.UnvotePost(post.ID, carolID)
+	require.NoError(t, err)
+	assert.Equal(t, models.VoteResult{Downvotes: 1, Score: -1}, result)
+	result, err = b.repos.Votes.UnvotePost(post.ID, carolID)
+	require.NoError(t, err)
+	assert.Equal(t, -1, result.Score, "отзыв несуществующего голоса ничего не меняет")
+
+	stored, err := b.repos.Posts.GetPostByID(post.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 0, stored.Upvotes)
+	assert.Equal(t, 1, stored.Downvotes)
+	assert.Equal(t, -1, stored.Score)
+	assert.Equal(t, 1, stored.Version, "голоса не меняют версию поста")
+
+	threadPosts, err := b.repos.Posts.GetByThreadID(thread.ID)
+	require.NoError(t, err)
+	require.Len(t, threadPosts, 1)
+	assert.Equal(t, -1, threadPosts[0].Score)
+
+	result, err = b.repos.Votes.VoteComment(comment.ID, bobID, 1)
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.Score)
+	storedComment, err := b.repos.Comments.GetCommentByID(comment.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 1, storedComment.Upvotes)
+	assert.Equal(t, 1, storedComment.Score)
+	comments, err := b.repos.Comments.GetCommentsByPostID(post.ID)
+	require.NoError(t, err)
+	require.Len(t, comments, 1)
+	assert.Equal(t, 1, comments[0].Score)
+	_, withComments, err := b.repos.Posts.GetPostWithComments(post.ID)
+	require.NoError(t, err)
+	require.Len(t, withComments, 1)
+	assert.Equal(t, 1, withComments[0].Score)
+
+	result, err = b.repos.Votes.UnvoteComment(comment.ID, bobID)
+	require.NoError(t, err)
+	assert.Equal(t, 0, result.Score)
+
+	_, err = b.repos.Votes.VotePost(post.ID+100, bobID, 1)
+	assert.ErrorIs(t, err, ErrPostNotFound)
+	_, err = b.repos.Votes.VoteComment(comment.ID+100, bobID, 1)
+	assert.ErrorIs(t, err, ErrCommentNotFound)
+	_, err = b.repos.Votes.VotePost(post.ID, carolID+100, 1)
+	assert.ErrorIs(t, err, ErrUserNotFound)
+
+	require.NoError(t, b.repos.Posts.DeletePost(post.ID))
+	_, err = b.repos.Votes.UnvotePost(post.ID, bobID)
+	assert.ErrorIs(t, err, ErrPostNotFound, "голоса удаляются вместе с постом")
+}
+
+func contractReactions(t *testing.T, b *contractBackend) {
+	aliceID := b.addUser(t, "alice", "user")
+	bobID := b.addUser(t, "bob", "user")
+	thread := &models.Thread{Title: "Тред", AuthorID: aliceID}
+	require.NoError(t, b.repos.Threads.Create(thread))
+	post := &models.Post{ThreadID: thread.ID, AuthorID: aliceID, Content: "Пост"}
+	require.NoError(t, b.repos.Posts.SavePost(post))
+	comment := &models.Comment{PostID: post.ID, AuthorID: aliceID, Content: "Комментарий"}
+	require.NoError(t, b.repos.Comments.SaveComment(comment))
+	message, err := b.repos.Chat.CreateMessage(aliceID, "Привет")
+	require.NoError(t, err)
+
+	added, err := b.repos.Reactions.Toggle(models.ReactionTargetPost, post.ID, bobID, "👍")
+	require.NoError(t, err)
+	assert.True(t, added)
+	_, err = b.repos.Reactions.Toggle(models.ReactionTargetPost, post.ID, aliceID, "🎉")
+	require.NoError(t, err)
+	_, err = b.repos.Reactions.Toggle(models.ReactionTargetPost, post.ID, aliceID, "👍")
+	require.NoError(t, err)
+	_, err = b.repos.Reactions.Toggle(models.ReactionTargetComment, comment.ID, bobID, "👍")
+	require.NoError(t, err)
+
+	counts, err := b.repos.Reactions.GetCounts(models.ReactionTargetPost, []int{post.ID}, bobID)
+	require.NoError(t, err)
+	assert.Equal(t, []models.ReactionCount{
+		{Emoji: "👍", Count: 2, ReactedByMe: true},
+		{Emoji: "🎉", Count: 1},
+	}, counts[post.ID], "реакции идут в порядке первой реакции эмодзи")
+
+	added, err = b.repos.Reactions.Toggle(models.ReactionTargetPost, post.ID, bobID, "👍")
+	require.NoError(t, err)
+	assert.False(t, added, "повторная реакция снимает ее")
+	counts, err = b.repos.Reactions.GetCounts(models.ReactionTargetPost, []int{post.ID, post.ID + 100}, bobID)
+	require.NoError(t, err)
+	assert.Equal(t, []models.ReactionCount{{Emoji: "🎉", Count: 1}, {Emoji: "👍", Count: 1}}, counts[post.ID],
+		"порядок считается по оставшимся реакциям")
+	assert.NotContains(t, counts, post.ID+100)
+
+	commentCounts, err := b.repos.Reactions.GetCounts(models.ReactionTargetComment, []int{comment.ID}, 0)
+	require.NoError(t, err)
+	assert.Equal(t, []models.ReactionCount{{Emoji: "👍", Count: 1}}, commentCounts[comment.ID],
+		"реакции на комментарий не смешиваются с реакциями на пост с тем же ID")
+
+	_, err = b.repos.Reactions.Toggle(models.ReactionTargetChat, message.ID, bobID, "👀")
+	require.NoError(t, err)
+	chatCounts, err := b.repos.Reactions.GetCounts(models.ReactionTargetChat, []int{message.ID}, bobID)
+	require.NoError(t, err)
+	assert.Equal(t, []models.ReactionCount{{Emoji: "👀", Count: 1, ReactedByMe: true}}, chatCounts[message.ID])
+
+	_, err = b.repos.Reactions.Toggle(models.ReactionTargetPost, post.ID+100, bobID, "👍")
+	assert.ErrorIs(t, err, ErrPostNotFound)
+	_, err = b.repos.Reactions.Toggle(models.ReactionTargetComment, comment.ID+100, bobID, "👍")
+	assert.ErrorIs(t, err, ErrCommentNotFound)
+	_, err = b.repos.Reactions.Toggle(models.ReactionTargetChat, message.ID+100, bobID, "👍")
+	assert.ErrorIs(t, err, ErrMessageNotFound)
+	_, err = b.repos.Reactions.Toggle(models.ReactionTargetPost, post.ID, bobID+100, "👍")
+	assert.ErrorIs(t, err, ErrUserNotFound)
+
+	require.NoError(t, b.repos.Posts.DeletePost(post.ID))
+	counts, err = b.repos.Reactions.GetCounts(models.ReactionTargetPost, []int{post.ID}, bobID)
+	require.NoError(t, err)
+	assert.Empty(t, counts, "реакции удаляются вместе с постом")
+	commentCounts, err = b.repos.Reactions.GetCounts(models.ReactionTargetComment, []int{comment.ID}, bobID)
+	require.NoError(t, err)
+	assert.Empty(t, commentCounts, "и вместе с комментариями поста")
+}
+
+func contractBookmarks(t *testing.T, b *contractBackend) {
+	aliceID := b.addUser(t, "alice", "user")
+	bobID := b.addUser(t, "bob", "user")
+	thread := &models.Thread{Title: "Тред", AuthorID: aliceID}
+	require.NoError(t, b.repos.Threads.Create(thread))
+	other := &models.Thread{Title: "Другой тред", AuthorID: aliceID}
+	require.NoError(t, b.repos.Threads.Create(other))
+	untitled := &models.Post{ThreadID: thread.ID, AuthorID: aliceID, Content: "Пост"}
+	require.NoError(t, b.repos.Posts.SavePost(untitled))
+	titled := &models.Post{ThreadID: thread.ID, AuthorID: aliceID, Title: "Заголовок", Content: "Пост"}
+	require.NoError(t, b.repos.Posts.SavePost(titled))
+
+	for _, bookmark := range []*models.Bookmark{
+		{UserID: bobID, TargetType: models.BookmarkTargetThread, TargetID: thread.ID},
+		{UserID: bobID, TargetType: models.BookmarkTargetPost, TargetID: untitled.ID, Note: "прочитать"},
+		{UserID: bobID, TargetType: models.BookmarkTargetPost, TargetID: titled.ID},
+		{UserID: bobID, TargetType: models.BookmarkTargetThread, TargetID: other.ID},
+		{UserID: aliceID, TargetType: models.BookmarkTargetThread, TargetID: thread.ID},
+	} {
+		require.NoError(t, b.repos.Bookmarks.Save(bookmark))
+		assert.False(t, bookmark.CreatedAt.IsZero())
+	}
+	first, _, err := b.repos.Bookmarks.GetByUser(bobID, models.RoleUser, 1, 3)
+	require.NoError(t, err)
+	require.Len(t, first, 1)
+
+	updated := &models.Bookmark{UserID: bobID, TargetType: models.BookmarkTargetThread, TargetID: thread.ID, Note: "заметка"}
+	require.NoError(t, b.repos.Bookmarks.Save(updated))
+	assert.Equal(t, first[0].CreatedAt.UTC(), updated.CreatedAt.UTC(), "повторное сохранение меняет только заметку")
+
+	err = b.repos.Bookmarks.Save(&models.Bookmark{UserID: bobID, TargetType: models.BookmarkTargetPost, TargetID: titled.ID + 100})
+	assert.ErrorIs(t, err, ErrPostNotFound)
+	err = b.repos.Bookmarks.Save(&models.Bookmark{UserID: bobID, TargetType: models.BookmarkTargetThread, TargetID: other.ID + 100})
+	assert.ErrorIs(t, err, ErrThreadNotFound)
+	err = b.repos.Bookmarks.Save(&models.Bookmark{UserID: bobID + 100, TargetType: models.BookmarkTargetThread, TargetID: thread.ID})
+	assert.ErrorIs(t, err, ErrUserNotFound)
+
+	page, total, err := b.repos.Bookmarks.GetByUser(bobID, models.RoleUser, 2, 0)
+	require.NoError(t, err)
+	assert.Equal(t, 4, total)
+	require.Len(t, page, 2)
+	assert.Equal(t, models.BookmarkTargetThread, page[0].TargetType, "новые закладки первыми")
+	assert.Equal(t, other.ID, page[0].TargetID)
+	assert.Equal(t, "Другой тред", page[0].Title)
+	assert.Equal(t, titled.ID, page[1].TargetID)
+	assert.Equal(t, "Заголовок", page[1].Title)
+	assert.Equal(t, thread.ID, page[1].ThreadID)
+
+	page, _, err = b.repos.Bookmarks.GetByUser(bobID, models.RoleUser, 2, 2)
+	require.NoError(t, err)
+	require.Len(t, page, 2)
+	assert.Equal(t, untitled.ID, page[0].TargetID)
+	assert.Equal(t, "Тред", page[0].Title, "пост без заголовка показывается с заголовком треда")
+	assert.Equal(t, "прочитать", page[0].Note)
+	assert.Equal(t, "заметка", page[1].Note)
+
+	bookmarked, err := b.repos.Bookmarks.GetBookmarked(bobID, models.BookmarkTargetPost, []int{untitled.ID, titled.ID + 100})
+	require.NoError(t, err)
+	assert.Equal(t, map[int]bool{untitled.ID: true}, bookmarked)
+
+	require.NoError(t, b.repos.Bookmarks.Delete(bobID, models.BookmarkTargetPost, titled.ID))
+	assert.ErrorIs(t, b.repos.Bookmarks.Delete(bobID, models.BookmarkTargetPost, titled.ID), ErrBookmarkNotFound)
+
+	require.NoError(t, b.repos.Posts.DeletePost(untitled.ID))
+	require.NoError(t, b.repos.Threads.Delete(other.ID))
+	page, total, err = b.repos.Bookmarks.GetByUser(bobID, models.RoleUser, 10, 0)
+	require.NoError(t, err)
+	assert.Equal(t, 1, total, "закладки удаляются вместе с записью")
+	require.Len(t, page, 1)
+	assert.Equal(t, thread.ID, page[0].TargetID)
+
+	require.NoError(t, b.repos.Threads.Delete(thread.ID))
+	page, _, err = b.repos.Bookmarks.GetByUser(aliceID, models.RoleUser, 10, 0)
+	require.NoError(t, err)
+	assert.Empty(t, page)
+}
+
+func contractBookmarksClosedCategory(t *testing.T, b *contractBackend) {
+	bobID := b.addUser(t, "bob", "user")
+	staff := &models.Category{Name: "Модераторская", ReadRole: models.RoleModerator, CreateRole: models.RoleModerator}
+	require.NoError(t, b.repos.Categories.Create(staff))
+	thread := &models.Thread{Title: "Закрытый тред", AuthorID: bobID, CategoryID: &staff.ID}
+	require.NoError(t, b.repos.Threads.Create(thread))
+	post := &models.Post{ThreadID: thread.ID, AuthorID: bobID, Title: "Закрытый пост", Content: "Пост"}
+	require.NoError(t, b.repos.Posts.SavePost(post))
+	require.NoError(t, b.repos.Bookmarks.Save(&models.Bookmark{UserID: bobID, TargetType: models.BookmarkTargetThread, TargetID: thread.ID}))
+	require.NoError(t, b.repos.Bookmarks.Save(&models.Bookmark{UserID: bobID, TargetType: models.BookmarkTargetPost, TargetID: post.ID}))
+
+	page, total, err := b.repos.Bookmarks.GetByUser(bobID, models.RoleUser, 10, 0)
+	require.NoError(t, err)
+	assert.Equal(t, 2, total, "закладки на закрытые записи остаются в списке, чтобы их можно было удалить")
+	require.Len(t, page, 2)
+	for _, bookmark := range page {
+		assert.Empty(t, bookmark.Title, "заголовок из закрытого раздела скрыт")
+		assert.Zero(t, bookmark.ThreadID)
+	}
+
+	page, _, err = b.repos.Bookmarks.GetByUser(bobID, models.RoleModerator, 10, 0)
+	require.NoError(t, err)
+	require.Len(t, page, 2)
+	assert.ElementsMatch(t, []string{"Закрытый тред", "Закрытый пост"}, []string{page[0].Title, page[1].Title})
+	assert.Equal(t, thread.ID, page[0].ThreadID)
+
+	// Открытый подраздел закрытого раздела закрыт так же, как родитель
+	open := &models.Category{ParentID: &staff.ID, Name: "Открытый подраздел", ReadRole: models.RoleGuest, CreateRole: models.RoleUser}
+	require.NoError(t, b.repos.Categories.Create(open))
+	nested := &models.Thread{Title: "Вложенный тред", AuthorID: bobID, CategoryID: &open.ID}
+	require.NoError(t, b.repos.Threads.Create(nested))
+	nestedPost := &models.Post{ThreadID: nested.ID, AuthorID: bobID, Content: "Пост"}
+	require.NoError(t, b.repos.Posts.SavePost(nestedPost))
+	require.NoError(t, b.repos.Bookmarks.Save(&models.Bookmark{UserID: bobID, TargetType: models.BookmarkTargetThread, TargetID: nested.ID}))
+
+	page, _, err = b.repos.Bookmarks.GetByUser(bobID, models.RoleUser, 1, 0)
+	require.NoError(t, err)
+	require.Len(t, page, 1)
+	assert.Equal(t, nested.ID, page[0].TargetID)
+	assert.Empty(t, page[0].Title)
+	refs, err := b.repos.Posts.GetPostRefs([]int{nestedPost.ID})
+	require.NoError(t, err)
+	assert.Equal(t, models.RoleModerator, refs[nestedPost.ID].ReadRole)
+}
+
+func contractSubscriptions(t *testing.T, b *contractBackend) {
+	aliceID := b.addUser(t, "alice", "user")
+	bobID := b.addUser(t, "bob", "user")
+	thread := &models.Thread{Title: "Тред", AuthorID: aliceID}
+	require.NoError(t, b.repos.Threads.Create(thread))
+	post := &models.Post{ThreadID: thread.ID, AuthorID: aliceID, Content: "Пост"}
+	require.NoError(t, b.repos.Posts.SavePost(post))
+
+	require.NoError(t, b.repos.Subscriptions.Subscribe(bobID, models.SubscriptionTargetThread, thread.ID))
+	require.NoError(t, b.repos.Subscriptions.Subscribe(aliceID, models.SubscriptionTargetThread, thread.ID))
+	require.NoError(t, b.repos.Subscriptions.Subscribe(bobID, models.SubscriptionTargetThread, thread.ID), "повторная подписка не ошибка")
+	require.NoError(t, b.repos.Subscriptions.Subscribe(aliceID, models.SubscriptionTargetPost, post.ID))
+
+	assert.ErrorIs(t, b.repos.Subscriptions.Subscribe(bobID, models.SubscriptionTargetPost, post.ID+100), ErrPostNotFound)
+	assert.ErrorIs(t, b.repos.Subscriptions.Subscribe(bobID, models.SubscriptionTargetThread, thread.ID+100), ErrThreadNotFound)
+	assert.ErrorIs(t, b.repos.Subscriptions.Subscribe(bobID+100, models.SubscriptionTargetThread, thread.ID), ErrUserNotFound)
+
+	subscribers, err := b.repos.Subscriptions.GetSubscribers(models.SubscriptionTargetThread, thread.ID)
+	require.NoError(t, err)
+	expected := []int{aliceID, bobID}
+	if bobID < aliceID {
+		expected = []int{bobID, aliceID}
+	}
+	assert.Equal(t, expected, subscribers)
+
+	subscribed, err := b.repos.Subscriptions.IsSubscribed(bobID, models.SubscriptionTargetPost, post.ID)
+	require.NoError(t, err)
+	assert.False(t, subscribed)
+	subscribed, err = b.repos.Subscriptions.IsSubscribed(aliceID, models.SubscriptionTargetPost, post.ID)
+	require.NoError(t, err)
+	assert.True(t, subscribed)
+
+	require.NoError(t, b.repos.Subscriptions.Unsubscribe(bobID, models.SubscriptionTargetThread, thread.ID))
+	assert.ErrorIs(t, b.repos.Subscriptions.Unsubscribe(bobID, models.SubscriptionTargetThread, thread.ID), ErrSubscriptionNotFound)
+
+	require.NoError(t, b.repos.Posts.DeletePost(post.ID))
+	subscribers, err = b.repos.Subscriptions.GetSubscribers(models.SubscriptionTargetPost, post.ID)
+	require.NoError(t, err)
+	assert.Empty(t, subscribers, "подписки удаляются вместе с записью")
+
+	require.NoError(t, b.repos.Threads.Delete(thread.ID))
+	subscribers, err = b.repos.Subscriptions.GetSubscribers(models.SubscriptionTargetThread, thread.ID)
+	require.NoError(t, err)
+	assert.Empty(t, subscribers)
+}
+
+func contractNotifications(t *testing.T, b *contractBackend) {
+	aliceID := b.addUser(t, "alice", "user")
+	bobID := b.addUser(t, "bob", "user")
+	thread := &models.Thread{Title: "Тред", AuthorID: aliceID}
+	require.NoError(t, b.repos.Threads.Create(thread))
+	post := &models.Post{ThreadID: thread.ID, AuthorID: aliceID, Content: "Пост"}
+	require.NoError(t, b.repos.Posts.SavePost(post))
+	titled := &models.Post{ThreadID: thread.ID, AuthorID: bobID, Title: "Заголовок", Content: "Ответ"}
+	require.NoError(t, b.repos.Posts.SavePost(titled))
+	comment := &models.Comment{PostID: post.ID, AuthorID: bobID, Content: "Комментарий"}
+	require.NoError(t, b.repos.Comments.SaveComment(comment))
+
+	notifications := []*models.Notification{
+		{UserID: aliceID, Type: models.NotificationNewPost, ActorID: bobID, ThreadID: thread.ID, PostID: titled.ID},
+		{UserID: aliceID, Type: models.NotificationNewComment, ActorID: bobID, ThreadID: thread.ID, PostID: post.ID, CommentID: &comment.ID},
+	}
+	require.NoError(t, b.repos.Notifications.SaveAll(notifications))
+	assert.NotZero(t, notifications[0].ID)
+	assert.False(t, notifications[1].CreatedAt.IsZero())
+
+	missing := post.ID + 100
+	err := b.repos.Notifications.SaveAll([]*models.Notification{
+		{UserID: bobID, Type: models.NotificationNewPost, ActorID: aliceID, ThreadID: thread.ID, PostID: post.ID},
+		{UserID: bobID, Type: models.NotificationNewPost, ActorID: aliceID, ThreadID: thread.ID, PostID: missing},
+	})
+	assert.Error(t, err)
+	unread, err := b.repos.Notifications.CountUnread(bobID)
+	require.NoError(t, err)
+	assert.Equal(t, 0, unread, "уведомления сохраняются все или ни одного")
+
+	page, total, err := b.repos.Notifications.GetByUser(aliceID, false, 10, 0)
+	require.NoError(t, err)
+	assert.Equal(t, 2, total)
+	require.Len(t, page, 2)
+	assert.Equal(t, notifications[1].ID, page[0].ID, "новые уведомления первыми")
+	assert.Equal(t, "bob", page[0].ActorName)
+	assert.Equal(t, "Тред", page[0].Title, "пост без заголовка показывается с заголовком треда")
+	require.NotNil(t, page[0].CommentID)
+	assert.Equal(t, comment.ID, *page[0].CommentID)
+	assert.Equal(t, "Заголовок", page[1].Title)
+	assert.Nil(t, page[1].CommentID)
+
+	require.NoError(t, b.repos.Notifications.MarkRead(aliceID, notifications[0].ID))
+	assert.ErrorIs(t, b.repos.Notifications.MarkRead(bobID, notifications[1].ID), ErrNotificationNotFound)
+	unread, err = b.repos.Notifications.CountUnread(aliceID)
+	require.NoError(t, err)
+	assert.Equal(t, 1, unread)
+	page, total, err = b.repos.Notifications.GetByUser(aliceID, true, 10, 0)
+	require.NoError(t, err)
+	assert.Equal(t, 1, total)
+	require.Len(t, page, 1)
+	assert.Equal(t, notifications[1].ID, page[0].ID)
+
+	updated, err := b.repos.Notifications.MarkAllRead(aliceID)
+	require.NoError(t, err)
+	assert.Equal(t, 1, updated)
+	unread, err = b.repos.Notifications.CountUnread(aliceID)
+	require.NoError(t, err)
+	assert.Equal(t, 0, unread)
+
+	require.NoError(t, b.repos.Comments.DeleteComment(comment.ID))
+	page, _, err = b.repos.Notifications.GetByUser(aliceID, false, 10, 0)
+	require.NoError(t, err)
+	require.Len(t, page, 1, "уведомления удаляются вместе с записью")
+	assert.Equal(t, notifications[0].ID, page[0].ID)
+
+	require.NoError(t, b.repos.Threads.Delete(thread.ID))
+	page, _, err = b.repos.Notifications.GetByUser(aliceID, false, 10, 0)
+	require.NoError(t, err)
+	assert.Empty(t, page)
+}
+
+func contractMentions(t *testing.T, b *contractBackend) {
+	aliceID := b.addUser(t, "alice", "user")
+	bobID := b.addUser(t, "bob", "user")
+	carolID := b.addUser(t, "carol", "user")
+	thread := &models.Thread{Title: "Тред", AuthorID: aliceID}
+	require.NoError(t, b.repos.Threads.Create(thread))
+	post := &models.Post{ThreadID: thread.ID, AuthorID: aliceID, Content: "@bob @carol"}
+	require.NoError(t, b.repos.Posts.SavePost(post))
+	comment := &models.Comment{PostID: post.ID, AuthorID: bobID, Content: "@alice"}
+	require.NoError(t, b.repos.Comments.SaveComment(comment))
+	message, err := b.repos.Chat.CreateMessage(aliceID, "@bob")
+	require.NoError(t, err)
+
+	resolved, err := b.repos.Mentions.ResolveUsernames([]string{"carol", "bob", "nobody"})
+	require.NoError(t, err)
+	assert.Equal(t, []models.Mention{{UserID: bobID, Username: "bob"}, {UserID: carolID, Username: "carol"}}, resolved)
+
+	added, err := b.repos.Mentions.Replace(models.MentionTargetPost, post.ID, []int{carolID, bobID})
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []int{bobID, carolID}, added)
+	added, err = b.repos.Mentions.Replace(models.MentionTargetPost, post.ID, []int{carolID, aliceID})
+	require.NoError(t, err)
+	assert.Equal(t, []int{aliceID}, added, "при правке добавленными считаются только новые упоминания")
+	_, err = b.repos.Mentions.Replace(models.MentionTargetComment, comment.ID, []int{aliceID})
+	require.NoError(t, err)
+	_, err = b.repos.Mentions.Replace(models.MentionTargetChat, message.ID, []int{bobID})
+	require.NoError(t, err)
+
+	_, err = b.repos.Mentions.Replace(models.MentionTargetPost, post.ID+100, []int{bobID})
+	assert.ErrorIs(t, err, ErrPostNotFound)
+	_, err = b.repos.Mentions.Replace(models.MentionTargetPost, post.ID, []int{carolID + 100})
+	assert.ErrorIs(t, err, ErrUserNotFound)
+
+	mentions, err := b.repos.Mentions.GetByTargets(models.MentionTargetPost, []int{post.ID, post.ID + 100})
+	require.NoError(t, err)
+	assert.Equal(t, map[int][]models.Mention{
+		post.ID: {{UserID: aliceID, Username: "alice"}, {UserID: carolID, Username: "carol"}},
+	}, mentions, "неудачная замена не меняет упоминаний")
+
+	chatMention := []*models.Notification{{UserID: bobID, Type: models.NotificationMention, ActorID: aliceID, ChatMessageID: &message.ID}}
+	require.NoError(t, b.repos.Notifications.SaveAll(chatMention))
+	page, _, err := b.repos.Notifications.GetByUser(bobID, false, 10, 0)
+	require.NoError(t, err)
+	require.Len(t, page, 1)
+	assert.Zero(t, page[0].ThreadID)
+	require.NotNil(t, page[0].ChatMessageID)
+	assert.Equal(t, message.ID, *page[0].ChatMessageID)
+
+	require.NoError(t, b.repos.Posts.DeletePost(post.ID))
+	mentions, err = b.repos.Mentions.GetByTargets(models.MentionTargetComment, []int{comment.ID})
+	require.NoError(t, err)
+	assert.Empty(t, mentions, "упоминания удаляются вместе с записью")
+	mentions, err = b.repos.Mentions.GetByTargets(models.MentionTargetChat, []int{message.ID})
+	require.NoError(t, err)
+	assert.Len(t, mentions[message.ID], 1)
+}
+
+func contractAttachments(t *testing.T, b *contractBackend) {
+	aliceID := b.addUser(t, "alice", "user")
+	bobID := b.addUser(t, "bob", "user")
+	thread := &models.Thread{Title: "Тред", AuthorID: aliceID}
+	require.NoError(t, b.repos.Threads.Create(thread))
+	post := &models.Post{ThreadID: thread.ID, AuthorID: aliceID, Content: "Скриншот"}
+	require.NoError(t, b.repos.Posts.SavePost(post))
+	comment := &models.Comment{PostID: post.ID, AuthorID: aliceID, Content: "Лог"}
+	require.NoError(t, b.repos.Comments.SaveComment(comment))
+
+	upload := func(uploaderID int, filename string) *models.Attachment {
+		key, err := storage.NewKey()
+		require.NoError(t, err)
+		attachment := &models.Attachment{UploaderID: uploaderID, Filename: filename, ContentType: "image/png", Size: 10, StorageKey: key}
+		require.NoError(t, b.repos.Attachments.Create(attachment))
+		return attachment
+	}
+	screen := upload(aliceID, "screen.png")
+	second := upload(aliceID, "second.png")
+	logFile := upload(aliceID, "app.log")
+	foreign := upload(bobID, "bob.png")
+
+	err := b.repos.Attachments.Create(&models.Attachment{UploaderID: bobID + 100, Filename: "x", StorageKey: "0123456789abcdef0123456789abcdef"})
+	assert.ErrorIs(t, err, ErrUserNotFound)
+
+	require.NoError(t, b.repos.Attachments.Link(models.AttachmentTargetPost, post.ID, aliceID, []int{screen.ID, second.ID}))
+	require.NoError(t, b.repos.Attachments.Link(models.AttachmentTargetComment, comment.ID, aliceID, []int{logFile.ID}))
+	err = b.repos.Attachments.Link(models.AttachmentTargetPost, post.ID, aliceID, []int{screen.ID, foreign.ID})
+	assert.ErrorIs(t, err, ErrAttachmentNotFound, "чужое вложение не привязывается")
+	err = b.repos.Attachments.Link(models.AttachmentTargetComment, comment.ID, aliceID, []int{logFile.ID, screen.ID})
+	assert.ErrorIs(t, err, ErrAttachmentNotFound, "вложение другой записи не привязывается")
+	err = b.repos.Attachments.Link(models.AttachmentTargetPost, post.ID+100, aliceID, nil)
+	assert.ErrorIs(t, err, ErrPostNotFound)
+
+	attachments, err := b.repos.Attachments.GetByTargets(models.AttachmentTargetPost, []int{post.ID})
+	require.NoError(t, err)
+	require.Len(t, attachments[post.ID], 2, "неудачная привязка ничего не меняет")
+	assert.Equal(t, screen.ID, attachments[post.ID][0].ID)
+	assert.Equal(t, "screen.png", attachments[post.ID][0].Filename)
+
+	// Правка поста без второго вложения отвязывает его
+	require.NoError(t, b.repos.Attachments.Link(models.AttachmentTargetPost, post.ID, aliceID, []int{screen.ID}))
+	stored, err := b.repos.Attachments.GetByID(second.ID)
+	require.NoError(t, err)
+	assert.Nil(t, stored.PostID)
+
+	require.NoError(t, b.repos.Comments.TombstoneComment(comment.ID))
+	attachments, err = b.repos.Attachments.GetByTargets(models.AttachmentTargetComment, []int{comment.ID})
+	require.NoError(t, err)
+	assert.Empty(t, attachments, "заглушка комментария теряет вложения")
+
+	require.NoError(t, b.repos.Posts.DeletePost(post.ID))
+	stored, err = b.repos.Attachments.GetByID(screen.ID)
+	require.NoError(t, err)
+	assert.Nil(t, stored.PostID, "вложения удаленного поста остаются сиротами")
+
+	orphans, err := b.repos.Attachments.DeleteOrphans(time.Now().Add(time.Hour), 3)
+	require.NoError(t, err)
+	require.Len(t, orphans, 3)
+	assert.ElementsMatch(t, []int{screen.ID, second.ID, logFile.ID}, []int{orphans[0].ID, orphans[1].ID, orphans[2].ID},
+		"сначала удаляются самые старые сироты")
+	orphans, err = b.repos.Attachments.DeleteOrphans(time.Now().Add(time.Hour), 10)
+	require.NoError(t, err)
+	require.Len(t, orphans, 1)
+	assert.Equal(t, foreign.ID, orphans[0].ID)
+	_, err = b.repos.Attachments.GetByID(screen.ID)
+	assert.ErrorIs(t, err, ErrAttachmentNotFound)
+}
+
+func contractPolls(t *testing.T, b *contractBackend) {
+	aliceID := b.addUser(t, "alice", "user")
+	bobID := b.addUser(t, "bob", "user")
+	thread := &models.Thread{Title: "Тред", AuthorID: aliceID}
+	require.NoError(t, b.repos.Threads.Create(thread))
+
+	poll := &models.Poll{
+		ThreadID: thread.ID,
+		Question: "Когда релиз?",
+		Options:  []models.PollOption{{Text: "В пятницу"}, {Text: "В понедельник"}, {Text: "Не спешим"}},
+	}
+	require.NoError(t, b.repos.Polls.Create(poll))
+	friday, monday, later := poll.Options[0].ID, poll.Options[1].ID, poll.Options[2].ID
+	assert.NotZero(t, friday)
+
+	err := b.repos.Polls.Create(&models.Poll{ThreadID: thread.ID, Question: "Еще один", Options: []models.PollOption{{Text: "Да"}, {Text: "Нет"}}})
+	assert.ErrorIs(t, err, ErrAlreadyExists, "у треда один опрос")
+	err = b.repos.Polls.Create(&models.Poll{ThreadID: thread.ID + 100, Question: "Нет треда", Options: []models.PollOption{{Text: "Да"}, {Text: "Нет"}}})
+	assert.ErrorIs(t, err, ErrThreadNotFound)
+
+	stored, err := b.repos.Polls.GetByThreadID(thread.ID)
+	require.NoError(t, err)
+	assert.Equal(t, poll.ID, stored.ID)
+	assert.Equal(t, "Когда релиз?", stored.Question)
+	require.Len(t, stored.Options, 3)
+	assert.Equal(t, "В понедельник", stored.Options[1].Text, "варианты в порядке создания")
+
+	require.NoError(t, b.repos.Polls.Vote(poll.ID, aliceID, []int{friday}))
+	require.NoError(t, b.repos.Polls.Vote(poll.ID, bobID, []int{monday}))
+	require.NoError(t, b.repos.Polls.Vote(poll.ID, aliceID, []int{later, monday}), "повторный голос заменяет прежний")
+	err = b.repos.Polls.Vote(poll.ID, bobID, []int{later, later + 100})
+	assert.ErrorIs(t, err, ErrPollOptionNotFound)
+
+	votes, err := b.repos.Polls.GetVotes(poll.ID)
+	require.NoError(t, err)
+	require.Len(t, votes, 3, "неудачный голос ничего не меняет")
+	assert.Equal(t, models.PollVote{OptionID: monday, PollVoter: models.PollVoter{UserID: bobID, Username: "bob"}}, votes[0])
+	assert.Equal(t, aliceID, votes[1].UserID)
+	assert.Equal(t, "alice", votes[1].Username)
+
+	require.NoError(t, b.repos.Polls.Vote(poll.ID, bobID, nil))
+	votes, err = b.repos.Polls.GetVotes(poll.ID)
+	require.NoError(t, err)
+	assert.Len(t, votes, 2, "пустой список снимает голос")
+
+	require.NoError(t, b.repos.Polls.Close(poll.ID, aliceID))
+	require.NoError(t, b.repos.Polls.Close(poll.ID, bobID))
+	stored, err = b.repos.Polls.GetByID(poll.ID)
+	require.NoError(t, err)
+	require.NotNil(t, stored.ClosedAt)
+	assert.Equal(t, aliceID, *stored.ClosedBy, "повторное закрытие не меняет опрос")
+	assert.ErrorIs(t, b.repos.Polls.Vote(poll.ID, bobID, []int{friday}), ErrPollClosed)
+	assert.ErrorIs(t, b.repos.Polls.Close(poll.ID+100, aliceID), ErrPollNotFound)
+
+	expired := &models.Thread{Title: "Просроченный опрос", AuthorID: aliceID}
+	require.NoError(t, b.repos.Threads.Create(expired))
+	closesAt := time.Now().Add(-time.Minute)
+	expiredPoll := &models.Poll{ThreadID: expired.ID, Question: "Поздно?", ClosesAt: &closesAt, Options: []models.PollOption{{Text: "Да"}, {Text: "Нет"}}}
+	require.NoError(t, b.repos.Polls.Create(expiredPoll))
+	assert.ErrorIs(t, b.repos.Polls.Vote(expiredPoll.ID, bobID, []int{expiredPoll.Options[0].ID}), ErrPollClosed)
+
+	require.NoError(t, b.repos.Threads.Delete(thread.ID))
+	_, err = b.repos.Polls.GetByID(poll.ID)
+	assert.ErrorIs(t, err, ErrPollNotFound, "опрос удаляется вместе с тредом")
+}
+
+func contractPostRevisions(t *testing.T, b *contractBackend) {
+	authorID := b.addUser(t, "alice", "user")
+	editorID := b.addUser(t, "bob", "moderator")
+	thread := &models.Thread{Title: "Тред", AuthorID: authorID}
+	require.NoError(t, b.repos.Threads.Create(thread))
+	post := &models.Post{ThreadID: thread.ID, AuthorID: authorID, Title: "Исходный заголовок", Content: "Исходный текст"}
+	require.NoError(t, b.repos.Posts.SavePost(post))
+
+	update := &models.Post{Title: "Новый заголовок", Content: "Исправленный текст", Version: 1}
+	require.NoError(t, b.repos.Posts.UpdatePost(update, post.ID, editorID, "опечатка"))
+	assert.Equal(t, 2, update.Version)
+
+	stale := &models.Post{Content: "Устаревшая правка", Version: 1}
+	assert.ErrorIs(t, b.repos.Posts.UpdatePost(stale, post.ID, editorID, ""), ErrVersionConflict)
+	assert.ErrorIs(t, b.repos.Posts.UpdatePost(&models.Post{Content: "Нет"}, post.ID+100, editorID, ""), ErrPostNotFound)
+
+	updated, err := b.repos.Posts.GetPostByID(post.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Исправленный текст", updated.Content)
+	assert.Equal(t, "Новый заголовок", updated.Title)
+	assert.Equal(t, 2, updated.Version)
+
+	revisions, err := b.repos.Posts.GetPostRevisions(post.ID)
+	require.NoError(t, err)
+	require.Len(t, revisions, 1)
+	assert.Equal(t, "Исходный текст", revisions[0].Content)
+	assert.Equal(t, "Исходный заголовок", revisions[0].Title, "ревизия хранит заголовок до правки")
+	assert.Equal(t, "bob", revisions[0].EditorName)
+	assert.Equal(t, "опечатка", revisions[0].Reason)
+
+	revision, err := b.repos.Posts.GetPostRevisionByID(post.ID, revisions[0].ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Исходный текст", revision.Content)
+	_, err = b.repos.Posts.GetPostRevisionByID(post.ID+100, revisions[0].ID)
+	assert.ErrorIs(t, err, ErrRevisionNotFound)
+}
+
+func contractComments(t *testing.T, b *contractBackend) {
+	userID := b.addUser(t, "alice", "user")
+	thread := &models.Thread{Title: "Тред", AuthorID: userID}
+	require.NoError(t, b.repos.Threads.Create(thread))
+	post := &models.Post{ThreadID: thread.ID, AuthorID: userID, Content: "Пост"}
+	require.NoError(t, b.repos.Posts.SavePost(post))
+
+	root := &models.Comment{PostID: post.ID, AuthorID: userID, Content: "Корень"}
+	require.NoError(t, b.repos.Comments.SaveComment(root))
+	other := &models.Comment{PostID: post.ID, AuthorID: userID, Content: "Второй корень"}
+	require.NoError(t, b.repos.Comments.SaveComment(other))
+	reply := &models.Comment{PostID: post.ID, AuthorID: userID, ParentCommentID: &root.ID, Depth: 1, Content: "Ответ"}
+	require.NoError(t, b.repos.Comments.SaveComment(reply))
+	nested := &models.Comment{PostID: post.ID, AuthorID: userID, ParentCommentID: &reply.ID, Depth: 2, Content: "Ответ на ответ"}
+	require.NoError(t, b.repos.Comments.SaveComment(nested))
+
+	comments, err := b.repos.Comments.GetCommentsByPostID(post.ID)
+	require.NoError(t, err)
+	require.Len(t, comments, 4)
+	assert.Equal(t, []int{root.ID, reply.ID, nested.ID, other.ID},
+		[]int{comments[0].ID, comments[1].ID, comments[2].ID, comments[3].ID}, "ответы идут сразу за родителем")
+	assert.Equal(t, []int{root.ID, reply.ID, nested.ID}, comments[2].Path)
+
+	_, withComments, err := b.repos.Posts.GetPostWithComments(post.ID)
+	require.NoError(t, err)
+	require.Len(t, withComments, 4)
+	assert.Equal(t, "alice", withComments[0].AuthorName)
+
+	hasReplies, err := b.repos.Comments.HasReplies(root.ID)
+	require.NoError(t, err)
+	assert.True(t, hasReplies)
+	hasReplies, err = b.repos.Comments.HasReplies(other.ID)
+	require.NoError(t, err)
+	assert.False(t, hasReplies)
+
+	stored, err := b.repos.Comments.GetCommentByID(other.ID)
+	require.NoError(t, err)
+	assert.False(t, stored.Edited)
+	stored.Content = "Исправлено"
+	require.NoError(t, b.repos.Comments.UpdateComment(stored))
+	assert.True(t, stored.Edited)
+	assert.ErrorIs(t, b.repos.Comments.UpdateComment(&models.Comment{ID: other.ID + 100}), ErrCommentNotFound)
+
+	tombstoned, err := b.repos.Comments.RemoveComment(root.ID)
+	require.NoError(t, err)
+	assert.True(t, tombstoned, "комментарий с ответами становится заглушкой")
+	tombstone, err := b.repos.Comments.GetCommentByID(root.ID)
+	require.NoError(t, err)
+	assert.True(t, tombstone.Deleted)
+	assert.Empty(t, tombstone.Content)
+	_, err = b.repos.Comments.GetCommentByID(nested.ID)
+	require.NoError(t, err, "ответы заглушки остаются")
+
+	count, err := b.repos.Users.GetUserCommentCount(userID)
+	require.NoError(t, err)
+	assert.Equal(t, 4, count)
+
+	require.NoError(t, b.repos.Comments.DeleteComment(root.ID))
+	_, err = b.repos.Comments.GetCommentByID(nested.ID)
+	assert.ErrorIs(t, err, ErrCommentNotFound, "ответы удаляются вместе с родителем")
+	assert.ErrorIs(t, b.repos.Comments.DeleteComment(root.ID), ErrCommentNotFound)
+	assert.ErrorIs(t, b.repos.Comments.TombstoneComment(root.ID), ErrCommentNotFound)
+	_, err = b.repos.Comments.RemoveComment(root.ID)
+	assert.ErrorIs(t, err, ErrCommentNotFound)
+
+	comments, err = b.repos.Comments.GetCommentsByPostID(post.ID)
+	require.NoError(t, err)
+	require.Len(t, comments, 1)
+	assert.Equal(t, other.ID, comments[0].ID)
+
+	tombstoned, err = b.repos.Comments.RemoveComment(other.ID)
+	require.NoError(t, err)
+	assert.False(t, tombstoned, "комментарий без ответов удаляется")
+	comments, err = b.repos.Comments.GetCommentsByPostID(post.ID)
+	require.NoError(t, err)
+	assert.Empty(t, comments)
+
+	require.NoError(t, b.repos.Threads.Lock(thread.ID, userID, ""))
+	late := &models.Comment{PostID: post.ID, AuthorID: userID, Content: "Поздно"}
+	assert.ErrorIs(t, b.repos.Comments.SaveComment(late), ErrThreadLocked)
+	comments, err = b.repos.Comments.GetCommentsByPostID(post.ID)
+	require.NoError(t, err)
+	assert.Empty(t, comments, "в закрытый тред комментарий не добавляется")
+}
+
+func contractCounters(t *testing.T, b *contractBackend) {
+	aliceID := b.addUser(t, "alice", "user")
+	bobID := b.addUser(t, "bob", "user")
+	carolID := b.addUser(t, "carol", "user")
+
+	thread := &models.Thread{Title: "Тред", AuthorID: aliceID}
+	require.NoError(t, b.repos.Threads.Create(thread))
+	stored, err := b.repos.Threads.GetByID(thread.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 0, stored.PostCount)
+	assert.Equal(t, 1, stored.ParticipantCount, "автор треда - участник")
+	assert.Nil(t, stored.LastPostAt)
+	assert.Nil(t, stored.LastPostAuthorID)
+
+	first := &models.Post{ThreadID: thread.ID, AuthorID: bobID, Content: "Первый"}
+	require.NoError(t, b.repos.Posts.SavePost(first))
+	second := &models.Post{ThreadID: thread.ID, AuthorID: aliceID, Content: "Второй"}
+	require.NoError(t, b.repos.Posts.SavePost(second))
+
+	root := &models.Comment{PostID: first.ID, AuthorID: carolID, Content: "Комментарий"}
+	require.NoError(t, b.repos.Comments.SaveComment(root))
+	reply := &models.Comment{PostID: first.ID, AuthorID: carolID, ParentCommentID: &root.ID, Depth: 1, Content: "Ответ"}
+	require.NoError(t, b.repos.Comments.SaveComment(reply))
+
+	stored, err = b.repos.Threads.GetByID(thread.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 2, stored.PostCount)
+	assert.Equal(t, 2, stored.CommentCount)
+	assert.Equal(t, 3, stored.ParticipantCount)
+	require.NotNil(t, stored.LastPostAt)
+	require.NotNil(t, stored.LastPostAuthorID)
+	assert.Equal(t, aliceID, *stored.LastPostAuthorID)
+
+	threads, err := b.repos.Threads.GetAllThreads()
+	require.NoError(t, err)
+	require.Len(t, threads, 1)
+	assert.Equal(t, 2, threads[0].PostCount)
+	assert.Equal(t, "alice", threads[0].LastPostAuthorName)
+
+	post, err := b.repos.Posts.GetPostByID(first.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 2, post.CommentCount)
+	posts, err := b.repos.Posts.GetByThreadID(thread.ID)
+	require.NoError(t, err)
+	require.Len(t, posts, 2)
+	assert.Equal(t, 2, posts[0].CommentCount)
+
+	// Заглушка не считается, но ответ держит автора в участниках
+	require.NoError(t, b.repos.Comments.TombstoneComment(root.ID))
+	require.NoError(t, b.repos.Comments.TombstoneComment(root.ID))
+	stored, err = b.repos.Threads.GetByID(thread.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 1, stored.CommentCount)
+	assert.Equal(t, 3, stored.ParticipantCount)
+
+	require.NoError(t, b.repos.Comments.DeleteComment(reply.ID))
+	stored, err = b.repos.Threads.GetByID(thread.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 0, stored.CommentCount)
+	assert.Equal(t, 2, stored.ParticipantCount)
+	post, err = b.repos.Posts.GetPostByID(first.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 0, post.CommentCount)
+
+	require.NoError(t, b.repos.Comments.SaveComment(&models.Comment{PostID: second.ID, AuthorID: carolID, Content: "Еще"}))
+	require.NoError(t, b.repos.Posts.DeletePost(second.ID))
+	stored, err = b.repos.Threads.GetByID(thread.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 1, stored.PostCount)
+	assert.Equal(t, 0, stored.CommentCount)
+	assert.Equal(t, 2, stored.ParticipantCount)
+	require.NotNil(t, stored.LastPostAuthorID)
+	assert.Equal(t, bobID, *stored.LastPostAuthorID, "последним становится предыдущий пост")
+
+	require.NoError(t, b.repos.Posts.DeletePost(first.ID))
+	stored, err = b.repos.Threads.GetByID(thread.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 0, stored.PostCount)
+	assert.Equal(t, 1, stored.ParticipantCount)
+	assert.Nil(t, stored.LastPostAt)
+
+	recount, err := b.repos.Counters.RecountCounters()
+	require.NoError(t, err)
+	assert.Equal(t, models.CounterRecount{}, recount, "счетчики уже согласованы")
+}
+
+func contractChat(t *testing.T, b *contractBackend) {
+	userID := b.addUser(t, "alice", "user")
+
+	first, err := b.repos.Chat.CreateMessage(userID, "Привет")
+	require.NoError(t, err)
+	_, err = b.repos.Chat.CreateMessage(userID, "Как дела?")
+	require.NoError(t, err)
+
+	messages, err := b.repos.Chat.GetAllMessages()
+	require.NoError(t, err)
+	require.Len(t, messages, 2)
+	assert.Equal(t, first.ID, messages[0].ID, "сообщения идут в порядке отправки")
+	assert.Equal(t, "alice", messages[0].AuthorName)
+
+	require.NoError(t, b.repos.Chat.Cleanup())
+	messages, err = b.repos.Chat.GetAllMessages()
+	require.NoError(t, err)
+	assert.Len(t, messages, 2, "свежие сообщения не удаляются")
+}
+
+func contractSearch(t *testing.T, b *contractBackend) {
+	userID := b.addUser(t, "alice", "user")
+	thread := &models.Thread{Title: "Обсуждение", AuthorID: userID}
+	require.NoError(t, b.repos.Threads.Create(thread))
+	post := &models.Post{ThreadID: thread.ID, AuthorID: userID, Content: "Моя кошка спит на диване"}
+	require.NoError(t, b.repos.Posts.SavePost(post))
+	other := &models.Post{ThreadID: thread.ID, AuthorID: userID, Content: "Собака гуляет"}
+	require.NoError(t, b.repos.Posts.SavePost(other))
+
+	results, total, err := b.repos.Search.Search(models.SearchParams{Query: "кошка", Limit: 20})
+	require.NoError(t, err)
+	assert.Equal(t, 1, total)
+	require.Len(t, results, 1)
+	assert.Equal(t, models.SearchTypePost, results[0].Type)
+	assert.Equal(t, post.ID, results[0].PostID)
+	assert.Equal(t, "alice", results[0].AuthorName)
+	assert.True(t, strings.Contains(results[0].Snippet, HighlightStart), fmt.Sprintf("совпадение выделено: %q", results[0].Snippet))
+
+	results, total, err = b.repos.Search.Search(models.SearchParams{Query: "кошка", Limit: 20, Offset: 40})
+	require.NoError(t, err)
+	assert.Empty(t, results)
+	assert.Equal(t, 1, total, "страница за последней сообщает общее число совпадений")
+
+	results, total, err = b.repos.Search.Search(models.SearchParams{Query: "кошка", Types: []string{models.SearchTypeComment}, Limit: 20})
+	require.NoError(t, err)
+	assert.Equal(t, 0, total)
+	assert.Empty(t, results)
+
+	staff := &models.Category{Name: "Модераторская", ReadRole: models.RoleModerator, CreateRole: models.RoleModerator}
+	require.NoError(t, b.repos.Categories.Create(staff))
+	hidden := &models.Thread{Title: "Кошка модераторов", AuthorID: userID, CategoryID: &staff.ID}
+	require.NoError(t, b.repos.Threads.Create(hidden))
+	require.NoError(t, b.repos.Posts.SavePost(&models.Post{ThreadID: hidden.ID, AuthorID: userID, Content: "Закрытая кошка"}))
+
+	open := &models.Category{ParentID: &staff.ID, Name: "Открытый подраздел", ReadRole: models.RoleGuest, CreateRole: models.RoleUser}
+	require.NoError(t, b.repos.Categories.Create(open))
+	nested := &models.Thread{Title: "Вложенный тред", AuthorID: userID, CategoryID: &open.ID}
+	require.NoError(t, b.repos.Threads.Create(nested))
+	require.NoError(t, b.repos.Posts.SavePost(&models.Post{ThreadID: nested.ID, AuthorID: userID, Content: "Вложенная кошка"}))
+
+	_, total, err = b.repos.Search.Search(models.SearchParams{Query: "кошка", Limit: 20, Role: models.RoleUser})
+	require.NoError(t, err)
+	assert.Equal(t, 1, total, "записи закрытого раздела и его подразделов не ищутся")
+	_, total, err = b.repos.Search.Search(models.SearchParams{Query: "кошка", Limit: 20, Role: models.RoleModerator})
+	require.NoError(t, err)
+	assert.Equal(t, 4, total, "модератор находит тред и посты закрытого раздела и подраздела")
+}
+
+func TestMemoryCounterRepository_RecountCounters(t *testing.T) {
+	store := NewMemoryStore()
+	store.EnsureUser(1, "alice", "user")
+	repos := NewMemoryRepositories(store)
+
+	thread := &models.Thread{Title: "Тред", AuthorID: 1}
+	require.NoError(t, repos.Threads.Create(thread))
+	post := &models.Post{ThreadID: thread.ID, AuthorID: 1, Content: "Пост"}
+	require.NoError(t, repos.Posts.SavePost(post))
+	require.NoError(t, repos.Comments.SaveComment(&models.Comment{PostID: post.ID, AuthorID: 1, Content: "Комментарий"}))
+
+	// Портим счетчики, как если бы их изменили в обход репозиториев
+	store.threads[thread.ID].PostCount = 10
+	store.posts[post.ID].CommentCount = 5
+
+	recount, err := repos.Counters.RecountCounters()
+	require.NoError(t, err)
+	assert.Equal(t, models.CounterRecount{Threads: 1, Posts: 1}, recount)
+
+	stored, err := repos.Threads.GetByID(thread.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 1, stored.PostCount)
+	assert.Equal(t, 1, stored.CommentCount)
+}
+
+func TestMemoryRepositories_ConcurrentAccess(t *testing.T) {
+	b := newMemoryBackend(t)
+	userID := b.addUser(t, "alice", "user")
+	thread := &models.Thread{Title: "Тред", AuthorID: userID}
+	require.NoError(t, b.repos.Threads.Create(thread))
+
+	const workers = 8
+	const postsPerWorker = 20
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < postsPerWorker; j++ {
+				post := &models.Post{ThreadID: thread.ID, AuthorID: userID, Content: "Пост"}
+				if assert.NoError(t, b.repos.Posts.SavePost(post)) {
+					assert.NoError(t, b.repos.Comments.SaveComment(&models.Comment{PostID: post.ID, AuthorID: userID, Content: "Комментарий"}))
+					assert.NoError(t, b.repos.Posts.UpdatePost(&models.Post{Content: "Правка"}, post.ID, userID, ""))
+				}
+				_, err := b.repos.Threads.GetAllThreads()
+				assert.NoError(t, err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	posts, err := b.repos.Posts.GetByThreadID(thread.ID)
+	require.NoError(t, err)
+	assert.Len(t, posts, workers*postsPerWorker)
+	count, err := b.repos.Users.GetUserCommentCount(userID)
+	require.NoError(t, err)
+	assert.Equal(t, workers*postsPerWorker, count)
+}
@@ -0,0 +1,186 @@
+package repository
+
+import (
+	"ForumService/internal/models"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/lib/pq"
+)
+
+// Маркеры начала и конца совпадения в сниппетах ts_headline. Используются управляющие
+// символы, чтобы сервис мог экранировать текст пользователя и только потом расставить теги.
+const (
+	HighlightStart = "\x02"
+	HighlightStop  = "\x03"
+)
+
+const searchHeadlineOptions = "StartSel=" + HighlightStart + ", StopSel=" + HighlightStop +
+	", MaxWords=35, MinWords=15, MaxFragments=2, FragmentDelimiter=\" … \""
+
+// Подзапросы для каждого типа результатов. Выражения to_tsvector совпадают
+// с GIN-индексами из миграции 000007_add_full_text_search. $3 - роли, которым
+// доступен раздел треда: записи из закрытых для пользователя разделов и их
+// подразделов не ищутся.
+var searchSubqueries = map[string]string{
+	models.SearchTypeThread: `
+		SELECT 'thread' AS type, t.id, t.id AS thread_id, 0 AS post_id, t.author_id,
+		       COALESCE(u.username, '') AS author_name, t.title,
+		       ts_headline('russian', t.title, q.query, $2) AS snippet,
+		       ts_rank(to_tsvector('russian', t.title), q.query) AS rank, t.created_at
+		FROM threads t
+		CROSS JOIN q
+		LEFT JOIN users u ON u.id = t.author_id
+		LEFT JOIN category_read_roles cat ON cat.id = t.category_id
+		WHERE to_tsvector('russian', t.title) @@ q.query
+		  AND (t.category_id IS NULL OR cat.read_role = ANY($3))`,
+	models.SearchTypePost: `
+		SELECT 'post' AS type, p.id, p.thread_id, p.id AS post_id, p.author_id,
+		       COALESCE(u.username, '') AS author_name, p.title,
+		       ts_headline('russian', p.content, q.query, $2) AS snippet,
+		       ts_rank(to_tsvector('russian', p.title || ' ' || p.content), q.query) AS rank, p.created_at
+		FROM posts p
+		JOIN threads t ON t.id = p.thread_id
+		CROSS JOIN q
+		LEFT JOIN users u ON u.id = p.author_id
+		LEFT JOIN category_read_roles cat ON cat.id = t.category_id
+		WHERE to_tsvector('russian', p.title || ' ' || p.content) @@ q.query
+		  AND (t.category_id IS NULL OR cat.read_role = ANY($3))`,
+	models.SearchTypeComment: `
+		SELECT 'comment' AS type, c.id, p.thread_id, c.post_id, c.author_id,
+		       COALESCE(u.username, '') AS author_name, p.title,
+		       ts_headline('russian', c.content, q.query, $2) AS snippet,
+		       ts_rank(to_tsvector('russian', c.content), q.query) AS rank, c.created_at
+		FROM comments c
+		JOIN posts p ON p.id = c.post_id
+		JOIN threads t ON t.id = p.thread_id
+		CROSS JOIN q
+		LEFT JOIN users u ON u.id = c.author_id
+		LEFT JOIN category_read_roles cat ON cat.id = t.category_id
+		WHERE c.deleted_at IS NULL AND to_tsvector('russian', c.content) @@ q.query
+		  AND (t.category_id IS NULL OR cat.read_role = ANY($3))`,
+}
+
+var searchTypeOrder = []string{models.SearchTypeThread, models.SearchTypePost, models.SearchTypeComment}
+
+type searchRepository struct {
+	db *sql.DB
+}
+
+func NewSearchRepository(db *sql.DB) SearchRepository {
+	return &searchRepository{db: db}
+}
+
+// Search выполняет полнотекстовый поиск по тредам, постам и комментариям.
+// Возвращает страницу результатов, отсортированную по релевантности, и общее число совпадений.
+func (r *searchRepository) Search(params models.SearchParams) ([]models.SearchResult, int, error) {
+	readRoles := make([]string, 0)
+	for _, role := range params.Role.AllowedRoles() {
+		readRoles = append(readRoles, string(role))
+	}
+	args := []interface{}{params.Query, searchHeadlineOptions, pq.Array(readRoles)}
+
+	var subqueries []string
+	for _, searchType := range searchTypeOrder {
+		if len(params.Types) == 0 || containsString(params.Types, searchType) {
+			subqueries = append(subqueries, searchSubqueries[searchType])
+		}
+	}
+	if len(subqueries) == 0 {
+		return []models.SearchResult{}, 0, nil
+	}
+
+	var filters []string
+	if params.AuthorID > 0 {
+		args = append(args, params.AuthorID)
+		filters = append(filters, fmt.Sprintf("author_id = $%d", len(args)))
+	}
+	if params.AuthorName != "" {
+		args = append(args, params.AuthorName)
+		filters = append(filters, fmt.Sprintf("LOWER(author_name) = LOWER($%d)", len(args)))
+	}
+	if !params.From.IsZero() {
+		args = append(args, params.From)
+		filters = append(filters, fmt.Sprintf("created_at >= $%d", len(args)))
+	}
+	if !params.To.IsZero() {
+		args = append(args, params.To)
+		filters = append(filters, fmt.Sprintf("created_at < $%d", len(args)))
+	}
+
+	where := ""
+	if len(filters) > 0 {
+		where = "WHERE " + strings.Join(filters, " AND ")
+	}
+
+	with := fmt.Sprintf(`
+		WITH RECURSIVE%s,
+		q AS (SELECT websearch_to_tsquery('russian', $1) AS query)`, categoryReadRolesCTE)
+	from := fmt.Sprintf(`
+		FROM (%s) AS results
+		%s`, strings.Join(subqueries, "\n\t\tUNION ALL"), where)
+	filterArgs := args
+
+	args = append(args, params.Limit, params.Offset)
+	query := fmt.Sprintf(`%s
+		SELECT type, id, thread_id, post_id, author_id, author_name, title, snippet, rank, created_at,
+		       COUNT(*) OVER() AS total%s
+		ORDER BY rank DESC, created_at DESC
+		LIMIT $%d OFFSET $%d`,
+		with, from, len(args)-1, len(args))
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("ошибка при выполнении поиска: %w", err)
+	}
+	defer rows.Close()
+
+	results := make([]models.SearchResult, 0)
+	total := 0
+	for rows.Next() {
+		var result models.SearchResult
+		err := rows.Scan(
+			&result.Type,
+			&result.ID,
+			&result.ThreadID,
+			&result.PostID,
+			&result.AuthorID,
+			&result.AuthorName,
+			&result.Title,
+			&result.Snippet,
+			&result.Rank,
+			&result.CreatedAt,
+			&total,
+		)
+		if err != nil {
+			return nil, 0, fmt.Errorf("ошибка при сканировании результата поиска: %w", err)
+		}
+		results = append(results, result)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("ошибка при итерации результатов поиска: %w", err)
+	}
+
+	// Общее число приходит в строках страницы, поэтому у страницы за последней
+	// его нет, и совпадения считаются отдельным запросом
+	if len(results) == 0 && params.Offset > 0 {
+		err = r.db.QueryRow(with+`
+		SELECT COUNT(*)`+from, filterArgs...).Scan(&total)
+		if err != nil {
+			return nil, 0, fmt.Errorf("ошибка при подсчете результатов поиска: %w", err)
+		}
+	}
+
+	return results, total, nil
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,124 @@
+package repository
+
+import (
+	"ForumService/internal/models"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupSearchRepositoryTest(t *testing.T) (SearchRepository, sqlmock.Sqlmock, func()) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+
+	repo := NewSearchRepository(db)
+
+	cleanup := func() {
+		db.Close()
+	}
+
+	return repo, mock, cleanup
+}
+
+var searchColumns = []string{"type", "id", "thread_id", "post_id", "author_id", "author_name", "title", "snippet", "rank", "created_at", "total"}
+
+func TestSearchRepository_Search(t *testing.T) {
+	repo, mock, cleanup := setupSearchRepositoryTest(t)
+	defer cleanup()
+
+	now := time.Now()
+	rows := sqlmock.NewRows(searchColumns).
+		AddRow("post", 5, 1, 5, 2, "user", "Заголовок", "про \x02поиск\x03", 0.8, now, 2).
+		AddRow("comment", 7, 1, 5, 3, "admin", "Заголовок", "\x02поиск\x03 работает", 0.4, now, 2)
+
+	mock.ExpectQuery("WITH RECURSIVE category_read_roles .* q AS \\(SELECT websearch_to_tsquery\\('russian', \\$1\\) AS query\\)").
+		WithArgs("поиск", searchHeadlineOptions, pq.Array([]string{"guest"}), 20, 0).
+		WillReturnRows(rows)
+
+	results, total, err := repo.Search(models.SearchParams{Query: "поиск", Limit: 20})
+	require.NoError(t, err)
+	assert.Equal(t, 2, total)
+	require.Len(t, results, 2)
+	assert.Equal(t, models.SearchTypePost, results[0].Type)
+	assert.Equal(t, 5, results[0].PostID)
+	assert.Equal(t, "admin", results[1].AuthorName)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSearchRepository_Search_Filters(t *testing.T) {
+	repo, mock, cleanup := setupSearchRepositoryTest(t)
+	defer cleanup()
+
+	from := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)
+
+	mock.ExpectQuery("FROM threads t .* LEFT JOIN category_read_roles cat ON cat.id = t.category_id .* cat.read_role = ANY\\(\\$3\\).* WHERE author_id = \\$4 AND LOWER\\(author_name\\) = LOWER\\(\\$5\\) AND created_at >= \\$6 AND created_at < \\$7 ORDER BY rank DESC, created_at DESC LIMIT \\$8 OFFSET \\$9").
+		WithArgs("поиск", searchHeadlineOptions, pq.Array([]string{"guest", "user", "moderator"}), 2, "user", from, to, 10, 20).
+		WillReturnRows(sqlmock.NewRows(searchColumns))
+	mock.ExpectQuery("SELECT COUNT\\(\\*\\) FROM \\(.*\\) AS results WHERE author_id = \\$4 AND LOWER\\(author_name\\) = LOWER\\(\\$5\\) AND created_at >= \\$6 AND created_at < \\$7$").
+		WithArgs("поиск", searchHeadlineOptions, pq.Array([]string{"guest", "user", "moderator"}), 2, "user", from, to).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+
+	results, total, err := repo.Search(models.SearchParams{
+		Query:      "поиск",
+		Types:      []string{models.SearchTypeThread},
+		AuthorID:   2,
+		AuthorName: "user",
+		From:       from,
+		To:         to,
+		Limit:      10,
+		Offset:     20,
+		Role:       models.RoleModerator,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 0, total)
+	assert.Empty(t, results)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSearchRepository_Search_PageOutOfRange(t *testing.T) {
+	repo, mock, cleanup := setupSearchRepositoryTest(t)
+	defer cleanup()
+
+	mock.ExpectQuery("LIMIT \\$4 OFFSET \\$5").
+		WithArgs("поиск", searchHeadlineOptions, pq.Array([]string{"guest"}), 20, 40).
+		WillReturnRows(sqlmock.NewRows(searchColumns))
+	mock.ExpectQuery("WITH RECURSIVE category_read_roles .* SELECT COUNT\\(\\*\\) FROM").
+		WithArgs("поиск", searchHeadlineOptions, pq.Array([]string{"guest"})).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(5))
+
+	results, total, err := repo.Search(models.SearchParams{Query: "поиск", Limit: 20, Offset: 40})
+	require.NoError(t, err)
+	assert.Empty(t, results)
+	assert.Equal(t, 5, total, "общее число совпадений не зависит от номера страницы")
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSearchRepository_Search_UnknownTypeOnly(t *testing.T) {
+	repo, mock, cleanup := setupSearchRepositoryTest(t)
+	defer cleanup()
+
+	results, total, err := repo.Search(models.SearchParams{Query: "поиск", Types: []string{"user"}, Limit: 20})
+	require.NoError(t, err)
+	assert.Equal(t, 0, total)
+	assert.Empty(t, results)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSearchRepository_Search_Error(t *testing.T) {
+	repo, mock, cleanup := setupSearchRepositoryTest(t)
+	defer cleanup()
+
+	mock.ExpectQuery("WITH RECURSIVE category_read_roles").
+		WillReturnError(errors.New("db error"))
+
+	results, total, err := repo.Search(models.SearchParams{Query: "поиск", Limit: 20})
+	require.Error(t, err)
+	assert.Nil(t, results)
+	assert.Equal(t, 0, total)
+}
@@ -0,0 +1,84 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+type subscriptionRepository struct {
+	db *sql.DB
+}
+
+func NewSubscriptionRepository(db *sql.DB) SubscriptionRepository {
+	return &subscriptionRepository{db: db}
+}
+
+// Subscribe блокирует запись на удаление, пока сохраняется подписка
+func (r *subscriptionRepository) Subscribe(userID int, targetType string, targetID int) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("ошибка при начале транзакции: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err = lockTarget(tx, targetType, targetID); err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`
+		INSERT INTO subscriptions (user_id, target_type, target_id) VALUES ($1, $2, $3)
+		ON CONFLICT (user_id, target_type, target_id) DO NOTHING`,
+		userID, targetType, targetID)
+	if isForeignKeyViolation(err) {
+		return ErrUserNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("ошибка при сохранении подписки: %w", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("ошибка при фиксации транзакции: %w", err)
+	}
+	return nil
+}
+
+func (r *subscriptionRepository) Unsubscribe(userID int, targetType string, targetID int) error {
+	result, err := r.db.Exec(`DELETE FROM subscriptions WHERE user_id = $1 AND target_type = $2 AND target_id = $3`,
+		userID, targetType, targetID)
+	if err != nil {
+		return fmt.Errorf("ошибка при удалении подписки: %w", err)
+	}
+	return checkRowsAffected(result, ErrSubscriptionNotFound)
+}
+
+func (r *subscriptionRepository) IsSubscribed(userID int, targetType string, targetID int) (bool, error) {
+	var subscribed bool
+	err := r.db.QueryRow(`SELECT EXISTS(SELECT 1 FROM subscriptions WHERE user_id = $1 AND target_type = $2 AND target_id = $3)`,
+		userID, targetType, targetID).Scan(&subscribed)
+	if err != nil {
+		return false, fmt.Errorf("ошибка при проверке подписки: %w", err)
+	}
+	return subscribed, nil
+}
+
+func (r *subscriptionRepository) GetSubscribers(targetType string, targetID int) ([]int, error) {
+	rows, err := r.db.Query(`SELECT user_id FROM subscriptions WHERE target_type = $1 AND target_id = $2 ORDER BY user_id`,
+		targetType, targetID)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при получении подписчиков: %w", err)
+	}
+	defer rows.Close()
+
+	subscribers := make([]int, 0)
+	for rows.Next() {
+		var userID int
+		if err := rows.Scan(&userID); err != nil {
+			return nil, fmt.Errorf("ошибка при сканировании подписчика: %w", err)
+		}
+		subscribers = append(subscribers, userID)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("ошибка при итерации по подписчикам: %w", err)
+	}
+	return subscribers, nil
+}
@@ -0,0 +1,76 @@
+package repository
+
+import (
+	"database/sql"
+	"testing"
+
+	"ForumService/internal/models"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupSubscriptionRepositoryTest(t *testing.T) (SubscriptionRepository, sqlmock.Sqlmock, func()) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+
+	return NewSubscriptionRepository(db), mock, func() { db.Close() }
+}
+
+func TestSubscriptionRepository_Subscribe(t *testing.T) {
+	repo, mock, cleanup := setupSubscriptionRepositoryTest(t)
+	defer cleanup()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT id FROM threads WHERE id = \\$1 FOR SHARE").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectExec("INSERT INTO subscriptions \\(user_id, target_type, target_id\\) VALUES \\(\\$1, \\$2, \\$3\\) ON CONFLICT \\(user_id, target_type, target_id\\) DO NOTHING").
+		WithArgs(2, "thread", 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	require.NoError(t, repo.Subscribe(2, models.SubscriptionTargetThread, 1))
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSubscriptionRepository_Subscribe_NotFound(t *testing.T) {
+	repo, mock, cleanup := setupSubscriptionRepositoryTest(t)
+	defer cleanup()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT id FROM posts WHERE id = \\$1 FOR SHARE").
+		WithArgs(99).
+		WillReturnError(sql.ErrNoRows)
+	mock.ExpectRollback()
+
+	assert.ErrorIs(t, repo.Subscribe(2, models.SubscriptionTargetPost, 99), ErrPostNotFound)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSubscriptionRepository_Unsubscribe(t *testing.T) {
+	repo, mock, cleanup := setupSubscriptionRepositoryTest(t)
+	defer cleanup()
+
+	mock.ExpectExec("DELETE FROM subscriptions WHERE user_id = \\$1 AND target_type = \\$2 AND target_id = \\$3").
+		WithArgs(2, "post", 5).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	assert.ErrorIs(t, repo.Unsubscribe(2, models.SubscriptionTargetPost, 5), ErrSubscriptionNotFound)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSubscriptionRepository_GetSubscribers(t *testing.T) {
+	repo, mock, cleanup := setupSubscriptionRepositoryTest(t)
+	defer cleanup()
+
+	mock.ExpectQuery("SELECT user_id FROM subscriptions WHERE target_type = \\$1 AND target_id = \\$2 ORDER BY user_id").
+		WithArgs("thread", 1).
+		WillReturnRows(sqlmock.NewRows([]string{"user_id"}).AddRow(2).AddRow(3))
+
+	subscribers, err := repo.GetSubscribers(models.SubscriptionTargetThread, 1)
+	require.NoError(t, err)
+	assert.Equal(t, []int{2, 3}, subscribers)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
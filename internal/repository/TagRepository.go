@@ -0,0 +1,202 @@
+package repository
+
+import (
+	"ForumService/internal/models"
+	"database/sql"
+	"fmt"
+
+	"github.com/lib/pq"
+)
+
+// tagSelect выбирает теги вместе с числом тредов
+const tagSelect = `
+	SELECT tg.id, tg.name, tg.created_at, COUNT(tt.thread_id) AS thread_count
+	FROM tags tg
+	LEFT JOIN thread_tags tt ON tt.tag_id = tg.id`
+
+type tagRepository struct {
+	db *sql.DB
+}
+
+func NewTagRepository(db *sql.DB) TagRepository {
+	return &tagRepository{db: db}
+}
+
+func (r *tagRepository) GetAll() ([]*models.Tag, error) {
+	rows, err := r.db.Query(tagSelect + `
+		GROUP BY tg.id
+		HAVING COUNT(tt.thread_id) > 0
+		ORDER BY thread_count DESC, tg.name`)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при получении тегов: %w", err)
+	}
+	defer rows.Close()
+
+	var tags []*models.Tag
+	for rows.Next() {
+		tag := &models.Tag{}
+		if err := rows.Scan(&tag.ID, &tag.Name, &tag.CreatedAt, &tag.ThreadCount); err != nil {
+			return nil, fmt.Errorf("ошибка при сканировании тега: %w", err)
+		}
+		tags = append(tags, tag)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("ошибка при итерации по тегам: %w", err)
+	}
+	return tags, nil
+}
+
+func (r *tagRepository) GetByID(id int) (*models.Tag, error) {
+	tag := &models.Tag{}
+	err := r.db.QueryRow(tagSelect+` WHERE tg.id = $1 GROUP BY tg.id`, id).
+		Scan(&tag.ID, &tag.Name, &tag.CreatedAt, &tag.ThreadCount)
+	if err == sql.ErrNoRows {
+		return nil, ErrTagNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при получении тега: %w", err)
+	}
+	return tag, nil
+}
+
+func (r *tagRepository) GetByThreadIDs(threadIDs []int) (map[int][]string, error) {
+	tags := make(map[int][]string)
+	if len(threadIDs) == 0 {
+		return tags, nil
+	}
+
+	const query = `
+		SELECT tt.thread_id, tg.name
+		FROM thread_tags tt
+		JOIN tags tg ON tg.id = tt.tag_id
+		WHERE tt.thread_id = ANY($1)
+		ORDER BY tg.name`
+	rows, err := r.db.Query(query, pq.Array(threadIDs))
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при получении тегов тредов: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var threadID int
+		var name string
+		if err := rows.Scan(&threadID, &name); err != nil {
+			return nil, fmt.Errorf("ошибка при сканировании тега треда: %w", err)
+		}
+		tags[threadID] = append(tags[threadID], name)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("ошибка при итерации по тегам тредов: %w", err)
+	}
+	return tags, nil
+}
+
+func (r *tagRepository) SetThreadTags(threadID int, names []string) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("ошибка при начале транзакции: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err = tx.Exec(`DELETE FROM thread_tags WHERE thread_id = $1`, threadID); err != nil {
+		return fmt.Errorf("ошибка при удалении тегов треда: %w", err)
+	}
+	if len(names) > 0 {
+		if _, err = tx.Exec(`INSERT INTO tags (name) SELECT unnest($1::text[]) ON CONFLICT (name) DO NOTHING`, pq.Array(names)); err != nil {
+			return fmt.Errorf("ошибка при создании тегов: %w", err)
+		}
+		_, err = tx.Exec(`INSERT INTO thread_tags (thread_id, tag_id) SELECT $1, id FROM tags WHERE name = ANY($2)`, threadID, pq.Array(names))
+		if isForeignKeyViolation(err) {
+			return ErrThreadNotFound
+		}
+		if err != nil {
+			return fmt.Errorf("ошибка при привязке тегов к треду: %w", err)
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("ошибка при фиксации транзакции: %w", err)
+	}
+	return nil
+}
+
+func (r *tagRepository) GetThreadIDs(names []string, matchAll bool) ([]int, error) {
+	if len(names) == 0 {
+		return nil, nil
+	}
+
+	// Для AND тред должен совпасть со всеми тегами, для OR - хотя бы с одним
+	required := 1
+	if matchAll {
+		required = len(names)
+	}
+	const query = `
+		SELECT tt.thread_id
+		FROM thread_tags tt
+		JOIN tags tg ON tg.id = tt.tag_id
+		WHERE tg.name = ANY($1)
+		GROUP BY tt.thread_id
+		HAVING COUNT(*) >= $2`
+	rows, err := r.db.Query(query, pq.Array(names), required)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при поиске тредов по тегам: %w", err)
+	}
+	defer rows.Close()
+
+	var threadIDs []int
+	for rows.Next() {
+		var threadID int
+		if err := rows.Scan(&threadID); err != nil {
+			return nil, fmt.Errorf("ошибка при сканировании треда: %w", err)
+		}
+		threadIDs = append(threadIDs, threadID)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("ошибка при итерации по тредам: %w", err)
+	}
+	return threadIDs, nil
+}
+
+func (r *tagRepository) Rename(id int, name string) error {
+	result, err := r.db.Exec(`UPDATE tags SET name = $2 WHERE id = $1`, id, name)
+	if isUniqueViolation(err) {
+		return ErrAlreadyExists
+	}
+	if err != nil {
+		return fmt.Errorf("ошибка при переименовании тега: %w", err)
+	}
+	return checkRowsAffected(result, ErrTagNotFound)
+}
+
+func (r *tagRepository) Merge(sourceID, targetID int) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("ошибка при начале транзакции: %w", err)
+	}
+	defer tx.Rollback()
+
+	var found int
+	if err = tx.QueryRow(`SELECT COUNT(*) FROM tags WHERE id = ANY($1)`, pq.Array([]int{sourceID, targetID})).Scan(&found); err != nil {
+		return fmt.Errorf("ошибка при проверке тегов: %w", err)
+	}
+	if found != 2 {
+		return ErrTagNotFound
+	}
+
+	// Треды, у которых уже есть оба тега, сохраняют одну привязку к целевому
+	const moveQuery = `
+		INSERT INTO thread_tags (thread_id, tag_id)
+		SELECT thread_id, $2 FROM thread_tags WHERE tag_id = $1
+		ON CONFLICT DO NOTHING`
+	if _, err = tx.Exec(moveQuery, sourceID, targetID); err != nil {
+		return fmt.Errorf("ошибка при переносе тредов на тег: %w", err)
+	}
+	if _, err = tx.Exec(`DELETE FROM tags WHERE id = $1`, sourceID); err != nil {
+		return fmt.Errorf("ошибка при удалении тега: %w", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("ошибка при фиксации транзакции: %w", err)
+	}
+	return nil
+}
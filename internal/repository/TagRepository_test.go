@@ -0,0 +1,189 @@
+package repository
+
+import (
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var tagColumns = []string{"id", "name", "created_at", "thread_count"}
+
+func setupTagRepositoryTest(t *testing.T) (TagRepository, sqlmock.Sqlmock) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	return NewTagRepository(db), mock
+}
+
+func TestTagRepository_GetAll(t *testing.T) {
+	repo, mock := setupTagRepositoryTest(t)
+
+	now := time.Now()
+	mock.ExpectQuery("FROM tags tg LEFT JOIN thread_tags tt ON tt.tag_id = tg.id GROUP BY tg.id HAVING COUNT\\(tt.thread_id\\) > 0 ORDER BY thread_count DESC, tg.name").
+		WillReturnRows(sqlmock.NewRows(tagColumns).AddRow(1, "go", now, 5).AddRow(2, "sql", now, 1))
+
+	tags, err := repo.GetAll()
+	require.NoError(t, err)
+	require.Len(t, tags, 2)
+	assert.Equal(t, "go", tags[0].Name)
+	assert.Equal(t, 5, tags[0].ThreadCount)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestTagRepository_GetByID_NotFound(t *testing.T) {
+	repo, mock := setupTagRepositoryTest(t)
+
+	mock.ExpectQuery("WHERE tg.id = \\$1 GROUP BY tg.id").
+		WithArgs(42).
+		WillReturnRows(sqlmock.NewRows(tagColumns))
+
+	_, err := repo.GetByID(42)
+	assert.ErrorIs(t, err, ErrTagNotFound)
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestTagRepository_GetByThreadIDs(t *testing.T) {
+	repo, mock := setupTagRepositoryTest(t)
+
+	mock.ExpectQuery("SELECT tt.thread_id, tg.name FROM thread_tags tt JOIN tags tg ON tg.id = tt.tag_id WHERE tt.thread_id = ANY\\(\\$1\\)").
+		WithArgs(pq.Array([]int{1, 2})).
+		WillReturnRows(sqlmock.NewRows([]string{"thread_id", "name"}).
+			AddRow(1, "go").AddRow(2, "go").AddRow(1, "sql"))
+
+	tags, err := repo.GetByThreadIDs([]int{1, 2})
+	require.NoError(t, err)
+	assert.Equal(t, map[int][]string{1: {"go", "sql"}, 2: {"go"}}, tags)
+
+	// Без тредов запрос не выполняется
+	tags, err = repo.GetByThreadIDs(nil)
+	require.NoError(t, err)
+	assert.Empty(t, tags)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestTagRepository_SetThreadTags(t *testing.T) {
+	repo, mock := setupTagRepositoryTest(t)
+
+	names := []string{"go", "sql"}
+	mock.ExpectBegin()
+	mock.ExpectExec("DELETE FROM thread_tags WHERE thread_id = \\$1").
+		WithArgs(1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO tags \\(name\\) SELECT unnest\\(\\$1::text\\[\\]\\) ON CONFLICT \\(name\\) DO NOTHING").
+		WithArgs(pq.Array(names)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO thread_tags \\(thread_id, tag_id\\) SELECT \\$1, id FROM tags WHERE name = ANY\\(\\$2\\)").
+		WithArgs(1, pq.Array(names)).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectCommit()
+
+	require.NoError(t, repo.SetThreadTags(1, names))
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestTagRepository_SetThreadTags_Clear(t *testing.T) {
+	repo, mock := setupTagRepositoryTest(t)
+
+	mock.ExpectBegin()
+	mock.ExpectExec("DELETE FROM thread_tags WHERE thread_id = \\$1").
+		WithArgs(1).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectCommit()
+
+	require.NoError(t, repo.SetThreadTags(1, nil))
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestTagRepository_SetThreadTags_ThreadNotFound(t *testing.T) {
+	repo, mock := setupTagRepositoryTest(t)
+
+	mock.ExpectBegin()
+	mock.ExpectExec("DELETE FROM thread_tags").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("INSERT INTO tags").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO thread_tags").WillReturnError(&pq.Error{Code: "23503"})
+	mock.ExpectRollback()
+
+	err := repo.SetThreadTags(42, []string{"go"})
+	assert.ErrorIs(t, err, ErrThreadNotFound)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestTagRepository_GetThreadIDs(t *testing.T) {
+	tests := []struct {
+		name     string
+		matchAll bool
+		required int
+	}{
+		{"все теги", true, 2},
+		{"любой тег", false, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo, mock := setupTagRepositoryTest(t)
+			names := []string{"go", "sql"}
+			mock.ExpectQuery("WHERE tg.name = ANY\\(\\$1\\) GROUP BY tt.thread_id HAVING COUNT\\(\\*\\) >= \\$2").
+				WithArgs(pq.Array(names), tt.required).
+				WillReturnRows(sqlmock.NewRows([]string{"thread_id"}).AddRow(3).AddRow(7))
+
+			threadIDs, err := repo.GetThreadIDs(names, tt.matchAll)
+			require.NoError(t, err)
+			assert.Equal(t, []int{3, 7}, threadIDs)
+			require.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestTagRepository_Rename(t *testing.T) {
+	repo, mock := setupTagRepositoryTest(t)
+
+	mock.ExpectExec("UPDATE tags SET name = \\$2 WHERE id = \\$1").
+		WithArgs(1, "golang").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("UPDATE tags").
+		WithArgs(1, "sql").
+		WillReturnError(&pq.Error{Code: "23505"})
+	mock.ExpectExec("UPDATE tags").
+		WithArgs(42, "db").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	require.NoError(t, repo.Rename(1, "golang"))
+	assert.ErrorIs(t, repo.Rename(1, "sql"), ErrAlreadyExists)
+	assert.ErrorIs(t, repo.Rename(42, "db"), ErrTagNotFound)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestTagRepository_Merge(t *testing.T) {
+	repo, mock := setupTagRepositoryTest(t)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT COUNT\\(\\*\\) FROM tags WHERE id = ANY\\(\\$1\\)").
+		WithArgs(pq.Array([]int{2, 1})).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(2))
+	mock.ExpectExec("INSERT INTO thread_tags \\(thread_id, tag_id\\) SELECT thread_id, \\$2 FROM thread_tags WHERE tag_id = \\$1 ON CONFLICT DO NOTHING").
+		WithArgs(2, 1).
+		WillReturnResult(sqlmock.NewResult(0, 3))
+	mock.ExpectExec("DELETE FROM tags WHERE id = \\$1").
+		WithArgs(2).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	require.NoError(t, repo.Merge(2, 1))
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestTagRepository_Merge_NotFound(t *testing.T) {
+	repo, mock := setupTagRepositoryTest(t)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT COUNT\\(\\*\\) FROM tags").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	mock.ExpectRollback()
+
+	assert.ErrorIs(t, repo.Merge(2, 42), ErrTagNotFound)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
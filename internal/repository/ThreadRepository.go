@@ -5,8 +5,12 @@ import (
 	"ForumService/internal/models"
 	"fmt"
 	"github.com/lib/pq"
+	"time"
 )
 
+// threadPinnedExpr истинно для закрепленных тредов, у которых не истек срок закрепления
+const threadPinnedExpr = `(t.pinned_at IS NOT NULL AND (t.pinned_until IS NULL OR t.pinned_until > CURRENT_TIMESTAMP))`
+
 type threadRepository struct {
 	db *sql.DB
 }
@@ -16,37 +20,76 @@ func NewThreadRepository(db *sql.DB) ThreadRepository {
 }
 
 func (r *threadRepository) GetByID(id int) (*models.Thread, error) {
-	query := `SELECT id, title, author_id, created_at, updated_at FROM threads WHERE id = $1`
+	query := `
+		SELECT t.id, t.title, t.author_id, t.created_at, t.updated_at, t.version,
+			t.post_count, t.comment_count, t.participant_count, t.view_count, t.last_post_at, t.last_post_author_id,
+			t.category_id, COALESCE(c.name, ''),
+			t.locked_at, t.locked_by, COALESCE(lb.username, ''), t.lock_reason,
+			` + threadPinnedExpr + `, t.pin_order, t.pinned_at, t.pinned_until,
+			t.type, t.accepted_post_id
+		FROM threads t
+		LEFT JOIN categories c ON t.category_id = c.id
+		LEFT JOIN users lb ON t.locked_by = lb.id
+		WHERE t.id = $1`
 	thread := &models.Thread{}
+	var lastPostAt, lockedAt, pinnedAt, pinnedUntil sql.NullTime
+	var lastPostAuthorID, categoryID, lockedBy, acceptedPostID sql.NullInt64
 	err := r.db.QueryRow(query, id).Scan(
 		&thread.ID,
 		&thread.Title,
 		&thread.AuthorID,
 		&thread.CreatedAt,
 		&thread.UpdatedAt,
+		&thread.Version,
+		&thread.PostCount,
+		&thread.CommentCount,
+		&thread.ParticipantCount,
+		&thread.ViewCount,
+		&lastPostAt,
+		&lastPostAuthorID,
+		&categoryID,
+		&thread.CategoryName,
+		&lockedAt,
+		&lockedBy,
+		&thread.LockedByName,
+		&thread.LockReason,
+		&thread.Pinned,
+		&thread.PinOrder,
+		&pinnedAt,
+		&pinnedUntil,
+		&thread.Type,
+		&acceptedPostID,
 	)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return nil, nil
+			return nil, ErrThreadNotFound
 		}
 		return nil, err
 	}
+	fillLastPost(thread, lastPostAt, lastPostAuthorID)
+	fillLock(thread, lockedAt, lockedBy)
+	fillAnswer(thread, acceptedPostID)
+	thread.PinnedAt = timePtr(pinnedAt)
+	thread.PinnedUntil = timePtr(pinnedUntil)
+	thread.CategoryID = intPtr(categoryID)
 	return thread, nil
 }
 
 func (r *threadRepository) Create(thread *models.Thread) error {
-	query := `INSERT INTO threads (title, author_id, created_at, updated_at) 
-			  VALUES ($1, $2, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP) 
-			  RETURNING id, title, author_id, created_at, updated_at`
+	query := `INSERT INTO threads (title, author_id, category_id, type, created_at, updated_at) 
+			  VALUES ($1, $2, $3, $4, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP) 
+			  RETURNING id, title, author_id, created_at, updated_at, participant_count, type`
 	
 	fmt.Printf("Создание треда: title=%s, author_id=%d\n", thread.Title, thread.AuthorID)
 	
-	err := r.db.QueryRow(query, thread.Title, thread.AuthorID).Scan(
+	err := r.db.QueryRow(query, thread.Title, thread.AuthorID, thread.CategoryID, threadType(thread.Type)).Scan(
 		&thread.ID,
 		&thread.Title,
 		&thread.AuthorID,
 		&thread.CreatedAt,
 		&thread.UpdatedAt,
+		&thread.ParticipantCount,
+		&thread.Type,
 	)
 	
 	if err != nil {
@@ -60,16 +103,104 @@ func (r *threadRepository) Create(thread *models.Thread) error {
 	return nil
 }
 
+// Update сохраняет название и раздел треда. Если thread.Version больше нуля, тред обновляется
+// только при совпадении версии, иначе возвращается ErrVersionConflict.
 func (r *threadRepository) Update(thread *models.Thread) error {
-	query := `UPDATE threads SET title = $1, updated_at = CURRENT_TIMESTAMP WHERE id = $2`
-	_, err := r.db.Exec(query, thread.Title, thread.ID)
+	query := `
+		UPDATE threads SET title = $1, category_id = $4, type = $5, version = version + 1, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $2 AND ($3 = 0 OR version = $3)
+		RETURNING version, updated_at`
+	err := r.db.QueryRow(query, thread.Title, thread.ID, thread.Version, thread.CategoryID, threadType(thread.Type)).Scan(&thread.Version, &thread.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return r.versionMismatchError(thread.ID)
+	}
 	return err
 }
 
+// versionMismatchError различает удаленный тред и тред, измененный другим пользователем
+func (r *threadRepository) versionMismatchError(id int) error {
+	var exists bool
+	if err := r.db.QueryRow(`SELECT EXISTS(SELECT 1 FROM threads WHERE id = $1)`, id).Scan(&exists); err != nil {
+		return err
+	}
+	if !exists {
+		return ErrThreadNotFound
+	}
+	return ErrVersionConflict
+}
+
+// Lock закрывает тред от имени модератора userID. Повторное закрытие обновляет причину.
+// Версия треда увеличивается, чтобы закэшированные по ETag ответы устарели.
+func (r *threadRepository) Lock(id int, userID int, reason string) error {
+	query := `
+		UPDATE threads SET locked_at = CURRENT_TIMESTAMP, locked_by = $2, lock_reason = $3, version = version + 1
+		WHERE id = $1`
+	result, err := r.db.Exec(query, id, userID, reason)
+	if err != nil {
+		return fmt.Errorf("ошибка при закрытии треда: %w", err)
+	}
+	return checkRowsAffected(result, ErrThreadNotFound)
+}
+
+// Unlock открывает тред и сбрасывает сведения о закрытии
+func (r *threadRepository) Unlock(id int) error {
+	query := `
+		UPDATE threads SET locked_at = NULL, locked_by = NULL, lock_reason = '', version = version + 1
+		WHERE id = $1`
+	result, err := r.db.Exec(query, id)
+	if err != nil {
+		return fmt.Errorf("ошибка при открытии треда: %w", err)
+	}
+	return checkRowsAffected(result, ErrThreadNotFound)
+}
+
+// Pin закрепляет тред с порядком order до момента until, nil - бессрочно.
+// Повторное закрепление обновляет порядок и срок.
+func (r *threadRepository) Pin(id int, order int, until *time.Time) error {
+	query := `
+		UPDATE threads SET pinned_at = CURRENT_TIMESTAMP, pin_order = $2, pinned_until = $3, version = version + 1
+		WHERE id = $1`
+	result, err := r.db.Exec(query, id, order, until)
+	if err != nil {
+		return fmt.Errorf("ошибка при закреплении треда: %w", err)
+	}
+	return checkRowsAffected(result, ErrThreadNotFound)
+}
+
+// Unpin снимает закрепление треда
+func (r *threadRepository) Unpin(id int) error {
+	query := `
+		UPDATE threads SET pinned_at = NULL, pin_order = 0, pinned_until = NULL, version = version + 1
+		WHERE id = $1`
+	result, err := r.db.Exec(query, id)
+	if err != nil {
+		return fmt.Errorf("ошибка при откреплении треда: %w", err)
+	}
+	return checkRowsAffected(result, ErrThreadNotFound)
+}
+
+// SetAcceptedPost отмечает пост postID принятым ответом треда, nil снимает отметку.
+// Принадлежность поста треду проверяет сервис; пост, удаленный до сохранения отметки,
+// дает ErrPostNotFound.
+func (r *threadRepository) SetAcceptedPost(id int, postID *int) error {
+	query := `UPDATE threads SET accepted_post_id = $2, version = version + 1 WHERE id = $1`
+	result, err := r.db.Exec(query, id, postID)
+	if isForeignKeyViolation(err) {
+		return ErrPostNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("ошибка при сохранении принятого ответа: %w", err)
+	}
+	return checkRowsAffected(result, ErrThreadNotFound)
+}
+
 func (r *threadRepository) Delete(id int) error {
 	query := `DELETE FROM threads WHERE id = $1`
-	_, err := r.db.Exec(query, id)
-	return err
+	result, err := r.db.Exec(query, id)
+	if err != nil {
+		return err
+	}
+	return checkRowsAffected(result, ErrThreadNotFound)
 }
 
 // CreateThread создает новый тред
@@ -123,14 +254,14 @@ func (r *threadRepository) GetThreadWithPosts(threadID int) (*models.Thread, []m
 	)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return nil, nil, nil, fmt.Errorf("thread not found")
+			return nil, nil, nil, ErrThreadNotFound
 		}
 		return nil, nil, nil, fmt.Errorf("failed to get thread: %w", err)
 	}
 
 	// 2. Получаем все посты треда
 	const postsQuery = `
-        SELECT id, thread_id, user_id, content, created_at, updated_at
+        SELECT id, thread_id, author_id, content, created_at, updated_at
         FROM posts
         WHERE thread_id = $1
         ORDER BY created_at DESC
@@ -171,7 +302,7 @@ func (r *threadRepository) GetThreadWithPosts(threadID int) (*models.Thread, []m
 
 	if len(postIDs) > 0 {
 		const commentsQuery = `
-            SELECT id, post_id, user_id, content, created_at
+            SELECT id, post_id, author_id, content, created_at
             FROM comments
             WHERE post_id = ANY($1)
             ORDER BY created_at ASC
@@ -229,7 +360,7 @@ func (r *threadRepository) DeleteThread(threadID int) error {
 	}
 
 	if rowsAffected == 0 {
-		return fmt.Errorf("thread not found")
+		return ErrThreadNotFound
 	}
 
 	return nil
@@ -271,14 +402,36 @@ func (r *threadRepository) DeleteThread(threadID int) error {
 //CREATE INDEX idx_comments_created_at ON comments(created_at);
 
 func (r *threadRepository) GetAllThreads() ([]*models.Thread, error) {
+	return r.queryThreads("")
+}
+
+// GetByCategoryID возвращает треды раздела без подразделов
+func (r *threadRepository) GetByCategoryID(categoryID int) ([]*models.Thread, error) {
+	return r.queryThreads("WHERE t.category_id = $1", categoryID)
+}
+
+// queryThreads выбирает треды с авторами и разделами, отфильтрованные условием where
+func (r *threadRepository) queryThreads(where string, args ...interface{}) ([]*models.Thread, error) {
 	query := `
-		SELECT t.id, t.title, t.author_id, t.created_at, t.updated_at, u.username as author_name
+		SELECT t.id, t.title, t.author_id, t.created_at, t.updated_at, u.username as author_name,
+			t.post_count, t.comment_count, t.participant_count, t.view_count, t.last_post_at, t.last_post_author_id,
+			COALESCE(lu.username, '') as last_post_author_name,
+			t.category_id, COALESCE(c.name, '') as category_name,
+			t.locked_at, t.locked_by, COALESCE(lb.username, '') as locked_by_name, t.lock_reason,
+			` + threadPinnedExpr + ` as pinned, t.pin_order, t.pinned_at, t.pinned_until,
+			t.type, t.accepted_post_id
 		FROM threads t
 		LEFT JOIN users u ON t.author_id = u.id
-		ORDER BY t.created_at DESC
+		LEFT JOIN users lu ON t.last_post_author_id = lu.id
+		LEFT JOIN categories c ON t.category_id = c.id
+		LEFT JOIN users lb ON t.locked_by = lb.id
+		` + where + `
+		ORDER BY ` + threadPinnedExpr + ` DESC,
+			CASE WHEN ` + threadPinnedExpr + ` THEN t.pin_order END ASC,
+			t.created_at DESC
 	`
 	
-	rows, err := r.db.Query(query)
+	rows, err := r.db.Query(query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("ошибка при получении тредов: %v", err)
 	}
@@ -287,6 +440,8 @@ func (r *threadRepository) GetAllThreads() ([]*models.Thread, error) {
 	var threads []*models.Thread
 	for rows.Next() {
 		thread := &models.Thread{}
+		var lastPostAt, lockedAt, pinnedAt, pinnedUntil sql.NullTime
+		var lastPostAuthorID, categoryID, lockedBy, acceptedPostID sql.NullInt64
 		err := rows.Scan(
 			&thread.ID,
 			&thread.Title,
@@ -294,10 +449,35 @@ func (r *threadRepository) GetAllThreads() ([]*models.Thread, error) {
 			&thread.CreatedAt,
 			&thread.UpdatedAt,
 			&thread.AuthorName,
+			&thread.PostCount,
+			&thread.CommentCount,
+			&thread.ParticipantCount,
+			&thread.ViewCount,
+			&lastPostAt,
+			&lastPostAuthorID,
+			&thread.LastPostAuthorName,
+			&categoryID,
+			&thread.CategoryName,
+			&lockedAt,
+			&lockedBy,
+			&thread.LockedByName,
+			&thread.LockReason,
+			&thread.Pinned,
+			&thread.PinOrder,
+			&pinnedAt,
+			&pinnedUntil,
+			&thread.Type,
+			&acceptedPostID,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("ошибка при сканировании треда: %v", err)
 		}
+		fillLastPost(thread, lastPostAt, lastPostAuthorID)
+		fillLock(thread, lockedAt, lockedBy)
+		fillAnswer(thread, acceptedPostID)
+		thread.PinnedAt = timePtr(pinnedAt)
+		thread.PinnedUntil = timePtr(pinnedUntil)
+		thread.CategoryID = intPtr(categoryID)
 		threads = append(threads, thread)
 	}
 
@@ -307,3 +487,38 @@ func (r *threadRepository) GetAllThreads() ([]*models.Thread, error) {
 
 	return threads, nil
 }
+
+// fillLock заполняет сведения о закрытии треда из nullable-колонок
+func fillLock(thread *models.Thread, lockedAt sql.NullTime, lockedBy sql.NullInt64) {
+	thread.Locked = lockedAt.Valid
+	thread.LockedAt = timePtr(lockedAt)
+	thread.LockedByID = intPtr(lockedBy)
+}
+
+// fillAnswer заполняет принятый ответ треда-вопроса из nullable-колонки
+func fillAnswer(thread *models.Thread, acceptedPostID sql.NullInt64) {
+	thread.AcceptedPostID = intPtr(acceptedPostID)
+	thread.Solved = acceptedPostID.Valid
+}
+
+// threadType возвращает тип треда для сохранения, пустой тип - обсуждение
+func threadType(value string) string {
+	if value == "" {
+		return models.ThreadTypeDiscussion
+	}
+	return value
+}
+
+// timePtr переводит nullable-колонку времени в указатель
+func timePtr(value sql.NullTime) *time.Time {
+	if !value.Valid {
+		return nil
+	}
+	at := value.Time
+	return &at
+}
+
+// pinActive повторяет threadPinnedExpr для тредов, прочитанных не из базы
+func pinActive(thread *models.Thread, now time.Time) bool {
+	return thread.PinnedAt != nil && (thread.PinnedUntil == nil || thread.PinnedUntil.After(now))
+}
@@ -1,7 +1,7 @@
 package repository
 
 import (
-	_"database/sql"
+	"database/sql"
 	"ForumService/internal/models"
 	"testing"
 	"time"
@@ -34,13 +34,14 @@ func TestThreadRepository_Create(t *testing.T) {
 	}
 
 	mock.ExpectQuery("INSERT INTO threads").
-		WithArgs(thread.Title, thread.AuthorID).
-		WillReturnRows(sqlmock.NewRows([]string{"id", "title", "author_id", "created_at", "updated_at"}).
-			AddRow(1, thread.Title, thread.AuthorID, time.Now(), time.Now()))
+		WithArgs(thread.Title, thread.AuthorID, nil, models.ThreadTypeDiscussion).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "title", "author_id", "created_at", "updated_at", "participant_count", "type"}).
+			AddRow(1, thread.Title, thread.AuthorID, time.Now(), time.Now(), 1, models.ThreadTypeDiscussion))
 
 	err := repo.Create(thread)
 	require.NoError(t, err)
 	assert.Equal(t, 1, thread.ID)
+	assert.Equal(t, 1, thread.ParticipantCount)
 	assert.Equal(t, "Test Thread", thread.Title)
 	assert.Equal(t, 1, thread.AuthorID)
 }
@@ -57,16 +58,61 @@ func TestThreadRepository_GetByID(t *testing.T) {
 		UpdatedAt: time.Now(),
 	}
 
-	mock.ExpectQuery("SELECT id, title, author_id, created_at, updated_at FROM threads WHERE id = \\$1").
+	mock.ExpectQuery("SELECT t.id, t.title, t.author_id, t.created_at, t.updated_at, t.version, t.post_count, t.comment_count, t.participant_count, t.view_count, t.last_post_at, t.last_post_author_id, t.category_id, COALESCE\\(c.name, ''\\), t.locked_at, t.locked_by, COALESCE\\(lb.username, ''\\), t.lock_reason, \\(t.pinned_at IS NOT NULL AND \\(t.pinned_until IS NULL OR t.pinned_until > CURRENT_TIMESTAMP\\)\\), t.pin_order, t.pinned_at, t.pinned_until, t.type, t.accepted_post_id FROM threads t LEFT JOIN categories c ON t.category_id = c.id LEFT JOIN users lb ON t.locked_by = lb.id WHERE t.id = \\$1").
 		WithArgs(1).
-		WillReturnRows(sqlmock.NewRows([]string{"id", "title", "author_id", "created_at", "updated_at"}).
-			AddRow(expectedThread.ID, expectedThread.Title, expectedThread.AuthorID, expectedThread.CreatedAt, expectedThread.UpdatedAt))
+		WillReturnRows(sqlmock.NewRows([]string{"id", "title", "author_id", "created_at", "updated_at", "version", "post_count", "comment_count", "participant_count", "view_count", "last_post_at", "last_post_author_id", "category_id", "category_name", "locked_at", "locked_by", "locked_by_name", "lock_reason", "pinned", "pin_order", "pinned_at", "pinned_until", "type", "accepted_post_id"}).
+			AddRow(expectedThread.ID, expectedThread.Title, expectedThread.AuthorID, expectedThread.CreatedAt, expectedThread.UpdatedAt, 2, 4, 9, 3, 17, expectedThread.UpdatedAt, 2, 5, "Go", nil, nil, "", "", false, 0, nil, nil, "discussion", nil))
 
 	thread, err := repo.GetByID(1)
 	require.NoError(t, err)
 	assert.Equal(t, expectedThread.ID, thread.ID)
 	assert.Equal(t, expectedThread.Title, thread.Title)
 	assert.Equal(t, expectedThread.AuthorID, thread.AuthorID)
+	assert.Equal(t, 4, thread.PostCount)
+	assert.Equal(t, 9, thread.CommentCount)
+	assert.Equal(t, 3, thread.ParticipantCount)
+	assert.Equal(t, 17, thread.ViewCount)
+	require.NotNil(t, thread.LastPostAt)
+	assert.True(t, expectedThread.UpdatedAt.Equal(*thread.LastPostAt))
+	require.NotNil(t, thread.LastPostAuthorID)
+	assert.Equal(t, 2, *thread.LastPostAuthorID)
+	require.NotNil(t, thread.CategoryID)
+	assert.Equal(t, 5, *thread.CategoryID)
+	assert.Equal(t, "Go", thread.CategoryName)
+}
+
+func TestThreadRepository_GetByID_WithoutPosts(t *testing.T) {
+	repo, mock, cleanup := setupThreadRepositoryTest(t)
+	defer cleanup()
+
+	mock.ExpectQuery("SELECT t.id, t.title, t.author_id, t.created_at, t.updated_at, t.version, t.post_count, t.comment_count, t.participant_count, t.view_count, t.last_post_at, t.last_post_author_id, t.category_id, COALESCE\\(c.name, ''\\), t.locked_at, t.locked_by, COALESCE\\(lb.username, ''\\), t.lock_reason, \\(t.pinned_at IS NOT NULL AND \\(t.pinned_until IS NULL OR t.pinned_until > CURRENT_TIMESTAMP\\)\\), t.pin_order, t.pinned_at, t.pinned_until, t.type, t.accepted_post_id FROM threads t LEFT JOIN categories c ON t.category_id = c.id LEFT JOIN users lb ON t.locked_by = lb.id WHERE t.id = \\$1").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "title", "author_id", "created_at", "updated_at", "version", "post_count", "comment_count", "participant_count", "view_count", "last_post_at", "last_post_author_id", "category_id", "category_name", "locked_at", "locked_by", "locked_by_name", "lock_reason", "pinned", "pin_order", "pinned_at", "pinned_until", "type", "accepted_post_id"}).
+			AddRow(1, "Test Thread", 1, time.Now(), time.Now(), 1, 0, 0, 1, 0, nil, nil, nil, "", nil, nil, "", "", false, 0, nil, nil, "discussion", nil))
+
+	thread, err := repo.GetByID(1)
+	require.NoError(t, err)
+	assert.Equal(t, 0, thread.PostCount)
+	assert.Nil(t, thread.LastPostAt)
+	assert.Nil(t, thread.LastPostAuthorID)
+	assert.Nil(t, thread.CategoryID)
+	assert.False(t, thread.Locked)
+	assert.Nil(t, thread.LockedAt)
+	assert.Nil(t, thread.LockedByID)
+}
+
+func TestThreadRepository_GetByID_NotFound(t *testing.T) {
+	repo, mock, cleanup := setupThreadRepositoryTest(t)
+	defer cleanup()
+
+	mock.ExpectQuery("SELECT t.id, t.title, t.author_id, t.created_at, t.updated_at, t.version, t.post_count, t.comment_count, t.participant_count, t.view_count, t.last_post_at, t.last_post_author_id, t.category_id, COALESCE\\(c.name, ''\\), t.locked_at, t.locked_by, COALESCE\\(lb.username, ''\\), t.lock_reason, \\(t.pinned_at IS NOT NULL AND \\(t.pinned_until IS NULL OR t.pinned_until > CURRENT_TIMESTAMP\\)\\), t.pin_order, t.pinned_at, t.pinned_until, t.type, t.accepted_post_id FROM threads t LEFT JOIN categories c ON t.category_id = c.id LEFT JOIN users lb ON t.locked_by = lb.id WHERE t.id = \\$1").
+		WithArgs(1).
+		WillReturnError(sql.ErrNoRows)
+
+	thread, err := repo.GetByID(1)
+	assert.Nil(t, thread)
+	assert.ErrorIs(t, err, ErrThreadNotFound)
+	assert.ErrorIs(t, err, ErrNotFound)
 }
 
 func TestThreadRepository_Update(t *testing.T) {
@@ -78,12 +124,130 @@ func TestThreadRepository_Update(t *testing.T) {
 		Title: "Updated Thread",
 	}
 
-	mock.ExpectExec("UPDATE threads SET title = \\$1, updated_at = CURRENT_TIMESTAMP WHERE id = \\$2").
-		WithArgs(thread.Title, thread.ID).
-		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery("UPDATE threads SET title = \\$1, category_id = \\$4, type = \\$5, version = version \\+ 1, updated_at = CURRENT_TIMESTAMP WHERE id = \\$2 AND \\(\\$3 = 0 OR version = \\$3\\) RETURNING version, updated_at").
+		WithArgs(thread.Title, thread.ID, 0, nil, "discussion").
+		WillReturnRows(sqlmock.NewRows([]string{"version", "updated_at"}).AddRow(2, time.Now()))
 
 	err := repo.Update(thread)
 	require.NoError(t, err)
+	assert.Equal(t, 2, thread.Version)
+}
+
+func TestThreadRepository_Update_VersionConflict(t *testing.T) {
+	repo, mock, cleanup := setupThreadRepositoryTest(t)
+	defer cleanup()
+
+	thread := &models.Thread{
+		ID:      1,
+		Title:   "Updated Thread",
+		Version: 1,
+	}
+
+	mock.ExpectQuery("UPDATE threads SET title = \\$1").
+		WithArgs(thread.Title, thread.ID, 1, nil, "discussion").
+		WillReturnError(sql.ErrNoRows)
+	mock.ExpectQuery("SELECT EXISTS\\(SELECT 1 FROM threads WHERE id = \\$1\\)").
+		WithArgs(thread.ID).
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+
+	err := repo.Update(thread)
+	assert.ErrorIs(t, err, ErrVersionConflict)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestThreadRepository_Update_NotFound(t *testing.T) {
+	repo, mock, cleanup := setupThreadRepositoryTest(t)
+	defer cleanup()
+
+	thread := &models.Thread{ID: 1, Title: "Updated Thread"}
+
+	mock.ExpectQuery("UPDATE threads SET title = \\$1").
+		WithArgs(thread.Title, thread.ID, 0, nil, "discussion").
+		WillReturnError(sql.ErrNoRows)
+	mock.ExpectQuery("SELECT EXISTS\\(SELECT 1 FROM threads WHERE id = \\$1\\)").
+		WithArgs(thread.ID).
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+
+	err := repo.Update(thread)
+	assert.ErrorIs(t, err, ErrThreadNotFound)
+}
+
+func TestThreadRepository_Lock(t *testing.T) {
+	repo, mock, cleanup := setupThreadRepositoryTest(t)
+	defer cleanup()
+
+	mock.ExpectExec("UPDATE threads SET locked_at = CURRENT_TIMESTAMP, locked_by = \\$2, lock_reason = \\$3, version = version \\+ 1 WHERE id = \\$1").
+		WithArgs(1, 2, "Вопрос решен").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("UPDATE threads SET locked_at").
+		WithArgs(42, 2, "").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	require.NoError(t, repo.Lock(1, 2, "Вопрос решен"))
+	assert.ErrorIs(t, repo.Lock(42, 2, ""), ErrThreadNotFound)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestThreadRepository_Unlock(t *testing.T) {
+	repo, mock, cleanup := setupThreadRepositoryTest(t)
+	defer cleanup()
+
+	mock.ExpectExec("UPDATE threads SET locked_at = NULL, locked_by = NULL, lock_reason = '', version = version \\+ 1 WHERE id = \\$1").
+		WithArgs(1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	require.NoError(t, repo.Unlock(1))
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestThreadRepository_Pin(t *testing.T) {
+	repo, mock, cleanup := setupThreadRepositoryTest(t)
+	defer cleanup()
+
+	until := time.Now().Add(24 * time.Hour)
+	mock.ExpectExec("UPDATE threads SET pinned_at = CURRENT_TIMESTAMP, pin_order = \\$2, pinned_until = \\$3, version = version \\+ 1 WHERE id = \\$1").
+		WithArgs(1, 2, &until).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("UPDATE threads SET pinned_at").
+		WithArgs(42, 0, nil).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	require.NoError(t, repo.Pin(1, 2, &until))
+	assert.ErrorIs(t, repo.Pin(42, 0, nil), ErrThreadNotFound)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestThreadRepository_Unpin(t *testing.T) {
+	repo, mock, cleanup := setupThreadRepositoryTest(t)
+	defer cleanup()
+
+	mock.ExpectExec("UPDATE threads SET pinned_at = NULL, pin_order = 0, pinned_until = NULL, version = version \\+ 1 WHERE id = \\$1").
+		WithArgs(1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	require.NoError(t, repo.Unpin(1))
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestThreadRepository_SetAcceptedPost(t *testing.T) {
+	repo, mock, cleanup := setupThreadRepositoryTest(t)
+	defer cleanup()
+
+	postID := 3
+	mock.ExpectExec("UPDATE threads SET accepted_post_id = \\$2, version = version \\+ 1 WHERE id = \\$1").
+		WithArgs(1, &postID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("UPDATE threads SET accepted_post_id").
+		WithArgs(1, &postID).
+		WillReturnError(&pq.Error{Code: "23503"})
+	mock.ExpectExec("UPDATE threads SET accepted_post_id").
+		WithArgs(42, nil).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	assert.NoError(t, repo.SetAcceptedPost(1, &postID))
+	assert.ErrorIs(t, repo.SetAcceptedPost(1, &postID), ErrPostNotFound)
+	assert.ErrorIs(t, repo.SetAcceptedPost(42, nil), ErrThreadNotFound)
+	require.NoError(t, mock.ExpectationsWereMet())
 }
 
 func TestThreadRepository_Delete(t *testing.T) {
@@ -98,6 +262,18 @@ func TestThreadRepository_Delete(t *testing.T) {
 	require.NoError(t, err)
 }
 
+func TestThreadRepository_Delete_NotFound(t *testing.T) {
+	repo, mock, cleanup := setupThreadRepositoryTest(t)
+	defer cleanup()
+
+	mock.ExpectExec("DELETE FROM threads WHERE id = \\$1").
+		WithArgs(1).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	err := repo.Delete(1)
+	assert.ErrorIs(t, err, ErrThreadNotFound)
+}
+
 func TestThreadRepository_GetAllThreads(t *testing.T) {
 	repo, mock, cleanup := setupThreadRepositoryTest(t)
 	defer cleanup()
@@ -121,12 +297,12 @@ func TestThreadRepository_GetAllThreads(t *testing.T) {
 		},
 	}
 
-	rows := sqlmock.NewRows([]string{"id", "title", "author_id", "created_at", "updated_at", "author_name"})
+	rows := sqlmock.NewRows([]string{"id", "title", "author_id", "created_at", "updated_at", "author_name", "post_count", "comment_count", "participant_count", "view_count", "last_post_at", "last_post_author_id", "last_post_author_name", "category_id", "category_name", "locked_at", "locked_by", "locked_by_name", "lock_reason", "pinned", "pin_order", "pinned_at", "pinned_until", "type", "accepted_post_id"})
 	for _, thread := range expectedThreads {
-		rows.AddRow(thread.ID, thread.Title, thread.AuthorID, thread.CreatedAt, thread.UpdatedAt, thread.AuthorName)
+		rows.AddRow(thread.ID, thread.Title, thread.AuthorID, thread.CreatedAt, thread.UpdatedAt, thread.AuthorName, thread.ID, 0, 1, 0, thread.CreatedAt, thread.AuthorID, thread.AuthorName, nil, "", nil, nil, "", "", false, 0, nil, nil, "discussion", nil)
 	}
 
-	mock.ExpectQuery("SELECT t.id, t.title, t.author_id, t.created_at, t.updated_at, u.username as author_name FROM threads t LEFT JOIN users u ON t.author_id = u.id ORDER BY t.created_at DESC").
+	mock.ExpectQuery("SELECT t.id, t.title, t.author_id, t.created_at, t.updated_at, u.username as author_name, t.post_count, t.comment_count, t.participant_count, t.view_count, t.last_post_at, t.last_post_author_id, COALESCE\\(lu.username, ''\\) as last_post_author_name, t.category_id, COALESCE\\(c.name, ''\\) as category_name, t.locked_at, t.locked_by, COALESCE\\(lb.username, ''\\) as locked_by_name, t.lock_reason, \\(t.pinned_at IS NOT NULL AND \\(t.pinned_until IS NULL OR t.pinned_until > CURRENT_TIMESTAMP\\)\\) as pinned, t.pin_order, t.pinned_at, t.pinned_until, t.type, t.accepted_post_id FROM threads t LEFT JOIN users u ON t.author_id = u.id LEFT JOIN users lu ON t.last_post_author_id = lu.id LEFT JOIN categories c ON t.category_id = c.id LEFT JOIN users lb ON t.locked_by = lb.id ORDER BY \\(t.pinned_at IS NOT NULL AND \\(t.pinned_until IS NULL OR t.pinned_until > CURRENT_TIMESTAMP\\)\\) DESC, CASE WHEN \\(t.pinned_at IS NOT NULL AND \\(t.pinned_until IS NULL OR t.pinned_until > CURRENT_TIMESTAMP\\)\\) THEN t.pin_order END ASC, t.created_at DESC").
 		WillReturnRows(rows)
 
 	threads, err := repo.GetAllThreads()
@@ -137,9 +313,44 @@ func TestThreadRepository_GetAllThreads(t *testing.T) {
 		assert.Equal(t, expectedThreads[i].Title, thread.Title)
 		assert.Equal(t, expectedThreads[i].AuthorID, thread.AuthorID)
 		assert.Equal(t, expectedThreads[i].AuthorName, thread.AuthorName)
+		assert.Equal(t, expectedThreads[i].ID, thread.PostCount)
+		assert.Equal(t, expectedThreads[i].AuthorName, thread.LastPostAuthorName)
 	}
 }
 
+func TestThreadRepository_GetByCategoryID(t *testing.T) {
+	repo, mock, cleanup := setupThreadRepositoryTest(t)
+	defer cleanup()
+
+	rows := sqlmock.NewRows([]string{"id", "title", "author_id", "created_at", "updated_at", "author_name", "post_count", "comment_count", "participant_count", "view_count", "last_post_at", "last_post_author_id", "last_post_author_name", "category_id", "category_name", "locked_at", "locked_by", "locked_by_name", "lock_reason", "pinned", "pin_order", "pinned_at", "pinned_until", "type", "accepted_post_id"}).
+		AddRow(1, "Thread 1", 1, time.Now(), time.Now(), "Test User 1", 2, 0, 1, 0, nil, nil, "", 5, "Go", time.Now(), 3, "moder", "Оффтоп", true, 1, time.Now(), nil, "question", 7)
+
+	mock.ExpectQuery("FROM threads t .* LEFT JOIN users lb ON t.locked_by = lb.id WHERE t.category_id = \\$1 ORDER BY .* t.pin_order END ASC, t.created_at DESC").
+		WithArgs(5).
+		WillReturnRows(rows)
+
+	threads, err := repo.GetByCategoryID(5)
+	require.NoError(t, err)
+	require.Len(t, threads, 1)
+	require.NotNil(t, threads[0].CategoryID)
+	assert.Equal(t, 5, *threads[0].CategoryID)
+	assert.Equal(t, "Go", threads[0].CategoryName)
+	assert.True(t, threads[0].Locked)
+	require.NotNil(t, threads[0].LockedByID)
+	assert.Equal(t, 3, *threads[0].LockedByID)
+	assert.Equal(t, "moder", threads[0].LockedByName)
+	assert.Equal(t, "Оффтоп", threads[0].LockReason)
+	assert.True(t, threads[0].Pinned)
+	assert.Equal(t, 1, threads[0].PinOrder)
+	assert.NotNil(t, threads[0].PinnedAt)
+	assert.Nil(t, threads[0].PinnedUntil)
+	assert.Equal(t, models.ThreadTypeQuestion, threads[0].Type)
+	require.NotNil(t, threads[0].AcceptedPostID)
+	assert.Equal(t, 7, *threads[0].AcceptedPostID)
+	assert.True(t, threads[0].Solved)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
 func TestThreadRepository_GetThreadWithPosts(t *testing.T) {
 	repo, mock, cleanup := setupThreadRepositoryTest(t)
 	defer cleanup()
@@ -173,12 +384,12 @@ func TestThreadRepository_GetThreadWithPosts(t *testing.T) {
 		},
 	}
 
-	postRows := sqlmock.NewRows([]string{"id", "thread_id", "user_id", "content", "created_at", "updated_at"})
+	postRows := sqlmock.NewRows([]string{"id", "thread_id", "author_id", "content", "created_at", "updated_at"})
 	for _, post := range expectedPosts {
 		postRows.AddRow(post.ID, post.ThreadID, post.AuthorID, post.Content, post.CreatedAt, post.UpdatedAt)
 	}
 
-	mock.ExpectQuery("SELECT id, thread_id, user_id, content, created_at, updated_at FROM posts WHERE thread_id = \\$1 ORDER BY created_at DESC").
+	mock.ExpectQuery("SELECT id, thread_id, author_id, content, created_at, updated_at FROM posts WHERE thread_id = \\$1 ORDER BY created_at DESC").
 		WithArgs(1).
 		WillReturnRows(postRows)
 
@@ -195,14 +406,14 @@ func TestThreadRepository_GetThreadWithPosts(t *testing.T) {
 		},
 	}
 
-	commentRows := sqlmock.NewRows([]string{"id", "post_id", "user_id", "content", "created_at"})
+	commentRows := sqlmock.NewRows([]string{"id", "post_id", "author_id", "content", "created_at"})
 	for _, comments := range expectedComments {
 		for _, comment := range comments {
 			commentRows.AddRow(comment.ID, comment.PostID, comment.AuthorID, comment.Content, comment.CreatedAt)
 		}
 	}
 
-	mock.ExpectQuery("SELECT id, post_id, user_id, content, created_at FROM comments WHERE post_id = ANY\\(\\$1\\) ORDER BY created_at ASC").
+	mock.ExpectQuery("SELECT id, post_id, author_id, content, created_at FROM comments WHERE post_id = ANY\\(\\$1\\) ORDER BY created_at ASC").
 		WithArgs(pq.Array([]int{1})).
 		WillReturnRows(commentRows)
 
@@ -21,6 +21,9 @@ func (r *userRepository) SaveUser(user *models.User) error {
 	if err != nil {
 		log := logger.GetLogger()
 		log.Error("ERROR IN USER REPO 1")
+		if isUniqueViolation(err) {
+			return ErrAlreadyExists
+		}
 		return err
 	}
 	return nil
@@ -39,7 +42,7 @@ func (r *userRepository) GetUserByID(id int) (*models.User, error) {
 		&user.Email,
 	)
 	if err == sql.ErrNoRows {
-		return nil, fmt.Errorf("пользователь с id %d не найден", id)
+		return nil, ErrUserNotFound
 	}
 	if err != nil {
 		return nil, fmt.Errorf("ошибка при получении пользователя: %w", err)
@@ -60,13 +63,16 @@ func (r *userRepository) GetUserByUsername(username string) (*models.User, error
 	if err != nil {
 		log := logger.GetLogger()
 		log.Error("ERROR IN USER REPO 2")
+		if err == sql.ErrNoRows {
+			return nil, ErrUserNotFound
+		}
 		return nil, err
 	}
 	return &user, nil
 }
 
 func (r *userRepository) GetUserPosts(userID int) ([]*models.Post, error) {
-	query := `SELECT id, thread_id, author_id, content, created_at, updated_at FROM posts WHERE author_id = $1`
+	query := `SELECT id, thread_id, author_id, title, content, created_at, updated_at FROM posts WHERE author_id = $1`
 	rows, err := r.db.Query(query, userID)
 	if err != nil {
 		return nil, fmt.Errorf("ошибка при получении постов пользователя: %w", err)
@@ -80,6 +86,7 @@ func (r *userRepository) GetUserPosts(userID int) ([]*models.Post, error) {
 			&post.ID,
 			&post.ThreadID,
 			&post.AuthorID,
+			&post.Title,
 			&post.Content,
 			&post.CreatedAt,
 			&post.UpdatedAt,
@@ -107,6 +114,9 @@ func (r *userRepository) GetUserRole(userID int) (string, error) {
 	err := r.db.QueryRow("SELECT role FROM users WHERE id = $1", userID).Scan(&role)
 	if err != nil {
 		fmt.Printf("Debug - UserRepository.GetUserRole - Error: %v\n", err)
+		if err == sql.ErrNoRows {
+			return "", ErrUserNotFound
+		}
 		return "", fmt.Errorf("couldn't get user role: %w", err)
 	}
 	fmt.Printf("Debug - UserRepository.GetUserRole - User ID: %d, Role: %s\n", userID, role)
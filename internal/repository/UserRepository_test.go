@@ -1,13 +1,14 @@
 package repository
 
 import (
-	_"database/sql"
+	"database/sql"
 	"ForumService/internal/models"
 	"testing"
 	"time"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
 )
 
 func setupUserRepositoryTest(t *testing.T) (*userRepository, sqlmock.Sqlmock, func()) {
@@ -41,6 +42,23 @@ func TestUserRepository_SaveUser(t *testing.T) {
 	assert.Equal(t, 1, user.ID)
 }
 
+func TestUserRepository_SaveUser_Duplicate(t *testing.T) {
+	repo, mock, cleanup := setupUserRepositoryTest(t)
+	defer cleanup()
+
+	user := &models.User{
+		Username: "testuser",
+		Email:    "test@example.com",
+	}
+
+	mock.ExpectQuery("INSERT INTO users").
+		WithArgs(user.Username, user.Email).
+		WillReturnError(&pq.Error{Code: "23505"})
+
+	err := repo.SaveUser(user)
+	assert.ErrorIs(t, err, ErrAlreadyExists)
+}
+
 func TestUserRepository_GetUserByID(t *testing.T) {
 	repo, mock, cleanup := setupUserRepositoryTest(t)
 	defer cleanup()
@@ -63,6 +81,19 @@ func TestUserRepository_GetUserByID(t *testing.T) {
 	assert.Equal(t, expectedUser.Email, user.Email)
 }
 
+func TestUserRepository_GetUserByID_NotFound(t *testing.T) {
+	repo, mock, cleanup := setupUserRepositoryTest(t)
+	defer cleanup()
+
+	mock.ExpectQuery("SELECT id, username, email FROM users WHERE id = \\$1").
+		WithArgs(1).
+		WillReturnError(sql.ErrNoRows)
+
+	user, err := repo.GetUserByID(1)
+	assert.Nil(t, user)
+	assert.ErrorIs(t, err, ErrUserNotFound)
+}
+
 func TestUserRepository_GetUserByUsername(t *testing.T) {
 	repo, mock, cleanup := setupUserRepositoryTest(t)
 	defer cleanup()
@@ -108,12 +139,12 @@ func TestUserRepository_GetUserPosts(t *testing.T) {
 		},
 	}
 
-	rows := sqlmock.NewRows([]string{"id", "thread_id", "author_id", "content", "created_at", "updated_at"})
+	rows := sqlmock.NewRows([]string{"id", "thread_id", "author_id", "title", "content", "created_at", "updated_at"})
 	for _, post := range expectedPosts {
-		rows.AddRow(post.ID, post.ThreadID, post.AuthorID, post.Content, post.CreatedAt, post.UpdatedAt)
+		rows.AddRow(post.ID, post.ThreadID, post.AuthorID, post.Title, post.Content, post.CreatedAt, post.UpdatedAt)
 	}
 
-	mock.ExpectQuery("SELECT id, thread_id, author_id, content, created_at, updated_at FROM posts WHERE author_id = \\$1").
+	mock.ExpectQuery("SELECT id, thread_id, author_id, title, content, created_at, updated_at FROM posts WHERE author_id = \\$1").
 		WithArgs(1).
 		WillReturnRows(rows)
 
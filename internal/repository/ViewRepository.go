@@ -0,0 +1,68 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/lib/pq"
+)
+
+type viewRepository struct {
+	db *sql.DB
+}
+
+func NewViewRepository(db *sql.DB) ViewRepository {
+	return &viewRepository{db: db}
+}
+
+// AddViews сбрасывает накопленные просмотры одной транзакцией: по одному UPDATE на треды и на посты.
+// Версии записей не меняются, так как просмотр не считается правкой.
+func (r *viewRepository) AddViews(threadViews map[int]int, postViews map[int]int) error {
+	if len(threadViews) == 0 && len(postViews) == 0 {
+		return nil
+	}
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("ошибка при начале транзакции: %w", err)
+	}
+	defer tx.Rollback()
+
+	if len(threadViews) > 0 {
+		ids, counts := splitViews(threadViews)
+		const query = `
+			UPDATE threads t SET view_count = t.view_count + v.views
+			FROM (SELECT unnest($1::int[]) AS id, unnest($2::int[]) AS views) v
+			WHERE t.id = v.id`
+		if _, err = tx.Exec(query, pq.Array(ids), pq.Array(counts)); err != nil {
+			return fmt.Errorf("ошибка при обновлении просмотров тредов: %w", err)
+		}
+	}
+
+	if len(postViews) > 0 {
+		ids, counts := splitViews(postViews)
+		const query = `
+			UPDATE posts p SET view_count = p.view_count + v.views
+			FROM (SELECT unnest($1::int[]) AS id, unnest($2::int[]) AS views) v
+			WHERE p.id = v.id`
+		if _, err = tx.Exec(query, pq.Array(ids), pq.Array(counts)); err != nil {
+			return fmt.Errorf("ошибка при обновлении просмотров постов: %w", err)
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("ошибка при фиксации транзакции: %w", err)
+	}
+	return nil
+}
+
+// splitViews раскладывает просмотры на параллельные массивы ID и приращений для unnest
+func splitViews(views map[int]int) ([]int, []int) {
+	ids := make([]int, 0, len(views))
+	counts := make([]int, 0, len(views))
+	for id, count := range views {
+		ids = append(ids, id)
+		counts = append(counts, count)
+	}
+	return ids, counts
+}
@@ -0,0 +1,65 @@
+package repository
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupViewRepositoryTest(t *testing.T) (ViewRepository, sqlmock.Sqlmock, func()) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+
+	return NewViewRepository(db), mock, func() { db.Close() }
+}
+
+func TestViewRepository_AddViews(t *testing.T) {
+	repo, mock, cleanup := setupViewRepositoryTest(t)
+	defer cleanup()
+
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE threads t SET view_count = t.view_count \\+ v.views FROM \\(SELECT unnest\\(\\$1::int\\[\\]\\) AS id, unnest\\(\\$2::int\\[\\]\\) AS views\\) v WHERE t.id = v.id").
+		WithArgs(pq.Array([]int{1}), pq.Array([]int{3})).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("UPDATE posts p SET view_count = p.view_count \\+ v.views FROM \\(SELECT unnest\\(\\$1::int\\[\\]\\) AS id, unnest\\(\\$2::int\\[\\]\\) AS views\\) v WHERE p.id = v.id").
+		WithArgs(pq.Array([]int{7}), pq.Array([]int{2})).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	require.NoError(t, repo.AddViews(map[int]int{1: 3}, map[int]int{7: 2}))
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestViewRepository_AddViews_OnlyThreads(t *testing.T) {
+	repo, mock, cleanup := setupViewRepositoryTest(t)
+	defer cleanup()
+
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE threads t SET view_count").
+		WithArgs(pq.Array([]int{1}), pq.Array([]int{1})).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	require.NoError(t, repo.AddViews(map[int]int{1: 1}, nil))
+	// Пустой буфер не открывает транзакцию
+	require.NoError(t, repo.AddViews(nil, map[int]int{}))
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestViewRepository_AddViews_Error(t *testing.T) {
+	repo, mock, cleanup := setupViewRepositoryTest(t)
+	defer cleanup()
+
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE threads t SET view_count").
+		WillReturnError(fmt.Errorf("database error"))
+	mock.ExpectRollback()
+
+	err := repo.AddViews(map[int]int{1: 1}, map[int]int{7: 1})
+	assert.Error(t, err)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
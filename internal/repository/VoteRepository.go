@@ -0,0 +1,117 @@
+package repository
+
+import (
+	"ForumService/internal/models"
+	"database/sql"
+	"fmt"
+)
+
+// voteTarget описывает таблицу записей, за которые голосуют, и таблицу их голосов
+type voteTarget struct {
+	table    string
+	votes    string
+	column   string
+	notFound error
+}
+
+var (
+	postVotes    = voteTarget{table: "posts", votes: "post_votes", column: "post_id", notFound: ErrPostNotFound}
+	commentVotes = voteTarget{table: "comments", votes: "comment_votes", column: "comment_id", notFound: ErrCommentNotFound}
+)
+
+type voteRepository struct {
+	db *sql.DB
+}
+
+func NewVoteRepository(db *sql.DB) VoteRepository {
+	return &voteRepository{db: db}
+}
+
+func (r *voteRepository) VotePost(postID, userID, value int) (models.VoteResult, error) {
+	return r.vote(postVotes, postID, userID, value)
+}
+
+func (r *voteRepository) UnvotePost(postID, userID int) (models.VoteResult, error) {
+	return r.vote(postVotes, postID, userID, 0)
+}
+
+func (r *voteRepository) VoteComment(commentID, userID, value int) (models.VoteResult, error) {
+	return r.vote(commentVotes, commentID, userID, value)
+}
+
+func (r *voteRepository) UnvoteComment(commentID, userID int) (models.VoteResult, error) {
+	return r.vote(commentVotes, commentID, userID, 0)
+}
+
+// vote заменяет голос пользователя на value (0 - отозвать) и сдвигает итоги записи на разницу
+// со старым голосом. Строка записи блокируется, поэтому конкурентные голоса не теряют итоги.
+func (r *voteRepository) vote(target voteTarget, id, userID, value int) (models.VoteResult, error) {
+	result := models.VoteResult{MyVote: value}
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return result, fmt.Errorf("ошибка при начале транзакции: %w", err)
+	}
+	defer tx.Rollback()
+
+	var lockedID int
+	err = tx.QueryRow(`SELECT id FROM `+target.table+` WHERE id = $1 FOR UPDATE`, id).Scan(&lockedID)
+	if err == sql.ErrNoRows {
+		return result, target.notFound
+	}
+	if err != nil {
+		return result, fmt.Errorf("ошибка при блокировке записи: %w", err)
+	}
+
+	var previous int
+	err = tx.QueryRow(`SELECT value FROM `+target.votes+` WHERE `+target.column+` = $1 AND user_id = $2`, id, userID).Scan(&previous)
+	if err != nil && err != sql.ErrNoRows {
+		return result, fmt.Errorf("ошибка при получении голоса: %w", err)
+	}
+
+	if value == 0 {
+		_, err = tx.Exec(`DELETE FROM `+target.votes+` WHERE `+target.column+` = $1 AND user_id = $2`, id, userID)
+	} else {
+		_, err = tx.Exec(`
+			INSERT INTO `+target.votes+` (`+target.column+`, user_id, value) VALUES ($1, $2, $3)
+			ON CONFLICT (`+target.column+`, user_id) DO UPDATE SET value = EXCLUDED.value, created_at = CURRENT_TIMESTAMP`,
+			id, userID, value)
+	}
+	if isForeignKeyViolation(err) {
+		return result, ErrUserNotFound
+	}
+	if err != nil {
+		return result, fmt.Errorf("ошибка при сохранении голоса: %w", err)
+	}
+
+	upDelta, downDelta := voteDelta(previous, value)
+	err = tx.QueryRow(`UPDATE `+target.table+` SET upvotes = upvotes + $2, downvotes = downvotes + $3 WHERE id = $1 RETURNING upvotes, downvotes`,
+		id, upDelta, downDelta).Scan(&result.Upvotes, &result.Downvotes)
+	if err != nil {
+		return result, fmt.Errorf("ошибка при обновлении итогов голосования: %w", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return result, fmt.Errorf("ошибка при фиксации транзакции: %w", err)
+	}
+	result.Score = result.Upvotes - result.Downvotes
+	return result, nil
+}
+
+// voteDelta возвращает сдвиг числа голосов за и против при замене голоса previous на value
+func voteDelta(previous, value int) (int, int) {
+	var up, down int
+	switch previous {
+	case 1:
+		up--
+	case -1:
+		down--
+	}
+	switch value {
+	case 1:
+		up++
+	case -1:
+		down++
+	}
+	return up, down
+}
@@ -0,0 +1,165 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"testing"
+
+	"ForumService/internal/models"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupVoteRepositoryTest(t *testing.T) (VoteRepository, sqlmock.Sqlmock, func()) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+
+	return NewVoteRepository(db), mock, func() { db.Close() }
+}
+
+func TestVoteRepository_VotePost_New(t *testing.T) {
+	repo, mock, cleanup := setupVoteRepositoryTest(t)
+	defer cleanup()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT id FROM posts WHERE id = \\$1 FOR UPDATE").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectQuery("SELECT value FROM post_votes WHERE post_id = \\$1 AND user_id = \\$2").
+		WithArgs(1, 2).
+		WillReturnError(sql.ErrNoRows)
+	mock.ExpectExec("INSERT INTO post_votes \\(post_id, user_id, value\\) VALUES \\(\\$1, \\$2, \\$3\\) ON CONFLICT \\(post_id, user_id\\) DO UPDATE SET value = EXCLUDED.value").
+		WithArgs(1, 2, 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery("UPDATE posts SET upvotes = upvotes \\+ \\$2, downvotes = downvotes \\+ \\$3 WHERE id = \\$1 RETURNING upvotes, downvotes").
+		WithArgs(1, 1, 0).
+		WillReturnRows(sqlmock.NewRows([]string{"upvotes", "downvotes"}).AddRow(4, 1))
+	mock.ExpectCommit()
+
+	result, err := repo.VotePost(1, 2, 1)
+	require.NoError(t, err)
+	assert.Equal(t, models.VoteResult{Upvotes: 4, Downvotes: 1, Score: 3, MyVote: 1}, result)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestVoteRepository_VoteComment_Change(t *testing.T) {
+	repo, mock, cleanup := setupVoteRepositoryTest(t)
+	defer cleanup()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT id FROM comments WHERE id = \\$1 FOR UPDATE").
+		WithArgs(5).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(5))
+	mock.ExpectQuery("SELECT value FROM comment_votes WHERE comment_id = \\$1 AND user_id = \\$2").
+		WithArgs(5, 2).
+		WillReturnRows(sqlmock.NewRows([]string{"value"}).AddRow(1))
+	mock.ExpectExec("INSERT INTO comment_votes").
+		WithArgs(5, 2, -1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery("UPDATE comments SET upvotes = upvotes \\+ \\$2, downvotes = downvotes \\+ \\$3").
+		WithArgs(5, -1, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"upvotes", "downvotes"}).AddRow(0, 1))
+	mock.ExpectCommit()
+
+	result, err := repo.VoteComment(5, 2, -1)
+	require.NoError(t, err)
+	assert.Equal(t, models.VoteResult{Upvotes: 0, Downvotes: 1, Score: -1, MyVote: -1}, result)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestVoteRepository_UnvotePost(t *testing.T) {
+	repo, mock, cleanup := setupVoteRepositoryTest(t)
+	defer cleanup()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT id FROM posts WHERE id = \\$1 FOR UPDATE").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectQuery("SELECT value FROM post_votes").
+		WithArgs(1, 2).
+		WillReturnRows(sqlmock.NewRows([]string{"value"}).AddRow(-1))
+	mock.ExpectExec("DELETE FROM post_votes WHERE post_id = \\$1 AND user_id = \\$2").
+		WithArgs(1, 2).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery("UPDATE posts SET upvotes").
+		WithArgs(1, 0, -1).
+		WillReturnRows(sqlmock.NewRows([]string{"upvotes", "downvotes"}).AddRow(2, 0))
+	mock.ExpectCommit()
+
+	result, err := repo.UnvotePost(1, 2)
+	require.NoError(t, err)
+	assert.Equal(t, models.VoteResult{Upvotes: 2, Downvotes: 0, Score: 2, MyVote: 0}, result)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestVoteRepository_VotePost_NotFound(t *testing.T) {
+	repo, mock, cleanup := setupVoteRepositoryTest(t)
+	defer cleanup()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT id FROM posts WHERE id = \\$1 FOR UPDATE").
+		WithArgs(99).
+		WillReturnError(sql.ErrNoRows)
+	mock.ExpectRollback()
+
+	_, err := repo.VotePost(99, 2, 1)
+	assert.ErrorIs(t, err, ErrPostNotFound)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestVoteRepository_VoteComment_UnknownUser(t *testing.T) {
+	repo, mock, cleanup := setupVoteRepositoryTest(t)
+	defer cleanup()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT id FROM comments WHERE id = \\$1 FOR UPDATE").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(5))
+	mock.ExpectQuery("SELECT value FROM comment_votes").
+		WillReturnError(sql.ErrNoRows)
+	mock.ExpectExec("INSERT INTO comment_votes").
+		WillReturnError(&pq.Error{Code: "23503"})
+	mock.ExpectRollback()
+
+	_, err := repo.VoteComment(5, 42, 1)
+	assert.ErrorIs(t, err, ErrUserNotFound)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestVoteRepository_VotePost_Error(t *testing.T) {
+	repo, mock, cleanup := setupVoteRepositoryTest(t)
+	defer cleanup()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT id FROM posts WHERE id = \\$1 FOR UPDATE").
+		WillReturnError(fmt.Errorf("database error"))
+	mock.ExpectRollback()
+
+	_, err := repo.VotePost(1, 2, 1)
+	assert.Error(t, err)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestVoteDelta(t *testing.T) {
+	tests := []struct {
+		previous, value int
+		up, down        int
+	}{
+		{0, 1, 1, 0},
+		{0, -1, 0, 1},
+		{1, 1, 0, 0},
+		{1, -1, -1, 1},
+		{-1, 1, 1, -1},
+		{1, 0, -1, 0},
+		{-1, 0, 0, -1},
+		{0, 0, 0, 0},
+	}
+
+	for _, tt := range tests {
+		up, down := voteDelta(tt.previous, tt.value)
+		assert.Equal(t, tt.up, up, "previous=%d value=%d", tt.previous, tt.value)
+		assert.Equal(t, tt.down, down, "previous=%d value=%d", tt.previous, tt.value)
+	}
+}
@@ -0,0 +1,75 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/lib/pq"
+)
+
+// ErrNotFound - базовая ошибка отсутствия записи. Все ошибки ниже оборачивают ее,
+// поэтому errors.Is(err, ErrNotFound) срабатывает для любой сущности.
+var ErrNotFound = errors.New("запись не найдена")
+
+// ErrAlreadyExists возвращается при нарушении ограничения уникальности
+var ErrAlreadyExists = errors.New("запись уже существует")
+
+// ErrVersionConflict возвращается, если запись изменили после того, как клиент ее прочитал
+var ErrVersionConflict = errors.New("версия записи не совпадает")
+
+var (
+	ErrThreadNotFound       = fmt.Errorf("тред не найден: %w", ErrNotFound)
+	ErrPostNotFound         = fmt.Errorf("пост не найден: %w", ErrNotFound)
+	ErrCommentNotFound      = fmt.Errorf("комментарий не найден: %w", ErrNotFound)
+	ErrUserNotFound         = fmt.Errorf("пользователь не найден: %w", ErrNotFound)
+	ErrRevisionNotFound     = fmt.Errorf("ревизия не найдена: %w", ErrNotFound)
+	ErrCategoryNotFound     = fmt.Errorf("раздел не найден: %w", ErrNotFound)
+	ErrTagNotFound          = fmt.Errorf("тег не найден: %w", ErrNotFound)
+	ErrMessageNotFound      = fmt.Errorf("сообщение не найдено: %w", ErrNotFound)
+	ErrBookmarkNotFound     = fmt.Errorf("закладка не найдена: %w", ErrNotFound)
+	ErrSubscriptionNotFound = fmt.Errorf("подписка не найдена: %w", ErrNotFound)
+	ErrNotificationNotFound = fmt.Errorf("уведомление не найдено: %w", ErrNotFound)
+	ErrAttachmentNotFound   = fmt.Errorf("вложение не найдено: %w", ErrNotFound)
+	ErrPollNotFound         = fmt.Errorf("опрос не найден: %w", ErrNotFound)
+	ErrPollOptionNotFound   = fmt.Errorf("вариант опроса не найден: %w", ErrNotFound)
+)
+
+// ErrCategoryNotEmpty возвращается при удалении раздела, в котором есть треды или подразделы
+var ErrCategoryNotEmpty = errors.New("в разделе есть треды или подразделы")
+
+// ErrThreadLocked возвращается при добавлении поста или комментария в закрытый тред
+var ErrThreadLocked = errors.New("тред закрыт")
+
+// ErrPollClosed возвращается при голосовании в закрытом опросе
+var ErrPollClosed = errors.New("опрос закрыт")
+
+// Коды ошибок PostgreSQL при нарушении ограничений уникальности и внешнего ключа
+const (
+	uniqueViolationCode     = "23505"
+	foreignKeyViolationCode = "23503"
+)
+
+// isUniqueViolation проверяет, что ошибка базы вызвана нарушением уникальности
+func isUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == uniqueViolationCode
+}
+
+// isForeignKeyViolation проверяет, что ошибка базы вызвана нарушением внешнего ключа
+func isForeignKeyViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == foreignKeyViolationCode
+}
+
+// checkRowsAffected возвращает notFound, если запрос не затронул ни одной строки
+func checkRowsAffected(result sql.Result, notFound error) error {
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return notFound
+	}
+	return nil
+}
@@ -1,12 +1,21 @@
 package repository
 
-import "ForumService/internal/models"
+import (
+	"ForumService/internal/models"
+	"time"
+)
 
 type CommentRepository interface {
 	SaveComment(comment *models.Comment) error
 	GetCommentByID(id int) (*models.Comment, error)
+	UpdateComment(comment *models.Comment) error
 	DeleteComment(id int) error
 	GetCommentsByPostID(postID int) ([]models.Comment, error)
+	HasReplies(id int) (bool, error)
+	TombstoneComment(id int) error
+	// RemoveComment атомарно удаляет комментарий без ответов или заменяет заглушкой
+	// комментарий с ответами; true означает, что осталась заглушка
+	RemoveComment(id int) (bool, error)
 }
 
 type ThreadRepository interface {
@@ -16,6 +25,14 @@ type ThreadRepository interface {
 	Delete(id int) error
 	GetAllThreads() ([]*models.Thread, error)
 	GetThreadWithPosts(threadID int) (*models.Thread, []models.Post, map[int][]models.Comment, error)
+	GetByCategoryID(categoryID int) ([]*models.Thread, error)
+	Lock(id int, userID int, reason string) error
+	Unlock(id int) error
+	// Pin закрепляет тред с порядком order до until, nil - бессрочно
+	Pin(id int, order int, until *time.Time) error
+	Unpin(id int) error
+	// SetAcceptedPost отмечает принятый ответ треда-вопроса, nil снимает отметку
+	SetAcceptedPost(id int, postID *int) error
 }
 
 type PostRepository interface {
@@ -23,9 +40,18 @@ type PostRepository interface {
 	GetPostByID(id int) (*models.Post, error)
 	GetPostWithComments(postID int) (*models.Post, []models.Comment, error)
 	GetPostsWithCommentsByThreadID(threadID int) ([]models.Post, map[int][]models.Comment, error)
-	UpdatePost(post *models.Post, postID int) error
+	UpdatePost(post *models.Post, postID int, editorID int, reason string) error
 	DeletePost(postID int) error
 	GetByThreadID(threadID int) ([]*models.Post, error)
+	GetPostRevisions(postID int) ([]models.PostRevision, error)
+	GetPostRevisionByID(postID int, revisionID int) (*models.PostRevision, error)
+	Pin(postID int) error
+	Unpin(postID int) error
+	// GetPostRefs возвращает ссылки на существующие посты из ids с ролью, нужной для чтения
+	// их раздела; удаленные посты пропускаются
+	GetPostRefs(ids []int) (map[int]models.PostRef, error)
+	// GetReplies возвращает ответы на посты postIDs по ID исходного поста, от ранних к поздним
+	GetReplies(postIDs []int) (map[int][]models.PostRef, error)
 }
 
 type UserRepository interface {
@@ -34,4 +60,156 @@ type UserRepository interface {
 	GetUserPosts(userID int) ([]*models.Post, error)
 	GetUserCommentCount(userID int) (int, error)
 	GetUserRole(userID int) (string, error)
-} 
\ No newline at end of file
+}
+
+type SearchRepository interface {
+	Search(params models.SearchParams) ([]models.SearchResult, int, error)
+}
+
+// CounterRepository восстанавливает денормализованные счетчики тредов и постов
+type CounterRepository interface {
+	RecountCounters() (models.CounterRecount, error)
+}
+
+// ViewRepository прибавляет накопленные просмотры к счетчикам тредов и постов.
+// Ключи - ID тредов и постов, значения - число новых просмотров; удаленные записи пропускаются.
+type ViewRepository interface {
+	AddViews(threadViews map[int]int, postViews map[int]int) error
+}
+
+// VoteRepository хранит голоса пользователей за посты и комментарии. Итоги голосования
+// хранятся в самих записях и меняются в одной транзакции с голосом.
+type VoteRepository interface {
+	// VotePost ставит или меняет голос value (1 или -1) пользователя за пост
+	VotePost(postID, userID, value int) (models.VoteResult, error)
+	// UnvotePost отзывает голос пользователя за пост, отсутствие голоса не ошибка
+	UnvotePost(postID, userID int) (models.VoteResult, error)
+	VoteComment(commentID, userID, value int) (models.VoteResult, error)
+	UnvoteComment(commentID, userID int) (models.VoteResult, error)
+}
+
+// ReactionRepository хранит реакции-эмодзи на посты, комментарии и сообщения чата.
+// Реакция определяется видом записи (models.ReactionTarget*), ее ID, пользователем и эмодзи.
+type ReactionRepository interface {
+	// Toggle ставит реакцию пользователя или снимает уже поставленную и сообщает, поставлена ли она
+	Toggle(targetType string, targetID, userID int, emoji string) (bool, error)
+	// GetCounts возвращает реакции на записи targetIDs, сгруппированные по эмодзи в порядке первой реакции.
+	// ReactedByMe отмечает реакции пользователя userID, записи без реакций в ответ не попадают.
+	GetCounts(targetType string, targetIDs []int, userID int) (map[int][]models.ReactionCount, error)
+}
+
+// BookmarkRepository хранит закладки пользователей на треды и посты.
+// Закладка определяется пользователем, видом записи (models.BookmarkTarget*) и ее ID.
+type BookmarkRepository interface {
+	// Save добавляет закладку или обновляет заметку уже существующей, заполняя CreatedAt
+	Save(bookmark *models.Bookmark) error
+	Delete(userID int, targetType string, targetID int) error
+	// GetByUser возвращает страницу закладок пользователя, новые первыми, и общее число закладок.
+	// У закладок на записи из разделов, которые роль role не может читать, Title и ThreadID пустые.
+	GetByUser(userID int, role models.Role, limit, offset int) ([]models.Bookmark, int, error)
+	// GetBookmarked отмечает записи из targetIDs, которые пользователь добавил в закладки
+	GetBookmarked(userID int, targetType string, targetIDs []int) (map[int]bool, error)
+}
+
+// SubscriptionRepository хранит подписки пользователей на треды и посты.
+// Подписка определяется пользователем, видом записи (models.SubscriptionTarget*) и ее ID.
+type SubscriptionRepository interface {
+	// Subscribe подписывает пользователя на запись, повторная подписка не ошибка
+	Subscribe(userID int, targetType string, targetID int) error
+	Unsubscribe(userID int, targetType string, targetID int) error
+	IsSubscribed(userID int, targetType string, targetID int) (bool, error)
+	// GetSubscribers возвращает ID подписчиков записи по возрастанию
+	GetSubscribers(targetType string, targetID int) ([]int, error)
+}
+
+// NotificationRepository хранит уведомления пользователей
+type NotificationRepository interface {
+	// SaveAll сохраняет уведомления в одной транзакции, заполняя ID и CreatedAt
+	SaveAll(notifications []*models.Notification) error
+	// GetByUser возвращает страницу уведомлений пользователя, новые первыми, и общее их число.
+	// При unreadOnly возвращаются только непрочитанные.
+	GetByUser(userID int, unreadOnly bool, limit, offset int) ([]models.Notification, int, error)
+	CountUnread(userID int) (int, error)
+	// MarkRead отмечает прочитанным уведомление пользователя, чужое уведомление не найдено
+	MarkRead(userID, notificationID int) error
+	// MarkAllRead отмечает прочитанными все уведомления пользователя и возвращает их число
+	MarkAllRead(userID int) (int, error)
+}
+
+// MentionRepository хранит упоминания пользователей в постах, комментариях и сообщениях чата.
+// Упоминание определяется видом записи (models.MentionTarget*), ее ID и пользователем.
+type MentionRepository interface {
+	// ResolveUsernames возвращает существующих пользователей с именами из usernames по алфавиту,
+	// неизвестные имена пропускаются
+	ResolveUsernames(usernames []string) ([]models.Mention, error)
+	// Replace заменяет упоминания записи пользователями userIDs и возвращает ID
+	// добавленных пользователей по возрастанию; уже упомянутые в ответ не попадают
+	Replace(targetType string, targetID int, userIDs []int) ([]int, error)
+	// GetByTargets возвращает упоминания записей targetIDs по алфавиту имен,
+	// записи без упоминаний в ответ не попадают
+	GetByTargets(targetType string, targetIDs []int) (map[int][]models.Mention, error)
+}
+
+// AttachmentRepository хранит сведения о файлах, прикрепленных к постам и комментариям
+// (models.AttachmentTarget*). Вложение создается непривязанным и привязывается к записи
+// при ее сохранении; при удалении записи оно снова становится непривязанным.
+type AttachmentRepository interface {
+	// Create сохраняет непривязанное вложение, заполняя ID и CreatedAt
+	Create(attachment *models.Attachment) error
+	GetByID(id int) (*models.Attachment, error)
+	// Link делает ids (без повторов) вложениями записи, остальные ее вложения отвязываются.
+	// Привязать можно вложения самой записи и непривязанные загрузки uploaderID,
+	// для остальных возвращается ErrAttachmentNotFound и ничего не меняется.
+	Link(targetType string, targetID int, uploaderID int, ids []int) error
+	// GetByTargets возвращает вложения записей targetIDs в порядке загрузки,
+	// записи без вложений в ответ не попадают
+	GetByTargets(targetType string, targetIDs []int) (map[int][]models.Attachment, error)
+	// DeleteOrphans удаляет до limit непривязанных вложений, загруженных раньше before,
+	// и возвращает их, чтобы удалить файлы из хранилища
+	DeleteOrphans(before time.Time, limit int) ([]models.Attachment, error)
+}
+
+// PollRepository хранит опросы тредов, их варианты и голоса. У треда не больше одного опроса.
+type PollRepository interface {
+	// Create сохраняет опрос вместе с вариантами и заполняет их ID. Если у треда уже
+	// есть опрос, возвращается ErrAlreadyExists.
+	Create(poll *models.Poll) error
+	// GetByID и GetByThreadID возвращают опрос с вариантами без итогов
+	GetByID(id int) (*models.Poll, error)
+	GetByThreadID(threadID int) (*models.Poll, error)
+	// Vote заменяет голоса пользователя в опросе на optionIDs, пустой список снимает голос.
+	// В закрытом опросе возвращается ErrPollClosed, для чужих вариантов -
+	// ErrPollOptionNotFound, и голоса не меняются.
+	Vote(pollID, userID int, optionIDs []int) error
+	// Close закрывает опрос от имени userID; закрытый раньше опрос не меняется
+	Close(pollID, userID int) error
+	// GetVotes возвращает голоса опроса в порядке подачи
+	GetVotes(pollID int) ([]models.PollVote, error)
+}
+
+// CategoryRepository хранит разделы форума. Счетчики тредов и постов в ответах
+// учитывают только треды самого раздела.
+type CategoryRepository interface {
+	Create(category *models.Category) error
+	GetByID(id int) (*models.Category, error)
+	GetAll() ([]*models.Category, error)
+	Update(category *models.Category) error
+	Delete(id int) error
+}
+
+// TagRepository хранит теги и их привязку к тредам. Названия тегов приходят уже нормализованными.
+type TagRepository interface {
+	// GetAll возвращает используемые теги с числом тредов, популярные первыми
+	GetAll() ([]*models.Tag, error)
+	GetByID(id int) (*models.Tag, error)
+	// GetByThreadIDs возвращает теги тредов в алфавитном порядке, треды без тегов в ответ не попадают
+	GetByThreadIDs(threadIDs []int) (map[int][]string, error)
+	// SetThreadTags заменяет теги треда, недостающие теги создаются
+	SetThreadTags(threadID int, names []string) error
+	// GetThreadIDs возвращает треды со всеми тегами names при matchAll или хотя бы с одним из них
+	GetThreadIDs(names []string, matchAll bool) ([]int, error)
+	// Rename переименовывает тег, занятое название дает ErrAlreadyExists
+	Rename(id int, name string) error
+	// Merge переносит треды тега sourceID на тег targetID и удаляет sourceID
+	Merge(sourceID, targetID int) error
+}
@@ -0,0 +1,290 @@
+package service
+
+import (
+	"ForumService/internal/models"
+	"ForumService/internal/repository"
+	"ForumService/internal/storage"
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/Luxtington/Shared/logger"
+	"go.uber.org/zap"
+)
+
+const (
+	// MaxAttachmentsPerTarget - сколько файлов можно прикрепить к одному посту или комментарию
+	MaxAttachmentsPerTarget = 10
+	// maxFilenameLength - длина имени файла в байтах, как VARCHAR(255) в таблице attachments
+	maxFilenameLength = 255
+	// orphanBatchSize - сколько непривязанных загрузок удаляется за один запрос
+	orphanBatchSize = 100
+)
+
+// allowedAttachmentTypes - типы файлов, которые можно загрузить. Тип определяется по
+// содержимому через http.DetectContentType: заголовку Content-Type из запроса не верим,
+// поэтому HTML и скрипты не пройдут, даже если назвать их картинкой.
+var allowedAttachmentTypes = map[string]bool{
+	"image/png":                 true,
+	"image/jpeg":                true,
+	"image/gif":                 true,
+	"image/webp":                true,
+	"text/plain; charset=utf-8": true,
+	"application/pdf":           true,
+	"application/zip":           true,
+	"application/x-gzip":        true,
+}
+
+// AttachmentService принимает загрузки файлов и прикрепляет их к постам и комментариям.
+// Загруженный файл сначала ни к чему не привязан: его прикрепляют при сохранении записи,
+// а загрузки, которые так и не прикрепили, удаляет сборщик мусора.
+type AttachmentService interface {
+	// Upload проверяет размер и тип файла, сохраняет его и миниатюру для картинок
+	Upload(uploaderID int, filename string, content io.Reader) (*models.Attachment, error)
+	Get(id int) (*models.Attachment, error)
+	// Open открывает файл вложения или его миниатюру
+	Open(attachment *models.Attachment, thumbnail bool) (io.ReadSeekCloser, error)
+	// Link заменяет вложения записи на ids. Прикрепить можно только свои непривязанные
+	// загрузки, вложения, уже прикрепленные к этой записи, сохраняются.
+	Link(targetType string, targetID, uploaderID int, ids []int) error
+	// CheckLink проверяет, что Link с этими ids пройдет. Вызывается до сохранения записи,
+	// чтобы не сохранить пост, к которому потом не прикрепятся файлы; targetID 0 - новая запись.
+	CheckLink(targetType string, targetID, uploaderID int, ids []int) error
+	// ForTargets возвращает вложения записей одного вида по ID записи
+	ForTargets(targetType string, targetIDs []int) (map[int][]models.Attachment, error)
+	// CollectGarbage удаляет загрузки, не привязанные к записи дольше orphanTTL, и возвращает их число
+	CollectGarbage() (int, error)
+	// Run собирает мусор по тикеру до отмены контекста
+	Run(ctx context.Context, interval time.Duration)
+}
+
+type attachmentService struct {
+	repo      repository.AttachmentRepository
+	storage   storage.Storage
+	maxSize   int64
+	orphanTTL time.Duration
+}
+
+func NewAttachmentService(repo repository.AttachmentRepository, files storage.Storage, maxSize int64, orphanTTL time.Duration) AttachmentService {
+	return &attachmentService{
+		repo:      repo,
+		storage:   files,
+		maxSize:   maxSize,
+		orphanTTL: orphanTTL,
+	}
+}
+
+// Upload читает файл в память целиком: размер ограничен maxSize, а тип и размеры
+// картинки нужно определить до сохранения
+func (s *attachmentService) Upload(uploaderID int, filename string, content io.Reader) (*models.Attachment, error) {
+	data, err := io.ReadAll(io.LimitReader(content, s.maxSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) == 0 {
+		return nil, ErrEmptyAttachment
+	}
+	if int64(len(data)) > s.maxSize {
+		return nil, ErrAttachmentTooLarge
+	}
+	contentType := http.DetectContentType(data)
+	if !allowedAttachmentTypes[contentType] {
+		return nil, ErrAttachmentType
+	}
+
+	attachment := &models.Attachment{
+		UploaderID:  uploaderID,
+		Filename:    sanitizeFilename(filename),
+		ContentType: contentType,
+		Size:        int64(len(data)),
+	}
+	var thumbnail []byte
+	if hasThumbnail(contentType) {
+		attachment.Width, attachment.Height, thumbnail, err = makeThumbnail(data)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if attachment.StorageKey, err = storage.NewKey(); err != nil {
+		return nil, err
+	}
+	if err = s.storage.Save(attachment.StorageKey, bytes.NewReader(data)); err != nil {
+		return nil, err
+	}
+	if thumbnail != nil {
+		attachment.ThumbnailKey = attachment.StorageKey + "-thumb"
+		if err = s.storage.Save(attachment.ThumbnailKey, bytes.NewReader(thumbnail)); err != nil {
+			s.deleteFiles(attachment)
+			return nil, err
+		}
+	}
+
+	if err = s.repo.Create(attachment); err != nil {
+		s.deleteFiles(attachment)
+		return nil, translateRepoError(err)
+	}
+	return attachment, nil
+}
+
+func (s *attachmentService) Get(id int) (*models.Attachment, error) {
+	attachment, err := s.repo.GetByID(id)
+	if err != nil {
+		return nil, translateRepoError(err)
+	}
+	return attachment, nil
+}
+
+func (s *attachmentService) Open(attachment *models.Attachment, thumbnail bool) (io.ReadSeekCloser, error) {
+	key := attachment.StorageKey
+	if thumbnail {
+		key = attachment.ThumbnailKey
+		if key == "" {
+			return nil, ErrAttachmentNotFound
+		}
+	}
+	file, err := s.storage.Open(key)
+	if errors.Is(err, storage.ErrNotFound) {
+		return nil, ErrAttachmentNotFound
+	}
+	return file, err
+}
+
+func (s *attachmentService) Link(targetType string, targetID, uploaderID int, ids []int) error {
+	unique := uniqueIDs(ids)
+	if len(unique) > MaxAttachmentsPerTarget {
+		return ErrTooManyAttachments
+	}
+	if err := s.repo.Link(targetType, targetID, uploaderID, unique); err != nil {
+		return translateRepoError(err)
+	}
+	return nil
+}
+
+func (s *attachmentService) CheckLink(targetType string, targetID, uploaderID int, ids []int) error {
+	unique := uniqueIDs(ids)
+	if len(unique) > MaxAttachmentsPerTarget {
+		return ErrTooManyAttachments
+	}
+	for _, id := range unique {
+		attachment, err := s.repo.GetByID(id)
+		if err != nil {
+			return translateRepoError(err)
+		}
+		linkedID := attachment.PostID
+		if targetType == models.AttachmentTargetComment {
+			linkedID = attachment.CommentID
+		}
+		linkedHere := targetID != 0 && linkedID != nil && *linkedID == targetID
+		ownOrphan := attachment.PostID == nil && attachment.CommentID == nil && attachment.UploaderID == uploaderID
+		// Чужие и занятые вложения неотличимы от несуществующих, чтобы не раскрывать чужие загрузки
+		if !linkedHere && !ownOrphan {
+			return ErrAttachmentNotFound
+		}
+	}
+	return nil
+}
+
+func (s *attachmentService) ForTargets(targetType string, targetIDs []int) (map[int][]models.Attachment, error) {
+	attachments, err := s.repo.GetByTargets(targetType, targetIDs)
+	if err != nil {
+		return nil, translateRepoError(err)
+	}
+	return attachments, nil
+}
+
+// CollectGarbage удаляет сначала строки, потом файлы: файл без строки никто не откроет,
+// а строка без файла отдавала бы 404. Файлы, которые не удалось стереть, только логируются.
+func (s *attachmentService) CollectGarbage() (int, error) {
+	before := time.Now().Add(-s.orphanTTL)
+	deleted := 0
+	for {
+		orphans, err := s.repo.DeleteOrphans(before, orphanBatchSize)
+		if err != nil {
+			return deleted, translateRepoError(err)
+		}
+		for i := range orphans {
+			s.deleteFiles(&orphans[i])
+		}
+		deleted += len(orphans)
+		if len(orphans) < orphanBatchSize {
+			return deleted, nil
+		}
+	}
+}
+
+func (s *attachmentService) Run(ctx context.Context, interval time.Duration) {
+	log := logger.GetLogger()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			deleted, err := s.CollectGarbage()
+			if err != nil {
+				log.Error("Ошибка при удалении непривязанных вложений", zap.Error(err))
+			}
+			if deleted > 0 {
+				log.Info("Удалены непривязанные вложения", zap.Int("count", deleted))
+			}
+		}
+	}
+}
+
+// deleteFiles стирает файл вложения и его миниатюру из хранилища
+func (s *attachmentService) deleteFiles(attachment *models.Attachment) {
+	log := logger.GetLogger()
+	for _, key := range []string{attachment.StorageKey, attachment.ThumbnailKey} {
+		if key == "" {
+			continue
+		}
+		if err := s.storage.Delete(key); err != nil {
+			log.Error("Ошибка при удалении файла вложения", zap.String("key", key), zap.Error(err))
+		}
+	}
+}
+
+// uniqueIDs убирает повторы, сохраняя порядок
+func uniqueIDs(ids []int) []int {
+	unique := make([]int, 0, len(ids))
+	seen := make(map[int]bool, len(ids))
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			unique = append(unique, id)
+		}
+	}
+	return unique
+}
+
+// sanitizeFilename оставляет от имени файла только последний элемент пути без
+// управляющих символов и обрезает его до maxFilenameLength байт
+func sanitizeFilename(filename string) string {
+	if i := strings.LastIndexAny(filename, `/\`); i >= 0 {
+		filename = filename[i+1:]
+	}
+	filename = strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) || r == utf8.RuneError {
+			return -1
+		}
+		return r
+	}, filename)
+	filename = strings.TrimSpace(filename)
+	for len(filename) > maxFilenameLength {
+		_, size := utf8.DecodeLastRuneInString(filename)
+		filename = filename[:len(filename)-size]
+	}
+	if filename == "" || filename == "." || filename == ".." {
+		return "file"
+	}
+	return filename
+}
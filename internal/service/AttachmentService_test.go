@@ -0,0 +1,225 @@
+package service
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/png"
+	"strings"
+	"testing"
+	"time"
+
+	"ForumService/internal/models"
+	"ForumService/internal/repository"
+	"ForumService/internal/service/mocks"
+	"ForumService/internal/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func setupAttachmentServiceTest(t *testing.T) (AttachmentService, *mocks.MockAttachmentRepo, storage.Storage) {
+	repo := new(mocks.MockAttachmentRepo)
+	files, err := storage.NewLocalStorage(t.TempDir())
+	require.NoError(t, err)
+	return NewAttachmentService(repo, files, 1<<20, time.Hour), repo, files
+}
+
+// testPNG рисует полупрозрачную картинку заданного размера
+func testPNG(t *testing.T, width, height int) []byte {
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.Set(x, y, color.NRGBA{R: 200, A: 128})
+		}
+	}
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, img))
+	return buf.Bytes()
+}
+
+func TestUploadAttachment_Image(t *testing.T) {
+	service, repo, files := setupAttachmentServiceTest(t)
+	repo.On("Create", mock.AnythingOfType("*models.Attachment")).Return(nil).Run(func(args mock.Arguments) {
+		args.Get(0).(*models.Attachment).ID = 5
+	})
+
+	attachment, err := service.Upload(2, "../../Скриншот\n.png", bytes.NewReader(testPNG(t, 640, 480)))
+	require.NoError(t, err)
+	assert.Equal(t, 5, attachment.ID)
+	assert.Equal(t, "Скриншот.png", attachment.Filename)
+	assert.Equal(t, "image/png", attachment.ContentType)
+	assert.Equal(t, 640, attachment.Width)
+	assert.Equal(t, 480, attachment.Height)
+	require.Equal(t, attachment.StorageKey+"-thumb", attachment.ThumbnailKey)
+
+	thumbnail, err := service.Open(attachment, true)
+	require.NoError(t, err)
+	defer thumbnail.Close()
+	img, format, err := image.Decode(thumbnail)
+	require.NoError(t, err)
+	assert.Equal(t, "jpeg", format)
+	assert.Equal(t, image.Pt(ThumbnailSize, 240), img.Bounds().Size())
+	r, g, b, _ := img.At(10, 10).RGBA()
+	assert.Greater(t, r>>8, uint32(200), "прозрачность ложится на белый фон")
+	assert.InDelta(t, 128, g>>8, 8)
+	assert.InDelta(t, 128, b>>8, 8)
+
+	_, err = files.Open(attachment.StorageKey)
+	assert.NoError(t, err)
+}
+
+func TestUploadAttachment_Text(t *testing.T) {
+	service, repo, _ := setupAttachmentServiceTest(t)
+	repo.On("Create", mock.AnythingOfType("*models.Attachment")).Return(nil)
+
+	attachment, err := service.Upload(2, "app.log", strings.NewReader("2024-01-01 ERROR что-то сломалось\n"))
+	require.NoError(t, err)
+	assert.Equal(t, "text/plain; charset=utf-8", attachment.ContentType)
+	assert.Empty(t, attachment.ThumbnailKey)
+	assert.Zero(t, attachment.Width)
+	_, err = service.Open(attachment, true)
+	assert.ErrorIs(t, err, ErrAttachmentNotFound)
+}
+
+func TestUploadAttachment_Rejected(t *testing.T) {
+	service, repo, _ := setupAttachmentServiceTest(t)
+
+	tests := []struct {
+		name    string
+		content []byte
+		want    error
+	}{
+		{"пустой файл", nil, ErrEmptyAttachment},
+		{"больше лимита", bytes.Repeat([]byte("a"), 1<<20+1), ErrAttachmentTooLarge},
+		{"HTML под видом картинки", []byte("<html><script>alert(1)</script></html>"), ErrAttachmentType},
+		{"исполняемый файл", []byte("MZ\x90\x00\x03\x00\x00\x00"), ErrAttachmentType},
+		{"битая картинка", []byte("\x89PNG\r\n\x1a\nне картинка"), ErrAttachmentType},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := service.Upload(2, "screen.png", bytes.NewReader(tt.content))
+			assert.ErrorIs(t, err, tt.want)
+		})
+	}
+	repo.AssertNotCalled(t, "Create", mock.Anything)
+}
+
+func TestUploadAttachment_RepoErrorDeletesFiles(t *testing.T) {
+	service, repo, files := setupAttachmentServiceTest(t)
+	var saved *models.Attachment
+	repo.On("Create", mock.AnythingOfType("*models.Attachment")).Return(repository.ErrUserNotFound).Run(func(args mock.Arguments) {
+		saved = args.Get(0).(*models.Attachment)
+	})
+
+	_, err := service.Upload(99, "screen.png", bytes.NewReader(testPNG(t, 10, 10)))
+	assert.ErrorIs(t, err, ErrUserNotFound)
+	require.NotNil(t, saved)
+	_, err = files.Open(saved.StorageKey)
+	assert.ErrorIs(t, err, storage.ErrNotFound)
+	_, err = files.Open(saved.ThumbnailKey)
+	assert.ErrorIs(t, err, storage.ErrNotFound)
+}
+
+func TestLinkAttachments(t *testing.T) {
+	service, repo, _ := setupAttachmentServiceTest(t)
+	repo.On("Link", models.AttachmentTargetPost, 1, 2, []int{5, 6}).Return(nil)
+	repo.On("Link", models.AttachmentTargetComment, 3, 2, []int{7}).Return(repository.ErrAttachmentNotFound)
+
+	assert.NoError(t, service.Link(models.AttachmentTargetPost, 1, 2, []int{5, 6, 5}))
+	assert.ErrorIs(t, service.Link(models.AttachmentTargetComment, 3, 2, []int{7}), ErrAttachmentNotFound)
+	tooMany := make([]int, MaxAttachmentsPerTarget+1)
+	for i := range tooMany {
+		tooMany[i] = i + 1
+	}
+	assert.ErrorIs(t, service.Link(models.AttachmentTargetPost, 1, 2, tooMany), ErrTooManyAttachments)
+	repo.AssertNumberOfCalls(t, "Link", 2)
+}
+
+func TestCheckLinkAttachments(t *testing.T) {
+	service, repo, _ := setupAttachmentServiceTest(t)
+	postID := 1
+	repo.On("GetByID", 5).Return(&models.Attachment{ID: 5, UploaderID: 2}, nil)
+	repo.On("GetByID", 6).Return(&models.Attachment{ID: 6, UploaderID: 2, PostID: &postID}, nil)
+	repo.On("GetByID", 7).Return(&models.Attachment{ID: 7, UploaderID: 3}, nil)
+	repo.On("GetByID", 8).Return((*models.Attachment)(nil), repository.ErrAttachmentNotFound)
+
+	assert.NoError(t, service.CheckLink(models.AttachmentTargetPost, 0, 2, []int{5, 5}))
+	assert.NoError(t, service.CheckLink(models.AttachmentTargetPost, 1, 2, []int{5, 6}))
+	assert.ErrorIs(t, service.CheckLink(models.AttachmentTargetPost, 0, 2, []int{6}), ErrAttachmentNotFound, "вложение другого поста")
+	assert.ErrorIs(t, service.CheckLink(models.AttachmentTargetComment, 1, 2, []int{6}), ErrAttachmentNotFound, "ссылка на пост не делает вложение вложением комментария")
+	assert.ErrorIs(t, service.CheckLink(models.AttachmentTargetPost, 0, 2, []int{7}), ErrAttachmentNotFound, "чужая загрузка")
+	assert.ErrorIs(t, service.CheckLink(models.AttachmentTargetPost, 0, 2, []int{8}), ErrAttachmentNotFound)
+	repo.AssertNotCalled(t, "Link", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestCollectGarbage(t *testing.T) {
+	service, repo, files := setupAttachmentServiceTest(t)
+	key, err := storage.NewKey()
+	require.NoError(t, err)
+	require.NoError(t, files.Save(key, strings.NewReader("файл")))
+	require.NoError(t, files.Save(key+"-thumb", strings.NewReader("миниатюра")))
+
+	batch := make([]models.Attachment, orphanBatchSize)
+	batch[0] = models.Attachment{ID: 1, StorageKey: key, ThumbnailKey: key + "-thumb"}
+	for i := 1; i < len(batch); i++ {
+		batch[i] = models.Attachment{ID: i + 1, StorageKey: strings.Repeat("0", 31) + "1"}
+	}
+	repo.On("DeleteOrphans", mock.AnythingOfType("time.Time"), orphanBatchSize).Return(batch, nil).Once()
+	repo.On("DeleteOrphans", mock.AnythingOfType("time.Time"), orphanBatchSize).Return([]models.Attachment{}, nil).Once()
+
+	deleted, err := service.CollectGarbage()
+	require.NoError(t, err)
+	assert.Equal(t, orphanBatchSize, deleted)
+	_, err = files.Open(key)
+	assert.ErrorIs(t, err, storage.ErrNotFound)
+	_, err = files.Open(key + "-thumb")
+	assert.ErrorIs(t, err, storage.ErrNotFound)
+	repo.AssertNumberOfCalls(t, "DeleteOrphans", 2)
+
+	before := repo.Calls[0].Arguments.Get(0).(time.Time)
+	assert.WithinDuration(t, time.Now().Add(-time.Hour), before, time.Minute)
+}
+
+func TestCollectGarbage_Error(t *testing.T) {
+	service, repo, _ := setupAttachmentServiceTest(t)
+	repo.On("DeleteOrphans", mock.Anything, orphanBatchSize).Return([]models.Attachment{}, errors.New("db down"))
+
+	_, err := service.CollectGarbage()
+	assert.Error(t, err)
+}
+
+func TestSanitizeFilename(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"report.pdf", "report.pdf"},
+		{`C:\Users\me\log.txt`, "log.txt"},
+		{"../../etc/passwd", "passwd"},
+		{"  имя\tфайла\x00.png ", "имяфайла.png"},
+		{"..", "file"},
+		{"", "file"},
+		{strings.Repeat("я", 200), strings.Repeat("я", 127)},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, sanitizeFilename(tt.in), tt.in)
+	}
+}
+
+func TestScaleDown(t *testing.T) {
+	tests := []struct {
+		width, height int
+		want          image.Point
+	}{
+		{640, 480, image.Pt(320, 240)},
+		{100, 2000, image.Pt(16, 320)},
+		{3000, 1, image.Pt(320, 1)},
+		{50, 40, image.Pt(50, 40)},
+	}
+	for _, tt := range tests {
+		src := image.NewGray(image.Rect(0, 0, tt.width, tt.height))
+		assert.Equal(t, tt.want, scaleDown(src, ThumbnailSize).Bounds().Size())
+	}
+}
@@ -0,0 +1,89 @@
+package service
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	_ "image/gif"
+	"image/jpeg"
+	_ "image/png"
+)
+
+const (
+	// ThumbnailSize - наибольшая сторона миниатюры в пикселях
+	ThumbnailSize = 320
+	// maxImagePixels ограничивает картинки, которые раскодируются для миниатюры:
+	// маленький файл может объявить огромные размеры и занять гигабайты памяти
+	maxImagePixels = 40_000_000
+	// maxSamples - сколько точек исходной картинки по каждой оси усредняется в пиксель миниатюры
+	maxSamples = 4
+)
+
+// hasThumbnail сообщает, умеем ли мы раскодировать картинку этого типа
+func hasThumbnail(contentType string) bool {
+	switch contentType {
+	case "image/png", "image/jpeg", "image/gif":
+		return true
+	}
+	return false
+}
+
+// makeThumbnail возвращает размеры картинки и ее уменьшенную копию в JPEG.
+// Файл, который не раскодируется как картинка своего типа, отклоняется.
+func makeThumbnail(data []byte) (int, int, []byte, error) {
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil || config.Width <= 0 || config.Height <= 0 {
+		return 0, 0, nil, ErrAttachmentType
+	}
+	if config.Width*config.Height > maxImagePixels {
+		return 0, 0, nil, ErrAttachmentTooLarge
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return 0, 0, nil, ErrAttachmentType
+	}
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, scaleDown(img, ThumbnailSize), &jpeg.Options{Quality: 80}); err != nil {
+		return 0, 0, nil, err
+	}
+	return config.Width, config.Height, buf.Bytes(), nil
+}
+
+// scaleDown уменьшает картинку так, чтобы большая сторона была не больше size,
+// усредняя несколько точек исходника на пиксель. Прозрачные области ложатся на белый фон:
+// в JPEG нет альфа-канала.
+func scaleDown(src image.Image, size int) image.Image {
+	bounds := src.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	dstWidth, dstHeight := width, height
+	if width > size || height > size {
+		if width >= height {
+			dstWidth, dstHeight = size, max(1, height*size/width)
+		} else {
+			dstWidth, dstHeight = max(1, width*size/height), size
+		}
+	}
+	samples := min(maxSamples, max(1, (width+dstWidth-1)/dstWidth))
+
+	dst := image.NewRGBA(image.Rect(0, 0, dstWidth, dstHeight))
+	for y := 0; y < dstHeight; y++ {
+		for x := 0; x < dstWidth; x++ {
+			var r, g, b uint32
+			for sy := 0; sy < samples; sy++ {
+				for sx := 0; sx < samples; sx++ {
+					srcX := bounds.Min.X + ((x*samples+sx)*width)/(dstWidth*samples)
+					srcY := bounds.Min.Y + ((y*samples+sy)*height)/(dstHeight*samples)
+					pr, pg, pb, pa := src.At(srcX, srcY).RGBA()
+					r += pr + 0xffff - pa
+					g += pg + 0xffff - pa
+					b += pb + 0xffff - pa
+				}
+			}
+			n := uint32(samples * samples)
+			dst.SetRGBA(x, y, color.RGBA{R: uint8(r / n >> 8), G: uint8(g / n >> 8), B: uint8(b / n >> 8), A: 0xff})
+		}
+	}
+	return dst
+}
@@ -0,0 +1,117 @@
+package service
+
+import (
+	"ForumService/internal/models"
+	"ForumService/internal/repository"
+	"strings"
+	"unicode/utf8"
+)
+
+// Размер страницы закладок
+const (
+	DefaultBookmarkLimit = 20
+	MaxBookmarkLimit     = 100
+)
+
+// MaxBookmarkNoteLength ограничивает длину заметки к закладке
+const MaxBookmarkNoteLength = 500
+
+// BookmarkService управляет закладками пользователей на треды и посты.
+// Заметки к закладкам личные: их видит только владелец.
+type BookmarkService interface {
+	// Add добавляет запись в закладки; для уже добавленной записи обновляет заметку.
+	// Записи из разделов, закрытых для роли role, считаются несуществующими.
+	Add(userID int, role string, targetType string, targetID int, note string) (*models.Bookmark, error)
+	Remove(userID int, targetType string, targetID int) error
+	// List возвращает страницу закладок пользователя, страницы нумеруются с единицы.
+	// У закладок на записи из разделов, закрытых для роли role, заголовок и тред скрыты.
+	List(userID int, role string, page, limit int) (*models.BookmarkPage, error)
+	// Bookmarked отмечает записи одного вида, добавленные пользователем в закладки
+	Bookmarked(userID int, targetType string, targetIDs []int) (map[int]bool, error)
+}
+
+type bookmarkService struct {
+	repo   repository.BookmarkRepository
+	access categoryAccess
+}
+
+// NewBookmarkService создает сервис закладок. Репозитории тредов, постов и разделов нужны,
+// чтобы не принимать закладки на записи из закрытых разделов.
+func NewBookmarkService(repo repository.BookmarkRepository, threadRepo repository.ThreadRepository, postRepo repository.PostRepository, categoryRepo repository.CategoryRepository) BookmarkService {
+	return &bookmarkService{
+		repo:   repo,
+		access: categoryAccess{threadRepo: threadRepo, postRepo: postRepo, categoryRepo: categoryRepo},
+	}
+}
+
+func (s *bookmarkService) Add(userID int, role string, targetType string, targetID int, note string) (*models.Bookmark, error) {
+	if !isBookmarkTarget(targetType) {
+		return nil, ErrInvalidBookmark
+	}
+	note = strings.TrimSpace(note)
+	if utf8.RuneCountInString(note) > MaxBookmarkNoteLength {
+		return nil, ErrInvalidBookmarkNote
+	}
+	if err := s.access.checkTarget(targetType, targetID, models.Role(role)); err != nil {
+		return nil, err
+	}
+
+	bookmark := &models.Bookmark{
+		UserID:     userID,
+		TargetType: targetType,
+		TargetID:   targetID,
+		Note:       note,
+	}
+	if err := s.repo.Save(bookmark); err != nil {
+		return nil, translateRepoError(err)
+	}
+	return bookmark, nil
+}
+
+func (s *bookmarkService) Remove(userID int, targetType string, targetID int) error {
+	if !isBookmarkTarget(targetType) {
+		return ErrInvalidBookmark
+	}
+	return translateRepoError(s.repo.Delete(userID, targetType, targetID))
+}
+
+func (s *bookmarkService) List(userID int, role string, page, limit int) (*models.BookmarkPage, error) {
+	if limit <= 0 {
+		limit = DefaultBookmarkLimit
+	}
+	if limit > MaxBookmarkLimit {
+		limit = MaxBookmarkLimit
+	}
+	if page < 1 {
+		page = 1
+	}
+
+	bookmarks, total, err := s.repo.GetByUser(userID, models.Role(role), limit, (page-1)*limit)
+	if err != nil {
+		return nil, translateRepoError(err)
+	}
+	return &models.BookmarkPage{
+		Bookmarks: bookmarks,
+		Total:     total,
+		Page:      page,
+		Limit:     limit,
+	}, nil
+}
+
+func (s *bookmarkService) Bookmarked(userID int, targetType string, targetIDs []int) (map[int]bool, error) {
+	if !isBookmarkTarget(targetType) {
+		return nil, ErrInvalidBookmark
+	}
+	if userID == 0 {
+		return map[int]bool{}, nil
+	}
+	bookmarked, err := s.repo.GetBookmarked(userID, targetType, targetIDs)
+	if err != nil {
+		return nil, translateRepoError(err)
+	}
+	return bookmarked, nil
+}
+
+func isBookmarkTarget(targetType string) bool {
+	return targetType == models.BookmarkTargetThread || targetType == models.BookmarkTargetPost
+}
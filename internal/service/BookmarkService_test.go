@@ -0,0 +1,116 @@
+package service
+
+import (
+	"strings"
+	"testing"
+
+	"ForumService/internal/models"
+	"ForumService/internal/repository"
+	"ForumService/internal/service/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// newBookmarkTestService создает сервис закладок, в котором тред 1 и его пост 5 лежат вне разделов,
+// а тред 7 и его пост 8 - в разделе 3, который читают только модераторы
+func newBookmarkTestService(repo *mocks.MockBookmarkRepo) BookmarkService {
+	threads := new(mocks.MockThreadRepo)
+	posts := new(mocks.MockPostRepo)
+	categories := new(mocks.MockCategoryRepo)
+	staffID := 3
+	threads.On("GetByID", 1).Return(&models.Thread{ID: 1}, nil)
+	threads.On("GetByID", 7).Return(&models.Thread{ID: 7, CategoryID: &staffID}, nil)
+	threads.On("GetByID", 99).Return((*models.Thread)(nil), repository.ErrThreadNotFound)
+	posts.On("GetPostByID", 5).Return(&models.Post{ID: 5, ThreadID: 1}, nil)
+	posts.On("GetPostByID", 8).Return(&models.Post{ID: 8, ThreadID: 7}, nil)
+	categories.On("GetByID", staffID).Return(&models.Category{ID: staffID, ReadRole: models.RoleModerator}, nil)
+	return NewBookmarkService(repo, threads, posts, categories)
+}
+
+func TestAddBookmark(t *testing.T) {
+	repo := new(mocks.MockBookmarkRepo)
+	service := newBookmarkTestService(repo)
+	repo.On("Save", &models.Bookmark{UserID: 2, TargetType: models.BookmarkTargetPost, TargetID: 5, Note: "прочитать"}).Return(nil)
+
+	bookmark, err := service.Add(2, "user", models.BookmarkTargetPost, 5, "  прочитать ")
+	assert.NoError(t, err)
+	assert.Equal(t, "прочитать", bookmark.Note)
+	repo.AssertExpectations(t)
+}
+
+func TestAddBookmark_Errors(t *testing.T) {
+	repo := new(mocks.MockBookmarkRepo)
+	service := newBookmarkTestService(repo)
+
+	_, err := service.Add(2, "user", "comment", 1, "")
+	assert.ErrorIs(t, err, ErrInvalidBookmark)
+	_, err = service.Add(2, "user", models.BookmarkTargetThread, 1, strings.Repeat("я", MaxBookmarkNoteLength+1))
+	assert.ErrorIs(t, err, ErrInvalidBookmarkNote)
+	_, err = service.Add(2, "user", models.BookmarkTargetThread, 99, "")
+	assert.ErrorIs(t, err, ErrThreadNotFound)
+	repo.AssertNumberOfCalls(t, "Save", 0)
+}
+
+func TestAddBookmark_ClosedCategory(t *testing.T) {
+	repo := new(mocks.MockBookmarkRepo)
+	service := newBookmarkTestService(repo)
+	repo.On("Save", mock.Anything).Return(nil)
+
+	_, err := service.Add(2, "user", models.BookmarkTargetThread, 7, "")
+	assert.ErrorIs(t, err, ErrThreadNotFound, "закрытый тред неотличим от несуществующего")
+	_, err = service.Add(2, "user", models.BookmarkTargetPost, 8, "")
+	assert.ErrorIs(t, err, ErrPostNotFound)
+	repo.AssertNumberOfCalls(t, "Save", 0)
+
+	_, err = service.Add(2, "moderator", models.BookmarkTargetPost, 8, "")
+	assert.NoError(t, err)
+	repo.AssertNumberOfCalls(t, "Save", 1)
+}
+
+func TestRemoveBookmark(t *testing.T) {
+	repo := new(mocks.MockBookmarkRepo)
+	service := newBookmarkTestService(repo)
+	repo.On("Delete", 2, models.BookmarkTargetThread, 1).Return(nil)
+	repo.On("Delete", 2, models.BookmarkTargetThread, 99).Return(repository.ErrBookmarkNotFound)
+
+	assert.NoError(t, service.Remove(2, models.BookmarkTargetThread, 1))
+	assert.ErrorIs(t, service.Remove(2, models.BookmarkTargetThread, 99), ErrBookmarkNotFound)
+	assert.ErrorIs(t, service.Remove(2, "chat", 1), ErrInvalidBookmark)
+}
+
+func TestListBookmarks(t *testing.T) {
+	repo := new(mocks.MockBookmarkRepo)
+	service := newBookmarkTestService(repo)
+	bookmarks := []models.Bookmark{{UserID: 2, TargetType: models.BookmarkTargetThread, TargetID: 1}}
+	repo.On("GetByUser", 2, models.RoleUser, 10, 10).Return(bookmarks, 11, nil)
+	repo.On("GetByUser", 2, models.RoleUser, DefaultBookmarkLimit, 0).Return([]models.Bookmark{}, 0, nil)
+	repo.On("GetByUser", 2, models.RoleUser, MaxBookmarkLimit, 0).Return([]models.Bookmark{}, 0, nil)
+
+	page, err := service.List(2, "user", 2, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, &models.BookmarkPage{Bookmarks: bookmarks, Total: 11, Page: 2, Limit: 10}, page)
+
+	page, err = service.List(2, "user", 0, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, page.Page)
+	assert.Equal(t, DefaultBookmarkLimit, page.Limit)
+
+	page, err = service.List(2, "user", 1, 1000)
+	assert.NoError(t, err)
+	assert.Equal(t, MaxBookmarkLimit, page.Limit)
+}
+
+func TestBookmarked(t *testing.T) {
+	repo := new(mocks.MockBookmarkRepo)
+	service := newBookmarkTestService(repo)
+	repo.On("GetBookmarked", 2, models.BookmarkTargetPost, []int{1, 2}).Return(map[int]bool{2: true}, nil)
+
+	bookmarked, err := service.Bookmarked(2, models.BookmarkTargetPost, []int{1, 2})
+	assert.NoError(t, err)
+	assert.Equal(t, map[int]bool{2: true}, bookmarked)
+
+	bookmarked, err = service.Bookmarked(0, models.BookmarkTargetPost, []int{1, 2})
+	assert.NoError(t, err)
+	assert.Empty(t, bookmarked, "у анонимного пользователя закладок нет")
+	repo.AssertNumberOfCalls(t, "GetBookmarked", 1)
+}
@@ -0,0 +1,82 @@
+package service
+
+import (
+	"ForumService/internal/models"
+	"ForumService/internal/repository"
+	"errors"
+)
+
+// categoryAccess проверяет read_role раздела треда для сервисов, которые отдают заголовки
+// и ссылки на треды и посты в обход ThreadService: закладок и уведомлений
+type categoryAccess struct {
+	threadRepo   repository.ThreadRepository
+	postRepo     repository.PostRepository
+	categoryRepo repository.CategoryRepository
+}
+
+// categoryReadRole возвращает роль, нужную для чтения раздела categoryID: самую строгую
+// read_role на пути к корню, как models.EffectiveReadRoles для всего дерева
+func categoryReadRole(categoryRepo repository.CategoryRepository, categoryID int) (models.Role, error) {
+	role := models.RoleGuest
+	seen := make(map[int]bool)
+	for id := &categoryID; id != nil && !seen[*id]; {
+		category, err := categoryRepo.GetByID(*id)
+		if err != nil {
+			return "", translateRepoError(err)
+		}
+		seen[*id] = true
+		role = role.Stricter(category.ReadRole)
+		id = category.ParentID
+	}
+	return role, nil
+}
+
+// threadReadRole возвращает роль, нужную для чтения треда threadID; треды вне разделов читают все
+func (a categoryAccess) threadReadRole(threadID int) (models.Role, error) {
+	thread, err := a.threadRepo.GetByID(threadID)
+	if err != nil {
+		return "", translateRepoError(err)
+	}
+	if thread.CategoryID == nil {
+		return models.RoleGuest, nil
+	}
+	return categoryReadRole(a.categoryRepo, *thread.CategoryID)
+}
+
+// checkThread возвращает ErrNoPermission, если роль не может читать раздел треда threadID
+func (a categoryAccess) checkThread(threadID int, role models.Role) error {
+	readRole, err := a.threadReadRole(threadID)
+	if err != nil {
+		return err
+	}
+	if !role.Allows(readRole) {
+		return ErrNoPermission
+	}
+	return nil
+}
+
+// checkPost проверяет доступ к разделу треда поста postID
+func (a categoryAccess) checkPost(postID int, role models.Role) error {
+	post, err := a.postRepo.GetPostByID(postID)
+	if err != nil {
+		return translateRepoError(err)
+	}
+	return a.checkThread(post.ThreadID, role)
+}
+
+// checkTarget проверяет доступ к треду или посту по виду записи закладки или подписки.
+// Закрытая запись выглядит несуществующей, чтобы перебор ID не раскрывал, какие записи есть.
+func (a categoryAccess) checkTarget(targetType string, targetID int, role models.Role) error {
+	if targetType == models.BookmarkTargetPost {
+		return hideNoPermission(a.checkPost(targetID, role), ErrPostNotFound)
+	}
+	return hideNoPermission(a.checkThread(targetID, role), ErrThreadNotFound)
+}
+
+// hideNoPermission заменяет отказ в доступе к разделу ошибкой notFound
+func hideNoPermission(err error, notFound error) error {
+	if errors.Is(err, ErrNoPermission) {
+		return notFound
+	}
+	return err
+}
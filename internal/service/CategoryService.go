@@ -0,0 +1,195 @@
+package service
+
+import (
+	"ForumService/internal/models"
+	"ForumService/internal/repository"
+	"strings"
+	"unicode/utf8"
+)
+
+// Допустимая длина названия раздела в символах
+const (
+	minCategoryNameLength = 2
+	maxCategoryNameLength = 100
+)
+
+type CategoryService interface {
+	GetCategoryTree(role string) ([]*models.Category, error)
+	GetCategory(id int, role string) (*models.Category, error)
+	CreateCategory(category *models.Category, userID int) error
+	UpdateCategory(category *models.Category, userID int) error
+	DeleteCategory(id int, userID int) error
+}
+
+type categoryService struct {
+	categoryRepo repository.CategoryRepository
+	userRepo     repository.UserRepository
+}
+
+func NewCategoryService(categoryRepo repository.CategoryRepository, userRepo repository.UserRepository) CategoryService {
+	return &categoryService{
+		categoryRepo: categoryRepo,
+		userRepo:     userRepo,
+	}
+}
+
+// GetCategoryTree возвращает дерево разделов, видимых пользователю с ролью role
+func (s *categoryService) GetCategoryTree(role string) ([]*models.Category, error) {
+	categories, err := s.categoryRepo.GetAll()
+	if err != nil {
+		return nil, translateRepoError(err)
+	}
+
+	children, roots := groupCategories(categories)
+	return visibleCategories(roots, children, models.Role(role)), nil
+}
+
+// GetCategory возвращает раздел вместе с видимыми подразделами. Раздел виден, только
+// если роль может читать и его, и всех его предков, как в дереве разделов.
+func (s *categoryService) GetCategory(id int, role string) (*models.Category, error) {
+	categories, err := s.categoryRepo.GetAll()
+	if err != nil {
+		return nil, translateRepoError(err)
+	}
+
+	children, _ := groupCategories(categories)
+	readRoles := models.EffectiveReadRoles(categories)
+	for _, category := range categories {
+		if category.ID != id {
+			continue
+		}
+		if !models.Role(role).Allows(readRoles[id]) {
+			return nil, ErrNoPermission
+		}
+		return visibleCategories([]*models.Category{category}, children, models.Role(role))[0], nil
+	}
+	return nil, ErrCategoryNotFound
+}
+
+func (s *categoryService) CreateCategory(category *models.Category, userID int) error {
+	if err := s.requireAdmin(userID); err != nil {
+		return err
+	}
+	if err := normalizeCategory(category); err != nil {
+		return err
+	}
+	return translateRepoError(s.categoryRepo.Create(category))
+}
+
+func (s *categoryService) UpdateCategory(category *models.Category, userID int) error {
+	if err := s.requireAdmin(userID); err != nil {
+		return err
+	}
+	if err := normalizeCategory(category); err != nil {
+		return err
+	}
+
+	categories, err := s.categoryRepo.GetAll()
+	if err != nil {
+		return translateRepoError(err)
+	}
+	if err := checkCategoryParent(category, categories); err != nil {
+		return err
+	}
+	return translateRepoError(s.categoryRepo.Update(category))
+}
+
+func (s *categoryService) DeleteCategory(id int, userID int) error {
+	if err := s.requireAdmin(userID); err != nil {
+		return err
+	}
+	return translateRepoError(s.categoryRepo.Delete(id))
+}
+
+// requireAdmin разрешает управление разделами только администраторам
+func (s *categoryService) requireAdmin(userID int) error {
+	userRole, err := s.userRepo.GetUserRole(userID)
+	if err != nil {
+		return translateRepoError(err)
+	}
+	if models.Role(userRole) != models.RoleAdmin {
+		return ErrNoPermission
+	}
+	return nil
+}
+
+// normalizeCategory проверяет раздел и подставляет роли по умолчанию:
+// читать могут все, создавать треды - аутентифицированные пользователи.
+// read_role подраздела может быть мягче родительской, но не открывает его:
+// при проверках действует самая строгая роль на пути от корня.
+func normalizeCategory(category *models.Category) error {
+	category.Name = strings.TrimSpace(category.Name)
+	category.Description = strings.TrimSpace(category.Description)
+	length := utf8.RuneCountInString(category.Name)
+	if length < minCategoryNameLength || length > maxCategoryNameLength {
+		return ErrInvalidCategoryName
+	}
+
+	if category.ReadRole == "" {
+		category.ReadRole = models.RoleGuest
+	}
+	if category.CreateRole == "" {
+		category.CreateRole = models.RoleUser
+	}
+	if !models.ValidRole(category.ReadRole) || !models.ValidRole(category.CreateRole) {
+		return ErrInvalidCategoryRole
+	}
+	// Гости не могут создавать треды
+	if category.CreateRole == models.RoleGuest {
+		return ErrInvalidCategoryRole
+	}
+	return nil
+}
+
+// checkCategoryParent запрещает делать раздел подразделом самого себя или своих потомков
+func checkCategoryParent(category *models.Category, categories []*models.Category) error {
+	parents := make(map[int]*int, len(categories))
+	for _, existing := range categories {
+		parents[existing.ID] = existing.ParentID
+	}
+	if _, ok := parents[category.ID]; !ok {
+		return ErrCategoryNotFound
+	}
+
+	for parentID := category.ParentID; parentID != nil; parentID = parents[*parentID] {
+		if *parentID == category.ID {
+			return ErrCategoryCycle
+		}
+		if _, ok := parents[*parentID]; !ok {
+			return ErrCategoryNotFound
+		}
+	}
+	return nil
+}
+
+// groupCategories раскладывает разделы по родителям, сохраняя порядок репозитория
+func groupCategories(categories []*models.Category) (map[int][]*models.Category, []*models.Category) {
+	children := make(map[int][]*models.Category)
+	var roots []*models.Category
+	for _, category := range categories {
+		if category.ParentID == nil {
+			roots = append(roots, category)
+			continue
+		}
+		children[*category.ParentID] = append(children[*category.ParentID], category)
+	}
+	return children, roots
+}
+
+// visibleCategories собирает поддеревья разделов, доступных роли, и прибавляет
+// к счетчикам раздела счетчики видимых подразделов
+func visibleCategories(nodes []*models.Category, children map[int][]*models.Category, role models.Role) []*models.Category {
+	var visible []*models.Category
+	for _, node := range nodes {
+		if !role.Allows(node.ReadRole) {
+			continue
+		}
+		node.Children = visibleCategories(children[node.ID], children, role)
+		for _, child := range node.Children {
+			node.ThreadCount += child.ThreadCount
+			node.PostCount += child.PostCount
+		}
+		visible = append(visible, node)
+	}
+	return visible
+}
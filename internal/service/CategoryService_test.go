@@ -0,0 +1,160 @@
+package service
+
+import (
+	"testing"
+
+	"ForumService/internal/models"
+	"ForumService/internal/repository"
+	"ForumService/internal/service/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func categoryTree() []*models.Category {
+	root, staff := 1, 3
+	return []*models.Category{
+		{ID: 1, Name: "Языки", ReadRole: models.RoleGuest, CreateRole: models.RoleUser, ThreadCount: 2, PostCount: 10},
+		{ID: 2, ParentID: &root, Name: "Go", ReadRole: models.RoleGuest, CreateRole: models.RoleUser, ThreadCount: 1, PostCount: 4},
+		{ID: 3, ParentID: &root, Name: "Модерация", ReadRole: models.RoleModerator, CreateRole: models.RoleModerator, ThreadCount: 5, PostCount: 50},
+		{ID: 4, ParentID: &staff, Name: "Жалобы", ReadRole: models.RoleGuest, CreateRole: models.RoleUser, ThreadCount: 1, PostCount: 1},
+	}
+}
+
+func TestGetCategoryTree_GuestSeesOpenCategories(t *testing.T) {
+	categoryRepo := new(mocks.MockCategoryRepo)
+	service := NewCategoryService(categoryRepo, new(mocks.MockUserRepo))
+	categoryRepo.On("GetAll").Return(categoryTree(), nil)
+
+	tree, err := service.GetCategoryTree("guest")
+	assert.NoError(t, err)
+	assert.Len(t, tree, 1)
+	assert.Equal(t, "Языки", tree[0].Name)
+	// Закрытый раздел скрывает и свой открытый подраздел
+	assert.Len(t, tree[0].Children, 1)
+	assert.Equal(t, "Go", tree[0].Children[0].Name)
+	assert.Equal(t, 3, tree[0].ThreadCount)
+	assert.Equal(t, 14, tree[0].PostCount)
+}
+
+func TestGetCategoryTree_ModeratorSeesAll(t *testing.T) {
+	categoryRepo := new(mocks.MockCategoryRepo)
+	service := NewCategoryService(categoryRepo, new(mocks.MockUserRepo))
+	categoryRepo.On("GetAll").Return(categoryTree(), nil)
+
+	tree, err := service.GetCategoryTree("moderator")
+	assert.NoError(t, err)
+	assert.Len(t, tree[0].Children, 2)
+	assert.Len(t, tree[0].Children[1].Children, 1)
+	assert.Equal(t, 9, tree[0].ThreadCount)
+	assert.Equal(t, 65, tree[0].PostCount)
+}
+
+func TestGetCategory(t *testing.T) {
+	categoryRepo := new(mocks.MockCategoryRepo)
+	service := NewCategoryService(categoryRepo, new(mocks.MockUserRepo))
+	categoryRepo.On("GetAll").Return(categoryTree(), nil)
+
+	_, err := service.GetCategory(3, "user")
+	assert.ErrorIs(t, err, ErrNoPermission)
+
+	// Открытый подраздел закрытого раздела скрыт так же, как в дереве
+	_, err = service.GetCategory(4, "user")
+	assert.ErrorIs(t, err, ErrNoPermission)
+
+	_, err = service.GetCategory(42, "admin")
+	assert.ErrorIs(t, err, ErrCategoryNotFound)
+
+	category, err := service.GetCategory(2, "guest")
+	assert.NoError(t, err)
+	assert.Equal(t, "Go", category.Name)
+}
+
+func TestCreateCategory_AdminOnly(t *testing.T) {
+	categoryRepo := new(mocks.MockCategoryRepo)
+	userRepo := new(mocks.MockUserRepo)
+	service := NewCategoryService(categoryRepo, userRepo)
+	userRepo.On("GetUserRole", 1).Return("moderator", nil)
+
+	err := service.CreateCategory(&models.Category{Name: "Новости"}, 1)
+	assert.ErrorIs(t, err, ErrNoPermission)
+	categoryRepo.AssertNotCalled(t, "Create", mock.Anything)
+}
+
+func TestCreateCategory_Defaults(t *testing.T) {
+	categoryRepo := new(mocks.MockCategoryRepo)
+	userRepo := new(mocks.MockUserRepo)
+	service := NewCategoryService(categoryRepo, userRepo)
+	userRepo.On("GetUserRole", 1).Return("admin", nil)
+	categoryRepo.On("Create", mock.AnythingOfType("*models.Category")).Return(nil)
+
+	category := &models.Category{Name: "  Новости  "}
+	err := service.CreateCategory(category, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, "Новости", category.Name)
+	assert.Equal(t, models.RoleGuest, category.ReadRole)
+	assert.Equal(t, models.RoleUser, category.CreateRole)
+}
+
+func TestCreateCategory_Validation(t *testing.T) {
+	categoryRepo := new(mocks.MockCategoryRepo)
+	userRepo := new(mocks.MockUserRepo)
+	service := NewCategoryService(categoryRepo, userRepo)
+	userRepo.On("GetUserRole", 1).Return("admin", nil)
+
+	assert.ErrorIs(t, service.CreateCategory(&models.Category{Name: "Я"}, 1), ErrInvalidCategoryName)
+	assert.ErrorIs(t, service.CreateCategory(&models.Category{Name: "Новости", ReadRole: "owner"}, 1), ErrInvalidCategoryRole)
+	assert.ErrorIs(t, service.CreateCategory(&models.Category{Name: "Новости", CreateRole: models.RoleGuest}, 1), ErrInvalidCategoryRole)
+}
+
+func TestCreateCategory_ParentNotFound(t *testing.T) {
+	categoryRepo := new(mocks.MockCategoryRepo)
+	userRepo := new(mocks.MockUserRepo)
+	service := NewCategoryService(categoryRepo, userRepo)
+	userRepo.On("GetUserRole", 1).Return("admin", nil)
+	categoryRepo.On("Create", mock.AnythingOfType("*models.Category")).Return(repository.ErrCategoryNotFound)
+
+	parentID := 42
+	err := service.CreateCategory(&models.Category{Name: "Новости", ParentID: &parentID}, 1)
+	assert.ErrorIs(t, err, ErrCategoryNotFound)
+}
+
+func TestUpdateCategory_Cycle(t *testing.T) {
+	categoryRepo := new(mocks.MockCategoryRepo)
+	userRepo := new(mocks.MockUserRepo)
+	service := NewCategoryService(categoryRepo, userRepo)
+	userRepo.On("GetUserRole", 1).Return("admin", nil)
+	categoryRepo.On("GetAll").Return(categoryTree(), nil)
+
+	// Раздел 1 нельзя сделать подразделом своего потомка 4
+	parentID := 4
+	err := service.UpdateCategory(&models.Category{ID: 1, Name: "Языки", ParentID: &parentID}, 1)
+	assert.ErrorIs(t, err, ErrCategoryCycle)
+
+	self := 2
+	err = service.UpdateCategory(&models.Category{ID: 2, Name: "Go", ParentID: &self}, 1)
+	assert.ErrorIs(t, err, ErrCategoryCycle)
+	categoryRepo.AssertNotCalled(t, "Update", mock.Anything)
+}
+
+func TestUpdateCategory_Success(t *testing.T) {
+	categoryRepo := new(mocks.MockCategoryRepo)
+	userRepo := new(mocks.MockUserRepo)
+	service := NewCategoryService(categoryRepo, userRepo)
+	userRepo.On("GetUserRole", 1).Return("admin", nil)
+	categoryRepo.On("GetAll").Return(categoryTree(), nil)
+	categoryRepo.On("Update", mock.AnythingOfType("*models.Category")).Return(nil)
+
+	err := service.UpdateCategory(&models.Category{ID: 4, Name: "Жалобы"}, 1)
+	assert.NoError(t, err)
+	categoryRepo.AssertCalled(t, "Update", mock.AnythingOfType("*models.Category"))
+}
+
+func TestDeleteCategory_NotEmpty(t *testing.T) {
+	categoryRepo := new(mocks.MockCategoryRepo)
+	userRepo := new(mocks.MockUserRepo)
+	service := NewCategoryService(categoryRepo, userRepo)
+	userRepo.On("GetUserRole", 1).Return("admin", nil)
+	categoryRepo.On("Delete", 1).Return(repository.ErrCategoryNotEmpty)
+
+	assert.ErrorIs(t, service.DeleteCategory(1, 1), ErrCategoryNotEmpty)
+}
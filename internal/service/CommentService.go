@@ -4,28 +4,46 @@ import (
 	"ForumService/internal/models"
 	"ForumService/internal/repository"
 	"fmt"
+	"time"
 )
 
+// MaxCommentDepth - максимальная глубина вложенности ответов на комментарии
+const MaxCommentDepth = 5
+
 type CommentService interface {
 	CreateComment(postID, authorID int, content string) (*models.Comment, error)
+	ReplyToComment(postID, parentID, authorID int, content string) (*models.Comment, error)
 	GetCommentByID(id int) (*models.Comment, error)
 	GetCommentsByPostID(postID int) ([]models.Comment, error)
+	UpdateComment(id int, userID int, content string) (*models.Comment, error)
 	DeleteComment(id int, userID int) error
 }
 
 type commentService struct {
-	repo     repository.CommentRepository
-	userRepo repository.UserRepository
+	repo       repository.CommentRepository
+	postRepo   repository.PostRepository
+	threadRepo repository.ThreadRepository
+	userRepo   repository.UserRepository
+	editWindow time.Duration
 }
 
-func NewCommentService(repo repository.CommentRepository, userRepo repository.UserRepository) CommentService {
+// NewCommentService создает сервис комментариев. editWindow ограничивает время,
+// в течение которого автор может редактировать комментарий; 0 отключает ограничение.
+// Репозитории постов и тредов нужны, чтобы не принимать комментарии в закрытых тредах.
+func NewCommentService(repo repository.CommentRepository, postRepo repository.PostRepository, threadRepo repository.ThreadRepository, userRepo repository.UserRepository, editWindow time.Duration) CommentService {
 	return &commentService{
-		repo:     repo,
-		userRepo: userRepo,
+		repo:       repo,
+		postRepo:   postRepo,
+		threadRepo: threadRepo,
+		userRepo:   userRepo,
+		editWindow: editWindow,
 	}
 }
 
 func (s *commentService) CreateComment(postID, authorID int, content string) (*models.Comment, error) {
+	if err := ensurePostThreadOpen(s.postRepo, s.threadRepo, postID); err != nil {
+		return nil, err
+	}
 	comment := &models.Comment{
 		PostID:   postID,
 		AuthorID: authorID,
@@ -33,7 +51,41 @@ func (s *commentService) CreateComment(postID, authorID int, content string) (*m
 	}
 
 	if err := s.repo.SaveComment(comment); err != nil {
-		return nil, fmt.Errorf("couldn't create comment: %w", err)
+		return nil, fmt.Errorf("couldn't create comment: %w", translateRepoError(err))
+	}
+
+	return comment, nil
+}
+
+func (s *commentService) ReplyToComment(postID, parentID, authorID int, content string) (*models.Comment, error) {
+	parent, err := s.repo.GetCommentByID(parentID)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't get parent comment: %w", translateRepoError(err))
+	}
+
+	if parent.PostID != postID {
+		return nil, ErrInvalidParent
+	}
+	if parent.Deleted {
+		return nil, ErrCommentDeleted
+	}
+	if parent.Depth+1 > MaxCommentDepth {
+		return nil, ErrCommentTooDeep
+	}
+	if err := ensurePostThreadOpen(s.postRepo, s.threadRepo, postID); err != nil {
+		return nil, err
+	}
+
+	comment := &models.Comment{
+		PostID:          postID,
+		ParentCommentID: &parent.ID,
+		Depth:           parent.Depth + 1,
+		AuthorID:        authorID,
+		Content:         content,
+	}
+
+	if err := s.repo.SaveComment(comment); err != nil {
+		return nil, fmt.Errorf("couldn't create reply: %w", translateRepoError(err))
 	}
 
 	return comment, nil
@@ -42,7 +94,7 @@ func (s *commentService) CreateComment(postID, authorID int, content string) (*m
 func (s *commentService) GetCommentByID(id int) (*models.Comment, error) {
 	comment, err := s.repo.GetCommentByID(id)
 	if err != nil {
-		return nil, fmt.Errorf("couldn't get comment: %w", err)
+		return nil, fmt.Errorf("couldn't get comment: %w", translateRepoError(err))
 	}
 	return comment, nil
 }
@@ -55,17 +107,54 @@ func (s *commentService) GetCommentsByPostID(postID int) ([]models.Comment, erro
 	return comments, nil
 }
 
+func (s *commentService) UpdateComment(commentID int, userID int, content string) (*models.Comment, error) {
+	// Проверяем существование комментария
+	comment, err := s.repo.GetCommentByID(commentID)
+	if err != nil {
+		return nil, translateRepoError(err)
+	}
+
+	// Получаем роль пользователя
+	userRole, err := s.userRepo.GetUserRole(userID)
+	if err != nil {
+		return nil, translateRepoError(err)
+	}
+
+	// Чужие комментарии правят модераторы и администраторы, страница поста
+	// показывает кнопку редактирования по тому же правилу
+	isModerator := userRole == "admin" || userRole == "moderator"
+	if comment.AuthorID != userID && !isModerator {
+		return nil, ErrNoPermission
+	}
+
+	if comment.Deleted {
+		return nil, ErrCommentDeleted
+	}
+
+	// После окончания окна редактирования править комментарий могут только модераторы
+	if s.editWindow > 0 && !isModerator && time.Since(comment.CreatedAt) > s.editWindow {
+		return nil, ErrEditWindowExpired
+	}
+
+	comment.Content = content
+	if err := s.repo.UpdateComment(comment); err != nil {
+		return nil, fmt.Errorf("couldn't update comment: %w", translateRepoError(err))
+	}
+
+	return comment, nil
+}
+
 func (s *commentService) DeleteComment(commentID int, userID int) error {
 	// Проверяем существование комментария
 	comment, err := s.repo.GetCommentByID(commentID)
 	if err != nil {
-		return err
+		return translateRepoError(err)
 	}
 
 	// Получаем роль пользователя
 	userRole, err := s.userRepo.GetUserRole(userID)
 	if err != nil {
-		return err
+		return translateRepoError(err)
 	}
 
 	// Отладочная информация
@@ -76,5 +165,38 @@ func (s *commentService) DeleteComment(commentID int, userID int) error {
 		return ErrNoPermission
 	}
 
-	return s.repo.DeleteComment(commentID)
+	// Комментарий с ответами заменяется заглушкой, чтобы не терять ветку обсуждения.
+	// Проверка ответов и удаление идут одной операцией: иначе ответ, добавленный
+	// между ними, удалился бы каскадом вместе с комментарием
+	tombstoned, err := s.repo.RemoveComment(commentID)
+	if err != nil {
+		return translateRepoError(err)
+	}
+	if tombstoned {
+		return nil
+	}
+
+	return s.removeOrphanedTombstones(comment.ParentCommentID)
+}
+
+// removeOrphanedTombstones удаляет заглушки удаленных комментариев,
+// у которых после удаления последнего ответа не осталось потомков
+func (s *commentService) removeOrphanedTombstones(parentID *int) error {
+	for parentID != nil {
+		parent, err := s.repo.GetCommentByID(*parentID)
+		if err != nil || !parent.Deleted {
+			return nil
+		}
+
+		hasReplies, err := s.repo.HasReplies(parent.ID)
+		if err != nil || hasReplies {
+			return nil
+		}
+
+		if err := s.repo.DeleteComment(parent.ID); err != nil {
+			return err
+		}
+		parentID = parent.ParentCommentID
+	}
+	return nil
 }
@@ -3,15 +3,28 @@ package service
 import (
 	"errors"
 	"testing"
+	"time"
 	"ForumService/internal/models"
+	"ForumService/internal/repository"
 	"ForumService/internal/service/mocks"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 )
 
+// openThreadRepos возвращает репозитории, по которым любой пост находится в открытом треде
+func openThreadRepos() (*mocks.MockPostRepo, *mocks.MockThreadRepo) {
+	postRepo := new(mocks.MockPostRepo)
+	postRepo.On("GetPostByID", mock.Anything).Return(&models.Post{ThreadID: 1}, nil).Maybe()
+	threadRepo := new(mocks.MockThreadRepo)
+	threadRepo.On("GetByID", 1).Return(&models.Thread{ID: 1}, nil).Maybe()
+	return postRepo, threadRepo
+}
+
 func TestCreateComment_Success(t *testing.T) {
 	repo := new(mocks.MockCommentRepo)
 	userRepo := new(mocks.MockUserRepo)
-	service := NewCommentService(repo, userRepo)
+	postRepo, threadRepo := openThreadRepos()
+	service := NewCommentService(repo, postRepo, threadRepo, userRepo, 0)
 
 	comment := &models.Comment{PostID: 1, AuthorID: 2, Content: "content"}
 	repo.On("SaveComment", comment).Return(nil)
@@ -26,7 +39,8 @@ func TestCreateComment_Success(t *testing.T) {
 func TestGetCommentByID_Success(t *testing.T) {
 	repo := new(mocks.MockCommentRepo)
 	userRepo := new(mocks.MockUserRepo)
-	service := NewCommentService(repo, userRepo)
+	postRepo, threadRepo := openThreadRepos()
+	service := NewCommentService(repo, postRepo, threadRepo, userRepo, 0)
 
 	comment := &models.Comment{ID: 1}
 	repo.On("GetCommentByID", 1).Return(comment, nil)
@@ -38,7 +52,8 @@ func TestGetCommentByID_Success(t *testing.T) {
 func TestGetCommentsByPostID_Success(t *testing.T) {
 	repo := new(mocks.MockCommentRepo)
 	userRepo := new(mocks.MockUserRepo)
-	service := NewCommentService(repo, userRepo)
+	postRepo, threadRepo := openThreadRepos()
+	service := NewCommentService(repo, postRepo, threadRepo, userRepo, 0)
 
 	comments := []models.Comment{{ID: 1, PostID: 1}}
 	repo.On("GetCommentsByPostID", 1).Return(comments, nil)
@@ -50,7 +65,8 @@ func TestGetCommentsByPostID_Success(t *testing.T) {
 func TestDeleteComment_NoPermission(t *testing.T) {
 	repo := new(mocks.MockCommentRepo)
 	userRepo := new(mocks.MockUserRepo)
-	service := NewCommentService(repo, userRepo)
+	postRepo, threadRepo := openThreadRepos()
+	service := NewCommentService(repo, postRepo, threadRepo, userRepo, 0)
 
 	comment := &models.Comment{ID: 1, AuthorID: 2}
 	repo.On("GetCommentByID", 1).Return(comment, nil)
@@ -63,12 +79,13 @@ func TestDeleteComment_NoPermission(t *testing.T) {
 func TestDeleteComment_Admin(t *testing.T) {
 	repo := new(mocks.MockCommentRepo)
 	userRepo := new(mocks.MockUserRepo)
-	service := NewCommentService(repo, userRepo)
+	postRepo, threadRepo := openThreadRepos()
+	service := NewCommentService(repo, postRepo, threadRepo, userRepo, 0)
 
 	comment := &models.Comment{ID: 1, AuthorID: 2}
 	repo.On("GetCommentByID", 1).Return(comment, nil)
 	userRepo.On("GetUserRole", 4).Return("admin", nil)
-	repo.On("DeleteComment", 1).Return(nil)
+	repo.On("RemoveComment", 1).Return(false, nil)
 
 	err := service.DeleteComment(1, 4)
 	assert.NoError(t, err)
@@ -77,7 +94,8 @@ func TestDeleteComment_Admin(t *testing.T) {
 func TestCreateComment_Error(t *testing.T) {
 	repo := new(mocks.MockCommentRepo)
 	userRepo := new(mocks.MockUserRepo)
-	service := NewCommentService(repo, userRepo)
+	postRepo, threadRepo := openThreadRepos()
+	service := NewCommentService(repo, postRepo, threadRepo, userRepo, 0)
 
 	comment := &models.Comment{PostID: 1, AuthorID: 2, Content: "fail"}
 	repo.On("SaveComment", comment).Return(errors.New("db error"))
@@ -90,10 +108,227 @@ func TestCreateComment_Error(t *testing.T) {
 func TestGetCommentByID_Error(t *testing.T) {
 	repo := new(mocks.MockCommentRepo)
 	userRepo := new(mocks.MockUserRepo)
-	service := NewCommentService(repo, userRepo)
+	postRepo, threadRepo := openThreadRepos()
+	service := NewCommentService(repo, postRepo, threadRepo, userRepo, 0)
 
 	repo.On("GetCommentByID", 1).Return((*models.Comment)(nil), errors.New("db error"))
 	res, err := service.GetCommentByID(1)
 	assert.Error(t, err)
 	assert.Nil(t, res)
-} 
\ No newline at end of file
+} 
+func TestUpdateComment_NotFound(t *testing.T) {
+	repo := new(mocks.MockCommentRepo)
+	userRepo := new(mocks.MockUserRepo)
+	postRepo, threadRepo := openThreadRepos()
+	service := NewCommentService(repo, postRepo, threadRepo, userRepo, 0)
+
+	repo.On("GetCommentByID", 1).Return((*models.Comment)(nil), repository.ErrCommentNotFound)
+	res, err := service.UpdateComment(1, 1, "new")
+	assert.ErrorIs(t, err, ErrCommentNotFound)
+	assert.Nil(t, res)
+}
+
+func TestUpdateComment_Author(t *testing.T) {
+	repo := new(mocks.MockCommentRepo)
+	userRepo := new(mocks.MockUserRepo)
+	postRepo, threadRepo := openThreadRepos()
+	service := NewCommentService(repo, postRepo, threadRepo, userRepo, 0)
+
+	comment := &models.Comment{ID: 1, AuthorID: 2, Content: "old", CreatedAt: time.Now().Add(-24 * time.Hour)}
+	repo.On("GetCommentByID", 1).Return(comment, nil)
+	userRepo.On("GetUserRole", 2).Return("user", nil)
+	repo.On("UpdateComment", comment).Return(nil)
+
+	res, err := service.UpdateComment(1, 2, "new")
+	assert.NoError(t, err)
+	assert.Equal(t, "new", res.Content)
+}
+
+func TestUpdateComment_NoPermission(t *testing.T) {
+	repo := new(mocks.MockCommentRepo)
+	userRepo := new(mocks.MockUserRepo)
+	postRepo, threadRepo := openThreadRepos()
+	service := NewCommentService(repo, postRepo, threadRepo, userRepo, 0)
+
+	comment := &models.Comment{ID: 1, AuthorID: 2}
+	repo.On("GetCommentByID", 1).Return(comment, nil)
+	userRepo.On("GetUserRole", 3).Return("user", nil)
+
+	res, err := service.UpdateComment(1, 3, "new")
+	assert.ErrorIs(t, err, ErrNoPermission)
+	assert.Nil(t, res)
+}
+
+func TestUpdateComment_EditWindowExpired(t *testing.T) {
+	repo := new(mocks.MockCommentRepo)
+	userRepo := new(mocks.MockUserRepo)
+	postRepo, threadRepo := openThreadRepos()
+	service := NewCommentService(repo, postRepo, threadRepo, userRepo, 15*time.Minute)
+
+	comment := &models.Comment{ID: 1, AuthorID: 2, CreatedAt: time.Now().Add(-time.Hour)}
+	repo.On("GetCommentByID", 1).Return(comment, nil)
+	userRepo.On("GetUserRole", 2).Return("user", nil)
+
+	res, err := service.UpdateComment(1, 2, "new")
+	assert.ErrorIs(t, err, ErrEditWindowExpired)
+	assert.Nil(t, res)
+	repo.AssertNotCalled(t, "UpdateComment", comment)
+}
+
+func TestUpdateComment_AdminAfterEditWindow(t *testing.T) {
+	repo := new(mocks.MockCommentRepo)
+	userRepo := new(mocks.MockUserRepo)
+	postRepo, threadRepo := openThreadRepos()
+	service := NewCommentService(repo, postRepo, threadRepo, userRepo, 15*time.Minute)
+
+	comment := &models.Comment{ID: 1, AuthorID: 2, CreatedAt: time.Now().Add(-time.Hour)}
+	repo.On("GetCommentByID", 1).Return(comment, nil)
+	userRepo.On("GetUserRole", 4).Return("admin", nil)
+	repo.On("UpdateComment", comment).Return(nil)
+
+	res, err := service.UpdateComment(1, 4, "new")
+	assert.NoError(t, err)
+	assert.Equal(t, "new", res.Content)
+}
+
+func TestUpdateComment_ModeratorOtherAuthor(t *testing.T) {
+	repo := new(mocks.MockCommentRepo)
+	userRepo := new(mocks.MockUserRepo)
+	postRepo, threadRepo := openThreadRepos()
+	service := NewCommentService(repo, postRepo, threadRepo, userRepo, 15*time.Minute)
+
+	comment := &models.Comment{ID: 1, AuthorID: 2, CreatedAt: time.Now().Add(-time.Hour)}
+	repo.On("GetCommentByID", 1).Return(comment, nil)
+	userRepo.On("GetUserRole", 5).Return("moderator", nil)
+	repo.On("UpdateComment", comment).Return(nil)
+
+	res, err := service.UpdateComment(1, 5, "new")
+	assert.NoError(t, err)
+	assert.Equal(t, "new", res.Content)
+}
+
+func TestReplyToComment_Success(t *testing.T) {
+	repo := new(mocks.MockCommentRepo)
+	userRepo := new(mocks.MockUserRepo)
+	postRepo, threadRepo := openThreadRepos()
+	service := NewCommentService(repo, postRepo, threadRepo, userRepo, 0)
+
+	parent := &models.Comment{ID: 1, PostID: 1, Depth: 1}
+	repo.On("GetCommentByID", 1).Return(parent, nil)
+	repo.On("SaveComment", mock.AnythingOfType("*models.Comment")).Return(nil)
+
+	res, err := service.ReplyToComment(1, 1, 2, "reply")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, res.Depth)
+	assert.Equal(t, 1, *res.ParentCommentID)
+}
+
+func TestReplyToComment_TooDeep(t *testing.T) {
+	repo := new(mocks.MockCommentRepo)
+	userRepo := new(mocks.MockUserRepo)
+	postRepo, threadRepo := openThreadRepos()
+	service := NewCommentService(repo, postRepo, threadRepo, userRepo, 0)
+
+	parent := &models.Comment{ID: 1, PostID: 1, Depth: MaxCommentDepth}
+	repo.On("GetCommentByID", 1).Return(parent, nil)
+
+	res, err := service.ReplyToComment(1, 1, 2, "reply")
+	assert.ErrorIs(t, err, ErrCommentTooDeep)
+	assert.Nil(t, res)
+}
+
+func TestReplyToComment_OtherPost(t *testing.T) {
+	repo := new(mocks.MockCommentRepo)
+	userRepo := new(mocks.MockUserRepo)
+	postRepo, threadRepo := openThreadRepos()
+	service := NewCommentService(repo, postRepo, threadRepo, userRepo, 0)
+
+	parent := &models.Comment{ID: 1, PostID: 2}
+	repo.On("GetCommentByID", 1).Return(parent, nil)
+
+	res, err := service.ReplyToComment(1, 1, 2, "reply")
+	assert.ErrorIs(t, err, ErrInvalidParent)
+	assert.Nil(t, res)
+}
+
+func TestDeleteComment_WithRepliesTombstones(t *testing.T) {
+	repo := new(mocks.MockCommentRepo)
+	userRepo := new(mocks.MockUserRepo)
+	postRepo, threadRepo := openThreadRepos()
+	service := NewCommentService(repo, postRepo, threadRepo, userRepo, 0)
+
+	comment := &models.Comment{ID: 1, AuthorID: 2}
+	repo.On("GetCommentByID", 1).Return(comment, nil)
+	userRepo.On("GetUserRole", 2).Return("user", nil)
+	repo.On("RemoveComment", 1).Return(true, nil)
+
+	err := service.DeleteComment(1, 2)
+	assert.NoError(t, err)
+	repo.AssertNotCalled(t, "DeleteComment", 1)
+}
+
+func TestDeleteComment_RemovesOrphanedTombstone(t *testing.T) {
+	repo := new(mocks.MockCommentRepo)
+	userRepo := new(mocks.MockUserRepo)
+	postRepo, threadRepo := openThreadRepos()
+	service := NewCommentService(repo, postRepo, threadRepo, userRepo, 0)
+
+	parentID := 1
+	parent := &models.Comment{ID: 1, AuthorID: 3, Deleted: true}
+	reply := &models.Comment{ID: 2, AuthorID: 2, ParentCommentID: &parentID, Depth: 1}
+	repo.On("GetCommentByID", 2).Return(reply, nil)
+	repo.On("GetCommentByID", 1).Return(parent, nil)
+	userRepo.On("GetUserRole", 2).Return("user", nil)
+	repo.On("RemoveComment", 2).Return(false, nil)
+	repo.On("HasReplies", 1).Return(false, nil)
+	repo.On("DeleteComment", 1).Return(nil)
+
+	err := service.DeleteComment(2, 2)
+	assert.NoError(t, err)
+	repo.AssertCalled(t, "DeleteComment", 1)
+}
+
+func TestCreateComment_ThreadLocked(t *testing.T) {
+	repo := new(mocks.MockCommentRepo)
+	postRepo := new(mocks.MockPostRepo)
+	threadRepo := new(mocks.MockThreadRepo)
+	service := NewCommentService(repo, postRepo, threadRepo, new(mocks.MockUserRepo), 0)
+
+	postRepo.On("GetPostByID", 1).Return(&models.Post{ID: 1, ThreadID: 3}, nil)
+	threadRepo.On("GetByID", 3).Return(&models.Thread{ID: 3, Locked: true}, nil)
+	repo.On("GetCommentByID", 7).Return(&models.Comment{ID: 7, PostID: 1}, nil)
+
+	_, err := service.CreateComment(1, 2, "content")
+	assert.ErrorIs(t, err, ErrThreadLocked)
+	_, err = service.ReplyToComment(1, 7, 2, "content")
+	assert.ErrorIs(t, err, ErrThreadLocked)
+	repo.AssertNotCalled(t, "SaveComment", mock.Anything)
+}
+
+func TestCreateComment_ThreadLockedConcurrently(t *testing.T) {
+	repo := new(mocks.MockCommentRepo)
+	postRepo := new(mocks.MockPostRepo)
+	threadRepo := new(mocks.MockThreadRepo)
+	service := NewCommentService(repo, postRepo, threadRepo, new(mocks.MockUserRepo), 0)
+
+	postRepo.On("GetPostByID", 1).Return(&models.Post{ID: 1, ThreadID: 3}, nil)
+	threadRepo.On("GetByID", 3).Return(&models.Thread{ID: 3}, nil)
+	repo.On("GetCommentByID", 7).Return(&models.Comment{ID: 7, PostID: 1}, nil)
+	repo.On("SaveComment", mock.AnythingOfType("*models.Comment")).Return(repository.ErrThreadLocked)
+
+	_, err := service.CreateComment(1, 2, "content")
+	assert.ErrorIs(t, err, ErrThreadLocked)
+	_, err = service.ReplyToComment(1, 7, 2, "content")
+	assert.ErrorIs(t, err, ErrThreadLocked)
+}
+
+func TestCreateComment_PostNotFound(t *testing.T) {
+	repo := new(mocks.MockCommentRepo)
+	postRepo := new(mocks.MockPostRepo)
+	service := NewCommentService(repo, postRepo, new(mocks.MockThreadRepo), new(mocks.MockUserRepo), 0)
+
+	postRepo.On("GetPostByID", 1).Return((*models.Post)(nil), repository.ErrPostNotFound)
+
+	_, err := service.CreateComment(1, 2, "content")
+	assert.ErrorIs(t, err, ErrPostNotFound)
+}
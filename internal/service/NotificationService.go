@@ -0,0 +1,470 @@
+package service
+
+import (
+	"ForumService/internal/models"
+	"ForumService/internal/repository"
+	"regexp"
+	"sort"
+	"unicode/utf8"
+)
+
+// Размер страницы уведомлений
+const (
+	DefaultNotificationLimit = 20
+	MaxNotificationLimit     = 100
+)
+
+// MaxMentions - сколько разных пользователей можно упомянуть в одной записи,
+// остальные упоминания остаются простым текстом
+const MaxMentions = 20
+
+// maxUsernameLength - длина username в таблице users
+const maxUsernameLength = 50
+
+// MentionPattern находит упоминания @username. Первая группа - символ перед "@" или пустая
+// строка в начале текста: так адреса почты не считаются упоминаниями. Вторая группа - имя,
+// которое не начинается и не заканчивается точкой или дефисом.
+var MentionPattern = regexp.MustCompile(`(^|[^\p{L}\p{N}_@.])@([\p{L}\p{N}_](?:[\p{L}\p{N}_.-]*[\p{L}\p{N}_])?)`)
+
+// ParseMentions возвращает имена упомянутых в тексте пользователей без повторов в порядке
+// первого упоминания, не больше MaxMentions. Упоминания внутри цитат постов не учитываются:
+// цитата не должна заново уведомлять тех, кого упомянул автор исходного поста.
+func ParseMentions(content string) []string {
+	var usernames []string
+	seen := make(map[string]bool)
+	content = QuotePattern.ReplaceAllString(content, " ")
+	for _, match := range MentionPattern.FindAllStringSubmatch(content, -1) {
+		username := match[2]
+		if seen[username] || utf8.RuneCountInString(username) > maxUsernameLength {
+			continue
+		}
+		seen[username] = true
+		usernames = append(usernames, username)
+		if len(usernames) == MaxMentions {
+			break
+		}
+	}
+	return usernames
+}
+
+// NotificationDeliverer доставляет новые уведомления подключенным пользователям
+type NotificationDeliverer interface {
+	DeliverNotification(notification models.Notification)
+}
+
+// NotificationService ведет подписки на треды и посты, упоминания пользователей
+// и уведомления о новых записях. Автор записи не получает уведомлений о собственных
+// постах и комментариях и об упоминании самого себя, а пользователи, которым раздел
+// треда не виден, - никаких уведомлений о записях треда.
+type NotificationService interface {
+	// Subscribe подписывает пользователя на тред или пост, повторная подписка не ошибка.
+	// Записи из разделов, закрытых для роли role, считаются несуществующими.
+	Subscribe(userID int, role string, targetType string, targetID int) error
+	Unsubscribe(userID int, targetType string, targetID int) error
+	IsSubscribed(userID int, targetType string, targetID int) (bool, error)
+
+	// ThreadCreated подписывает автора на новый тред
+	ThreadCreated(thread *models.Thread) error
+	// PostCreated подписывает автора на новый пост и уведомляет подписчиков треда
+	// и упомянутых пользователей. Упоминания сохраняются и записываются в post.Mentions.
+	PostCreated(post *models.Post) error
+	// PostUpdated сохраняет упоминания отредактированного поста и уведомляет только
+	// пользователей, которых не было среди упомянутых до правки
+	PostUpdated(post *models.Post) error
+	// CommentCreated уведомляет подписчиков поста, упомянутых пользователей, а при ответе -
+	// автора родительского комментария. Упоминания записываются в comment.Mentions.
+	CommentCreated(comment *models.Comment) error
+	// CommentUpdated сохраняет упоминания комментария так же, как PostUpdated
+	CommentUpdated(comment *models.Comment) error
+	// ChatMessageCreated уведомляет упомянутых в сообщении чата и записывает их в message.Mentions
+	ChatMessageCreated(message *models.ChatMessage) error
+	// Mentions возвращает упоминания в записях вида targetType (models.MentionTarget*)
+	Mentions(targetType string, targetIDs []int) (map[int][]models.Mention, error)
+
+	// List возвращает страницу уведомлений пользователя, страницы нумеруются с единицы
+	List(userID int, unreadOnly bool, page, limit int) (*models.NotificationPage, error)
+	UnreadCount(userID int) (int, error)
+	MarkRead(userID, notificationID int) error
+	// MarkAllRead отмечает прочитанными все уведомления пользователя и возвращает их число
+	MarkAllRead(userID int) (int, error)
+}
+
+type notificationService struct {
+	subscriptions repository.SubscriptionRepository
+	notifications repository.NotificationRepository
+	mentions      repository.MentionRepository
+	postRepo      repository.PostRepository
+	commentRepo   repository.CommentRepository
+	threadRepo    repository.ThreadRepository
+	userRepo      repository.UserRepository
+	access        categoryAccess
+	deliverer     NotificationDeliverer
+}
+
+// NewNotificationService создает сервис уведомлений. Сохраненные уведомления сразу
+// передаются deliverer; при nil они доступны только через List. Репозиторий разделов нужен,
+// чтобы не подписывать и не уведомлять пользователей, которым раздел треда не виден.
+func NewNotificationService(
+	subscriptions repository.SubscriptionRepository,
+	notifications repository.NotificationRepository,
+	mentions repository.MentionRepository,
+	postRepo repository.PostRepository,
+	commentRepo repository.CommentRepository,
+	threadRepo repository.ThreadRepository,
+	userRepo repository.UserRepository,
+	categoryRepo repository.CategoryRepository,
+	deliverer NotificationDeliverer,
+) NotificationService {
+	return &notificationService{
+		subscriptions: subscriptions,
+		notifications: notifications,
+		mentions:      mentions,
+		postRepo:      postRepo,
+		commentRepo:   commentRepo,
+		threadRepo:    threadRepo,
+		userRepo:      userRepo,
+		access:        categoryAccess{threadRepo: threadRepo, postRepo: postRepo, categoryRepo: categoryRepo},
+		deliverer:     deliverer,
+	}
+}
+
+func (s *notificationService) Subscribe(userID int, role string, targetType string, targetID int) error {
+	if !isSubscriptionTarget(targetType) {
+		return ErrInvalidSubscription
+	}
+	if err := s.access.checkTarget(targetType, targetID, models.Role(role)); err != nil {
+		return err
+	}
+	return translateRepoError(s.subscriptions.Subscribe(userID, targetType, targetID))
+}
+
+func (s *notificationService) Unsubscribe(userID int, targetType string, targetID int) error {
+	if !isSubscriptionTarget(targetType) {
+		return ErrInvalidSubscription
+	}
+	return translateRepoError(s.subscriptions.Unsubscribe(userID, targetType, targetID))
+}
+
+func (s *notificationService) IsSubscribed(userID int, targetType string, targetID int) (bool, error) {
+	if !isSubscriptionTarget(targetType) {
+		return false, ErrInvalidSubscription
+	}
+	if userID == 0 {
+		return false, nil
+	}
+	subscribed, err := s.subscriptions.IsSubscribed(userID, targetType, targetID)
+	if err != nil {
+		return false, translateRepoError(err)
+	}
+	return subscribed, nil
+}
+
+func (s *notificationService) ThreadCreated(thread *models.Thread) error {
+	return translateRepoError(s.subscriptions.Subscribe(thread.AuthorID, models.SubscriptionTargetThread, thread.ID))
+}
+
+func (s *notificationService) PostCreated(post *models.Post) error {
+	if err := s.subscriptions.Subscribe(post.AuthorID, models.SubscriptionTargetPost, post.ID); err != nil {
+		return translateRepoError(err)
+	}
+
+	subscribers, err := s.subscriptions.GetSubscribers(models.SubscriptionTargetThread, post.ThreadID)
+	if err != nil {
+		return translateRepoError(err)
+	}
+	recipients := make(map[int]string, len(subscribers))
+	for _, userID := range subscribers {
+		recipients[userID] = models.NotificationNewPost
+	}
+	if err := s.syncPostMentions(post, true, recipients); err != nil {
+		return err
+	}
+	return s.notify(recipients, postNotification(post), post)
+}
+
+func (s *notificationService) PostUpdated(post *models.Post) error {
+	recipients := make(map[int]string)
+	if err := s.syncPostMentions(post, false, recipients); err != nil {
+		return err
+	}
+	return s.notify(recipients, postNotification(post), post)
+}
+
+// syncPostMentions сохраняет упоминания поста и отмечает новых упомянутых среди получателей.
+// Упомянутые, которым раздел треда не виден, остаются в упоминаниях, но notify их отбросит.
+func (s *notificationService) syncPostMentions(post *models.Post, created bool, recipients map[int]string) error {
+	mentions, added, err := s.syncMentions(models.MentionTargetPost, post.ID, post.Content, created)
+	if err != nil {
+		return err
+	}
+	post.Mentions = mentions
+	for _, userID := range added {
+		recipients[userID] = models.NotificationMention
+	}
+	return nil
+}
+
+func (s *notificationService) CommentCreated(comment *models.Comment) error {
+	post, err := s.postRepo.GetPostByID(comment.PostID)
+	if err != nil {
+		return translateRepoError(err)
+	}
+
+	subscribers, err := s.subscriptions.GetSubscribers(models.SubscriptionTargetPost, post.ID)
+	if err != nil {
+		return translateRepoError(err)
+	}
+	recipients := make(map[int]string, len(subscribers)+1)
+	for _, userID := range subscribers {
+		recipients[userID] = models.NotificationNewComment
+	}
+	if err := s.syncCommentMentions(comment, true, recipients); err != nil {
+		return err
+	}
+	// Автор родительского комментария узнает именно об ответе, даже если подписан на пост или упомянут
+	if comment.ParentCommentID != nil {
+		parent, err := s.commentRepo.GetCommentByID(*comment.ParentCommentID)
+		if err != nil {
+			return translateRepoError(err)
+		}
+		recipients[parent.AuthorID] = models.NotificationReply
+	}
+
+	return s.notify(recipients, commentNotification(post, comment), post)
+}
+
+func (s *notificationService) CommentUpdated(comment *models.Comment) error {
+	recipients := make(map[int]string)
+	if err := s.syncCommentMentions(comment, false, recipients); err != nil {
+		return err
+	}
+	if len(recipients) == 0 {
+		return nil
+	}
+
+	post, err := s.postRepo.GetPostByID(comment.PostID)
+	if err != nil {
+		return translateRepoError(err)
+	}
+	return s.notify(recipients, commentNotification(post, comment), post)
+}
+
+// syncCommentMentions сохраняет упоминания комментария и отмечает новых упомянутых среди получателей.
+// Как и у постов, права на раздел упомянутых проверяет notify.
+func (s *notificationService) syncCommentMentions(comment *models.Comment, created bool, recipients map[int]string) error {
+	mentions, added, err := s.syncMentions(models.MentionTargetComment, comment.ID, comment.Content, created)
+	if err != nil {
+		return err
+	}
+	comment.Mentions = mentions
+	for _, userID := range added {
+		recipients[userID] = models.NotificationMention
+	}
+	return nil
+}
+
+func (s *notificationService) ChatMessageCreated(message *models.ChatMessage) error {
+	mentions, added, err := s.syncMentions(models.MentionTargetChat, message.ID, message.Content, true)
+	if err != nil {
+		return err
+	}
+	message.Mentions = mentions
+
+	recipients := make(map[int]string, len(added))
+	for _, userID := range added {
+		recipients[userID] = models.NotificationMention
+	}
+	messageID := message.ID
+	return s.notify(recipients, models.Notification{ActorID: message.AuthorID, ChatMessageID: &messageID}, nil)
+}
+
+func (s *notificationService) Mentions(targetType string, targetIDs []int) (map[int][]models.Mention, error) {
+	mentions, err := s.mentions.GetByTargets(targetType, targetIDs)
+	if err != nil {
+		return nil, translateRepoError(err)
+	}
+	return mentions, nil
+}
+
+// syncMentions заменяет упоминания записи упомянутыми в content существующими пользователями.
+// Возвращает все упоминания записи и ID пользователей, которых среди них раньше не было.
+// У только что созданной записи (created) упоминаний еще нет, и без них хранилище не трогается.
+func (s *notificationService) syncMentions(targetType string, targetID int, content string, created bool) ([]models.Mention, []int, error) {
+	var mentions []models.Mention
+	if usernames := ParseMentions(content); len(usernames) > 0 {
+		resolved, err := s.mentions.ResolveUsernames(usernames)
+		if err != nil {
+			return nil, nil, translateRepoError(err)
+		}
+		mentions = resolved
+	}
+	if created && len(mentions) == 0 {
+		return nil, nil, nil
+	}
+
+	userIDs := make([]int, len(mentions))
+	for i, mention := range mentions {
+		userIDs[i] = mention.UserID
+	}
+	added, err := s.mentions.Replace(targetType, targetID, userIDs)
+	if err != nil {
+		return nil, nil, translateRepoError(err)
+	}
+	return mentions, added, nil
+}
+
+// postNotification - общие поля уведомлений о посте
+func postNotification(post *models.Post) models.Notification {
+	return models.Notification{ActorID: post.AuthorID, ThreadID: post.ThreadID, PostID: post.ID}
+}
+
+// commentNotification - общие поля уведомлений о комментарии к посту
+func commentNotification(post *models.Post, comment *models.Comment) models.Notification {
+	commentID := comment.ID
+	return models.Notification{ActorID: comment.AuthorID, ThreadID: post.ThreadID, PostID: post.ID, CommentID: &commentID}
+}
+
+// notify сохраняет уведомления для получателей, кроме автора записи и пользователей, которым
+// не виден раздел треда, и доставляет их. В base заполнены автор и ссылки на запись;
+// post нужен для заголовка и проверки раздела и равен nil у сообщений чата.
+func (s *notificationService) notify(recipients map[int]string, base models.Notification, post *models.Post) error {
+	delete(recipients, base.ActorID)
+	if post != nil && len(recipients) > 0 {
+		if err := s.dropUnreadable(recipients, post.ThreadID); err != nil {
+			return err
+		}
+	}
+	if len(recipients) == 0 {
+		return nil
+	}
+
+	userIDs := make([]int, 0, len(recipients))
+	for userID := range recipients {
+		userIDs = append(userIDs, userID)
+	}
+	sort.Ints(userIDs)
+
+	notifications := make([]*models.Notification, len(userIDs))
+	for i, userID := range userIDs {
+		notification := base
+		notification.UserID = userID
+		notification.Type = recipients[userID]
+		notifications[i] = &notification
+	}
+	if err := s.notifications.SaveAll(notifications); err != nil {
+		return translateRepoError(err)
+	}
+
+	if s.deliverer == nil {
+		return nil
+	}
+	title := ""
+	if post != nil {
+		title = s.postTitle(post)
+	}
+	actorName := s.actorName(base.ActorID)
+	for _, notification := range notifications {
+		notification.Title = title
+		notification.ActorName = actorName
+		s.deliverer.DeliverNotification(*notification)
+	}
+	return nil
+}
+
+// dropUnreadable убирает из получателей пользователей, чья роль не дает читать раздел треда.
+// Подписки и автоподписки авторов не отзываются, когда read_role раздела ужесточают,
+// поэтому права проверяются при каждом уведомлении. Роли запрашиваются только для
+// разделов, закрытых от гостей.
+func (s *notificationService) dropUnreadable(recipients map[int]string, threadID int) error {
+	readRole, err := s.access.threadReadRole(threadID)
+	if err != nil {
+		return err
+	}
+	if models.RoleGuest.Allows(readRole) {
+		return nil
+	}
+	for userID := range recipients {
+		role, err := s.userRepo.GetUserRole(userID)
+		if err != nil {
+			return translateRepoError(err)
+		}
+		if !models.Role(role).Allows(readRole) {
+			delete(recipients, userID)
+		}
+	}
+	return nil
+}
+
+// actorName возвращает имя автора записи для доставленного уведомления, пустое при ошибке
+func (s *notificationService) actorName(actorID int) string {
+	user, err := s.userRepo.GetUserByID(actorID)
+	if err != nil {
+		return ""
+	}
+	return user.Username
+}
+
+// postTitle возвращает заголовок поста, а у поста без заголовка - заголовок треда, как в List.
+// Доставка не должна срываться из-за заголовка, поэтому ошибка дает пустую строку.
+func (s *notificationService) postTitle(post *models.Post) string {
+	if post.Title != "" {
+		return post.Title
+	}
+	thread, err := s.threadRepo.GetByID(post.ThreadID)
+	if err != nil {
+		return ""
+	}
+	return thread.Title
+}
+
+func (s *notificationService) List(userID int, unreadOnly bool, page, limit int) (*models.NotificationPage, error) {
+	if limit <= 0 {
+		limit = DefaultNotificationLimit
+	}
+	if limit > MaxNotificationLimit {
+		limit = MaxNotificationLimit
+	}
+	if page < 1 {
+		page = 1
+	}
+
+	notifications, total, err := s.notifications.GetByUser(userID, unreadOnly, limit, (page-1)*limit)
+	if err != nil {
+		return nil, translateRepoError(err)
+	}
+	unread, err := s.notifications.CountUnread(userID)
+	if err != nil {
+		return nil, translateRepoError(err)
+	}
+	return &models.NotificationPage{
+		Notifications: notifications,
+		Unread:        unread,
+		Total:         total,
+		Page:          page,
+		Limit:         limit,
+	}, nil
+}
+
+func (s *notificationService) UnreadCount(userID int) (int, error) {
+	unread, err := s.notifications.CountUnread(userID)
+	if err != nil {
+		return 0, translateRepoError(err)
+	}
+	return unread, nil
+}
+
+func (s *notificationService) MarkRead(userID, notificationID int) error {
+	return translateRepoError(s.notifications.MarkRead(userID, notificationID))
+}
+
+func (s *notificationService) MarkAllRead(userID int) (int, error) {
+	updated, err := s.notifications.MarkAllRead(userID)
+	if err != nil {
+		return 0, translateRepoError(err)
+	}
+	return updated, nil
+}
+
+func isSubscriptionTarget(targetType string) bool {
+	return targetType == models.SubscriptionTargetThread || targetType == models.SubscriptionTargetPost
+}
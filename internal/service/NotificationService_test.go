@@ -0,0 +1,397 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"ForumService/internal/models"
+	"ForumService/internal/repository"
+	"ForumService/internal/service/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type recordingDeliverer struct {
+	delivered []models.Notification
+}
+
+func (d *recordingDeliverer) DeliverNotification(notification models.Notification) {
+	d.delivered = append(d.delivered, notification)
+}
+
+type notificationTestDeps struct {
+	subscriptions *mocks.MockSubscriptionRepo
+	notifications *mocks.MockNotificationRepo
+	mentions      *mocks.MockMentionRepo
+	posts         *mocks.MockPostRepo
+	comments      *mocks.MockCommentRepo
+	threads       *mocks.MockThreadRepo
+	users         *mocks.MockUserRepo
+	categories    *mocks.MockCategoryRepo
+	deliverer     *recordingDeliverer
+}
+
+func newNotificationTestService() (NotificationService, *notificationTestDeps) {
+	deps := &notificationTestDeps{
+		subscriptions: new(mocks.MockSubscriptionRepo),
+		notifications: new(mocks.MockNotificationRepo),
+		mentions:      new(mocks.MockMentionRepo),
+		posts:         new(mocks.MockPostRepo),
+		comments:      new(mocks.MockCommentRepo),
+		threads:       new(mocks.MockThreadRepo),
+		users:         new(mocks.MockUserRepo),
+		categories:    new(mocks.MockCategoryRepo),
+		deliverer:     &recordingDeliverer{},
+	}
+	service := NewNotificationService(deps.subscriptions, deps.notifications, deps.mentions, deps.posts, deps.comments, deps.threads, deps.users, deps.categories, deps.deliverer)
+	return service, deps
+}
+
+func TestSubscribe(t *testing.T) {
+	service, deps := newNotificationTestService()
+	deps.threads.On("GetByID", 1).Return(&models.Thread{ID: 1}, nil)
+	deps.posts.On("GetPostByID", 99).Return((*models.Post)(nil), repository.ErrPostNotFound)
+	deps.subscriptions.On("Subscribe", 2, models.SubscriptionTargetThread, 1).Return(nil)
+	deps.subscriptions.On("Unsubscribe", 2, models.SubscriptionTargetThread, 1).Return(repository.ErrSubscriptionNotFound)
+
+	assert.NoError(t, service.Subscribe(2, "user", models.SubscriptionTargetThread, 1))
+	assert.ErrorIs(t, service.Subscribe(2, "user", models.SubscriptionTargetPost, 99), ErrPostNotFound)
+	assert.ErrorIs(t, service.Subscribe(2, "user", "comment", 1), ErrInvalidSubscription)
+	assert.ErrorIs(t, service.Unsubscribe(2, models.SubscriptionTargetThread, 1), ErrSubscriptionNotFound)
+
+	subscribed, err := service.IsSubscribed(0, models.SubscriptionTargetThread, 1)
+	assert.NoError(t, err)
+	assert.False(t, subscribed, "гость ни на что не подписан")
+	deps.subscriptions.AssertNumberOfCalls(t, "IsSubscribed", 0)
+}
+
+func TestSubscribe_ClosedCategory(t *testing.T) {
+	service, deps := newNotificationTestService()
+	staffID := 3
+	deps.threads.On("GetByID", 7).Return(&models.Thread{ID: 7, CategoryID: &staffID}, nil)
+	deps.posts.On("GetPostByID", 8).Return(&models.Post{ID: 8, ThreadID: 7}, nil)
+	deps.categories.On("GetByID", staffID).Return(&models.Category{ID: staffID, ReadRole: models.RoleModerator}, nil)
+	deps.subscriptions.On("Subscribe", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+
+	assert.ErrorIs(t, service.Subscribe(2, "user", models.SubscriptionTargetThread, 7), ErrThreadNotFound,
+		"закрытый тред неотличим от несуществующего")
+	assert.ErrorIs(t, service.Subscribe(2, "user", models.SubscriptionTargetPost, 8), ErrPostNotFound)
+	deps.subscriptions.AssertNumberOfCalls(t, "Subscribe", 0)
+
+	assert.NoError(t, service.Subscribe(2, "moderator", models.SubscriptionTargetPost, 8))
+	deps.subscriptions.AssertNumberOfCalls(t, "Subscribe", 1)
+}
+
+func TestThreadCreated_SubscribesAuthor(t *testing.T) {
+	service, deps := newNotificationTestService()
+	deps.subscriptions.On("Subscribe", 3, models.SubscriptionTargetThread, 1).Return(nil)
+
+	assert.NoError(t, service.ThreadCreated(&models.Thread{ID: 1, AuthorID: 3}))
+	deps.subscriptions.AssertExpectations(t)
+}
+
+func TestPostCreated(t *testing.T) {
+	service, deps := newNotificationTestService()
+	post := &models.Post{ID: 5, ThreadID: 1, AuthorID: 2}
+	deps.subscriptions.On("Subscribe", 2, models.SubscriptionTargetPost, 5).Return(nil)
+	deps.subscriptions.On("GetSubscribers", models.SubscriptionTargetThread, 1).Return([]int{2, 3, 4}, nil)
+	deps.notifications.On("SaveAll", mock.Anything).Return(nil).Run(func(args mock.Arguments) {
+		for i, notification := range args.Get(0).([]*models.Notification) {
+			notification.ID = 10 + i
+		}
+	})
+	deps.threads.On("GetByID", 1).Return(&models.Thread{ID: 1, Title: "Тред"}, nil)
+	deps.users.On("GetUserByID", 2).Return(&models.User{ID: 2, Username: "alice"}, nil)
+
+	assert.NoError(t, service.PostCreated(post))
+
+	saved := deps.notifications.Calls[0].Arguments.Get(0).([]*models.Notification)
+	assert.Equal(t, []*models.Notification{
+		{ID: 10, UserID: 3, Type: models.NotificationNewPost, ActorID: 2, ActorName: "alice", ThreadID: 1, PostID: 5, Title: "Тред"},
+		{ID: 11, UserID: 4, Type: models.NotificationNewPost, ActorID: 2, ActorName: "alice", ThreadID: 1, PostID: 5, Title: "Тред"},
+	}, saved, "автор поста не получает уведомления")
+	assert.Len(t, deps.deliverer.delivered, 2)
+	assert.Equal(t, 3, deps.deliverer.delivered[0].UserID)
+	assert.Equal(t, "Тред", deps.deliverer.delivered[0].Title, "у поста без заголовка - заголовок треда")
+}
+
+func TestPostCreated_NoSubscribers(t *testing.T) {
+	service, deps := newNotificationTestService()
+	deps.subscriptions.On("Subscribe", 2, models.SubscriptionTargetPost, 5).Return(nil)
+	deps.subscriptions.On("GetSubscribers", models.SubscriptionTargetThread, 1).Return([]int{2}, nil)
+
+	assert.NoError(t, service.PostCreated(&models.Post{ID: 5, ThreadID: 1, AuthorID: 2}))
+	deps.notifications.AssertNumberOfCalls(t, "SaveAll", 0)
+	assert.Empty(t, deps.deliverer.delivered)
+}
+
+func TestCommentCreated_Reply(t *testing.T) {
+	service, deps := newNotificationTestService()
+	parentID := 7
+	comment := &models.Comment{ID: 8, PostID: 5, ParentCommentID: &parentID, AuthorID: 4}
+	deps.posts.On("GetPostByID", 5).Return(&models.Post{ID: 5, ThreadID: 1, AuthorID: 2, Title: "Пост"}, nil)
+	deps.subscriptions.On("GetSubscribers", models.SubscriptionTargetPost, 5).Return([]int{2, 3, 4}, nil)
+	deps.threads.On("GetByID", 1).Return(&models.Thread{ID: 1}, nil)
+	deps.comments.On("GetCommentByID", 7).Return(&models.Comment{ID: 7, PostID: 5, AuthorID: 3}, nil)
+	deps.notifications.On("SaveAll", mock.Anything).Return(nil)
+	deps.users.On("GetUserByID", 4).Return((*models.User)(nil), repository.ErrUserNotFound)
+
+	assert.NoError(t, service.CommentCreated(comment), "без имени автора уведомление все равно доставляется")
+	assert.Len(t, deps.deliverer.delivered, 2)
+
+	saved := deps.notifications.Calls[0].Arguments.Get(0).([]*models.Notification)
+	if assert.Len(t, saved, 2) {
+		assert.Equal(t, 2, saved[0].UserID)
+		assert.Equal(t, models.NotificationNewComment, saved[0].Type)
+		assert.Equal(t, 3, saved[1].UserID)
+		assert.Equal(t, models.NotificationReply, saved[1].Type, "автор родительского комментария получает ответ")
+		assert.Equal(t, 8, *saved[1].CommentID)
+		assert.Equal(t, "Пост", saved[1].Title)
+	}
+	deps.threads.AssertNumberOfCalls(t, "GetByID", 1)
+	deps.users.AssertNumberOfCalls(t, "GetUserRole", 0)
+}
+
+func TestPostCreated_ClosedCategory(t *testing.T) {
+	service, deps := newNotificationTestService()
+	staffID := 3
+	post := &models.Post{ID: 5, ThreadID: 1, AuthorID: 2, Title: "Пост"}
+	deps.subscriptions.On("Subscribe", 2, models.SubscriptionTargetPost, 5).Return(nil)
+	// bob подписался, когда раздел был открыт, и остался подписчиком после смены read_role
+	deps.subscriptions.On("GetSubscribers", models.SubscriptionTargetThread, 1).Return([]int{2, 3, 4}, nil)
+	deps.threads.On("GetByID", 1).Return(&models.Thread{ID: 1, CategoryID: &staffID}, nil)
+	deps.categories.On("GetByID", staffID).Return(&models.Category{ID: staffID, ReadRole: models.RoleModerator}, nil)
+	deps.users.On("GetUserRole", 3).Return("user", nil)
+	deps.users.On("GetUserRole", 4).Return("moderator", nil)
+	deps.users.On("GetUserByID", 2).Return(&models.User{ID: 2, Username: "alice"}, nil)
+	deps.notifications.On("SaveAll", mock.Anything).Return(nil)
+
+	assert.NoError(t, service.PostCreated(post))
+
+	saved := deps.notifications.Calls[0].Arguments.Get(0).([]*models.Notification)
+	if assert.Len(t, saved, 1, "пользователь без доступа к разделу не получает уведомление") {
+		assert.Equal(t, 4, saved[0].UserID)
+	}
+	if assert.Len(t, deps.deliverer.delivered, 1) {
+		assert.Equal(t, 4, deps.deliverer.delivered[0].UserID)
+	}
+}
+
+func TestCommentCreated_PostNotFound(t *testing.T) {
+	service, deps := newNotificationTestService()
+	deps.posts.On("GetPostByID", 5).Return((*models.Post)(nil), repository.ErrPostNotFound)
+
+	assert.ErrorIs(t, service.CommentCreated(&models.Comment{ID: 8, PostID: 5, AuthorID: 4}), ErrPostNotFound)
+}
+
+func TestListNotifications(t *testing.T) {
+	service, deps := newNotificationTestService()
+	notifications := []models.Notification{{ID: 10, UserID: 2, Type: models.NotificationNewPost}}
+	deps.notifications.On("GetByUser", 2, true, 10, 10).Return(notifications, 11, nil)
+	deps.notifications.On("GetByUser", 2, false, MaxNotificationLimit, 0).Return([]models.Notification{}, 0, nil)
+	deps.notifications.On("CountUnread", 2).Return(4, nil)
+
+	page, err := service.List(2, true, 2, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, &models.NotificationPage{Notifications: notifications, Unread: 4, Total: 11, Page: 2, Limit: 10}, page)
+
+	page, err = service.List(2, false, 0, 1000)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, page.Page)
+	assert.Equal(t, MaxNotificationLimit, page.Limit)
+}
+
+func TestMarkNotificationsRead(t *testing.T) {
+	service, deps := newNotificationTestService()
+	deps.notifications.On("MarkRead", 2, 10).Return(nil)
+	deps.notifications.On("MarkRead", 3, 10).Return(repository.ErrNotificationNotFound)
+	deps.notifications.On("MarkAllRead", 2).Return(3, nil)
+
+	assert.NoError(t, service.MarkRead(2, 10))
+	assert.ErrorIs(t, service.MarkRead(3, 10), ErrNotificationNotFound)
+	updated, err := service.MarkAllRead(2)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, updated)
+}
+
+func TestParseMentions(t *testing.T) {
+	assert.Equal(t, []string{"alice", "bob.smith", "Иван"},
+		ParseMentions("@alice, привет! Позови @bob.smith. И @Иван тоже, и еще раз @alice"))
+	assert.Empty(t, ParseMentions("почта alice@example.com и одинокая @ не упоминания"))
+	assert.Equal(t, []string{"a_b"}, ParseMentions("(@a_b)"))
+	assert.Equal(t, []string{"bob"}, ParseMentions("[quote=3]@alice писала[/quote] @bob, что скажешь?"),
+		"упоминания в цитатах не учитываются")
+
+	var many []string
+	for i := 0; i < MaxMentions+5; i++ {
+		many = append(many, fmt.Sprintf("@user%d", i))
+	}
+	assert.Len(t, ParseMentions(strings.Join(many, " ")), MaxMentions)
+}
+
+func TestPostCreated_Mentions(t *testing.T) {
+	service, deps := newNotificationTestService()
+	post := &models.Post{ID: 5, ThreadID: 1, AuthorID: 2, Title: "Пост", Content: "@bob и @carol, а также @alice и @ghost"}
+	mentions := []models.Mention{{UserID: 2, Username: "alice"}, {UserID: 3, Username: "bob"}, {UserID: 4, Username: "carol"}}
+	deps.subscriptions.On("Subscribe", 2, models.SubscriptionTargetPost, 5).Return(nil)
+	deps.subscriptions.On("GetSubscribers", models.SubscriptionTargetThread, 1).Return([]int{2, 3}, nil)
+	deps.mentions.On("ResolveUsernames", []string{"bob", "carol", "alice", "ghost"}).Return(mentions, nil)
+	deps.mentions.On("Replace", models.MentionTargetPost, 5, []int{2, 3, 4}).Return([]int{2, 3, 4}, nil)
+	deps.threads.On("GetByID", 1).Return(&models.Thread{ID: 1}, nil)
+	deps.notifications.On("SaveAll", mock.Anything).Return(nil)
+	deps.users.On("GetUserByID", 2).Return(&models.User{ID: 2, Username: "alice"}, nil)
+
+	assert.NoError(t, service.PostCreated(post))
+	assert.Equal(t, mentions, post.Mentions)
+
+	saved := deps.notifications.Calls[0].Arguments.Get(0).([]*models.Notification)
+	if assert.Len(t, saved, 2, "автор не получает уведомления об упоминании самого себя") {
+		assert.Equal(t, 3, saved[0].UserID)
+		assert.Equal(t, models.NotificationMention, saved[0].Type, "упоминание важнее подписки на тред")
+		assert.Equal(t, 4, saved[1].UserID)
+		assert.Equal(t, models.NotificationMention, saved[1].Type)
+	}
+}
+
+func TestPostUpdated_NotifiesOnlyNewMentions(t *testing.T) {
+	service, deps := newNotificationTestService()
+	post := &models.Post{ID: 5, ThreadID: 1, AuthorID: 2, Title: "Пост", Content: "@bob @carol"}
+	mentions := []models.Mention{{UserID: 3, Username: "bob"}, {UserID: 4, Username: "carol"}}
+	deps.mentions.On("ResolveUsernames", []string{"bob", "carol"}).Return(mentions, nil)
+	deps.mentions.On("Replace", models.MentionTargetPost, 5, []int{3, 4}).Return([]int{4}, nil)
+	deps.threads.On("GetByID", 1).Return(&models.Thread{ID: 1}, nil)
+	deps.notifications.On("SaveAll", mock.Anything).Return(nil)
+	deps.users.On("GetUserByID", 2).Return(&models.User{ID: 2, Username: "alice"}, nil)
+
+	assert.NoError(t, service.PostUpdated(post))
+	assert.Equal(t, mentions, post.Mentions)
+
+	saved := deps.notifications.Calls[0].Arguments.Get(0).([]*models.Notification)
+	assert.Equal(t, []*models.Notification{
+		{UserID: 4, Type: models.NotificationMention, ActorID: 2, ActorName: "alice", ThreadID: 1, PostID: 5, Title: "Пост"},
+	}, saved, "bob уже был упомянут до правки")
+	deps.subscriptions.AssertNumberOfCalls(t, "GetSubscribers", 0)
+}
+
+func TestPostUpdated_RemovedMentions(t *testing.T) {
+	service, deps := newNotificationTestService()
+	deps.mentions.On("Replace", models.MentionTargetPost, 5, []int{}).Return([]int{}, nil)
+
+	post := &models.Post{ID: 5, ThreadID: 1, AuthorID: 2, Content: "без упоминаний"}
+	assert.NoError(t, service.PostUpdated(post))
+	assert.Empty(t, post.Mentions)
+	deps.mentions.AssertExpectations(t)
+	deps.notifications.AssertNumberOfCalls(t, "SaveAll", 0)
+}
+
+func TestCommentUpdated_Mentions(t *testing.T) {
+	service, deps := newNotificationTestService()
+	comment := &models.Comment{ID: 8, PostID: 5, AuthorID: 4, Content: "@bob"}
+	deps.mentions.On("ResolveUsernames", []string{"bob"}).Return([]models.Mention{{UserID: 3, Username: "bob"}}, nil)
+	deps.mentions.On("Replace", models.MentionTargetComment, 8, []int{3}).Return([]int{3}, nil)
+	deps.posts.On("GetPostByID", 5).Return(&models.Post{ID: 5, ThreadID: 1, AuthorID: 2, Title: "Пост"}, nil)
+	deps.notifications.On("SaveAll", mock.Anything).Return(nil)
+	deps.users.On("GetUserByID", 4).Return(&models.User{ID: 4, Username: "dave"}, nil)
+	deps.threads.On("GetByID", 1).Return(&models.Thread{ID: 1}, nil)
+
+	assert.NoError(t, service.CommentUpdated(comment))
+
+	saved := deps.notifications.Calls[0].Arguments.Get(0).([]*models.Notification)
+	if assert.Len(t, saved, 1) {
+		assert.Equal(t, 3, saved[0].UserID)
+		assert.Equal(t, models.NotificationMention, saved[0].Type)
+		assert.Equal(t, 8, *saved[0].CommentID)
+	}
+}
+
+func TestMentions_ClosedCategory(t *testing.T) {
+	staffID := 3
+	// Автор dave пишет в разделе для модераторов и упоминает bob (user) и carol (moderator)
+	setup := func() (NotificationService, *notificationTestDeps) {
+		service, deps := newNotificationTestService()
+		deps.threads.On("GetByID", 1).Return(&models.Thread{ID: 1, Title: "Тред", CategoryID: &staffID}, nil)
+		deps.categories.On("GetByID", staffID).Return(&models.Category{ID: staffID, ReadRole: models.RoleModerator}, nil)
+		deps.posts.On("GetPostByID", 5).Return(&models.Post{ID: 5, ThreadID: 1, AuthorID: 4, Title: "Секретный пост"}, nil)
+		deps.mentions.On("ResolveUsernames", []string{"bob", "carol"}).
+			Return([]models.Mention{{UserID: 2, Username: "bob"}, {UserID: 3, Username: "carol"}}, nil)
+		deps.mentions.On("Replace", mock.Anything, mock.Anything, []int{2, 3}).Return([]int{2, 3}, nil)
+		deps.subscriptions.On("Subscribe", 4, models.SubscriptionTargetPost, 5).Return(nil)
+		deps.subscriptions.On("GetSubscribers", mock.Anything, mock.Anything).Return([]int{}, nil)
+		deps.users.On("GetUserRole", 2).Return("user", nil)
+		deps.users.On("GetUserRole", 3).Return("moderator", nil)
+		deps.users.On("GetUserByID", 4).Return(&models.User{ID: 4, Username: "dave"}, nil)
+		deps.notifications.On("SaveAll", mock.Anything).Return(nil)
+		return service, deps
+	}
+	post := func() *models.Post {
+		return &models.Post{ID: 5, ThreadID: 1, AuthorID: 4, Title: "Секретный пост", Content: "@bob @carol"}
+	}
+	comment := func() *models.Comment {
+		return &models.Comment{ID: 8, PostID: 5, AuthorID: 4, Content: "@bob @carol"}
+	}
+
+	tests := []struct {
+		name string
+		run  func(service NotificationService) error
+	}{
+		{"новый пост", func(service NotificationService) error { return service.PostCreated(post()) }},
+		{"правка поста", func(service NotificationService) error { return service.PostUpdated(post()) }},
+		{"новый комментарий", func(service NotificationService) error { return service.CommentCreated(comment()) }},
+		{"правка комментария", func(service NotificationService) error { return service.CommentUpdated(comment()) }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service, deps := setup()
+			assert.NoError(t, tt.run(service))
+
+			saved := deps.notifications.Calls[0].Arguments.Get(0).([]*models.Notification)
+			if assert.Len(t, saved, 1, "упоминание не раскрывает заголовок и ссылку тем, кому раздел не виден") {
+				assert.Equal(t, 3, saved[0].UserID)
+				assert.Equal(t, models.NotificationMention, saved[0].Type)
+			}
+			for _, delivered := range deps.deliverer.delivered {
+				assert.NotEqual(t, 2, delivered.UserID)
+			}
+		})
+	}
+}
+
+func TestCommentUpdated_NoNewMentions(t *testing.T) {
+	service, deps := newNotificationTestService()
+	deps.mentions.On("ResolveUsernames", []string{"bob"}).Return([]models.Mention{{UserID: 3, Username: "bob"}}, nil)
+	deps.mentions.On("Replace", models.MentionTargetComment, 8, []int{3}).Return([]int{}, nil)
+
+	assert.NoError(t, service.CommentUpdated(&models.Comment{ID: 8, PostID: 5, AuthorID: 4, Content: "@bob"}))
+	deps.posts.AssertNumberOfCalls(t, "GetPostByID", 0)
+	deps.notifications.AssertNumberOfCalls(t, "SaveAll", 0)
+}
+
+func TestChatMessageCreated(t *testing.T) {
+	service, deps := newNotificationTestService()
+	message := &models.ChatMessage{ID: 9, AuthorID: 2, Content: "@bob смотри"}
+	deps.mentions.On("ResolveUsernames", []string{"bob"}).Return([]models.Mention{{UserID: 3, Username: "bob"}}, nil)
+	deps.mentions.On("Replace", models.MentionTargetChat, 9, []int{3}).Return([]int{3}, nil)
+	deps.notifications.On("SaveAll", mock.Anything).Return(nil)
+	deps.users.On("GetUserByID", 2).Return(&models.User{ID: 2, Username: "alice"}, nil)
+
+	assert.NoError(t, service.ChatMessageCreated(message))
+	assert.Equal(t, []models.Mention{{UserID: 3, Username: "bob"}}, message.Mentions)
+
+	if assert.Len(t, deps.deliverer.delivered, 1) {
+		delivered := deps.deliverer.delivered[0]
+		assert.Equal(t, models.NotificationMention, delivered.Type)
+		assert.Zero(t, delivered.PostID, "у упоминания в чате нет поста")
+		assert.Equal(t, 9, *delivered.ChatMessageID)
+		assert.Equal(t, "alice", delivered.ActorName)
+	}
+	deps.threads.AssertNumberOfCalls(t, "GetByID", 0)
+}
+
+func TestChatMessageCreated_NoMentions(t *testing.T) {
+	service, deps := newNotificationTestService()
+
+	assert.NoError(t, service.ChatMessageCreated(&models.ChatMessage{ID: 9, AuthorID: 2, Content: "всем привет"}))
+	deps.mentions.AssertNumberOfCalls(t, "Replace", 0)
+	deps.notifications.AssertNumberOfCalls(t, "SaveAll", 0)
+}
@@ -0,0 +1,191 @@
+package service
+
+import (
+	"ForumService/internal/models"
+	"ForumService/internal/repository"
+	"errors"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	// MinPollOptions и MaxPollOptions ограничивают число вариантов в опросе
+	MinPollOptions = 2
+	MaxPollOptions = 10
+	// MaxPollQuestionLength и MaxPollOptionLength - длина вопроса и варианта в символах
+	MaxPollQuestionLength = 255
+	MaxPollOptionLength   = 100
+)
+
+// PollService ведет опросы в тредах: один опрос на тред, голос можно изменить или
+// снять, пока опрос не закрыт. Закрыть опрос могут автор треда и модераторы.
+type PollService interface {
+	// Validate проверяет и нормализует опрос. Вызывается до создания треда, чтобы
+	// не создать тред, к которому потом не добавится опрос.
+	Validate(poll *models.Poll) error
+	// Create добавляет опрос к треду threadID и заполняет ID опроса и вариантов
+	Create(threadID int, poll *models.Poll) error
+	// Get и GetByThread возвращают опрос с итогами, userID отмечает свои голоса
+	Get(pollID, userID int) (*models.Poll, error)
+	GetByThread(threadID, userID int) (*models.Poll, error)
+	// Vote заменяет голос пользователя на optionIDs, пустой список снимает голос
+	Vote(pollID, userID int, optionIDs []int) (*models.Poll, error)
+	// Close завершает голосование от имени userID
+	Close(pollID, userID int) (*models.Poll, error)
+}
+
+type pollService struct {
+	repo       repository.PollRepository
+	threadRepo repository.ThreadRepository
+	userRepo   repository.UserRepository
+}
+
+func NewPollService(repo repository.PollRepository, threadRepo repository.ThreadRepository, userRepo repository.UserRepository) PollService {
+	return &pollService{
+		repo:       repo,
+		threadRepo: threadRepo,
+		userRepo:   userRepo,
+	}
+}
+
+// Validate обрезает пробелы в вопросе и вариантах; варианты, различающиеся
+// только регистром, считаются повтором
+func (s *pollService) Validate(poll *models.Poll) error {
+	poll.Question = strings.TrimSpace(poll.Question)
+	length := utf8.RuneCountInString(poll.Question)
+	if length == 0 || length > MaxPollQuestionLength {
+		return ErrInvalidPollQuestion
+	}
+
+	if len(poll.Options) < MinPollOptions || len(poll.Options) > MaxPollOptions {
+		return ErrInvalidPollOptions
+	}
+	seen := make(map[string]bool, len(poll.Options))
+	for i := range poll.Options {
+		text := strings.TrimSpace(poll.Options[i].Text)
+		length := utf8.RuneCountInString(text)
+		key := strings.ToLower(text)
+		if length == 0 || length > MaxPollOptionLength || seen[key] {
+			return ErrInvalidPollOptions
+		}
+		seen[key] = true
+		poll.Options[i] = models.PollOption{Text: text}
+	}
+
+	if poll.ClosesAt != nil && !poll.ClosesAt.After(time.Now()) {
+		return ErrInvalidPollClose
+	}
+	return nil
+}
+
+func (s *pollService) Create(threadID int, poll *models.Poll) error {
+	if err := s.Validate(poll); err != nil {
+		return err
+	}
+	poll.ThreadID = threadID
+	if err := s.repo.Create(poll); err != nil {
+		if errors.Is(err, repository.ErrAlreadyExists) {
+			return ErrPollExists
+		}
+		return translateRepoError(err)
+	}
+	return s.fillResults(poll, 0)
+}
+
+func (s *pollService) Get(pollID, userID int) (*models.Poll, error) {
+	poll, err := s.repo.GetByID(pollID)
+	if err != nil {
+		return nil, translateRepoError(err)
+	}
+	if err := s.fillResults(poll, userID); err != nil {
+		return nil, err
+	}
+	return poll, nil
+}
+
+func (s *pollService) GetByThread(threadID, userID int) (*models.Poll, error) {
+	poll, err := s.repo.GetByThreadID(threadID)
+	if err != nil {
+		return nil, translateRepoError(err)
+	}
+	if err := s.fillResults(poll, userID); err != nil {
+		return nil, err
+	}
+	return poll, nil
+}
+
+func (s *pollService) Vote(pollID, userID int, optionIDs []int) (*models.Poll, error) {
+	poll, err := s.repo.GetByID(pollID)
+	if err != nil {
+		return nil, translateRepoError(err)
+	}
+	unique := uniqueIDs(optionIDs)
+	if !poll.MultipleChoice && len(unique) > 1 {
+		return nil, ErrInvalidPollChoice
+	}
+	if err := s.repo.Vote(pollID, userID, unique); err != nil {
+		return nil, translateRepoError(err)
+	}
+	return s.Get(pollID, userID)
+}
+
+func (s *pollService) Close(pollID, userID int) (*models.Poll, error) {
+	poll, err := s.repo.GetByID(pollID)
+	if err != nil {
+		return nil, translateRepoError(err)
+	}
+	thread, err := s.threadRepo.GetByID(poll.ThreadID)
+	if err != nil {
+		return nil, translateRepoError(err)
+	}
+	if thread.AuthorID != userID {
+		role, err := s.userRepo.GetUserRole(userID)
+		if err != nil {
+			return nil, translateRepoError(err)
+		}
+		if !models.Role(role).Allows(models.RoleModerator) {
+			return nil, ErrNoPermission
+		}
+	}
+
+	if err := s.repo.Close(pollID, userID); err != nil {
+		return nil, translateRepoError(err)
+	}
+	return s.Get(pollID, userID)
+}
+
+// fillResults считает голоса по вариантам. Голосовавшие раскрываются только в открытом
+// опросе, а свои голоса пользователь видит в любом.
+func (s *pollService) fillResults(poll *models.Poll, userID int) error {
+	votes, err := s.repo.GetVotes(poll.ID)
+	if err != nil {
+		return translateRepoError(err)
+	}
+
+	byOption := make(map[int]*models.PollOption, len(poll.Options))
+	for i := range poll.Options {
+		poll.Options[i].Votes = 0
+		poll.Options[i].Voters = nil
+		byOption[poll.Options[i].ID] = &poll.Options[i]
+	}
+	voters := make(map[int]bool)
+	poll.MyVotes = nil
+	for _, vote := range votes {
+		option, ok := byOption[vote.OptionID]
+		if !ok {
+			continue
+		}
+		option.Votes++
+		voters[vote.UserID] = true
+		if !poll.Anonymous {
+			option.Voters = append(option.Voters, vote.PollVoter)
+		}
+		if userID != 0 && vote.UserID == userID {
+			poll.MyVotes = append(poll.MyVotes, vote.OptionID)
+		}
+	}
+	poll.TotalVoters = len(voters)
+	poll.Closed = poll.IsClosed(time.Now())
+	return nil
+}
@@ -0,0 +1,193 @@
+package service
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"ForumService/internal/models"
+	"ForumService/internal/repository"
+	"ForumService/internal/service/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func setupPollServiceTest() (PollService, *mocks.MockPollRepo, *mocks.MockThreadRepo, *mocks.MockUserRepo) {
+	repo := new(mocks.MockPollRepo)
+	threadRepo := new(mocks.MockThreadRepo)
+	userRepo := new(mocks.MockUserRepo)
+	return NewPollService(repo, threadRepo, userRepo), repo, threadRepo, userRepo
+}
+
+func testPoll(anonymous bool, multiple bool) *models.Poll {
+	return &models.Poll{
+		ID:             7,
+		ThreadID:       3,
+		Question:       "Когда релиз?",
+		Anonymous:      anonymous,
+		MultipleChoice: multiple,
+		Options:        []models.PollOption{{ID: 11, Text: "В пятницу"}, {ID: 12, Text: "В понедельник"}},
+	}
+}
+
+func TestValidatePoll(t *testing.T) {
+	service, _, _, _ := setupPollServiceTest()
+	past := time.Now().Add(-time.Minute)
+	options := func(texts ...string) []models.PollOption {
+		result := make([]models.PollOption, len(texts))
+		for i, text := range texts {
+			result[i] = models.PollOption{ID: 99, Text: text}
+		}
+		return result
+	}
+
+	poll := &models.Poll{Question: "  Когда релиз? ", Options: options(" В пятницу ", "В понедельник")}
+	require.NoError(t, service.Validate(poll))
+	assert.Equal(t, "Когда релиз?", poll.Question)
+	assert.Equal(t, []models.PollOption{{Text: "В пятницу"}, {Text: "В понедельник"}}, poll.Options)
+
+	tests := []struct {
+		name string
+		poll *models.Poll
+		want error
+	}{
+		{"пустой вопрос", &models.Poll{Question: "  ", Options: options("Да", "Нет")}, ErrInvalidPollQuestion},
+		{"длинный вопрос", &models.Poll{Question: strings.Repeat("я", MaxPollQuestionLength+1), Options: options("Да", "Нет")}, ErrInvalidPollQuestion},
+		{"один вариант", &models.Poll{Question: "Вопрос", Options: options("Да")}, ErrInvalidPollOptions},
+		{"пустой вариант", &models.Poll{Question: "Вопрос", Options: options("Да", " ")}, ErrInvalidPollOptions},
+		{"повтор варианта", &models.Poll{Question: "Вопрос", Options: options("Да", "да ")}, ErrInvalidPollOptions},
+		{"длинный вариант", &models.Poll{Question: "Вопрос", Options: options("Да", strings.Repeat("я", MaxPollOptionLength+1))}, ErrInvalidPollOptions},
+		{"много вариантов", &models.Poll{Question: "Вопрос", Options: options("1", "2", "3", "4", "5", "6", "7", "8", "9", "10", "11")}, ErrInvalidPollOptions},
+		{"закрытие в прошлом", &models.Poll{Question: "Вопрос", Options: options("Да", "Нет"), ClosesAt: &past}, ErrInvalidPollClose},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.ErrorIs(t, service.Validate(tt.poll), tt.want)
+		})
+	}
+}
+
+func TestCreatePoll(t *testing.T) {
+	service, repo, _, _ := setupPollServiceTest()
+	repo.On("Create", mock.AnythingOfType("*models.Poll")).Return(nil).Run(func(args mock.Arguments) {
+		poll := args.Get(0).(*models.Poll)
+		poll.ID = 7
+		poll.Options[0].ID = 11
+		poll.Options[1].ID = 12
+	}).Once()
+	repo.On("GetVotes", 7).Return([]models.PollVote{}, nil)
+
+	poll := &models.Poll{Question: "Когда релиз?", Options: []models.PollOption{{Text: "В пятницу"}, {Text: "В понедельник"}}}
+	require.NoError(t, service.Create(3, poll))
+	assert.Equal(t, 3, poll.ThreadID)
+	assert.Equal(t, 7, poll.ID)
+	assert.Zero(t, poll.TotalVoters)
+	assert.False(t, poll.Closed)
+
+	repo.On("Create", mock.AnythingOfType("*models.Poll")).Return(repository.ErrAlreadyExists).Once()
+	assert.ErrorIs(t, service.Create(3, &models.Poll{Question: "Еще", Options: []models.PollOption{{Text: "Да"}, {Text: "Нет"}}}), ErrPollExists)
+}
+
+func TestGetPoll_Results(t *testing.T) {
+	votes := []models.PollVote{
+		{OptionID: 11, PollVoter: models.PollVoter{UserID: 2, Username: "alice"}},
+		{OptionID: 12, PollVoter: models.PollVoter{UserID: 3, Username: "bob"}},
+		{OptionID: 11, PollVoter: models.PollVoter{UserID: 3, Username: "bob"}},
+	}
+
+	t.Run("открытый опрос", func(t *testing.T) {
+		service, repo, _, _ := setupPollServiceTest()
+		repo.On("GetByID", 7).Return(testPoll(false, true), nil)
+		repo.On("GetVotes", 7).Return(votes, nil)
+
+		poll, err := service.Get(7, 3)
+		require.NoError(t, err)
+		assert.Equal(t, 2, poll.TotalVoters)
+		assert.Equal(t, 2, poll.Options[0].Votes)
+		assert.Equal(t, 1, poll.Options[1].Votes)
+		assert.Equal(t, []models.PollVoter{{UserID: 2, Username: "alice"}, {UserID: 3, Username: "bob"}}, poll.Options[0].Voters)
+		assert.Equal(t, []int{12, 11}, poll.MyVotes)
+	})
+
+	t.Run("анонимный опрос", func(t *testing.T) {
+		service, repo, _, _ := setupPollServiceTest()
+		repo.On("GetByID", 7).Return(testPoll(true, true), nil)
+		repo.On("GetVotes", 7).Return(votes, nil)
+
+		poll, err := service.Get(7, 2)
+		require.NoError(t, err)
+		assert.Equal(t, 2, poll.Options[0].Votes)
+		assert.Nil(t, poll.Options[0].Voters, "голосовавшие не раскрываются")
+		assert.Equal(t, []int{11}, poll.MyVotes, "свои голоса видны и в анонимном опросе")
+	})
+
+	t.Run("закрыт по времени", func(t *testing.T) {
+		service, repo, _, _ := setupPollServiceTest()
+		poll := testPoll(false, false)
+		closesAt := time.Now().Add(-time.Minute)
+		poll.ClosesAt = &closesAt
+		repo.On("GetByThreadID", 3).Return(poll, nil)
+		repo.On("GetVotes", 7).Return([]models.PollVote{}, nil)
+
+		poll, err := service.GetByThread(3, 0)
+		require.NoError(t, err)
+		assert.True(t, poll.Closed)
+		assert.Nil(t, poll.MyVotes)
+	})
+}
+
+func TestVotePoll(t *testing.T) {
+	service, repo, _, _ := setupPollServiceTest()
+	repo.On("GetByID", 7).Return(testPoll(false, false), nil)
+	repo.On("Vote", 7, 2, []int{11}).Return(nil)
+	repo.On("Vote", 7, 2, []int{}).Return(nil)
+	repo.On("Vote", 7, 4, []int{12}).Return(repository.ErrPollClosed)
+	repo.On("GetVotes", 7).Return([]models.PollVote{{OptionID: 11, PollVoter: models.PollVoter{UserID: 2, Username: "alice"}}}, nil)
+
+	poll, err := service.Vote(7, 2, []int{11, 11})
+	require.NoError(t, err)
+	assert.Equal(t, []int{11}, poll.MyVotes)
+
+	_, err = service.Vote(7, 2, nil)
+	assert.NoError(t, err, "пустой список снимает голос")
+
+	_, err = service.Vote(7, 2, []int{11, 12})
+	assert.ErrorIs(t, err, ErrInvalidPollChoice)
+	_, err = service.Vote(7, 4, []int{12})
+	assert.ErrorIs(t, err, ErrPollClosed)
+	repo.AssertNumberOfCalls(t, "Vote", 3)
+}
+
+func TestClosePoll(t *testing.T) {
+	tests := []struct {
+		name    string
+		userID  int
+		role    string
+		wantErr error
+	}{
+		{"автор треда", 1, "user", nil},
+		{"модератор", 5, "moderator", nil},
+		{"администратор", 6, "admin", nil},
+		{"другой пользователь", 2, "user", ErrNoPermission},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service, repo, threadRepo, userRepo := setupPollServiceTest()
+			repo.On("GetByID", 7).Return(testPoll(false, false), nil)
+			repo.On("Close", 7, tt.userID).Return(nil)
+			repo.On("GetVotes", 7).Return([]models.PollVote{}, nil)
+			threadRepo.On("GetByID", 3).Return(&models.Thread{ID: 3, AuthorID: 1}, nil)
+			userRepo.On("GetUserRole", tt.userID).Return(tt.role, nil)
+
+			_, err := service.Close(7, tt.userID)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				repo.AssertNotCalled(t, "Close", mock.Anything, mock.Anything)
+				return
+			}
+			require.NoError(t, err)
+			repo.AssertCalled(t, "Close", 7, tt.userID)
+		})
+	}
+}
@@ -3,14 +3,53 @@ package service
 import (
 	"ForumService/internal/models"
 	"ForumService/internal/repository"
+	"errors"
+	"fmt"
+	"github.com/pmezard/go-difflib/difflib"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
 )
 
+// Ограничения на длину заголовка поста (совпадают с ErrInvalidTitle)
+const (
+	MinPostTitleLength = 3
+	MaxPostTitleLength = 100
+)
+
+// MaxQuotes - сколько процитированных постов учитывается в одном посте
+const MaxQuotes = 20
+
+// QuotePattern находит цитаты постов [quote=ID]текст[/quote]. Первая группа - ID
+// процитированного поста, вторая - текст цитаты. Вложенные цитаты не поддерживаются.
+var QuotePattern = regexp.MustCompile(`(?s)\[quote=(\d{1,9})\](.*?)\[/quote\]`)
+
+// ParseQuotes возвращает ID процитированных постов без повторов в порядке первой цитаты,
+// не больше MaxQuotes
+func ParseQuotes(content string) []int {
+	var ids []int
+	seen := make(map[int]bool)
+	for _, match := range QuotePattern.FindAllStringSubmatch(content, -1) {
+		id, err := strconv.Atoi(match[1])
+		if err != nil || seen[id] {
+			continue
+		}
+		seen[id] = true
+		ids = append(ids, id)
+		if len(ids) == MaxQuotes {
+			break
+		}
+	}
+	return ids
+}
+
 type PostService interface {
 	CreatePost(post *models.Post) error
 	GetPostByID(id int) (*models.Post, error)
 	GetPostWithComments(postID int) (*models.Post, []models.Comment, error)
 	GetPostsWithCommentsByThreadID(threadID int) ([]models.Post, map[int][]models.Comment, error)
-	UpdatePost(post *models.Post, postID int, userID int) error
+	UpdatePost(post *models.Post, postID int, userID int, reason string) error
 	DeletePost(postID int, userID int) error
 	GetAllPosts() ([]*models.Post, error)
 	CreateComment(comment *models.Comment) error
@@ -19,6 +58,9 @@ type PostService interface {
 	GetPost(id int) (*models.Post, error)
 	GetPostsByThreadID(threadID int) ([]*models.Post, error)
 	GetThreadByID(id int) (*models.Thread, error)
+	GetPostRevisions(postID int) ([]models.PostRevision, error)
+	GetPostRevisionDiff(postID int, fromRevisionID int, toRevisionID int) (string, error)
+	SetPostPin(postID int, pinned bool, userID int) (*models.Post, error)
 }
 
 type postService struct {
@@ -38,17 +80,128 @@ func NewPostService(repo repository.PostRepository, commentRepo repository.Comme
 }
 
 func (s *postService) CreatePost(post *models.Post) error {
-	return s.repo.SavePost(post)
+	if err := normalizePostTitle(post); err != nil {
+		return err
+	}
+	if err := ensureThreadOpen(s.threadRepo, post.ThreadID); err != nil {
+		return err
+	}
+	if err := s.checkReplyTarget(post); err != nil {
+		return err
+	}
+	return translateRepoError(s.repo.SavePost(post))
+}
+
+// checkReplyTarget проверяет, что пост, на который отвечает новый пост, есть в том же треде
+func (s *postService) checkReplyTarget(post *models.Post) error {
+	if post.ReplyToPostID == nil {
+		return nil
+	}
+	target, err := s.repo.GetPostByID(*post.ReplyToPostID)
+	if errors.Is(err, repository.ErrPostNotFound) {
+		return ErrInvalidReplyTarget
+	}
+	if err != nil {
+		return translateRepoError(err)
+	}
+	if target.ThreadID != post.ThreadID {
+		return ErrInvalidReplyTarget
+	}
+	return nil
+}
+
+// attachPostLinks заполняет связи постов: пост, на который отвечает каждый пост,
+// процитированные посты и ответы. Цитата удаленного поста, как и ответ на пост,
+// удаленный после чтения ответа, остается ссылкой с отметкой Deleted. Так же выглядит
+// цитата поста из раздела, который может читать не каждый, кто видит цитирующий пост.
+func attachPostLinks(repo repository.PostRepository, posts []*models.Post) error {
+	if len(posts) == 0 {
+		return nil
+	}
+
+	ids := make([]int, len(posts))
+	quotes := make([][]int, len(posts))
+	var linked []int
+	for i, post := range posts {
+		ids[i] = post.ID
+		if post.ReplyToPostID != nil {
+			linked = append(linked, *post.ReplyToPostID)
+		}
+		quotes[i] = ParseQuotes(post.Content)
+		linked = append(linked, quotes[i]...)
+	}
+
+	refs := make(map[int]models.PostRef)
+	if len(linked) > 0 {
+		// Ссылки на сами посты нужны, чтобы знать, кто может их читать
+		var err error
+		if refs, err = repo.GetPostRefs(append(linked, ids...)); err != nil {
+			return err
+		}
+	}
+	replies, err := repo.GetReplies(ids)
+	if err != nil {
+		return err
+	}
+
+	for i, post := range posts {
+		post.ReplyTo = nil
+		if post.ReplyToPostID != nil {
+			ref := postRef(refs, post, *post.ReplyToPostID)
+			post.ReplyTo = &ref
+		}
+		post.Quotes = nil
+		for _, id := range quotes[i] {
+			post.Quotes = append(post.Quotes, postRef(refs, post, id))
+		}
+		post.Replies = replies[post.ID]
+	}
+	return nil
+}
+
+// postRef возвращает ссылку из поста from на пост id или отметку об удаленном посте.
+// Пост другого треда раскрывается, только если роль, нужная для чтения from,
+// достаточна и для его раздела: иначе автор и тред утекли бы читателю без доступа.
+func postRef(refs map[int]models.PostRef, from *models.Post, id int) models.PostRef {
+	ref, ok := refs[id]
+	if !ok {
+		return models.PostRef{ID: id, Deleted: true}
+	}
+	if ref.ThreadID != from.ThreadID {
+		source, ok := refs[from.ID]
+		if !ok || !source.ReadRole.Allows(ref.ReadRole) {
+			return models.PostRef{ID: id, Deleted: true}
+		}
+	}
+	return ref
+}
+
+// normalizePostTitle обрезает пробелы в заголовке и проверяет его длину.
+// Заголовок необязателен: пустая строка означает пост без заголовка.
+func normalizePostTitle(post *models.Post) error {
+	post.Title = strings.TrimSpace(post.Title)
+	if post.Title == "" {
+		return nil
+	}
+	length := utf8.RuneCountInString(post.Title)
+	if length < MinPostTitleLength || length > MaxPostTitleLength {
+		return ErrInvalidTitle
+	}
+	return nil
 }
 
 func (s *postService) GetPostByID(id int) (*models.Post, error) {
-	return s.repo.GetPostByID(id)
+	post, err := s.repo.GetPostByID(id)
+	if err != nil {
+		return nil, translateRepoError(err)
+	}
+	return post, nil
 }
 
 func (s *postService) GetPostWithComments(postID int) (*models.Post, []models.Comment, error) {
 	post, comments, err := s.repo.GetPostWithComments(postID)
 	if err != nil {
-		return nil, nil, err
+		return nil, nil, translateRepoError(err)
 	}
 
 	// Добавляем информацию о возможности редактирования для комментариев
@@ -60,6 +213,9 @@ func (s *postService) GetPostWithComments(postID int) (*models.Post, []models.Co
 	// Устанавливаем флаг CanEdit для поста
 	post.CanEdit = true // По умолчанию устанавливаем true, так как проверка будет на уровне UI
 
+	if err := attachPostLinks(s.repo, []*models.Post{post}); err != nil {
+		return nil, nil, err
+	}
 	return post, comments, nil
 }
 
@@ -79,42 +235,46 @@ func (s *postService) GetPostsWithCommentsByThreadID(threadID int) ([]models.Pos
 	return posts, commentsMap, nil
 }
 
-func (s *postService) UpdatePost(post *models.Post, postID int, userID int) error {
+func (s *postService) UpdatePost(post *models.Post, postID int, userID int, reason string) error {
 	existingPost, err := s.repo.GetPostByID(postID)
 	if err != nil {
-		return err
+		return translateRepoError(err)
 	}
 
 	// Получаем роль пользователя
 	userRole, err := s.userRepo.GetUserRole(userID)
 	if err != nil {
-		return err
+		return translateRepoError(err)
 	}
 
 	if existingPost.AuthorID != userID && userRole != "admin" {
 		return ErrNoPermission
 	}
 
-	return s.repo.UpdatePost(post, postID)
+	if err := normalizePostTitle(post); err != nil {
+		return err
+	}
+
+	return translateRepoError(s.repo.UpdatePost(post, postID, userID, reason))
 }
 
 func (s *postService) DeletePost(postID int, userID int) error {
 	post, err := s.repo.GetPostByID(postID)
 	if err != nil {
-		return err
+		return translateRepoError(err)
 	}
 
 	// Получаем роль пользователя
 	userRole, err := s.userRepo.GetUserRole(userID)
 	if err != nil {
-		return err
+		return translateRepoError(err)
 	}
 
 	if post.AuthorID != userID && userRole != "admin" {
 		return ErrNoPermission
 	}
 
-	return s.repo.DeletePost(postID)
+	return translateRepoError(s.repo.DeletePost(postID))
 }
 
 func (s *postService) GetAllPosts() ([]*models.Post, error) {
@@ -122,19 +282,34 @@ func (s *postService) GetAllPosts() ([]*models.Post, error) {
 }
 
 func (s *postService) CreateComment(comment *models.Comment) error {
-	return s.commentRepo.SaveComment(comment)
+	if err := ensurePostThreadOpen(s.repo, s.threadRepo, comment.PostID); err != nil {
+		return err
+	}
+	return translateRepoError(s.commentRepo.SaveComment(comment))
 }
 
 func (s *postService) GetCommentByID(id int) (*models.Comment, error) {
-	return s.commentRepo.GetCommentByID(id)
+	comment, err := s.commentRepo.GetCommentByID(id)
+	if err != nil {
+		return nil, translateRepoError(err)
+	}
+	return comment, nil
 }
 
 func (s *postService) DeleteComment(id int) error {
-	return s.commentRepo.DeleteComment(id)
+	return translateRepoError(s.commentRepo.DeleteComment(id))
 }
 
+// GetPost возвращает пост вместе со связями с другими постами
 func (s *postService) GetPost(id int) (*models.Post, error) {
-	return s.repo.GetPostByID(id)
+	post, err := s.GetPostByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if err := attachPostLinks(s.repo, []*models.Post{post}); err != nil {
+		return nil, err
+	}
+	return post, nil
 }
 
 func (s *postService) GetPostsByThreadID(threadID int) ([]*models.Post, error) {
@@ -142,5 +317,82 @@ func (s *postService) GetPostsByThreadID(threadID int) ([]*models.Post, error) {
 }
 
 func (s *postService) GetThreadByID(id int) (*models.Thread, error) {
-	return s.threadRepo.GetByID(id)
+	thread, err := s.threadRepo.GetByID(id)
+	if err != nil {
+		return nil, translateRepoError(err)
+	}
+	return thread, nil
+}
+
+// SetPostPin закрепляет пост в начале треда или снимает закрепление.
+// Доступно модераторам и администраторам.
+func (s *postService) SetPostPin(postID int, pinned bool, userID int) (*models.Post, error) {
+	userRole, err := s.userRepo.GetUserRole(userID)
+	if err != nil {
+		return nil, translateRepoError(err)
+	}
+	if !models.Role(userRole).Allows(models.RoleModerator) {
+		return nil, ErrNoPermission
+	}
+
+	if pinned {
+		err = s.repo.Pin(postID)
+	} else {
+		err = s.repo.Unpin(postID)
+	}
+	if err != nil {
+		return nil, translateRepoError(err)
+	}
+	return s.GetPostByID(postID)
+}
+
+func (s *postService) GetPostRevisions(postID int) ([]models.PostRevision, error) {
+	if _, err := s.repo.GetPostByID(postID); err != nil {
+		return nil, translateRepoError(err)
+	}
+	return s.repo.GetPostRevisions(postID)
+}
+
+// GetPostRevisionDiff строит unified diff между двумя ревизиями поста.
+// Нулевой toRevisionID означает текущую версию поста.
+func (s *postService) GetPostRevisionDiff(postID int, fromRevisionID int, toRevisionID int) (string, error) {
+	from, err := s.repo.GetPostRevisionByID(postID, fromRevisionID)
+	if err != nil {
+		return "", translateRepoError(err)
+	}
+
+	toText, toLabel := "", "текущая версия"
+	if toRevisionID == 0 {
+		post, err := s.repo.GetPostByID(postID)
+		if err != nil {
+			return "", translateRepoError(err)
+		}
+		toText = revisionText(post.Title, post.Content)
+	} else {
+		to, err := s.repo.GetPostRevisionByID(postID, toRevisionID)
+		if err != nil {
+			return "", translateRepoError(err)
+		}
+		toText = revisionText(to.Title, to.Content)
+		toLabel = fmt.Sprintf("ревизия #%d", to.ID)
+	}
+
+	diff := difflib.UnifiedDiff{
+		A:        difflib.SplitLines(revisionText(from.Title, from.Content)),
+		B:        difflib.SplitLines(toText),
+		FromFile: fmt.Sprintf("ревизия #%d", from.ID),
+		ToFile:   toLabel,
+		Context:  3,
+	}
+
+	return difflib.GetUnifiedDiffString(diff)
+}
+
+// revisionText - текст версии поста для diff: заголовок, если он есть, идет отдельной
+// строкой перед содержимым, чтобы правка заголовка тоже попадала в diff
+func revisionText(title, content string) string {
+	if title == "" {
+		return content + "\n"
+	}
+	return "Заголовок: " + title + "\n\n" + content + "\n"
 }
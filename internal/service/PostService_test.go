@@ -2,8 +2,10 @@ package service
 
 import (
 	"errors"
+	"strings"
 	"testing"
 	"ForumService/internal/models"
+	"ForumService/internal/repository"
 	"ForumService/internal/service/mocks"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -17,11 +19,41 @@ func TestCreatePost_Success(t *testing.T) {
 	service := NewPostService(repo, commentRepo, threadRepo, userRepo)
 
 	post := &models.Post{ID: 1, Content: "test"}
+	threadRepo.On("GetByID", 0).Return(&models.Thread{}, nil)
 	repo.On("SavePost", post).Return(nil)
 	err := service.CreatePost(post)
 	assert.NoError(t, err)
 }
 
+func TestCreatePost_TrimsTitle(t *testing.T) {
+	repo := new(mocks.MockPostRepo)
+	commentRepo := new(mocks.MockCommentRepo)
+	threadRepo := new(mocks.MockThreadRepo)
+	userRepo := new(mocks.MockUserRepo)
+	service := NewPostService(repo, commentRepo, threadRepo, userRepo)
+
+	post := &models.Post{ID: 1, Title: "  Заголовок  ", Content: "test"}
+	threadRepo.On("GetByID", 0).Return(&models.Thread{}, nil)
+	repo.On("SavePost", post).Return(nil)
+	err := service.CreatePost(post)
+	assert.NoError(t, err)
+	assert.Equal(t, "Заголовок", post.Title)
+}
+
+func TestCreatePost_InvalidTitle(t *testing.T) {
+	repo := new(mocks.MockPostRepo)
+	commentRepo := new(mocks.MockCommentRepo)
+	threadRepo := new(mocks.MockThreadRepo)
+	userRepo := new(mocks.MockUserRepo)
+	service := NewPostService(repo, commentRepo, threadRepo, userRepo)
+
+	for _, title := range []string{"ab", strings.Repeat("я", MaxPostTitleLength+1)} {
+		err := service.CreatePost(&models.Post{Title: title, Content: "test"})
+		assert.ErrorIs(t, err, ErrInvalidTitle)
+	}
+	repo.AssertNotCalled(t, "SavePost", mock.Anything)
+}
+
 func TestGetPostByID_Success(t *testing.T) {
 	repo := new(mocks.MockPostRepo)
 	commentRepo := new(mocks.MockCommentRepo)
@@ -47,7 +79,7 @@ func TestUpdatePost_NoPermission(t *testing.T) {
 	repo.On("GetPostByID", 1).Return(post, nil)
 	userRepo.On("GetUserRole", 3).Return("user", nil)
 
-	err := service.UpdatePost(&models.Post{ID: 1}, 1, 3)
+	err := service.UpdatePost(&models.Post{ID: 1}, 1, 3, "")
 	assert.ErrorIs(t, err, ErrNoPermission)
 }
 
@@ -61,9 +93,9 @@ func TestUpdatePost_Admin(t *testing.T) {
 	post := &models.Post{ID: 1, AuthorID: 2}
 	repo.On("GetPostByID", 1).Return(post, nil)
 	userRepo.On("GetUserRole", 4).Return("admin", nil)
-	repo.On("UpdatePost", mock.AnythingOfType("*models.Post"), 1).Return(nil)
+	repo.On("UpdatePost", mock.AnythingOfType("*models.Post"), 1, 4, "").Return(nil)
 
-	err := service.UpdatePost(&models.Post{ID: 1}, 1, 4)
+	err := service.UpdatePost(&models.Post{ID: 1}, 1, 4, "")
 	assert.NoError(t, err)
 }
 
@@ -106,6 +138,7 @@ func TestCreatePost_Error(t *testing.T) {
 	service := NewPostService(repo, commentRepo, threadRepo, userRepo)
 
 	post := &models.Post{ID: 1, Content: "test"}
+	threadRepo.On("GetByID", 0).Return(&models.Thread{}, nil)
 	repo.On("SavePost", post).Return(errors.New("db error"))
 	err := service.CreatePost(post)
 	assert.Error(t, err)
@@ -124,6 +157,19 @@ func TestGetPostByID_Error(t *testing.T) {
 	assert.Nil(t, res)
 }
 
+func TestGetPostByID_NotFound(t *testing.T) {
+	repo := new(mocks.MockPostRepo)
+	commentRepo := new(mocks.MockCommentRepo)
+	threadRepo := new(mocks.MockThreadRepo)
+	userRepo := new(mocks.MockUserRepo)
+	service := NewPostService(repo, commentRepo, threadRepo, userRepo)
+
+	repo.On("GetPostByID", 1).Return((*models.Post)(nil), repository.ErrPostNotFound)
+	res, err := service.GetPostByID(1)
+	assert.ErrorIs(t, err, ErrPostNotFound)
+	assert.Nil(t, res)
+}
+
 func TestUpdatePost_Error(t *testing.T) {
 	repo := new(mocks.MockPostRepo)
 	commentRepo := new(mocks.MockCommentRepo)
@@ -134,9 +180,9 @@ func TestUpdatePost_Error(t *testing.T) {
 	post := &models.Post{ID: 1, AuthorID: 1}
 	repo.On("GetPostByID", 1).Return(post, nil)
 	userRepo.On("GetUserRole", 1).Return("user", nil)
-	repo.On("UpdatePost", mock.AnythingOfType("*models.Post"), 1).Return(errors.New("db error"))
+	repo.On("UpdatePost", mock.AnythingOfType("*models.Post"), 1, 1, "").Return(errors.New("db error"))
 
-	err := service.UpdatePost(&models.Post{ID: 1}, 1, 1)
+	err := service.UpdatePost(&models.Post{ID: 1}, 1, 1, "")
 	assert.Error(t, err)
 }
 
@@ -166,6 +212,7 @@ func TestGetPostWithComments_Success(t *testing.T) {
 	post := &models.Post{ID: 1, ThreadID: 1, AuthorID: 1, Content: "post"}
 	comments := []models.Comment{{ID: 1, PostID: 1, AuthorID: 1, Content: "comment"}}
 	repo.On("GetPostWithComments", 1).Return(post, comments, nil)
+	expectNoReplies(repo)
 
 	resPost, resComments, err := service.GetPostWithComments(1)
 	assert.NoError(t, err)
@@ -218,4 +265,234 @@ func TestGetPostsWithCommentsByThreadID_Error(t *testing.T) {
 	assert.Error(t, err)
 	assert.Nil(t, resPosts)
 	assert.Nil(t, resComments)
-} 
\ No newline at end of file
+} 
+func TestGetPostRevisionDiff_AgainstCurrent(t *testing.T) {
+	repo := new(mocks.MockPostRepo)
+	commentRepo := new(mocks.MockCommentRepo)
+	threadRepo := new(mocks.MockThreadRepo)
+	userRepo := new(mocks.MockUserRepo)
+	service := NewPostService(repo, commentRepo, threadRepo, userRepo)
+
+	repo.On("GetPostRevisionByID", 1, 7).Return(&models.PostRevision{ID: 7, PostID: 1, Content: "строка 1\nстрока 2"}, nil)
+	repo.On("GetPostByID", 1).Return(&models.Post{ID: 1, Content: "строка 1\nстрока 3"}, nil)
+
+	diff, err := service.GetPostRevisionDiff(1, 7, 0)
+	assert.NoError(t, err)
+	assert.Contains(t, diff, "--- ревизия #7")
+	assert.Contains(t, diff, "+++ текущая версия")
+	assert.Contains(t, diff, "-строка 2")
+	assert.Contains(t, diff, "+строка 3")
+}
+
+func TestGetPostRevisionDiff_Title(t *testing.T) {
+	repo := new(mocks.MockPostRepo)
+	service := NewPostService(repo, new(mocks.MockCommentRepo), new(mocks.MockThreadRepo), new(mocks.MockUserRepo))
+
+	repo.On("GetPostRevisionByID", 1, 7).Return(&models.PostRevision{ID: 7, PostID: 1, Title: "Старый", Content: "текст"}, nil)
+	repo.On("GetPostRevisionByID", 1, 8).Return(&models.PostRevision{ID: 8, PostID: 1, Content: "текст"}, nil)
+	repo.On("GetPostByID", 1).Return(&models.Post{ID: 1, Title: "Новый", Content: "текст"}, nil)
+
+	diff, err := service.GetPostRevisionDiff(1, 7, 0)
+	assert.NoError(t, err)
+	assert.Contains(t, diff, "-Заголовок: Старый")
+	assert.Contains(t, diff, "+Заголовок: Новый")
+	assert.NotContains(t, diff, "-текст", "содержимое не менялось")
+
+	diff, err = service.GetPostRevisionDiff(1, 7, 8)
+	assert.NoError(t, err)
+	assert.Contains(t, diff, "-Заголовок: Старый", "снятый заголовок тоже виден в diff")
+}
+
+func TestGetPostRevisionDiff_RevisionNotFound(t *testing.T) {
+	repo := new(mocks.MockPostRepo)
+	commentRepo := new(mocks.MockCommentRepo)
+	threadRepo := new(mocks.MockThreadRepo)
+	userRepo := new(mocks.MockUserRepo)
+	service := NewPostService(repo, commentRepo, threadRepo, userRepo)
+
+	repo.On("GetPostRevisionByID", 1, 7).Return((*models.PostRevision)(nil), errors.New("ревизия не найдена"))
+
+	diff, err := service.GetPostRevisionDiff(1, 7, 0)
+	assert.Error(t, err)
+	assert.Empty(t, diff)
+}
+
+func TestUpdatePost_VersionConflict(t *testing.T) {
+	repo := new(mocks.MockPostRepo)
+	commentRepo := new(mocks.MockCommentRepo)
+	threadRepo := new(mocks.MockThreadRepo)
+	userRepo := new(mocks.MockUserRepo)
+	service := NewPostService(repo, commentRepo, threadRepo, userRepo)
+
+	post := &models.Post{ID: 1, AuthorID: 1, Version: 3}
+	repo.On("GetPostByID", 1).Return(post, nil)
+	userRepo.On("GetUserRole", 1).Return("user", nil)
+	repo.On("UpdatePost", mock.AnythingOfType("*models.Post"), 1, 1, "").Return(repository.ErrVersionConflict)
+
+	err := service.UpdatePost(&models.Post{ID: 1, Version: 2}, 1, 1, "")
+	assert.ErrorIs(t, err, ErrVersionConflict)
+}
+
+func TestCreatePost_ThreadLocked(t *testing.T) {
+	repo := new(mocks.MockPostRepo)
+	threadRepo := new(mocks.MockThreadRepo)
+	service := NewPostService(repo, new(mocks.MockCommentRepo), threadRepo, new(mocks.MockUserRepo))
+
+	threadRepo.On("GetByID", 1).Return(&models.Thread{ID: 1, Locked: true}, nil)
+	threadRepo.On("GetByID", 2).Return((*models.Thread)(nil), repository.ErrThreadNotFound)
+
+	assert.ErrorIs(t, service.CreatePost(&models.Post{ThreadID: 1, Content: "test"}), ErrThreadLocked)
+	assert.ErrorIs(t, service.CreatePost(&models.Post{ThreadID: 2, Content: "test"}), ErrThreadNotFound)
+	repo.AssertNotCalled(t, "SavePost", mock.Anything)
+}
+
+func TestCreatePost_ThreadLockedConcurrently(t *testing.T) {
+	repo := new(mocks.MockPostRepo)
+	commentRepo := new(mocks.MockCommentRepo)
+	threadRepo := new(mocks.MockThreadRepo)
+	service := NewPostService(repo, commentRepo, threadRepo, new(mocks.MockUserRepo))
+
+	// Тред закрыли после ранней проверки: закрытие видит только транзакция сохранения
+	threadRepo.On("GetByID", 1).Return(&models.Thread{ID: 1}, nil)
+	repo.On("SavePost", mock.AnythingOfType("*models.Post")).Return(repository.ErrThreadLocked)
+	repo.On("GetPostByID", 5).Return(&models.Post{ID: 5, ThreadID: 1}, nil)
+	commentRepo.On("SaveComment", mock.AnythingOfType("*models.Comment")).Return(repository.ErrThreadLocked)
+
+	assert.ErrorIs(t, service.CreatePost(&models.Post{ThreadID: 1, Content: "test"}), ErrThreadLocked)
+	assert.ErrorIs(t, service.CreateComment(&models.Comment{PostID: 5, AuthorID: 2, Content: "content"}), ErrThreadLocked)
+}
+
+func TestPostServiceCreateComment_ThreadLocked(t *testing.T) {
+	repo := new(mocks.MockPostRepo)
+	commentRepo := new(mocks.MockCommentRepo)
+	threadRepo := new(mocks.MockThreadRepo)
+	service := NewPostService(repo, commentRepo, threadRepo, new(mocks.MockUserRepo))
+
+	repo.On("GetPostByID", 5).Return(&models.Post{ID: 5, ThreadID: 1}, nil)
+	threadRepo.On("GetByID", 1).Return(&models.Thread{ID: 1, Locked: true}, nil)
+
+	err := service.CreateComment(&models.Comment{PostID: 5, AuthorID: 2, Content: "content"})
+	assert.ErrorIs(t, err, ErrThreadLocked)
+	commentRepo.AssertNotCalled(t, "SaveComment", mock.Anything)
+}
+
+func TestSetPostPin(t *testing.T) {
+	repo := new(mocks.MockPostRepo)
+	userRepo := new(mocks.MockUserRepo)
+	service := NewPostService(repo, new(mocks.MockCommentRepo), new(mocks.MockThreadRepo), userRepo)
+
+	userRepo.On("GetUserRole", 1).Return("user", nil)
+	userRepo.On("GetUserRole", 2).Return("moderator", nil)
+	repo.On("Pin", 5).Return(nil)
+	repo.On("Unpin", 5).Return(nil)
+	repo.On("Pin", 42).Return(repository.ErrPostNotFound)
+	repo.On("GetPostByID", 5).Return(&models.Post{ID: 5, Pinned: true}, nil).Once()
+	repo.On("GetPostByID", 5).Return(&models.Post{ID: 5}, nil).Once()
+
+	post, err := service.SetPostPin(5, true, 2)
+	assert.NoError(t, err)
+	assert.True(t, post.Pinned)
+	post, err = service.SetPostPin(5, false, 2)
+	assert.NoError(t, err)
+	assert.False(t, post.Pinned)
+
+	_, err = service.SetPostPin(5, true, 1)
+	assert.ErrorIs(t, err, ErrNoPermission, "автор поста не может его закрепить")
+	_, err = service.SetPostPin(42, true, 2)
+	assert.ErrorIs(t, err, ErrPostNotFound)
+	repo.AssertNumberOfCalls(t, "Pin", 2)
+}
+
+// expectNoReplies разрешает запрос ответов на посты, когда на них никто не отвечал
+func expectNoReplies(repo *mocks.MockPostRepo) {
+	repo.On("GetReplies", mock.Anything).Return(map[int][]models.PostRef{}, nil).Maybe()
+}
+
+func TestParseQuotes(t *testing.T) {
+	content := "[quote=12]первый[/quote] текст [quote=7]\nвторой\n[/quote] [quote=12]снова[/quote] [quote=x]нет[/quote]"
+	assert.Equal(t, []int{12, 7}, ParseQuotes(content))
+	assert.Empty(t, ParseQuotes("[quote=5]без закрывающего тега"))
+}
+
+func TestCreatePost_ReplyTo(t *testing.T) {
+	repo := new(mocks.MockPostRepo)
+	threadRepo := new(mocks.MockThreadRepo)
+	service := NewPostService(repo, new(mocks.MockCommentRepo), threadRepo, new(mocks.MockUserRepo))
+
+	replyTo := 5
+	post := &models.Post{ThreadID: 1, AuthorID: 2, Content: "ответ", ReplyToPostID: &replyTo}
+	threadRepo.On("GetByID", 1).Return(&models.Thread{ID: 1}, nil)
+	repo.On("GetPostByID", 5).Return(&models.Post{ID: 5, ThreadID: 1}, nil)
+	repo.On("SavePost", post).Return(nil)
+
+	assert.NoError(t, service.CreatePost(post))
+	repo.AssertExpectations(t)
+}
+
+func TestCreatePost_InvalidReplyTarget(t *testing.T) {
+	repo := new(mocks.MockPostRepo)
+	threadRepo := new(mocks.MockThreadRepo)
+	service := NewPostService(repo, new(mocks.MockCommentRepo), threadRepo, new(mocks.MockUserRepo))
+
+	threadRepo.On("GetByID", 1).Return(&models.Thread{ID: 1}, nil)
+	repo.On("GetPostByID", 5).Return(&models.Post{ID: 5, ThreadID: 2}, nil)
+	repo.On("GetPostByID", 6).Return((*models.Post)(nil), repository.ErrPostNotFound)
+
+	for _, replyTo := range []int{5, 6} {
+		err := service.CreatePost(&models.Post{ThreadID: 1, Content: "ответ", ReplyToPostID: &replyTo})
+		assert.ErrorIs(t, err, ErrInvalidReplyTarget)
+	}
+	repo.AssertNotCalled(t, "SavePost", mock.Anything)
+}
+
+func TestGetPost_Links(t *testing.T) {
+	repo := new(mocks.MockPostRepo)
+	service := NewPostService(repo, new(mocks.MockCommentRepo), new(mocks.MockThreadRepo), new(mocks.MockUserRepo))
+
+	replyTo := 3
+	repo.On("GetPostByID", 10).Return(&models.Post{
+		ID:            10,
+		ThreadID:      1,
+		Content:       "[quote=3]исходный[/quote] согласен, и [quote=4]удаленный[/quote]",
+		ReplyToPostID: &replyTo,
+	}, nil)
+	source := models.PostRef{ID: 3, ThreadID: 1, AuthorID: 2, AuthorName: "alice"}
+	repo.On("GetPostRefs", []int{3, 3, 4, 10}).Return(map[int]models.PostRef{3: source, 10: {ID: 10, ThreadID: 1}}, nil)
+	reply := models.PostRef{ID: 11, ThreadID: 1, AuthorID: 4, AuthorName: "bob"}
+	repo.On("GetReplies", []int{10}).Return(map[int][]models.PostRef{10: {reply}}, nil)
+
+	post, err := service.GetPost(10)
+	assert.NoError(t, err)
+	assert.Equal(t, &source, post.ReplyTo)
+	assert.Equal(t, []models.PostRef{source, {ID: 4, Deleted: true}}, post.Quotes)
+	assert.Equal(t, []models.PostRef{reply}, post.Replies)
+}
+
+func TestGetPost_QuotesFromClosedCategories(t *testing.T) {
+	repo := new(mocks.MockPostRepo)
+	service := NewPostService(repo, new(mocks.MockCommentRepo), new(mocks.MockThreadRepo), new(mocks.MockUserRepo))
+
+	// Пост открытого раздела цитирует пост раздела для модераторов и пост другого открытого треда
+	repo.On("GetPostByID", 10).Return(&models.Post{ID: 10, ThreadID: 1, Content: "[quote=20]а[/quote] [quote=21]б[/quote]"}, nil)
+	// Пост раздела для модераторов цитирует пост раздела для пользователей
+	repo.On("GetPostByID", 30).Return(&models.Post{ID: 30, ThreadID: 7, Content: "[quote=21]б[/quote]"}, nil)
+	public := models.PostRef{ID: 21, ThreadID: 3, AuthorID: 5, AuthorName: "carol", ReadRole: models.RoleUser}
+	refs := map[int]models.PostRef{
+		10: {ID: 10, ThreadID: 1, ReadRole: models.RoleGuest},
+		20: {ID: 20, ThreadID: 2, AuthorID: 4, AuthorName: "dave", ReadRole: models.RoleModerator},
+		21: public,
+		30: {ID: 30, ThreadID: 7, ReadRole: models.RoleModerator},
+	}
+	repo.On("GetPostRefs", []int{20, 21, 10}).Return(refs, nil)
+	repo.On("GetPostRefs", []int{21, 30}).Return(refs, nil)
+	repo.On("GetReplies", mock.Anything).Return(map[int][]models.PostRef{}, nil)
+
+	post, err := service.GetPost(10)
+	assert.NoError(t, err)
+	assert.Equal(t, []models.PostRef{{ID: 20, Deleted: true}, {ID: 21, Deleted: true}}, post.Quotes,
+		"гость видит пост 10, поэтому ссылки на посты, закрытые от гостей, не раскрываются")
+
+	post, err = service.GetPost(30)
+	assert.NoError(t, err)
+	assert.Equal(t, []models.PostRef{public}, post.Quotes)
+}
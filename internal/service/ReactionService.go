@@ -0,0 +1,99 @@
+package service
+
+import (
+	"ForumService/internal/models"
+	"ForumService/internal/repository"
+)
+
+// ReactionService ставит эмодзи-реакции на посты, комментарии и сообщения чата.
+// Повторная реакция тем же эмодзи снимает ее, допустимые эмодзи задаются конфигурацией.
+type ReactionService interface {
+	// Toggle ставит или снимает реакцию и возвращает новые итоги по записи
+	Toggle(targetType string, targetID, userID int, emoji string) (*models.ReactionChange, error)
+	// Counts возвращает сгруппированные реакции записей одного вида, userID отмечает свои реакции
+	Counts(targetType string, targetIDs []int, userID int) (map[int][]models.ReactionCount, error)
+	AllowedEmojis() []string
+}
+
+type reactionService struct {
+	repo        repository.ReactionRepository
+	commentRepo repository.CommentRepository
+	allowed     []string
+}
+
+func NewReactionService(repo repository.ReactionRepository, commentRepo repository.CommentRepository, allowed []string) ReactionService {
+	return &reactionService{
+		repo:        repo,
+		commentRepo: commentRepo,
+		allowed:     allowed,
+	}
+}
+
+// Toggle не принимает реакции на удаленные комментарии-заглушки
+func (s *reactionService) Toggle(targetType string, targetID, userID int, emoji string) (*models.ReactionChange, error) {
+	if !isReactionTarget(targetType) {
+		return nil, ErrInvalidReaction
+	}
+	if !s.isAllowed(emoji) {
+		return nil, ErrReactionNotAllowed
+	}
+	if targetType == models.ReactionTargetComment {
+		comment, err := s.commentRepo.GetCommentByID(targetID)
+		if err != nil {
+			return nil, translateRepoError(err)
+		}
+		if comment.Deleted {
+			return nil, ErrCommentDeleted
+		}
+	}
+
+	added, err := s.repo.Toggle(targetType, targetID, userID, emoji)
+	if err != nil {
+		return nil, translateRepoError(err)
+	}
+	counts, err := s.repo.GetCounts(targetType, []int{targetID}, userID)
+	if err != nil {
+		return nil, translateRepoError(err)
+	}
+
+	return &models.ReactionChange{
+		TargetType: targetType,
+		TargetID:   targetID,
+		UserID:     userID,
+		Emoji:      emoji,
+		Added:      added,
+		Reactions:  counts[targetID],
+	}, nil
+}
+
+func (s *reactionService) Counts(targetType string, targetIDs []int, userID int) (map[int][]models.ReactionCount, error) {
+	if !isReactionTarget(targetType) {
+		return nil, ErrInvalidReaction
+	}
+	counts, err := s.repo.GetCounts(targetType, targetIDs, userID)
+	if err != nil {
+		return nil, translateRepoError(err)
+	}
+	return counts, nil
+}
+
+func (s *reactionService) AllowedEmojis() []string {
+	return s.allowed
+}
+
+func (s *reactionService) isAllowed(emoji string) bool {
+	for _, allowed := range s.allowed {
+		if allowed == emoji {
+			return true
+		}
+	}
+	return false
+}
+
+func isReactionTarget(targetType string) bool {
+	switch targetType {
+	case models.ReactionTargetPost, models.ReactionTargetComment, models.ReactionTargetChat:
+		return true
+	}
+	return false
+}
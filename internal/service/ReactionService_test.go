@@ -0,0 +1,58 @@
+package service
+
+import (
+	"testing"
+
+	"ForumService/internal/models"
+	"ForumService/internal/repository"
+	"ForumService/internal/service/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func setupReactionServiceTest() (ReactionService, *mocks.MockReactionRepo, *mocks.MockCommentRepo) {
+	reactionRepo := new(mocks.MockReactionRepo)
+	commentRepo := new(mocks.MockCommentRepo)
+	return NewReactionService(reactionRepo, commentRepo, []string{"👍", "🎉"}), reactionRepo, commentRepo
+}
+
+func TestToggleReaction(t *testing.T) {
+	service, reactionRepo, _ := setupReactionServiceTest()
+	counts := map[int][]models.ReactionCount{1: {{Emoji: "👍", Count: 2, ReactedByMe: true}}}
+	reactionRepo.On("Toggle", models.ReactionTargetPost, 1, 3, "👍").Return(true, nil)
+	reactionRepo.On("GetCounts", models.ReactionTargetPost, []int{1}, 3).Return(counts, nil)
+
+	change, err := service.Toggle(models.ReactionTargetPost, 1, 3, "👍")
+	assert.NoError(t, err)
+	assert.True(t, change.Added)
+	assert.Equal(t, counts[1], change.Reactions)
+	reactionRepo.AssertExpectations(t)
+}
+
+func TestToggleReaction_Errors(t *testing.T) {
+	service, reactionRepo, commentRepo := setupReactionServiceTest()
+	commentRepo.On("GetCommentByID", 6).Return(&models.Comment{ID: 6, Deleted: true}, nil)
+	reactionRepo.On("Toggle", models.ReactionTargetChat, 99, 3, "🎉").Return(false, repository.ErrMessageNotFound)
+
+	_, err := service.Toggle("thread", 1, 3, "👍")
+	assert.ErrorIs(t, err, ErrInvalidReaction)
+	_, err = service.Toggle(models.ReactionTargetPost, 1, 3, "🔥")
+	assert.ErrorIs(t, err, ErrReactionNotAllowed)
+	_, err = service.Toggle(models.ReactionTargetComment, 6, 3, "👍")
+	assert.ErrorIs(t, err, ErrCommentDeleted)
+	_, err = service.Toggle(models.ReactionTargetChat, 99, 3, "🎉")
+	assert.ErrorIs(t, err, ErrMessageNotFound)
+	reactionRepo.AssertNumberOfCalls(t, "Toggle", 1)
+	reactionRepo.AssertNotCalled(t, "GetCounts", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestReactionCounts(t *testing.T) {
+	service, reactionRepo, _ := setupReactionServiceTest()
+	reactionRepo.On("GetCounts", models.ReactionTargetComment, []int{5, 7}, 0).
+		Return(map[int][]models.ReactionCount{7: {{Emoji: "🎉", Count: 1}}}, nil)
+
+	counts, err := service.Counts(models.ReactionTargetComment, []int{5, 7}, 0)
+	assert.NoError(t, err)
+	assert.Len(t, counts, 1)
+	assert.Equal(t, []string{"👍", "🎉"}, service.AllowedEmojis())
+}
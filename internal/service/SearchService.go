@@ -0,0 +1,83 @@
+package service
+
+import (
+	"ForumService/internal/models"
+	"ForumService/internal/repository"
+	"fmt"
+	"html"
+	"strings"
+)
+
+// Размер страницы результатов поиска
+const (
+	DefaultSearchLimit = 20
+	MaxSearchLimit     = 100
+)
+
+type SearchService interface {
+	Search(params models.SearchParams, page int) (*models.SearchPage, error)
+}
+
+type searchService struct {
+	repo repository.SearchRepository
+}
+
+func NewSearchService(repo repository.SearchRepository) SearchService {
+	return &searchService{repo: repo}
+}
+
+// Search проверяет параметры запроса, выполняет поиск и готовит сниппеты к выводу в HTML.
+// Страницы нумеруются с единицы.
+func (s *searchService) Search(params models.SearchParams, page int) (*models.SearchPage, error) {
+	params.Query = strings.TrimSpace(params.Query)
+	if params.Query == "" {
+		return nil, ErrEmptySearchQuery
+	}
+
+	for _, searchType := range params.Types {
+		switch searchType {
+		case models.SearchTypeThread, models.SearchTypePost, models.SearchTypeComment:
+		default:
+			return nil, ErrInvalidSearchType
+		}
+	}
+
+	if !params.From.IsZero() && !params.To.IsZero() && !params.From.Before(params.To) {
+		return nil, ErrInvalidDateRange
+	}
+
+	if params.Limit <= 0 {
+		params.Limit = DefaultSearchLimit
+	}
+	if params.Limit > MaxSearchLimit {
+		params.Limit = MaxSearchLimit
+	}
+	if page < 1 {
+		page = 1
+	}
+	params.Offset = (page - 1) * params.Limit
+
+	results, total, err := s.repo.Search(params)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't search: %w", err)
+	}
+
+	for i := range results {
+		results[i].Snippet = highlightSnippet(results[i].Snippet)
+	}
+
+	return &models.SearchPage{
+		Query:   params.Query,
+		Results: results,
+		Total:   total,
+		Page:    page,
+		Limit:   params.Limit,
+	}, nil
+}
+
+// highlightSnippet экранирует текст сниппета и заменяет маркеры совпадений тегами <mark>
+func highlightSnippet(snippet string) string {
+	escaped := html.EscapeString(snippet)
+	escaped = strings.ReplaceAll(escaped, repository.HighlightStart, "<mark>")
+	return strings.ReplaceAll(escaped, repository.HighlightStop, "</mark>")
+}
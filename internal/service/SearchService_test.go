@@ -0,0 +1,65 @@
+package service
+
+import (
+	"ForumService/internal/models"
+	"ForumService/internal/service/mocks"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestSearch_Success(t *testing.T) {
+	repo := new(mocks.MockSearchRepo)
+	service := NewSearchService(repo)
+
+	expectedParams := models.SearchParams{Query: "поиск", Limit: 10, Offset: 10}
+	results := []models.SearchResult{{Type: models.SearchTypePost, ID: 1, Snippet: "<b>\x02поиск\x03</b>"}}
+	repo.On("Search", expectedParams).Return(results, 11, nil)
+
+	res, err := service.Search(models.SearchParams{Query: "  поиск ", Limit: 10}, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, 11, res.Total)
+	assert.Equal(t, 2, res.Page)
+	assert.Equal(t, "&lt;b&gt;<mark>поиск</mark>&lt;/b&gt;", res.Results[0].Snippet)
+}
+
+func TestSearch_DefaultsAndLimits(t *testing.T) {
+	repo := new(mocks.MockSearchRepo)
+	service := NewSearchService(repo)
+
+	repo.On("Search", models.SearchParams{Query: "поиск", Limit: MaxSearchLimit}).Return([]models.SearchResult{}, 0, nil)
+
+	res, err := service.Search(models.SearchParams{Query: "поиск", Limit: 1000}, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, res.Page)
+	assert.Equal(t, MaxSearchLimit, res.Limit)
+}
+
+func TestSearch_InvalidParams(t *testing.T) {
+	repo := new(mocks.MockSearchRepo)
+	service := NewSearchService(repo)
+
+	_, err := service.Search(models.SearchParams{Query: "   "}, 1)
+	assert.ErrorIs(t, err, ErrEmptySearchQuery)
+
+	_, err = service.Search(models.SearchParams{Query: "поиск", Types: []string{"user"}}, 1)
+	assert.ErrorIs(t, err, ErrInvalidSearchType)
+
+	now := time.Now()
+	_, err = service.Search(models.SearchParams{Query: "поиск", From: now, To: now.Add(-time.Hour)}, 1)
+	assert.ErrorIs(t, err, ErrInvalidDateRange)
+
+	repo.AssertNotCalled(t, "Search")
+}
+
+func TestSearch_RepoError(t *testing.T) {
+	repo := new(mocks.MockSearchRepo)
+	service := NewSearchService(repo)
+
+	repo.On("Search", models.SearchParams{Query: "поиск", Limit: DefaultSearchLimit}).Return([]models.SearchResult(nil), 0, errors.New("db error"))
+
+	res, err := service.Search(models.SearchParams{Query: "поиск"}, 1)
+	assert.Error(t, err)
+	assert.Nil(t, res)
+}
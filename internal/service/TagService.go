@@ -0,0 +1,144 @@
+package service
+
+import (
+	"ForumService/internal/models"
+	"ForumService/internal/repository"
+	"errors"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Ограничения на теги тредов
+const (
+	minTagLength     = 2
+	maxTagLength     = 32
+	maxTagsPerThread = 5
+)
+
+type TagService interface {
+	GetTags() ([]*models.Tag, error)
+	RenameTag(id int, name string, userID int) (*models.Tag, error)
+	MergeTags(sourceID, targetID int, userID int) (*models.Tag, error)
+}
+
+type tagService struct {
+	tagRepo  repository.TagRepository
+	userRepo repository.UserRepository
+}
+
+func NewTagService(tagRepo repository.TagRepository, userRepo repository.UserRepository) TagService {
+	return &tagService{
+		tagRepo:  tagRepo,
+		userRepo: userRepo,
+	}
+}
+
+// GetTags возвращает используемые теги с числом тредов
+func (s *tagService) GetTags() ([]*models.Tag, error) {
+	tags, err := s.tagRepo.GetAll()
+	if err != nil {
+		return nil, translateRepoError(err)
+	}
+	return tags, nil
+}
+
+// RenameTag переименовывает тег. Чтобы слить тег с существующим, нужно использовать MergeTags.
+func (s *tagService) RenameTag(id int, name string, userID int) (*models.Tag, error) {
+	if err := s.requireModerator(userID); err != nil {
+		return nil, err
+	}
+	name, err := normalizeTag(name)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.tagRepo.Rename(id, name); err != nil {
+		if errors.Is(err, repository.ErrAlreadyExists) {
+			return nil, ErrTagExists
+		}
+		return nil, translateRepoError(err)
+	}
+	return s.getTag(id)
+}
+
+// MergeTags переносит треды тега sourceID на тег targetID и удаляет sourceID
+func (s *tagService) MergeTags(sourceID, targetID int, userID int) (*models.Tag, error) {
+	if err := s.requireModerator(userID); err != nil {
+		return nil, err
+	}
+	if sourceID == targetID {
+		return nil, ErrInvalidTagMerge
+	}
+
+	if err := s.tagRepo.Merge(sourceID, targetID); err != nil {
+		return nil, translateRepoError(err)
+	}
+	return s.getTag(targetID)
+}
+
+func (s *tagService) getTag(id int) (*models.Tag, error) {
+	tag, err := s.tagRepo.GetByID(id)
+	if err != nil {
+		return nil, translateRepoError(err)
+	}
+	return tag, nil
+}
+
+// requireModerator разрешает управление тегами модераторам и администраторам
+func (s *tagService) requireModerator(userID int) error {
+	userRole, err := s.userRepo.GetUserRole(userID)
+	if err != nil {
+		return translateRepoError(err)
+	}
+	if !models.Role(userRole).Allows(models.RoleModerator) {
+		return ErrNoPermission
+	}
+	return nil
+}
+
+// normalizeThreadTags нормализует теги треда и проверяет их число
+func normalizeThreadTags(raw []string) ([]string, error) {
+	tags, err := normalizeTags(raw)
+	if err != nil {
+		return nil, err
+	}
+	if len(tags) > maxTagsPerThread {
+		return nil, ErrTooManyTags
+	}
+	return tags, nil
+}
+
+// normalizeTags нормализует теги и убирает повторы
+func normalizeTags(raw []string) ([]string, error) {
+	tags := make([]string, 0, len(raw))
+	seen := make(map[string]bool, len(raw))
+	for _, value := range raw {
+		tag, err := normalizeTag(value)
+		if err != nil {
+			return nil, err
+		}
+		if seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		tags = append(tags, tag)
+	}
+	return tags, nil
+}
+
+// normalizeTag приводит тег к нижнему регистру и заменяет пробелы дефисами.
+// Кроме букв и цифр допустимы символы - _ + # . (например, "c++" или "c#").
+func normalizeTag(value string) (string, error) {
+	tag := strings.ToLower(strings.Join(strings.Fields(value), "-"))
+	length := utf8.RuneCountInString(tag)
+	if length < minTagLength || length > maxTagLength {
+		return "", ErrInvalidTag
+	}
+	for _, r := range tag {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) && !strings.ContainsRune("-_+#.", r) {
+			return "", ErrInvalidTag
+		}
+	}
+	return tag, nil
+}
@@ -0,0 +1,105 @@
+package service
+
+import (
+	"testing"
+
+	"ForumService/internal/models"
+	"ForumService/internal/repository"
+	"ForumService/internal/service/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestNormalizeTags(t *testing.T) {
+	tests := []struct {
+		name     string
+		raw      []string
+		expected []string
+		err      error
+	}{
+		{"регистр и пробелы", []string{" Go ", "Machine   Learning"}, []string{"go", "machine-learning"}, nil},
+		{"повторы", []string{"go", "GO", "sql"}, []string{"go", "sql"}, nil},
+		{"спецсимволы", []string{"C++", "c#", "node.js", "Базы_данных"}, []string{"c++", "c#", "node.js", "базы_данных"}, nil},
+		{"слишком короткий", []string{"a"}, nil, ErrInvalidTag},
+		{"недопустимый символ", []string{"go!"}, nil, ErrInvalidTag},
+		{"слишком длинный", []string{"abcdefghijklmnopqrstuvwxyz1234567"}, nil, ErrInvalidTag},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tags, err := normalizeTags(tt.raw)
+			assert.ErrorIs(t, err, tt.err)
+			if tt.err == nil {
+				assert.Equal(t, tt.expected, tags)
+			}
+		})
+	}
+}
+
+func TestNormalizeThreadTags_Limit(t *testing.T) {
+	_, err := normalizeThreadTags([]string{"go", "sql", "db", "web", "api", "GO"})
+	assert.NoError(t, err, "повторы не учитываются в лимите")
+
+	_, err = normalizeThreadTags([]string{"go", "sql", "db", "web", "api", "grpc"})
+	assert.ErrorIs(t, err, ErrTooManyTags)
+}
+
+func TestRenameTag(t *testing.T) {
+	tagRepo := new(mocks.MockTagRepo)
+	userRepo := new(mocks.MockUserRepo)
+	service := NewTagService(tagRepo, userRepo)
+	userRepo.On("GetUserRole", 1).Return("moderator", nil)
+	tagRepo.On("Rename", 3, "golang").Return(nil)
+	tagRepo.On("GetByID", 3).Return(&models.Tag{ID: 3, Name: "golang", ThreadCount: 2}, nil)
+
+	tag, err := service.RenameTag(3, " GoLang ", 1)
+	assert.NoError(t, err)
+	assert.Equal(t, "golang", tag.Name)
+}
+
+func TestRenameTag_Errors(t *testing.T) {
+	tagRepo := new(mocks.MockTagRepo)
+	userRepo := new(mocks.MockUserRepo)
+	service := NewTagService(tagRepo, userRepo)
+	userRepo.On("GetUserRole", 1).Return("user", nil)
+	userRepo.On("GetUserRole", 2).Return("admin", nil)
+	tagRepo.On("Rename", 3, "sql").Return(repository.ErrAlreadyExists)
+	tagRepo.On("Rename", 4, "sql").Return(repository.ErrTagNotFound)
+
+	_, err := service.RenameTag(3, "sql", 1)
+	assert.ErrorIs(t, err, ErrNoPermission)
+	_, err = service.RenameTag(3, "!", 2)
+	assert.ErrorIs(t, err, ErrInvalidTag)
+	_, err = service.RenameTag(3, "sql", 2)
+	assert.ErrorIs(t, err, ErrTagExists)
+	_, err = service.RenameTag(4, "sql", 2)
+	assert.ErrorIs(t, err, ErrTagNotFound)
+}
+
+func TestMergeTags(t *testing.T) {
+	tagRepo := new(mocks.MockTagRepo)
+	userRepo := new(mocks.MockUserRepo)
+	service := NewTagService(tagRepo, userRepo)
+	userRepo.On("GetUserRole", 1).Return("moderator", nil)
+	tagRepo.On("Merge", 2, 1).Return(nil)
+	tagRepo.On("GetByID", 1).Return(&models.Tag{ID: 1, Name: "go", ThreadCount: 5}, nil)
+
+	tag, err := service.MergeTags(2, 1, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, 5, tag.ThreadCount)
+
+	_, err = service.MergeTags(1, 1, 1)
+	assert.ErrorIs(t, err, ErrInvalidTagMerge)
+	tagRepo.AssertNumberOfCalls(t, "Merge", 1)
+}
+
+func TestMergeTags_NoPermission(t *testing.T) {
+	tagRepo := new(mocks.MockTagRepo)
+	userRepo := new(mocks.MockUserRepo)
+	service := NewTagService(tagRepo, userRepo)
+	userRepo.On("GetUserRole", 1).Return("user", nil)
+
+	_, err := service.MergeTags(2, 1, 1)
+	assert.ErrorIs(t, err, ErrNoPermission)
+	tagRepo.AssertNotCalled(t, "Merge", mock.Anything, mock.Anything)
+}
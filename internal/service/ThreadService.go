@@ -3,31 +3,56 @@ package service
 import (
 	"ForumService/internal/models"
 	"ForumService/internal/repository"
-	"errors"
 	"fmt"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
 )
 
+// MaxLockReasonLength ограничивает длину причины закрытия треда
+const MaxLockReasonLength = 500
+
+// MaxPinOrder ограничивает порядок закрепленного треда
+const MaxPinOrder = 1000
+
 type ThreadService interface {
 	GetThreadWithPosts(threadID int) (*models.Thread, []*models.Post, error)
-	CreateThread(title string, authorID int) (*models.Thread, error)
+	CreateThread(title string, threadType string, categoryID *int, tags []string, authorID int) (*models.Thread, error)
 	UpdateThread(thread *models.Thread, userID int) error
 	DeleteThread(threadID int, userID int) error
-	GetAllThreads() ([]*models.Thread, error)
+	SetThreadLock(threadID int, locked bool, reason string, userID int) (*models.Thread, error)
+	SetThreadPin(threadID int, pinned bool, order int, until *time.Time, userID int) (*models.Thread, error)
+	// SetAcceptedAnswer принимает пост postID как ответ на вопрос треда, nil снимает отметку
+	SetAcceptedAnswer(threadID int, postID *int, userID int) (*models.Thread, error)
+	GetAllThreads(role string) ([]*models.Thread, error)
+	GetThreadsByCategory(categoryID int, role string) ([]*models.Thread, error)
+	GetThreadsByTags(tags []string, matchAll bool, role string) ([]*models.Thread, error)
+	CheckThreadAccess(thread *models.Thread, role string) error
+	// CheckThreadIDAccess и CheckPostAccess проверяют доступ к разделу треда по ID треда или его поста
+	CheckThreadIDAccess(threadID int, role string) error
+	CheckPostAccess(postID int, role string) error
+	// FilterReadablePosts убирает посты тредов из разделов, закрытых для роли
+	FilterReadablePosts(posts []*models.Post, role string) ([]*models.Post, error)
 	GetPostsByThreadID(threadID int) ([]*models.Post, error)
 	GetUserByID(userID int) (*models.User, error)
 }
 
 type threadService struct {
-	threadRepo repository.ThreadRepository
-	postRepo   repository.PostRepository
-	userRepo   repository.UserRepository
+	threadRepo   repository.ThreadRepository
+	postRepo     repository.PostRepository
+	userRepo     repository.UserRepository
+	categoryRepo repository.CategoryRepository
+	tagRepo      repository.TagRepository
 }
 
-func NewThreadService(threadRepo repository.ThreadRepository, postRepo repository.PostRepository, userRepo repository.UserRepository) ThreadService {
+func NewThreadService(threadRepo repository.ThreadRepository, postRepo repository.PostRepository, userRepo repository.UserRepository, categoryRepo repository.CategoryRepository, tagRepo repository.TagRepository) ThreadService {
 	return &threadService{
-		threadRepo: threadRepo,
-		postRepo:   postRepo,
-		userRepo:   userRepo,
+		threadRepo:   threadRepo,
+		postRepo:     postRepo,
+		userRepo:     userRepo,
+		categoryRepo: categoryRepo,
+		tagRepo:      tagRepo,
 	}
 }
 
@@ -36,14 +61,13 @@ func (s *threadService) GetThreadWithPosts(threadID int) (*models.Thread, []*mod
 	thread, err := s.threadRepo.GetByID(threadID)
 	if err != nil {
 		fmt.Printf("Ошибка при получении треда из репозитория: %v\n", err)
-		return nil, nil, err
-	}
-	if thread == nil {
-		fmt.Printf("Тред не найден\n")
-		return nil, nil, errors.New("thread not found")
+		return nil, nil, translateRepoError(err)
 	}
 
 	fmt.Printf("Тред найден: %+v\n", thread)
+	if err := s.attachTags([]*models.Thread{thread}); err != nil {
+		return nil, nil, err
+	}
 	posts, err := s.postRepo.GetByThreadID(threadID)
 	if err != nil {
 		fmt.Printf("Ошибка при получении постов: %v\n", err)
@@ -51,72 +75,518 @@ func (s *threadService) GetThreadWithPosts(threadID int) (*models.Thread, []*mod
 	}
 
 	fmt.Printf("Получено постов: %d\n", len(posts))
+	markAcceptedAnswer(thread, posts)
+	if err := attachPostLinks(s.postRepo, posts); err != nil {
+		return nil, nil, err
+	}
+	SortPosts(posts, SortDate)
 	return thread, posts, nil
 }
 
-func (s *threadService) CreateThread(title string, authorID int) (*models.Thread, error) {
+// CreateThread создает тред типа threadType, пустой тип - обсуждение. Если указан раздел,
+// автор должен иметь право создавать в нем треды. Теги нормализуются, повторы отбрасываются.
+func (s *threadService) CreateThread(title string, threadType string, categoryID *int, tags []string, authorID int) (*models.Thread, error) {
+	switch threadType {
+	case "":
+		threadType = models.ThreadTypeDiscussion
+	case models.ThreadTypeDiscussion, models.ThreadTypeQuestion:
+	default:
+		return nil, ErrInvalidThreadType
+	}
+	tags, err := normalizeThreadTags(tags)
+	if err != nil {
+		return nil, err
+	}
 	thread := &models.Thread{
-		Title:    title,
-		AuthorID: authorID,
+		Title:      title,
+		Type:       threadType,
+		AuthorID:   authorID,
+		CategoryID: categoryID,
+	}
+
+	if categoryID != nil {
+		if err := s.checkCategoryCreate(*categoryID, authorID); err != nil {
+			return nil, err
+		}
 	}
 
 	if err := s.threadRepo.Create(thread); err != nil {
-		return nil, err
+		return nil, translateRepoError(err)
+	}
+	thread.Tags = []string{}
+	if len(tags) > 0 {
+		if err := s.setTags(thread, tags); err != nil {
+			return nil, err
+		}
 	}
 
 	return thread, nil
 }
 
+// UpdateThread сохраняет название, тип, раздел и теги треда. Пустой тип оставляет прежний,
+// если thread.Tags равен nil, теги не меняются.
 func (s *threadService) UpdateThread(thread *models.Thread, userID int) error {
+	switch thread.Type {
+	case "", models.ThreadTypeDiscussion, models.ThreadTypeQuestion:
+	default:
+		return ErrInvalidThreadType
+	}
+	var tags []string
+	if thread.Tags != nil {
+		var err error
+		if tags, err = normalizeThreadTags(thread.Tags); err != nil {
+			return err
+		}
+	}
+
 	existingThread, err := s.threadRepo.GetByID(thread.ID)
 	if err != nil {
-		return err
+		return translateRepoError(err)
 	}
 
 	// Получаем роль пользователя
 	userRole, err := s.userRepo.GetUserRole(userID)
 	if err != nil {
-		return err
+		return translateRepoError(err)
 	}
 
 	if existingThread.AuthorID != userID && userRole != "admin" {
 		return ErrNoPermission
 	}
 
-	return s.threadRepo.Update(thread)
+	if thread.Type == "" {
+		thread.Type = existingThread.Type
+	}
+
+	// Перенести тред можно только в раздел, где пользователь может создавать треды
+	if thread.CategoryID != nil && !sameCategory(existingThread.CategoryID, thread.CategoryID) {
+		if err := s.checkCategoryCreate(*thread.CategoryID, userID); err != nil {
+			return err
+		}
+	}
+
+	if err := s.threadRepo.Update(thread); err != nil {
+		return translateRepoError(err)
+	}
+	if tags != nil {
+		return s.setTags(thread, tags)
+	}
+	return nil
 }
 
 func (s *threadService) DeleteThread(threadID int, userID int) error {
 	thread, err := s.threadRepo.GetByID(threadID)
 	if err != nil {
-		return err
+		return translateRepoError(err)
 	}
 
 	// Получаем роль пользователя
 	userRole, err := s.userRepo.GetUserRole(userID)
 	if err != nil {
-		return err
+		return translateRepoError(err)
 	}
 
 	if thread.AuthorID != userID && userRole != "admin" {
 		return ErrNoPermission
 	}
 
-	return s.threadRepo.Delete(threadID)
+	return translateRepoError(s.threadRepo.Delete(threadID))
+}
+
+// SetThreadLock закрывает или открывает тред. Доступно модераторам и администраторам.
+func (s *threadService) SetThreadLock(threadID int, locked bool, reason string, userID int) (*models.Thread, error) {
+	if err := s.requireModerator(userID); err != nil {
+		return nil, err
+	}
+
+	var err error
+	if locked {
+		reason = strings.TrimSpace(reason)
+		if utf8.RuneCountInString(reason) > MaxLockReasonLength {
+			return nil, ErrInvalidLockReason
+		}
+		err = s.threadRepo.Lock(threadID, userID, reason)
+	} else {
+		err = s.threadRepo.Unlock(threadID)
+	}
+	if err != nil {
+		return nil, translateRepoError(err)
+	}
+	return s.getThread(threadID)
+}
+
+// SetThreadPin закрепляет тред с порядком order до until (nil - бессрочно) или снимает
+// закрепление. Доступно модераторам и администраторам.
+func (s *threadService) SetThreadPin(threadID int, pinned bool, order int, until *time.Time, userID int) (*models.Thread, error) {
+	if err := s.requireModerator(userID); err != nil {
+		return nil, err
+	}
+
+	var err error
+	if pinned {
+		if order < 0 || order > MaxPinOrder {
+			return nil, ErrInvalidPinOrder
+		}
+		if until != nil && !until.After(time.Now()) {
+			return nil, ErrInvalidPinExpiry
+		}
+		err = s.threadRepo.Pin(threadID, order, until)
+	} else {
+		err = s.threadRepo.Unpin(threadID)
+	}
+	if err != nil {
+		return nil, translateRepoError(err)
+	}
+	return s.getThread(threadID)
+}
+
+// SetAcceptedAnswer отмечает принятый ответ треда-вопроса. Доступно автору треда,
+// модераторам и администраторам. Ответом может быть любой пост треда, кроме первого:
+// первый пост и есть вопрос.
+func (s *threadService) SetAcceptedAnswer(threadID int, postID *int, userID int) (*models.Thread, error) {
+	thread, err := s.threadRepo.GetByID(threadID)
+	if err != nil {
+		return nil, translateRepoError(err)
+	}
+	if thread.AuthorID != userID {
+		if err := s.requireModerator(userID); err != nil {
+			return nil, err
+		}
+	}
+	if thread.Type != models.ThreadTypeQuestion {
+		return nil, ErrNotQuestion
+	}
+
+	if postID != nil {
+		posts, err := s.postRepo.GetByThreadID(threadID)
+		if err != nil {
+			return nil, translateRepoError(err)
+		}
+		opening := openingPost(posts)
+		found := false
+		for _, post := range posts {
+			if post.ID == *postID {
+				found = true
+				break
+			}
+		}
+		if !found || opening == nil || opening.ID == *postID {
+			return nil, ErrInvalidAnswer
+		}
+	}
+
+	if err := s.threadRepo.SetAcceptedPost(threadID, postID); err != nil {
+		return nil, translateRepoError(err)
+	}
+	return s.getThread(threadID)
+}
+
+// requireModerator возвращает ErrNoPermission, если пользователь не модератор и не администратор
+func (s *threadService) requireModerator(userID int) error {
+	userRole, err := s.userRepo.GetUserRole(userID)
+	if err != nil {
+		return translateRepoError(err)
+	}
+	if !models.Role(userRole).Allows(models.RoleModerator) {
+		return ErrNoPermission
+	}
+	return nil
+}
+
+// getThread возвращает тред с тегами
+func (s *threadService) getThread(threadID int) (*models.Thread, error) {
+	thread, err := s.threadRepo.GetByID(threadID)
+	if err != nil {
+		return nil, translateRepoError(err)
+	}
+	if err := s.attachTags([]*models.Thread{thread}); err != nil {
+		return nil, err
+	}
+	return thread, nil
 }
 
-func (s *threadService) GetAllThreads() ([]*models.Thread, error) {
+// GetAllThreads возвращает треды, кроме тредов из разделов, закрытых для роли role
+func (s *threadService) GetAllThreads(role string) ([]*models.Thread, error) {
 	threads, err := s.threadRepo.GetAllThreads()
 	if err != nil {
 		return nil, fmt.Errorf("ошибка при получении тредов: %v", err)
 	}
+
+	hidden, err := s.hiddenCategories(threads, models.Role(role))
+	if err != nil {
+		return nil, err
+	}
+	visible := threads
+	if len(hidden) > 0 {
+		visible = make([]*models.Thread, 0, len(threads))
+		for _, thread := range threads {
+			if thread.CategoryID == nil || !hidden[*thread.CategoryID] {
+				visible = append(visible, thread)
+			}
+		}
+	}
+
+	if err := s.attachTags(visible); err != nil {
+		return nil, err
+	}
+	return visible, nil
+}
+
+// GetThreadsByTags возвращает видимые роли треды со всеми тегами tags при matchAll
+// или хотя бы с одним из них
+func (s *threadService) GetThreadsByTags(tags []string, matchAll bool, role string) ([]*models.Thread, error) {
+	tags, err := normalizeTags(tags)
+	if err != nil {
+		return nil, err
+	}
+	threadIDs, err := s.tagRepo.GetThreadIDs(tags, matchAll)
+	if err != nil {
+		return nil, translateRepoError(err)
+	}
+	if len(threadIDs) == 0 {
+		return []*models.Thread{}, nil
+	}
+
+	threads, err := s.GetAllThreads(role)
+	if err != nil {
+		return nil, err
+	}
+	matched := make(map[int]bool, len(threadIDs))
+	for _, id := range threadIDs {
+		matched[id] = true
+	}
+	result := make([]*models.Thread, 0, len(threadIDs))
+	for _, thread := range threads {
+		if matched[thread.ID] {
+			result = append(result, thread)
+		}
+	}
+	return result, nil
+}
+
+// GetThreadsByCategory возвращает треды раздела без подразделов
+func (s *threadService) GetThreadsByCategory(categoryID int, role string) ([]*models.Thread, error) {
+	readRole, err := categoryReadRole(s.categoryRepo, categoryID)
+	if err != nil {
+		return nil, err
+	}
+	if !models.Role(role).Allows(readRole) {
+		return nil, ErrNoPermission
+	}
+
+	threads, err := s.threadRepo.GetByCategoryID(categoryID)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при получении тредов раздела: %v", err)
+	}
+	if err := s.attachTags(threads); err != nil {
+		return nil, err
+	}
 	return threads, nil
 }
 
+// CheckThreadAccess проверяет, что роль role может читать раздел треда и всех его предков
+func (s *threadService) CheckThreadAccess(thread *models.Thread, role string) error {
+	if thread.CategoryID == nil {
+		return nil
+	}
+	readRole, err := categoryReadRole(s.categoryRepo, *thread.CategoryID)
+	if err != nil {
+		return err
+	}
+	if !models.Role(role).Allows(readRole) {
+		return ErrNoPermission
+	}
+	return nil
+}
+
+func (s *threadService) CheckThreadIDAccess(threadID int, role string) error {
+	thread, err := s.threadRepo.GetByID(threadID)
+	if err != nil {
+		return translateRepoError(err)
+	}
+	return s.CheckThreadAccess(thread, role)
+}
+
+func (s *threadService) CheckPostAccess(postID int, role string) error {
+	post, err := s.postRepo.GetPostByID(postID)
+	if err != nil {
+		return translateRepoError(err)
+	}
+	return s.CheckThreadIDAccess(post.ThreadID, role)
+}
+
+func (s *threadService) FilterReadablePosts(posts []*models.Post, role string) ([]*models.Post, error) {
+	if len(posts) == 0 {
+		return posts, nil
+	}
+	threads, err := s.threadRepo.GetAllThreads()
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при получении тредов: %v", err)
+	}
+	hidden, err := s.hiddenCategories(threads, models.Role(role))
+	if err != nil {
+		return nil, err
+	}
+	if len(hidden) == 0 {
+		return posts, nil
+	}
+
+	hiddenThreads := make(map[int]bool)
+	for _, thread := range threads {
+		if thread.CategoryID != nil && hidden[*thread.CategoryID] {
+			hiddenThreads[thread.ID] = true
+		}
+	}
+	readable := make([]*models.Post, 0, len(posts))
+	for _, post := range posts {
+		if !hiddenThreads[post.ThreadID] {
+			readable = append(readable, post)
+		}
+	}
+	return readable, nil
+}
+
+// hiddenCategories возвращает разделы тредов, закрытые для роли сами или через предков. Разделы
+// запрашиваются, только если среди тредов есть треды в разделах.
+func (s *threadService) hiddenCategories(threads []*models.Thread, role models.Role) (map[int]bool, error) {
+	categorized := false
+	for _, thread := range threads {
+		if thread.CategoryID != nil {
+			categorized = true
+			break
+		}
+	}
+	if !categorized {
+		return nil, nil
+	}
+
+	categories, err := s.categoryRepo.GetAll()
+	if err != nil {
+		return nil, translateRepoError(err)
+	}
+	hidden := make(map[int]bool)
+	for id, readRole := range models.EffectiveReadRoles(categories) {
+		if !role.Allows(readRole) {
+			hidden[id] = true
+		}
+	}
+	return hidden, nil
+}
+
+// checkCategoryCreate проверяет, что пользователь может создавать треды в разделе
+func (s *threadService) checkCategoryCreate(categoryID int, userID int) error {
+	category, err := s.categoryRepo.GetByID(categoryID)
+	if err != nil {
+		return translateRepoError(err)
+	}
+	readRole, err := categoryReadRole(s.categoryRepo, categoryID)
+	if err != nil {
+		return err
+	}
+	userRole, err := s.userRepo.GetUserRole(userID)
+	if err != nil {
+		return translateRepoError(err)
+	}
+	role := models.Role(userRole)
+	if !role.Allows(readRole) || !role.Allows(category.CreateRole) {
+		return ErrNoPermission
+	}
+	return nil
+}
+
+// setTags сохраняет уже нормализованные теги треда
+func (s *threadService) setTags(thread *models.Thread, tags []string) error {
+	if err := s.tagRepo.SetThreadTags(thread.ID, tags); err != nil {
+		return translateRepoError(err)
+	}
+	sorted := append([]string{}, tags...)
+	sort.Strings(sorted)
+	thread.Tags = sorted
+	return nil
+}
+
+// attachTags загружает теги тредов одним запросом. У тредов без тегов Tags - пустой срез.
+func (s *threadService) attachTags(threads []*models.Thread) error {
+	if len(threads) == 0 {
+		return nil
+	}
+	threadIDs := make([]int, len(threads))
+	for i, thread := range threads {
+		threadIDs[i] = thread.ID
+	}
+	tags, err := s.tagRepo.GetByThreadIDs(threadIDs)
+	if err != nil {
+		return translateRepoError(err)
+	}
+	for _, thread := range threads {
+		thread.Tags = tags[thread.ID]
+		if thread.Tags == nil {
+			thread.Tags = []string{}
+		}
+	}
+	return nil
+}
+
+func sameCategory(a, b *int) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+// GetPostsByThreadID возвращает посты треда; в треде-вопросе принятый ответ отмечен
+// и идет сразу после первого поста
 func (s *threadService) GetPostsByThreadID(threadID int) ([]*models.Post, error) {
-	return s.postRepo.GetByThreadID(threadID)
+	thread, err := s.threadRepo.GetByID(threadID)
+	if err != nil {
+		return nil, translateRepoError(err)
+	}
+	posts, err := s.postRepo.GetByThreadID(threadID)
+	if err != nil {
+		return nil, err
+	}
+	markAcceptedAnswer(thread, posts)
+	if err := attachPostLinks(s.postRepo, posts); err != nil {
+		return nil, err
+	}
+	SortPosts(posts, SortDate)
+	return posts, nil
+}
+
+// markAcceptedAnswer отмечает принятый ответ среди постов треда
+func markAcceptedAnswer(thread *models.Thread, posts []*models.Post) {
+	for _, post := range posts {
+		post.Accepted = thread.AcceptedPostID != nil && post.ID == *thread.AcceptedPostID
+	}
 }
 
 func (s *threadService) GetUserByID(userID int) (*models.User, error) {
-	return s.userRepo.GetUserByID(userID)
+	user, err := s.userRepo.GetUserByID(userID)
+	if err != nil {
+		return nil, translateRepoError(err)
+	}
+	return user, nil
+}
+
+// ensureThreadOpen возвращает ErrThreadLocked, если в тред нельзя добавлять посты и комментарии.
+// Это ранняя проверка до остальной работы: окончательно закрытие проверяет репозиторий
+// в транзакции сохранения, иначе пост, сохраняемый одновременно с закрытием, проскочит.
+func ensureThreadOpen(threadRepo repository.ThreadRepository, threadID int) error {
+	thread, err := threadRepo.GetByID(threadID)
+	if err != nil {
+		return translateRepoError(err)
+	}
+	if thread.Locked {
+		return ErrThreadLocked
+	}
+	return nil
+}
+
+// ensurePostThreadOpen проверяет, что тред поста postID открыт для комментариев
+func ensurePostThreadOpen(postRepo repository.PostRepository, threadRepo repository.ThreadRepository, postID int) error {
+	post, err := postRepo.GetPostByID(postID)
+	if err != nil {
+		return translateRepoError(err)
+	}
+	return ensureThreadOpen(threadRepo, post.ThreadID)
 }
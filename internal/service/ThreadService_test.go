@@ -2,23 +2,34 @@ package service
 
 import (
 	"errors"
+	"strings"
 	"testing"
+	"time"
 
 	"ForumService/internal/models"
+	"ForumService/internal/repository"
 	"ForumService/internal/service/mocks"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 )
 
+// untaggedRepo возвращает репозиторий тегов, в котором у тредов нет тегов
+func untaggedRepo() *mocks.MockTagRepo {
+	tagRepo := new(mocks.MockTagRepo)
+	tagRepo.On("GetByThreadIDs", mock.Anything).Return(map[int][]string{}, nil).Maybe()
+	return tagRepo
+}
+
 func TestGetThreadWithPosts_Success(t *testing.T) {
 	threadRepo := new(mocks.MockThreadRepo)
 	postRepo := new(mocks.MockPostRepo)
 	userRepo := new(mocks.MockUserRepo)
-	service := NewThreadService(threadRepo, postRepo, userRepo)
+	service := NewThreadService(threadRepo, postRepo, userRepo, new(mocks.MockCategoryRepo), untaggedRepo())
 
 	thread := &models.Thread{ID: 1, Title: "Test", AuthorID: 1}
 	posts := []*models.Post{{ID: 1, ThreadID: 1, AuthorID: 1, Content: "post"}}
 	threadRepo.On("GetByID", 1).Return(thread, nil)
+	expectNoReplies(postRepo)
 	postRepo.On("GetByThreadID", 1).Return(posts, nil)
 
 	resThread, resPosts, err := service.GetThreadWithPosts(1)
@@ -31,12 +42,12 @@ func TestGetThreadWithPosts_ThreadNotFound(t *testing.T) {
 	threadRepo := new(mocks.MockThreadRepo)
 	postRepo := new(mocks.MockPostRepo)
 	userRepo := new(mocks.MockUserRepo)
-	service := NewThreadService(threadRepo, postRepo, userRepo)
+	service := NewThreadService(threadRepo, postRepo, userRepo, new(mocks.MockCategoryRepo), untaggedRepo())
 
-	threadRepo.On("GetByID", 2).Return((*models.Thread)(nil), errors.New("not found"))
+	threadRepo.On("GetByID", 2).Return((*models.Thread)(nil), repository.ErrThreadNotFound)
 
 	resThread, resPosts, err := service.GetThreadWithPosts(2)
-	assert.Error(t, err)
+	assert.ErrorIs(t, err, ErrThreadNotFound)
 	assert.Nil(t, resThread)
 	assert.Nil(t, resPosts)
 }
@@ -45,20 +56,35 @@ func TestCreateThread_Success(t *testing.T) {
 	threadRepo := new(mocks.MockThreadRepo)
 	postRepo := new(mocks.MockPostRepo)
 	userRepo := new(mocks.MockUserRepo)
-	service := NewThreadService(threadRepo, postRepo, userRepo)
+	service := NewThreadService(threadRepo, postRepo, userRepo, new(mocks.MockCategoryRepo), untaggedRepo())
 
 	threadRepo.On("Create", mock.AnythingOfType("*models.Thread")).Return(nil)
-	thread, err := service.CreateThread("title", 1)
+	thread, err := service.CreateThread("title", "", nil, nil, 1)
 	assert.NoError(t, err)
 	assert.Equal(t, "title", thread.Title)
 	assert.Equal(t, 1, thread.AuthorID)
+	assert.Equal(t, models.ThreadTypeDiscussion, thread.Type)
+}
+
+func TestCreateThread_Question(t *testing.T) {
+	threadRepo := new(mocks.MockThreadRepo)
+	service := NewThreadService(threadRepo, new(mocks.MockPostRepo), new(mocks.MockUserRepo), new(mocks.MockCategoryRepo), untaggedRepo())
+
+	threadRepo.On("Create", mock.AnythingOfType("*models.Thread")).Return(nil)
+	thread, err := service.CreateThread("title", models.ThreadTypeQuestion, nil, nil, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, models.ThreadTypeQuestion, thread.Type)
+
+	_, err = service.CreateThread("title", "poll", nil, nil, 1)
+	assert.ErrorIs(t, err, ErrInvalidThreadType)
+	threadRepo.AssertNumberOfCalls(t, "Create", 1)
 }
 
 func TestUpdateThread_NoPermission(t *testing.T) {
 	threadRepo := new(mocks.MockThreadRepo)
 	postRepo := new(mocks.MockPostRepo)
 	userRepo := new(mocks.MockUserRepo)
-	service := NewThreadService(threadRepo, postRepo, userRepo)
+	service := NewThreadService(threadRepo, postRepo, userRepo, new(mocks.MockCategoryRepo), untaggedRepo())
 
 	thread := &models.Thread{ID: 1, AuthorID: 2}
 	threadRepo.On("GetByID", 1).Return(thread, nil)
@@ -72,7 +98,7 @@ func TestUpdateThread_Admin(t *testing.T) {
 	threadRepo := new(mocks.MockThreadRepo)
 	postRepo := new(mocks.MockPostRepo)
 	userRepo := new(mocks.MockUserRepo)
-	service := NewThreadService(threadRepo, postRepo, userRepo)
+	service := NewThreadService(threadRepo, postRepo, userRepo, new(mocks.MockCategoryRepo), untaggedRepo())
 
 	thread := &models.Thread{ID: 1, AuthorID: 2}
 	threadRepo.On("GetByID", 1).Return(thread, nil)
@@ -83,11 +109,32 @@ func TestUpdateThread_Admin(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+func TestUpdateThread_Type(t *testing.T) {
+	threadRepo := new(mocks.MockThreadRepo)
+	userRepo := new(mocks.MockUserRepo)
+	service := NewThreadService(threadRepo, new(mocks.MockPostRepo), userRepo, new(mocks.MockCategoryRepo), untaggedRepo())
+
+	threadRepo.On("GetByID", 1).Return(&models.Thread{ID: 1, AuthorID: 1, Type: models.ThreadTypeQuestion}, nil)
+	userRepo.On("GetUserRole", 1).Return("user", nil)
+	threadRepo.On("Update", mock.AnythingOfType("*models.Thread")).Return(nil)
+
+	// Без типа остается прежний
+	thread := &models.Thread{ID: 1, Title: "Тред"}
+	assert.NoError(t, service.UpdateThread(thread, 1))
+	assert.Equal(t, models.ThreadTypeQuestion, thread.Type)
+
+	thread = &models.Thread{ID: 1, Title: "Тред", Type: models.ThreadTypeDiscussion}
+	assert.NoError(t, service.UpdateThread(thread, 1))
+	assert.Equal(t, models.ThreadTypeDiscussion, thread.Type)
+
+	assert.ErrorIs(t, service.UpdateThread(&models.Thread{ID: 1, Type: "poll"}, 1), ErrInvalidThreadType)
+}
+
 func TestDeleteThread_NoPermission(t *testing.T) {
 	threadRepo := new(mocks.MockThreadRepo)
 	postRepo := new(mocks.MockPostRepo)
 	userRepo := new(mocks.MockUserRepo)
-	service := NewThreadService(threadRepo, postRepo, userRepo)
+	service := NewThreadService(threadRepo, postRepo, userRepo, new(mocks.MockCategoryRepo), untaggedRepo())
 
 	thread := &models.Thread{ID: 1, AuthorID: 2}
 	threadRepo.On("GetByID", 1).Return(thread, nil)
@@ -101,7 +148,7 @@ func TestDeleteThread_Admin(t *testing.T) {
 	threadRepo := new(mocks.MockThreadRepo)
 	postRepo := new(mocks.MockPostRepo)
 	userRepo := new(mocks.MockUserRepo)
-	service := NewThreadService(threadRepo, postRepo, userRepo)
+	service := NewThreadService(threadRepo, postRepo, userRepo, new(mocks.MockCategoryRepo), untaggedRepo())
 
 	thread := &models.Thread{ID: 1, AuthorID: 2}
 	threadRepo.On("GetByID", 1).Return(thread, nil)
@@ -116,10 +163,10 @@ func TestGetAllThreads_Error(t *testing.T) {
 	threadRepo := new(mocks.MockThreadRepo)
 	postRepo := new(mocks.MockPostRepo)
 	userRepo := new(mocks.MockUserRepo)
-	service := NewThreadService(threadRepo, postRepo, userRepo)
+	service := NewThreadService(threadRepo, postRepo, userRepo, new(mocks.MockCategoryRepo), untaggedRepo())
 
 	threadRepo.On("GetAllThreads").Return(([]*models.Thread)(nil), errors.New("fail"))
-	threads, err := service.GetAllThreads()
+	threads, err := service.GetAllThreads("user")
 	assert.Error(t, err)
 	assert.Nil(t, threads)
 }
@@ -128,14 +175,14 @@ func TestGetAllThreads_Success(t *testing.T) {
 	threadRepo := new(mocks.MockThreadRepo)
 	postRepo := new(mocks.MockPostRepo)
 	userRepo := new(mocks.MockUserRepo)
-	service := NewThreadService(threadRepo, postRepo, userRepo)
+	service := NewThreadService(threadRepo, postRepo, userRepo, new(mocks.MockCategoryRepo), untaggedRepo())
 
 	threads := []*models.Thread{
 		{ID: 1, Title: "Thread 1", AuthorID: 1},
 		{ID: 2, Title: "Thread 2", AuthorID: 2},
 	}
 	threadRepo.On("GetAllThreads").Return(threads, nil)
-	res, err := service.GetAllThreads()
+	res, err := service.GetAllThreads("user")
 	assert.NoError(t, err)
 	assert.Equal(t, threads, res)
 }
@@ -144,20 +191,48 @@ func TestGetPostsByThreadID(t *testing.T) {
 	threadRepo := new(mocks.MockThreadRepo)
 	postRepo := new(mocks.MockPostRepo)
 	userRepo := new(mocks.MockUserRepo)
-	service := NewThreadService(threadRepo, postRepo, userRepo)
+	service := NewThreadService(threadRepo, postRepo, userRepo, new(mocks.MockCategoryRepo), untaggedRepo())
 
 	posts := []*models.Post{{ID: 1, ThreadID: 1, AuthorID: 1, Content: "post"}}
+	threadRepo.On("GetByID", 1).Return(&models.Thread{ID: 1}, nil)
+	expectNoReplies(postRepo)
 	postRepo.On("GetByThreadID", 1).Return(posts, nil)
 	res, err := service.GetPostsByThreadID(1)
 	assert.NoError(t, err)
 	assert.Equal(t, posts, res)
 }
 
+func TestGetPostsByThreadID_AcceptedAnswer(t *testing.T) {
+	threadRepo := new(mocks.MockThreadRepo)
+	postRepo := new(mocks.MockPostRepo)
+	service := NewThreadService(threadRepo, postRepo, new(mocks.MockUserRepo), new(mocks.MockCategoryRepo), untaggedRepo())
+
+	accepted := 3
+	created := time.Now()
+	threadRepo.On("GetByID", 1).Return(&models.Thread{ID: 1, Type: models.ThreadTypeQuestion, AcceptedPostID: &accepted, Solved: true}, nil)
+	threadRepo.On("GetByID", 2).Return((*models.Thread)(nil), repository.ErrThreadNotFound)
+	expectNoReplies(postRepo)
+	postRepo.On("GetByThreadID", 1).Return([]*models.Post{
+		{ID: 1, ThreadID: 1, CreatedAt: created},
+		{ID: 2, ThreadID: 1, CreatedAt: created.Add(time.Minute)},
+		{ID: 3, ThreadID: 1, CreatedAt: created.Add(2 * time.Minute)},
+	}, nil)
+
+	posts, err := service.GetPostsByThreadID(1)
+	assert.NoError(t, err)
+	assert.Equal(t, []int{1, 3, 2}, postIDs(posts), "принятый ответ идет сразу после первого поста")
+	assert.True(t, posts[1].Accepted)
+	assert.False(t, posts[2].Accepted)
+
+	_, err = service.GetPostsByThreadID(2)
+	assert.ErrorIs(t, err, ErrThreadNotFound)
+}
+
 func TestGetUserByID(t *testing.T) {
 	threadRepo := new(mocks.MockThreadRepo)
 	postRepo := new(mocks.MockPostRepo)
 	userRepo := new(mocks.MockUserRepo)
-	service := NewThreadService(threadRepo, postRepo, userRepo)
+	service := NewThreadService(threadRepo, postRepo, userRepo, new(mocks.MockCategoryRepo), untaggedRepo())
 
 	user := &models.User{ID: 1, Username: "test"}
 	userRepo.On("GetUserByID", 1).Return(user, nil)
@@ -170,11 +245,12 @@ func TestGetThreadWithPosts_WithComments(t *testing.T) {
 	threadRepo := new(mocks.MockThreadRepo)
 	postRepo := new(mocks.MockPostRepo)
 	userRepo := new(mocks.MockUserRepo)
-	service := NewThreadService(threadRepo, postRepo, userRepo)
+	service := NewThreadService(threadRepo, postRepo, userRepo, new(mocks.MockCategoryRepo), untaggedRepo())
 
 	thread := &models.Thread{ID: 1, Title: "thread"}
 	posts := []*models.Post{{ID: 1, ThreadID: 1, AuthorID: 1, Content: "post"}}
 	threadRepo.On("GetByID", 1).Return(thread, nil)
+	expectNoReplies(postRepo)
 	postRepo.On("GetByThreadID", 1).Return(posts, nil)
 
 	resThread, resPosts, err := service.GetThreadWithPosts(1)
@@ -187,7 +263,7 @@ func TestGetThreadWithPosts_Error(t *testing.T) {
 	threadRepo := new(mocks.MockThreadRepo)
 	postRepo := new(mocks.MockPostRepo)
 	userRepo := new(mocks.MockUserRepo)
-	service := NewThreadService(threadRepo, postRepo, userRepo)
+	service := NewThreadService(threadRepo, postRepo, userRepo, new(mocks.MockCategoryRepo), untaggedRepo())
 
 	threadRepo.On("GetByID", 1).Return((*models.Thread)(nil), errors.New("db error"))
 
@@ -195,4 +271,428 @@ func TestGetThreadWithPosts_Error(t *testing.T) {
 	assert.Error(t, err)
 	assert.Nil(t, resThread)
 	assert.Nil(t, resPosts)
-} 
\ No newline at end of file
+} 
+func TestCreateThread_CategoryNoPermission(t *testing.T) {
+	threadRepo := new(mocks.MockThreadRepo)
+	postRepo := new(mocks.MockPostRepo)
+	userRepo := new(mocks.MockUserRepo)
+	categoryRepo := new(mocks.MockCategoryRepo)
+	service := NewThreadService(threadRepo, postRepo, userRepo, categoryRepo, untaggedRepo())
+
+	categoryID := 3
+	categoryRepo.On("GetByID", 3).Return(&models.Category{ID: 3, ReadRole: models.RoleGuest, CreateRole: models.RoleModerator}, nil)
+	userRepo.On("GetUserRole", 1).Return("user", nil)
+
+	thread, err := service.CreateThread("title", "", &categoryID, nil, 1)
+	assert.ErrorIs(t, err, ErrNoPermission)
+	assert.Nil(t, thread)
+	threadRepo.AssertNotCalled(t, "Create", mock.Anything)
+}
+
+func TestCreateThread_CategoryNotFound(t *testing.T) {
+	threadRepo := new(mocks.MockThreadRepo)
+	postRepo := new(mocks.MockPostRepo)
+	userRepo := new(mocks.MockUserRepo)
+	categoryRepo := new(mocks.MockCategoryRepo)
+	service := NewThreadService(threadRepo, postRepo, userRepo, categoryRepo, untaggedRepo())
+
+	categoryID := 3
+	categoryRepo.On("GetByID", 3).Return((*models.Category)(nil), repository.ErrCategoryNotFound)
+
+	_, err := service.CreateThread("title", "", &categoryID, nil, 1)
+	assert.ErrorIs(t, err, ErrCategoryNotFound)
+}
+
+func TestCreateThread_InCategory(t *testing.T) {
+	threadRepo := new(mocks.MockThreadRepo)
+	postRepo := new(mocks.MockPostRepo)
+	userRepo := new(mocks.MockUserRepo)
+	categoryRepo := new(mocks.MockCategoryRepo)
+	service := NewThreadService(threadRepo, postRepo, userRepo, categoryRepo, untaggedRepo())
+
+	categoryID := 3
+	categoryRepo.On("GetByID", 3).Return(&models.Category{ID: 3, ReadRole: models.RoleGuest, CreateRole: models.RoleModerator}, nil)
+	userRepo.On("GetUserRole", 1).Return("moderator", nil)
+	threadRepo.On("Create", mock.AnythingOfType("*models.Thread")).Return(nil)
+
+	thread, err := service.CreateThread("title", "", &categoryID, nil, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, &categoryID, thread.CategoryID)
+}
+
+func TestGetAllThreads_HidesClosedCategories(t *testing.T) {
+	threadRepo := new(mocks.MockThreadRepo)
+	postRepo := new(mocks.MockPostRepo)
+	userRepo := new(mocks.MockUserRepo)
+	categoryRepo := new(mocks.MockCategoryRepo)
+	service := NewThreadService(threadRepo, postRepo, userRepo, categoryRepo, untaggedRepo())
+
+	open, staff := 1, 2
+	threads := []*models.Thread{
+		{ID: 1, Title: "Open", CategoryID: &open},
+		{ID: 2, Title: "Staff", CategoryID: &staff},
+		{ID: 3, Title: "Uncategorized"},
+	}
+	threadRepo.On("GetAllThreads").Return(threads, nil)
+	categoryRepo.On("GetAll").Return([]*models.Category{
+		{ID: 1, ReadRole: models.RoleGuest},
+		{ID: 2, ReadRole: models.RoleModerator},
+	}, nil)
+
+	res, err := service.GetAllThreads("guest")
+	assert.NoError(t, err)
+	assert.Equal(t, []*models.Thread{threads[0], threads[2]}, res)
+
+	res, err = service.GetAllThreads("admin")
+	assert.NoError(t, err)
+	assert.Equal(t, threads, res)
+}
+
+func TestCheckPostAccess_ClosedCategory(t *testing.T) {
+	threadRepo := new(mocks.MockThreadRepo)
+	postRepo := new(mocks.MockPostRepo)
+	userRepo := new(mocks.MockUserRepo)
+	categoryRepo := new(mocks.MockCategoryRepo)
+	service := NewThreadService(threadRepo, postRepo, userRepo, categoryRepo, untaggedRepo())
+
+	staff := 2
+	postRepo.On("GetPostByID", 7).Return(&models.Post{ID: 7, ThreadID: 3}, nil)
+	threadRepo.On("GetByID", 3).Return(&models.Thread{ID: 3, CategoryID: &staff}, nil)
+	categoryRepo.On("GetByID", 2).Return(&models.Category{ID: 2, ReadRole: models.RoleModerator}, nil)
+
+	assert.ErrorIs(t, service.CheckPostAccess(7, "guest"), ErrNoPermission)
+	assert.NoError(t, service.CheckPostAccess(7, "moderator"))
+}
+
+func TestFilterReadablePosts(t *testing.T) {
+	threadRepo := new(mocks.MockThreadRepo)
+	postRepo := new(mocks.MockPostRepo)
+	userRepo := new(mocks.MockUserRepo)
+	categoryRepo := new(mocks.MockCategoryRepo)
+	service := NewThreadService(threadRepo, postRepo, userRepo, categoryRepo, untaggedRepo())
+
+	open, staff := 1, 2
+	threadRepo.On("GetAllThreads").Return([]*models.Thread{
+		{ID: 1, CategoryID: &open},
+		{ID: 2, CategoryID: &staff},
+	}, nil)
+	categoryRepo.On("GetAll").Return([]*models.Category{
+		{ID: 1, ReadRole: models.RoleGuest},
+		{ID: 2, ReadRole: models.RoleModerator},
+	}, nil)
+	posts := []*models.Post{{ID: 1, ThreadID: 1}, {ID: 2, ThreadID: 2}}
+
+	res, err := service.FilterReadablePosts(posts, "user")
+	assert.NoError(t, err)
+	assert.Equal(t, []*models.Post{posts[0]}, res)
+}
+
+func TestGetThreadsByCategory(t *testing.T) {
+	threadRepo := new(mocks.MockThreadRepo)
+	postRepo := new(mocks.MockPostRepo)
+	userRepo := new(mocks.MockUserRepo)
+	categoryRepo := new(mocks.MockCategoryRepo)
+	service := NewThreadService(threadRepo, postRepo, userRepo, categoryRepo, untaggedRepo())
+
+	categoryRepo.On("GetByID", 2).Return(&models.Category{ID: 2, ReadRole: models.RoleUser}, nil)
+	threads := []*models.Thread{{ID: 5, Title: "Thread"}}
+	threadRepo.On("GetByCategoryID", 2).Return(threads, nil)
+
+	_, err := service.GetThreadsByCategory(2, "guest")
+	assert.ErrorIs(t, err, ErrNoPermission)
+
+	res, err := service.GetThreadsByCategory(2, "user")
+	assert.NoError(t, err)
+	assert.Equal(t, threads, res)
+}
+
+func TestCheckThreadAccess(t *testing.T) {
+	threadRepo := new(mocks.MockThreadRepo)
+	postRepo := new(mocks.MockPostRepo)
+	userRepo := new(mocks.MockUserRepo)
+	categoryRepo := new(mocks.MockCategoryRepo)
+	service := NewThreadService(threadRepo, postRepo, userRepo, categoryRepo, untaggedRepo())
+
+	categoryID := 2
+	categoryRepo.On("GetByID", 2).Return(&models.Category{ID: 2, ReadRole: models.RoleModerator}, nil)
+
+	assert.NoError(t, service.CheckThreadAccess(&models.Thread{ID: 1}, "guest"))
+	assert.ErrorIs(t, service.CheckThreadAccess(&models.Thread{ID: 1, CategoryID: &categoryID}, "user"), ErrNoPermission)
+	assert.NoError(t, service.CheckThreadAccess(&models.Thread{ID: 1, CategoryID: &categoryID}, "moderator"))
+}
+
+func TestCheckThreadAccess_InheritsParentReadRole(t *testing.T) {
+	threadRepo := new(mocks.MockThreadRepo)
+	postRepo := new(mocks.MockPostRepo)
+	userRepo := new(mocks.MockUserRepo)
+	categoryRepo := new(mocks.MockCategoryRepo)
+	service := NewThreadService(threadRepo, postRepo, userRepo, categoryRepo, untaggedRepo())
+
+	// Открытый для гостей подраздел внутри раздела администраторов
+	parent, child := 1, 2
+	categories := []*models.Category{
+		{ID: 1, ReadRole: models.RoleAdmin},
+		{ID: 2, ParentID: &parent, ReadRole: models.RoleGuest},
+	}
+	categoryRepo.On("GetByID", 1).Return(categories[0], nil)
+	categoryRepo.On("GetByID", 2).Return(categories[1], nil)
+	categoryRepo.On("GetAll").Return(categories, nil)
+	thread := &models.Thread{ID: 5, Title: "Thread", CategoryID: &child}
+	threadRepo.On("GetByID", 5).Return(thread, nil)
+	threadRepo.On("GetAllThreads").Return([]*models.Thread{thread}, nil)
+	threadRepo.On("GetByCategoryID", 2).Return([]*models.Thread{thread}, nil)
+	postRepo.On("GetPostByID", 7).Return(&models.Post{ID: 7, ThreadID: 5}, nil)
+
+	assert.ErrorIs(t, service.CheckThreadAccess(thread, "moderator"), ErrNoPermission)
+	assert.ErrorIs(t, service.CheckPostAccess(7, "guest"), ErrNoPermission)
+	_, err := service.GetThreadsByCategory(child, "guest")
+	assert.ErrorIs(t, err, ErrNoPermission)
+	res, err := service.GetAllThreads("moderator")
+	assert.NoError(t, err)
+	assert.Empty(t, res)
+
+	assert.NoError(t, service.CheckThreadAccess(thread, "admin"))
+	res, err = service.GetThreadsByCategory(child, "admin")
+	assert.NoError(t, err)
+	assert.Len(t, res, 1)
+}
+
+func TestCreateThread_WithTags(t *testing.T) {
+	threadRepo := new(mocks.MockThreadRepo)
+	postRepo := new(mocks.MockPostRepo)
+	userRepo := new(mocks.MockUserRepo)
+	tagRepo := new(mocks.MockTagRepo)
+	service := NewThreadService(threadRepo, postRepo, userRepo, new(mocks.MockCategoryRepo), tagRepo)
+
+	threadRepo.On("Create", mock.AnythingOfType("*models.Thread")).Run(func(args mock.Arguments) {
+		args.Get(0).(*models.Thread).ID = 7
+	}).Return(nil)
+	tagRepo.On("SetThreadTags", 7, []string{"sql", "go"}).Return(nil)
+
+	thread, err := service.CreateThread("title", "", nil, []string{"SQL", "Go", "go"}, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"go", "sql"}, thread.Tags)
+}
+
+func TestCreateThread_InvalidTags(t *testing.T) {
+	threadRepo := new(mocks.MockThreadRepo)
+	service := NewThreadService(threadRepo, new(mocks.MockPostRepo), new(mocks.MockUserRepo), new(mocks.MockCategoryRepo), new(mocks.MockTagRepo))
+
+	_, err := service.CreateThread("title", "", nil, []string{"go", "sql", "db", "web", "api", "grpc"}, 1)
+	assert.ErrorIs(t, err, ErrTooManyTags)
+	_, err = service.CreateThread("title", "", nil, []string{"?"}, 1)
+	assert.ErrorIs(t, err, ErrInvalidTag)
+	threadRepo.AssertNotCalled(t, "Create", mock.Anything)
+}
+
+func TestUpdateThread_Tags(t *testing.T) {
+	threadRepo := new(mocks.MockThreadRepo)
+	userRepo := new(mocks.MockUserRepo)
+	tagRepo := new(mocks.MockTagRepo)
+	service := NewThreadService(threadRepo, new(mocks.MockPostRepo), userRepo, new(mocks.MockCategoryRepo), tagRepo)
+
+	threadRepo.On("GetByID", 1).Return(&models.Thread{ID: 1, AuthorID: 1}, nil)
+	userRepo.On("GetUserRole", 1).Return("user", nil)
+	threadRepo.On("Update", mock.AnythingOfType("*models.Thread")).Return(nil)
+	tagRepo.On("SetThreadTags", 1, []string{"db"}).Return(nil)
+
+	// Без тегов теги треда не трогаются
+	assert.NoError(t, service.UpdateThread(&models.Thread{ID: 1, Title: "Тред"}, 1))
+	tagRepo.AssertNotCalled(t, "SetThreadTags", mock.Anything, mock.Anything)
+
+	thread := &models.Thread{ID: 1, Title: "Тред", Tags: []string{"DB"}}
+	assert.NoError(t, service.UpdateThread(thread, 1))
+	assert.Equal(t, []string{"db"}, thread.Tags)
+}
+
+func TestGetAllThreads_AttachesTags(t *testing.T) {
+	threadRepo := new(mocks.MockThreadRepo)
+	tagRepo := new(mocks.MockTagRepo)
+	service := NewThreadService(threadRepo, new(mocks.MockPostRepo), new(mocks.MockUserRepo), new(mocks.MockCategoryRepo), tagRepo)
+
+	threadRepo.On("GetAllThreads").Return([]*models.Thread{{ID: 1}, {ID: 2}}, nil)
+	tagRepo.On("GetByThreadIDs", []int{1, 2}).Return(map[int][]string{1: {"go"}}, nil)
+
+	threads, err := service.GetAllThreads("user")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"go"}, threads[0].Tags)
+	assert.Equal(t, []string{}, threads[1].Tags)
+}
+
+func TestGetThreadsByTags(t *testing.T) {
+	threadRepo := new(mocks.MockThreadRepo)
+	tagRepo := new(mocks.MockTagRepo)
+	categoryRepo := new(mocks.MockCategoryRepo)
+	service := NewThreadService(threadRepo, new(mocks.MockPostRepo), new(mocks.MockUserRepo), categoryRepo, tagRepo)
+
+	staff := 9
+	threadRepo.On("GetAllThreads").Return([]*models.Thread{{ID: 1}, {ID: 2}, {ID: 3, CategoryID: &staff}}, nil)
+	categoryRepo.On("GetAll").Return([]*models.Category{{ID: 9, ReadRole: models.RoleModerator}}, nil)
+	tagRepo.On("GetThreadIDs", []string{"go", "sql"}, false).Return([]int{2, 3}, nil)
+	tagRepo.On("GetByThreadIDs", mock.Anything).Return(map[int][]string{2: {"go"}, 3: {"sql"}}, nil)
+
+	threads, err := service.GetThreadsByTags([]string{"Go", "sql"}, false, "user")
+	assert.NoError(t, err)
+	assert.Len(t, threads, 1, "тред из закрытого раздела не попадает в выдачу")
+	assert.Equal(t, 2, threads[0].ID)
+}
+
+func TestGetThreadsByTags_NoMatches(t *testing.T) {
+	threadRepo := new(mocks.MockThreadRepo)
+	tagRepo := new(mocks.MockTagRepo)
+	service := NewThreadService(threadRepo, new(mocks.MockPostRepo), new(mocks.MockUserRepo), new(mocks.MockCategoryRepo), tagRepo)
+	tagRepo.On("GetThreadIDs", []string{"rust"}, true).Return([]int(nil), nil)
+
+	threads, err := service.GetThreadsByTags([]string{"rust"}, true, "user")
+	assert.NoError(t, err)
+	assert.Empty(t, threads)
+	threadRepo.AssertNotCalled(t, "GetAllThreads")
+}
+
+func TestSetThreadLock(t *testing.T) {
+	threadRepo := new(mocks.MockThreadRepo)
+	userRepo := new(mocks.MockUserRepo)
+	service := NewThreadService(threadRepo, new(mocks.MockPostRepo), userRepo, new(mocks.MockCategoryRepo), untaggedRepo())
+
+	moderatorID := 2
+	userRepo.On("GetUserRole", moderatorID).Return("moderator", nil)
+	threadRepo.On("Lock", 1, moderatorID, "Вопрос решен").Return(nil)
+	threadRepo.On("Unlock", 1).Return(nil)
+	threadRepo.On("GetByID", 1).Return(&models.Thread{ID: 1, Locked: true, LockedByID: &moderatorID, LockReason: "Вопрос решен"}, nil).Once()
+	threadRepo.On("GetByID", 1).Return(&models.Thread{ID: 1}, nil).Once()
+
+	thread, err := service.SetThreadLock(1, true, "  Вопрос решен ", moderatorID)
+	assert.NoError(t, err)
+	assert.True(t, thread.Locked)
+	assert.Equal(t, []string{}, thread.Tags)
+
+	thread, err = service.SetThreadLock(1, false, "", moderatorID)
+	assert.NoError(t, err)
+	assert.False(t, thread.Locked)
+	threadRepo.AssertExpectations(t)
+}
+
+func TestSetThreadLock_Errors(t *testing.T) {
+	threadRepo := new(mocks.MockThreadRepo)
+	userRepo := new(mocks.MockUserRepo)
+	service := NewThreadService(threadRepo, new(mocks.MockPostRepo), userRepo, new(mocks.MockCategoryRepo), untaggedRepo())
+
+	userRepo.On("GetUserRole", 1).Return("user", nil)
+	userRepo.On("GetUserRole", 2).Return("admin", nil)
+	threadRepo.On("Lock", 42, 2, "").Return(repository.ErrThreadNotFound)
+
+	_, err := service.SetThreadLock(1, true, "", 1)
+	assert.ErrorIs(t, err, ErrNoPermission, "автор треда не может его закрыть")
+	_, err = service.SetThreadLock(1, true, strings.Repeat("я", MaxLockReasonLength+1), 2)
+	assert.ErrorIs(t, err, ErrInvalidLockReason)
+	_, err = service.SetThreadLock(42, true, "", 2)
+	assert.ErrorIs(t, err, ErrThreadNotFound)
+}
+
+func TestSetThreadPin(t *testing.T) {
+	threadRepo := new(mocks.MockThreadRepo)
+	userRepo := new(mocks.MockUserRepo)
+	service := NewThreadService(threadRepo, new(mocks.MockPostRepo), userRepo, new(mocks.MockCategoryRepo), untaggedRepo())
+
+	until := time.Now().Add(24 * time.Hour)
+	userRepo.On("GetUserRole", 2).Return("moderator", nil)
+	threadRepo.On("Pin", 1, 3, &until).Return(nil)
+	threadRepo.On("Unpin", 1).Return(nil)
+	threadRepo.On("GetByID", 1).Return(&models.Thread{ID: 1, Pinned: true, PinOrder: 3, PinnedUntil: &until}, nil).Once()
+	threadRepo.On("GetByID", 1).Return(&models.Thread{ID: 1}, nil).Once()
+
+	thread, err := service.SetThreadPin(1, true, 3, &until, 2)
+	assert.NoError(t, err)
+	assert.True(t, thread.Pinned)
+	assert.Equal(t, 3, thread.PinOrder)
+	assert.Equal(t, []string{}, thread.Tags)
+
+	thread, err = service.SetThreadPin(1, false, 0, nil, 2)
+	assert.NoError(t, err)
+	assert.False(t, thread.Pinned)
+	threadRepo.AssertExpectations(t)
+}
+
+func TestSetThreadPin_Errors(t *testing.T) {
+	threadRepo := new(mocks.MockThreadRepo)
+	userRepo := new(mocks.MockUserRepo)
+	service := NewThreadService(threadRepo, new(mocks.MockPostRepo), userRepo, new(mocks.MockCategoryRepo), untaggedRepo())
+
+	userRepo.On("GetUserRole", 1).Return("user", nil)
+	userRepo.On("GetUserRole", 2).Return("admin", nil)
+	threadRepo.On("Pin", 42, 0, (*time.Time)(nil)).Return(repository.ErrThreadNotFound)
+
+	past := time.Now().Add(-time.Minute)
+	_, err := service.SetThreadPin(1, true, 0, nil, 1)
+	assert.ErrorIs(t, err, ErrNoPermission, "автор треда не может его закрепить")
+	_, err = service.SetThreadPin(1, true, -1, nil, 2)
+	assert.ErrorIs(t, err, ErrInvalidPinOrder)
+	_, err = service.SetThreadPin(1, true, MaxPinOrder+1, nil, 2)
+	assert.ErrorIs(t, err, ErrInvalidPinOrder)
+	_, err = service.SetThreadPin(1, true, 0, &past, 2)
+	assert.ErrorIs(t, err, ErrInvalidPinExpiry)
+	_, err = service.SetThreadPin(42, true, 0, nil, 2)
+	assert.ErrorIs(t, err, ErrThreadNotFound)
+	threadRepo.AssertNumberOfCalls(t, "Pin", 1)
+}
+
+func TestSetAcceptedAnswer(t *testing.T) {
+	threadRepo := new(mocks.MockThreadRepo)
+	postRepo := new(mocks.MockPostRepo)
+	userRepo := new(mocks.MockUserRepo)
+	service := NewThreadService(threadRepo, postRepo, userRepo, new(mocks.MockCategoryRepo), untaggedRepo())
+
+	answer := 2
+	created := time.Now()
+	question := &models.Thread{ID: 1, AuthorID: 1, Type: models.ThreadTypeQuestion}
+	threadRepo.On("GetByID", 1).Return(question, nil).Once()
+	threadRepo.On("GetByID", 1).Return(&models.Thread{ID: 1, AuthorID: 1, Type: models.ThreadTypeQuestion, AcceptedPostID: &answer, Solved: true}, nil).Once()
+	threadRepo.On("GetByID", 1).Return(question, nil)
+	postRepo.On("GetByThreadID", 1).Return([]*models.Post{
+		{ID: 1, ThreadID: 1, CreatedAt: created},
+		{ID: 2, ThreadID: 1, CreatedAt: created.Add(time.Minute)},
+	}, nil)
+	threadRepo.On("SetAcceptedPost", 1, &answer).Return(nil)
+	threadRepo.On("SetAcceptedPost", 1, (*int)(nil)).Return(nil)
+	userRepo.On("GetUserRole", 2).Return("moderator", nil)
+
+	thread, err := service.SetAcceptedAnswer(1, &answer, 1)
+	assert.NoError(t, err)
+	assert.True(t, thread.Solved)
+	assert.Equal(t, &answer, thread.AcceptedPostID)
+
+	thread, err = service.SetAcceptedAnswer(1, nil, 2)
+	assert.NoError(t, err, "модератор может снять принятый ответ")
+	assert.False(t, thread.Solved)
+	threadRepo.AssertExpectations(t)
+}
+
+func TestSetAcceptedAnswer_Errors(t *testing.T) {
+	threadRepo := new(mocks.MockThreadRepo)
+	postRepo := new(mocks.MockPostRepo)
+	userRepo := new(mocks.MockUserRepo)
+	service := NewThreadService(threadRepo, postRepo, userRepo, new(mocks.MockCategoryRepo), untaggedRepo())
+
+	created := time.Now()
+	threadRepo.On("GetByID", 1).Return(&models.Thread{ID: 1, AuthorID: 1, Type: models.ThreadTypeQuestion}, nil)
+	threadRepo.On("GetByID", 2).Return(&models.Thread{ID: 2, AuthorID: 1, Type: models.ThreadTypeDiscussion}, nil)
+	threadRepo.On("GetByID", 42).Return((*models.Thread)(nil), repository.ErrThreadNotFound)
+	postRepo.On("GetByThreadID", 1).Return([]*models.Post{
+		{ID: 1, ThreadID: 1, CreatedAt: created},
+		{ID: 2, ThreadID: 1, CreatedAt: created.Add(time.Minute)},
+	}, nil)
+	userRepo.On("GetUserRole", 3).Return("user", nil)
+
+	opening, foreign, answer := 1, 7, 2
+	_, err := service.SetAcceptedAnswer(1, &answer, 3)
+	assert.ErrorIs(t, err, ErrNoPermission, "принять ответ может только автор треда или модератор")
+	_, err = service.SetAcceptedAnswer(2, &answer, 1)
+	assert.ErrorIs(t, err, ErrNotQuestion)
+	_, err = service.SetAcceptedAnswer(1, &opening, 1)
+	assert.ErrorIs(t, err, ErrInvalidAnswer, "первый пост и есть вопрос")
+	_, err = service.SetAcceptedAnswer(1, &foreign, 1)
+	assert.ErrorIs(t, err, ErrInvalidAnswer)
+	_, err = service.SetAcceptedAnswer(42, nil, 1)
+	assert.ErrorIs(t, err, ErrThreadNotFound)
+	threadRepo.AssertNotCalled(t, "SetAcceptedPost", mock.Anything, mock.Anything)
+}
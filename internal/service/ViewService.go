@@ -0,0 +1,142 @@
+package service
+
+import (
+	"ForumService/internal/repository"
+	"context"
+	"sync"
+	"time"
+
+	"github.com/Luxtington/Shared/logger"
+	"go.uber.org/zap"
+)
+
+// ViewService считает просмотры тредов и постов. Повторные просмотры одного посетителя
+// в пределах окна не учитываются, а приращения копятся в памяти и сбрасываются
+// в хранилище пачками, чтобы просмотр страницы не превращался в запись в базу.
+type ViewService interface {
+	// RecordThreadView учитывает просмотр треда посетителем viewer и сообщает, был ли он засчитан
+	RecordThreadView(threadID int, viewer string) bool
+	// RecordPostView учитывает просмотр поста посетителем viewer и сообщает, был ли он засчитан
+	RecordPostView(postID int, viewer string) bool
+	// Flush сбрасывает накопленные просмотры в хранилище. При ошибке они остаются в буфере до следующего сброса.
+	Flush() error
+	// Run сбрасывает просмотры каждые interval, пока не отменен ctx. После отмены
+	// делает последний сброс и только затем возвращается.
+	Run(ctx context.Context, interval time.Duration)
+}
+
+// viewKey - просмотр записи посетителем, thread отличает треды от постов с тем же ID
+type viewKey struct {
+	thread bool
+	id     int
+	viewer string
+}
+
+type viewService struct {
+	repo   repository.ViewRepository
+	window time.Duration
+	now    func() time.Time
+
+	mu sync.Mutex
+	// seen - время последнего засчитанного просмотра, записи старше окна удаляются при сбросе
+	seen        map[viewKey]time.Time
+	threadViews map[int]int
+	postViews   map[int]int
+}
+
+// NewViewService создает счетчик просмотров. window - окно, в котором повторные
+// просмотры одного посетителя считаются одним; 0 засчитывает каждый просмотр.
+func NewViewService(repo repository.ViewRepository, window time.Duration) ViewService {
+	return &viewService{
+		repo:        repo,
+		window:      window,
+		now:         time.Now,
+		seen:        make(map[viewKey]time.Time),
+		threadViews: make(map[int]int),
+		postViews:   make(map[int]int),
+	}
+}
+
+func (s *viewService) RecordThreadView(threadID int, viewer string) bool {
+	return s.record(viewKey{thread: true, id: threadID, viewer: viewer}, s.threadViews)
+}
+
+func (s *viewService) RecordPostView(postID int, viewer string) bool {
+	return s.record(viewKey{id: postID, viewer: viewer}, s.postViews)
+}
+
+func (s *viewService) record(key viewKey, pending map[int]int) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	if last, ok := s.seen[key]; ok && now.Sub(last) < s.window {
+		return false
+	}
+	if s.window > 0 {
+		s.seen[key] = now
+	}
+	pending[key.id]++
+	return true
+}
+
+// Flush забирает буфер под блокировкой и пишет его без нее, чтобы запись в базу
+// не задерживала учет новых просмотров
+func (s *viewService) Flush() error {
+	s.mu.Lock()
+	threadViews, postViews := s.threadViews, s.postViews
+	s.threadViews = make(map[int]int)
+	s.postViews = make(map[int]int)
+	s.pruneSeenLocked()
+	s.mu.Unlock()
+
+	if len(threadViews) == 0 && len(postViews) == 0 {
+		return nil
+	}
+	if err := s.repo.AddViews(threadViews, postViews); err != nil {
+		s.mu.Lock()
+		mergeViews(s.threadViews, threadViews)
+		mergeViews(s.postViews, postViews)
+		s.mu.Unlock()
+		return err
+	}
+	return nil
+}
+
+// pruneSeenLocked забывает посетителей, чье окно уже закончилось
+func (s *viewService) pruneSeenLocked() {
+	now := s.now()
+	for key, last := range s.seen {
+		if now.Sub(last) >= s.window {
+			delete(s.seen, key)
+		}
+	}
+}
+
+func (s *viewService) Run(ctx context.Context, interval time.Duration) {
+	log := logger.GetLogger()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			// Последний сброс идет здесь же, чтобы не пересечься со сбросом по тикеру
+			if err := s.Flush(); err != nil {
+				log.Error("Ошибка при сохранении просмотров", zap.Error(err))
+			}
+			return
+		case <-ticker.C:
+			if err := s.Flush(); err != nil {
+				log.Error("Ошибка при сохранении просмотров", zap.Error(err))
+			}
+		}
+	}
+}
+
+// mergeViews возвращает несохраненные просмотры в буфер
+func mergeViews(dst map[int]int, src map[int]int) {
+	for id, count := range src {
+		dst[id] += count
+	}
+}
@@ -0,0 +1,85 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"ForumService/internal/service/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestViewService создает счетчик просмотров с управляемыми часами
+func newTestViewService(repo *mocks.MockViewRepo, window time.Duration) (*viewService, *time.Time) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	s := NewViewService(repo, window).(*viewService)
+	s.now = func() time.Time { return now }
+	return s, &now
+}
+
+func TestViewService_Deduplication(t *testing.T) {
+	repo := new(mocks.MockViewRepo)
+	s, now := newTestViewService(repo, 30*time.Minute)
+
+	assert.True(t, s.RecordThreadView(1, "user:1"))
+	assert.False(t, s.RecordThreadView(1, "user:1"), "повторный просмотр в окне не считается")
+	assert.True(t, s.RecordThreadView(1, "user:2"))
+	assert.True(t, s.RecordThreadView(2, "user:1"))
+	assert.True(t, s.RecordPostView(1, "user:1"), "пост с тем же ID считается отдельно от треда")
+
+	*now = now.Add(30 * time.Minute)
+	assert.True(t, s.RecordThreadView(1, "user:1"), "после окна просмотр считается снова")
+
+	repo.On("AddViews", map[int]int{1: 3, 2: 1}, map[int]int{1: 1}).Return(nil).Once()
+	require.NoError(t, s.Flush())
+	repo.AssertExpectations(t)
+}
+
+func TestViewService_WithoutWindow(t *testing.T) {
+	repo := new(mocks.MockViewRepo)
+	s, _ := newTestViewService(repo, 0)
+
+	assert.True(t, s.RecordPostView(5, "ip:127.0.0.1"))
+	assert.True(t, s.RecordPostView(5, "ip:127.0.0.1"))
+	assert.Empty(t, s.seen)
+
+	repo.On("AddViews", map[int]int{}, map[int]int{5: 2}).Return(nil).Once()
+	require.NoError(t, s.Flush())
+	repo.AssertExpectations(t)
+}
+
+func TestViewService_Flush(t *testing.T) {
+	repo := new(mocks.MockViewRepo)
+	s, now := newTestViewService(repo, time.Minute)
+
+	// Пустой буфер не обращается к хранилищу
+	require.NoError(t, s.Flush())
+
+	s.RecordThreadView(1, "user:1")
+	repo.On("AddViews", map[int]int{1: 1}, map[int]int{}).Return(errors.New("db down")).Once()
+	assert.Error(t, s.Flush())
+
+	// Несохраненные просмотры возвращаются в буфер и уходят со следующим сбросом
+	s.RecordThreadView(1, "user:2")
+	repo.On("AddViews", map[int]int{1: 2}, map[int]int{}).Return(nil).Once()
+	require.NoError(t, s.Flush())
+	repo.AssertExpectations(t)
+
+	*now = now.Add(time.Minute)
+	require.NoError(t, s.Flush())
+	assert.Empty(t, s.seen, "посетители с закончившимся окном забываются")
+}
+
+func TestViewService_RunFlushesOnStop(t *testing.T) {
+	repo := new(mocks.MockViewRepo)
+	s, _ := newTestViewService(repo, time.Minute)
+	s.RecordPostView(3, "user:1")
+	repo.On("AddViews", map[int]int{}, map[int]int{3: 1}).Return(nil).Once()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	s.Run(ctx, time.Hour)
+	repo.AssertExpectations(t)
+}
@@ -0,0 +1,200 @@
+package service
+
+import (
+	"ForumService/internal/models"
+	"ForumService/internal/repository"
+	"sort"
+)
+
+// Порядки сортировки постов треда и комментариев поста
+const (
+	// SortDate - порядок по умолчанию: по времени создания
+	SortDate = "date"
+	// SortTop - сначала записи с большим рейтингом
+	SortTop = "top"
+)
+
+// VoteService принимает голоса за посты и комментарии. У пользователя один голос
+// за запись: повторный голос заменяет прежний, за свои записи голосовать нельзя.
+type VoteService interface {
+	// VotePost ставит голос value (1 - за, -1 - против) и возвращает новые итоги
+	VotePost(postID, userID, value int) (models.VoteResult, error)
+	UnvotePost(postID, userID int) (models.VoteResult, error)
+	VoteComment(commentID, userID, value int) (models.VoteResult, error)
+	UnvoteComment(commentID, userID int) (models.VoteResult, error)
+}
+
+type voteService struct {
+	repo        repository.VoteRepository
+	postRepo    repository.PostRepository
+	commentRepo repository.CommentRepository
+}
+
+func NewVoteService(repo repository.VoteRepository, postRepo repository.PostRepository, commentRepo repository.CommentRepository) VoteService {
+	return &voteService{
+		repo:        repo,
+		postRepo:    postRepo,
+		commentRepo: commentRepo,
+	}
+}
+
+func (s *voteService) VotePost(postID, userID, value int) (models.VoteResult, error) {
+	if value != 1 && value != -1 {
+		return models.VoteResult{}, ErrInvalidVote
+	}
+	post, err := s.postRepo.GetPostByID(postID)
+	if err != nil {
+		return models.VoteResult{}, translateRepoError(err)
+	}
+	if post.AuthorID == userID {
+		return models.VoteResult{}, ErrSelfVote
+	}
+	result, err := s.repo.VotePost(postID, userID, value)
+	if err != nil {
+		return models.VoteResult{}, translateRepoError(err)
+	}
+	return result, nil
+}
+
+func (s *voteService) UnvotePost(postID, userID int) (models.VoteResult, error) {
+	result, err := s.repo.UnvotePost(postID, userID)
+	if err != nil {
+		return models.VoteResult{}, translateRepoError(err)
+	}
+	return result, nil
+}
+
+// VoteComment не принимает голоса за удаленные комментарии-заглушки
+func (s *voteService) VoteComment(commentID, userID, value int) (models.VoteResult, error) {
+	if value != 1 && value != -1 {
+		return models.VoteResult{}, ErrInvalidVote
+	}
+	comment, err := s.commentRepo.GetCommentByID(commentID)
+	if err != nil {
+		return models.VoteResult{}, translateRepoError(err)
+	}
+	if comment.Deleted {
+		return models.VoteResult{}, ErrCommentDeleted
+	}
+	if comment.AuthorID == userID {
+		return models.VoteResult{}, ErrSelfVote
+	}
+	result, err := s.repo.VoteComment(commentID, userID, value)
+	if err != nil {
+		return models.VoteResult{}, translateRepoError(err)
+	}
+	return result, nil
+}
+
+func (s *voteService) UnvoteComment(commentID, userID int) (models.VoteResult, error) {
+	result, err := s.repo.UnvoteComment(commentID, userID)
+	if err != nil {
+		return models.VoteResult{}, translateRepoError(err)
+	}
+	return result, nil
+}
+
+// ParseSort проверяет порядок сортировки из запроса, пустой означает SortDate
+func ParseSort(value string) (string, error) {
+	switch value {
+	case "", SortDate:
+		return SortDate, nil
+	case SortTop:
+		return SortTop, nil
+	}
+	return "", ErrInvalidSort
+}
+
+// SortPosts упорядочивает посты треда. Порядок задается только здесь и не зависит
+// от того, в каком порядке посты вернул репозиторий: первый пост треда, принятый ответ,
+// закрепленные посты в порядке закрепления, затем остальные - по времени создания,
+// а при SortTop по убыванию рейтинга, равные по времени создания.
+func SortPosts(posts []*models.Post, order string) {
+	opening := openingPost(posts)
+	rank := func(post *models.Post) int {
+		switch {
+		case post == opening:
+			return 0
+		case post.Accepted:
+			return 1
+		case post.Pinned:
+			return 2
+		}
+		return 3
+	}
+	sort.SliceStable(posts, func(i, j int) bool {
+		a, b := posts[i], posts[j]
+		if rankA, rankB := rank(a), rank(b); rankA != rankB {
+			return rankA < rankB
+		}
+		if rank(a) == 2 && a.PinnedAt != nil && b.PinnedAt != nil && !a.PinnedAt.Equal(*b.PinnedAt) {
+			return a.PinnedAt.Before(*b.PinnedAt)
+		}
+		if rank(a) == 3 && order == SortTop && a.Score != b.Score {
+			return a.Score > b.Score
+		}
+		return createdBefore(a, b)
+	})
+}
+
+// createdBefore сравнивает посты по времени создания, одновременные - по ID
+func createdBefore(a, b *models.Post) bool {
+	if !a.CreatedAt.Equal(b.CreatedAt) {
+		return a.CreatedAt.Before(b.CreatedAt)
+	}
+	return a.ID < b.ID
+}
+
+// openingPost возвращает первый по времени создания пост треда
+func openingPost(posts []*models.Post) *models.Post {
+	var opening *models.Post
+	for _, post := range posts {
+		if opening == nil || createdBefore(post, opening) {
+			opening = post
+		}
+	}
+	return opening
+}
+
+// SortComments упорядочивает дерево комментариев. При SortTop ответы одного родителя
+// идут по убыванию рейтинга, но каждый ответ по-прежнему следует сразу за своей веткой.
+func SortComments(comments []models.Comment, order string) []models.Comment {
+	if order != SortTop || len(comments) == 0 {
+		return comments
+	}
+
+	known := make(map[int]bool, len(comments))
+	for _, comment := range comments {
+		known[comment.ID] = true
+	}
+	children := make(map[int][]int)
+	var roots []int
+	for i, comment := range comments {
+		if comment.ParentCommentID != nil && known[*comment.ParentCommentID] {
+			children[*comment.ParentCommentID] = append(children[*comment.ParentCommentID], i)
+		} else {
+			roots = append(roots, i)
+		}
+	}
+
+	byScore := func(indexes []int) {
+		sort.SliceStable(indexes, func(i, j int) bool {
+			return comments[indexes[i]].Score > comments[indexes[j]].Score
+		})
+	}
+	result := make([]models.Comment, 0, len(comments))
+	var walk func(idx int)
+	walk = func(idx int) {
+		result = append(result, comments[idx])
+		replies := children[comments[idx].ID]
+		byScore(replies)
+		for _, child := range replies {
+			walk(child)
+		}
+	}
+	byScore(roots)
+	for _, idx := range roots {
+		walk(idx)
+	}
+	return result
+}
@@ -0,0 +1,171 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"ForumService/internal/models"
+	"ForumService/internal/repository"
+	"ForumService/internal/service/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func setupVoteServiceTest() (VoteService, *mocks.MockVoteRepo, *mocks.MockPostRepo, *mocks.MockCommentRepo) {
+	voteRepo := new(mocks.MockVoteRepo)
+	postRepo := new(mocks.MockPostRepo)
+	commentRepo := new(mocks.MockCommentRepo)
+	return NewVoteService(voteRepo, postRepo, commentRepo), voteRepo, postRepo, commentRepo
+}
+
+func TestVotePost(t *testing.T) {
+	service, voteRepo, postRepo, _ := setupVoteServiceTest()
+	postRepo.On("GetPostByID", 1).Return(&models.Post{ID: 1, AuthorID: 2}, nil)
+	voteRepo.On("VotePost", 1, 3, -1).Return(models.VoteResult{Downvotes: 1, Score: -1, MyVote: -1}, nil)
+
+	result, err := service.VotePost(1, 3, -1)
+	assert.NoError(t, err)
+	assert.Equal(t, -1, result.Score)
+	voteRepo.AssertExpectations(t)
+}
+
+func TestVotePost_Errors(t *testing.T) {
+	service, voteRepo, postRepo, _ := setupVoteServiceTest()
+	postRepo.On("GetPostByID", 1).Return(&models.Post{ID: 1, AuthorID: 2}, nil)
+	postRepo.On("GetPostByID", 99).Return((*models.Post)(nil), repository.ErrPostNotFound)
+
+	_, err := service.VotePost(1, 3, 2)
+	assert.ErrorIs(t, err, ErrInvalidVote)
+	_, err = service.VotePost(1, 3, 0)
+	assert.ErrorIs(t, err, ErrInvalidVote, "отзыв голоса идет через UnvotePost")
+	_, err = service.VotePost(1, 2, 1)
+	assert.ErrorIs(t, err, ErrSelfVote)
+	_, err = service.VotePost(99, 3, 1)
+	assert.ErrorIs(t, err, ErrPostNotFound)
+	voteRepo.AssertNotCalled(t, "VotePost", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestUnvotePost(t *testing.T) {
+	service, voteRepo, _, _ := setupVoteServiceTest()
+	voteRepo.On("UnvotePost", 1, 3).Return(models.VoteResult{Upvotes: 2, Score: 2}, nil)
+	voteRepo.On("UnvotePost", 99, 3).Return(models.VoteResult{}, repository.ErrPostNotFound)
+
+	result, err := service.UnvotePost(1, 3)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, result.Score)
+	_, err = service.UnvotePost(99, 3)
+	assert.ErrorIs(t, err, ErrPostNotFound)
+}
+
+func TestVoteComment(t *testing.T) {
+	service, voteRepo, _, commentRepo := setupVoteServiceTest()
+	commentRepo.On("GetCommentByID", 5).Return(&models.Comment{ID: 5, AuthorID: 2}, nil)
+	commentRepo.On("GetCommentByID", 6).Return(&models.Comment{ID: 6, AuthorID: 2, Deleted: true}, nil)
+	voteRepo.On("VoteComment", 5, 3, 1).Return(models.VoteResult{Upvotes: 1, Score: 1, MyVote: 1}, nil)
+
+	result, err := service.VoteComment(5, 3, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, result.MyVote)
+
+	_, err = service.VoteComment(5, 2, 1)
+	assert.ErrorIs(t, err, ErrSelfVote)
+	_, err = service.VoteComment(6, 3, 1)
+	assert.ErrorIs(t, err, ErrCommentDeleted)
+	voteRepo.AssertNumberOfCalls(t, "VoteComment", 1)
+}
+
+func TestParseSort(t *testing.T) {
+	order, err := ParseSort("")
+	assert.NoError(t, err)
+	assert.Equal(t, SortDate, order)
+	order, err = ParseSort("top")
+	assert.NoError(t, err)
+	assert.Equal(t, SortTop, order)
+	_, err = ParseSort("random")
+	assert.ErrorIs(t, err, ErrInvalidSort)
+}
+
+func TestSortPosts(t *testing.T) {
+	posts := []*models.Post{
+		{ID: 1, Pinned: true, Score: -5},
+		{ID: 2, Score: 1},
+		{ID: 3, Score: 7},
+		{ID: 4, Score: 1},
+	}
+
+	SortPosts(posts, SortDate)
+	assert.Equal(t, []int{1, 2, 3, 4}, postIDs(posts))
+
+	SortPosts(posts, SortTop)
+	assert.Equal(t, []int{1, 3, 2, 4}, postIDs(posts), "закрепленные первыми, равные в прежнем порядке")
+}
+
+func TestSortPosts_AcceptedAnswer(t *testing.T) {
+	created := time.Now()
+	posts := []*models.Post{
+		{ID: 1, CreatedAt: created, Score: 0},
+		{ID: 2, CreatedAt: created.Add(time.Minute), Score: 5},
+		{ID: 3, CreatedAt: created.Add(2 * time.Minute), Score: 1, Accepted: true},
+		{ID: 4, CreatedAt: created.Add(3 * time.Minute), Score: 9},
+	}
+
+	SortPosts(posts, SortDate)
+	assert.Equal(t, []int{1, 3, 2, 4}, postIDs(posts))
+
+	SortPosts(posts, SortTop)
+	assert.Equal(t, []int{1, 3, 4, 2}, postIDs(posts), "первый пост и принятый ответ не сортируются по рейтингу")
+}
+
+func TestSortPosts_PinnedAndAccepted(t *testing.T) {
+	created := time.Now()
+	pinnedEarly, pinnedLate := created.Add(time.Hour), created.Add(2*time.Hour)
+	posts := []*models.Post{
+		// Порядок репозитория: закрепленные первыми
+		{ID: 4, CreatedAt: created.Add(3 * time.Minute), Pinned: true, PinnedAt: &pinnedEarly},
+		{ID: 2, CreatedAt: created.Add(time.Minute), Pinned: true, PinnedAt: &pinnedLate},
+		{ID: 1, CreatedAt: created},
+		{ID: 3, CreatedAt: created.Add(2 * time.Minute), Score: 1, Accepted: true},
+		{ID: 5, CreatedAt: created.Add(4 * time.Minute), Score: 2},
+		{ID: 6, CreatedAt: created.Add(5 * time.Minute), Score: 8},
+	}
+
+	SortPosts(posts, SortDate)
+	assert.Equal(t, []int{1, 3, 4, 2, 5, 6}, postIDs(posts),
+		"первый пост, принятый ответ, закрепленные в порядке закрепления, затем остальные")
+
+	SortPosts(posts, SortTop)
+	assert.Equal(t, []int{1, 3, 4, 2, 6, 5}, postIDs(posts))
+
+	// Без принятого ответа первый пост тоже остается выше закрепленных
+	posts[1].Accepted = false
+	SortPosts(posts, SortDate)
+	assert.Equal(t, []int{1, 4, 2, 3, 5, 6}, postIDs(posts))
+}
+
+func TestSortComments(t *testing.T) {
+	root := 1
+	reply := 3
+	comments := []models.Comment{
+		{ID: 1, Score: 0},
+		{ID: 3, ParentCommentID: &root, Score: 1},
+		{ID: 5, ParentCommentID: &reply, Score: 0},
+		{ID: 4, ParentCommentID: &root, Score: 4},
+		{ID: 2, Score: 2},
+	}
+
+	sorted := SortComments(comments, SortTop)
+	ids := make([]int, len(sorted))
+	for i, comment := range sorted {
+		ids[i] = comment.ID
+	}
+	assert.Equal(t, []int{2, 1, 4, 3, 5}, ids, "ответы сортируются внутри своей ветки")
+	assert.Equal(t, comments, SortComments(comments, SortDate))
+}
+
+func postIDs(posts []*models.Post) []int {
+	ids := make([]int, len(posts))
+	for i, post := range posts {
+		ids[i] = post.ID
+	}
+	return ids
+}
@@ -1,21 +1,122 @@
 package service
 
 import (
+	"ForumService/internal/repository"
 	"errors"
 )
 
 var (
-	ErrInvalidTitle        = errors.New("title must be 3-100 characters")
-	ErrInvalidContent      = errors.New("content must be 5-5000 characters")
-	ErrThreadNotFound      = errors.New("thread not found")
-	ErrPostNotFound        = errors.New("post not found")
-	ErrUnauthorized        = errors.New("unauthorized access")
-	ErrInternalServerError = errors.New("internal server error")
-	ErrEmptyContent        = errors.New("content cannot be empty")
-	ErrNoPermission        = errors.New("no permission to modify this post")
-	ErrUserNotFound        = errors.New("user not found")
+	ErrInvalidTitle         = errors.New("title must be 3-100 characters")
+	ErrInvalidContent       = errors.New("content must be 5-5000 characters")
+	ErrThreadNotFound       = errors.New("thread not found")
+	ErrPostNotFound         = errors.New("post not found")
+	ErrUnauthorized         = errors.New("unauthorized access")
+	ErrInternalServerError  = errors.New("internal server error")
+	ErrEmptyContent         = errors.New("content cannot be empty")
+	ErrNoPermission         = errors.New("no permission to modify this post")
+	ErrUserNotFound         = errors.New("user not found")
+	ErrEditWindowExpired    = errors.New("edit window has expired")
+	ErrCommentTooDeep       = errors.New("comment nesting is too deep")
+	ErrInvalidParent        = errors.New("parent comment belongs to another post")
+	ErrCommentDeleted       = errors.New("comment has been deleted")
+	ErrEmptySearchQuery     = errors.New("search query cannot be empty")
+	ErrInvalidSearchType    = errors.New("unknown search result type")
+	ErrInvalidDateRange     = errors.New("date range start must be before its end")
+	ErrCommentNotFound      = errors.New("comment not found")
+	ErrRevisionNotFound     = errors.New("revision not found")
+	ErrAlreadyExists        = errors.New("already exists")
+	ErrVersionConflict      = errors.New("resource was modified by another request")
+	ErrCategoryNotFound     = errors.New("category not found")
+	ErrCategoryNotEmpty     = errors.New("category has threads or subcategories")
+	ErrInvalidCategoryName  = errors.New("category name must be 2-100 characters")
+	ErrInvalidCategoryRole  = errors.New("unknown category role")
+	ErrCategoryCycle        = errors.New("category cannot be nested into itself")
+	ErrTagNotFound          = errors.New("tag not found")
+	ErrTagExists            = errors.New("tag with this name already exists")
+	ErrInvalidTag           = errors.New("tag must be 2-32 letters, digits or - _ + # .")
+	ErrTooManyTags          = errors.New("thread cannot have more than 5 tags")
+	ErrInvalidTagMerge      = errors.New("tag cannot be merged into itself")
+	ErrThreadLocked         = errors.New("thread is locked")
+	ErrInvalidLockReason    = errors.New("lock reason must be at most 500 characters")
+	ErrInvalidPinOrder      = errors.New("pin order must be between 0 and 1000")
+	ErrInvalidPinExpiry     = errors.New("pin expiry must be in the future")
+	ErrInvalidVote          = errors.New("vote must be 1 or -1")
+	ErrSelfVote             = errors.New("authors cannot vote on their own content")
+	ErrInvalidSort          = errors.New("unknown sort order")
+	ErrMessageNotFound      = errors.New("chat message not found")
+	ErrReactionNotAllowed   = errors.New("reaction is not in the allowed set")
+	ErrInvalidReaction      = errors.New("unknown reaction target")
+	ErrInvalidThreadType    = errors.New("thread type must be discussion or question")
+	ErrNotQuestion          = errors.New("thread is not a question")
+	ErrInvalidAnswer        = errors.New("answer must be a reply post of the same thread")
+	ErrBookmarkNotFound     = errors.New("bookmark not found")
+	ErrInvalidBookmark      = errors.New("unknown bookmark target")
+	ErrInvalidBookmarkNote  = errors.New("bookmark note must be at most 500 characters")
+	ErrSubscriptionNotFound = errors.New("subscription not found")
+	ErrInvalidSubscription  = errors.New("unknown subscription target")
+	ErrNotificationNotFound = errors.New("notification not found")
+	ErrInvalidReplyTarget   = errors.New("reply target must be a post of the same thread")
+	ErrAttachmentNotFound   = errors.New("attachment not found")
+	ErrAttachmentTooLarge   = errors.New("attachment is too large")
+	ErrAttachmentType       = errors.New("attachment type is not allowed")
+	ErrEmptyAttachment      = errors.New("attachment is empty")
+	ErrTooManyAttachments   = errors.New("too many attachments")
+	ErrPollNotFound         = errors.New("poll not found")
+	ErrPollOptionNotFound   = errors.New("poll option not found")
+	ErrPollClosed           = errors.New("poll is closed")
+	ErrPollExists           = errors.New("thread already has a poll")
+	ErrInvalidPollQuestion  = errors.New("poll question must be 1-255 characters")
+	ErrInvalidPollOptions   = errors.New("poll must have 2-10 distinct options of 1-100 characters")
+	ErrInvalidPollClose     = errors.New("poll close time must be in the future")
+	ErrInvalidPollChoice    = errors.New("single choice poll accepts one option")
 )
 
+// translateRepoError переводит ошибки репозиториев в ошибки сервисного слоя,
+// чтобы обработчики не зависели от деталей хранилища. Неизвестные ошибки возвращаются как есть.
+func translateRepoError(err error) error {
+	switch {
+	case errors.Is(err, repository.ErrThreadNotFound):
+		return ErrThreadNotFound
+	case errors.Is(err, repository.ErrPostNotFound):
+		return ErrPostNotFound
+	case errors.Is(err, repository.ErrCommentNotFound):
+		return ErrCommentNotFound
+	case errors.Is(err, repository.ErrUserNotFound):
+		return ErrUserNotFound
+	case errors.Is(err, repository.ErrRevisionNotFound):
+		return ErrRevisionNotFound
+	case errors.Is(err, repository.ErrAlreadyExists):
+		return ErrAlreadyExists
+	case errors.Is(err, repository.ErrVersionConflict):
+		return ErrVersionConflict
+	case errors.Is(err, repository.ErrCategoryNotFound):
+		return ErrCategoryNotFound
+	case errors.Is(err, repository.ErrCategoryNotEmpty):
+		return ErrCategoryNotEmpty
+	case errors.Is(err, repository.ErrTagNotFound):
+		return ErrTagNotFound
+	case errors.Is(err, repository.ErrMessageNotFound):
+		return ErrMessageNotFound
+	case errors.Is(err, repository.ErrBookmarkNotFound):
+		return ErrBookmarkNotFound
+	case errors.Is(err, repository.ErrSubscriptionNotFound):
+		return ErrSubscriptionNotFound
+	case errors.Is(err, repository.ErrNotificationNotFound):
+		return ErrNotificationNotFound
+	case errors.Is(err, repository.ErrAttachmentNotFound):
+		return ErrAttachmentNotFound
+	case errors.Is(err, repository.ErrPollNotFound):
+		return ErrPollNotFound
+	case errors.Is(err, repository.ErrPollOptionNotFound):
+		return ErrPollOptionNotFound
+	case errors.Is(err, repository.ErrPollClosed):
+		return ErrPollClosed
+	case errors.Is(err, repository.ErrThreadLocked):
+		return ErrThreadLocked
+	}
+	return err
+}
+
 //
 //func GetHTTPStatus(err error) int {
 //	switch err {
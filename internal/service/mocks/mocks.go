@@ -3,6 +3,7 @@ package mocks
 import (
 	"ForumService/internal/models"
 	"github.com/stretchr/testify/mock"
+	"time"
 )
 
 type MockThreadRepo struct{ mock.Mock }
@@ -11,6 +12,12 @@ func (m *MockThreadRepo) GetByID(id int) (*models.Thread, error) { args := m.Cal
 func (m *MockThreadRepo) Update(thread *models.Thread) error { args := m.Called(thread); return args.Error(0) }
 func (m *MockThreadRepo) Delete(id int) error { args := m.Called(id); return args.Error(0) }
 func (m *MockThreadRepo) GetAllThreads() ([]*models.Thread, error) { args := m.Called(); return args.Get(0).([]*models.Thread), args.Error(1) }
+func (m *MockThreadRepo) GetByCategoryID(categoryID int) ([]*models.Thread, error) { args := m.Called(categoryID); return args.Get(0).([]*models.Thread), args.Error(1) }
+func (m *MockThreadRepo) Lock(id int, userID int, reason string) error { args := m.Called(id, userID, reason); return args.Error(0) }
+func (m *MockThreadRepo) Unlock(id int) error { args := m.Called(id); return args.Error(0) }
+func (m *MockThreadRepo) Pin(id int, order int, until *time.Time) error { args := m.Called(id, order, until); return args.Error(0) }
+func (m *MockThreadRepo) Unpin(id int) error { args := m.Called(id); return args.Error(0) }
+func (m *MockThreadRepo) SetAcceptedPost(id int, postID *int) error { args := m.Called(id, postID); return args.Error(0) }
 func (m *MockThreadRepo) GetThreadWithPosts(threadID int) (*models.Thread, []models.Post, map[int][]models.Comment, error) { args := m.Called(threadID); return args.Get(0).(*models.Thread), args.Get(1).([]models.Post), args.Get(2).(map[int][]models.Comment), args.Error(3) }
 
 type MockPostRepo struct{ mock.Mock }
@@ -18,15 +25,25 @@ func (m *MockPostRepo) SavePost(post *models.Post) error { args := m.Called(post
 func (m *MockPostRepo) GetPostByID(id int) (*models.Post, error) { args := m.Called(id); return args.Get(0).(*models.Post), args.Error(1) }
 func (m *MockPostRepo) GetPostWithComments(postID int) (*models.Post, []models.Comment, error) { args := m.Called(postID); return args.Get(0).(*models.Post), args.Get(1).([]models.Comment), args.Error(2) }
 func (m *MockPostRepo) GetPostsWithCommentsByThreadID(threadID int) ([]models.Post, map[int][]models.Comment, error) { args := m.Called(threadID); return args.Get(0).([]models.Post), args.Get(1).(map[int][]models.Comment), args.Error(2) }
-func (m *MockPostRepo) UpdatePost(post *models.Post, postID int) error { args := m.Called(post, postID); return args.Error(0) }
+func (m *MockPostRepo) UpdatePost(post *models.Post, postID int, editorID int, reason string) error { args := m.Called(post, postID, editorID, reason); return args.Error(0) }
 func (m *MockPostRepo) DeletePost(postID int) error { args := m.Called(postID); return args.Error(0) }
 func (m *MockPostRepo) GetByThreadID(threadID int) ([]*models.Post, error) { args := m.Called(threadID); return args.Get(0).([]*models.Post), args.Error(1) }
+func (m *MockPostRepo) GetPostRevisions(postID int) ([]models.PostRevision, error) { args := m.Called(postID); return args.Get(0).([]models.PostRevision), args.Error(1) }
+func (m *MockPostRepo) GetPostRevisionByID(postID int, revisionID int) (*models.PostRevision, error) { args := m.Called(postID, revisionID); return args.Get(0).(*models.PostRevision), args.Error(1) }
+func (m *MockPostRepo) Pin(postID int) error { args := m.Called(postID); return args.Error(0) }
+func (m *MockPostRepo) Unpin(postID int) error { args := m.Called(postID); return args.Error(0) }
+func (m *MockPostRepo) GetPostRefs(ids []int) (map[int]models.PostRef, error) { args := m.Called(ids); return args.Get(0).(map[int]models.PostRef), args.Error(1) }
+func (m *MockPostRepo) GetReplies(postIDs []int) (map[int][]models.PostRef, error) { args := m.Called(postIDs); return args.Get(0).(map[int][]models.PostRef), args.Error(1) }
 
 type MockCommentRepo struct{ mock.Mock }
 func (m *MockCommentRepo) SaveComment(comment *models.Comment) error { args := m.Called(comment); return args.Error(0) }
 func (m *MockCommentRepo) GetCommentByID(id int) (*models.Comment, error) { args := m.Called(id); return args.Get(0).(*models.Comment), args.Error(1) }
+func (m *MockCommentRepo) UpdateComment(comment *models.Comment) error { args := m.Called(comment); return args.Error(0) }
 func (m *MockCommentRepo) DeleteComment(id int) error { args := m.Called(id); return args.Error(0) }
 func (m *MockCommentRepo) GetCommentsByPostID(postID int) ([]models.Comment, error) { args := m.Called(postID); return args.Get(0).([]models.Comment), args.Error(1) }
+func (m *MockCommentRepo) HasReplies(id int) (bool, error) { args := m.Called(id); return args.Bool(0), args.Error(1) }
+func (m *MockCommentRepo) TombstoneComment(id int) error { args := m.Called(id); return args.Error(0) }
+func (m *MockCommentRepo) RemoveComment(id int) (bool, error) { args := m.Called(id); return args.Bool(0), args.Error(1) }
 
 type MockUserRepo struct{ mock.Mock }
 func (m *MockUserRepo) GetUserByID(id int) (*models.User, error) { args := m.Called(id); return args.Get(0).(*models.User), args.Error(1) }
@@ -40,4 +57,75 @@ func (m *MockChatRepo) CreateMessage(authorID int, content string) (*models.Chat
 func (m *MockChatRepo) GetAllMessages() ([]*models.ChatMessage, error) { args := m.Called(); return args.Get(0).([]*models.ChatMessage), args.Error(1) }
 func (m *MockChatRepo) CleanOldMessages() error { args := m.Called(); return args.Error(0) }
 func (m *MockChatRepo) Cleanup() error { args := m.Called(); return args.Error(0) }
-func (m *MockChatRepo) DeleteOldMessages() error { args := m.Called(); return args.Error(0) } 
\ No newline at end of file
+func (m *MockChatRepo) DeleteOldMessages() error { args := m.Called(); return args.Error(0) }
+
+type MockSearchRepo struct{ mock.Mock }
+func (m *MockSearchRepo) Search(params models.SearchParams) ([]models.SearchResult, int, error) { args := m.Called(params); return args.Get(0).([]models.SearchResult), args.Int(1), args.Error(2) }
+
+type MockCategoryRepo struct{ mock.Mock }
+func (m *MockCategoryRepo) Create(category *models.Category) error { args := m.Called(category); return args.Error(0) }
+func (m *MockCategoryRepo) GetByID(id int) (*models.Category, error) { args := m.Called(id); return args.Get(0).(*models.Category), args.Error(1) }
+func (m *MockCategoryRepo) GetAll() ([]*models.Category, error) { args := m.Called(); return args.Get(0).([]*models.Category), args.Error(1) }
+func (m *MockCategoryRepo) Update(category *models.Category) error { args := m.Called(category); return args.Error(0) }
+func (m *MockCategoryRepo) Delete(id int) error { args := m.Called(id); return args.Error(0) }
+
+type MockTagRepo struct{ mock.Mock }
+func (m *MockTagRepo) GetAll() ([]*models.Tag, error) { args := m.Called(); return args.Get(0).([]*models.Tag), args.Error(1) }
+func (m *MockTagRepo) GetByID(id int) (*models.Tag, error) { args := m.Called(id); return args.Get(0).(*models.Tag), args.Error(1) }
+func (m *MockTagRepo) GetByThreadIDs(threadIDs []int) (map[int][]string, error) { args := m.Called(threadIDs); return args.Get(0).(map[int][]string), args.Error(1) }
+func (m *MockTagRepo) SetThreadTags(threadID int, names []string) error { args := m.Called(threadID, names); return args.Error(0) }
+func (m *MockTagRepo) GetThreadIDs(names []string, matchAll bool) ([]int, error) { args := m.Called(names, matchAll); return args.Get(0).([]int), args.Error(1) }
+func (m *MockTagRepo) Rename(id int, name string) error { args := m.Called(id, name); return args.Error(0) }
+func (m *MockTagRepo) Merge(sourceID, targetID int) error { args := m.Called(sourceID, targetID); return args.Error(0) }
+
+type MockViewRepo struct{ mock.Mock }
+func (m *MockViewRepo) AddViews(threadViews map[int]int, postViews map[int]int) error { args := m.Called(threadViews, postViews); return args.Error(0) }
+
+type MockVoteRepo struct{ mock.Mock }
+func (m *MockVoteRepo) VotePost(postID, userID, value int) (models.VoteResult, error) { args := m.Called(postID, userID, value); return args.Get(0).(models.VoteResult), args.Error(1) }
+func (m *MockVoteRepo) UnvotePost(postID, userID int) (models.VoteResult, error) { args := m.Called(postID, userID); return args.Get(0).(models.VoteResult), args.Error(1) }
+func (m *MockVoteRepo) VoteComment(commentID, userID, value int) (models.VoteResult, error) { args := m.Called(commentID, userID, value); return args.Get(0).(models.VoteResult), args.Error(1) }
+func (m *MockVoteRepo) UnvoteComment(commentID, userID int) (models.VoteResult, error) { args := m.Called(commentID, userID); return args.Get(0).(models.VoteResult), args.Error(1) }
+
+type MockReactionRepo struct{ mock.Mock }
+func (m *MockReactionRepo) Toggle(targetType string, targetID, userID int, emoji string) (bool, error) { args := m.Called(targetType, targetID, userID, emoji); return args.Bool(0), args.Error(1) }
+func (m *MockReactionRepo) GetCounts(targetType string, targetIDs []int, userID int) (map[int][]models.ReactionCount, error) { args := m.Called(targetType, targetIDs, userID); return args.Get(0).(map[int][]models.ReactionCount), args.Error(1) }
+
+type MockBookmarkRepo struct{ mock.Mock }
+func (m *MockBookmarkRepo) Save(bookmark *models.Bookmark) error { args := m.Called(bookmark); return args.Error(0) }
+func (m *MockBookmarkRepo) Delete(userID int, targetType string, targetID int) error { args := m.Called(userID, targetType, targetID); return args.Error(0) }
+func (m *MockBookmarkRepo) GetByUser(userID int, role models.Role, limit, offset int) ([]models.Bookmark, int, error) { args := m.Called(userID, role, limit, offset); return args.Get(0).([]models.Bookmark), args.Int(1), args.Error(2) }
+func (m *MockBookmarkRepo) GetBookmarked(userID int, targetType string, targetIDs []int) (map[int]bool, error) { args := m.Called(userID, targetType, targetIDs); return args.Get(0).(map[int]bool), args.Error(1) }
+
+type MockSubscriptionRepo struct{ mock.Mock }
+func (m *MockSubscriptionRepo) Subscribe(userID int, targetType string, targetID int) error { args := m.Called(userID, targetType, targetID); return args.Error(0) }
+func (m *MockSubscriptionRepo) Unsubscribe(userID int, targetType string, targetID int) error { args := m.Called(userID, targetType, targetID); return args.Error(0) }
+func (m *MockSubscriptionRepo) IsSubscribed(userID int, targetType string, targetID int) (bool, error) { args := m.Called(userID, targetType, targetID); return args.Bool(0), args.Error(1) }
+func (m *MockSubscriptionRepo) GetSubscribers(targetType string, targetID int) ([]int, error) { args := m.Called(targetType, targetID); return args.Get(0).([]int), args.Error(1) }
+
+type MockNotificationRepo struct{ mock.Mock }
+func (m *MockNotificationRepo) SaveAll(notifications []*models.Notification) error { args := m.Called(notifications); return args.Error(0) }
+func (m *MockNotificationRepo) GetByUser(userID int, unreadOnly bool, limit, offset int) ([]models.Notification, int, error) { args := m.Called(userID, unreadOnly, limit, offset); return args.Get(0).([]models.Notification), args.Int(1), args.Error(2) }
+func (m *MockNotificationRepo) CountUnread(userID int) (int, error) { args := m.Called(userID); return args.Int(0), args.Error(1) }
+func (m *MockNotificationRepo) MarkRead(userID, notificationID int) error { args := m.Called(userID, notificationID); return args.Error(0) }
+func (m *MockNotificationRepo) MarkAllRead(userID int) (int, error) { args := m.Called(userID); return args.Int(0), args.Error(1) }
+
+type MockMentionRepo struct{ mock.Mock }
+func (m *MockMentionRepo) ResolveUsernames(usernames []string) ([]models.Mention, error) { args := m.Called(usernames); return args.Get(0).([]models.Mention), args.Error(1) }
+func (m *MockMentionRepo) Replace(targetType string, targetID int, userIDs []int) ([]int, error) { args := m.Called(targetType, targetID, userIDs); return args.Get(0).([]int), args.Error(1) }
+func (m *MockMentionRepo) GetByTargets(targetType string, targetIDs []int) (map[int][]models.Mention, error) { args := m.Called(targetType, targetIDs); return args.Get(0).(map[int][]models.Mention), args.Error(1) }
+
+type MockAttachmentRepo struct{ mock.Mock }
+func (m *MockAttachmentRepo) Create(attachment *models.Attachment) error { args := m.Called(attachment); return args.Error(0) }
+func (m *MockAttachmentRepo) GetByID(id int) (*models.Attachment, error) { args := m.Called(id); return args.Get(0).(*models.Attachment), args.Error(1) }
+func (m *MockAttachmentRepo) Link(targetType string, targetID int, uploaderID int, ids []int) error { args := m.Called(targetType, targetID, uploaderID, ids); return args.Error(0) }
+func (m *MockAttachmentRepo) GetByTargets(targetType string, targetIDs []int) (map[int][]models.Attachment, error) { args := m.Called(targetType, targetIDs); return args.Get(0).(map[int][]models.Attachment), args.Error(1) }
+func (m *MockAttachmentRepo) DeleteOrphans(before time.Time, limit int) ([]models.Attachment, error) { args := m.Called(before, limit); return args.Get(0).([]models.Attachment), args.Error(1) }
+
+type MockPollRepo struct{ mock.Mock }
+func (m *MockPollRepo) Create(poll *models.Poll) error { args := m.Called(poll); return args.Error(0) }
+func (m *MockPollRepo) GetByID(id int) (*models.Poll, error) { args := m.Called(id); return args.Get(0).(*models.Poll), args.Error(1) }
+func (m *MockPollRepo) GetByThreadID(threadID int) (*models.Poll, error) { args := m.Called(threadID); return args.Get(0).(*models.Poll), args.Error(1) }
+func (m *MockPollRepo) Vote(pollID, userID int, optionIDs []int) error { args := m.Called(pollID, userID, optionIDs); return args.Error(0) }
+func (m *MockPollRepo) Close(pollID, userID int) error { args := m.Called(pollID, userID); return args.Error(0) }
+func (m *MockPollRepo) GetVotes(pollID int) ([]models.PollVote, error) { args := m.Called(pollID); return args.Get(0).([]models.PollVote), args.Error(1) }
@@ -20,7 +20,11 @@ func NewUserService(repo repository.UserRepository) UserService {
 }
 
 func (s *userService) GetUserByID(id int) (*models.User, error) {
-	return s.repo.GetUserByID(id)
+	user, err := s.repo.GetUserByID(id)
+	if err != nil {
+		return nil, translateRepoError(err)
+	}
+	return user, nil
 }
 
 func (s *userService) GetUserPosts(userID int) ([]*models.Post, error) {
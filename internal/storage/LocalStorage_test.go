@@ -0,0 +1,53 @@
+package storage
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLocalStorage(t *testing.T) {
+	root := t.TempDir()
+	s, err := NewLocalStorage(root)
+	require.NoError(t, err)
+
+	key, err := NewKey()
+	require.NoError(t, err)
+	require.NoError(t, s.Save(key, strings.NewReader("содержимое")))
+
+	file, err := s.Open(key)
+	require.NoError(t, err)
+	content, err := io.ReadAll(file)
+	require.NoError(t, err)
+	require.NoError(t, file.Close())
+	assert.Equal(t, "содержимое", string(content))
+
+	entries, err := os.ReadDir(filepath.Join(root, key[:2]))
+	require.NoError(t, err)
+	assert.Len(t, entries, 1, "временный файл не остается рядом с сохраненным")
+
+	require.NoError(t, s.Save(key+"-thumb", strings.NewReader("миниатюра")))
+	require.NoError(t, s.Delete(key))
+	require.NoError(t, s.Delete(key), "повторное удаление не ошибка")
+	_, err = s.Open(key)
+	assert.ErrorIs(t, err, ErrNotFound)
+	_, err = s.Open(key + "-thumb")
+	assert.NoError(t, err)
+}
+
+func TestLocalStorage_InvalidKey(t *testing.T) {
+	s, err := NewLocalStorage(t.TempDir())
+	require.NoError(t, err)
+
+	for _, key := range []string{"", "short", "../../../../etc/passwd/0123456789abcdef0123456789", strings.Repeat("A", 32)} {
+		assert.ErrorIs(t, s.Save(key, strings.NewReader("x")), ErrInvalidKey, key)
+		_, err := s.Open(key)
+		assert.ErrorIs(t, err, ErrInvalidKey, key)
+		assert.ErrorIs(t, s.Delete(key), ErrInvalidKey, key)
+	}
+}
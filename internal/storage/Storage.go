@@ -0,0 +1,48 @@
+package storage
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"io"
+)
+
+// ErrNotFound возвращается, если в хранилище нет файла с таким ключом
+var ErrNotFound = errors.New("файл не найден в хранилище")
+
+// ErrInvalidKey возвращается для ключа, который не может быть выдан NewKey
+var ErrInvalidKey = errors.New("недопустимый ключ файла")
+
+// Storage хранит загруженные файлы по ключам. Ключ выдает NewKey: имя файла
+// от пользователя в хранилище не попадает.
+type Storage interface {
+	// Save сохраняет файл под ключом key. Файл появляется в хранилище целиком или не появляется вовсе.
+	Save(key string, content io.Reader) error
+	// Open открывает файл для чтения. Файл поддерживает Seek, чтобы отдавать его по частям.
+	Open(key string) (io.ReadSeekCloser, error)
+	// Delete удаляет файл. Удаление отсутствующего файла не ошибка.
+	Delete(key string) error
+}
+
+// NewKey выдает случайный ключ для нового файла
+func NewKey() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// validKey проверяет, что ключ выдан NewKey или получен из него добавлением суффикса
+// из букв, цифр и дефиса. Так ключ не может выйти за пределы хранилища.
+func validKey(key string) bool {
+	if len(key) < 32 || len(key) > 100 {
+		return false
+	}
+	for _, r := range key {
+		if !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r == '-') {
+			return false
+		}
+	}
+	return true
+}
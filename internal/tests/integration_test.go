@@ -3,24 +3,28 @@ package tests
 import (
 	"ForumService/internal/repository"
 	"database/sql"
+	"os"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"testing"
 )
 
-func setupTest(t *testing.T) (*sql.DB, repository.UserRepository, repository.PostRepository, repository.CommentRepository, func()) {
-	db, err := sql.Open("postgres", "host=localhost port=5432 user=postgres password=postgres dbname=forum sslmode=disable")
-	require.NoError(t, err)
-
-	userRepo := repository.NewUserRepository(db)
-	postRepo := repository.NewPostRepository(db)
-	commentRepo := repository.NewCommentRepository(db)
-
-	cleanup := func() {
-		db.Close()
+// setupTest возвращает репозитории поверх базы из TEST_DATABASE_URL,
+// а если переменная не задана - поверх хранилища в памяти
+func setupTest(t *testing.T) (*repository.Repositories, repository.UserRepository, repository.PostRepository, repository.CommentRepository, func()) {
+	repos := repository.NewMemoryRepositories(repository.NewMemoryStore())
+	cleanup := func() {}
+
+	if dsn := os.Getenv("TEST_DATABASE_URL"); dsn != "" {
+		db, err := sql.Open("postgres", dsn)
+		require.NoError(t, err)
+		repos = repository.NewPostgresRepositories(db)
+		cleanup = func() {
+			db.Close()
+		}
 	}
 
-	return db, userRepo, postRepo, commentRepo, cleanup
+	return repos, repos.Users, repos.Posts, repos.Comments, cleanup
 }
 
 func TestGetNonExistingUser(t *testing.T) {
@@ -69,12 +73,11 @@ func TestGetUserCommentCountZero(t *testing.T) {
 }
 
 func TestGetNonExistingThread(t *testing.T) {
-	db, _, _, _, cleanup := setupTest(t)
+	repos, _, _, _, cleanup := setupTest(t)
 	defer cleanup()
 
-	threadRepo := repository.NewThreadRepository(db)
-	thread, err := threadRepo.GetByID(-1)
-	assert.NoError(t, err)
+	thread, err := repos.Threads.GetByID(-1)
+	assert.ErrorIs(t, err, repository.ErrThreadNotFound)
 	assert.Nil(t, thread)
 }
 
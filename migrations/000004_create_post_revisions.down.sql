@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_post_revisions_post_id;
+DROP TABLE IF EXISTS post_revisions;
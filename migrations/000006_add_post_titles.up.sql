@@ -0,0 +1 @@
+ALTER TABLE posts ADD COLUMN IF NOT EXISTS title VARCHAR(100) NOT NULL DEFAULT '';
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_comments_content_fts;
+DROP INDEX IF EXISTS idx_posts_content_fts;
+DROP INDEX IF EXISTS idx_threads_title_fts;
@@ -0,0 +1,4 @@
+-- Индексы для полнотекстового поиска. Выражения должны совпадать с используемыми в SearchRepository
+CREATE INDEX IF NOT EXISTS idx_threads_title_fts ON threads USING GIN (to_tsvector('russian', title));
+CREATE INDEX IF NOT EXISTS idx_posts_content_fts ON posts USING GIN (to_tsvector('russian', title || ' ' || content));
+CREATE INDEX IF NOT EXISTS idx_comments_content_fts ON comments USING GIN (to_tsvector('russian', content));
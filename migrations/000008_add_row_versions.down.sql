@@ -0,0 +1,2 @@
+ALTER TABLE posts DROP COLUMN IF EXISTS version;
+ALTER TABLE threads DROP COLUMN IF EXISTS version;
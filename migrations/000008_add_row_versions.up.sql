@@ -0,0 +1,3 @@
+-- Версии строк для оптимистичной блокировки: каждое изменение увеличивает version на 1
+ALTER TABLE threads ADD COLUMN IF NOT EXISTS version INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE posts ADD COLUMN IF NOT EXISTS version INTEGER NOT NULL DEFAULT 1;
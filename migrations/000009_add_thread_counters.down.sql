@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS idx_posts_thread_id_created_at;
+ALTER TABLE posts DROP COLUMN IF EXISTS comment_count;
+ALTER TABLE threads DROP COLUMN IF EXISTS last_post_author_id;
+ALTER TABLE threads DROP COLUMN IF EXISTS last_post_at;
+ALTER TABLE threads DROP COLUMN IF EXISTS participant_count;
+ALTER TABLE threads DROP COLUMN IF EXISTS comment_count;
+ALTER TABLE threads DROP COLUMN IF EXISTS post_count;
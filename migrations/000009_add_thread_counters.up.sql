@@ -0,0 +1,49 @@
+-- Денормализованные счетчики тредов и постов. Их поддерживают запросы репозиториев,
+-- меняющие посты и комментарии; удаленные комментарии-заглушки не учитываются.
+ALTER TABLE threads ADD COLUMN IF NOT EXISTS post_count INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE threads ADD COLUMN IF NOT EXISTS comment_count INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE threads ADD COLUMN IF NOT EXISTS participant_count INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE threads ADD COLUMN IF NOT EXISTS last_post_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE threads ADD COLUMN IF NOT EXISTS last_post_author_id INTEGER REFERENCES users(id) ON DELETE SET NULL;
+ALTER TABLE posts ADD COLUMN IF NOT EXISTS comment_count INTEGER NOT NULL DEFAULT 0;
+
+-- Поиск последнего поста треда при удалении постов
+CREATE INDEX IF NOT EXISTS idx_posts_thread_id_created_at ON posts(thread_id, created_at DESC);
+
+-- Заполняем счетчики для существующих данных
+UPDATE posts p SET comment_count = c.comment_count
+FROM (
+    SELECT post_id, COUNT(*) AS comment_count
+    FROM comments
+    WHERE deleted_at IS NULL
+    GROUP BY post_id
+) c
+WHERE c.post_id = p.id;
+
+UPDATE threads t SET
+    post_count = COALESCE(s.post_count, 0),
+    comment_count = COALESCE(s.comment_count, 0),
+    participant_count = (
+        SELECT COUNT(*) FROM (
+            SELECT t.author_id
+            UNION
+            SELECT author_id FROM posts WHERE thread_id = t.id
+            UNION
+            SELECT c.author_id FROM comments c JOIN posts p ON p.id = c.post_id
+            WHERE p.thread_id = t.id AND c.deleted_at IS NULL
+        ) participants
+    ),
+    last_post_at = l.created_at,
+    last_post_author_id = l.author_id
+FROM threads t2
+LEFT JOIN (
+    SELECT thread_id, COUNT(*) AS post_count, SUM(comment_count) AS comment_count
+    FROM posts
+    GROUP BY thread_id
+) s ON s.thread_id = t2.id
+LEFT JOIN (
+    SELECT DISTINCT ON (thread_id) thread_id, created_at, author_id
+    FROM posts
+    ORDER BY thread_id, created_at DESC, id DESC
+) l ON l.thread_id = t2.id
+WHERE t2.id = t.id;
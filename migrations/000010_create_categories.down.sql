@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_threads_category_id_created_at;
+ALTER TABLE threads DROP COLUMN IF EXISTS category_id;
+DROP INDEX IF EXISTS idx_categories_parent_id_position;
+DROP TABLE IF EXISTS categories;
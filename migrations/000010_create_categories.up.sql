@@ -0,0 +1,22 @@
+-- Разделы форума. Роли доступа хранятся строками, потому что кроме ролей
+-- пользователей в них допустима роль guest для неаутентифицированных посетителей.
+CREATE TABLE IF NOT EXISTS categories (
+    id SERIAL PRIMARY KEY,
+    parent_id INTEGER REFERENCES categories(id) ON DELETE RESTRICT,
+    name VARCHAR(100) NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    position INTEGER NOT NULL DEFAULT 0,
+    read_role VARCHAR(20) NOT NULL DEFAULT 'guest'
+        CHECK (read_role IN ('guest', 'user', 'moderator', 'admin')),
+    create_role VARCHAR(20) NOT NULL DEFAULT 'user'
+        CHECK (create_role IN ('user', 'moderator', 'admin')),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_categories_parent_id_position ON categories(parent_id, position);
+
+-- Раздел с тредами удалить нельзя, существующие треды остаются вне разделов
+ALTER TABLE threads ADD COLUMN IF NOT EXISTS category_id INTEGER REFERENCES categories(id) ON DELETE RESTRICT;
+
+CREATE INDEX IF NOT EXISTS idx_threads_category_id_created_at ON threads(category_id, created_at DESC);
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_thread_tags_tag_id;
+DROP TABLE IF EXISTS thread_tags;
+DROP TABLE IF EXISTS tags;
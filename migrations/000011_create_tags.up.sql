@@ -0,0 +1,14 @@
+-- Теги тредов. Названия хранятся нормализованными (в нижнем регистре), поэтому уникальны как есть.
+CREATE TABLE IF NOT EXISTS tags (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(32) NOT NULL UNIQUE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS thread_tags (
+    thread_id INTEGER NOT NULL REFERENCES threads(id) ON DELETE CASCADE,
+    tag_id INTEGER NOT NULL REFERENCES tags(id) ON DELETE CASCADE,
+    PRIMARY KEY (thread_id, tag_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_thread_tags_tag_id ON thread_tags(tag_id);
@@ -0,0 +1,3 @@
+ALTER TABLE threads DROP COLUMN IF EXISTS lock_reason;
+ALTER TABLE threads DROP COLUMN IF EXISTS locked_by;
+ALTER TABLE threads DROP COLUMN IF EXISTS locked_at;
@@ -0,0 +1,5 @@
+-- Закрытый тред: locked_at заполнено, новые посты и комментарии не принимаются.
+-- Если закрывший модератор удален, тред остается закрытым.
+ALTER TABLE threads ADD COLUMN IF NOT EXISTS locked_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE threads ADD COLUMN IF NOT EXISTS locked_by INTEGER REFERENCES users(id) ON DELETE SET NULL;
+ALTER TABLE threads ADD COLUMN IF NOT EXISTS lock_reason TEXT NOT NULL DEFAULT '';
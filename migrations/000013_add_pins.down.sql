@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_threads_pinned_at;
+ALTER TABLE posts DROP COLUMN IF EXISTS pinned_at;
+ALTER TABLE threads DROP COLUMN IF EXISTS pin_order;
+ALTER TABLE threads DROP COLUMN IF EXISTS pinned_until;
+ALTER TABLE threads DROP COLUMN IF EXISTS pinned_at;
@@ -0,0 +1,10 @@
+-- Закрепленный тред: pinned_at заполнено и pinned_until пусто или еще не наступило.
+-- Закрепленные треды идут в начале списка по возрастанию pin_order.
+ALTER TABLE threads ADD COLUMN IF NOT EXISTS pinned_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE threads ADD COLUMN IF NOT EXISTS pinned_until TIMESTAMP WITH TIME ZONE;
+ALTER TABLE threads ADD COLUMN IF NOT EXISTS pin_order INTEGER NOT NULL DEFAULT 0;
+
+-- Закрепленные посты идут в начале треда в порядке закрепления
+ALTER TABLE posts ADD COLUMN IF NOT EXISTS pinned_at TIMESTAMP WITH TIME ZONE;
+
+CREATE INDEX IF NOT EXISTS idx_threads_pinned_at ON threads(pinned_at) WHERE pinned_at IS NOT NULL;
@@ -0,0 +1,2 @@
+ALTER TABLE posts DROP COLUMN IF EXISTS view_count;
+ALTER TABLE threads DROP COLUMN IF EXISTS view_count;
@@ -0,0 +1,3 @@
+-- Просмотры копятся в памяти сервиса и сбрасываются сюда пачками
+ALTER TABLE threads ADD COLUMN IF NOT EXISTS view_count INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE posts ADD COLUMN IF NOT EXISTS view_count INTEGER NOT NULL DEFAULT 0;
@@ -0,0 +1,6 @@
+ALTER TABLE comments DROP COLUMN IF EXISTS downvotes;
+ALTER TABLE comments DROP COLUMN IF EXISTS upvotes;
+ALTER TABLE posts DROP COLUMN IF EXISTS downvotes;
+ALTER TABLE posts DROP COLUMN IF EXISTS upvotes;
+DROP TABLE IF EXISTS comment_votes;
+DROP TABLE IF EXISTS post_votes;
@@ -0,0 +1,5 @@
+DROP TRIGGER IF EXISTS chat_messages_delete_reactions ON chat_messages;
+DROP TRIGGER IF EXISTS comments_delete_reactions ON comments;
+DROP TRIGGER IF EXISTS posts_delete_reactions ON posts;
+DROP FUNCTION IF EXISTS delete_target_reactions();
+DROP TABLE IF EXISTS reactions;
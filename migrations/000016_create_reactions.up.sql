@@ -0,0 +1,31 @@
+-- Реакции-эмодзи на посты, комментарии и сообщения чата. Одна таблица на все виды
+-- записей, поэтому внешнего ключа на запись нет: реакции удаляются триггерами.
+CREATE TABLE IF NOT EXISTS reactions (
+    target_type VARCHAR(16) NOT NULL CHECK (target_type IN ('post', 'comment', 'chat')),
+    target_id INTEGER NOT NULL,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    emoji VARCHAR(32) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (target_type, target_id, user_id, emoji)
+);
+
+CREATE INDEX IF NOT EXISTS idx_reactions_target ON reactions(target_type, target_id);
+
+CREATE OR REPLACE FUNCTION delete_target_reactions() RETURNS TRIGGER AS $$
+BEGIN
+    DELETE FROM reactions WHERE target_type = TG_ARGV[0] AND target_id = OLD.id;
+    RETURN OLD;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS posts_delete_reactions ON posts;
+CREATE TRIGGER posts_delete_reactions AFTER DELETE ON posts
+    FOR EACH ROW EXECUTE FUNCTION delete_target_reactions('post');
+
+DROP TRIGGER IF EXISTS comments_delete_reactions ON comments;
+CREATE TRIGGER comments_delete_reactions AFTER DELETE ON comments
+    FOR EACH ROW EXECUTE FUNCTION delete_target_reactions('comment');
+
+DROP TRIGGER IF EXISTS chat_messages_delete_reactions ON chat_messages;
+CREATE TRIGGER chat_messages_delete_reactions AFTER DELETE ON chat_messages
+    FOR EACH ROW EXECUTE FUNCTION delete_target_reactions('chat');
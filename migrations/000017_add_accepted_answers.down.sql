@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_threads_unanswered;
+ALTER TABLE threads DROP COLUMN IF EXISTS accepted_post_id;
+ALTER TABLE threads DROP COLUMN IF EXISTS type;
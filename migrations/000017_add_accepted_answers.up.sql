@@ -0,0 +1,8 @@
+-- Тред-вопрос может иметь принятый ответ - один из постов треда.
+-- При удалении поста отметка о принятом ответе снимается.
+ALTER TABLE threads ADD COLUMN IF NOT EXISTS type VARCHAR(16) NOT NULL DEFAULT 'discussion'
+    CHECK (type IN ('discussion', 'question'));
+ALTER TABLE threads ADD COLUMN IF NOT EXISTS accepted_post_id INTEGER REFERENCES posts(id) ON DELETE SET NULL;
+
+-- Фильтр вопросов без ответа
+CREATE INDEX IF NOT EXISTS idx_threads_unanswered ON threads(id) WHERE type = 'question' AND accepted_post_id IS NULL;
@@ -0,0 +1,4 @@
+DROP TRIGGER IF EXISTS posts_delete_bookmarks ON posts;
+DROP TRIGGER IF EXISTS threads_delete_bookmarks ON threads;
+DROP FUNCTION IF EXISTS delete_target_bookmarks();
+DROP TABLE IF EXISTS bookmarks;
@@ -0,0 +1,29 @@
+-- Закладки пользователей на треды и посты с необязательной личной заметкой.
+-- Как и у reactions, внешнего ключа на запись нет: закладки удаляются триггерами
+-- вместе с тредом или постом.
+CREATE TABLE IF NOT EXISTS bookmarks (
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    target_type VARCHAR(16) NOT NULL CHECK (target_type IN ('thread', 'post')),
+    target_id INTEGER NOT NULL,
+    note TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (user_id, target_type, target_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_bookmarks_user_created ON bookmarks(user_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_bookmarks_target ON bookmarks(target_type, target_id);
+
+CREATE OR REPLACE FUNCTION delete_target_bookmarks() RETURNS TRIGGER AS $$
+BEGIN
+    DELETE FROM bookmarks WHERE target_type = TG_ARGV[0] AND target_id = OLD.id;
+    RETURN OLD;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS threads_delete_bookmarks ON threads;
+CREATE TRIGGER threads_delete_bookmarks AFTER DELETE ON threads
+    FOR EACH ROW EXECUTE FUNCTION delete_target_bookmarks('thread');
+
+DROP TRIGGER IF EXISTS posts_delete_bookmarks ON posts;
+CREATE TRIGGER posts_delete_bookmarks AFTER DELETE ON posts
+    FOR EACH ROW EXECUTE FUNCTION delete_target_bookmarks('post');
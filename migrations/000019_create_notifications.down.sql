@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS notifications;
+DROP TRIGGER IF EXISTS posts_delete_subscriptions ON posts;
+DROP TRIGGER IF EXISTS threads_delete_subscriptions ON threads;
+DROP FUNCTION IF EXISTS delete_target_subscriptions();
+DROP TABLE IF EXISTS subscriptions;
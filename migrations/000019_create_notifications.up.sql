@@ -0,0 +1,43 @@
+-- Подписки пользователей на треды и посты. Как у bookmarks, внешнего ключа на запись
+-- нет: подписки удаляются триггерами вместе с тредом или постом.
+CREATE TABLE IF NOT EXISTS subscriptions (
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    target_type VARCHAR(16) NOT NULL CHECK (target_type IN ('thread', 'post')),
+    target_id INTEGER NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (user_id, target_type, target_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_subscriptions_target ON subscriptions(target_type, target_id);
+
+CREATE OR REPLACE FUNCTION delete_target_subscriptions() RETURNS TRIGGER AS $$
+BEGIN
+    DELETE FROM subscriptions WHERE target_type = TG_ARGV[0] AND target_id = OLD.id;
+    RETURN OLD;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS threads_delete_subscriptions ON threads;
+CREATE TRIGGER threads_delete_subscriptions AFTER DELETE ON threads
+    FOR EACH ROW EXECUTE FUNCTION delete_target_subscriptions('thread');
+
+DROP TRIGGER IF EXISTS posts_delete_subscriptions ON posts;
+CREATE TRIGGER posts_delete_subscriptions AFTER DELETE ON posts
+    FOR EACH ROW EXECUTE FUNCTION delete_target_subscriptions('post');
+
+-- Уведомления о новых постах, комментариях и ответах. Уведомление удаляется вместе
+-- с записью, о которой оно сообщает.
+CREATE TABLE IF NOT EXISTS notifications (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    type VARCHAR(16) NOT NULL CHECK (type IN ('post', 'comment', 'reply')),
+    actor_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    thread_id INTEGER NOT NULL REFERENCES threads(id) ON DELETE CASCADE,
+    post_id INTEGER NOT NULL REFERENCES posts(id) ON DELETE CASCADE,
+    comment_id INTEGER REFERENCES comments(id) ON DELETE CASCADE,
+    read BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_notifications_user_created ON notifications(user_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_notifications_user_unread ON notifications(user_id) WHERE NOT read;
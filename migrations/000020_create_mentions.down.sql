@@ -0,0 +1,12 @@
+DELETE FROM notifications WHERE type = 'mention' OR thread_id IS NULL OR post_id IS NULL;
+ALTER TABLE notifications DROP CONSTRAINT IF EXISTS notifications_type_check;
+ALTER TABLE notifications ADD CONSTRAINT notifications_type_check
+    CHECK (type IN ('post', 'comment', 'reply'));
+ALTER TABLE notifications DROP COLUMN IF EXISTS chat_message_id;
+ALTER TABLE notifications ALTER COLUMN post_id SET NOT NULL;
+ALTER TABLE notifications ALTER COLUMN thread_id SET NOT NULL;
+DROP TRIGGER IF EXISTS chat_messages_delete_mentions ON chat_messages;
+DROP TRIGGER IF EXISTS comments_delete_mentions ON comments;
+DROP TRIGGER IF EXISTS posts_delete_mentions ON posts;
+DROP FUNCTION IF EXISTS delete_target_mentions();
+DROP TABLE IF EXISTS mentions;
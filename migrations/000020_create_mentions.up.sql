@@ -0,0 +1,39 @@
+-- Упоминания пользователей (@username) в постах, комментариях и сообщениях чата.
+-- Как у reactions, внешнего ключа на запись нет: упоминания удаляются триггерами.
+CREATE TABLE IF NOT EXISTS mentions (
+    target_type VARCHAR(16) NOT NULL CHECK (target_type IN ('post', 'comment', 'chat')),
+    target_id INTEGER NOT NULL,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (target_type, target_id, user_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_mentions_user ON mentions(user_id);
+
+CREATE OR REPLACE FUNCTION delete_target_mentions() RETURNS TRIGGER AS $$
+BEGIN
+    DELETE FROM mentions WHERE target_type = TG_ARGV[0] AND target_id = OLD.id;
+    RETURN OLD;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS posts_delete_mentions ON posts;
+CREATE TRIGGER posts_delete_mentions AFTER DELETE ON posts
+    FOR EACH ROW EXECUTE FUNCTION delete_target_mentions('post');
+
+DROP TRIGGER IF EXISTS comments_delete_mentions ON comments;
+CREATE TRIGGER comments_delete_mentions AFTER DELETE ON comments
+    FOR EACH ROW EXECUTE FUNCTION delete_target_mentions('comment');
+
+DROP TRIGGER IF EXISTS chat_messages_delete_mentions ON chat_messages;
+CREATE TRIGGER chat_messages_delete_mentions AFTER DELETE ON chat_messages
+    FOR EACH ROW EXECUTE FUNCTION delete_target_mentions('chat');
+
+-- Уведомление об упоминании в чате не относится ни к треду, ни к посту. Сообщения чата
+-- живут недолго, поэтому уведомление переживает сообщение и теряет только ссылку на него.
+ALTER TABLE notifications ALTER COLUMN thread_id DROP NOT NULL;
+ALTER TABLE notifications ALTER COLUMN post_id DROP NOT NULL;
+ALTER TABLE notifications ADD COLUMN IF NOT EXISTS chat_message_id INTEGER REFERENCES chat_messages(id) ON DELETE SET NULL;
+ALTER TABLE notifications DROP CONSTRAINT IF EXISTS notifications_type_check;
+ALTER TABLE notifications ADD CONSTRAINT notifications_type_check
+    CHECK (type IN ('post', 'comment', 'reply', 'mention'));
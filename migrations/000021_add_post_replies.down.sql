@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_posts_reply_to;
+ALTER TABLE posts DROP COLUMN IF EXISTS reply_to_post_id;
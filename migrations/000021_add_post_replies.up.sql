@@ -0,0 +1,6 @@
+-- Пост может быть ответом на другой пост того же треда. При удалении исходного поста
+-- ответ остается, а ссылка на исходный пост снимается.
+ALTER TABLE posts ADD COLUMN IF NOT EXISTS reply_to_post_id INTEGER REFERENCES posts(id) ON DELETE SET NULL;
+
+-- Список ответов на пост
+CREATE INDEX IF NOT EXISTS idx_posts_reply_to ON posts(reply_to_post_id) WHERE reply_to_post_id IS NOT NULL;
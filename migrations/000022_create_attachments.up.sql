@@ -0,0 +1,25 @@
+-- Файлы, загруженные к постам и комментариям. Сами файлы лежат в хранилище по storage_key,
+-- в таблице - сведения о них. Вложение загружается до публикации записи и привязывается
+-- к ней при сохранении; при удалении записи оно отвязывается и позже удаляется сборщиком
+-- вместе с файлами, как и загрузки, которые так и не привязали.
+CREATE TABLE IF NOT EXISTS attachments (
+    id SERIAL PRIMARY KEY,
+    uploader_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    post_id INTEGER REFERENCES posts(id) ON DELETE SET NULL,
+    comment_id INTEGER REFERENCES comments(id) ON DELETE SET NULL,
+    filename VARCHAR(255) NOT NULL,
+    content_type VARCHAR(100) NOT NULL,
+    size BIGINT NOT NULL CHECK (size >= 0),
+    storage_key VARCHAR(100) NOT NULL UNIQUE,
+    thumbnail_key VARCHAR(100),
+    width INTEGER NOT NULL DEFAULT 0,
+    height INTEGER NOT NULL DEFAULT 0,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CHECK (post_id IS NULL OR comment_id IS NULL)
+);
+
+CREATE INDEX IF NOT EXISTS idx_attachments_post ON attachments(post_id) WHERE post_id IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_attachments_comment ON attachments(comment_id) WHERE comment_id IS NOT NULL;
+
+-- Поиск непривязанных вложений для сборщика
+CREATE INDEX IF NOT EXISTS idx_attachments_orphans ON attachments(created_at) WHERE post_id IS NULL AND comment_id IS NULL;
@@ -0,0 +1,2 @@
+-- Ревизия хранит и заголовок поста: заголовок можно менять при правке
+ALTER TABLE post_revisions ADD COLUMN IF NOT EXISTS title VARCHAR(100) NOT NULL DEFAULT '';
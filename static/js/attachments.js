@@ -0,0 +1,92 @@
+// Вложения постов и комментариев: показ файлов и загрузка из форм. Использует escapeHtml
+// из mentions.js и getToken со страницы.
+
+function formatFileSize(size) {
+    if (size < 1024) {
+        return `${size} Б`;
+    }
+    if (size < 1024 * 1024) {
+        return `${(size / 1024).toFixed(1)} КБ`;
+    }
+    return `${(size / 1024 / 1024).toFixed(1)} МБ`;
+}
+
+// renderAttachments повторяет разметку вложений из post.html: картинки миниатюрами,
+// остальные файлы ссылками на скачивание
+function renderAttachments(attachments) {
+    if (!attachments || attachments.length === 0) {
+        return '';
+    }
+    const items = attachments.map(attachment => {
+        const name = escapeHtml(attachment.filename);
+        if (attachment.thumbnail_url) {
+            return `<a href="${attachment.url}" target="_blank" class="attachment attachment-image" title="${name}">` +
+                `<img src="${attachment.thumbnail_url}" alt="${name}" loading="lazy"></a>`;
+        }
+        return `<a href="${attachment.url}" class="attachment attachment-file" title="${name}">` +
+            `<i class="bi bi-paperclip"></i> ${name} <small class="text-muted">${formatFileSize(attachment.size)}</small></a>`;
+    });
+    return `<div class="attachments">${items.join('')}</div>`;
+}
+
+// setupAttachmentInput подключает поле выбора файлов внутри container: файлы загружаются
+// сразу после выбора, а attachmentIds(container) возвращает ID для attachment_ids
+function setupAttachmentInput(container, initial) {
+    const input = container.querySelector('.attachment-input');
+    const list = container.querySelector('.attachment-list');
+    if (!input || !list) {
+        return;
+    }
+    container.attachments = [];
+
+    const render = () => {
+        list.innerHTML = container.attachments.map((attachment, i) =>
+            `<span class="badge bg-light text-dark border attachment-chip">` +
+            `<i class="bi bi-paperclip"></i> ${escapeHtml(attachment.filename)} ` +
+            `<button type="button" class="btn-close btn-sm" data-index="${i}" aria-label="Убрать файл"></button></span>`
+        ).join('');
+    };
+    container.resetAttachments = (attachments) => {
+        container.attachments = (attachments || []).slice();
+        input.value = '';
+        render();
+    };
+    container.resetAttachments(initial);
+
+    list.addEventListener('click', (e) => {
+        const button = e.target.closest('[data-index]');
+        if (button) {
+            container.attachments.splice(parseInt(button.dataset.index), 1);
+            render();
+        }
+    });
+
+    input.addEventListener('change', async () => {
+        for (const file of input.files) {
+            const body = new FormData();
+            body.append('file', file);
+            try {
+                const response = await fetch('/api/attachments', {
+                    method: 'POST',
+                    headers: { 'Authorization': `Bearer ${getToken()}` },
+                    body: body
+                });
+                const result = await response.json();
+                if (!response.ok) {
+                    alert(`${file.name}: ${result.error || 'Ошибка при загрузке файла'}`);
+                    continue;
+                }
+                container.attachments.push(result);
+                render();
+            } catch (error) {
+                console.error('Error:', error);
+                alert(`${file.name}: Ошибка при загрузке файла`);
+            }
+        }
+        input.value = '';
+    });
+}
+
+function attachmentIds(container) {
+    return (container.attachments || []).map(attachment => attachment.id);
+}
@@ -59,6 +59,21 @@ function initWebSocket() {
                 alert(message.content);
                 return;
             }
+            if (message.type === 'reaction') {
+                applyReactionEvent(message);
+                return;
+            }
+            if (message.type === 'poll') {
+                // Итоги опросов показывает страница треда
+                return;
+            }
+            if (message.type === 'notification') {
+                // Уведомления показывает notifications.js, если он подключен на странице
+                if (window.onNotification) {
+                    window.onNotification(message.notification);
+                }
+                return;
+            }
             addMessageToChat(message);
         } catch (error) {
             console.error('Ошибка при разборе сообщения:', error);
@@ -113,17 +128,97 @@ function addMessageToChat(message) {
             <i class="bi bi-person-circle"></i> ${message.author_name || 'Аноним'} • ${formatDate(message.created_at)}
         </div>
         <div class="chat-message-content">
-            ${message.content || ''}
+            ${renderContent(message)}
         </div>
+        <div class="chat-reactions"></div>
     `;
     console.log('HTML для сообщения:', html);
     messageDiv.innerHTML = html;
+    if (message.id) {
+        messageDiv.dataset.messageId = message.id;
+        (message.reactions || []).forEach(reaction => {
+            if (reaction.reacted_by_me) {
+                myReactions.add(`${message.id}:${reaction.emoji}`);
+            }
+        });
+        renderMessageReactions(messageDiv, message.reactions || []);
+    }
     
     chatMessages.appendChild(messageDiv);
     console.log('Сообщение добавлено в чат');
     scrollChatToBottom();
 }
 
+// Реакции на сообщения чата. Сервер рассылает изменения всем клиентам без отметок
+// "моя реакция", поэтому свои реакции запоминаются здесь по ответам и событиям чата.
+let reactionEmojis = [];
+const myReactions = new Set();
+
+function loadReactionEmojis() {
+    return fetch('/api/reactions')
+        .then(response => response.ok ? response.json() : [])
+        .then(data => { reactionEmojis = data || []; })
+        .catch(error => console.error('Ошибка при загрузке реакций:', error));
+}
+
+function renderMessageReactions(messageDiv, reactions) {
+    const container = messageDiv.querySelector('.chat-reactions');
+    const messageId = messageDiv.dataset.messageId;
+    container.innerHTML = '';
+    reactions.forEach(reaction => {
+        const mine = myReactions.has(`${messageId}:${reaction.emoji}`);
+        container.appendChild(reactionButton(messageId, reaction.emoji,
+            `${reaction.emoji} ${reaction.count}`, mine ? 'btn-primary' : 'btn-outline-secondary'));
+    });
+    if (window.userId) {
+        reactionEmojis.forEach(emoji => {
+            container.appendChild(reactionButton(messageId, emoji, emoji, 'btn-link reaction-add'));
+        });
+    }
+}
+
+function reactionButton(messageId, emoji, label, style) {
+    const button = document.createElement('button');
+    button.className = `btn btn-sm ${style}`;
+    button.textContent = label;
+    button.disabled = !window.userId;
+    button.addEventListener('click', () => toggleMessageReaction(messageId, emoji));
+    return button;
+}
+
+// Ответ содержит итоги с отметками пользователя; остальные клиенты получат событие через WebSocket
+function toggleMessageReaction(messageId, emoji) {
+    fetch(`/api/chat/${messageId}/reactions`, {
+        method: 'POST',
+        headers: { 'Content-Type': 'application/json' },
+        body: JSON.stringify({ emoji: emoji })
+    })
+        .then(response => response.json().then(result => ({ ok: response.ok, result })))
+        .then(({ ok, result }) => {
+            if (!ok) {
+                alert(result.error || 'Ошибка при изменении реакции');
+                return;
+            }
+            applyReactionEvent(result);
+        })
+        .catch(error => console.error('Ошибка при изменении реакции:', error));
+}
+
+function applyReactionEvent(change) {
+    if (change.user_id === window.userId) {
+        const key = `${change.target_id}:${change.emoji}`;
+        if (change.added) {
+            myReactions.add(key);
+        } else {
+            myReactions.delete(key);
+        }
+    }
+    const messageDiv = document.querySelector(`.chat-message[data-message-id="${change.target_id}"]`);
+    if (messageDiv) {
+        renderMessageReactions(messageDiv, change.reactions || []);
+    }
+}
+
 // Функция для отправки сообщения в чат
 function sendChatMessage(event) {
     event.preventDefault();
@@ -218,7 +313,7 @@ function loadChatMessages() {
 
 // Загружаем сообщения при загрузке страницы
 document.addEventListener('DOMContentLoaded', () => {
-    loadChatMessages();
+    loadReactionEmojis().then(loadChatMessages);
     initWebSocket();
     scrollChatToBottom();
     
@@ -0,0 +1,34 @@
+// Отображение текста записей. Сервер присылает текст, уже переведенный из Markdown
+// в очищенный HTML (content_html); если его нет, текст экранируется, а известные
+// упоминания становятся ссылками на профиль.
+
+const MENTION_PATTERN = /(^|[^\p{L}\p{N}_@.])@([\p{L}\p{N}_](?:[\p{L}\p{N}_.-]*[\p{L}\p{N}_])?)/gu;
+
+function escapeHtml(text) {
+    const div = document.createElement('div');
+    div.textContent = text;
+    return div.innerHTML;
+}
+
+function renderMentions(content, mentions) {
+    const known = new Map((mentions || []).map(mention => [mention.username, mention.user_id]));
+    let html = '';
+    let last = 0;
+    for (const match of (content || '').matchAll(MENTION_PATTERN)) {
+        const start = match.index + match[1].length;
+        const username = match[2];
+        if (!known.has(username)) continue;
+        html += escapeHtml(content.slice(last, start));
+        html += `<a href="/users/${known.get(username)}" class="mention">@${escapeHtml(username)}</a>`;
+        last = start + 1 + username.length;
+    }
+    return html + escapeHtml((content || '').slice(last));
+}
+
+// renderContent возвращает HTML поста, комментария или сообщения чата
+function renderContent(item) {
+    if (item.content_html !== undefined) {
+        return item.content_html;
+    }
+    return renderMentions(item.content, item.mentions);
+}
@@ -0,0 +1,107 @@
+// Уведомления о новых постах, комментариях, ответах и упоминаниях. Новые уведомления приходят
+// через WebSocket чата (chat.js вызывает window.onNotification), список загружается по API.
+
+let unreadNotifications = 0;
+
+function renderUnreadNotifications() {
+    const badge = document.getElementById('notificationsUnread');
+    if (!badge) return;
+    badge.textContent = unreadNotifications > 99 ? '99+' : String(unreadNotifications);
+    badge.classList.toggle('d-none', unreadNotifications === 0);
+}
+
+function loadUnreadNotifications() {
+    return fetch('/api/notifications/unread-count')
+        .then(response => response.ok ? response.json() : { unread: 0 })
+        .then(data => {
+            unreadNotifications = data.unread || 0;
+            renderUnreadNotifications();
+        })
+        .catch(error => console.error('Ошибка при загрузке числа уведомлений:', error));
+}
+
+function notificationText(notification) {
+    const actor = notification.actor_name || 'Пользователь';
+    switch (notification.type) {
+        case 'post':
+            return `${actor} написал пост в треде`;
+        case 'reply':
+            return `${actor} ответил на ваш комментарий`;
+        case 'mention':
+            return `${actor} упомянул вас`;
+        default:
+            return `${actor} прокомментировал пост`;
+    }
+}
+
+function notificationItem(notification) {
+    const item = document.createElement('a');
+    // Упоминание в чате не привязано к посту: ведем на главную, где открыт чат
+    item.href = notification.post_id ? `/posts/${notification.post_id}` : '/';
+    item.className = 'dropdown-item text-wrap py-2 border-bottom' + (notification.read ? ' text-muted' : ' fw-semibold');
+    item.dataset.id = notification.id;
+
+    const text = document.createElement('div');
+    text.textContent = notificationText(notification);
+    const title = document.createElement('small');
+    title.className = 'd-block text-muted';
+    title.textContent = `${notification.title || (notification.post_id ? '' : 'Чат')} · ${formatDate(notification.created_at)}`;
+    item.appendChild(text);
+    item.appendChild(title);
+
+    item.addEventListener('click', () => {
+        if (!notification.read) {
+            // keepalive дает запросу завершиться после перехода по ссылке
+            fetch(`/api/notifications/${notification.id}/read`, { method: 'PUT', keepalive: true });
+        }
+    });
+    return item;
+}
+
+function loadNotifications() {
+    const list = document.getElementById('notificationsList');
+    return fetch('/api/notifications?limit=10')
+        .then(response => response.json().then(result => ({ ok: response.ok, result })))
+        .then(({ ok, result }) => {
+            if (!ok) {
+                list.innerHTML = `<p class="text-danger px-3 py-2 mb-0">${result.error || 'Ошибка при загрузке уведомлений'}</p>`;
+                return;
+            }
+            unreadNotifications = result.unread || 0;
+            renderUnreadNotifications();
+            list.innerHTML = '';
+            if (!result.notifications || result.notifications.length === 0) {
+                list.innerHTML = '<p class="text-muted text-center px-3 py-3 mb-0">Уведомлений пока нет</p>';
+                return;
+            }
+            result.notifications.forEach(notification => list.appendChild(notificationItem(notification)));
+        })
+        .catch(error => console.error('Ошибка при загрузке уведомлений:', error));
+}
+
+function markAllNotificationsRead() {
+    fetch('/api/notifications/read-all', { method: 'PUT' })
+        .then(response => {
+            if (response.ok) {
+                loadNotifications();
+            }
+        })
+        .catch(error => console.error('Ошибка при отметке уведомлений:', error));
+}
+
+window.onNotification = function(notification) {
+    unreadNotifications++;
+    renderUnreadNotifications();
+    const list = document.getElementById('notificationsList');
+    if (list && list.children.length > 0) {
+        list.prepend(notificationItem(notification));
+    }
+};
+
+document.addEventListener('DOMContentLoaded', () => {
+    const dropdown = document.getElementById('notifications');
+    if (!dropdown) return;
+    loadUnreadNotifications();
+    dropdown.addEventListener('show.bs.dropdown', loadNotifications);
+    document.getElementById('notificationsReadAll').addEventListener('click', markAllNotificationsRead);
+});
@@ -0,0 +1,139 @@
+// Опрос треда: итоги, голосование и закрытие. Итоги обновляются сообщениями "poll"
+// из WebSocket. Использует escapeHtml из mentions.js и getToken со страницы.
+
+function formatPollDate(value) {
+    return new Date(value).toLocaleString('ru-RU', {
+        day: '2-digit', month: '2-digit', year: 'numeric', hour: '2-digit', minute: '2-digit'
+    });
+}
+
+// renderPoll рисует опрос в container. Пока опрос открыт, вошедший пользователь видит
+// варианты для выбора; итоги видны всем
+function renderPoll(container, poll) {
+    container.poll = poll;
+    const myVotes = poll.my_votes || [];
+    const canVote = Boolean(window.userId) && !poll.closed;
+    const inputType = poll.multiple_choice ? 'checkbox' : 'radio';
+
+    const options = poll.options.map(option => {
+        const percent = poll.total_voters ? Math.round(option.votes * 100 / poll.total_voters) : 0;
+        const mine = myVotes.includes(option.id);
+        const voters = (option.voters || []).map(voter => escapeHtml(voter.username)).join(', ');
+        const label = canVote
+            ? `<label class="form-check-label"><input class="form-check-input me-1" type="${inputType}" name="poll-${poll.id}" value="${option.id}"${mine ? ' checked' : ''}> ${escapeHtml(option.text)}</label>`
+            : `<span>${escapeHtml(option.text)}${mine ? ' <i class="bi bi-check-circle-fill text-success" title="Ваш голос"></i>' : ''}</span>`;
+        return `<div class="poll-option">
+            <div class="d-flex justify-content-between">${label}<small class="text-muted">${option.votes} (${percent}%)</small></div>
+            <div class="progress poll-bar"><div class="progress-bar${mine ? ' bg-success' : ''}" style="width: ${percent}%"></div></div>
+            ${voters ? `<small class="text-muted">${voters}</small>` : ''}
+        </div>`;
+    }).join('');
+
+    let status = `Проголосовали: ${poll.total_voters}`;
+    if (poll.anonymous) {
+        status += ' · анонимный опрос';
+    }
+    if (poll.closed) {
+        status += ' · опрос закрыт';
+    } else if (poll.closes_at) {
+        status += ` · закроется ${formatPollDate(poll.closes_at)}`;
+    }
+
+    const buttons = [];
+    if (canVote) {
+        buttons.push('<button type="button" class="btn btn-sm btn-primary poll-vote">Голосовать</button>');
+        if (myVotes.length) {
+            buttons.push('<button type="button" class="btn btn-sm btn-outline-secondary poll-retract">Отозвать голос</button>');
+        }
+    }
+    if (container.dataset.canClose === 'true' && !poll.closed) {
+        buttons.push('<button type="button" class="btn btn-sm btn-outline-danger poll-close">Закрыть опрос</button>');
+    }
+
+    container.innerHTML = `<div class="card thread-poll mb-3"><div class="card-body">
+        <h5 class="card-title"><i class="bi bi-bar-chart"></i> ${escapeHtml(poll.question)}</h5>
+        ${options}
+        <small class="text-muted d-block mt-2">${status}</small>
+        ${buttons.length ? `<div class="mt-2 d-flex gap-2">${buttons.join('')}</div>` : ''}
+    </div></div>`;
+}
+
+async function sendPollRequest(container, url, method, body, fallback) {
+    try {
+        const response = await fetch(url, {
+            method: method,
+            headers: {
+                'Content-Type': 'application/json',
+                'Authorization': `Bearer ${getToken()}`
+            },
+            body: body ? JSON.stringify(body) : undefined
+        });
+        const result = await response.json().catch(() => ({}));
+        if (!response.ok) {
+            alert(result.error || fallback);
+            return;
+        }
+        renderPoll(container, result);
+    } catch (error) {
+        console.error('Error:', error);
+        alert(fallback);
+    }
+}
+
+// applyPollEvent обновляет итоги из сообщения Hub. В сообщении нет голосов получателя,
+// поэтому свои голоса берутся из текущего состояния
+function applyPollEvent(container, poll) {
+    if (!container.poll || container.poll.id !== poll.id) {
+        return;
+    }
+    poll.my_votes = container.poll.my_votes;
+    renderPoll(container, poll);
+}
+
+function connectPollUpdates(container) {
+    const protocol = window.location.protocol === 'https:' ? 'wss:' : 'ws:';
+    const ws = new WebSocket(`${protocol}//${window.location.host}/ws`);
+    ws.onmessage = function(event) {
+        try {
+            const message = JSON.parse(event.data);
+            if (message.type === 'poll') {
+                applyPollEvent(container, message.poll);
+            }
+        } catch (error) {
+            console.error('Ошибка при разборе сообщения:', error);
+        }
+    };
+    ws.onclose = function() {
+        setTimeout(() => connectPollUpdates(container), 5000);
+    };
+}
+
+// initPoll показывает опрос и подключает кнопки. Живые итоги приходят только
+// вошедшим пользователям: WebSocket требует аутентификации
+function initPoll(container, poll) {
+    if (!container || !poll) {
+        return;
+    }
+    renderPoll(container, poll);
+
+    container.addEventListener('click', function(e) {
+        const current = container.poll;
+        if (e.target.closest('.poll-vote')) {
+            const optionIds = Array.from(container.querySelectorAll('.form-check-input:checked'))
+                .map(input => parseInt(input.value, 10));
+            if (optionIds.length === 0) {
+                alert('Выберите хотя бы один вариант');
+                return;
+            }
+            sendPollRequest(container, `/api/polls/${current.id}/vote`, 'POST', { option_ids: optionIds }, 'Ошибка при голосовании');
+        } else if (e.target.closest('.poll-retract')) {
+            sendPollRequest(container, `/api/polls/${current.id}/vote`, 'DELETE', null, 'Ошибка при отзыве голоса');
+        } else if (e.target.closest('.poll-close') && confirm('Закрыть опрос? Голосование будет завершено.')) {
+            sendPollRequest(container, `/api/polls/${current.id}/close`, 'POST', null, 'Ошибка при закрытии опроса');
+        }
+    });
+
+    if (window.userId) {
+        connectPollUpdates(container);
+    }
+}
@@ -0,0 +1,47 @@
+// Цитаты постов [quote=ID]текст[/quote] и ссылки между постами. Если сервер не прислал
+// готовый HTML поста, повторяет handlers.RenderPost без Markdown; использует mentions.js.
+
+const QUOTE_PATTERN = /\[quote=(\d{1,9})\]([\s\S]*?)\[\/quote\]/g;
+
+function quoteHeader(id, quote) {
+    if (quote && quote.deleted) {
+        return `<blockquote class="post-quote post-quote-deleted"><div class="post-quote-source">Цитата из удаленного поста #${id}</div>`;
+    }
+    const source = quote && quote.author_name
+        ? `${escapeHtml(quote.author_name)} в посте #${id}`
+        : `Пост #${id}`;
+    return `<blockquote class="post-quote"><div class="post-quote-source"><a href="/posts/${id}">${source}</a></div>`;
+}
+
+function renderPostContent(post) {
+    if (post.content_html !== undefined) {
+        return post.content_html;
+    }
+    const content = post.content || '';
+    const quotes = new Map((post.quotes || []).map(quote => [quote.id, quote]));
+    let html = '';
+    let last = 0;
+    for (const match of content.matchAll(QUOTE_PATTERN)) {
+        const id = parseInt(match[1]);
+        html += renderMentions(content.slice(last, match.index), post.mentions);
+        html += quoteHeader(id, quotes.get(id));
+        html += renderMentions(match[2].trim(), post.mentions) + '</blockquote>';
+        last = match.index + match[0].length;
+    }
+    return html + renderMentions(content.slice(last), post.mentions);
+}
+
+// quoteText возвращает цитату поста для вставки в новый пост. Цитаты внутри поста
+// отбрасываются: вложенные цитаты не поддерживаются.
+function quoteText(post) {
+    const text = (post.content || '').replace(QUOTE_PATTERN, '').trim();
+    return `[quote=${post.id}]${text}[/quote]\n`;
+}
+
+function postRefLink(ref) {
+    if (ref.deleted) {
+        return `удаленный пост #${ref.id}`;
+    }
+    const author = ref.author_name ? ` ${escapeHtml(ref.author_name)}` : '';
+    return `<a href="/posts/${ref.id}">#${ref.id}${author}</a>`;
+}
@@ -60,9 +60,11 @@ function addThreadToList(thread) {
         return;
     }
 
-    const threadList = document.querySelector('.thread-list');
+    // Тред попадает в список своего раздела; разделы, скрытые от пользователя, на странице не выводятся
+    const categoryId = thread.category_id ? String(thread.category_id) : '';
+    const threadList = document.querySelector(`.thread-list[data-category-id="${categoryId}"]`);
     if (!threadList) {
-        console.error('Элемент .thread-list не найден');
+        console.log('Раздел треда не выводится на странице:', thread);
         return;
     }
 
@@ -77,7 +79,7 @@ function addThreadToList(thread) {
         <div class="card-body">
             <div class="d-flex justify-content-between align-items-center">
                 <h5 class="card-title mb-0">
-                    <a href="/threads/${thread.id}" class="text-decoration-none">${thread.title || ''}</a>
+                    ${thread.pinned ? '<i class="bi bi-pin-angle-fill text-primary" title="Тред закреплен"></i> ' : ''}${thread.locked ? '<i class="bi bi-lock-fill text-warning" title="Тред закрыт"></i> ' : ''}<a href="/threads/${thread.id}" class="text-decoration-none">${thread.title || ''}</a>${thread.type === 'question' ? ' <span class="badge bg-secondary">Вопрос</span>' : ''}
                 </h5>
                 ${(thread.author_id === window.userId || window.userRole === "admin") ? `
                 <div class="btn-group">
@@ -93,8 +95,18 @@ function addThreadToList(thread) {
             <p class="card-text text-muted mt-2">
                 <small>
                     <i class="bi bi-clock"></i> ${formatDate(thread.created_at)}
+                    <i class="bi bi-chat-left-text ms-2"></i> ${thread.post_count || 0}
+                    <i class="bi bi-chat-dots ms-2"></i> ${thread.comment_count || 0}
+                    <i class="bi bi-people ms-2"></i> ${thread.participant_count || 1}
+                    <i class="bi bi-eye ms-2" title="Просмотры"></i> ${thread.view_count || 0}
+                    ${thread.last_post_at ? `<span class="ms-2">последний пост ${formatDate(thread.last_post_at)}${thread.last_post_author_name ? ', ' + thread.last_post_author_name : ''}</span>` : ''}
                 </small>
             </p>
+            ${(thread.tags || []).length ? `
+            <div class="thread-tags">
+                ${thread.tags.map(tag => `<a href="/threads?tag=${encodeURIComponent(tag)}" class="badge bg-light text-secondary text-decoration-none me-1">#${tag}</a>`).join('')}
+            </div>
+            ` : ''}
         </div>
     `;
     console.log('HTML для треда:', html);
@@ -102,6 +114,24 @@ function addThreadToList(thread) {
     threadList.appendChild(threadCard);
 }
 
+// Сообщение для раздела без тредов
+function showEmptyThreadList(threadList) {
+    if (threadList.dataset.categoryId) {
+        threadList.innerHTML = `
+            <div class="text-center text-muted py-3">
+                <p class="mb-0">В разделе пока нет тредов</p>
+            </div>
+        `;
+        return;
+    }
+    threadList.innerHTML = `
+        <div class="text-center text-muted py-5">
+            <i class="bi bi-chat-square-text display-1"></i>
+            <p class="mt-3">Пока нет тредов. Создайте первый!</p>
+        </div>
+    `;
+}
+
 // Обработчик формы редактирования
 document.getElementById('editThreadForm').addEventListener('submit', function(e) {
     e.preventDefault();
@@ -130,32 +160,67 @@ document.getElementById('editThreadForm').addEventListener('submit', function(e)
     });
 });
 
+// Поля опроса показываются только при включенном флажке
+document.getElementById('threadPollEnabled').addEventListener('change', function() {
+    document.getElementById('threadPollFields').style.display = this.checked ? 'block' : 'none';
+});
+
+// Опрос из формы создания треда, null если опрос не нужен
+function threadPollFromForm() {
+    if (!document.getElementById('threadPollEnabled').checked) {
+        return null;
+    }
+    const closesAt = document.getElementById('threadPollClosesAt').value;
+    return {
+        question: document.getElementById('threadPollQuestion').value,
+        options: document.getElementById('threadPollOptions').value
+            .split('\n')
+            .map(option => option.trim())
+            .filter(option => option),
+        multiple_choice: document.getElementById('threadPollMultiple').checked,
+        anonymous: document.getElementById('threadPollAnonymous').checked,
+        closes_at: closesAt ? new Date(closesAt).toISOString() : null
+    };
+}
+
 // Обработчик формы создания треда
 document.getElementById('createThreadForm').addEventListener('submit', function(e) {
     e.preventDefault();
     
     const title = document.getElementById('threadTitle').value;
-    console.log('Отправка запроса на создание треда:', title);
+    const type = document.getElementById('threadType').value;
+    const categorySelect = document.getElementById('threadCategory');
+    const categoryId = categorySelect && categorySelect.value ? parseInt(categorySelect.value, 10) : null;
+    const tags = document.getElementById('threadTags').value
+        .split(',')
+        .map(tag => tag.trim())
+        .filter(tag => tag);
+    console.log('Отправка запроса на создание треда:', title, categoryId, tags);
     
     fetch('/api/threads', {
         method: 'POST',
         headers: {
             'Content-Type': 'application/json',
         },
-        body: JSON.stringify({ title: title })
+        body: JSON.stringify({ title: title, type: type, category_id: categoryId, tags: tags, poll: threadPollFromForm() })
     })
     .then(response => {
         console.log('Получен ответ:', response.status);
         if (response.ok) {
             return response.json();
-        } else {
-            throw new Error('Ошибка при создании треда');
         }
+        return response.json().catch(() => ({})).then(error => {
+            throw new Error(error.error || 'Ошибка при создании треда');
+        });
     })
     .then(thread => {
         console.log('Получен тред:', thread);
         addThreadToList(thread);
         document.getElementById('threadTitle').value = '';
+        document.getElementById('threadTags').value = '';
+        ['threadPollQuestion', 'threadPollOptions', 'threadPollClosesAt'].forEach(id => {
+            document.getElementById(id).value = '';
+        });
         bootstrap.Modal.getInstance(document.getElementById('createThreadModal')).hide();
     })
     .catch(error => {
@@ -176,23 +241,19 @@ function loadThreads() {
         })
         .then(data => {
             console.log('Получены треды:', data);
-            const threadList = document.querySelector('.thread-list');
-            if (!threadList) {
+            const threadLists = document.querySelectorAll('.thread-list');
+            if (threadLists.length === 0) {
                 console.error('Элемент .thread-list не найден');
                 return;
             }
             
-            threadList.innerHTML = '';
-            if (!data || data.length === 0) {
-                threadList.innerHTML = `
-                    <div class="text-center text-muted py-5">
-                        <i class="bi bi-chat-square-text display-1"></i>
-                        <p class="mt-3">Пока нет тредов. Создайте первый!</p>
-                    </div>
-                `;
-            } else {
-                data.forEach(thread => addThreadToList(thread));
-            }
+            threadLists.forEach(threadList => threadList.innerHTML = '');
+            (data || []).forEach(thread => addThreadToList(thread));
+            threadLists.forEach(threadList => {
+                if (!threadList.children.length) {
+                    showEmptyThreadList(threadList);
+                }
+            });
         })
         .catch(error => {
             console.error('Ошибка при загрузке тредов:', error);